@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"cloud-notes/internal/events"
+	"cloud-notes/internal/storage"
+)
+
+// tailEvents prints the events of the Redis stream as a member of the
+// consumer group until interrupted. A new group starts at the oldest event
+// still in the stream, an existing one where it stopped.
+func tailEvents(ctx context.Context, a *app, args []string) error {
+	hostname, _ := os.Hostname()
+
+	fs := flag.NewFlagSet("tail-events", flag.ContinueOnError)
+	group := fs.String("group", "admin", "consumer group")
+	consumer := fs.String("consumer", hostname, "consumer name")
+	if err := parse(fs, args, group, consumer); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	bus := events.New(a.log, a.st, &a.cfg.Events)
+	bus.Consume(ctx, *group, *consumer,
+		func(ctx context.Context, event *storage.OutboxEvent) error {
+			fmt.Printf("%s %s %s user=%s %s\n",
+				event.CreatedAt.Format(time.RFC3339), event.Type, event.ID,
+				event.UserID, event.Payload)
+			return nil
+		})
+
+	return nil
+}
+
+// retryEvents returns the events that failed EVENTS_MAX_ATTEMPTS times to
+// dispatch, with their attempts reset.
+func retryEvents(ctx context.Context, a *app, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	count, err := a.st.Outbox().Retry(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d events returned to dispatch\n", count)
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"cloud-notes/internal/quota"
+	"cloud-notes/internal/security"
+	userService "cloud-notes/internal/services/user"
+)
+
+// runJob runs one of the jobs the worker schedules, for when it has to be
+// done right away or the worker is stuck.
+func runJob(ctx context.Context, a *app, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	sec := security.MustNew(a.log, a.st, &a.cfg.JWT)
+	qt := quota.New(a.log, a.st, &a.cfg.Quota)
+	userSrv := userService.New(a.log, a.st, sec, a.au, qt, a.cfg)
+
+	jobs := map[string]func(ctx context.Context) error{
+		"purge-deleted-users":   userSrv.PurgeDeletedUsers,
+		"purge-expired-exports": userSrv.PurgeExpiredExports,
+	}
+
+	job, ok := jobs[args[0]]
+	if !ok {
+		return errUsage
+	}
+
+	err := job(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s done\n", args[0])
+	return nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"cloud-notes/internal/audit"
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/password"
+	"cloud-notes/internal/storage"
+)
+
+// app holds what the commands share. It is built from the same environment
+// as the server, so the tool always talks to the server's databases.
+type app struct {
+	log logger.Logger
+	cfg *config.Config
+	st  storage.Storage
+	pw  password.Hasher
+	pp  password.Policy
+	au  audit.Auditor
+}
+
+type command struct {
+	name  string
+	args  string
+	about string
+	run   func(ctx context.Context, a *app, args []string) error
+}
+
+var errUsage = errors.New("invalid usage")
+
+var commands = []*command{
+	{"create-user", "-login L [-email E] -first-name N [-timezone TZ] " +
+		"[-role R] [-password-stdin]", "create an active user", createUser},
+	{"reset-password", "-login L [-password-stdin]",
+		"set a new password and end all sessions", resetPassword},
+	{"set-role", "-login L -role R", "change the user's role", setRole},
+	{"block", "-login L -reason R [-expires RFC3339]",
+		"block the user and end all sessions", blockUser},
+	{"unblock", "-login L", "unblock the user", unblockUser},
+	{"sessions", "-login L", "list the user's sessions", listSessions},
+	{"revoke-sessions", "-login L [-session ID]",
+		"end one or all sessions of the user", revokeSessions},
+	{"notes-count", "-login L", "count the user's notes", notesCount},
+	{"recalculate-usage", "-login L",
+		"count the user's quota usage again", recalculateUsage},
+	{"tail-events", "[-group G] [-consumer C]",
+		"print events as a consumer group member", tailEvents},
+	{"retry-events", "", "dispatch failed events again", retryEvents},
+	{"run-job", "purge-deleted-users|purge-expired-exports",
+		"run a maintenance job once", runJob},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var cmd *command
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			cmd = c
+		}
+	}
+
+	if cmd == nil {
+		usage()
+		os.Exit(2)
+	}
+
+	// There is no request and no caller, so the audit trail says where
+	// the changes came from.
+	ctx := audit.SetSource(context.Background(), "cli")
+	cfg := config.MustLoad()
+	log := logger.MustLoad(&cfg.Logger)
+	if cfg.Storage == config.StorageMemory {
+		fmt.Fprintln(os.Stderr,
+			"admin: STORAGE=memory lives inside the server, nothing to manage")
+		os.Exit(1)
+	}
+
+	pg := postgres.MustConnect(ctx, &cfg.Postgres)
+	rd := redis.MustConnect(ctx, &cfg.Redis)
+	st := storage.New(log, pg, rd)
+
+	a := &app{
+		log: log,
+		cfg: cfg,
+		st:  st,
+		pw:  password.MustNew(&cfg.PasswordHash),
+		pp:  password.NewPolicy(&cfg.PasswordPolicy, &cfg.PasswordHash),
+		au:  audit.New(log, st),
+	}
+
+	err := cmd.run(ctx, a, os.Args[2:])
+	switch {
+	case err == nil:
+	case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+		fmt.Fprintf(os.Stderr, "usage: admin %s %s\n", cmd.name, cmd.args)
+		os.Exit(2)
+	default:
+		fmt.Fprintf(os.Stderr, "admin %s: %v\n", cmd.name, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	var b strings.Builder
+	b.WriteString("usage: admin <command> [flags]\n\ncommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "  %-16s %s\n", c.name, c.about)
+	}
+	fmt.Fprint(os.Stderr, b.String())
+}
+
+// parse parses the command's flags and fails with errUsage when one of the
+// required string flags is empty.
+func parse(fs *flag.FlagSet, args []string, required ...*string) error {
+	fs.SetOutput(os.Stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return errUsage
+	}
+
+	for _, value := range required {
+		if *value == "" {
+			return errUsage
+		}
+	}
+
+	return nil
+}
+
+func (a *app) userByLogin(
+	ctx context.Context, login string) (*storage.User, error) {
+	user, err := a.st.Users().GetByLogin(ctx, login)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, fmt.Errorf("user %q not found", login)
+	}
+
+	return user, nil
+}
+
+// record adds an event to the audit trail for the changes the commands
+// make on their own.
+func (a *app) record(ctx context.Context, eventType string,
+	user *storage.User, details map[string]string) {
+	a.au.Record(ctx, &audit.Event{
+		Type:    eventType,
+		UserID:  &user.ID,
+		Details: details,
+	})
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"cloud-notes/internal/audit"
+
+	"github.com/google/uuid"
+)
+
+func listSessions(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("sessions", flag.ContinueOnError)
+	login := fs.String("login", "", "login (email)")
+	if err := parse(fs, args, login); err != nil {
+		return err
+	}
+
+	user, err := a.userByLogin(ctx, *login)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := a.st.Sessions().GetByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCREATED\tEXPIRES\tKIND\tUSER AGENT")
+	for _, session := range sessions {
+		kind := "login"
+		switch {
+		case session.ClientID != nil:
+			kind = "oauth " + session.ClientID.String()
+		case session.ImpersonatorID != nil:
+			kind = "impersonation " + session.ImpersonatorID.String()
+		}
+
+		expires := "-"
+		if session.ExpiresAt != nil {
+			expires = session.ExpiresAt.Format(time.RFC3339)
+		}
+
+		userAgent := "-"
+		if session.UserAgent != nil {
+			userAgent = *session.UserAgent
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", session.ID,
+			session.CreatedAt.Format(time.RFC3339), expires, kind, userAgent)
+	}
+
+	return w.Flush()
+}
+
+func revokeSessions(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("revoke-sessions", flag.ContinueOnError)
+	login := fs.String("login", "", "login (email)")
+	sessionID := fs.String("session", "",
+		"session to end (default all sessions)")
+	if err := parse(fs, args, login); err != nil {
+		return err
+	}
+
+	user, err := a.userByLogin(ctx, *login)
+	if err != nil {
+		return err
+	}
+
+	if *sessionID == "" {
+		err = a.st.Sessions().DeleteByUserID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		a.record(ctx, audit.EventForcedLogout, user, nil)
+
+		fmt.Printf("all sessions of %s ended\n", user.Login)
+		return nil
+	}
+
+	id, err := uuid.Parse(*sessionID)
+	if err != nil {
+		return fmt.Errorf("invalid session id %q", *sessionID)
+	}
+
+	session, err := a.st.Sessions().GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if session == nil || session.UserID != user.ID {
+		return fmt.Errorf("session %s of %q not found", id, user.Login)
+	}
+
+	err = a.st.Sessions().Delete(ctx, session.ID)
+	if err != nil {
+		return err
+	}
+
+	a.record(ctx, audit.EventSessionRevoked, user, map[string]string{
+		"session_id": session.ID.String(),
+	})
+
+	fmt.Printf("session %s ended\n", session.ID)
+	return nil
+}
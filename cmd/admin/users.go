@@ -0,0 +1,419 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"cloud-notes/internal/audit"
+	"cloud-notes/internal/events"
+	"cloud-notes/internal/password"
+	"cloud-notes/internal/quota"
+	"cloud-notes/internal/security"
+	adminService "cloud-notes/internal/services/admin"
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const generatedPasswordLength = 20
+
+// passwordClasses are the character classes the password policy may
+// require. Generated passwords contain each of them.
+var passwordClasses = []string{
+	"abcdefghijkmnopqrstuvwxyz",
+	"ABCDEFGHJKLMNPQRSTUVWXYZ",
+	"23456789",
+	"!#%+-=?@_",
+}
+
+func createUser(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("create-user", flag.ContinueOnError)
+	login := fs.String("login", "", "login")
+	email := fs.String("email", "",
+		"email for the mail, the login if it is an address")
+	firstName := fs.String("first-name", "", "first name")
+	timezone := fs.String("timezone", "UTC", "IANA timezone")
+	role := fs.String("role", string(storage.UserRoleUser), "role")
+	fromStdin := fs.Bool("password-stdin", false,
+		"read the password from stdin instead of generating one")
+	if err := parse(fs, args, login, firstName); err != nil {
+		return err
+	}
+
+	if _, err := time.LoadLocation(*timezone); err != nil {
+		return fmt.Errorf("invalid timezone %q", *timezone)
+	}
+
+	existing, err := a.st.Users().GetByLogin(ctx, *login)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		return fmt.Errorf("user %q already exists", *login)
+	}
+
+	if *email == "" && strings.Contains(*login, "@") {
+		*email = *login
+	}
+
+	var userEmail *string
+	if *email != "" {
+		value := strings.ToLower(*email)
+		userEmail = &value
+
+		existing, err = a.st.Users().GetByEmail(ctx, value)
+		if err != nil {
+			return err
+		}
+
+		if existing != nil {
+			return fmt.Errorf("email %q is taken by %q", value, existing.Login)
+		}
+	}
+
+	found, err := a.st.Roles().GetByName(ctx, *role)
+	if err != nil {
+		return err
+	}
+
+	if found == nil {
+		return fmt.Errorf("role %q not found", *role)
+	}
+
+	user := &storage.User{
+		ID:        uuid.New(),
+		Login:     *login,
+		Email:     userEmail,
+		FirstName: *firstName,
+		Timezone:  *timezone,
+		Status:    storage.UserStatusActive,
+		CreatedAt: time.Now(),
+		Role:      storage.UserRole(found.Name),
+	}
+
+	plain, err := a.newPassword(user, *fromStdin)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash, err = a.pw.Hash(plain)
+	if err != nil {
+		return err
+	}
+
+	event, err := events.NewUserRegistered(user, events.SourceAdmin)
+	if err != nil {
+		return err
+	}
+
+	err = a.st.WithTx(ctx, func(st storage.Storage) error {
+		err := st.Users().Create(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		return st.Outbox().Create(ctx, event)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("created user %s (%s)\n", user.Login, user.ID)
+	if !*fromStdin {
+		fmt.Printf("password: %s\n", plain)
+	}
+
+	return nil
+}
+
+func resetPassword(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("reset-password", flag.ContinueOnError)
+	login := fs.String("login", "", "login (email)")
+	fromStdin := fs.Bool("password-stdin", false,
+		"read the password from stdin instead of generating one")
+	if err := parse(fs, args, login); err != nil {
+		return err
+	}
+
+	user, err := a.userByLogin(ctx, *login)
+	if err != nil {
+		return err
+	}
+
+	plain, err := readPassword(*fromStdin)
+	if err != nil {
+		return err
+	}
+
+	err = a.admin().ResetPassword(ctx, &adminService.ResetPasswordInput{
+		UserID:   user.ID,
+		Password: plain,
+	})
+	var policyErr *password.PolicyError
+	switch {
+	case err == nil:
+	case errors.As(err, &policyErr):
+		return describePolicyError(policyErr)
+	case errors.Is(err, adminService.ErrUserDeleted):
+		return fmt.Errorf("user %q is deleted", user.Login)
+	default:
+		return err
+	}
+
+	fmt.Printf("password of %s reset, all sessions ended\n", user.Login)
+	if !*fromStdin {
+		fmt.Printf("password: %s\n", plain)
+	}
+
+	return nil
+}
+
+func setRole(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("set-role", flag.ContinueOnError)
+	login := fs.String("login", "", "login (email)")
+	role := fs.String("role", "", "role")
+	if err := parse(fs, args, login, role); err != nil {
+		return err
+	}
+
+	user, err := a.userByLogin(ctx, *login)
+	if err != nil {
+		return err
+	}
+
+	found, err := a.st.Roles().GetByName(ctx, *role)
+	if err != nil {
+		return err
+	}
+
+	if found == nil {
+		return fmt.Errorf("role %q not found", *role)
+	}
+
+	previous := user.Role
+	user.Role = storage.UserRole(found.Name)
+	err = a.st.Users().Update(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	a.record(ctx, audit.EventRoleChanged, user, map[string]string{
+		"from": string(previous),
+		"to":   string(user.Role),
+	})
+
+	fmt.Printf("role of %s set to %s\n", user.Login, user.Role)
+	return nil
+}
+
+func blockUser(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("block", flag.ContinueOnError)
+	login := fs.String("login", "", "login (email)")
+	reason := fs.String("reason", "", "reason shown to the user")
+	expires := fs.String("expires", "",
+		"when the block ends, RFC 3339 (default never)")
+	if err := parse(fs, args, login, reason); err != nil {
+		return err
+	}
+
+	var expiresAt *time.Time
+	if *expires != "" {
+		parsed, err := time.Parse(time.RFC3339, *expires)
+		if err != nil {
+			return fmt.Errorf("invalid expiry %q", *expires)
+		}
+		expiresAt = &parsed
+	}
+
+	user, err := a.userByLogin(ctx, *login)
+	if err != nil {
+		return err
+	}
+
+	err = a.admin().BlockUser(ctx, &adminService.BlockUserInput{
+		UserID:    user.ID,
+		Reason:    *reason,
+		ExpiresAt: expiresAt,
+	})
+	switch {
+	case err == nil:
+	case errors.Is(err, adminService.ErrUserDeleted):
+		return fmt.Errorf("user %q is deleted", user.Login)
+	default:
+		return err
+	}
+
+	fmt.Printf("user %s blocked\n", user.Login)
+	return nil
+}
+
+func unblockUser(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("unblock", flag.ContinueOnError)
+	login := fs.String("login", "", "login (email)")
+	if err := parse(fs, args, login); err != nil {
+		return err
+	}
+
+	user, err := a.userByLogin(ctx, *login)
+	if err != nil {
+		return err
+	}
+
+	if user.Status != storage.UserStatusBlocked {
+		return fmt.Errorf("user %q is not blocked", user.Login)
+	}
+
+	lifted, err := a.st.Blocks().Lift(ctx, user.ID, nil)
+	if err != nil {
+		return err
+	}
+
+	if !lifted {
+		return fmt.Errorf("user %q is not blocked", user.Login)
+	}
+
+	a.record(ctx, audit.EventUserUnblocked, user, nil)
+
+	fmt.Printf("user %s unblocked\n", user.Login)
+	return nil
+}
+
+func notesCount(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("notes-count", flag.ContinueOnError)
+	login := fs.String("login", "", "login (email)")
+	if err := parse(fs, args, login); err != nil {
+		return err
+	}
+
+	user, err := a.userByLogin(ctx, *login)
+	if err != nil {
+		return err
+	}
+
+	count, err := a.st.Notes().Count(ctx, &user.ID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(count)
+	return nil
+}
+
+// recalculateUsage counts the user's usage again from the notes, in case
+// the tracked one drifted.
+func recalculateUsage(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("recalculate-usage", flag.ContinueOnError)
+	login := fs.String("login", "", "login (email)")
+	if err := parse(fs, args, login); err != nil {
+		return err
+	}
+
+	user, err := a.userByLogin(ctx, *login)
+	if err != nil {
+		return err
+	}
+
+	err = a.st.Usage().Recalculate(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	usage, err := a.st.Usage().GetByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d notes, %d bytes\n", usage.Notes, usage.Bytes)
+	return nil
+}
+
+// newPassword reads the password from stdin or generates one. Either way
+// it has to meet the password policy.
+func (a *app) newPassword(user *storage.User, fromStdin bool) (string, error) {
+	plain, err := readPassword(fromStdin)
+	if err != nil {
+		return "", err
+	}
+
+	violations, err := a.pp.Check(plain, user.Login, user.FirstName)
+	if err != nil {
+		return "", err
+	}
+
+	if len(violations) > 0 {
+		return "", describePolicyError(&password.PolicyError{
+			Violations: violations,
+		})
+	}
+
+	return plain, nil
+}
+
+func readPassword(fromStdin bool) (string, error) {
+	if !fromStdin {
+		return generatePassword(), nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("read password: %w", err)
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// describePolicyError adds the violated rules to the error, the command
+// has no other way to show them.
+func describePolicyError(err *password.PolicyError) error {
+	messages := make([]string, 0, len(err.Violations))
+	for _, violation := range err.Violations {
+		messages = append(messages, violation.Message)
+	}
+
+	return fmt.Errorf("%w: %s", err, strings.Join(messages, "; "))
+}
+
+// admin builds the service behind the admin API, so the commands that
+// mirror it apply the same rules.
+func (a *app) admin() adminService.Service {
+	sec := security.MustNew(a.log, a.st, &a.cfg.JWT)
+	qt := quota.New(a.log, a.st, &a.cfg.Quota)
+	return adminService.New(a.log, a.st, sec, a.au, qt, a.pw, a.pp, a.cfg)
+}
+
+func generatePassword() string {
+	alphabet := strings.Join(passwordClasses, "")
+	b := make([]byte, generatedPasswordLength)
+	for i := range b {
+		class := alphabet
+		if i < len(passwordClasses) {
+			class = passwordClasses[i]
+		}
+		b[i] = class[randomIndex(len(class))]
+	}
+
+	for i := len(b) - 1; i > 0; i-- {
+		j := randomIndex(i + 1)
+		b[i], b[j] = b[j], b[i]
+	}
+
+	return string(b)
+}
+
+func randomIndex(n int) int {
+	index, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		panic(err)
+	}
+
+	return int(index.Int64())
+}
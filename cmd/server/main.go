@@ -6,19 +6,36 @@ import (
 	"net/http"
 	"time"
 
+	"cloud-notes/internal/audit"
 	"cloud-notes/internal/config"
 	"cloud-notes/internal/database/postgres"
 	"cloud-notes/internal/database/redis"
+	adminHandler "cloud-notes/internal/handlers/admin"
 	authHandler "cloud-notes/internal/handlers/auth"
 	notesHandler "cloud-notes/internal/handlers/notes"
+	oauthHandler "cloud-notes/internal/handlers/oauth"
 	userHandler "cloud-notes/internal/handlers/user"
+	webhooksHandler "cloud-notes/internal/handlers/webhooks"
+	workspacesHandler "cloud-notes/internal/handlers/workspaces"
 	"cloud-notes/internal/logger"
+	"cloud-notes/internal/mailer"
 	"cloud-notes/internal/middleware"
+	"cloud-notes/internal/oidc"
+	"cloud-notes/internal/password"
+	"cloud-notes/internal/quota"
 	"cloud-notes/internal/security"
+	adminService "cloud-notes/internal/services/admin"
 	authService "cloud-notes/internal/services/auth"
 	notesService "cloud-notes/internal/services/notes"
+	oauthService "cloud-notes/internal/services/oauth"
 	userService "cloud-notes/internal/services/user"
+	webhooksService "cloud-notes/internal/services/webhooks"
+	workspacesService "cloud-notes/internal/services/workspaces"
 	"cloud-notes/internal/storage"
+	"cloud-notes/internal/storage/memory"
+	"cloud-notes/internal/totp"
+	"cloud-notes/internal/webauthn"
+	"cloud-notes/internal/worker"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -29,46 +46,211 @@ func main() {
 	cfg := config.MustLoad()
 
 	log := logger.MustLoad(&cfg.Logger)
-	pg := postgres.MustConnect(ctx, &cfg.Postgres)
-	rd := redis.MustConnect(ctx, &cfg.Redis)
 
-	st := storage.New(log, pg, rd)
-	sec := security.New(log, st, &cfg.JWT)
+	var st storage.Storage
+	switch cfg.Storage {
+	case config.StorageMemory:
+		st = memory.New()
 
-	authSrv := authService.New(log, st, sec)
-	userSrv := userService.New(log, st)
-	notesSrv := notesService.New(log, st)
+		// No worker can reach this process's memory, so the server
+		// runs the jobs itself.
+		go worker.MustNew(log, st, cfg).Run(ctx)
+	default:
+		pg := postgres.MustConnect(ctx, &cfg.Postgres)
+		rd := redis.MustConnect(ctx, &cfg.Redis)
+
+		st = storage.New(log, pg, rd)
+	}
+
+	sec := security.MustNew(log, st, &cfg.JWT)
+	otp := totp.New(&cfg.TOTP, time.Now)
+	wa := webauthn.MustNew(&cfg.WebAuthn)
+	ml := mailer.MustLoad(log, &cfg.Mailer)
+	oc := oidc.New(&cfg.OIDC)
+	pw := password.MustNew(&cfg.PasswordHash)
+	pp := password.NewPolicy(&cfg.PasswordPolicy, &cfg.PasswordHash)
+	au := audit.New(log, st)
+	qt := quota.New(log, st, &cfg.Quota)
+
+	authSrv := authService.New(log, st, sec, otp, wa, ml, oc, pw, pp, au, cfg)
+	userSrv := userService.New(log, st, sec, au, qt, cfg)
+	notesSrv := notesService.New(log, st, qt)
+	adminSrv := adminService.New(log, st, sec, au, qt, pw, pp, cfg)
+	oauthSrv := oauthService.New(log, st, sec, au, cfg)
+	workspacesSrv := workspacesService.New(log, st)
+	webhooksSrv := webhooksService.New(log, st, cfg)
 
 	auth := authHandler.New(log, authSrv)
 	user := userHandler.New(log, userSrv)
 	notes := notesHandler.New(log, notesSrv)
+	admin := adminHandler.New(log, adminSrv)
+	oauth := oauthHandler.New(log, oauthSrv)
+	workspaces := workspacesHandler.New(log, workspacesSrv)
+	webhooks := webhooksHandler.New(log, webhooksSrv)
+
+	authLimit := middleware.RateLimit(log, st, "auth", &cfg.RateLimit.Auth)
+	readLimit := middleware.RateLimit(log, st, "read", &cfg.RateLimit.Read)
+	writeLimit := middleware.RateLimit(log, st, "write", &cfg.RateLimit.Write)
+
+	session := middleware.Security(log, st, sec)
+	profileRead := middleware.Security(
+		log, st, sec, security.ScopeProfileRead)
+	profileWrite := middleware.Security(
+		log, st, sec, security.ScopeProfileWrite)
+	notesRead := middleware.Security(log, st, sec, security.ScopeNotesRead)
+	notesWrite := middleware.Security(log, st, sec, security.ScopeNotesWrite)
+	require := middleware.Require
+	owner := middleware.DenyImpersonation()
 
 	r := chi.NewRouter()
+	r.Use(middleware.RealIP(cfg.Server.TrustedProxies))
 	r.Use(middleware.Logging(log))
+	r.Use(middleware.Audit())
 	r.Route("/api", func(r chi.Router) {
-		r.With(middleware.Security(log, st, sec)).Group(func(r chi.Router) {
-			r.Post("/auth/logout", auth.Logout)
-			r.Post("/auth/change-password", auth.ChangePassword)
-			r.Route("/user", func(r chi.Router) {
-				r.Get("/profile", user.GetProfile)
-				r.Put("/profile", user.UpdateProfile)
-				r.Delete("/profile", user.DeleteProfile)
+		r.With(session).Group(func(r chi.Router) {
+			r.With(authLimit).Post("/auth/logout", auth.Logout)
+			r.With(owner, authLimit).
+				Post("/auth/change-password", auth.ChangePassword)
+			r.With(owner, authLimit).Route("/auth/2fa", func(r chi.Router) {
+				r.Post("/enroll", auth.EnrollTwoFactor)
+				r.Post("/confirm", auth.ConfirmTwoFactor)
+				r.Post("/disable", auth.DisableTwoFactor)
+			})
+			r.With(owner, authLimit).
+				Route("/auth/passkeys", func(r chi.Router) {
+					r.Get("/", auth.GetPasskeys)
+					r.Post("/register/begin", auth.BeginPasskeyRegistration)
+					r.Post("/register/finish", auth.FinishPasskeyRegistration)
+					r.Delete("/{passkey-id}", auth.DeletePasskey)
+				})
+			r.With(owner, authLimit).Route("/auth/tokens", func(r chi.Router) {
+				r.Get("/", auth.GetPersonalTokens)
+				r.Post("/", auth.CreatePersonalToken)
+				r.Delete("/{token-id}", auth.RevokePersonalToken)
+			})
+			r.With(owner, writeLimit).
+				Route("/oauth/clients", func(r chi.Router) {
+					r.Get("/", oauth.GetClients)
+					r.Post("/", oauth.CreateClient)
+					r.Delete("/{client-id}", oauth.DeleteClient)
+				})
+			r.With(owner, authLimit).
+				Route("/oauth/authorize", func(r chi.Router) {
+					r.Get("/", oauth.GetAuthorization)
+					r.Post("/", oauth.ApproveAuthorization)
+				})
+			r.Route("/webhooks", func(r chi.Router) {
+				r.With(readLimit).Get("/", webhooks.GetWebhooks)
+				r.With(writeLimit).Post("/", webhooks.CreateWebhook)
+				r.Route("/{webhook-id}", func(r chi.Router) {
+					r.With(writeLimit).Put("/", webhooks.UpdateWebhook)
+					r.With(writeLimit).Delete("/", webhooks.DeleteWebhook)
+					r.With(readLimit).Get("/deliveries", webhooks.GetDeliveries)
+					r.With(writeLimit).Post(
+						"/deliveries/{delivery-id}/replay",
+						webhooks.ReplayDelivery)
+				})
+			})
+			r.Route("/workspaces", func(r chi.Router) {
+				r.With(readLimit).Get("/", workspaces.GetWorkspaces)
+				r.With(writeLimit).Post("/", workspaces.CreateWorkspace)
+				r.Route("/{workspace-id}", func(r chi.Router) {
+					r.With(readLimit).Get("/", workspaces.GetWorkspace)
+					r.With(writeLimit).Put("/", workspaces.RenameWorkspace)
+					r.With(writeLimit).Delete("/", workspaces.DeleteWorkspace)
+					r.With(writeLimit).Route("/members",
+						func(r chi.Router) {
+							r.Post("/", workspaces.AddMember)
+							r.Put("/{user-id}", workspaces.UpdateMember)
+							r.Delete("/{user-id}", workspaces.RemoveMember)
+						})
+				})
+			})
+		})
+		r.With(session).Route("/admin", func(r chi.Router) {
+			r.With(require(security.PermissionStatsRead), readLimit).
+				Get("/stats", admin.GetStats)
+			r.With(require(security.PermissionSecurityEventsRead), readLimit).
+				Get("/security-events", admin.GetSecurityEvents)
+			r.With(require(security.PermissionUsersRead), readLimit).
+				Get("/users", admin.ListUsers)
+			r.With(require(security.PermissionUsersRead), readLimit).
+				Get("/plans", admin.GetPlans)
+			r.Route("/users/{user-id}", func(r chi.Router) {
+				r.With(require(security.PermissionUsersRead), readLimit).
+					Get("/", admin.GetUser)
+				r.With(require(security.PermissionUsersRead), readLimit).
+					Get("/quota", admin.GetQuota)
+				r.With(require(security.PermissionRolesManage), writeLimit).
+					Put("/role", admin.SetRole)
+				r.With(require(security.PermissionUsersWrite), writeLimit).
+					Group(func(r chi.Router) {
+						r.Post("/block", admin.BlockUser)
+						r.Post("/unblock", admin.UnblockUser)
+						r.Post("/logout", admin.LogoutUser)
+						r.Put("/quota", admin.SetQuota)
+						r.Delete("/quota", admin.ResetQuota)
+					})
+				r.With(require(security.PermissionUsersImpersonate),
+					authLimit).Post("/impersonate", admin.ImpersonateUser)
 			})
-			r.Route("/notes", func(r chi.Router) {
-				r.Post("/", notes.CreateNote)
-				r.Get("/", notes.GetNotes)
-				r.Route("/{note-id}", func(r chi.Router) {
-					r.Put("/", notes.UpdateNote)
-					r.Delete("/", notes.DeleteNote)
+			r.With(require(security.PermissionJobsManage)).
+				Route("/jobs", func(r chi.Router) {
+					r.With(readLimit).Get("/", admin.ListJobs)
+					r.With(readLimit).Get("/{job-id}", admin.GetJob)
+					r.With(writeLimit).Post("/{job-id}/retry", admin.RetryJob)
+				})
+			r.With(require(security.PermissionRolesManage)).
+				Route("/roles", func(r chi.Router) {
+					r.With(readLimit).Get("/", admin.GetRoles)
+					r.With(writeLimit).Post("/", admin.CreateRole)
+					r.With(writeLimit).Put("/{role}", admin.UpdateRole)
+					r.With(writeLimit).Delete("/{role}", admin.DeleteRole)
 				})
+		})
+		r.Route("/user", func(r chi.Router) {
+			r.With(profileRead, readLimit).Get("/profile", user.GetProfile)
+			r.With(profileWrite, writeLimit).Put("/profile", user.UpdateProfile)
+			r.With(session, owner, writeLimit).
+				Delete("/profile", user.DeleteProfile)
+			r.With(session, owner, writeLimit).
+				Post("/export", user.RequestExport)
+			r.With(session, readLimit).
+				Get("/export/{export-id}", user.GetExport)
+			r.With(session, readLimit).
+				Get("/security-events", user.GetSecurityEvents)
+			r.With(profileRead, readLimit).Get("/usage", user.GetUsage)
+			r.With(readLimit).Get("/export/download", user.DownloadExport)
+		})
+		r.Route("/notes", func(r chi.Router) {
+			r.With(notesWrite, writeLimit).Post("/", notes.CreateNote)
+			r.With(notesRead, readLimit).Get("/", notes.GetNotes)
+			r.Route("/{note-id}", func(r chi.Router) {
+				r.With(notesWrite, writeLimit).Put("/", notes.UpdateNote)
+				r.With(notesWrite, writeLimit).Delete("/", notes.DeleteNote)
 			})
 		})
-		r.Group(func(r chi.Router) {
+		r.With(authLimit).Group(func(r chi.Router) {
 			r.Post("/auth/register", auth.Register)
+			r.Post("/auth/verify", auth.VerifyEmail)
+			r.Post("/auth/verify/resend", auth.ResendVerification)
+			r.Post("/auth/forgot-password", auth.ForgotPassword)
+			r.Post("/auth/reset-password", auth.ResetPassword)
 			r.Post("/auth/login", auth.Login)
+			r.Post("/auth/restore", auth.RestoreAccount)
+			r.Post("/auth/login/2fa", auth.VerifyTwoFactor)
+			r.Post("/auth/login/passkey/begin", auth.BeginPasskeyLogin)
+			r.Post("/auth/login/passkey/finish", auth.FinishPasskeyLogin)
+			r.Post("/auth/login/oidc/begin", auth.BeginOIDCLogin)
+			r.Post("/auth/login/oidc/finish", auth.FinishOIDCLogin)
+			r.Post("/oauth/token", oauth.Token)
+			r.Post("/oauth/introspect", oauth.Introspect)
+			r.Post("/oauth/revoke", oauth.Revoke)
 		})
 	})
 
+	r.Get("/.well-known/jwks.json", auth.GetJWKS)
+
 	r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
@@ -83,6 +265,7 @@ func main() {
 
 	log.InfoContext(ctx, "starting server",
 		logger.String("env", cfg.Env),
+		logger.String("storage", cfg.Storage),
 		logger.String("host", cfg.Server.Host),
 		logger.Int("port", cfg.Server.Port))
 	_ = srv.ListenAndServe()
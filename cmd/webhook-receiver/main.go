@@ -0,0 +1,58 @@
+// Command webhook-receiver is a local endpoint for trying out webhooks. It
+// checks the signature of every delivery and prints the events.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"cloud-notes/internal/security"
+)
+
+const signatureTolerance = 5 * time.Minute
+
+func main() {
+	addr := flag.String("addr", ":9000", "address to listen on")
+	secret := flag.String("secret", "", "webhook secret (cnw_...)")
+	status := flag.Int("status", http.StatusOK, "status to respond with")
+	flag.Parse()
+
+	if *secret == "" {
+		fmt.Fprintln(os.Stderr, "usage: webhook-receiver -secret S "+
+			"[-addr :9000] [-status 200]")
+		os.Exit(2)
+	}
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = security.VerifyWebhook(*secret,
+			r.Header.Get("X-CloudNotes-Signature"), body,
+			signatureTolerance, time.Now())
+		if err != nil {
+			fmt.Printf("%s %s: %v\n", r.Header.Get("X-CloudNotes-Delivery"),
+				r.Header.Get("X-CloudNotes-Event"), err)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		fmt.Printf("%s %s: %s\n", r.Header.Get("X-CloudNotes-Delivery"),
+			r.Header.Get("X-CloudNotes-Event"), body)
+		w.WriteHeader(*status)
+	})
+
+	fmt.Printf("listening on %s\n", *addr)
+	err := http.ListenAndServe(*addr, nil) // nolint
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
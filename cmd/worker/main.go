@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"os/signal"
+	"syscall"
+
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/storage"
+	"cloud-notes/internal/worker"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(
+		context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	cfg := config.MustLoad()
+
+	log := logger.MustLoad(&cfg.Logger)
+	if cfg.Storage == config.StorageMemory {
+		panic("the worker can't see the server's memory storage, " +
+			"the server runs the jobs itself with STORAGE=memory")
+	}
+
+	pg := postgres.MustConnect(ctx, &cfg.Postgres)
+	rd := redis.MustConnect(ctx, &cfg.Redis)
+
+	st := storage.New(log, pg, rd)
+
+	worker.MustNew(log, st, cfg).Run(ctx)
+}
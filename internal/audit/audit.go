@@ -0,0 +1,137 @@
+package audit
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventLoginSucceeded       = "login_succeeded"
+	EventLoginFailed          = "login_failed"
+	EventLogout               = "logout"
+	EventPasswordChanged      = "password_changed"
+	EventPasswordReset        = "password_reset"
+	EventTwoFactorEnabled     = "two_factor_enabled"
+	EventTwoFactorDisabled    = "two_factor_disabled"
+	EventPersonalTokenCreated = "personal_token_created"
+	EventPersonalTokenRevoked = "personal_token_revoked"
+	EventOAuthConsentGranted  = "oauth_consent_granted"
+	EventSessionRevoked       = "session_revoked"
+	EventRefreshTokenReused   = "refresh_token_reused"
+	EventProfileUpdated       = "profile_updated"
+	EventAccountDeleted       = "account_deleted"
+	EventAccountRestored      = "account_restored"
+	EventUserBlocked          = "user_blocked"
+	EventUserUnblocked        = "user_unblocked"
+	EventRoleChanged          = "role_changed"
+	EventForcedLogout         = "forced_logout"
+	EventImpersonated         = "impersonated"
+	EventQuotaChanged         = "quota_changed"
+	EventJobRetried           = "job_retried"
+)
+
+// Event is what services report. The request details and the actor are
+// filled in by the auditor.
+type Event struct {
+	Type    string
+	UserID  *uuid.UUID
+	Login   *string
+	Details map[string]string
+}
+
+// Request is the client that made the request, as seen by the server.
+type Request struct {
+	IP        string
+	UserAgent string
+}
+
+type ctxKey struct{}
+
+type sourceCtxKey struct{}
+
+type auditor struct {
+	log logger.Logger
+	st  storage.Storage
+}
+
+func New(log logger.Logger, st storage.Storage) Auditor {
+	return &auditor{
+		log: log,
+		st:  st,
+	}
+}
+
+// Record appends the event to the audit trail. The action it describes has
+// already happened, so a failed write is logged instead of failing it.
+func (a *auditor) Record(ctx context.Context, event *Event) {
+	const op = "audit.Record"
+	log := a.log.With(logger.String("op", op))
+
+	record := &storage.SecurityEvent{
+		ID:        uuid.New(),
+		UserID:    event.UserID,
+		Type:      event.Type,
+		Login:     event.Login,
+		Details:   event.Details,
+		CreatedAt: time.Now(),
+	}
+
+	if source, ok := LookupSource(ctx); ok {
+		record.Details = maps.Clone(event.Details)
+		if record.Details == nil {
+			record.Details = make(map[string]string)
+		}
+		record.Details["source"] = source
+	}
+
+	if request, ok := LookupRequest(ctx); ok {
+		record.IP = &request.IP
+		if request.UserAgent != "" {
+			record.UserAgent = &request.UserAgent
+		}
+	}
+
+	// The caller is recorded as the actor only when acting on someone
+	// else, like an admin blocking a user or impersonating them.
+	claims, ok := security.LookupClaims(ctx)
+	switch {
+	case !ok:
+	case claims.ImpersonatorID != nil:
+		record.ActorID = claims.ImpersonatorID
+	case event.UserID == nil || claims.UserID != *event.UserID:
+		record.ActorID = &claims.UserID
+	}
+
+	err := a.st.SecurityEvents().Create(ctx, record)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to record security event",
+			logger.String("type", event.Type), logger.Error(err))
+	}
+}
+
+func LookupRequest(ctx context.Context) (*Request, bool) {
+	request, ok := ctx.Value(ctxKey{}).(*Request)
+	return request, ok
+}
+
+func SetRequest(ctx context.Context, request *Request) context.Context {
+	return context.WithValue(ctx, ctxKey{}, request)
+}
+
+// LookupSource returns where the change came from when it wasn't a
+// request, like "cli" for the admin tool.
+func LookupSource(ctx context.Context) (string, bool) {
+	source, ok := ctx.Value(sourceCtxKey{}).(string)
+	return source, ok
+}
+
+func SetSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, sourceCtxKey{}, source)
+}
@@ -0,0 +1,9 @@
+package audit
+
+import (
+	"context"
+)
+
+type Auditor interface {
+	Record(ctx context.Context, event *Event)
+}
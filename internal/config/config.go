@@ -1,18 +1,39 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net/netip"
+	"strings"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
-	Env      string `env:"ENV" env-required:"true"`
-	Server   `                 env-required:"true" env-prefix:"SERVER_"`
-	Logger   `                 env-required:"true" env-prefix:"LOGGER_"`
-	Postgres `                 env-required:"true" env-prefix:"POSTGRES_"`
-	Redis    `                 env-required:"true" env-prefix:"REDIS_"`
-	JWT      `                 env-required:"true" env-prefix:"JWT_"`
+	Env            string `env:"ENV" env-required:"true"`
+	Storage        string `env:"STORAGE" env-default:"postgres"`
+	Server         `                 env-required:"true" env-prefix:"SERVER_"`
+	Logger         `                 env-required:"true" env-prefix:"LOGGER_"`
+	Postgres       `                                     env-prefix:"POSTGRES_"`
+	Redis          `                                     env-prefix:"REDIS_"`
+	JWT            `                 env-required:"true" env-prefix:"JWT_"`
+	RateLimit      `                                     env-prefix:"RATE_LIMIT_"`
+	TOTP           `                                     env-prefix:"TOTP_"`
+	WebAuthn       `                                     env-prefix:"WEBAUTHN_"`
+	Mailer         `                                     env-prefix:"MAILER_"`
+	Verification   `                                     env-prefix:"VERIFICATION_"`
+	PasswordReset  `                                     env-prefix:"PASSWORD_RESET_"`
+	Admin          `                                     env-prefix:"ADMIN_"`
+	Deletion       `                                     env-prefix:"DELETION_"`
+	Export         `                                     env-prefix:"EXPORT_"`
+	OIDC           `                                     env-prefix:"OIDC_"`
+	OAuth          `                                     env-prefix:"OAUTH_"`
+	PasswordHash   `                                     env-prefix:"PASSWORD_HASH_"`
+	PasswordPolicy `                                     env-prefix:"PASSWORD_POLICY_"`
+	Quota          `                                     env-prefix:"QUOTA_"`
+	Webhook        `                                     env-prefix:"WEBHOOK_"`
+	Events         `                                     env-prefix:"EVENTS_"`
+	Jobs           `                                     env-prefix:"JOBS_"`
 }
 
 type Server struct {
@@ -21,6 +42,47 @@ type Server struct {
 	ReadTimeout  int    `env:"READ_TIMEOUT"  env-required:"true"`
 	WriteTimeout int    `env:"WRITE_TIMEOUT" env-required:"true"`
 	IdleTimeout  int    `env:"IDLE_TIMEOUT"  env-required:"true"`
+	PublicURL    string `env:"PUBLIC_URL"    env-default:"http://localhost:8000"`
+
+	TrustedProxies Proxies `env:"TRUSTED_PROXIES" env-default:""`
+}
+
+// Proxies are the reverse proxies whose X-Forwarded-For header is trusted,
+// given as IPs and CIDR ranges separated by commas.
+type Proxies []netip.Prefix
+
+func (p *Proxies) SetValue(value string) error {
+	*p = nil
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(item)
+			if addrErr != nil {
+				return err
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+
+		*p = append(*p, prefix.Masked())
+	}
+
+	return nil
+}
+
+func (p Proxies) Contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range p {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
 }
 
 type Logger struct {
@@ -29,24 +91,174 @@ type Logger struct {
 	Format string `env:"FORMAT" env-required:"true"`
 }
 
+// Storage backends. With StorageMemory the server keeps everything in its
+// own memory and needs neither Postgres nor Redis.
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
+// Postgres and Redis are required unless STORAGE=memory, validate checks
+// them.
 type Postgres struct {
-	User     string `env:"USER"     env-required:"true"`
-	Password string `env:"PASSWORD" env-required:"true"`
-	Host     string `env:"HOST"     env-required:"true"`
-	Port     int    `env:"PORT"     env-required:"true"`
-	DB       string `env:"DB"       env-required:"true"`
-	URL      string `env:"URL"      env-required:"true"`
+	User     string `env:"USER"`
+	Password string `env:"PASSWORD"`
+	Host     string `env:"HOST"`
+	Port     int    `env:"PORT"`
+	DB       string `env:"DB"`
+	URL      string `env:"URL"`
 }
 
 type Redis struct {
-	Host string `env:"HOST" env-required:"true"`
-	Port int    `env:"PORT" env-required:"true"`
-	DB   int    `env:"DB" env-required:"true"`
-	URL  string `env:"URL"  env-required:"true"`
+	Host string `env:"HOST"`
+	Port int    `env:"PORT"`
+	DB   int    `env:"DB"`
+	URL  string `env:"URL"`
 }
 
 type JWT struct {
-	Secret string `env:"SECRET" env-required:"true"`
+	Algorithm      string   `env:"ALGORITHM"        env-default:"EdDSA"`
+	PrivateKeyFile string   `env:"PRIVATE_KEY_FILE" env-default:""`
+	PublicKeyFiles []string `env:"PUBLIC_KEY_FILES" env-default:""`
+	LegacyHS256    bool     `env:"LEGACY_HS256"     env-default:"false"`
+	Secret         string   `env:"SECRET"           env-default:""`
+	ChallengeTTL   int      `env:"CHALLENGE_TTL"    env-default:"300"`
+}
+
+type RateLimit struct {
+	Auth  RateLimitBudget `env-prefix:"AUTH_"`
+	Read  RateLimitBudget `env-prefix:"READ_"`
+	Write RateLimitBudget `env-prefix:"WRITE_"`
+}
+
+type RateLimitBudget struct {
+	Limit  int `env:"LIMIT"  env-default:"60"`
+	Period int `env:"PERIOD" env-default:"60"`
+}
+
+type TOTP struct {
+	Issuer      string `env:"ISSUER"       env-default:"CloudNotes"`
+	Skew        int    `env:"SKEW"         env-default:"1"`
+	MaxAttempts int    `env:"MAX_ATTEMPTS" env-default:"5"`
+}
+
+type WebAuthn struct {
+	RPID      string   `env:"RP_ID"      env-default:"localhost"`
+	RPName    string   `env:"RP_NAME"    env-default:"CloudNotes"`
+	RPOrigins []string `env:"RP_ORIGINS" env-default:"http://localhost:8000"`
+	Timeout   int      `env:"TIMEOUT"    env-default:"300"`
+}
+
+type Mailer struct {
+	Driver string `env:"DRIVER" env-default:"log"`
+	From   string `env:"FROM"   env-default:"noreply@cloud-notes.local"`
+	Dir    string `env:"DIR"    env-default:"mail"`
+	SMTP   `                    env-prefix:"SMTP_"`
+}
+
+type SMTP struct {
+	Host     string `env:"HOST"     env-default:"localhost"`
+	Port     int    `env:"PORT"     env-default:"25"`
+	User     string `env:"USER"`
+	Password string `env:"PASSWORD"`
+}
+
+type Verification struct {
+	TTL          int `env:"TTL"           env-default:"86400"`
+	ResendLimit  int `env:"RESEND_LIMIT"  env-default:"3"`
+	ResendPeriod int `env:"RESEND_PERIOD" env-default:"3600"`
+}
+
+type PasswordReset struct {
+	TTL    int `env:"TTL"    env-default:"3600"`
+	Limit  int `env:"LIMIT"  env-default:"3"`
+	Period int `env:"PERIOD" env-default:"3600"`
+}
+
+type Admin struct {
+	ImpersonationTTL int `env:"IMPERSONATION_TTL" env-default:"3600"`
+}
+
+type Deletion struct {
+	GracePeriod   int `env:"GRACE_PERIOD"   env-default:"2592000"`
+	PurgeInterval int `env:"PURGE_INTERVAL" env-default:"3600"`
+}
+
+type Export struct {
+	TTL             int `env:"TTL"              env-default:"86400"`
+	CleanupInterval int `env:"CLEANUP_INTERVAL" env-default:"3600"`
+}
+
+type OIDC struct {
+	Provider     string   `env:"PROVIDER"      env-default:"sso"`
+	Issuer       string   `env:"ISSUER"        env-default:""`
+	ClientID     string   `env:"CLIENT_ID"     env-default:""`
+	ClientSecret string   `env:"CLIENT_SECRET" env-default:""`
+	RedirectURL  string   `env:"REDIRECT_URL"  env-default:""`
+	Scopes       []string `env:"SCOPES"        env-default:"openid,email,profile"`
+	Timeout      int      `env:"TIMEOUT"       env-default:"600"`
+}
+
+type PasswordHash struct {
+	Algorithm         string `env:"ALGORITHM"          env-default:"argon2id"`
+	Argon2Memory      int    `env:"ARGON2_MEMORY"      env-default:"65536"`
+	Argon2Iterations  int    `env:"ARGON2_ITERATIONS"  env-default:"3"`
+	Argon2Parallelism int    `env:"ARGON2_PARALLELISM" env-default:"2"`
+	Argon2KeyLength   int    `env:"ARGON2_KEY_LENGTH"  env-default:"32"`
+	BcryptCost        int    `env:"BCRYPT_COST"        env-default:"10"`
+}
+
+type PasswordPolicy struct {
+	MinLength        int    `env:"MIN_LENGTH"         env-default:"8"`
+	MaxLength        int    `env:"MAX_LENGTH"         env-default:"128"`
+	RequireLower     bool   `env:"REQUIRE_LOWER"      env-default:"false"`
+	RequireUpper     bool   `env:"REQUIRE_UPPER"      env-default:"false"`
+	RequireDigit     bool   `env:"REQUIRE_DIGIT"      env-default:"false"`
+	RequireSymbol    bool   `env:"REQUIRE_SYMBOL"     env-default:"false"`
+	DisallowPersonal bool   `env:"DISALLOW_PERSONAL"  env-default:"true"`
+	History          int    `env:"HISTORY"            env-default:"5"`
+	BreachedDir      string `env:"BREACHED_DIR"       env-default:""`
+	BreachedMinCount int    `env:"BREACHED_MIN_COUNT" env-default:"1"`
+}
+
+type Quota struct {
+	DefaultPlan string `env:"DEFAULT_PLAN" env-default:"free"`
+}
+
+type Webhook struct {
+	Timeout      int  `env:"TIMEOUT"       env-default:"10"`
+	MaxAttempts  int  `env:"MAX_ATTEMPTS"  env-default:"8"`
+	BackoffBase  int  `env:"BACKOFF_BASE"  env-default:"30"`
+	BackoffMax   int  `env:"BACKOFF_MAX"   env-default:"21600"`
+	Interval     int  `env:"INTERVAL"      env-default:"5"`
+	Retention    int  `env:"RETENTION"     env-default:"2592000"`
+	AllowPrivate bool `env:"ALLOW_PRIVATE" env-default:"false"`
+	DisableAfter int  `env:"DISABLE_AFTER" env-default:"5"`
+}
+
+type Events struct {
+	Interval    int `env:"INTERVAL"     env-default:"1"`
+	Lease       int `env:"LEASE"        env-default:"60"`
+	MaxAttempts int `env:"MAX_ATTEMPTS" env-default:"10"`
+	Retention   int `env:"RETENTION"    env-default:"604800"`
+	ClaimIdle   int `env:"CLAIM_IDLE"   env-default:"60"`
+}
+
+type Jobs struct {
+	Interval    int `env:"INTERVAL"     env-default:"1"`
+	Concurrency int `env:"CONCURRENCY"  env-default:"10"`
+	Lease       int `env:"LEASE"        env-default:"600"`
+	MaxAttempts int `env:"MAX_ATTEMPTS" env-default:"5"`
+	BackoffBase int `env:"BACKOFF_BASE" env-default:"10"`
+	BackoffMax  int `env:"BACKOFF_MAX"  env-default:"3600"`
+	Retention   int `env:"RETENTION"    env-default:"604800"`
+}
+
+type OAuth struct {
+	AccessTokenTTL  int `env:"ACCESS_TOKEN_TTL"  env-default:"3600"`
+	RefreshTokenTTL int `env:"REFRESH_TOKEN_TTL" env-default:"2592000"`
+	GrantLifetime   int `env:"GRANT_LIFETIME"    env-default:"7776000"`
+	CodeTTL         int `env:"CODE_TTL"          env-default:"60"`
 }
 
 func Load() (*Config, error) {
@@ -57,9 +269,75 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
+	err = c.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
 	return c, nil
 }
 
+// validate rejects the values cleanenv accepts but the code can't work
+// with.
+func (c *Config) validate() error {
+	switch c.Storage {
+	case StorageMemory:
+	case StoragePostgres:
+		required := []struct {
+			name string
+			set  bool
+		}{
+			{"POSTGRES_USER", c.Postgres.User != ""},
+			{"POSTGRES_PASSWORD", c.Postgres.Password != ""},
+			{"POSTGRES_HOST", c.Postgres.Host != ""},
+			{"POSTGRES_PORT", c.Postgres.Port != 0},
+			{"POSTGRES_DB", c.Postgres.DB != ""},
+			{"POSTGRES_URL", c.Postgres.URL != ""},
+			{"REDIS_HOST", c.Redis.Host != ""},
+			{"REDIS_PORT", c.Redis.Port != 0},
+			{"REDIS_URL", c.Redis.URL != ""},
+		}
+		for _, r := range required {
+			if !r.set {
+				return fmt.Errorf("%s is required with STORAGE=%s",
+					r.name, StoragePostgres)
+			}
+		}
+	default:
+		return fmt.Errorf("STORAGE must be %q or %q, got %q",
+			StoragePostgres, StorageMemory, c.Storage)
+	}
+
+	budgets := []struct {
+		name   string
+		budget *RateLimitBudget
+	}{
+		{"AUTH", &c.RateLimit.Auth},
+		{"READ", &c.RateLimit.Read},
+		{"WRITE", &c.RateLimit.Write},
+	}
+	for _, b := range budgets {
+		err := b.budget.validate()
+		if err != nil {
+			return fmt.Errorf("RATE_LIMIT_%s_%w", b.name, err)
+		}
+	}
+
+	return nil
+}
+
+func (b *RateLimitBudget) validate() error {
+	if b.Limit < 1 {
+		return errors.New("LIMIT must be at least 1")
+	}
+
+	if b.Period < 1 {
+		return errors.New("PERIOD must be at least 1")
+	}
+
+	return nil
+}
+
 func MustLoad() *Config {
 	c, err := Load()
 	if err != nil {
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"cloud-notes/internal/config"
@@ -17,9 +18,44 @@ type Batch = pgx.Batch
 type Row = pgx.Row
 type Rows = pgx.Rows
 type Command = pgconn.CommandTag
+type TxOptions = pgx.TxOptions
+
+const Serializable = pgx.Serializable
+
+// DB is what storages run their queries on: the pool, or a transaction
+// when they are part of one. Begin on a transaction starts a savepoint.
+type DB interface {
+	Exec(ctx context.Context, sql string, args ...any) (Command, error)
+	Query(ctx context.Context, sql string, args ...any) (Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) Row
+	Begin(ctx context.Context) (Tx, error)
+}
 
 var ErrNoRows = pgx.ErrNoRows
 
+// BeginTx starts a transaction with the options on the pool. On a
+// transaction it starts a savepoint, which runs with the options of the
+// transaction.
+func BeginTx(ctx context.Context, db DB, opts TxOptions) (Tx, error) {
+	if p, ok := db.(*Postgres); ok {
+		return p.BeginTx(ctx, opts)
+	}
+
+	return db.Begin(ctx)
+}
+
+// IsRetryable reports whether Postgres aborted the transaction because of
+// concurrent ones, a serialization failure or a deadlock. Running the
+// transaction again may succeed.
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == "40001" || pgErr.Code == "40P01"
+}
+
 func Connect(ctx context.Context, cfg *config.Postgres) (*Postgres, error) {
 	p, err := pgxpool.New(ctx, cfg.URL)
 	if err != nil {
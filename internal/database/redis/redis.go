@@ -10,9 +10,17 @@ import (
 )
 
 type Redis = redis.Client
+type Script = redis.Script
+
+type XAddArgs = redis.XAddArgs
+type XReadGroupArgs = redis.XReadGroupArgs
+type XAutoClaimArgs = redis.XAutoClaimArgs
+type XMessage = redis.XMessage
 
 var ErrNoRows = redis.Nil
 
+var NewScript = redis.NewScript
+
 func Connect(ctx context.Context, cfg *config.Redis) (*Redis, error) {
 	r := redis.NewClient(&redis.Options{
 		Addr: fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
@@ -0,0 +1,34 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// accountEvent is the payload of the account events, the type tells what
+// happened to the account.
+type accountEvent struct {
+	UserID     uuid.UUID `json:"user_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// NewAccountEvent describes a security-relevant change of the user's
+// account, like a new password or disabled 2FA.
+func NewAccountEvent(
+	eventType string, userID uuid.UUID) (*storage.OutboxEvent, error) {
+	const op = "events.NewAccountEvent"
+
+	event, err := NewEvent(eventType, userID, &accountEvent{
+		UserID:     userID,
+		OccurredAt: time.Now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return event, nil
+}
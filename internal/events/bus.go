@@ -0,0 +1,185 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/storage"
+)
+
+const (
+	dispatchBatchSize = 100
+	consumeBatchSize  = 10
+	consumeBlock      = 5 * time.Second
+)
+
+// Handler reacts to an event. Events are delivered at least once, so it
+// has to tolerate seeing the same event again, the ID stays the same.
+type Handler func(ctx context.Context, event *storage.OutboxEvent) error
+
+type bus struct {
+	log logger.Logger
+	st  storage.Storage
+	cfg *config.Events
+}
+
+func New(log logger.Logger, st storage.Storage, cfg *config.Events) Bus {
+	return &bus{
+		log: log,
+		st:  st,
+		cfg: cfg,
+	}
+}
+
+// Dispatch publishes the outbox events to the Redis stream, oldest first.
+// Events are claimed for EVENTS_LEASE seconds, so workers running it at the
+// same time don't publish an event twice. An event that failed is tried
+// again after its lease and is put aside after EVENTS_MAX_ATTEMPTS
+// attempts, without holding up the events after it.
+func (b *bus) Dispatch(ctx context.Context) error {
+	const op = "events.Dispatch"
+	log := b.log.With(logger.String("op", op))
+
+	lease := time.Second * time.Duration(b.cfg.Lease)
+	for {
+		now := time.Now()
+		events, err := b.st.Outbox().Claim(
+			ctx, now, now.Add(lease), dispatchBatchSize)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		slices.SortFunc(events, func(a, b *storage.OutboxEvent) int {
+			return a.CreatedAt.Compare(b.CreatedAt)
+		})
+
+		for _, event := range events {
+			err = b.dispatch(ctx, event)
+			if err != nil {
+				b.fail(ctx, log, event, err)
+			}
+		}
+
+		if len(events) < dispatchBatchSize {
+			return nil
+		}
+	}
+}
+
+func (b *bus) dispatch(ctx context.Context, event *storage.OutboxEvent) error {
+	const op = "events.dispatch"
+
+	err := b.st.Stream().Publish(ctx, event)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = b.st.Outbox().MarkDispatched(ctx, event.ID, time.Now())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// fail records the error of the attempt. After the last attempt the event
+// is marked failed and waits for the retry-events admin command.
+func (b *bus) fail(ctx context.Context, log logger.Logger,
+	event *storage.OutboxEvent, cause error) {
+	log = log.With(logger.String("event", event.ID.String()),
+		logger.Int("attempts", event.Attempts))
+
+	var failedAt *time.Time
+	if event.Attempts >= b.cfg.MaxAttempts {
+		now := time.Now()
+		failedAt = &now
+		log.ErrorContext(ctx, "event failed", logger.Error(cause))
+	} else {
+		log.WarnContext(ctx, "failed to dispatch event", logger.Error(cause))
+	}
+
+	err := b.st.Outbox().Fail(
+		context.WithoutCancel(ctx), event.ID, cause.Error(), failedAt)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to update event", logger.Error(err))
+	}
+}
+
+// Consume reads the Redis stream as a member of the consumer group until
+// the context is done. Every event goes to one consumer of the group and
+// is acknowledged once handled. Events a consumer failed to handle or
+// never acknowledged, because it crashed, are claimed again after
+// EVENTS_CLAIM_IDLE seconds.
+func (b *bus) Consume(
+	ctx context.Context, group, consumer string, handler Handler) {
+	const op = "events.Consume"
+	log := b.log.With(logger.String("op", op),
+		logger.String("group", group), logger.String("consumer", consumer))
+
+	for ctx.Err() == nil {
+		err := b.st.Stream().CreateGroup(ctx, group)
+		if err == nil {
+			break
+		}
+
+		log.ErrorContext(ctx, "", logger.Error(err))
+		b.wait(ctx)
+	}
+
+	minIdle := time.Second * time.Duration(b.cfg.ClaimIdle)
+	for ctx.Err() == nil {
+		messages, err := b.st.Stream().Claim(
+			ctx, group, consumer, minIdle, consumeBatchSize)
+		if err == nil && len(messages) == 0 {
+			messages, err = b.st.Stream().Read(
+				ctx, group, consumer, consumeBatchSize, consumeBlock)
+		}
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			b.wait(ctx)
+			continue
+		}
+
+		for _, message := range messages {
+			err = handler(ctx, message.Event)
+			if err != nil {
+				log.ErrorContext(ctx, "failed to handle event",
+					logger.String("event", message.Event.ID.String()),
+					logger.Error(err))
+				continue
+			}
+
+			err = b.st.Stream().Ack(ctx, group, message.ID)
+			if err != nil {
+				log.ErrorContext(ctx, "", logger.Error(err))
+			}
+		}
+	}
+}
+
+// wait pauses the consumer after a failure, so an unavailable Redis isn't
+// retried in a busy loop.
+func (b *bus) wait(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second * time.Duration(b.cfg.Interval)):
+	}
+}
+
+// Purge deletes dispatched events older than EVENTS_RETENTION seconds.
+func (b *bus) Purge(ctx context.Context) error {
+	const op = "events.Purge"
+	_ = b.log.With(logger.String("op", op))
+
+	retention := time.Second * time.Duration(b.cfg.Retention)
+	err := b.st.Outbox().DeleteDispatched(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
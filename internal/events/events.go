@@ -0,0 +1,65 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// Types of the events written to the outbox. Note and account events are
+// part of the public API, webhooks subscribe to them by name.
+const (
+	TypeNoteCreated              = "note.created"
+	TypeNoteUpdated              = "note.updated"
+	TypeNoteDeleted              = "note.deleted"
+	TypeAccountPasswordChanged   = "account.password_changed"
+	TypeAccountTwoFactorEnabled  = "account.two_factor_enabled"
+	TypeAccountTwoFactorDisabled = "account.two_factor_disabled"
+	TypeAccountDeletionRequested = "account.deletion_requested"
+	TypeAccountRestored          = "account.restored"
+	TypeUserRegistered           = "user.registered"
+)
+
+// Types lists the event types webhooks may subscribe to. user.registered
+// is only published inside the system, nobody has a webhook before it.
+var Types = []string{
+	TypeNoteCreated,
+	TypeNoteUpdated,
+	TypeNoteDeleted,
+	TypeAccountPasswordChanged,
+	TypeAccountTwoFactorEnabled,
+	TypeAccountTwoFactorDisabled,
+	TypeAccountDeletionRequested,
+	TypeAccountRestored,
+}
+
+func IsType(eventType string) bool {
+	return slices.Contains(Types, eventType)
+}
+
+// NewEvent describes a change for the outbox, data becomes the payload. The
+// event belongs to userID, like the owner of the changed note.
+func NewEvent(eventType string,
+	userID uuid.UUID, data any) (*storage.OutboxEvent, error) {
+	const op = "events.NewEvent"
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	return &storage.OutboxEvent{
+		ID:        uuid.New(),
+		Type:      eventType,
+		UserID:    userID,
+		Payload:   payload,
+		CreatedAt: now,
+		RunAt:     now,
+	}, nil
+}
@@ -0,0 +1,11 @@
+package events
+
+import (
+	"context"
+)
+
+type Bus interface {
+	Dispatch(ctx context.Context) error
+	Consume(ctx context.Context, group, consumer string, handler Handler)
+	Purge(ctx context.Context) error
+}
@@ -0,0 +1,66 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// noteEvent is the note as it appears in the events' payload.
+type noteEvent struct {
+	ID          uuid.UUID  `json:"id"`
+	WorkspaceID *uuid.UUID `json:"workspace_id,omitempty"`
+	AuthorID    uuid.UUID  `json:"author_id"`
+	Title       *string    `json:"title"`
+	Text        *string    `json:"text"`
+	Pinned      bool       `json:"pinned"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// NewNoteEvent describes the change of the note for the outbox. The event
+// is the owner's of the note's space, like the note's quota usage.
+func NewNoteEvent(eventType string,
+	ownerID uuid.UUID, note *storage.Note) (*storage.OutboxEvent, error) {
+	const op = "events.NewNoteEvent"
+
+	event, err := NewEvent(eventType, ownerID, &noteEvent{
+		ID:          note.ID,
+		WorkspaceID: note.WorkspaceID,
+		AuthorID:    note.UserID,
+		Title:       note.Title,
+		Text:        note.Text,
+		Pinned:      note.Pinned,
+		UpdatedAt:   note.UpdatedAt,
+		CreatedAt:   note.CreatedAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return event, nil
+}
+
+// NoteWorkspace returns the workspace of the note the event is about, nil
+// for personal notes and for the events of other types.
+func NoteWorkspace(event *storage.OutboxEvent) (*uuid.UUID, error) {
+	const op = "events.NoteWorkspace"
+
+	switch event.Type {
+	case TypeNoteCreated, TypeNoteUpdated, TypeNoteDeleted:
+	default:
+		return nil, nil
+	}
+
+	note := new(noteEvent)
+	err := json.Unmarshal(event.Payload, note)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return note.WorkspaceID, nil
+}
@@ -0,0 +1,46 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// How a user registered, the source of a user.registered event.
+const (
+	SourcePassword = "password"
+	SourceOIDC     = "oidc"
+	SourceAdmin    = "admin"
+)
+
+// userRegistered is the payload of a user.registered event.
+type userRegistered struct {
+	ID        uuid.UUID `json:"id"`
+	Login     string    `json:"login"`
+	FirstName string    `json:"first_name"`
+	Status    string    `json:"status"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewUserRegistered(
+	user *storage.User, source string) (*storage.OutboxEvent, error) {
+	const op = "events.NewUserRegistered"
+
+	event, err := NewEvent(TypeUserRegistered, user.ID, &userRegistered{
+		ID:        user.ID,
+		Login:     user.Login,
+		FirstName: user.FirstName,
+		Status:    string(user.Status),
+		Source:    source,
+		CreatedAt: user.CreatedAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return event, nil
+}
@@ -0,0 +1,161 @@
+package admin
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type BlockUserRequest struct {
+	Reason    string     `json:"reason"     validate:"required,max=500"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type UserResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Login     string    `json:"login"`
+	Email     *string   `json:"email"`
+	FirstName string    `json:"first_name"`
+	Timezone  string    `json:"timezone"`
+	Status    string    `json:"status"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ListUsersResponse struct {
+	Users []*UserResponse `json:"users"`
+	Total uint64          `json:"total"`
+}
+
+type BlockResponse struct {
+	Reason    string     `json:"reason"`
+	BlockedBy *uuid.UUID `json:"blocked_by,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type GetUserResponse struct {
+	UserResponse
+	NotesCount    uint64         `json:"notes_count"`
+	SessionsCount uint64         `json:"sessions_count"`
+	Block         *BlockResponse `json:"block,omitempty"`
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+type RoleResponse struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	Builtin     bool      `json:"builtin"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type GetRolesResponse struct {
+	Roles []*RoleResponse `json:"roles"`
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name"        validate:"required,max=50"`
+	Description string   `json:"description" validate:"max=500"`
+	Permissions []string `json:"permissions" validate:"required"`
+}
+
+type UpdateRoleRequest struct {
+	Description string   `json:"description" validate:"max=500"`
+	Permissions []string `json:"permissions" validate:"required"`
+}
+
+type ImpersonateUserResponse struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type UserStatsResponse struct {
+	Total   uint64 `json:"total"`
+	Pending uint64 `json:"pending"`
+	Active  uint64 `json:"active"`
+	Blocked uint64 `json:"blocked"`
+	Deleted uint64 `json:"deleted"`
+	Admins  uint64 `json:"admins"`
+}
+
+type GetStatsResponse struct {
+	Users          UserStatsResponse `json:"users"`
+	NewUsersDay    uint64            `json:"new_users_day"`
+	NewUsersWeek   uint64            `json:"new_users_week"`
+	Notes          uint64            `json:"notes"`
+	ActiveSessions uint64            `json:"active_sessions"`
+}
+
+type SecurityEventResponse struct {
+	ID        uuid.UUID         `json:"id"`
+	UserID    *uuid.UUID        `json:"user_id,omitempty"`
+	ActorID   *uuid.UUID        `json:"actor_id,omitempty"`
+	Type      string            `json:"type"`
+	Login     *string           `json:"login,omitempty"`
+	IP        *string           `json:"ip,omitempty"`
+	UserAgent *string           `json:"user_agent,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type GetSecurityEventsResponse struct {
+	Events []*SecurityEventResponse `json:"events"`
+}
+
+type PlanResponse struct {
+	Name          string `json:"name"`
+	MaxNotes      int64  `json:"max_notes"`
+	MaxTotalBytes int64  `json:"max_total_bytes"`
+	MaxNoteBytes  int64  `json:"max_note_bytes"`
+	Default       bool   `json:"default"`
+}
+
+type GetPlansResponse struct {
+	Plans []*PlanResponse `json:"plans"`
+}
+
+type QuotaResponse struct {
+	Plan          string                 `json:"plan"`
+	Notes         int64                  `json:"notes"`
+	MaxNotes      int64                  `json:"max_notes"`
+	Bytes         int64                  `json:"bytes"`
+	MaxTotalBytes int64                  `json:"max_total_bytes"`
+	MaxNoteBytes  int64                  `json:"max_note_bytes"`
+	Override      *QuotaOverrideResponse `json:"override,omitempty"`
+}
+
+type QuotaOverrideResponse struct {
+	Plan          *string   `json:"plan,omitempty"`
+	MaxNotes      *int64    `json:"max_notes,omitempty"`
+	MaxTotalBytes *int64    `json:"max_total_bytes,omitempty"`
+	MaxNoteBytes  *int64    `json:"max_note_bytes,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type SetQuotaRequest struct {
+	Plan          *string `json:"plan"            validate:"omitempty,min=1"`
+	MaxNotes      *int64  `json:"max_notes"       validate:"omitempty,min=0"`
+	MaxTotalBytes *int64  `json:"max_total_bytes" validate:"omitempty,min=0"`
+	MaxNoteBytes  *int64  `json:"max_note_bytes"  validate:"omitempty,min=0"`
+}
+
+type JobResponse struct {
+	ID         uuid.UUID       `json:"id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	Status     string          `json:"status"`
+	Attempts   int             `json:"attempts"`
+	RunAt      time.Time       `json:"run_at"`
+	Error      *string         `json:"error,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type ListJobsResponse struct {
+	Jobs []*JobResponse `json:"jobs"`
+}
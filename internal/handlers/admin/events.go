@@ -0,0 +1,82 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"cloud-notes/internal/handlers/query"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/render"
+	"cloud-notes/internal/services/admin"
+)
+
+func (h *Handler) GetSecurityEvents(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.GetSecurityEvents"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	input, err := parseSecurityEventsQuery(r)
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	output, err := h.srv.GetSecurityEvents(ctx, input)
+
+	switch {
+	case err == nil:
+		response := &GetSecurityEventsResponse{
+			Events: []*SecurityEventResponse{},
+		}
+		for _, event := range output.Events {
+			response.Events = append(response.Events,
+				&SecurityEventResponse{
+					ID:        event.ID,
+					UserID:    event.UserID,
+					ActorID:   event.ActorID,
+					Type:      event.Type,
+					Login:     event.Login,
+					IP:        event.IP,
+					UserAgent: event.UserAgent,
+					Details:   event.Details,
+					CreatedAt: event.CreatedAt,
+				})
+		}
+		render.JSON(w, http.StatusOK, response)
+	case errors.Is(err, admin.ErrInvalidPeriod):
+		render.Error(w, http.StatusUnprocessableEntity, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func parseSecurityEventsQuery(
+	r *http.Request) (*admin.GetSecurityEventsInput, error) {
+	input := &admin.GetSecurityEventsInput{
+		Type: query.String(r, "type"),
+		IP:   query.String(r, "ip"),
+	}
+
+	var err error
+	if input.UserID, err = query.UUID(r, "user_id"); err != nil {
+		return nil, err
+	}
+
+	if input.From, err = query.Time(r, "from"); err != nil {
+		return nil, err
+	}
+
+	if input.To, err = query.Time(r, "to"); err != nil {
+		return nil, err
+	}
+
+	if input.Limit, err = query.Uint64(r, "limit"); err != nil {
+		return nil, err
+	}
+
+	if input.Offset, err = query.Uint64(r, "offset"); err != nil {
+		return nil, err
+	}
+
+	return input, nil
+}
@@ -0,0 +1,102 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/render"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/services/admin"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	log logger.Logger
+	srv admin.Service
+	val *validator.Validate
+}
+
+func New(log logger.Logger, srv admin.Service) Handler {
+	return Handler{
+		log: log,
+		srv: srv,
+		val: validator.New(),
+	}
+}
+
+func (h *Handler) BlockUser(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.BlockUser"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	userID, err := uuid.Parse(chi.URLParam(r, "user-id"))
+	if err != nil {
+		render.Error(w, http.StatusBadRequest,
+			errors.New("invalid user id"))
+		return
+	}
+
+	request := new(BlockUserRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	claims := security.GetClaims(ctx)
+	err = h.srv.BlockUser(ctx, &admin.BlockUserInput{
+		AdminID:   &claims.UserID,
+		UserID:    userID,
+		Reason:    request.Reason,
+		ExpiresAt: request.ExpiresAt,
+	})
+
+	switch {
+	case err == nil:
+		render.Empty(w)
+	case errors.Is(err, admin.ErrUserNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, admin.ErrUserDeleted),
+		errors.Is(err, admin.ErrBlockSelf):
+		render.Error(w, http.StatusConflict, err)
+	case errors.Is(err, admin.ErrInvalidExpiry):
+		render.Error(w, http.StatusUnprocessableEntity, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) UnblockUser(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.UnblockUser"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	userID, err := uuid.Parse(chi.URLParam(r, "user-id"))
+	if err != nil {
+		render.Error(w, http.StatusBadRequest,
+			errors.New("invalid user id"))
+		return
+	}
+
+	err = h.srv.UnblockUser(ctx, userID)
+
+	switch {
+	case err == nil:
+		render.Empty(w)
+	case errors.Is(err, admin.ErrUserNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, admin.ErrUserNotBlocked):
+		render.Error(w, http.StatusConflict, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,116 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"cloud-notes/internal/handlers/query"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/render"
+	"cloud-notes/internal/services/admin"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.ListJobs"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	limit, err := query.Uint64(r, "limit")
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	offset, err := query.Uint64(r, "offset")
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	output, err := h.srv.ListJobs(ctx, &admin.ListJobsInput{
+		Status: query.String(r, "status"),
+		Type:   query.String(r, "type"),
+		Limit:  limit,
+		Offset: offset,
+	})
+
+	switch { // nolint
+	case err == nil:
+		response := &ListJobsResponse{
+			Jobs: make([]*JobResponse, 0, len(output.Jobs)),
+		}
+		for _, job := range output.Jobs {
+			response.Jobs = append(response.Jobs, toJobResponse(job))
+		}
+		render.JSON(w, http.StatusOK, response)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.GetJob"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "job-id"))
+	if err != nil {
+		render.Error(w, http.StatusBadRequest,
+			errors.New("invalid job id"))
+		return
+	}
+
+	output, err := h.srv.GetJob(ctx, jobID)
+
+	switch {
+	case err == nil:
+		render.JSON(w, http.StatusOK, toJobResponse(output))
+	case errors.Is(err, admin.ErrJobNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) RetryJob(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.RetryJob"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "job-id"))
+	if err != nil {
+		render.Error(w, http.StatusBadRequest,
+			errors.New("invalid job id"))
+		return
+	}
+
+	output, err := h.srv.RetryJob(ctx, jobID)
+
+	switch {
+	case err == nil:
+		render.JSON(w, http.StatusOK, toJobResponse(output))
+	case errors.Is(err, admin.ErrJobNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, admin.ErrJobNotFailed):
+		render.Error(w, http.StatusConflict, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func toJobResponse(job *admin.JobOutput) *JobResponse {
+	return &JobResponse{
+		ID:         job.ID,
+		Type:       job.Type,
+		Payload:    job.Payload,
+		Status:     job.Status,
+		Attempts:   job.Attempts,
+		RunAt:      job.RunAt,
+		Error:      job.Error,
+		FinishedAt: job.FinishedAt,
+		CreatedAt:  job.CreatedAt,
+	}
+}
@@ -0,0 +1,155 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/render"
+	"cloud-notes/internal/services/admin"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) GetPlans(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.GetPlans"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	output, err := h.srv.GetPlans(ctx)
+
+	switch { // nolint
+	case err == nil:
+		response := &GetPlansResponse{
+			Plans: make([]*PlanResponse, 0, len(output.Plans)),
+		}
+		for _, plan := range output.Plans {
+			response.Plans = append(response.Plans, &PlanResponse{
+				Name:          plan.Name,
+				MaxNotes:      plan.MaxNotes,
+				MaxTotalBytes: plan.MaxTotalBytes,
+				MaxNoteBytes:  plan.MaxNoteBytes,
+				Default:       plan.Default,
+			})
+		}
+		render.JSON(w, http.StatusOK, response)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) GetQuota(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.GetQuota"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	userID, err := uuid.Parse(chi.URLParam(r, "user-id"))
+	if err != nil {
+		render.Error(w, http.StatusBadRequest,
+			errors.New("invalid user id"))
+		return
+	}
+
+	output, err := h.srv.GetQuota(ctx, userID)
+
+	switch {
+	case err == nil:
+		render.JSON(w, http.StatusOK, toQuotaResponse(output))
+	case errors.Is(err, admin.ErrUserNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) SetQuota(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.SetQuota"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	userID, err := uuid.Parse(chi.URLParam(r, "user-id"))
+	if err != nil {
+		render.Error(w, http.StatusBadRequest,
+			errors.New("invalid user id"))
+		return
+	}
+
+	request := new(SetQuotaRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	output, err := h.srv.SetQuota(ctx, &admin.SetQuotaInput{
+		UserID:        userID,
+		Plan:          request.Plan,
+		MaxNotes:      request.MaxNotes,
+		MaxTotalBytes: request.MaxTotalBytes,
+		MaxNoteBytes:  request.MaxNoteBytes,
+	})
+
+	switch {
+	case err == nil:
+		render.JSON(w, http.StatusOK, toQuotaResponse(output))
+	case errors.Is(err, admin.ErrUserNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, admin.ErrPlanNotFound):
+		render.Error(w, http.StatusUnprocessableEntity, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) ResetQuota(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.ResetQuota"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	userID, err := uuid.Parse(chi.URLParam(r, "user-id"))
+	if err != nil {
+		render.Error(w, http.StatusBadRequest,
+			errors.New("invalid user id"))
+		return
+	}
+
+	err = h.srv.ResetQuota(ctx, userID)
+
+	switch {
+	case err == nil:
+		render.Empty(w)
+	case errors.Is(err, admin.ErrUserNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func toQuotaResponse(output *admin.QuotaOutput) *QuotaResponse {
+	response := &QuotaResponse{
+		Plan:          output.Plan,
+		Notes:         output.Notes,
+		MaxNotes:      output.MaxNotes,
+		Bytes:         output.Bytes,
+		MaxTotalBytes: output.MaxTotalBytes,
+		MaxNoteBytes:  output.MaxNoteBytes,
+	}
+
+	if output.Override != nil {
+		response.Override = &QuotaOverrideResponse{
+			Plan:          output.Override.Plan,
+			MaxNotes:      output.Override.MaxNotes,
+			MaxTotalBytes: output.Override.MaxTotalBytes,
+			MaxNoteBytes:  output.Override.MaxNoteBytes,
+			UpdatedAt:     output.Override.UpdatedAt,
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,135 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/render"
+	"cloud-notes/internal/services/admin"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handler) GetRoles(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.GetRoles"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	output, err := h.srv.GetRoles(ctx)
+
+	switch { // nolint
+	case err == nil:
+		response := &GetRolesResponse{
+			Roles: make([]*RoleResponse, 0, len(output.Roles)),
+		}
+		for _, role := range output.Roles {
+			response.Roles = append(response.Roles, toRoleResponse(role))
+		}
+		render.JSON(w, http.StatusOK, response)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.CreateRole"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	request := new(CreateRoleRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	output, err := h.srv.CreateRole(ctx, &admin.SaveRoleInput{
+		Name:        request.Name,
+		Description: request.Description,
+		Permissions: request.Permissions,
+	})
+
+	switch {
+	case err == nil:
+		render.JSON(w, http.StatusCreated, toRoleResponse(output))
+	case errors.Is(err, admin.ErrRoleExists):
+		render.Error(w, http.StatusConflict, err)
+	case errors.Is(err, admin.ErrInvalidRoleName),
+		errors.Is(err, admin.ErrUnknownPermission):
+		render.Error(w, http.StatusUnprocessableEntity, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.UpdateRole"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	request := new(UpdateRoleRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	output, err := h.srv.UpdateRole(ctx, &admin.SaveRoleInput{
+		Name:        chi.URLParam(r, "role"),
+		Description: request.Description,
+		Permissions: request.Permissions,
+	})
+
+	switch {
+	case err == nil:
+		render.JSON(w, http.StatusOK, toRoleResponse(output))
+	case errors.Is(err, admin.ErrRoleNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, admin.ErrRoleBuiltin):
+		render.Error(w, http.StatusConflict, err)
+	case errors.Is(err, admin.ErrUnknownPermission):
+		render.Error(w, http.StatusUnprocessableEntity, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.DeleteRole"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	err := h.srv.DeleteRole(ctx, chi.URLParam(r, "role"))
+
+	switch {
+	case err == nil:
+		render.Empty(w)
+	case errors.Is(err, admin.ErrRoleNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, admin.ErrRoleBuiltin),
+		errors.Is(err, admin.ErrRoleInUse):
+		render.Error(w, http.StatusConflict, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func toRoleResponse(role *admin.RoleOutput) *RoleResponse {
+	return &RoleResponse{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: role.Permissions,
+		Builtin:     role.Builtin,
+		CreatedAt:   role.CreatedAt,
+	}
+}
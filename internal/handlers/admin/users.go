@@ -0,0 +1,242 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"cloud-notes/internal/handlers/query"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/render"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/services/admin"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.ListUsers"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	limit, err := query.Uint64(r, "limit")
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	offset, err := query.Uint64(r, "offset")
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	output, err := h.srv.ListUsers(ctx, &admin.ListUsersInput{
+		Query:  query.String(r, "query"),
+		Status: query.String(r, "status"),
+		Role:   query.String(r, "role"),
+		Limit:  limit,
+		Offset: offset,
+	})
+
+	switch { // nolint
+	case err == nil:
+		response := &ListUsersResponse{
+			Users: make([]*UserResponse, 0, len(output.Users)),
+			Total: output.Total,
+		}
+		for _, user := range output.Users {
+			response.Users = append(response.Users, toUserResponse(user))
+		}
+		render.JSON(w, http.StatusOK, response)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.GetUser"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	userID, err := uuid.Parse(chi.URLParam(r, "user-id"))
+	if err != nil {
+		render.Error(w, http.StatusBadRequest,
+			errors.New("invalid user id"))
+		return
+	}
+
+	output, err := h.srv.GetUser(ctx, userID)
+
+	switch {
+	case err == nil:
+		response := &GetUserResponse{
+			UserResponse:  *toUserResponse(&output.UserOutput),
+			NotesCount:    output.NotesCount,
+			SessionsCount: output.SessionsCount,
+		}
+		if output.Block != nil {
+			response.Block = &BlockResponse{
+				Reason:    output.Block.Reason,
+				BlockedBy: output.Block.BlockedBy,
+				ExpiresAt: output.Block.ExpiresAt,
+				CreatedAt: output.Block.CreatedAt,
+			}
+		}
+		render.JSON(w, http.StatusOK, response)
+	case errors.Is(err, admin.ErrUserNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) SetRole(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.SetRole"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	userID, err := uuid.Parse(chi.URLParam(r, "user-id"))
+	if err != nil {
+		render.Error(w, http.StatusBadRequest,
+			errors.New("invalid user id"))
+		return
+	}
+
+	request := new(SetRoleRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	claims := security.GetClaims(ctx)
+	err = h.srv.SetRole(ctx, &admin.SetRoleInput{
+		AdminID: claims.UserID,
+		UserID:  userID,
+		Role:    request.Role,
+	})
+
+	switch {
+	case err == nil:
+		render.Empty(w)
+	case errors.Is(err, admin.ErrUserNotFound),
+		errors.Is(err, admin.ErrRoleNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, admin.ErrChangeOwnRole):
+		render.Error(w, http.StatusConflict, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) LogoutUser(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.LogoutUser"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	userID, err := uuid.Parse(chi.URLParam(r, "user-id"))
+	if err != nil {
+		render.Error(w, http.StatusBadRequest,
+			errors.New("invalid user id"))
+		return
+	}
+
+	err = h.srv.LogoutUser(ctx, userID)
+
+	switch {
+	case err == nil:
+		render.Empty(w)
+	case errors.Is(err, admin.ErrUserNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) ImpersonateUser(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.ImpersonateUser"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	userID, err := uuid.Parse(chi.URLParam(r, "user-id"))
+	if err != nil {
+		render.Error(w, http.StatusBadRequest,
+			errors.New("invalid user id"))
+		return
+	}
+
+	claims := security.GetClaims(ctx)
+	input := &admin.ImpersonateUserInput{
+		AdminID: claims.UserID,
+		UserID:  userID,
+	}
+
+	if r.UserAgent() != "" {
+		userAgent := r.UserAgent()
+		input.UserAgent = &userAgent
+	}
+
+	output, err := h.srv.ImpersonateUser(ctx, input)
+
+	switch {
+	case err == nil:
+		render.JSON(w, http.StatusOK, &ImpersonateUserResponse{
+			AccessToken: output.AccessToken,
+			ExpiresAt:   output.ExpiresAt,
+		})
+	case errors.Is(err, admin.ErrUserNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, admin.ErrImpersonateSelf),
+		errors.Is(err, admin.ErrUserNotActive):
+		render.Error(w, http.StatusConflict, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.GetStats"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	output, err := h.srv.GetStats(ctx)
+
+	switch { // nolint
+	case err == nil:
+		render.JSON(w, http.StatusOK, &GetStatsResponse{
+			Users: UserStatsResponse{
+				Total:   output.Users.Total,
+				Pending: output.Users.Pending,
+				Active:  output.Users.Active,
+				Blocked: output.Users.Blocked,
+				Deleted: output.Users.Deleted,
+				Admins:  output.Users.Admins,
+			},
+			NewUsersDay:    output.NewUsersDay,
+			NewUsersWeek:   output.NewUsersWeek,
+			Notes:          output.Notes,
+			ActiveSessions: output.ActiveSessions,
+		})
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func toUserResponse(user *admin.UserOutput) *UserResponse {
+	return &UserResponse{
+		ID:        user.ID,
+		Login:     user.Login,
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		Timezone:  user.Timezone,
+		Status:    user.Status,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+	}
+}
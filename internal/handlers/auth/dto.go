@@ -1,22 +1,150 @@
 package auth
 
+import (
+	"encoding/json"
+	"time"
+
+	"cloud-notes/internal/render"
+	"cloud-notes/internal/security"
+
+	"github.com/google/uuid"
+)
+
 type RegisterRequest struct {
 	Login     string `json:"login" validate:"required,min=6,max=32"`
-	Password  string `json:"password" validate:"required,min=8"`
+	Email     string `json:"email" validate:"required,email,max=254"`
+	Password  string `json:"password" validate:"required"`
 	FirstName string `json:"first_name" validate:"required,min=2,max=32"`
 	Timezone  string `json:"timezone" validate:"required"`
 }
 
+type PasswordViolationResponse struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type PasswordPolicyErrorResponse struct {
+	render.ErrorResponse
+	Violations []*PasswordViolationResponse `json:"violations"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Login string `json:"login" validate:"required"`
+}
+
 type LoginRequest struct {
 	Login    string `json:"login" validate:"required"`
 	Password string `json:"password" validate:"required"`
 }
 
 type LoginResponse struct {
-	AccessToken string `json:"access_token"`
+	AccessToken    string `json:"access_token,omitempty"`
+	ChallengeToken string `json:"challenge_token,omitempty"`
 }
 
 type ChangePasswordRequest struct {
-	OldPassword string `json:"old_password" validate:"required,min=8"`
-	NewPassword string `json:"new_password" validate:"required,min=8"`
+	OldPassword string `json:"old_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Login string `json:"login" validate:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+type EnrollTwoFactorResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+	QRCode []byte `json:"qr_code"`
+}
+
+type ConfirmTwoFactorRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type ConfirmTwoFactorResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type VerifyTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required_without=RecoveryCode"`
+	RecoveryCode   string `json:"recovery_code" validate:"required_without=Code"`
+}
+
+type BeginPasskeyResponse struct {
+	CeremonyID uuid.UUID `json:"ceremony_id"`
+	Options    any       `json:"options"`
+}
+
+type FinishPasskeyRegistrationRequest struct {
+	CeremonyID uuid.UUID       `json:"ceremony_id"`
+	Name       string          `json:"name" validate:"required,min=1,max=64"`
+	Credential json.RawMessage `json:"credential" validate:"required"`
+}
+
+type PasskeyResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type GetPasskeysResponse struct {
+	Passkeys []*PasskeyResponse `json:"passkeys"`
+}
+
+type FinishPasskeyLoginRequest struct {
+	CeremonyID uuid.UUID       `json:"ceremony_id"`
+	Credential json.RawMessage `json:"credential" validate:"required"`
+}
+
+type CreatePersonalTokenRequest struct {
+	Name      string     `json:"name" validate:"required,min=1,max=64"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=notes:read notes:write profile:read profile:write"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type PersonalTokenResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreatePersonalTokenResponse struct {
+	PersonalTokenResponse
+	Token string `json:"token"`
+}
+
+type GetPersonalTokensResponse struct {
+	PersonalTokens []*PersonalTokenResponse `json:"personal_tokens"`
+}
+
+type JWKSResponse struct {
+	Keys []*security.JWK `json:"keys"`
+}
+
+type BeginOIDCResponse struct {
+	URL   string    `json:"url"`
+	State uuid.UUID `json:"state"`
+}
+
+type FinishOIDCLoginRequest struct {
+	State uuid.UUID `json:"state"`
+	Code  string    `json:"code" validate:"required"`
 }
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"cloud-notes/internal/logger"
+	"cloud-notes/internal/password"
 	"cloud-notes/internal/render"
 	"cloud-notes/internal/security"
 	"cloud-notes/internal/services/auth"
@@ -45,16 +46,77 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 
 	err := h.srv.Register(ctx, &auth.RegisterInput{
 		Login:     request.Login,
+		Email:     request.Email,
 		Password:  request.Password,
 		FirstName: request.FirstName,
 		Timezone:  request.Timezone,
 	})
 
+	var policyErr *password.PolicyError
 	switch {
 	case err == nil:
 		render.Empty(w)
-	case errors.Is(err, auth.ErrLoginAlreadyExists):
+	case errors.Is(err, auth.ErrLoginAlreadyExists),
+		errors.Is(err, auth.ErrEmailAlreadyExists):
 		render.Error(w, http.StatusConflict, err)
+	case errors.As(err, &policyErr):
+		renderPolicyError(w, policyErr)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.auth.VerifyEmail"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	request := new(VerifyEmailRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	err := h.srv.VerifyEmail(ctx, request.Token)
+
+	switch {
+	case err == nil:
+		render.Empty(w)
+	case errors.Is(err, auth.ErrInvalidVerificationToken):
+		render.Error(w, http.StatusBadRequest, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.auth.ResendVerification"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	request := new(ResendVerificationRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	err := h.srv.ResendVerification(ctx, request.Login)
+
+	switch {
+	case err == nil:
+		render.Empty(w)
+	case errors.Is(err, auth.ErrTooManyVerificationMails):
+		render.Error(w, http.StatusTooManyRequests, err)
 	default:
 		render.ServerError(w, http.StatusInternalServerError)
 	}
@@ -90,12 +152,73 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	switch {
 	case err == nil:
 		render.JSON(w, http.StatusOK, LoginResponse{
-			AccessToken: output.AccessToken,
+			AccessToken:    output.AccessToken,
+			ChallengeToken: output.ChallengeToken,
 		})
 	case errors.Is(err, auth.ErrUserNotFound):
 		render.Error(w, http.StatusNotFound, err)
 	case errors.Is(err, auth.ErrInvalidPassword):
 		render.Error(w, http.StatusForbidden, err)
+	default:
+		renderStatusError(w, err)
+	}
+}
+
+func (h *Handler) RestoreAccount(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.auth.RestoreAccount"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	request := new(LoginRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	input := &auth.LoginInput{
+		Login:    request.Login,
+		Password: request.Password,
+	}
+	if r.UserAgent() != "" {
+		userAgent := r.UserAgent()
+		input.UserAgent = &userAgent
+	}
+
+	output, err := h.srv.RestoreAccount(ctx, input)
+
+	switch {
+	case err == nil:
+		render.JSON(w, http.StatusOK, LoginResponse{
+			AccessToken:    output.AccessToken,
+			ChallengeToken: output.ChallengeToken,
+		})
+	case errors.Is(err, auth.ErrUserNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, auth.ErrInvalidPassword):
+		render.Error(w, http.StatusForbidden, err)
+	case errors.Is(err, auth.ErrUserNotDeleted):
+		render.Error(w, http.StatusConflict, err)
+	default:
+		renderStatusError(w, err)
+	}
+}
+
+// renderStatusError renders errors of accounts that can't log in, falling
+// back to the internal server error.
+func renderStatusError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrEmailNotVerified):
+		render.CodeError(w, http.StatusForbidden,
+			render.CodeEmailNotVerified, err)
+	case errors.Is(err, auth.ErrUserBlocked):
+		render.CodeError(w, http.StatusForbidden, render.CodeUserBlocked, err)
+	case errors.Is(err, auth.ErrUserDeleted):
+		render.CodeError(w, http.StatusForbidden, render.CodeUserDeleted, err)
 	default:
 		render.ServerError(w, http.StatusInternalServerError)
 	}
@@ -139,12 +262,99 @@ func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 		NewPassword: request.NewPassword,
 	})
 
+	var policyErr *password.PolicyError
 	switch {
 	case err == nil:
 		render.Empty(w)
 	case errors.Is(err, auth.ErrInvalidPassword):
 		render.Error(w, http.StatusForbidden, err)
+	case errors.As(err, &policyErr):
+		renderPolicyError(w, policyErr)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.auth.ForgotPassword"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	request := new(ForgotPasswordRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	err := h.srv.ForgotPassword(ctx, request.Login)
+
+	switch {
+	case err == nil:
+		render.Empty(w)
+	case errors.Is(err, auth.ErrTooManyResetMails):
+		render.Error(w, http.StatusTooManyRequests, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.auth.ResetPassword"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	request := new(ResetPasswordRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	err := h.srv.ResetPassword(ctx, &auth.ResetPasswordInput{
+		Token:       request.Token,
+		NewPassword: request.NewPassword,
+	})
+
+	var policyErr *password.PolicyError
+	switch {
+	case err == nil:
+		render.Empty(w)
+	case errors.Is(err, auth.ErrInvalidResetToken):
+		render.Error(w, http.StatusBadRequest, err)
+	case errors.As(err, &policyErr):
+		renderPolicyError(w, policyErr)
 	default:
 		render.ServerError(w, http.StatusInternalServerError)
 	}
 }
+
+// renderPolicyError lists every broken password rule, so clients can show
+// them next to the field at once.
+func renderPolicyError(w http.ResponseWriter, err *password.PolicyError) {
+	response := &PasswordPolicyErrorResponse{
+		ErrorResponse: render.ErrorResponse{
+			Detail: err.Error(),
+			Code:   render.CodeWeakPassword,
+		},
+		Violations: make(
+			[]*PasswordViolationResponse, 0, len(err.Violations)),
+	}
+	for _, violation := range err.Violations {
+		response.Violations = append(response.Violations,
+			&PasswordViolationResponse{
+				Rule:    violation.Rule,
+				Message: violation.Message,
+			})
+	}
+
+	render.JSON(w, http.StatusUnprocessableEntity, response)
+}
@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"net/http"
+
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/render"
+)
+
+func (h *Handler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.auth.GetJWKS"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	output, err := h.srv.GetJWKS(ctx)
+
+	switch { // nolint
+	case err == nil:
+		// Verifiers may cache the keys for a while, a rotated key is
+		// published ahead of signing with it.
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		render.JSON(w, http.StatusOK, &JWKSResponse{
+			Keys: output.Keys,
+		})
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
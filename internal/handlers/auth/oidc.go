@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/render"
+	"cloud-notes/internal/services/auth"
+)
+
+func (h *Handler) BeginOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.auth.BeginOIDCLogin"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	output, err := h.srv.BeginOIDCLogin(ctx)
+
+	switch {
+	case err == nil:
+		render.JSON(w, http.StatusOK, &BeginOIDCResponse{
+			URL:   output.URL,
+			State: output.State,
+		})
+	case errors.Is(err, auth.ErrOIDCDisabled):
+		render.Error(w, http.StatusNotFound, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) FinishOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.auth.FinishOIDCLogin"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	request := new(FinishOIDCLoginRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	input := &auth.FinishOIDCLoginInput{
+		State: request.State,
+		Code:  request.Code,
+	}
+	if r.UserAgent() != "" {
+		userAgent := r.UserAgent()
+		input.UserAgent = &userAgent
+	}
+
+	output, err := h.srv.FinishOIDCLogin(ctx, input)
+
+	switch {
+	case err == nil:
+		render.JSON(w, http.StatusOK, LoginResponse{
+			AccessToken:    output.AccessToken,
+			ChallengeToken: output.ChallengeToken,
+		})
+	case errors.Is(err, auth.ErrOIDCDisabled):
+		render.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, auth.ErrInvalidCeremony):
+		render.Error(w, http.StatusBadRequest, err)
+	case errors.Is(err, auth.ErrInvalidOIDCCode),
+		errors.Is(err, auth.ErrOIDCEmailNotVerified):
+		render.Error(w, http.StatusForbidden, err)
+	case errors.Is(err, auth.ErrOIDCLoginTaken):
+		render.Error(w, http.StatusConflict, err)
+	default:
+		renderStatusError(w, err)
+	}
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/render"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/services/auth"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) BeginPasskeyRegistration(
+	w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.auth.BeginPasskeyRegistration"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	claims := security.GetClaims(ctx)
+	output, err := h.srv.BeginPasskeyRegistration(ctx, claims.UserID)
+
+	switch {
+	case err == nil:
+		render.JSON(w, http.StatusOK, &BeginPasskeyResponse{
+			CeremonyID: output.CeremonyID,
+			Options:    output.Options,
+		})
+	case errors.Is(err, auth.ErrUserNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) FinishPasskeyRegistration(
+	w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.auth.FinishPasskeyRegistration"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	request := new(FinishPasskeyRegistrationRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	claims := security.GetClaims(ctx)
+	output, err := h.srv.FinishPasskeyRegistration(ctx,
+		&auth.FinishPasskeyRegistrationInput{
+			UserID:     claims.UserID,
+			CeremonyID: request.CeremonyID,
+			Name:       request.Name,
+			Credential: request.Credential,
+		})
+
+	switch {
+	case err == nil:
+		render.JSON(w, http.StatusOK, &PasskeyResponse{
+			ID:         output.ID,
+			Name:       output.Name,
+			LastUsedAt: output.LastUsedAt,
+			CreatedAt:  output.CreatedAt,
+		})
+	case errors.Is(err, auth.ErrInvalidCeremony),
+		errors.Is(err, auth.ErrInvalidCredential):
+		render.Error(w, http.StatusBadRequest, err)
+	case errors.Is(err, auth.ErrUserNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) GetPasskeys(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.auth.GetPasskeys"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	claims := security.GetClaims(ctx)
+	output, err := h.srv.GetPasskeys(ctx, claims.UserID)
+
+	switch { // nolint
+	case err == nil:
+		response := &GetPasskeysResponse{
+			Passkeys: make([]*PasskeyResponse, 0, len(output.Passkeys)),
+		}
+		for _, passkey := range output.Passkeys {
+			response.Passkeys = append(response.Passkeys, &PasskeyResponse{
+				ID:         passkey.ID,
+				Name:       passkey.Name,
+				LastUsedAt: passkey.LastUsedAt,
+				CreatedAt:  passkey.CreatedAt,
+			})
+		}
+		render.JSON(w, http.StatusOK, response)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) DeletePasskey(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.auth.DeletePasskey"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	passkeyID, err := uuid.Parse(chi.URLParam(r, "passkey-id"))
+	if err != nil {
+		render.Error(w, http.StatusBadRequest,
+			errors.New("invalid passkey id"))
+		return
+	}
+
+	claims := security.GetClaims(ctx)
+	err = h.srv.DeletePasskey(ctx, &auth.DeletePasskeyInput{
+		UserID:    claims.UserID,
+		PasskeyID: passkeyID,
+	})
+
+	switch {
+	case err == nil:
+		render.Empty(w)
+	case errors.Is(err, auth.ErrPasskeyNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) BeginPasskeyLogin(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.auth.BeginPasskeyLogin"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	output, err := h.srv.BeginPasskeyLogin(ctx)
+
+	switch { // nolint
+	case err == nil:
+		render.JSON(w, http.StatusOK, &BeginPasskeyResponse{
+			CeremonyID: output.CeremonyID,
+			Options:    output.Options,
+		})
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) FinishPasskeyLogin(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.auth.FinishPasskeyLogin"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	request := new(FinishPasskeyLoginRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	input := &auth.FinishPasskeyLoginInput{
+		CeremonyID: request.CeremonyID,
+		Credential: request.Credential,
+	}
+	if r.UserAgent() != "" {
+		userAgent := r.UserAgent()
+		input.UserAgent = &userAgent
+	}
+
+	output, err := h.srv.FinishPasskeyLogin(ctx, input)
+
+	switch {
+	case err == nil:
+		render.JSON(w, http.StatusOK, LoginResponse{
+			AccessToken: output.AccessToken,
+		})
+	case errors.Is(err, auth.ErrInvalidCeremony):
+		render.Error(w, http.StatusBadRequest, err)
+	case errors.Is(err, auth.ErrInvalidCredential),
+		errors.Is(err, auth.ErrPasskeyCloned):
+		render.Error(w, http.StatusForbidden, err)
+	default:
+		renderStatusError(w, err)
+	}
+}
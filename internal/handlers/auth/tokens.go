@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/render"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/services/auth"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) CreatePersonalToken(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.auth.CreatePersonalToken"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	request := new(CreatePersonalTokenRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	claims := security.GetClaims(ctx)
+	output, err := h.srv.CreatePersonalToken(ctx,
+		&auth.CreatePersonalTokenInput{
+			UserID:    claims.UserID,
+			Name:      request.Name,
+			Scopes:    request.Scopes,
+			ExpiresAt: request.ExpiresAt,
+		})
+
+	switch {
+	case err == nil:
+		render.JSON(w, http.StatusCreated, &CreatePersonalTokenResponse{
+			PersonalTokenResponse: *toPersonalTokenResponse(
+				&output.PersonalTokenOutput),
+			Token: output.Token,
+		})
+	case errors.Is(err, auth.ErrInvalidExpiry):
+		render.Error(w, http.StatusUnprocessableEntity, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) GetPersonalTokens(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.auth.GetPersonalTokens"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	claims := security.GetClaims(ctx)
+	output, err := h.srv.GetPersonalTokens(ctx, claims.UserID)
+
+	switch { // nolint
+	case err == nil:
+		response := &GetPersonalTokensResponse{
+			PersonalTokens: make(
+				[]*PersonalTokenResponse, 0, len(output.PersonalTokens)),
+		}
+		for _, personalToken := range output.PersonalTokens {
+			response.PersonalTokens = append(response.PersonalTokens,
+				toPersonalTokenResponse(personalToken))
+		}
+		render.JSON(w, http.StatusOK, response)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) RevokePersonalToken(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.auth.RevokePersonalToken"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	personalTokenID, err := uuid.Parse(chi.URLParam(r, "token-id"))
+	if err != nil {
+		render.Error(w, http.StatusBadRequest,
+			errors.New("invalid token id"))
+		return
+	}
+
+	claims := security.GetClaims(ctx)
+	err = h.srv.RevokePersonalToken(ctx, &auth.RevokePersonalTokenInput{
+		UserID:          claims.UserID,
+		PersonalTokenID: personalTokenID,
+	})
+
+	switch {
+	case err == nil:
+		render.Empty(w)
+	case errors.Is(err, auth.ErrPersonalTokenNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func toPersonalTokenResponse(
+	output *auth.PersonalTokenOutput) *PersonalTokenResponse {
+	return &PersonalTokenResponse{
+		ID:         output.ID,
+		Name:       output.Name,
+		Scopes:     output.Scopes,
+		LastUsedAt: output.LastUsedAt,
+		ExpiresAt:  output.ExpiresAt,
+		CreatedAt:  output.CreatedAt,
+	}
+}
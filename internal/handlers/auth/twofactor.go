@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/render"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/services/auth"
+)
+
+func (h *Handler) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.auth.EnrollTwoFactor"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	claims := security.GetClaims(ctx)
+	output, err := h.srv.EnrollTwoFactor(ctx, claims.UserID)
+
+	switch {
+	case err == nil:
+		render.JSON(w, http.StatusOK, &EnrollTwoFactorResponse{
+			Secret: output.Secret,
+			URI:    output.URI,
+			QRCode: output.QRCode,
+		})
+	case errors.Is(err, auth.ErrUserNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, auth.ErrTwoFactorAlreadyEnabled):
+		render.Error(w, http.StatusConflict, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.auth.ConfirmTwoFactor"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	request := new(ConfirmTwoFactorRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	claims := security.GetClaims(ctx)
+	output, err := h.srv.ConfirmTwoFactor(ctx, &auth.ConfirmTwoFactorInput{
+		UserID: claims.UserID,
+		Code:   request.Code,
+	})
+
+	switch {
+	case err == nil:
+		render.JSON(w, http.StatusOK, &ConfirmTwoFactorResponse{
+			RecoveryCodes: output.RecoveryCodes,
+		})
+	case errors.Is(err, auth.ErrTwoFactorNotEnrolled):
+		render.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, auth.ErrTwoFactorAlreadyEnabled):
+		render.Error(w, http.StatusConflict, err)
+	case errors.Is(err, auth.ErrInvalidCode):
+		render.Error(w, http.StatusForbidden, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.auth.DisableTwoFactor"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	request := new(DisableTwoFactorRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	claims := security.GetClaims(ctx)
+	err := h.srv.DisableTwoFactor(ctx, &auth.DisableTwoFactorInput{
+		UserID:   claims.UserID,
+		Password: request.Password,
+	})
+
+	switch {
+	case err == nil:
+		render.Empty(w)
+	case errors.Is(err, auth.ErrInvalidPassword):
+		render.Error(w, http.StatusForbidden, err)
+	case errors.Is(err, auth.ErrTwoFactorNotEnabled):
+		render.Error(w, http.StatusNotFound, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.auth.VerifyTwoFactor"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	request := new(VerifyTwoFactorRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	input := &auth.VerifyTwoFactorInput{
+		ChallengeToken: request.ChallengeToken,
+		Code:           request.Code,
+		RecoveryCode:   request.RecoveryCode,
+	}
+	if r.UserAgent() != "" {
+		userAgent := r.UserAgent()
+		input.UserAgent = &userAgent
+	}
+
+	output, err := h.srv.VerifyTwoFactor(ctx, input)
+
+	switch {
+	case err == nil:
+		render.JSON(w, http.StatusOK, LoginResponse{
+			AccessToken: output.AccessToken,
+		})
+	case errors.Is(err, auth.ErrInvalidChallenge):
+		render.Error(w, http.StatusUnauthorized, err)
+	case errors.Is(err, auth.ErrInvalidCode):
+		render.Error(w, http.StatusForbidden, err)
+	case errors.Is(err, auth.ErrTooManyCodeAttempts):
+		render.Error(w, http.StatusTooManyRequests, err)
+	default:
+		renderStatusError(w, err)
+	}
+}
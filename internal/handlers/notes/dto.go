@@ -7,17 +7,19 @@ import (
 )
 
 type NoteResponse struct {
-	ID        uuid.UUID  `json:"id"`
-	Title     *string    `json:"title"`
-	Text      *string    `json:"text"`
-	Pinned    bool       `json:"pinned"`
-	UpdatedAt *time.Time `json:"updated_at"`
-	CreatedAt time.Time  `json:"created_at"`
+	ID          uuid.UUID  `json:"id"`
+	WorkspaceID *uuid.UUID `json:"workspace_id,omitempty"`
+	AuthorID    uuid.UUID  `json:"author_id"`
+	Title       *string    `json:"title"`
+	Text        *string    `json:"text"`
+	Pinned      bool       `json:"pinned"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+	CreatedAt   time.Time  `json:"created_at"`
 }
 
 type CreateNoteRequest struct {
 	Title  *string `json:"title" validate:"required,min=1,max=1000"`
-	Text   *string `json:"text" validate:"required,min=1,max=10000"`
+	Text   *string `json:"text" validate:"required,min=1"`
 	Pinned bool    `json:"pinned"`
 }
 
@@ -27,6 +29,6 @@ type GetNotesResponse struct {
 
 type UpdateNoteRequest struct {
 	Title  *string `json:"title" validate:"required,min=1,max=1000"`
-	Text   *string `json:"text" validate:"required,min=1,max=10000"`
+	Text   *string `json:"text" validate:"required,min=1"`
 	Pinned bool    `json:"pinned"`
 }
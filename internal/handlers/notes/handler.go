@@ -1,6 +1,7 @@
 package notes
 
 import (
+	"cloud-notes/internal/handlers/query"
 	"cloud-notes/internal/logger"
 	"cloud-notes/internal/render"
 	"cloud-notes/internal/security"
@@ -14,6 +15,10 @@ import (
 	"github.com/google/uuid"
 )
 
+// WorkspaceHeader switches note requests from the personal notes to the
+// notes of a workspace.
+const WorkspaceHeader = "X-Workspace-ID"
+
 type Handler struct {
 	log logger.Logger
 	srv notes.Service
@@ -33,6 +38,12 @@ func (h *Handler) CreateNote(w http.ResponseWriter, r *http.Request) {
 	_ = h.log.With(logger.String("op", op))
 	ctx := r.Context()
 
+	space, err := getSpace(r)
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
 	request := new(CreateNoteRequest)
 	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
 		render.InvalidJSONError(w)
@@ -44,24 +55,29 @@ func (h *Handler) CreateNote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	claims := security.GetClaims(ctx)
 	output, err := h.srv.CreateNote(ctx, &notes.CreateNoteInput{
-		UserID: claims.UserID,
+		Space:  *space,
 		Title:  request.Title,
 		Text:   request.Text,
 		Pinned: request.Pinned,
 	})
 
-	switch { // nolint
+	switch {
 	case err == nil:
-		render.JSON(w, http.StatusOK, &NoteResponse{
-			ID:        output.ID,
-			Title:     output.Title,
-			Text:      output.Text,
-			Pinned:    output.Pinned,
-			UpdatedAt: output.UpdatedAt,
-			CreatedAt: output.CreatedAt,
-		})
+		render.JSON(w, http.StatusOK, toNoteResponse(output))
+	case errors.Is(err, notes.ErrWorkspaceNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, notes.ErrReadOnly):
+		render.Error(w, http.StatusForbidden, err)
+	case errors.Is(err, notes.ErrNoteTooLarge):
+		render.CodeError(w, http.StatusRequestEntityTooLarge,
+			render.CodeNoteTooLarge, err)
+	case errors.Is(err, notes.ErrNotesLimit):
+		render.CodeError(w, http.StatusForbidden,
+			render.CodeNotesLimitExceeded, err)
+	case errors.Is(err, notes.ErrStorageLimit):
+		render.CodeError(w, http.StatusForbidden,
+			render.CodeStorageLimitExceeded, err)
 	default:
 		render.ServerError(w, http.StatusInternalServerError)
 	}
@@ -72,23 +88,26 @@ func (h *Handler) GetNotes(w http.ResponseWriter, r *http.Request) {
 	_ = h.log.With(logger.String("op", op))
 	ctx := r.Context()
 
-	claims := security.GetClaims(ctx)
-	output, err := h.srv.GetNotes(ctx, claims.UserID)
+	space, err := getSpace(r)
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	output, err := h.srv.GetNotes(ctx, &notes.GetNotesInput{
+		Space: *space,
+		Query: query.String(r, "query"),
+	})
 
-	switch { // nolint
+	switch {
 	case err == nil:
 		response := new(GetNotesResponse)
 		for _, note := range output.Notes {
-			response.Notes = append(response.Notes, &NoteResponse{
-				ID:        note.ID,
-				Title:     note.Title,
-				Text:      note.Text,
-				Pinned:    note.Pinned,
-				UpdatedAt: note.UpdatedAt,
-				CreatedAt: note.CreatedAt,
-			})
+			response.Notes = append(response.Notes, toNoteResponse(note))
 		}
 		render.JSON(w, http.StatusOK, response)
+	case errors.Is(err, notes.ErrWorkspaceNotFound):
+		render.Error(w, http.StatusNotFound, err)
 	default:
 		render.ServerError(w, http.StatusInternalServerError)
 	}
@@ -106,6 +125,12 @@ func (h *Handler) UpdateNote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	space, err := getSpace(r)
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
 	request := new(UpdateNoteRequest)
 	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
 		render.InvalidJSONError(w)
@@ -117,27 +142,28 @@ func (h *Handler) UpdateNote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	claims := security.GetClaims(ctx)
 	output, err := h.srv.UpdateNote(ctx, &notes.UpdateNoteInput{
-		UserID: claims.UserID,
+		Space:  *space,
 		NoteID: noteID,
 		Title:  request.Title,
 		Text:   request.Text,
 		Pinned: request.Pinned,
 	})
 
-	switch { // nolint
+	switch {
 	case err == nil:
-		render.JSON(w, http.StatusOK, &NoteResponse{
-			ID:        output.ID,
-			Title:     output.Title,
-			Text:      output.Text,
-			Pinned:    output.Pinned,
-			UpdatedAt: output.UpdatedAt,
-			CreatedAt: output.CreatedAt,
-		})
-	case errors.Is(err, notes.ErrNoteNotFound):
+		render.JSON(w, http.StatusOK, toNoteResponse(output))
+	case errors.Is(err, notes.ErrNoteNotFound),
+		errors.Is(err, notes.ErrWorkspaceNotFound):
 		render.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, notes.ErrReadOnly):
+		render.Error(w, http.StatusForbidden, err)
+	case errors.Is(err, notes.ErrNoteTooLarge):
+		render.CodeError(w, http.StatusRequestEntityTooLarge,
+			render.CodeNoteTooLarge, err)
+	case errors.Is(err, notes.ErrStorageLimit):
+		render.CodeError(w, http.StatusForbidden,
+			render.CodeStorageLimitExceeded, err)
 	default:
 		render.ServerError(w, http.StatusInternalServerError)
 	}
@@ -155,18 +181,59 @@ func (h *Handler) DeleteNote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	claims := security.GetClaims(ctx)
+	space, err := getSpace(r)
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
 	err = h.srv.DeleteNote(ctx, &notes.DeleteNoteInput{
-		UserID: claims.UserID,
+		Space:  *space,
 		NoteID: noteID,
 	})
 
-	switch { // nolint
+	switch {
 	case err == nil:
 		render.Empty(w)
-	case errors.Is(err, notes.ErrNoteNotFound):
+	case errors.Is(err, notes.ErrNoteNotFound),
+		errors.Is(err, notes.ErrWorkspaceNotFound):
 		render.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, notes.ErrReadOnly):
+		render.Error(w, http.StatusForbidden, err)
 	default:
 		render.ServerError(w, http.StatusInternalServerError)
 	}
 }
+
+func getSpace(r *http.Request) (*notes.Space, error) {
+	claims := security.GetClaims(r.Context())
+	space := &notes.Space{
+		UserID: claims.UserID,
+	}
+
+	header := r.Header.Get(WorkspaceHeader)
+	if header == "" {
+		return space, nil
+	}
+
+	workspaceID, err := uuid.Parse(header)
+	if err != nil {
+		return nil, errors.New("invalid workspace id")
+	}
+
+	space.WorkspaceID = &workspaceID
+	return space, nil
+}
+
+func toNoteResponse(output *notes.NoteOutput) *NoteResponse {
+	return &NoteResponse{
+		ID:          output.ID,
+		WorkspaceID: output.WorkspaceID,
+		AuthorID:    output.AuthorID,
+		Title:       output.Title,
+		Text:        output.Text,
+		Pinned:      output.Pinned,
+		UpdatedAt:   output.UpdatedAt,
+		CreatedAt:   output.CreatedAt,
+	}
+}
@@ -0,0 +1,119 @@
+package oauth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/render"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/services/oauth"
+
+	"github.com/google/uuid"
+)
+
+// GetAuthorization checks the authorization request the client sent the
+// user with and returns what the consent screen has to show.
+func (h *Handler) GetAuthorization(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.oauth.GetAuthorization"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	query := r.URL.Query()
+	request := &AuthorizeRequest{
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		ResponseType:        query.Get("response_type"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	claims := security.GetClaims(ctx)
+	output, err := h.srv.GetAuthorization(ctx,
+		toAuthorizeInput(claims, request))
+
+	switch {
+	case err == nil:
+		render.JSON(w, http.StatusOK, &GetAuthorizationResponse{
+			ClientID:    output.ClientID,
+			ClientName:  output.ClientName,
+			RedirectURI: output.RedirectURI,
+			Scopes:      output.Scopes,
+		})
+	default:
+		renderAuthorizeError(w, err)
+	}
+}
+
+func (h *Handler) ApproveAuthorization(
+	w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.oauth.ApproveAuthorization"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	request := new(ApproveAuthorizationRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	claims := security.GetClaims(ctx)
+	output, err := h.srv.ApproveAuthorization(ctx,
+		&oauth.ApproveAuthorizationInput{
+			AuthorizeInput: *toAuthorizeInput(
+				claims, &request.AuthorizeRequest),
+			Approved: request.Approved,
+		})
+
+	switch {
+	case err == nil:
+		render.JSON(w, http.StatusOK, &ApproveAuthorizationResponse{
+			RedirectURL: output.RedirectURL,
+		})
+	default:
+		renderAuthorizeError(w, err)
+	}
+}
+
+// renderAuthorizeError never redirects, the redirect URI can't be trusted
+// until the request has been checked.
+func renderAuthorizeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, oauth.ErrClientNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, oauth.ErrInvalidRedirectURI),
+		errors.Is(err, oauth.ErrUnsupportedResponseType),
+		errors.Is(err, oauth.ErrInvalidCodeChallenge),
+		errors.Is(err, oauth.ErrInvalidScope):
+		render.Error(w, http.StatusUnprocessableEntity, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func toAuthorizeInput(claims *security.Claims,
+	request *AuthorizeRequest) *oauth.AuthorizeInput {
+	return &oauth.AuthorizeInput{
+		UserID:              claims.UserID,
+		ClientID:            uuid.MustParse(request.ClientID),
+		RedirectURI:         request.RedirectURI,
+		ResponseType:        request.ResponseType,
+		Scope:               request.Scope,
+		State:               request.State,
+		CodeChallenge:       request.CodeChallenge,
+		CodeChallengeMethod: request.CodeChallengeMethod,
+	}
+}
@@ -0,0 +1,113 @@
+package oauth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/render"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/services/oauth"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) CreateClient(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.oauth.CreateClient"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	request := new(CreateClientRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	claims := security.GetClaims(ctx)
+	output, err := h.srv.CreateClient(ctx, &oauth.CreateClientInput{
+		OwnerID:      claims.UserID,
+		Name:         request.Name,
+		RedirectURIs: request.RedirectURIs,
+		Scopes:       request.Scopes,
+		Confidential: request.Confidential,
+	})
+
+	switch { // nolint
+	case err == nil:
+		render.JSON(w, http.StatusCreated, &CreateClientResponse{
+			ClientResponse: *toClientResponse(&output.ClientOutput),
+			Secret:         output.Secret,
+		})
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) GetClients(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.oauth.GetClients"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	claims := security.GetClaims(ctx)
+	output, err := h.srv.GetClients(ctx, claims.UserID)
+
+	switch { // nolint
+	case err == nil:
+		response := &GetClientsResponse{
+			Clients: make([]*ClientResponse, 0, len(output.Clients)),
+		}
+		for _, client := range output.Clients {
+			response.Clients = append(response.Clients,
+				toClientResponse(client))
+		}
+		render.JSON(w, http.StatusOK, response)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) DeleteClient(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.oauth.DeleteClient"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	clientID, err := uuid.Parse(chi.URLParam(r, "client-id"))
+	if err != nil {
+		render.Error(w, http.StatusBadRequest,
+			errors.New("invalid client id"))
+		return
+	}
+
+	claims := security.GetClaims(ctx)
+	err = h.srv.DeleteClient(ctx, &oauth.DeleteClientInput{
+		OwnerID:  claims.UserID,
+		ClientID: clientID,
+	})
+
+	switch {
+	case err == nil:
+		render.Empty(w)
+	case errors.Is(err, oauth.ErrClientNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func toClientResponse(output *oauth.ClientOutput) *ClientResponse {
+	return &ClientResponse{
+		ID:           output.ID,
+		Name:         output.Name,
+		RedirectURIs: output.RedirectURIs,
+		Scopes:       output.Scopes,
+		Confidential: output.Confidential,
+		CreatedAt:    output.CreatedAt,
+	}
+}
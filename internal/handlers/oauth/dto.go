@@ -0,0 +1,82 @@
+package oauth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateClientRequest struct {
+	Name         string   `json:"name"          validate:"required,min=1,max=64"`
+	RedirectURIs []string `json:"redirect_uris" validate:"required,min=1,max=10,dive,url"`
+	Scopes       []string `json:"scopes"        validate:"required,min=1,dive,oneof=notes:read notes:write profile:read profile:write"`
+	Confidential bool     `json:"confidential"`
+}
+
+type ClientResponse struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+	Confidential bool      `json:"confidential"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type CreateClientResponse struct {
+	ClientResponse
+	Secret *string `json:"secret,omitempty"`
+}
+
+type GetClientsResponse struct {
+	Clients []*ClientResponse `json:"clients"`
+}
+
+type AuthorizeRequest struct {
+	ClientID            string `json:"client_id"             validate:"required,uuid"`
+	RedirectURI         string `json:"redirect_uri"`
+	ResponseType        string `json:"response_type"         validate:"required"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"                 validate:"max=512"`
+	CodeChallenge       string `json:"code_challenge"        validate:"required"`
+	CodeChallengeMethod string `json:"code_challenge_method" validate:"required"`
+}
+
+type ApproveAuthorizationRequest struct {
+	AuthorizeRequest
+	Approved bool `json:"approved"`
+}
+
+type GetAuthorizationResponse struct {
+	ClientID    uuid.UUID `json:"client_id"`
+	ClientName  string    `json:"client_name"`
+	RedirectURI string    `json:"redirect_uri"`
+	Scopes      []string  `json:"scopes"`
+}
+
+type ApproveAuthorizationResponse struct {
+	RedirectURL string `json:"redirect_url"`
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+type IntrospectResponse struct {
+	Active    bool       `json:"active"`
+	Scope     string     `json:"scope,omitempty"`
+	ClientID  *uuid.UUID `json:"client_id,omitempty"`
+	Subject   *uuid.UUID `json:"sub,omitempty"`
+	TokenType string     `json:"token_type,omitempty"`
+	ExpiresAt *int64     `json:"exp,omitempty"`
+}
+
+// ErrorResponse follows RFC 6749, so standard OAuth client libraries can
+// read the token endpoint errors.
+type ErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
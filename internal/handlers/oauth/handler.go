@@ -0,0 +1,34 @@
+package oauth
+
+import (
+	"net/http"
+
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/render"
+	"cloud-notes/internal/services/oauth"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type Handler struct {
+	log logger.Logger
+	srv oauth.Service
+	val *validator.Validate
+}
+
+func New(log logger.Logger, srv oauth.Service) Handler {
+	return Handler{
+		log: log,
+		srv: srv,
+		val: validator.New(),
+	}
+}
+
+func renderOAuthError(w http.ResponseWriter, statusCode int, code string,
+	err error) {
+	w.Header().Set("Cache-Control", "no-store")
+	render.JSON(w, statusCode, &ErrorResponse{
+		Error:            code,
+		ErrorDescription: err.Error(),
+	})
+}
@@ -0,0 +1,157 @@
+package oauth
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/render"
+	"cloud-notes/internal/services/oauth"
+)
+
+func (h *Handler) Token(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.oauth.Token"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	if err := r.ParseForm(); err != nil {
+		renderOAuthError(w, http.StatusBadRequest, "invalid_request",
+			oauth.ErrInvalidRequest)
+		return
+	}
+
+	input := &oauth.TokenInput{
+		ClientCredentials: clientCredentials(r),
+		GrantType:         r.PostForm.Get("grant_type"),
+		Code:              r.PostForm.Get("code"),
+		RedirectURI:       r.PostForm.Get("redirect_uri"),
+		CodeVerifier:      r.PostForm.Get("code_verifier"),
+		RefreshToken:      r.PostForm.Get("refresh_token"),
+		Scope:             r.PostForm.Get("scope"),
+	}
+	if r.UserAgent() != "" {
+		userAgent := r.UserAgent()
+		input.UserAgent = &userAgent
+	}
+
+	output, err := h.srv.Token(ctx, input)
+
+	switch {
+	case err == nil:
+		w.Header().Set("Cache-Control", "no-store")
+		render.JSON(w, http.StatusOK, &TokenResponse{
+			AccessToken:  output.AccessToken,
+			TokenType:    output.TokenType,
+			ExpiresIn:    output.ExpiresIn,
+			RefreshToken: output.RefreshToken,
+			Scope:        strings.Join(output.Scopes, " "),
+		})
+	case errors.Is(err, oauth.ErrUnsupportedGrantType):
+		renderOAuthError(w, http.StatusBadRequest,
+			"unsupported_grant_type", err)
+	case errors.Is(err, oauth.ErrInvalidGrant):
+		renderOAuthError(w, http.StatusBadRequest, "invalid_grant", err)
+	case errors.Is(err, oauth.ErrInvalidScope):
+		renderOAuthError(w, http.StatusBadRequest, "invalid_scope", err)
+	default:
+		renderClientError(w, err)
+	}
+}
+
+func (h *Handler) Introspect(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.oauth.Introspect"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	if err := r.ParseForm(); err != nil {
+		renderOAuthError(w, http.StatusBadRequest, "invalid_request",
+			oauth.ErrInvalidRequest)
+		return
+	}
+
+	output, err := h.srv.Introspect(ctx, &oauth.IntrospectInput{
+		ClientCredentials: clientCredentials(r),
+		Token:             r.PostForm.Get("token"),
+	})
+
+	switch {
+	case err == nil:
+		response := &IntrospectResponse{
+			Active:    output.Active,
+			Scope:     strings.Join(output.Scopes, " "),
+			ClientID:  output.ClientID,
+			Subject:   output.UserID,
+			TokenType: output.TokenType,
+		}
+		if output.ExpiresAt != nil {
+			exp := output.ExpiresAt.Unix()
+			response.ExpiresAt = &exp
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		render.JSON(w, http.StatusOK, response)
+	default:
+		renderClientError(w, err)
+	}
+}
+
+func (h *Handler) Revoke(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.oauth.Revoke"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	if err := r.ParseForm(); err != nil {
+		renderOAuthError(w, http.StatusBadRequest, "invalid_request",
+			oauth.ErrInvalidRequest)
+		return
+	}
+
+	err := h.srv.Revoke(ctx, &oauth.RevokeInput{
+		ClientCredentials: clientCredentials(r),
+		Token:             r.PostForm.Get("token"),
+	})
+
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusOK)
+	default:
+		renderClientError(w, err)
+	}
+}
+
+// renderClientError renders the errors shared by all client endpoints.
+func renderClientError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, oauth.ErrInvalidRequest):
+		renderOAuthError(w, http.StatusBadRequest, "invalid_request", err)
+	case errors.Is(err, oauth.ErrInvalidClient):
+		w.Header().Set("WWW-Authenticate", `Basic realm="cloud-notes"`)
+		renderOAuthError(w, http.StatusUnauthorized, "invalid_client", err)
+	default:
+		renderOAuthError(w, http.StatusInternalServerError, "server_error",
+			errors.New(strings.ToLower(
+				http.StatusText(http.StatusInternalServerError))))
+	}
+}
+
+// clientCredentials reads the client from HTTP Basic authentication or,
+// when there is none, from the form. Basic credentials are form encoded
+// as RFC 6749 requires.
+func clientCredentials(r *http.Request) oauth.ClientCredentials {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		return oauth.ClientCredentials{
+			ClientID:     r.PostForm.Get("client_id"),
+			ClientSecret: r.PostForm.Get("client_secret"),
+		}
+	}
+
+	clientID, _ = url.QueryUnescape(clientID)
+	clientSecret, _ = url.QueryUnescape(clientSecret)
+
+	return oauth.ClientCredentials{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+	}
+}
@@ -0,0 +1,65 @@
+package query
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The helpers below return nil for a missing parameter, so the result can
+// be passed straight into optional service inputs.
+
+func String(r *http.Request, name string) *string {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil
+	}
+
+	return &value
+}
+
+func Uint64(r *http.Request, name string) (*uint64, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s", name)
+	}
+
+	return &parsed, nil
+}
+
+func UUID(r *http.Request, name string) (*uuid.UUID, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := uuid.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s", name)
+	}
+
+	return &parsed, nil
+}
+
+// Time expects RFC 3339, the format the API renders timestamps in.
+func Time(r *http.Request, name string) (*time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s", name)
+	}
+
+	return &parsed, nil
+}
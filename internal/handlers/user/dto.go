@@ -2,10 +2,13 @@ package user
 
 import (
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type GetProfileResponse struct {
 	Login     string    `json:"login"`
+	Email     *string   `json:"email"`
 	FirstName string    `json:"first_name"`
 	Timezone  string    `json:"timezone"`
 	CreatedAt time.Time `json:"created_at"`
@@ -15,3 +18,35 @@ type UpdateProfileRequest struct {
 	FirstName string `json:"first_name"`
 	Timezone  string `json:"timezone"`
 }
+
+type ExportResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Status      string     `json:"status"`
+	DownloadURL *string    `json:"download_url,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type SecurityEventResponse struct {
+	ID        uuid.UUID         `json:"id"`
+	Type      string            `json:"type"`
+	ByAdmin   bool              `json:"by_admin"`
+	IP        *string           `json:"ip,omitempty"`
+	UserAgent *string           `json:"user_agent,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type GetSecurityEventsResponse struct {
+	Events []*SecurityEventResponse `json:"events"`
+}
+
+type UsageResponse struct {
+	Plan          string `json:"plan"`
+	Notes         int64  `json:"notes"`
+	MaxNotes      int64  `json:"max_notes"`
+	Bytes         int64  `json:"bytes"`
+	MaxTotalBytes int64  `json:"max_total_bytes"`
+	MaxNoteBytes  int64  `json:"max_note_bytes"`
+}
@@ -0,0 +1,58 @@
+package user
+
+import (
+	"net/http"
+
+	"cloud-notes/internal/handlers/query"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/render"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/services/user"
+)
+
+func (h *Handler) GetSecurityEvents(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.user.GetSecurityEvents"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	limit, err := query.Uint64(r, "limit")
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	offset, err := query.Uint64(r, "offset")
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	claims := security.GetClaims(ctx)
+	output, err := h.srv.GetSecurityEvents(ctx, &user.GetSecurityEventsInput{
+		UserID: claims.UserID,
+		Limit:  limit,
+		Offset: offset,
+	})
+
+	switch { // nolint
+	case err == nil:
+		response := &GetSecurityEventsResponse{
+			Events: []*SecurityEventResponse{},
+		}
+		for _, event := range output.Events {
+			response.Events = append(response.Events,
+				&SecurityEventResponse{
+					ID:        event.ID,
+					Type:      event.Type,
+					ByAdmin:   event.ByAdmin,
+					IP:        event.IP,
+					UserAgent: event.UserAgent,
+					Details:   event.Details,
+					CreatedAt: event.CreatedAt,
+				})
+		}
+		render.JSON(w, http.StatusOK, response)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
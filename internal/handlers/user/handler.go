@@ -2,14 +2,19 @@ package user
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"cloud-notes/internal/logger"
 	"cloud-notes/internal/render"
 	"cloud-notes/internal/security"
 	"cloud-notes/internal/services/user"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 )
 
 type Handler struct {
@@ -38,6 +43,7 @@ func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	case err == nil:
 		render.JSON(w, http.StatusOK, &GetProfileResponse{
 			Login:     output.Login,
+			Email:     output.Email,
 			FirstName: output.FirstName,
 			Timezone:  output.Timezone,
 			CreatedAt: output.CreatedAt,
@@ -93,3 +99,90 @@ func (h *Handler) DeleteProfile(w http.ResponseWriter, r *http.Request) {
 		render.ServerError(w, http.StatusInternalServerError)
 	}
 }
+
+func (h *Handler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.user.RequestExport"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	claims := security.GetClaims(ctx)
+	output, err := h.srv.RequestExport(ctx, claims.UserID)
+
+	switch {
+	case err == nil:
+		render.JSON(w, http.StatusAccepted, toExportResponse(output))
+	case errors.Is(err, user.ErrExportInProgress):
+		render.Error(w, http.StatusConflict, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) GetExport(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.user.GetExport"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	exportID, err := uuid.Parse(chi.URLParam(r, "export-id"))
+	if err != nil {
+		render.Error(w, http.StatusBadRequest,
+			errors.New("invalid export id"))
+		return
+	}
+
+	claims := security.GetClaims(ctx)
+	output, err := h.srv.GetExport(ctx, &user.GetExportInput{
+		UserID:   claims.UserID,
+		ExportID: exportID,
+	})
+
+	switch {
+	case err == nil:
+		render.JSON(w, http.StatusOK, toExportResponse(output))
+	case errors.Is(err, user.ErrExportNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.user.DownloadExport"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	output, err := h.srv.DownloadExport(ctx, r.URL.Query().Get("token"))
+
+	switch {
+	case err == nil:
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition",
+			fmt.Sprintf("attachment; filename=%q", output.FileName))
+		w.Header().Set("Content-Length", strconv.Itoa(len(output.Archive)))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(output.Archive)
+	case errors.Is(err, user.ErrInvalidDownloadToken):
+		render.Error(w, http.StatusUnauthorized, err)
+	case errors.Is(err, user.ErrExportNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, user.ErrExportNotReady):
+		render.Error(w, http.StatusConflict, err)
+	case errors.Is(err, user.ErrUserBlocked):
+		render.CodeError(w, http.StatusForbidden, render.CodeUserBlocked, err)
+	case errors.Is(err, user.ErrUserDeleted):
+		render.CodeError(w, http.StatusForbidden, render.CodeUserDeleted, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func toExportResponse(output *user.ExportOutput) *ExportResponse {
+	return &ExportResponse{
+		ID:          output.ID,
+		Status:      output.Status,
+		DownloadURL: output.DownloadURL,
+		CompletedAt: output.CompletedAt,
+		ExpiresAt:   output.ExpiresAt,
+		CreatedAt:   output.CreatedAt,
+	}
+}
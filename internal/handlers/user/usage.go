@@ -0,0 +1,32 @@
+package user
+
+import (
+	"net/http"
+
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/render"
+	"cloud-notes/internal/security"
+)
+
+func (h *Handler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.user.GetUsage"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	claims := security.GetClaims(ctx)
+	output, err := h.srv.GetUsage(ctx, claims.UserID)
+
+	switch { // nolint
+	case err == nil:
+		render.JSON(w, http.StatusOK, &UsageResponse{
+			Plan:          output.Plan,
+			Notes:         output.Notes,
+			MaxNotes:      output.MaxNotes,
+			Bytes:         output.Bytes,
+			MaxTotalBytes: output.MaxTotalBytes,
+			MaxNoteBytes:  output.MaxNoteBytes,
+		})
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
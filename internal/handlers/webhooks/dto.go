@@ -0,0 +1,57 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WebhookResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	URL       string     `json:"url"`
+	Events    []string   `json:"events"`
+	Active    bool       `json:"active"`
+	Failures  int        `json:"failures"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+type CreateWebhookResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"`
+}
+
+type GetWebhooksResponse struct {
+	Webhooks []*WebhookResponse `json:"webhooks"`
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"    validate:"required,max=2000"`
+	Events []string `json:"events" validate:"required,min=1"`
+}
+
+type UpdateWebhookRequest struct {
+	URL    string   `json:"url"    validate:"required,max=2000"`
+	Events []string `json:"events" validate:"required,min=1"`
+	Active bool     `json:"active"`
+}
+
+type DeliveryResponse struct {
+	ID           uuid.UUID       `json:"id"`
+	EventID      uuid.UUID       `json:"event_id"`
+	EventType    string          `json:"event_type"`
+	Payload      json.RawMessage `json:"payload"`
+	Status       string          `json:"status"`
+	Attempts     int             `json:"attempts"`
+	ResponseCode *int            `json:"response_code,omitempty"`
+	Error        *string         `json:"error,omitempty"`
+	ReplayOf     *uuid.UUID      `json:"replay_of,omitempty"`
+	NextAttempt  *time.Time      `json:"next_attempt_at,omitempty"`
+	DeliveredAt  *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+type GetDeliveriesResponse struct {
+	Deliveries []*DeliveryResponse `json:"deliveries"`
+}
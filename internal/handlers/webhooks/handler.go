@@ -0,0 +1,272 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"cloud-notes/internal/handlers/query"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/render"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/services/webhooks"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	log logger.Logger
+	srv webhooks.Service
+	val *validator.Validate
+}
+
+func New(log logger.Logger, srv webhooks.Service) Handler {
+	return Handler{
+		log: log,
+		srv: srv,
+		val: validator.New(),
+	}
+}
+
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.webhooks.CreateWebhook"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	request := new(CreateWebhookRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	claims := security.GetClaims(ctx)
+	output, err := h.srv.CreateWebhook(ctx, &webhooks.CreateWebhookInput{
+		UserID: claims.UserID,
+		URL:    request.URL,
+		Events: request.Events,
+	})
+	if err != nil {
+		renderError(w, err)
+		return
+	}
+
+	render.JSON(w, http.StatusCreated, &CreateWebhookResponse{
+		WebhookResponse: *toWebhookResponse(&output.WebhookOutput),
+		Secret:          output.Secret,
+	})
+}
+
+func (h *Handler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.webhooks.GetWebhooks"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	claims := security.GetClaims(ctx)
+	output, err := h.srv.GetWebhooks(ctx, claims.UserID)
+
+	switch { // nolint
+	case err == nil:
+		response := &GetWebhooksResponse{
+			Webhooks: make([]*WebhookResponse, 0, len(output.Webhooks)),
+		}
+		for _, webhook := range output.Webhooks {
+			response.Webhooks = append(response.Webhooks,
+				toWebhookResponse(webhook))
+		}
+		render.JSON(w, http.StatusOK, response)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.webhooks.UpdateWebhook"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	input, err := webhookInput(r)
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	request := new(UpdateWebhookRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	output, err := h.srv.UpdateWebhook(ctx, &webhooks.UpdateWebhookInput{
+		WebhookInput: *input,
+		URL:          request.URL,
+		Events:       request.Events,
+		Active:       request.Active,
+	})
+	if err != nil {
+		renderError(w, err)
+		return
+	}
+
+	render.JSON(w, http.StatusOK, toWebhookResponse(output))
+}
+
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.webhooks.DeleteWebhook"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	input, err := webhookInput(r)
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.srv.DeleteWebhook(ctx, input)
+	renderError(w, err)
+}
+
+func (h *Handler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.webhooks.GetDeliveries"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	input, err := webhookInput(r)
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	limit, err := query.Uint64(r, "limit")
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	offset, err := query.Uint64(r, "offset")
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	output, err := h.srv.GetDeliveries(ctx, &webhooks.GetDeliveriesInput{
+		WebhookInput: *input,
+		Limit:        limit,
+		Offset:       offset,
+	})
+	if err != nil {
+		renderError(w, err)
+		return
+	}
+
+	response := &GetDeliveriesResponse{
+		Deliveries: make([]*DeliveryResponse, 0, len(output.Deliveries)),
+	}
+	for _, delivery := range output.Deliveries {
+		response.Deliveries = append(response.Deliveries,
+			toDeliveryResponse(delivery))
+	}
+	render.JSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) ReplayDelivery(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.webhooks.ReplayDelivery"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	input, err := webhookInput(r)
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	deliveryID, err := uuid.Parse(chi.URLParam(r, "delivery-id"))
+	if err != nil {
+		render.Error(w, http.StatusBadRequest,
+			errors.New("invalid delivery id"))
+		return
+	}
+
+	output, err := h.srv.ReplayDelivery(ctx, &webhooks.ReplayDeliveryInput{
+		WebhookInput: *input,
+		DeliveryID:   deliveryID,
+	})
+	if err != nil {
+		renderError(w, err)
+		return
+	}
+
+	render.JSON(w, http.StatusAccepted, toDeliveryResponse(output))
+}
+
+func webhookInput(r *http.Request) (*webhooks.WebhookInput, error) {
+	webhookID, err := uuid.Parse(chi.URLParam(r, "webhook-id"))
+	if err != nil {
+		return nil, errors.New("invalid webhook id")
+	}
+
+	claims := security.GetClaims(r.Context())
+	return &webhooks.WebhookInput{
+		UserID:    claims.UserID,
+		WebhookID: webhookID,
+	}, nil
+}
+
+// renderError renders the errors the webhook routes share, and an empty
+// response when there is none.
+func renderError(w http.ResponseWriter, err error) {
+	switch {
+	case err == nil:
+		render.Empty(w)
+	case errors.Is(err, webhooks.ErrWebhookNotFound),
+		errors.Is(err, webhooks.ErrDeliveryNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, webhooks.ErrTooManyWebhooks):
+		render.Error(w, http.StatusConflict, err)
+	case errors.Is(err, webhooks.ErrInvalidURL),
+		errors.Is(err, webhooks.ErrUnknownEvent):
+		render.Error(w, http.StatusUnprocessableEntity, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func toWebhookResponse(output *webhooks.WebhookOutput) *WebhookResponse {
+	return &WebhookResponse{
+		ID:        output.ID,
+		URL:       output.URL,
+		Events:    output.Events,
+		Active:    output.Active,
+		Failures:  output.Failures,
+		CreatedAt: output.CreatedAt,
+		UpdatedAt: output.UpdatedAt,
+	}
+}
+
+func toDeliveryResponse(output *webhooks.DeliveryOutput) *DeliveryResponse {
+	return &DeliveryResponse{
+		ID:           output.ID,
+		EventID:      output.EventID,
+		EventType:    output.EventType,
+		Payload:      output.Payload,
+		Status:       output.Status,
+		Attempts:     output.Attempts,
+		ResponseCode: output.ResponseCode,
+		Error:        output.Error,
+		ReplayOf:     output.ReplayOf,
+		NextAttempt:  output.NextAttempt,
+		DeliveredAt:  output.DeliveredAt,
+		CreatedAt:    output.CreatedAt,
+	}
+}
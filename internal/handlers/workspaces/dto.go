@@ -0,0 +1,45 @@
+package workspaces
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WorkspaceResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	OwnerID   uuid.UUID `json:"owner_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GetWorkspacesResponse struct {
+	Workspaces []*WorkspaceResponse `json:"workspaces"`
+}
+
+type MemberResponse struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Login     string    `json:"login"`
+	FirstName string    `json:"first_name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GetWorkspaceResponse struct {
+	WorkspaceResponse
+	Members []*MemberResponse `json:"members"`
+}
+
+type WorkspaceRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=200"`
+}
+
+type AddMemberRequest struct {
+	Login string `json:"login" validate:"required,email"`
+	Role  string `json:"role"  validate:"required,oneof=editor viewer"`
+}
+
+type UpdateMemberRequest struct {
+	Role string `json:"role" validate:"required,oneof=editor viewer"`
+}
@@ -0,0 +1,312 @@
+package workspaces
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/render"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/services/workspaces"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	log logger.Logger
+	srv workspaces.Service
+	val *validator.Validate
+}
+
+func New(log logger.Logger, srv workspaces.Service) Handler {
+	return Handler{
+		log: log,
+		srv: srv,
+		val: validator.New(),
+	}
+}
+
+func (h *Handler) CreateWorkspace(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.workspaces.CreateWorkspace"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	request := new(WorkspaceRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	claims := security.GetClaims(ctx)
+	output, err := h.srv.CreateWorkspace(ctx,
+		&workspaces.CreateWorkspaceInput{
+			UserID: claims.UserID,
+			Name:   request.Name,
+		})
+
+	switch { // nolint
+	case err == nil:
+		render.JSON(w, http.StatusCreated, toWorkspaceResponse(output))
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) GetWorkspaces(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.workspaces.GetWorkspaces"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	claims := security.GetClaims(ctx)
+	output, err := h.srv.GetWorkspaces(ctx, claims.UserID)
+
+	switch { // nolint
+	case err == nil:
+		response := &GetWorkspacesResponse{
+			Workspaces: make([]*WorkspaceResponse, 0, len(output.Workspaces)),
+		}
+		for _, workspace := range output.Workspaces {
+			response.Workspaces = append(response.Workspaces,
+				toWorkspaceResponse(workspace))
+		}
+		render.JSON(w, http.StatusOK, response)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) GetWorkspace(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.workspaces.GetWorkspace"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	input, err := workspaceInput(r)
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	output, err := h.srv.GetWorkspace(ctx, input)
+
+	switch {
+	case err == nil:
+		response := &GetWorkspaceResponse{
+			WorkspaceResponse: *toWorkspaceResponse(&output.WorkspaceOutput),
+			Members:           make([]*MemberResponse, 0, len(output.Members)),
+		}
+		for _, member := range output.Members {
+			response.Members = append(response.Members,
+				toMemberResponse(member))
+		}
+		render.JSON(w, http.StatusOK, response)
+	case errors.Is(err, workspaces.ErrWorkspaceNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) RenameWorkspace(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.workspaces.RenameWorkspace"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	input, err := workspaceInput(r)
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	request := new(WorkspaceRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	err = h.srv.RenameWorkspace(ctx, &workspaces.RenameWorkspaceInput{
+		WorkspaceInput: *input,
+		Name:           request.Name,
+	})
+	renderError(w, err)
+}
+
+func (h *Handler) DeleteWorkspace(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.workspaces.DeleteWorkspace"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	input, err := workspaceInput(r)
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.srv.DeleteWorkspace(ctx, input)
+	renderError(w, err)
+}
+
+func (h *Handler) AddMember(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.workspaces.AddMember"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	input, err := workspaceInput(r)
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	request := new(AddMemberRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	output, err := h.srv.AddMember(ctx, &workspaces.AddMemberInput{
+		WorkspaceInput: *input,
+		Login:          request.Login,
+		Role:           request.Role,
+	})
+	if err != nil {
+		renderError(w, err)
+		return
+	}
+
+	render.JSON(w, http.StatusCreated, toMemberResponse(output))
+}
+
+func (h *Handler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.workspaces.UpdateMember"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	input, err := workspaceInput(r)
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	memberID, err := uuid.Parse(chi.URLParam(r, "user-id"))
+	if err != nil {
+		render.Error(w, http.StatusBadRequest,
+			errors.New("invalid user id"))
+		return
+	}
+
+	request := new(UpdateMemberRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		render.InvalidJSONError(w)
+		return
+	}
+
+	if err := h.val.StructCtx(ctx, request); err != nil {
+		render.ValidationError(w, request, err)
+		return
+	}
+
+	err = h.srv.UpdateMember(ctx, &workspaces.UpdateMemberInput{
+		WorkspaceInput: *input,
+		MemberID:       memberID,
+		Role:           request.Role,
+	})
+	renderError(w, err)
+}
+
+func (h *Handler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.workspaces.RemoveMember"
+	_ = h.log.With(logger.String("op", op))
+	ctx := r.Context()
+
+	input, err := workspaceInput(r)
+	if err != nil {
+		render.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	memberID, err := uuid.Parse(chi.URLParam(r, "user-id"))
+	if err != nil {
+		render.Error(w, http.StatusBadRequest,
+			errors.New("invalid user id"))
+		return
+	}
+
+	err = h.srv.RemoveMember(ctx, &workspaces.RemoveMemberInput{
+		WorkspaceInput: *input,
+		MemberID:       memberID,
+	})
+	renderError(w, err)
+}
+
+func workspaceInput(r *http.Request) (*workspaces.WorkspaceInput, error) {
+	workspaceID, err := uuid.Parse(chi.URLParam(r, "workspace-id"))
+	if err != nil {
+		return nil, errors.New("invalid workspace id")
+	}
+
+	claims := security.GetClaims(r.Context())
+	return &workspaces.WorkspaceInput{
+		UserID:      claims.UserID,
+		WorkspaceID: workspaceID,
+	}, nil
+}
+
+// renderError renders the outcome of the workspace and member changes,
+// which share their errors.
+func renderError(w http.ResponseWriter, err error) {
+	switch {
+	case err == nil:
+		render.Empty(w)
+	case errors.Is(err, workspaces.ErrWorkspaceNotFound),
+		errors.Is(err, workspaces.ErrUserNotFound),
+		errors.Is(err, workspaces.ErrMemberNotFound):
+		render.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, workspaces.ErrNotOwner):
+		render.Error(w, http.StatusForbidden, err)
+	case errors.Is(err, workspaces.ErrAlreadyMember),
+		errors.Is(err, workspaces.ErrOwnerRole):
+		render.Error(w, http.StatusConflict, err)
+	case errors.Is(err, workspaces.ErrInvalidRole):
+		render.Error(w, http.StatusUnprocessableEntity, err)
+	default:
+		render.ServerError(w, http.StatusInternalServerError)
+	}
+}
+
+func toWorkspaceResponse(
+	output *workspaces.WorkspaceOutput) *WorkspaceResponse {
+	return &WorkspaceResponse{
+		ID:        output.ID,
+		Name:      output.Name,
+		OwnerID:   output.OwnerID,
+		Role:      output.Role,
+		CreatedAt: output.CreatedAt,
+	}
+}
+
+func toMemberResponse(output *workspaces.MemberOutput) *MemberResponse {
+	return &MemberResponse{
+		UserID:    output.UserID,
+		Login:     output.Login,
+		FirstName: output.FirstName,
+		Role:      output.Role,
+		CreatedAt: output.CreatedAt,
+	}
+}
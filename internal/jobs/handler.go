@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"cloud-notes/internal/storage"
+)
+
+// Handler runs a job. Jobs are run at least once, a worker that dies in
+// the middle leaves the job to be run again, so it has to tolerate that.
+// A returned error fails the attempt and the job is retried later.
+type Handler func(ctx context.Context, job *storage.Job) error
+
+// Typed adapts a handler of a payload type, the payload is decoded before
+// the handler is called.
+func Typed[T any](handler func(ctx context.Context, payload *T) error) Handler {
+	return func(ctx context.Context, job *storage.Job) error {
+		payload := new(T)
+		err := json.Unmarshal(job.Payload, payload)
+		if err != nil {
+			return fmt.Errorf("invalid payload: %w", err)
+		}
+
+		return handler(ctx, payload)
+	}
+}
+
+// Task adapts a handler of a job without a payload, like a periodic purge.
+func Task(task func(ctx context.Context) error) Handler {
+	return func(ctx context.Context, _ *storage.Job) error {
+		return task(ctx)
+	}
+}
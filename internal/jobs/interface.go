@@ -0,0 +1,14 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+type Queue interface {
+	Enqueue(ctx context.Context, jobType string, payload any) error
+	Handle(jobType string, handler Handler)
+	Schedule(jobType string, interval time.Duration)
+	Run(ctx context.Context)
+	Purge(ctx context.Context) error
+}
@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// Types of the jobs run by the worker.
+const (
+	TypeSendVerification       = "auth.verification"
+	TypeSendPasswordReset      = "auth.password_reset"
+	TypeBuildExport            = "export.build"
+	TypePurgeExpiredExports    = "export.purge"
+	TypePurgeDeletedUsers      = "user.purge"
+	TypeDispatchEvents         = "events.dispatch"
+	TypePurgeEvents            = "events.purge"
+	TypeDeliverWebhooks        = "webhooks.deliver"
+	TypePurgeWebhookDeliveries = "webhooks.purge"
+	TypePurgeJobs              = "jobs.purge"
+)
+
+type SendVerification struct {
+	Login string `json:"login"`
+}
+
+type SendPasswordReset struct {
+	Login string `json:"login"`
+}
+
+type BuildExport struct {
+	ExportID uuid.UUID `json:"export_id"`
+}
+
+// NewJob describes a job for the queue, payload is what its handler gets.
+// The job runs as soon as a worker picks it up.
+func NewJob(jobType string, payload any) (*storage.Job, error) {
+	const op = "jobs.NewJob"
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	return &storage.Job{
+		ID:        uuid.New(),
+		Type:      jobType,
+		Payload:   data,
+		Status:    storage.JobStatusPending,
+		Attempts:  0,
+		RunAt:     now,
+		CreatedAt: now,
+	}, nil
+}
@@ -0,0 +1,242 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/scheduler"
+	"cloud-notes/internal/storage"
+)
+
+const maxErrorLength = 500
+
+var errLeaseExpired = errors.New("lease expired on every attempt")
+
+type periodic struct {
+	jobType  string
+	interval time.Duration
+}
+
+type queue struct {
+	log      logger.Logger
+	st       storage.Storage
+	cfg      *config.Jobs
+	handlers map[string]Handler
+	periodic []*periodic
+}
+
+func New(log logger.Logger, st storage.Storage, cfg *config.Jobs) Queue {
+	return &queue{
+		log:      log,
+		st:       st,
+		cfg:      cfg,
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Enqueue adds a job for the worker. Services that change data together
+// with enqueueing a job create it with NewJob in their transaction
+// instead, so the job exists only if the change does.
+func (q *queue) Enqueue(
+	ctx context.Context, jobType string, payload any) error {
+	const op = "jobs.Enqueue"
+	_ = q.log.With(logger.String("op", op))
+
+	job, err := NewJob(jobType, payload)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = q.st.Jobs().Create(ctx, job)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Handle sets the handler of the jobs of the given type. Handlers are set
+// before the worker starts running.
+func (q *queue) Handle(jobType string, handler Handler) {
+	q.handlers[jobType] = handler
+}
+
+// Schedule enqueues a job of the given type once per interval. Intervals
+// are aligned to the clock and the job of each one is enqueued once, so
+// any number of workers may schedule the same job.
+func (q *queue) Schedule(jobType string, interval time.Duration) {
+	q.periodic = append(q.periodic, &periodic{
+		jobType:  jobType,
+		interval: interval,
+	})
+}
+
+// Run schedules and runs the jobs every JOBS_INTERVAL seconds until the
+// context is done.
+func (q *queue) Run(ctx context.Context) {
+	interval := time.Second * time.Duration(q.cfg.Interval)
+	go scheduler.Every(ctx, q.log, "schedule jobs", interval, q.schedule)
+	scheduler.Every(ctx, q.log, "run jobs", interval, q.work)
+}
+
+func (q *queue) schedule(ctx context.Context) error {
+	const op = "jobs.schedule"
+	_ = q.log.With(logger.String("op", op))
+
+	now := time.Now()
+	for _, p := range q.periodic {
+		job, err := NewJob(p.jobType, nil)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		slot := now.Truncate(p.interval)
+		uniqueKey := fmt.Sprintf("%s@%d", p.jobType, slot.Unix())
+		job.RunAt = slot
+		job.UniqueKey = &uniqueKey
+
+		err = q.st.Jobs().Create(ctx, job)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// work runs the jobs that are due, up to JOBS_CONCURRENCY at a time. Each
+// job is leased while it runs, so workers can run this together without
+// running a job twice.
+func (q *queue) work(ctx context.Context) error {
+	const op = "jobs.work"
+	_ = q.log.With(logger.String("op", op))
+
+	lease := time.Second * time.Duration(q.cfg.Lease)
+	limit := uint64(q.cfg.Concurrency)
+	for {
+		now := time.Now()
+		jobs, err := q.st.Jobs().Claim(ctx, now, now.Add(lease), limit)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		var wg sync.WaitGroup
+		for _, job := range jobs {
+			wg.Go(func() { q.run(ctx, job) })
+		}
+		wg.Wait()
+
+		if uint64(len(jobs)) < limit {
+			return nil
+		}
+	}
+}
+
+// run makes one attempt and records its outcome. Failed attempts are
+// retried with exponential backoff, a job that runs out of attempts is
+// left failed until it's retried by hand. The attempt is already counted
+// by Claim, a job claimed past JOBS_MAX_ATTEMPTS lost its earlier workers
+// and is failed without running. The outcome is dropped if the lease ran
+// out and another worker claimed the job meanwhile, that worker records
+// its own.
+func (q *queue) run(ctx context.Context, job *storage.Job) {
+	const op = "jobs.run"
+	log := q.log.With(logger.String("op", op),
+		logger.String("job_id", job.ID.String()),
+		logger.String("job_type", job.Type))
+
+	lease := job.RunAt
+
+	var err error
+	if job.Attempts > q.cfg.MaxAttempts {
+		err = errLeaseExpired
+	} else {
+		err = q.call(ctx, job)
+	}
+
+	now := time.Now()
+	switch {
+	case err == nil:
+		job.Status = storage.JobStatusSucceeded
+		job.Error = nil
+		job.FinishedAt = &now
+	case job.Attempts >= q.cfg.MaxAttempts:
+		log.ErrorContext(ctx, "job failed", logger.Error(err))
+		job.Status = storage.JobStatusFailed
+		job.FinishedAt = &now
+	default:
+		log.WarnContext(ctx, "job attempt failed", logger.Error(err))
+		job.RunAt = now.Add(q.backoff(job.Attempts))
+	}
+
+	if err != nil {
+		message := err.Error()
+		if len(message) > maxErrorLength {
+			message = message[:maxErrorLength]
+		}
+		job.Error = &message
+	}
+
+	// The outcome is saved even if the worker is stopping, otherwise the
+	// job would wait for its lease to run out.
+	updated, err := q.st.Jobs().Update(context.WithoutCancel(ctx), job, lease)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to update job", logger.Error(err))
+	} else if !updated {
+		log.WarnContext(ctx, "job lease lost, outcome dropped")
+	}
+}
+
+// call runs the handler within the lease. A panic fails the attempt
+// instead of the worker.
+func (q *queue) call(ctx context.Context, job *storage.Job) (err error) {
+	handler, ok := q.handlers[job.Type]
+	if !ok {
+		return fmt.Errorf("no handler for job type %s", job.Type)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(
+		ctx, time.Second*time.Duration(q.cfg.Lease))
+	defer cancel()
+
+	return handler(ctx, job)
+}
+
+// backoff doubles the delay after every failed attempt, up to the maximum.
+func (q *queue) backoff(attempts int) time.Duration {
+	delay := time.Second * time.Duration(q.cfg.BackoffBase)
+	maxDelay := time.Second * time.Duration(q.cfg.BackoffMax)
+	for range attempts - 1 {
+		if delay >= maxDelay {
+			break
+		}
+		delay *= 2
+	}
+
+	return min(delay, maxDelay)
+}
+
+// Purge deletes succeeded jobs older than JOBS_RETENTION seconds.
+func (q *queue) Purge(ctx context.Context) error {
+	const op = "jobs.Purge"
+	_ = q.log.With(logger.String("op", op))
+
+	retention := time.Second * time.Duration(q.cfg.Retention)
+	err := q.st.Jobs().DeleteSucceeded(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"cloud-notes/internal/config"
+
+	"github.com/google/uuid"
+)
+
+// fileMailer writes every message as an .eml file, so development and
+// tests can read links from the disk instead of a real mailbox.
+type fileMailer struct {
+	dir  string
+	from string
+}
+
+func newFile(cfg *config.Mailer) (Mailer, error) {
+	err := os.MkdirAll(cfg.Dir, 0o750)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mail dir: %w", err)
+	}
+
+	return &fileMailer{
+		dir:  cfg.Dir,
+		from: cfg.From,
+	}, nil
+}
+
+func (m *fileMailer) Send(_ context.Context, message *Message) error {
+	name := fmt.Sprintf("%s-%s.eml",
+		time.Now().UTC().Format("20060102T150405"), uuid.NewString())
+
+	err := os.WriteFile(
+		path.Join(m.dir, name), encode(m.from, message), 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to write mail: %w", err)
+	}
+
+	return nil
+}
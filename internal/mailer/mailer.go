@@ -0,0 +1,31 @@
+package mailer
+
+import (
+	"fmt"
+	"strings"
+
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/logger"
+)
+
+func Load(log logger.Logger, cfg *config.Mailer) (Mailer, error) {
+	switch strings.Trim(strings.ToLower(cfg.Driver), " \n\t") {
+	case "smtp":
+		return newSMTP(cfg), nil
+	case "file":
+		return newFile(cfg)
+	case "log":
+		return newLog(log), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer driver: %s", cfg.Driver)
+	}
+}
+
+func MustLoad(log logger.Logger, cfg *config.Mailer) Mailer {
+	m, err := Load(log, cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	return m
+}
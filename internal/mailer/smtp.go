@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"cloud-notes/internal/config"
+)
+
+type smtpMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func newSMTP(cfg *config.Mailer) Mailer {
+	m := &smtpMailer{
+		addr: net.JoinHostPort(cfg.SMTP.Host, strconv.Itoa(cfg.SMTP.Port)),
+		from: cfg.From,
+	}
+	if cfg.SMTP.User != "" {
+		m.auth = smtp.PlainAuth(
+			"", cfg.SMTP.User, cfg.SMTP.Password, cfg.SMTP.Host)
+	}
+
+	return m
+}
+
+func (m *smtpMailer) Send(_ context.Context, message *Message) error {
+	err := smtp.SendMail(m.addr, m.auth, m.from,
+		[]string{message.To}, encode(m.from, message))
+	if err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+
+	return nil
+}
+
+func encode(from string, message *Message) []byte {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "From: %s\r\n", from)
+	fmt.Fprintf(buf, "To: %s\r\n", message.To)
+	fmt.Fprintf(buf, "Subject: %s\r\n",
+		mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(buf, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(buf, "\r\n%s\r\n", message.Text)
+
+	return buf.Bytes()
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"cloud-notes/internal/audit"
+)
+
+// Audit remembers the client of the request for the security events the
+// services record while handling it.
+func Audit() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := audit.SetRequest(r.Context(), &audit.Request{
+				IP:        clientIP(r),
+				UserAgent: r.UserAgent(),
+			})
+
+			r = r.WithContext(ctx)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"cloud-notes/internal/render"
+	"cloud-notes/internal/security"
+)
+
+var (
+	ErrForbidden     = errors.New("forbidden")
+	ErrImpersonation = errors.New("not allowed while impersonating")
+)
+
+// Require lets through only callers whose role grants all permissions. It
+// relies on the claims set by Security, so it must be mounted after it.
+func Require(permissions ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := security.LookupClaims(r.Context())
+			if !ok || !claims.HasPermissions(permissions...) {
+				render.Error(w, http.StatusForbidden, ErrForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// DenyImpersonation keeps impersonation sessions away from routes that
+// manage credentials or destroy the account. An admin acting as the user
+// must not get access that outlives the session or act irreversibly on
+// the user's behalf. It must be mounted after Security.
+func DenyImpersonation() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := security.LookupClaims(r.Context())
+			if !ok || claims.ImpersonatorID != nil {
+				render.Error(w, http.StatusForbidden, ErrImpersonation)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/render"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/storage"
+)
+
+var (
+	ErrTooManyRequests = errors.New("too many requests")
+)
+
+// RateLimit limits requests of the named route group with a token bucket
+// shared between replicas. Authenticated requests are counted per user, so
+// the middleware must be mounted after Security on protected routes, and
+// anonymous requests are counted per client IP.
+func RateLimit(log logger.Logger, st storage.Storage, group string,
+	cfg *config.RateLimitBudget) func(http.Handler) http.Handler {
+	budget := &storage.RateLimitBudget{
+		Limit:  cfg.Limit,
+		Period: time.Second * time.Duration(cfg.Period),
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "middleware.RateLimit"
+			log := log.With(logger.String("op", op))
+			ctx := r.Context()
+
+			key := group + ":ip:" + clientIP(r)
+			if claims, ok := security.LookupClaims(ctx); ok {
+				key = group + ":user:" + claims.UserID.String()
+			}
+
+			result, err := st.RateLimits().Take(ctx, key, budget)
+			if err != nil {
+				log.ErrorContext(ctx, "", logger.Error(err))
+				render.ServerError(w, http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("RateLimit-Remaining",
+				strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", seconds(result.Reset))
+
+			if !result.Allowed {
+				w.Header().Set("Retry-After", seconds(result.RetryAfter))
+				render.Error(w, http.StatusTooManyRequests,
+					ErrTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientIP is the address the request came from, or the client behind a
+// trusted proxy once RealIP has run.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"cloud-notes/internal/config"
+)
+
+// RealIP replaces the remote address of requests that came through one of
+// the trusted proxies with the client address from X-Forwarded-For, so the
+// middlewares after it see the client. The header is read from the right,
+// every proxy appends the address it got the request from: the first
+// address that isn't a trusted proxy is the client. Requests from anywhere
+// else keep their remote address, their header may be forged.
+func RealIP(proxies config.Proxies) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(proxies) > 0 {
+				if ip, ok := forwardedFor(r, proxies); ok {
+					r.RemoteAddr = ip.String()
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func forwardedFor(r *http.Request, proxies config.Proxies) (netip.Addr, bool) {
+	peer, err := netip.ParseAddr(clientIP(r))
+	if err != nil || !proxies.Contains(peer) {
+		return netip.Addr{}, false
+	}
+
+	var hops []string
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+
+	client, found := netip.Addr{}, false
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+
+		client, found = addr.Unmap(), true
+		if !proxies.Contains(addr) {
+			break
+		}
+	}
+
+	return client, found
+}
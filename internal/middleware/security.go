@@ -1,9 +1,11 @@
 package middleware
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"cloud-notes/internal/logger"
 	"cloud-notes/internal/render"
@@ -16,10 +18,18 @@ var (
 	ErrInvalidAuthScheme = errors.New("invalid auth scheme")
 	ErrInvalidToken      = errors.New("invalid auth token")
 	ErrSessionExpired    = errors.New("session expired")
+	ErrEmailNotVerified  = errors.New("email not verified")
+	ErrUserBlocked       = errors.New("user blocked")
+	ErrUserDeleted       = errors.New("user deleted")
+	ErrTokenExpired      = errors.New("token expired")
+	ErrMissingScope      = errors.New("token lacks required scope")
 )
 
-func Security(log logger.Logger, st storage.Storage,
-	sec security.Security) func(http.Handler) http.Handler {
+// Security authenticates requests with a session access token or, when
+// scopes are given, with a personal or OAuth client token that has all of
+// them. Routes without scopes are available to login sessions only.
+func Security(log logger.Logger, st storage.Storage, sec security.Security,
+	scopes ...string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			const op = "middleware.Security"
@@ -38,23 +48,81 @@ func Security(log logger.Logger, st storage.Storage,
 				return
 			}
 
-			claims, err := sec.ParseAccessToken(ctx, token)
-			if err != nil {
-				render.Error(w, http.StatusUnauthorized, ErrInvalidToken)
+			var claims *security.Claims
+			var err error
+			if security.IsPersonalToken(token) {
+				claims, err = parsePersonalToken(ctx, sec, token, scopes)
+			} else {
+				claims, err = parseSessionToken(ctx, st, sec, token, scopes)
+			}
+
+			switch {
+			case err == nil:
+			case errors.Is(err, ErrSessionExpired):
+				render.CodeError(w, http.StatusUnauthorized,
+					render.CodeSessionExpired, err)
+				return
+			case errors.Is(err, ErrInvalidToken),
+				errors.Is(err, ErrTokenExpired):
+				render.Error(w, http.StatusUnauthorized, err)
+				return
+			case errors.Is(err, ErrMissingScope):
+				render.Error(w, http.StatusForbidden, err)
+				return
+			default:
+				render.ServerError(w, http.StatusInternalServerError)
 				return
 			}
 
-			session, err := st.Sessions().GetByID(ctx, claims.SessionID)
+			user, err := st.Users().GetByID(ctx, claims.UserID)
 			if err != nil {
 				render.ServerError(w, http.StatusInternalServerError)
 				return
 			}
 
-			if session == nil {
-				render.Error(w, http.StatusUnauthorized, ErrSessionExpired)
+			if user == nil {
+				render.CodeError(w, http.StatusUnauthorized,
+					render.CodeSessionExpired, ErrSessionExpired)
+				return
+			}
+
+			if user.Status == storage.UserStatusBlocked {
+				user, err = liftExpiredBlock(ctx, st, user)
+				if err != nil {
+					render.ServerError(w, http.StatusInternalServerError)
+					return
+				}
+			}
+
+			switch user.Status {
+			case storage.UserStatusPending:
+				render.CodeError(w, http.StatusForbidden,
+					render.CodeEmailNotVerified, ErrEmailNotVerified)
+				return
+			case storage.UserStatusBlocked:
+				render.CodeError(w, http.StatusForbidden,
+					render.CodeUserBlocked, ErrUserBlocked)
+				return
+			case storage.UserStatusDeleted:
+				render.CodeError(w, http.StatusForbidden,
+					render.CodeUserDeleted, ErrUserDeleted)
+				return
+			}
+
+			role, err := st.Roles().GetByName(ctx, string(user.Role))
+			if err != nil {
+				render.ServerError(w, http.StatusInternalServerError)
 				return
 			}
 
+			// Scoped tokens and impersonation sessions act with the user's
+			// data but never with their role's permissions.
+			claims.Role = string(user.Role)
+			if role != nil && claims.Scopes == nil &&
+				claims.ImpersonatorID == nil {
+				claims.Permissions = role.Permissions
+			}
+
 			ctx = security.SetClaims(ctx, claims)
 
 			r = r.WithContext(ctx)
@@ -62,3 +130,85 @@ func Security(log logger.Logger, st storage.Storage,
 		})
 	}
 }
+
+// liftExpiredBlock lifts the user's block once it's over, so tokens work
+// again without a new login, and returns the user with the status they had
+// before it.
+func liftExpiredBlock(ctx context.Context, st storage.Storage,
+	user *storage.User) (*storage.User, error) {
+	now := time.Now()
+	lifted, err := st.Blocks().Lift(ctx, user.ID, &now)
+	if err != nil {
+		return nil, err
+	}
+
+	if !lifted {
+		return user, nil
+	}
+
+	restored, err := st.Users().GetByID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if restored == nil {
+		return user, nil
+	}
+
+	return restored, nil
+}
+
+func parseSessionToken(ctx context.Context, st storage.Storage,
+	sec security.Security, token string,
+	scopes []string) (*security.Claims, error) {
+	claims, err := sec.ParseAccessToken(ctx, token)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if claims.ClientID != nil && len(scopes) == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	session, err := st.Sessions().GetByID(ctx, claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Revoking an OAuth grant deletes its session, which invalidates the
+	// access tokens issued for it before they expire.
+	if session == nil || (session.ExpiresAt != nil &&
+		!session.ExpiresAt.After(time.Now())) {
+		return nil, ErrSessionExpired
+	}
+
+	if !claims.HasScopes(scopes...) {
+		return nil, ErrMissingScope
+	}
+
+	return claims, nil
+}
+
+func parsePersonalToken(ctx context.Context, sec security.Security,
+	token string, scopes []string) (*security.Claims, error) {
+	if len(scopes) == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	claims, err := sec.ParsePersonalToken(ctx, token)
+	switch {
+	case err == nil:
+	case errors.Is(err, security.ErrInvalidToken):
+		return nil, ErrInvalidToken
+	case errors.Is(err, security.ErrTokenExpired):
+		return nil, ErrTokenExpired
+	default:
+		return nil, err
+	}
+
+	if !claims.HasScopes(scopes...) {
+		return nil, ErrMissingScope
+	}
+
+	return claims, nil
+}
@@ -11,6 +11,9 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
+// migrationsLock is the key of the advisory lock held while migrating.
+const migrationsLock = 7243051
+
 func Migrate(ctx context.Context, dir string, url string) error {
 	conn, err := pgx.Connect(ctx, url)
 	if err != nil {
@@ -18,7 +21,16 @@ func Migrate(ctx context.Context, dir string, url string) error {
 	}
 	defer conn.Close(ctx) // nolint
 
-	query := `CREATE TABLE IF NOT EXISTS migrations (
+	// Migrators started at once, like the test packages, wait for each
+	// other instead of applying the same files twice. Closing the
+	// connection releases the lock.
+	query := `SELECT pg_advisory_lock($1)`
+	_, err = conn.Exec(ctx, query, migrationsLock)
+	if err != nil {
+		return fmt.Errorf("failed to lock migrations: %w", err)
+	}
+
+	query = `CREATE TABLE IF NOT EXISTS migrations (
 		    	id       SERIAL PRIMARY KEY,
 		        file     TEXT NOT NULL)`
 	_, err = conn.Exec(ctx, query)
@@ -0,0 +1,14 @@
+package oidc
+
+import (
+	"context"
+)
+
+type OIDC interface {
+	Enabled() bool
+	Provider() string
+	AuthCodeURL(
+		ctx context.Context, state, nonce, verifier string) (string, error)
+	Exchange(
+		ctx context.Context, code, nonce, verifier string) (*Identity, error)
+}
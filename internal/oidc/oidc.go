@@ -0,0 +1,153 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"cloud-notes/internal/config"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+var (
+	ErrDisabled      = errors.New("oidc login is disabled")
+	ErrInvalidCode   = errors.New("invalid authorization code")
+	ErrInvalidNonce  = errors.New("invalid nonce")
+	ErrInvalidClaims = errors.New("invalid id token claims")
+)
+
+// GenerateVerifier returns a random PKCE code verifier. It is also good
+// enough for nonces.
+var GenerateVerifier = oauth2.GenerateVerifier
+
+// Identity is the verified subset of ID token claims used to find or
+// create the local user.
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type client struct {
+	cfg *config.OIDC
+
+	// The provider is discovered on first use, so an unavailable issuer
+	// doesn't keep the server from starting.
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+func New(cfg *config.OIDC) OIDC {
+	return &client{
+		cfg: cfg,
+	}
+}
+
+func (c *client) Enabled() bool {
+	return c.cfg.Issuer != ""
+}
+
+func (c *client) Provider() string {
+	return c.cfg.Provider
+}
+
+func (c *client) AuthCodeURL(
+	ctx context.Context, state, nonce, verifier string) (string, error) {
+	oauth, _, err := c.config(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return oauth.AuthCodeURL(state, oidc.Nonce(nonce),
+		oauth2.S256ChallengeOption(verifier)), nil
+}
+
+func (c *client) Exchange(
+	ctx context.Context, code, nonce, verifier string) (*Identity, error) {
+	oauth, idVerifier, err := c.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauth.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, ErrInvalidCode
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, ErrInvalidClaims
+	}
+
+	idToken, err := idVerifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, ErrInvalidClaims
+	}
+
+	if idToken.Nonce != nonce {
+		return nil, ErrInvalidNonce
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+		GivenName     string `json:"given_name"`
+	}
+	err = idToken.Claims(&claims)
+	if err != nil {
+		return nil, ErrInvalidClaims
+	}
+
+	name := claims.GivenName
+	if name == "" {
+		name = claims.Name
+	}
+
+	return &Identity{
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          name,
+	}, nil
+}
+
+func (c *client) config(
+	ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	if !c.Enabled() {
+		return nil, nil, ErrDisabled
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.provider == nil {
+		// The provider keeps the context to refresh the issuer keys later,
+		// so it must outlive the request.
+		provider, err := oidc.NewProvider(
+			context.WithoutCancel(ctx), c.cfg.Issuer)
+		if err != nil {
+			return nil, nil, fmt.Errorf(
+				"failed to discover oidc issuer: %w", err)
+		}
+		c.provider = provider
+	}
+
+	oauth := &oauth2.Config{
+		ClientID:     c.cfg.ClientID,
+		ClientSecret: c.cfg.ClientSecret,
+		RedirectURL:  c.cfg.RedirectURL,
+		Endpoint:     c.provider.Endpoint(),
+		Scopes:       c.cfg.Scopes,
+	}
+
+	verifier := c.provider.Verifier(&oidc.Config{
+		ClientID: c.cfg.ClientID,
+	})
+
+	return oauth, verifier, nil
+}
@@ -0,0 +1,62 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"cloud-notes/internal/config"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcryptMaxBytes is as much of the password as bcrypt reads, the rest is
+// ignored.
+const bcryptMaxBytes = 72
+
+// bcryptAlgorithm is kept to check the hashes made before argon2id became
+// the default. Bcrypt ignores everything past 72 bytes of the password, so
+// such hashes should be rehashed on the next login.
+type bcryptAlgorithm struct {
+	cost int
+}
+
+// newBcrypt rejects the costs the library would quietly replace with its
+// default, which would make every hash look outdated.
+func newBcrypt(cfg *config.PasswordHash) (*bcryptAlgorithm, error) {
+	if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf(
+			"PASSWORD_HASH_BCRYPT_COST must be between %d and %d",
+			bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	return &bcryptAlgorithm{cost: cfg.BcryptCost}, nil
+}
+
+func (a *bcryptAlgorithm) hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), a.cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes), nil
+}
+
+func (a *bcryptAlgorithm) compare(hash, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrMismatchedPassword
+	}
+
+	return err
+}
+
+func (a *bcryptAlgorithm) recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") ||
+		strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (a *bcryptAlgorithm) outdated(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != a.cost
+}
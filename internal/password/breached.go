@@ -0,0 +1,60 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1" // nolint
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const breachedPrefixLength = 5
+
+// breachedList looks passwords up in a local copy of a breached password
+// corpus in the k-anonymity range format: one <PREFIX>.txt file per first
+// five hex digits of the SHA-1 hash, with SUFFIX:COUNT lines. Only the one
+// file the password falls into is read.
+type breachedList struct {
+	dir      string
+	minCount int
+}
+
+func (b *breachedList) contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password)) // nolint
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:breachedPrefixLength], hash[breachedPrefixLength:]
+
+	file, err := os.Open(filepath.Join(b.dir, prefix+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to open breached range: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		lineSuffix, rawCount, ok := strings.Cut(line, ":")
+		if !ok || !strings.EqualFold(lineSuffix, suffix) {
+			continue
+		}
+
+		count, err := strconv.Atoi(rawCount)
+		if err != nil {
+			return false, fmt.Errorf("invalid breached range line: %s", line)
+		}
+
+		return count >= b.minCount, nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to read breached range: %w", err)
+	}
+
+	return false, nil
+}
@@ -0,0 +1,14 @@
+package password
+
+type Hasher interface {
+	Hash(password string) (string, error)
+	Compare(hash, password string) error
+	NeedsRehash(hash string) bool
+}
+
+// Policy checks new passwords. The personal values, such as the login and
+// the name, must not be part of the password.
+type Policy interface {
+	Check(password string, personal ...string) ([]*Violation, error)
+	History() int
+}
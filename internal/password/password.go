@@ -0,0 +1,94 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+
+	"cloud-notes/internal/config"
+)
+
+var (
+	ErrMismatchedPassword = errors.New("password doesn't match the hash")
+	ErrUnknownHash        = errors.New("unknown password hash format")
+)
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+// algorithm is a single hashing scheme. Every scheme recognizes its own
+// hashes, so hashes of different schemes can live side by side.
+type algorithm interface {
+	hash(password string) (string, error)
+	compare(hash, password string) error
+	recognizes(hash string) bool
+	outdated(hash string) bool
+}
+
+// hasher hashes new passwords with the configured algorithm and checks
+// existing ones with whichever algorithm they were hashed with.
+type hasher struct {
+	current    algorithm
+	algorithms []algorithm
+}
+
+// New checks the parameters of both algorithms, even of the one not used
+// for new hashes: they decide which existing hashes are outdated.
+func New(cfg *config.PasswordHash) (Hasher, error) {
+	argon, err := newArgon2id(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	bc, err := newBcrypt(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &hasher{
+		algorithms: []algorithm{argon, bc},
+	}
+
+	switch cfg.Algorithm {
+	case AlgorithmArgon2id:
+		h.current = argon
+	case AlgorithmBcrypt:
+		h.current = bc
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm: %s",
+			cfg.Algorithm)
+	}
+
+	return h, nil
+}
+
+func MustNew(cfg *config.PasswordHash) Hasher {
+	h, err := New(cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	return h
+}
+
+func (h *hasher) Hash(password string) (string, error) {
+	return h.current.hash(password)
+}
+
+func (h *hasher) Compare(hash, password string) error {
+	for _, a := range h.algorithms {
+		if a.recognizes(hash) {
+			return a.compare(hash, password)
+		}
+	}
+
+	return ErrUnknownHash
+}
+
+// NeedsRehash tells whether the hash was made with another algorithm or
+// with other parameters than the configured ones. It's meant to be called
+// right after a successful Compare, while the password is at hand.
+func (h *hasher) NeedsRehash(hash string) bool {
+	return !h.current.recognizes(hash) || h.current.outdated(hash)
+}
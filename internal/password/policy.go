@@ -0,0 +1,190 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"cloud-notes/internal/config"
+)
+
+const (
+	RuleMinLength = "min_length"
+	RuleMaxLength = "max_length"
+	RuleLower     = "lowercase"
+	RuleUpper     = "uppercase"
+	RuleDigit     = "digit"
+	RuleSymbol    = "symbol"
+	RulePersonal  = "personal_info"
+	RuleBreached  = "breached"
+	RuleHistory   = "history"
+)
+
+// personalMinLength keeps short names from rejecting half of all passwords.
+const personalMinLength = 3
+
+// Violation is a single failed rule with a message for the user.
+type Violation struct {
+	Rule    string
+	Message string
+}
+
+// PolicyError lists every rule the password fails at once, so the user
+// doesn't have to guess them one by one.
+type PolicyError struct {
+	Violations []*Violation
+}
+
+func (e *PolicyError) Error() string {
+	return "password doesn't meet the policy"
+}
+
+type policy struct {
+	cfg       *config.PasswordPolicy
+	maxLength int
+	maxBytes  int
+	breached  *breachedList
+}
+
+// NewPolicy takes the hashing config too: bcrypt ignores the password past
+// 72 bytes, so with bcrypt longer passwords are refused instead of being
+// cut short without a word.
+func NewPolicy(
+	cfg *config.PasswordPolicy, hash *config.PasswordHash) Policy {
+	p := &policy{
+		cfg:       cfg,
+		maxLength: cfg.MaxLength,
+	}
+
+	if hash.Algorithm == AlgorithmBcrypt {
+		p.maxBytes = bcryptMaxBytes
+		if p.maxLength <= 0 || p.maxLength > bcryptMaxBytes {
+			p.maxLength = bcryptMaxBytes
+		}
+	}
+
+	if cfg.BreachedDir != "" {
+		p.breached = &breachedList{
+			dir:      cfg.BreachedDir,
+			minCount: cfg.BreachedMinCount,
+		}
+	}
+
+	return p
+}
+
+func (p *policy) History() int {
+	return p.cfg.History
+}
+
+func (p *policy) Check(
+	password string, personal ...string) ([]*Violation, error) {
+	violations := make([]*Violation, 0)
+
+	length := utf8.RuneCountInString(password)
+	if length < p.cfg.MinLength {
+		violations = append(violations, &Violation{
+			Rule: RuleMinLength,
+			Message: fmt.Sprintf("must be at least %d characters long",
+				p.cfg.MinLength),
+		})
+	}
+
+	if p.maxLength > 0 && length > p.maxLength {
+		violations = append(violations, &Violation{
+			Rule: RuleMaxLength,
+			Message: fmt.Sprintf("must be at most %d characters long",
+				p.maxLength),
+		})
+	} else if p.maxBytes > 0 && len(password) > p.maxBytes {
+		violations = append(violations, &Violation{
+			Rule: RuleMaxLength,
+			Message: fmt.Sprintf("must be at most %d bytes long",
+				p.maxBytes),
+		})
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r), unicode.IsSymbol(r), unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	classes := []struct {
+		required bool
+		present  bool
+		rule     string
+		message  string
+	}{
+		{p.cfg.RequireLower, lower, RuleLower,
+			"must contain a lowercase letter"},
+		{p.cfg.RequireUpper, upper, RuleUpper,
+			"must contain an uppercase letter"},
+		{p.cfg.RequireDigit, digit, RuleDigit, "must contain a digit"},
+		{p.cfg.RequireSymbol, symbol, RuleSymbol, "must contain a symbol"},
+	}
+	for _, class := range classes {
+		if class.required && !class.present {
+			violations = append(violations, &Violation{
+				Rule:    class.rule,
+				Message: class.message,
+			})
+		}
+	}
+
+	if p.cfg.DisallowPersonal && containsPersonal(password, personal) {
+		violations = append(violations, &Violation{
+			Rule:    RulePersonal,
+			Message: "must not contain your login or name",
+		})
+	}
+
+	if p.breached != nil {
+		breached, err := p.breached.contains(password)
+		if err != nil {
+			return nil, err
+		}
+
+		if breached {
+			violations = append(violations, &Violation{
+				Rule: RuleBreached,
+				Message: "has appeared in a data breach and can't be " +
+					"used",
+			})
+		}
+	}
+
+	return violations, nil
+}
+
+// containsPersonal checks the password against the personal values and,
+// for emails, against the local part as well.
+func containsPersonal(password string, personal []string) bool {
+	password = strings.ToLower(password)
+
+	for _, value := range personal {
+		value = strings.ToLower(strings.TrimSpace(value))
+		parts := []string{value}
+		if local, _, ok := strings.Cut(value, "@"); ok {
+			parts = append(parts, local)
+		}
+
+		for _, part := range parts {
+			if utf8.RuneCountInString(part) >= personalMinLength &&
+				strings.Contains(password, part) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
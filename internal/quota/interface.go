@@ -0,0 +1,17 @@
+package quota
+
+import (
+	"context"
+
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+type Quota interface {
+	Limits(ctx context.Context, userID uuid.UUID) (*Limits, error)
+	Reserve(ctx context.Context, st storage.Storage,
+		userID uuid.UUID, notes, bytes, noteBytes int64) error
+	Release(ctx context.Context, st storage.Storage,
+		userID uuid.UUID, notes, bytes int64) error
+}
@@ -0,0 +1,158 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoteTooLarge = errors.New("note is too large")
+	ErrNotesLimit   = errors.New("notes limit exceeded")
+	ErrStorageLimit = errors.New("storage limit exceeded")
+)
+
+// Limits are the plan's limits with the admin's overrides applied.
+type Limits struct {
+	Plan          string
+	MaxNotes      int64
+	MaxTotalBytes int64
+	MaxNoteBytes  int64
+}
+
+type quota struct {
+	log logger.Logger
+	st  storage.Storage
+	cfg *config.Quota
+}
+
+func New(log logger.Logger, st storage.Storage, cfg *config.Quota) Quota {
+	return &quota{
+		log: log,
+		st:  st,
+		cfg: cfg,
+	}
+}
+
+// Limits finds the user's plan, the default one unless an admin picked
+// another, and applies the admin's overrides to it.
+func (q *quota) Limits(
+	ctx context.Context, userID uuid.UUID) (*Limits, error) {
+	return q.limits(ctx, q.st, userID)
+}
+
+// limits reads the limits from st, the transaction of the caller if there
+// is one.
+func (q *quota) limits(ctx context.Context,
+	st storage.Storage, userID uuid.UUID) (*Limits, error) {
+	const op = "quota.Limits"
+
+	override, err := st.Quotas().GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	name := q.cfg.DefaultPlan
+	if override != nil && override.Plan != nil {
+		name = *override.Plan
+	}
+
+	plan, err := st.Plans().GetByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if plan == nil {
+		return nil, fmt.Errorf("%s: unknown plan %q", op, name)
+	}
+
+	limits := &Limits{
+		Plan:          plan.Name,
+		MaxNotes:      plan.MaxNotes,
+		MaxTotalBytes: plan.MaxTotalBytes,
+		MaxNoteBytes:  plan.MaxNoteBytes,
+	}
+
+	if override == nil {
+		return limits, nil
+	}
+
+	if override.MaxNotes != nil {
+		limits.MaxNotes = *override.MaxNotes
+	}
+
+	if override.MaxTotalBytes != nil {
+		limits.MaxTotalBytes = *override.MaxTotalBytes
+	}
+
+	if override.MaxNoteBytes != nil {
+		limits.MaxNoteBytes = *override.MaxNoteBytes
+	}
+
+	return limits, nil
+}
+
+// Reserve adds the notes and the bytes to the user's usage, checking the
+// written note's size on the way. Nothing is reserved when a limit is
+// exceeded. st is the transaction that writes the notes, so the usage
+// changes only if they are written.
+func (q *quota) Reserve(ctx context.Context, st storage.Storage,
+	userID uuid.UUID, notes, bytes, noteBytes int64) error {
+	const op = "quota.Reserve"
+
+	limits, err := q.limits(ctx, st, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if noteBytes > limits.MaxNoteBytes {
+		return ErrNoteTooLarge
+	}
+
+	ok, err := st.Usage().Reserve(ctx, userID,
+		notes, bytes, limits.MaxNotes, limits.MaxTotalBytes)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if ok {
+		return nil
+	}
+
+	if notes <= 0 {
+		return ErrStorageLimit
+	}
+
+	usage, err := st.Usage().GetByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if usage != nil && usage.Notes+notes > limits.MaxNotes {
+		return ErrNotesLimit
+	}
+
+	return ErrStorageLimit
+}
+
+// Release takes the notes and the bytes back from the user's usage in st,
+// the transaction that deletes the notes.
+func (q *quota) Release(ctx context.Context, st storage.Storage,
+	userID uuid.UUID, notes, bytes int64) error {
+	const op = "quota.Release"
+
+	_, err := st.Usage().Reserve(ctx, userID,
+		-notes, -bytes, math.MaxInt64, math.MaxInt64)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -11,8 +11,20 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
+const (
+	CodeSessionExpired       = "session_expired"
+	CodeEmailNotVerified     = "email_not_verified"
+	CodeUserBlocked          = "user_blocked"
+	CodeUserDeleted          = "user_deleted"
+	CodeWeakPassword         = "weak_password"
+	CodeNoteTooLarge         = "note_too_large"
+	CodeNotesLimitExceeded   = "notes_limit_exceeded"
+	CodeStorageLimitExceeded = "storage_limit_exceeded"
+)
+
 type ErrorResponse struct {
 	Detail string `json:"detail"`
+	Code   string `json:"code,omitempty"`
 }
 
 func JSON(w http.ResponseWriter, statusCode int, v any) {
@@ -36,6 +48,15 @@ func Error(w http.ResponseWriter, statusCode int, err error) {
 	})
 }
 
+// CodeError adds a stable machine readable code to the error, so clients
+// can tell apart errors sharing the same status.
+func CodeError(w http.ResponseWriter, statusCode int, code string, err error) {
+	JSON(w, statusCode, &ErrorResponse{
+		Detail: err.Error(),
+		Code:   code,
+	})
+}
+
 func ValidationError(w http.ResponseWriter, request any, err error) {
 	var errs validator.ValidationErrors
 	errors.As(err, &errs)
@@ -0,0 +1,34 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"cloud-notes/internal/logger"
+)
+
+type Job func(ctx context.Context) error
+
+// Every runs the job once per interval until the context is done. A failed
+// run is logged and the job is tried again on the next tick.
+func Every(ctx context.Context, log logger.Logger, name string,
+	interval time.Duration, job Job) {
+	const op = "scheduler.Every"
+	log = log.With(logger.String("op", op), logger.String("job", name))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := job(ctx)
+		if err != nil {
+			log.ErrorContext(ctx, "job failed", logger.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -2,6 +2,7 @@ package security
 
 import (
 	"errors"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,10 +10,75 @@ import (
 
 var (
 	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
 )
 
+// Claims describe the caller. Requests authenticated with a personal token
+// have no session and are limited to the token's Scopes, while session
+// requests have nil Scopes and may do anything. Tokens issued to an OAuth
+// client carry its ClientID, the granted Scopes and an expiry. When an
+// admin acts as the user, ImpersonatorID is the admin.
+//
+// Role and Permissions aren't part of the token. They are loaded with the
+// user on every request, so role changes apply at once.
 type Claims struct {
+	UserID         uuid.UUID  `json:"user_id"`
+	SessionID      uuid.UUID  `json:"session_id"`
+	ClientID       *uuid.UUID `json:"client_id,omitempty"`
+	ImpersonatorID *uuid.UUID `json:"impersonator_id,omitempty"`
+	Scopes         []string   `json:"scopes,omitempty"`
+	Role           string     `json:"-"`
+	Permissions    []string   `json:"-"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// HasScopes tells whether the caller may use routes requiring all scopes.
+func (c *Claims) HasScopes(scopes ...string) bool {
+	if c.Scopes == nil {
+		return true
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(c.Scopes, scope) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// HasPermissions tells whether the caller's role grants all permissions.
+func (c *Claims) HasPermissions(permissions ...string) bool {
+	for _, permission := range permissions {
+		if !slices.Contains(c.Permissions, permission) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ChallengeClaims are issued after a successful password check for users
+// with two-factor authentication and are exchanged for an access token
+// once the second factor is verified.
+type ChallengeClaims struct {
+	UserID    uuid.UUID `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// VerificationClaims are put into email verification links. TokenID allows
+// the link to be used only once and to be replaced by a newer one.
+type VerificationClaims struct {
+	UserID    uuid.UUID `json:"user_id"`
+	TokenID   uuid.UUID `json:"token_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// DownloadClaims are put into export download links, so the archive can be
+// fetched without an access token until the export expires.
+type DownloadClaims struct {
 	UserID    uuid.UUID `json:"user_id"`
-	SessionID uuid.UUID `json:"session_id"`
-	CreatedAt time.Time `json:"created_at"`
+	ExportID  uuid.UUID `json:"export_id"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
@@ -7,4 +7,17 @@ import (
 type Security interface {
 	GenerateAccessToken(ctx context.Context, claims *Claims) string
 	ParseAccessToken(ctx context.Context, accessToken string) (*Claims, error)
+	ParsePersonalToken(
+		ctx context.Context, personalToken string) (*Claims, error)
+	GenerateChallengeToken(ctx context.Context, claims *ChallengeClaims) string
+	ParseChallengeToken(
+		ctx context.Context, challengeToken string) (*ChallengeClaims, error)
+	GenerateVerificationToken(
+		ctx context.Context, claims *VerificationClaims) string
+	ParseVerificationToken(ctx context.Context,
+		verificationToken string) (*VerificationClaims, error)
+	GenerateDownloadToken(ctx context.Context, claims *DownloadClaims) string
+	ParseDownloadToken(
+		ctx context.Context, downloadToken string) (*DownloadClaims, error)
+	JWKS(ctx context.Context) []*JWK
 }
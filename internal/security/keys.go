@@ -0,0 +1,274 @@
+package security
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"cloud-notes/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWK is a public verification key as published in the JWKS document.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type key struct {
+	id     string
+	method jwt.SigningMethod
+	sign   any
+	verify any
+	jwk    *JWK
+}
+
+// keySet holds the key new tokens are signed with and every key tokens are
+// still accepted with. Keeping the previous public keys in the set lets
+// tokens signed before a rotation live out their lifetime.
+type keySet struct {
+	signing      *key
+	verification map[string]*key
+}
+
+func loadKeys(cfg *config.JWT) (*keySet, error) {
+	ks := &keySet{
+		verification: make(map[string]*key),
+	}
+
+	// The shared secret has no kid. It is only used when HS256 is turned
+	// on explicitly, to accept the tokens issued before asymmetric keys
+	// were configured, otherwise a leaked secret could still forge tokens.
+	if cfg.LegacyHS256 {
+		if cfg.Secret == "" {
+			return nil, errors.New("jwt secret is required for HS256")
+		}
+
+		ks.verification[""] = &key{
+			method: jwt.SigningMethodHS256,
+			sign:   []byte(cfg.Secret),
+			verify: []byte(cfg.Secret),
+		}
+	}
+
+	switch cfg.Algorithm {
+	case jwt.SigningMethodHS256.Alg():
+		ks.signing = ks.verification[""]
+		if ks.signing == nil {
+			return nil, errors.New("HS256 requires JWT_LEGACY_HS256")
+		}
+	case jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg():
+		signing, err := loadPrivateKey(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if signing.method.Alg() != cfg.Algorithm {
+			return nil, fmt.Errorf("private key doesn't match %s",
+				cfg.Algorithm)
+		}
+
+		ks.signing = signing
+		ks.verification[signing.id] = signing
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm: %s", cfg.Algorithm)
+	}
+
+	for _, file := range cfg.PublicKeyFiles {
+		if file == "" {
+			continue
+		}
+
+		k, err := loadPublicKey(file)
+		if err != nil {
+			return nil, err
+		}
+
+		ks.verification[k.id] = k
+	}
+
+	return ks, nil
+}
+
+// algorithms lists the algorithms of the accepted keys, so tokens signed
+// with anything else are rejected before any key is looked up.
+func (ks *keySet) algorithms() []string {
+	seen := make(map[string]bool)
+	algorithms := make([]string, 0, len(ks.verification))
+	for _, k := range ks.verification {
+		if !seen[k.method.Alg()] {
+			seen[k.method.Alg()] = true
+			algorithms = append(algorithms, k.method.Alg())
+		}
+	}
+
+	return algorithms
+}
+
+// keyfunc picks the key by kid and accepts it only for its own algorithm,
+// so an RSA public key can never be used as an HMAC secret.
+func (ks *keySet) keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	k, ok := ks.verification[kid]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, ErrInvalidToken
+	}
+
+	return k.verify, nil
+}
+
+func (ks *keySet) sign(claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	if ks.signing.id != "" {
+		token.Header["kid"] = ks.signing.id
+	}
+
+	signed, _ := token.SignedString(ks.signing.sign)
+	return signed
+}
+
+// jwks returns the public keys only, shared secrets are never published.
+func (ks *keySet) jwks() []*JWK {
+	jwks := make([]*JWK, 0, len(ks.verification))
+	for _, k := range ks.verification {
+		if k.jwk != nil {
+			jwks = append(jwks, k.jwk)
+		}
+	}
+
+	return jwks
+}
+
+func loadPrivateKey(file string) (*key, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var private any
+	private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %s: %w", file, err)
+	}
+
+	switch private := private.(type) {
+	case *rsa.PrivateKey:
+		return newKey(jwt.SigningMethodRS256, private, &private.PublicKey)
+	case ed25519.PrivateKey:
+		return newKey(jwt.SigningMethodEdDSA, private, private.Public())
+	default:
+		return nil, fmt.Errorf("unsupported private key type in %s", file)
+	}
+}
+
+func loadPublicKey(file string) (*key, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var public any
+	public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		public, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %w", file, err)
+	}
+
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		return newKey(jwt.SigningMethodRS256, nil, public)
+	case ed25519.PublicKey:
+		return newKey(jwt.SigningMethodEdDSA, nil, public)
+	default:
+		return nil, fmt.Errorf("unsupported public key type in %s", file)
+	}
+}
+
+func readPEM(file string) (*pem.Block, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", file, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no pem data in %s", file)
+	}
+
+	return block, nil
+}
+
+// newKey derives the kid from the RFC 7638 thumbprint of the public key,
+// so every replica names the same key the same way without configuration.
+func newKey(method jwt.SigningMethod, private, public any) (*key, error) {
+	jwk := &JWK{
+		Use: "sig",
+		Alg: method.Alg(),
+	}
+
+	var thumbprint any
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(
+			big.NewInt(int64(public.E)).Bytes())
+		thumbprint = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		thumbprint = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	default:
+		return nil, errors.New("unsupported public key type")
+	}
+
+	data, err := json.Marshal(thumbprint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute key id: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	jwk.Kid = base64.RawURLEncoding.EncodeToString(sum[:])
+
+	return &key{
+		id:     jwk.Kid,
+		method: method,
+		sign:   private,
+		verify: public,
+		jwk:    jwk,
+	}, nil
+}
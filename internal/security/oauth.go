@@ -0,0 +1,55 @@
+package security
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+)
+
+// The prefixes tell the OAuth secrets apart from each other and from the
+// personal tokens, so one can never be presented in place of another.
+const (
+	clientSecretPrefix = "cns_"
+	refreshTokenPrefix = "cnr_"
+	authCodePrefix     = "cnc_"
+)
+
+// GenerateClientSecret returns a new OAuth client secret and its hash.
+func GenerateClientSecret() (string, string, error) {
+	secret, secretHash, err := generateToken(clientSecretPrefix)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate client secret: %w", err)
+	}
+
+	return secret, secretHash, nil
+}
+
+// GenerateRefreshToken returns a new OAuth refresh token and its hash.
+func GenerateRefreshToken() (string, string, error) {
+	token, tokenHash, err := generateToken(refreshTokenPrefix)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	return token, tokenHash, nil
+}
+
+// GenerateAuthCode returns a new OAuth authorization code and its hash.
+func GenerateAuthCode() (string, string, error) {
+	code, codeHash, err := generateToken(authCodePrefix)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate auth code: %w", err)
+	}
+
+	return code, codeHash, nil
+}
+
+// VerifyCodeChallenge checks the PKCE verifier against the S256 challenge
+// sent with the authorization request.
+func VerifyCodeChallenge(verifier, challenge string) bool {
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
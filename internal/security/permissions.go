@@ -0,0 +1,33 @@
+package security
+
+import (
+	"slices"
+)
+
+// Permissions are granted to users through their role and checked on the
+// routes that need them. Unlike scopes, they are never given to tokens.
+const (
+	PermissionUsersRead          = "users:read"
+	PermissionUsersWrite         = "users:write"
+	PermissionUsersImpersonate   = "users:impersonate"
+	PermissionRolesManage        = "roles:manage"
+	PermissionSecurityEventsRead = "security_events:read"
+	PermissionStatsRead          = "stats:read"
+	PermissionJobsManage         = "jobs:manage"
+)
+
+// Permissions lists every known permission, custom roles may only use
+// these.
+var Permissions = []string{
+	PermissionUsersRead,
+	PermissionUsersWrite,
+	PermissionUsersImpersonate,
+	PermissionRolesManage,
+	PermissionSecurityEventsRead,
+	PermissionStatsRead,
+	PermissionJobsManage,
+}
+
+func IsPermission(permission string) bool {
+	return slices.Contains(Permissions, permission)
+}
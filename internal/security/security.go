@@ -2,6 +2,8 @@ package security
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"cloud-notes/internal/config"
@@ -12,20 +14,45 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	purposeChallenge    = "challenge"
+	purposeVerification = "verification"
+	purposeDownload     = "download"
+)
+
+const personalTokenTouchPeriod = time.Minute
+
 type CtxKey struct{}
 
 type security struct {
-	log logger.Logger
-	st  storage.Storage
-	sec []byte
+	log  logger.Logger
+	st   storage.Storage
+	keys *keySet
+	ttl  time.Duration
 }
 
-func New(log logger.Logger, st storage.Storage, cfg *config.JWT) Security {
+func New(log logger.Logger, st storage.Storage,
+	cfg *config.JWT) (Security, error) {
+	keys, err := loadKeys(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load jwt keys: %w", err)
+	}
+
 	return &security{
-		log: log,
-		st:  st,
-		sec: []byte(cfg.Secret),
+		log:  log,
+		st:   st,
+		keys: keys,
+		ttl:  time.Second * time.Duration(cfg.ChallengeTTL),
+	}, nil
+}
+
+func MustNew(log logger.Logger, st storage.Storage, cfg *config.JWT) Security {
+	s, err := New(log, st, cfg)
+	if err != nil {
+		panic(err)
 	}
+
+	return s
 }
 
 func (s *security) GenerateAccessToken(
@@ -33,13 +60,29 @@ func (s *security) GenerateAccessToken(
 	const op = "security.GenerateAccessToken"
 	_ = s.log.With(logger.String("op", op))
 
-	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	mapClaims := jwt.MapClaims{
 		"user_id":    claims.UserID.String(),
 		"session_id": claims.SessionID.String(),
 		"created_at": claims.CreatedAt.String(),
-	}).SignedString(s.sec)
+	}
 
-	return token
+	if claims.ClientID != nil {
+		mapClaims["client_id"] = claims.ClientID.String()
+	}
+
+	if claims.ImpersonatorID != nil {
+		mapClaims["impersonator_id"] = claims.ImpersonatorID.String()
+	}
+
+	if claims.Scopes != nil {
+		mapClaims["scope"] = strings.Join(claims.Scopes, " ")
+	}
+
+	if claims.ExpiresAt != nil {
+		mapClaims["exp"] = jwt.NewNumericDate(*claims.ExpiresAt)
+	}
+
+	return s.keys.sign(mapClaims)
 }
 
 func (s *security) ParseAccessToken(
@@ -47,31 +90,305 @@ func (s *security) ParseAccessToken(
 	const op = "security.ParseAccessToken"
 	_ = s.log.With(logger.String("op", op))
 
-	token, err := jwt.Parse(accessToken,
-		func(token *jwt.Token) (interface{}, error) {
-			return s.sec, nil
-		})
+	claims, err := s.parse(accessToken)
 	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := claims["purpose"]; ok {
 		return nil, ErrInvalidToken
 	}
 
-	claims, _ := token.Claims.(jwt.MapClaims)
+	userID, err := parseUUID(claims, "user_id")
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID, err := parseUUID(claims, "session_id")
+	if err != nil {
+		return nil, err
+	}
 
-	userID := uuid.MustParse(claims["user_id"].(string))
-	sessionID := uuid.MustParse(claims["session_id"].(string))
-	createdAt, _ := time.Parse(time.RFC3339, claims["created_at"].(string))
+	rawCreatedAt, _ := claims["created_at"].(string)
+	createdAt, _ := time.Parse(time.RFC3339, rawCreatedAt)
 
-	return &Claims{
+	result := &Claims{
 		UserID:    userID,
 		SessionID: sessionID,
 		CreatedAt: createdAt,
+	}
+
+	// Client tokens are always scoped, a missing scope claim must not turn
+	// one into a full access session token.
+	if _, ok := claims["client_id"]; ok {
+		clientID, err := parseUUID(claims, "client_id")
+		if err != nil {
+			return nil, err
+		}
+
+		expiresAt, err := claims.GetExpirationTime()
+		if err != nil || expiresAt == nil {
+			return nil, ErrInvalidToken
+		}
+
+		scope, _ := claims["scope"].(string)
+		result.ClientID = &clientID
+		result.Scopes = append(make([]string, 0), strings.Fields(scope)...)
+		result.ExpiresAt = &expiresAt.Time
+	}
+
+	if _, ok := claims["impersonator_id"]; ok {
+		impersonatorID, err := parseUUID(claims, "impersonator_id")
+		if err != nil {
+			return nil, err
+		}
+
+		result.ImpersonatorID = &impersonatorID
+	}
+
+	return result, nil
+}
+
+// ParsePersonalToken looks the token up in storage and records its use at
+// most once per personalTokenTouchPeriod to spare writes on every request.
+func (s *security) ParsePersonalToken(
+	ctx context.Context, personalToken string) (*Claims, error) {
+	const op = "security.ParsePersonalToken"
+	_ = s.log.With(logger.String("op", op))
+
+	token, err := s.st.PersonalTokens().GetByHash(
+		ctx, HashPersonalToken(personalToken))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if token == nil {
+		return nil, ErrInvalidToken
+	}
+
+	now := time.Now()
+	if token.ExpiresAt != nil && !token.ExpiresAt.After(now) {
+		return nil, ErrTokenExpired
+	}
+
+	if token.LastUsedAt == nil ||
+		now.Sub(*token.LastUsedAt) >= personalTokenTouchPeriod {
+		token.LastUsedAt = &now
+		err = s.st.PersonalTokens().Update(ctx, token)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// Nil scopes would grant full access, so a token without any scopes
+	// still gets an empty list.
+	scopes := token.Scopes
+	if scopes == nil {
+		scopes = make([]string, 0)
+	}
+
+	return &Claims{
+		UserID:    token.UserID,
+		Scopes:    scopes,
+		CreatedAt: token.CreatedAt,
 	}, nil
 }
 
+func (s *security) GenerateChallengeToken(
+	_ context.Context, claims *ChallengeClaims) string {
+	const op = "security.GenerateChallengeToken"
+	_ = s.log.With(logger.String("op", op))
+
+	if claims.ExpiresAt.IsZero() {
+		claims.ExpiresAt = time.Now().Add(s.ttl)
+	}
+
+	token := s.keys.sign(jwt.MapClaims{
+		"purpose": purposeChallenge,
+		"user_id": claims.UserID.String(),
+		"exp":     jwt.NewNumericDate(claims.ExpiresAt),
+	})
+
+	return token
+}
+
+func (s *security) ParseChallengeToken(
+	_ context.Context, challengeToken string) (*ChallengeClaims, error) {
+	const op = "security.ParseChallengeToken"
+	_ = s.log.With(logger.String("op", op))
+
+	claims, err := s.parse(challengeToken, jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+
+	if purpose, _ := claims["purpose"].(string); purpose != purposeChallenge {
+		return nil, ErrInvalidToken
+	}
+
+	userID, err := parseUUID(claims, "user_id")
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return &ChallengeClaims{
+		UserID:    userID,
+		ExpiresAt: expiresAt.Time,
+	}, nil
+}
+
+func (s *security) GenerateVerificationToken(
+	_ context.Context, claims *VerificationClaims) string {
+	const op = "security.GenerateVerificationToken"
+	_ = s.log.With(logger.String("op", op))
+
+	token := s.keys.sign(jwt.MapClaims{
+		"purpose": purposeVerification,
+		"user_id": claims.UserID.String(),
+		"jti":     claims.TokenID.String(),
+		"exp":     jwt.NewNumericDate(claims.ExpiresAt),
+	})
+
+	return token
+}
+
+func (s *security) ParseVerificationToken(
+	_ context.Context, verificationToken string) (*VerificationClaims, error) {
+	const op = "security.ParseVerificationToken"
+	_ = s.log.With(logger.String("op", op))
+
+	claims, err := s.parse(verificationToken, jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+
+	purpose, _ := claims["purpose"].(string)
+	if purpose != purposeVerification {
+		return nil, ErrInvalidToken
+	}
+
+	userID, err := parseUUID(claims, "user_id")
+	if err != nil {
+		return nil, err
+	}
+
+	tokenID, err := parseUUID(claims, "jti")
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return &VerificationClaims{
+		UserID:    userID,
+		TokenID:   tokenID,
+		ExpiresAt: expiresAt.Time,
+	}, nil
+}
+
+func (s *security) GenerateDownloadToken(
+	_ context.Context, claims *DownloadClaims) string {
+	const op = "security.GenerateDownloadToken"
+	_ = s.log.With(logger.String("op", op))
+
+	token := s.keys.sign(jwt.MapClaims{
+		"purpose":   purposeDownload,
+		"user_id":   claims.UserID.String(),
+		"export_id": claims.ExportID.String(),
+		"exp":       jwt.NewNumericDate(claims.ExpiresAt),
+	})
+
+	return token
+}
+
+func (s *security) ParseDownloadToken(
+	_ context.Context, downloadToken string) (*DownloadClaims, error) {
+	const op = "security.ParseDownloadToken"
+	_ = s.log.With(logger.String("op", op))
+
+	claims, err := s.parse(downloadToken, jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+
+	purpose, _ := claims["purpose"].(string)
+	if purpose != purposeDownload {
+		return nil, ErrInvalidToken
+	}
+
+	userID, err := parseUUID(claims, "user_id")
+	if err != nil {
+		return nil, err
+	}
+
+	exportID, err := parseUUID(claims, "export_id")
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return &DownloadClaims{
+		UserID:    userID,
+		ExportID:  exportID,
+		ExpiresAt: expiresAt.Time,
+	}, nil
+}
+
+func (s *security) JWKS(_ context.Context) []*JWK {
+	const op = "security.JWKS"
+	_ = s.log.With(logger.String("op", op))
+
+	return s.keys.jwks()
+}
+
+func (s *security) parse(
+	token string, opts ...jwt.ParserOption) (jwt.MapClaims, error) {
+	opts = append(opts, jwt.WithValidMethods(s.keys.algorithms()))
+
+	parsed, err := jwt.Parse(token, s.keys.keyfunc, opts...)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+func parseUUID(claims jwt.MapClaims, key string) (uuid.UUID, error) {
+	raw, _ := claims[key].(string)
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, ErrInvalidToken
+	}
+
+	return id, nil
+}
+
 func GetClaims(ctx context.Context) *Claims {
 	return ctx.Value(CtxKey{}).(*Claims)
 }
 
+func LookupClaims(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(CtxKey{}).(*Claims)
+	return claims, ok
+}
+
 func SetClaims(ctx context.Context, claims *Claims) context.Context {
 	ctx = context.WithValue(ctx, CtxKey{}, claims)
 	return ctx
@@ -0,0 +1,68 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const webhookSecretPrefix = "cnw_"
+
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// GenerateWebhookSecret returns a new secret for signing webhook
+// deliveries. Unlike the tokens, it is needed as is to sign, so there is
+// no hash to store instead.
+func GenerateWebhookSecret() (string, error) {
+	secret, _, err := generateToken(webhookSecretPrefix)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	return secret, nil
+}
+
+// SignWebhook signs the delivery body with HMAC-SHA256. The timestamp is
+// signed too, so receivers can reject old deliveries replayed by others.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook checks the "t=<unix time>,v1=<signature>" header the way
+// receivers should: the signature has to match and be recent enough.
+func VerifyWebhook(secret, header string, body []byte,
+	tolerance time.Duration, now time.Time) error {
+	var timestamp int64
+	var signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp, _ = strconv.ParseInt(value, 10, 64)
+		case "v1":
+			signature = value
+		}
+	}
+
+	signedAt := time.Unix(timestamp, 0)
+	if timestamp == 0 || now.Sub(signedAt).Abs() > tolerance {
+		return ErrInvalidWebhookSignature
+	}
+
+	expected := SignWebhook(secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidWebhookSignature
+	}
+
+	return nil
+}
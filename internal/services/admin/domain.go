@@ -0,0 +1,233 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrUserDeleted       = errors.New("user deleted")
+	ErrUserNotBlocked    = errors.New("user not blocked")
+	ErrBlockSelf         = errors.New("can't block yourself")
+	ErrInvalidExpiry     = errors.New("block expiry must be in the future")
+	ErrInvalidPeriod     = errors.New("period start is after its end")
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrRoleExists        = errors.New("role already exists")
+	ErrRoleBuiltin       = errors.New("builtin roles can't be changed")
+	ErrRoleInUse         = errors.New("role is assigned to users")
+	ErrInvalidRoleName   = errors.New("invalid role name")
+	ErrUnknownPermission = errors.New("unknown permission")
+	ErrChangeOwnRole     = errors.New("can't change your own role")
+	ErrUserNotActive     = errors.New("user not active")
+	ErrImpersonateSelf   = errors.New("can't impersonate yourself")
+	ErrPlanNotFound      = errors.New("plan not found")
+	ErrJobNotFound       = errors.New("job not found")
+	ErrJobNotFailed      = errors.New("only failed jobs can be retried")
+)
+
+// BlockUserInput blocks the user. AdminID is nil when the block doesn't
+// come from an admin's request, like one from the command line.
+type BlockUserInput struct {
+	AdminID   *uuid.UUID
+	UserID    uuid.UUID
+	Reason    string
+	ExpiresAt *time.Time
+}
+
+// ResetPasswordInput sets the user's password to Password, which still
+// has to meet the password policy.
+type ResetPasswordInput struct {
+	UserID   uuid.UUID
+	Password string
+}
+
+// GetSecurityEventsInput filters the audit trail. Nil fields match
+// everything.
+type GetSecurityEventsInput struct {
+	UserID *uuid.UUID
+	Type   *string
+	IP     *string
+	From   *time.Time
+	To     *time.Time
+	Limit  *uint64
+	Offset *uint64
+}
+
+type SecurityEventOutput struct {
+	ID        uuid.UUID
+	UserID    *uuid.UUID
+	ActorID   *uuid.UUID
+	Type      string
+	Login     *string
+	IP        *string
+	UserAgent *string
+	Details   map[string]string
+	CreatedAt time.Time
+}
+
+type GetSecurityEventsOutput struct {
+	Events []*SecurityEventOutput
+}
+
+// ListUsersInput filters the user list. Query matches a part of the login
+// or the first name.
+type ListUsersInput struct {
+	Query  *string
+	Status *string
+	Role   *string
+	Limit  *uint64
+	Offset *uint64
+}
+
+type UserOutput struct {
+	ID        uuid.UUID
+	Login     string
+	Email     *string
+	FirstName string
+	Timezone  string
+	Status    string
+	Role      string
+	CreatedAt time.Time
+}
+
+type ListUsersOutput struct {
+	Users []*UserOutput
+	Total uint64
+}
+
+type BlockOutput struct {
+	Reason    string
+	BlockedBy *uuid.UUID
+	ExpiresAt *time.Time
+	CreatedAt time.Time
+}
+
+type GetUserOutput struct {
+	UserOutput
+	NotesCount    uint64
+	SessionsCount uint64
+	Block         *BlockOutput
+}
+
+type SetRoleInput struct {
+	AdminID uuid.UUID
+	UserID  uuid.UUID
+	Role    string
+}
+
+type ImpersonateUserInput struct {
+	AdminID   uuid.UUID
+	UserID    uuid.UUID
+	UserAgent *string
+}
+
+type ImpersonateUserOutput struct {
+	AccessToken string
+	ExpiresAt   time.Time
+}
+
+type RoleOutput struct {
+	Name        string
+	Description string
+	Permissions []string
+	Builtin     bool
+	CreatedAt   time.Time
+}
+
+type GetRolesOutput struct {
+	Roles []*RoleOutput
+}
+
+type SaveRoleInput struct {
+	Name        string
+	Description string
+	Permissions []string
+}
+
+type UserStats struct {
+	Total   uint64
+	Pending uint64
+	Active  uint64
+	Blocked uint64
+	Deleted uint64
+	Admins  uint64
+}
+
+type GetStatsOutput struct {
+	Users          UserStats
+	NewUsersDay    uint64
+	NewUsersWeek   uint64
+	Notes          uint64
+	ActiveSessions uint64
+}
+
+type PlanOutput struct {
+	Name          string
+	MaxNotes      int64
+	MaxTotalBytes int64
+	MaxNoteBytes  int64
+	Default       bool
+}
+
+type GetPlansOutput struct {
+	Plans []*PlanOutput
+}
+
+// QuotaOutput is the user's usage next to the limits in effect. Override
+// is what an admin changed, nil when the user has the default limits.
+type QuotaOutput struct {
+	Plan          string
+	Notes         int64
+	MaxNotes      int64
+	Bytes         int64
+	MaxTotalBytes int64
+	MaxNoteBytes  int64
+	Override      *QuotaOverrideOutput
+}
+
+type QuotaOverrideOutput struct {
+	Plan          *string
+	MaxNotes      *int64
+	MaxTotalBytes *int64
+	MaxNoteBytes  *int64
+	UpdatedAt     time.Time
+}
+
+// SetQuotaInput replaces the user's override. Nil fields fall back to the
+// default plan and to the plan's limits.
+type SetQuotaInput struct {
+	UserID        uuid.UUID
+	Plan          *string
+	MaxNotes      *int64
+	MaxTotalBytes *int64
+	MaxNoteBytes  *int64
+}
+
+// ListJobsInput filters the job list. Nil fields match every job, failed
+// jobs are the dead letters.
+type ListJobsInput struct {
+	Status *string
+	Type   *string
+	Limit  *uint64
+	Offset *uint64
+}
+
+type JobOutput struct {
+	ID         uuid.UUID
+	Type       string
+	Payload    json.RawMessage
+	Status     string
+	Attempts   int
+	RunAt      time.Time
+	Error      *string
+	FinishedAt *time.Time
+	CreatedAt  time.Time
+}
+
+type ListJobsOutput struct {
+	Jobs []*JobOutput
+}
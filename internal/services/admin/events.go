@@ -0,0 +1,60 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/storage"
+)
+
+const (
+	defaultSecurityEventsLimit = 50
+	maxSecurityEventsLimit     = 500
+)
+
+func (s *service) GetSecurityEvents(ctx context.Context,
+	input *GetSecurityEventsInput) (*GetSecurityEventsOutput, error) {
+	const op = "services.admin.GetSecurityEvents"
+	_ = s.log.With(logger.String("op", op))
+
+	if input.From != nil && input.To != nil && input.From.After(*input.To) {
+		return nil, ErrInvalidPeriod
+	}
+
+	limit := uint64(defaultSecurityEventsLimit)
+	if input.Limit != nil {
+		limit = min(*input.Limit, maxSecurityEventsLimit)
+	}
+
+	events, err := s.st.SecurityEvents().Find(ctx,
+		&storage.SecurityEventFilter{
+			UserID: input.UserID,
+			Type:   input.Type,
+			IP:     input.IP,
+			From:   input.From,
+			To:     input.To,
+			Limit:  &limit,
+			Offset: input.Offset,
+		})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	output := new(GetSecurityEventsOutput)
+	for _, event := range events {
+		output.Events = append(output.Events, &SecurityEventOutput{
+			ID:        event.ID,
+			UserID:    event.UserID,
+			ActorID:   event.ActorID,
+			Type:      event.Type,
+			Login:     event.Login,
+			IP:        event.IP,
+			UserAgent: event.UserAgent,
+			Details:   event.Details,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+
+	return output, nil
+}
@@ -0,0 +1,35 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	BlockUser(ctx context.Context, input *BlockUserInput) error
+	UnblockUser(ctx context.Context, userID uuid.UUID) error
+	ListUsers(ctx context.Context,
+		input *ListUsersInput) (*ListUsersOutput, error)
+	GetUser(ctx context.Context, userID uuid.UUID) (*GetUserOutput, error)
+	SetRole(ctx context.Context, input *SetRoleInput) error
+	LogoutUser(ctx context.Context, userID uuid.UUID) error
+	ResetPassword(ctx context.Context, input *ResetPasswordInput) error
+	ImpersonateUser(ctx context.Context,
+		input *ImpersonateUserInput) (*ImpersonateUserOutput, error)
+	GetStats(ctx context.Context) (*GetStatsOutput, error)
+	GetRoles(ctx context.Context) (*GetRolesOutput, error)
+	CreateRole(ctx context.Context, input *SaveRoleInput) (*RoleOutput, error)
+	UpdateRole(ctx context.Context, input *SaveRoleInput) (*RoleOutput, error)
+	DeleteRole(ctx context.Context, name string) error
+	GetSecurityEvents(ctx context.Context,
+		input *GetSecurityEventsInput) (*GetSecurityEventsOutput, error)
+	GetPlans(ctx context.Context) (*GetPlansOutput, error)
+	GetQuota(ctx context.Context, userID uuid.UUID) (*QuotaOutput, error)
+	SetQuota(ctx context.Context, input *SetQuotaInput) (*QuotaOutput, error)
+	ResetQuota(ctx context.Context, userID uuid.UUID) error
+	ListJobs(ctx context.Context,
+		input *ListJobsInput) (*ListJobsOutput, error)
+	GetJob(ctx context.Context, jobID uuid.UUID) (*JobOutput, error)
+	RetryJob(ctx context.Context, jobID uuid.UUID) (*JobOutput, error)
+}
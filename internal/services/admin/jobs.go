@@ -0,0 +1,132 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/audit"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultJobsLimit = 50
+	maxJobsLimit     = 500
+)
+
+func (s *service) ListJobs(ctx context.Context,
+	input *ListJobsInput) (*ListJobsOutput, error) {
+	const op = "services.admin.ListJobs"
+	_ = s.log.With(logger.String("op", op))
+
+	filter := new(storage.JobFilter)
+	if input.Status != nil {
+		filter.Status = storage.JobStatus(*input.Status)
+	}
+	if input.Type != nil {
+		filter.Type = *input.Type
+	}
+
+	limit := uint64(defaultJobsLimit)
+	if input.Limit != nil {
+		limit = min(*input.Limit, maxJobsLimit)
+	}
+
+	var offset uint64
+	if input.Offset != nil {
+		offset = *input.Offset
+	}
+
+	jobs, err := s.st.Jobs().List(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	output := &ListJobsOutput{Jobs: make([]*JobOutput, 0, len(jobs))}
+	for _, job := range jobs {
+		output.Jobs = append(output.Jobs, toJobOutput(job))
+	}
+
+	return output, nil
+}
+
+func (s *service) GetJob(
+	ctx context.Context, jobID uuid.UUID) (*JobOutput, error) {
+	const op = "services.admin.GetJob"
+	_ = s.log.With(logger.String("op", op))
+
+	job, err := s.st.Jobs().GetByID(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if job == nil {
+		return nil, ErrJobNotFound
+	}
+
+	return toJobOutput(job), nil
+}
+
+// RetryJob takes a failed job out of the dead letters and gives it a fresh
+// set of attempts. The last error stays until the job succeeds.
+func (s *service) RetryJob(
+	ctx context.Context, jobID uuid.UUID) (*JobOutput, error) {
+	const op = "services.admin.RetryJob"
+	_ = s.log.With(logger.String("op", op))
+
+	job, err := s.st.Jobs().GetByID(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if job == nil {
+		return nil, ErrJobNotFound
+	}
+
+	if job.Status != storage.JobStatusFailed {
+		return nil, ErrJobNotFailed
+	}
+
+	lease := job.RunAt
+	job.Status = storage.JobStatusPending
+	job.Attempts = 0
+	job.RunAt = time.Now()
+	job.FinishedAt = nil
+
+	// A job retried twice at once goes back to the queue once.
+	updated, err := s.st.Jobs().Update(ctx, job, lease)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !updated {
+		return nil, ErrJobNotFailed
+	}
+
+	s.au.Record(ctx, &audit.Event{
+		Type: audit.EventJobRetried,
+		Details: map[string]string{
+			"job_id": job.ID.String(),
+			"type":   job.Type,
+		},
+	})
+
+	return toJobOutput(job), nil
+}
+
+func toJobOutput(job *storage.Job) *JobOutput {
+	return &JobOutput{
+		ID:         job.ID,
+		Type:       job.Type,
+		Payload:    job.Payload,
+		Status:     string(job.Status),
+		Attempts:   job.Attempts,
+		RunAt:      job.RunAt,
+		Error:      job.Error,
+		FinishedAt: job.FinishedAt,
+		CreatedAt:  job.CreatedAt,
+	}
+}
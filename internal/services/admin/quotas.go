@@ -0,0 +1,190 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"cloud-notes/internal/audit"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+func (s *service) GetPlans(ctx context.Context) (*GetPlansOutput, error) {
+	const op = "services.admin.GetPlans"
+	_ = s.log.With(logger.String("op", op))
+
+	plans, err := s.st.Plans().GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	output := new(GetPlansOutput)
+	for _, plan := range plans {
+		output.Plans = append(output.Plans, &PlanOutput{
+			Name:          plan.Name,
+			MaxNotes:      plan.MaxNotes,
+			MaxTotalBytes: plan.MaxTotalBytes,
+			MaxNoteBytes:  plan.MaxNoteBytes,
+			Default:       plan.Name == s.cfg.Quota.DefaultPlan,
+		})
+	}
+
+	return output, nil
+}
+
+func (s *service) GetQuota(
+	ctx context.Context, userID uuid.UUID) (*QuotaOutput, error) {
+	const op = "services.admin.GetQuota"
+	_ = s.log.With(logger.String("op", op))
+
+	user, err := s.st.Users().GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	output, err := s.quota(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return output, nil
+}
+
+// SetQuota replaces the user's override, so the fields left out go back to
+// the plan's limits. Limits below the current usage are allowed: nothing
+// is deleted, the user just can't add more until under the limit again.
+func (s *service) SetQuota(
+	ctx context.Context, input *SetQuotaInput) (*QuotaOutput, error) {
+	const op = "services.admin.SetQuota"
+	_ = s.log.With(logger.String("op", op))
+
+	user, err := s.st.Users().GetByID(ctx, input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	if input.Plan != nil {
+		plan, err := s.st.Plans().GetByName(ctx, *input.Plan)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if plan == nil {
+			return nil, ErrPlanNotFound
+		}
+	}
+
+	err = s.st.Quotas().Create(ctx, &storage.Quota{
+		UserID:        user.ID,
+		Plan:          input.Plan,
+		MaxNotes:      input.MaxNotes,
+		MaxTotalBytes: input.MaxTotalBytes,
+		MaxNoteBytes:  input.MaxNoteBytes,
+		UpdatedAt:     time.Now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	output, err := s.quota(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.au.Record(ctx, &audit.Event{
+		Type:   audit.EventQuotaChanged,
+		UserID: &user.ID,
+		Details: map[string]string{
+			"plan":            output.Plan,
+			"max_notes":       strconv.FormatInt(output.MaxNotes, 10),
+			"max_total_bytes": strconv.FormatInt(output.MaxTotalBytes, 10),
+			"max_note_bytes":  strconv.FormatInt(output.MaxNoteBytes, 10),
+		},
+	})
+
+	return output, nil
+}
+
+// ResetQuota drops the user's override, back to the default plan.
+func (s *service) ResetQuota(ctx context.Context, userID uuid.UUID) error {
+	const op = "services.admin.ResetQuota"
+	_ = s.log.With(logger.String("op", op))
+
+	user, err := s.st.Users().GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	err = s.st.Quotas().Delete(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.au.Record(ctx, &audit.Event{
+		Type:    audit.EventQuotaChanged,
+		UserID:  &user.ID,
+		Details: map[string]string{"plan": s.cfg.Quota.DefaultPlan},
+	})
+
+	return nil
+}
+
+func (s *service) quota(
+	ctx context.Context, userID uuid.UUID) (*QuotaOutput, error) {
+	const op = "services.admin.quota"
+
+	limits, err := s.qt.Limits(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	usage, err := s.st.Usage().GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	override, err := s.st.Quotas().GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	output := &QuotaOutput{
+		Plan:          limits.Plan,
+		MaxNotes:      limits.MaxNotes,
+		MaxTotalBytes: limits.MaxTotalBytes,
+		MaxNoteBytes:  limits.MaxNoteBytes,
+	}
+
+	if usage != nil {
+		output.Notes = usage.Notes
+		output.Bytes = usage.Bytes
+	}
+
+	if override != nil {
+		output.Override = &QuotaOverrideOutput{
+			Plan:          override.Plan,
+			MaxNotes:      override.MaxNotes,
+			MaxTotalBytes: override.MaxTotalBytes,
+			MaxNoteBytes:  override.MaxNoteBytes,
+			UpdatedAt:     override.UpdatedAt,
+		}
+	}
+
+	return output, nil
+}
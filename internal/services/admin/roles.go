@@ -0,0 +1,181 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/storage"
+)
+
+const maxRoleNameLength = 50
+
+func (s *service) GetRoles(ctx context.Context) (*GetRolesOutput, error) {
+	const op = "services.admin.GetRoles"
+	_ = s.log.With(logger.String("op", op))
+
+	roles, err := s.st.Roles().GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	output := &GetRolesOutput{
+		Roles: make([]*RoleOutput, 0, len(roles)),
+	}
+	for _, role := range roles {
+		output.Roles = append(output.Roles, toRoleOutput(role))
+	}
+
+	return output, nil
+}
+
+func (s *service) CreateRole(
+	ctx context.Context, input *SaveRoleInput) (*RoleOutput, error) {
+	const op = "services.admin.CreateRole"
+	_ = s.log.With(logger.String("op", op))
+
+	if !validRoleName(input.Name) {
+		return nil, ErrInvalidRoleName
+	}
+
+	permissions, err := checkPermissions(input.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := s.st.Roles().GetByName(ctx, input.Name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if role != nil {
+		return nil, ErrRoleExists
+	}
+
+	role = &storage.Role{
+		Name:        input.Name,
+		Description: input.Description,
+		Permissions: permissions,
+		CreatedAt:   time.Now(),
+	}
+
+	err = s.st.Roles().Create(ctx, role)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return toRoleOutput(role), nil
+}
+
+func (s *service) UpdateRole(
+	ctx context.Context, input *SaveRoleInput) (*RoleOutput, error) {
+	const op = "services.admin.UpdateRole"
+	_ = s.log.With(logger.String("op", op))
+
+	permissions, err := checkPermissions(input.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := s.st.Roles().GetByName(ctx, input.Name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if role == nil {
+		return nil, ErrRoleNotFound
+	}
+
+	if role.Builtin {
+		return nil, ErrRoleBuiltin
+	}
+
+	role.Description = input.Description
+	role.Permissions = permissions
+	err = s.st.Roles().Update(ctx, role)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return toRoleOutput(role), nil
+}
+
+// DeleteRole removes a custom role. Users have to be moved to another role
+// first, so nobody silently loses their permissions.
+func (s *service) DeleteRole(ctx context.Context, name string) error {
+	const op = "services.admin.DeleteRole"
+	_ = s.log.With(logger.String("op", op))
+
+	role, err := s.st.Roles().GetByName(ctx, name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if role == nil {
+		return ErrRoleNotFound
+	}
+
+	if role.Builtin {
+		return ErrRoleBuiltin
+	}
+
+	users, err := s.st.Roles().CountUsers(ctx, role.Name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if users > 0 {
+		return ErrRoleInUse
+	}
+
+	err = s.st.Roles().Delete(ctx, role.Name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// validRoleName allows lowercase letters, digits, "_" and "-", so role
+// names are safe to use in URLs and query parameters.
+func validRoleName(name string) bool {
+	if name == "" || len(name) > maxRoleNameLength {
+		return false
+	}
+
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '-':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func checkPermissions(permissions []string) ([]string, error) {
+	for _, permission := range permissions {
+		if !security.IsPermission(permission) {
+			return nil, ErrUnknownPermission
+		}
+	}
+
+	// A nil slice would be stored as NULL.
+	result := append(make([]string, 0, len(permissions)), permissions...)
+	slices.Sort(result)
+	return slices.Compact(result), nil
+}
+
+func toRoleOutput(role *storage.Role) *RoleOutput {
+	return &RoleOutput{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: role.Permissions,
+		Builtin:     role.Builtin,
+		CreatedAt:   role.CreatedAt,
+	}
+}
@@ -0,0 +1,398 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/audit"
+	"cloud-notes/internal/events"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/password"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultUsersLimit = 50
+	maxUsersLimit     = 500
+)
+
+func (s *service) ListUsers(ctx context.Context,
+	input *ListUsersInput) (*ListUsersOutput, error) {
+	const op = "services.admin.ListUsers"
+	_ = s.log.With(logger.String("op", op))
+
+	filter := &storage.UserFilter{
+		Query: input.Query,
+	}
+
+	if input.Status != nil {
+		status := storage.UserStatus(*input.Status)
+		filter.Status = &status
+	}
+
+	if input.Role != nil {
+		role := storage.UserRole(*input.Role)
+		filter.Role = &role
+	}
+
+	limit := uint64(defaultUsersLimit)
+	if input.Limit != nil {
+		limit = min(*input.Limit, maxUsersLimit)
+	}
+
+	users, err := s.st.Users().List(ctx, filter, &limit, input.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	total, err := s.st.Users().Count(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	output := &ListUsersOutput{
+		Users: make([]*UserOutput, 0, len(users)),
+		Total: total,
+	}
+	for _, user := range users {
+		output.Users = append(output.Users, toUserOutput(user))
+	}
+
+	return output, nil
+}
+
+func (s *service) GetUser(
+	ctx context.Context, userID uuid.UUID) (*GetUserOutput, error) {
+	const op = "services.admin.GetUser"
+	_ = s.log.With(logger.String("op", op))
+
+	user, err := s.st.Users().GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	notesCount, err := s.st.Notes().Count(ctx, &user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sessionsCount, err := s.st.Sessions().CountActive(ctx, &user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	output := &GetUserOutput{
+		UserOutput:    *toUserOutput(user),
+		NotesCount:    notesCount,
+		SessionsCount: sessionsCount,
+	}
+
+	if user.Status == storage.UserStatusBlocked {
+		block, err := s.st.Blocks().GetByUserID(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if block != nil {
+			output.Block = &BlockOutput{
+				Reason:    block.Reason,
+				BlockedBy: block.BlockedBy,
+				ExpiresAt: block.ExpiresAt,
+				CreatedAt: block.CreatedAt,
+			}
+		}
+	}
+
+	return output, nil
+}
+
+func (s *service) SetRole(ctx context.Context, input *SetRoleInput) error {
+	const op = "services.admin.SetRole"
+	_ = s.log.With(logger.String("op", op))
+
+	// Otherwise the last admin could lock everyone out of the admin API.
+	if input.UserID == input.AdminID {
+		return ErrChangeOwnRole
+	}
+
+	found, err := s.st.Roles().GetByName(ctx, input.Role)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if found == nil {
+		return ErrRoleNotFound
+	}
+
+	role := storage.UserRole(found.Name)
+	user, err := s.st.Users().GetByID(ctx, input.UserID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	if user.Role == role {
+		return nil
+	}
+
+	previous := user.Role
+	user.Role = role
+	err = s.st.Users().Update(ctx, user)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.au.Record(ctx, &audit.Event{
+		Type:   audit.EventRoleChanged,
+		UserID: &user.ID,
+		Details: map[string]string{
+			"from": string(previous),
+			"to":   string(role),
+		},
+	})
+
+	return nil
+}
+
+// LogoutUser ends every session of the user, including OAuth grants.
+func (s *service) LogoutUser(ctx context.Context, userID uuid.UUID) error {
+	const op = "services.admin.LogoutUser"
+	_ = s.log.With(logger.String("op", op))
+
+	user, err := s.st.Users().GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	err = s.st.Sessions().DeleteByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.au.Record(ctx, &audit.Event{
+		Type:   audit.EventForcedLogout,
+		UserID: &user.ID,
+	})
+
+	return nil
+}
+
+// ResetPassword sets a new password and ends everything issued with the
+// old one, like the reset by email does.
+func (s *service) ResetPassword(
+	ctx context.Context, input *ResetPasswordInput) error {
+	const op = "services.admin.ResetPassword"
+	log := s.log.With(logger.String("op", op))
+
+	user, err := s.st.Users().GetByID(ctx, input.UserID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	if user.Status == storage.UserStatusDeleted {
+		return ErrUserDeleted
+	}
+
+	violations, err := s.pp.Check(input.Password, user.Login, user.FirstName)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(violations) > 0 {
+		return &password.PolicyError{
+			Violations: violations,
+		}
+	}
+
+	user.PasswordHash, err = s.pw.Hash(input.Password)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	event, err := events.NewAccountEvent(
+		events.TypeAccountPasswordChanged, user.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.st.WithTx(ctx, func(st storage.Storage) error {
+		err := st.Users().Update(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		err = st.Sessions().DeleteByUserID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		err = st.PersonalTokens().DeleteByUserID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		return st.Outbox().Create(ctx, event)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.au.Record(ctx, &audit.Event{
+		Type:   audit.EventPasswordReset,
+		UserID: &user.ID,
+	})
+
+	return nil
+}
+
+// ImpersonateUser opens a short-lived session as the user. The session
+// remembers the admin, so the audited actions done with it are recorded
+// with the admin as the actor. Note writes aren't audited, for them the
+// trail only shows that the session was opened. The session can't manage
+// credentials, export or delete the account.
+func (s *service) ImpersonateUser(ctx context.Context,
+	input *ImpersonateUserInput) (*ImpersonateUserOutput, error) {
+	const op = "services.admin.ImpersonateUser"
+	_ = s.log.With(logger.String("op", op))
+
+	if input.UserID == input.AdminID {
+		return nil, ErrImpersonateSelf
+	}
+
+	user, err := s.st.Users().GetByID(ctx, input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	if user.Status != storage.UserStatusActive {
+		return nil, ErrUserNotActive
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(
+		time.Second * time.Duration(s.cfg.Admin.ImpersonationTTL))
+	session := &storage.Session{
+		ID:             uuid.New(),
+		UserID:         user.ID,
+		UserAgent:      input.UserAgent,
+		CreatedAt:      now,
+		ExpiresAt:      &expiresAt,
+		ImpersonatorID: &input.AdminID,
+	}
+
+	err = s.st.Sessions().Create(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	token := s.sec.GenerateAccessToken(ctx, &security.Claims{
+		UserID:         user.ID,
+		SessionID:      session.ID,
+		ImpersonatorID: &input.AdminID,
+		ExpiresAt:      &expiresAt,
+		CreatedAt:      session.CreatedAt,
+	})
+
+	s.au.Record(ctx, &audit.Event{
+		Type:   audit.EventImpersonated,
+		UserID: &user.ID,
+		Details: map[string]string{
+			"session_id": session.ID.String(),
+			"expires_at": expiresAt.Format(time.RFC3339),
+		},
+	})
+
+	return &ImpersonateUserOutput{
+		AccessToken: token,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+func (s *service) GetStats(ctx context.Context) (*GetStatsOutput, error) {
+	const op = "services.admin.GetStats"
+	_ = s.log.With(logger.String("op", op))
+
+	now := time.Now()
+	dayAgo := now.Add(-24 * time.Hour)
+	weekAgo := now.Add(-7 * 24 * time.Hour)
+	pending := storage.UserStatusPending
+	active := storage.UserStatusActive
+	blocked := storage.UserStatusBlocked
+	deleted := storage.UserStatusDeleted
+	admin := storage.UserRoleAdmin
+
+	output := new(GetStatsOutput)
+	counts := []struct {
+		filter *storage.UserFilter
+		dst    *uint64
+	}{
+		{nil, &output.Users.Total},
+		{&storage.UserFilter{Status: &pending}, &output.Users.Pending},
+		{&storage.UserFilter{Status: &active}, &output.Users.Active},
+		{&storage.UserFilter{Status: &blocked}, &output.Users.Blocked},
+		{&storage.UserFilter{Status: &deleted}, &output.Users.Deleted},
+		{&storage.UserFilter{Role: &admin}, &output.Users.Admins},
+		{&storage.UserFilter{CreatedAfter: &dayAgo}, &output.NewUsersDay},
+		{&storage.UserFilter{CreatedAfter: &weekAgo}, &output.NewUsersWeek},
+	}
+
+	for _, count := range counts {
+		value, err := s.st.Users().Count(ctx, count.filter)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		*count.dst = value
+	}
+
+	notes, err := s.st.Notes().Count(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	output.Notes = notes
+
+	sessions, err := s.st.Sessions().CountActive(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	output.ActiveSessions = sessions
+
+	return output, nil
+}
+
+func toUserOutput(user *storage.User) *UserOutput {
+	return &UserOutput{
+		ID:        user.ID,
+		Login:     user.Login,
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		Timezone:  user.Timezone,
+		Status:    string(user.Status),
+		Role:      string(user.Role),
+		CreatedAt: user.CreatedAt,
+	}
+}
@@ -2,18 +2,52 @@ package auth
 
 import (
 	"errors"
+	"time"
+
+	"cloud-notes/internal/security"
 
 	"github.com/google/uuid"
 )
 
 var (
 	ErrLoginAlreadyExists = errors.New("login already exists")
+	ErrEmailAlreadyExists = errors.New("email already exists")
 	ErrUserNotFound       = errors.New("user not found")
 	ErrInvalidPassword    = errors.New("invalid password")
+	ErrEmailNotVerified   = errors.New("email not verified")
+	ErrUserBlocked        = errors.New("user blocked")
+	ErrUserDeleted        = errors.New("user deleted")
+	ErrUserNotDeleted     = errors.New("user not deleted")
+
+	ErrInvalidVerificationToken = errors.New("invalid verification token")
+	ErrTooManyVerificationMails = errors.New("too many verification mails")
+	ErrInvalidResetToken        = errors.New("invalid reset token")
+	ErrTooManyResetMails        = errors.New("too many reset mails")
+
+	ErrTwoFactorAlreadyEnabled = errors.New("2fa already enabled")
+	ErrTwoFactorNotEnrolled    = errors.New("2fa not enrolled")
+	ErrTwoFactorNotEnabled     = errors.New("2fa not enabled")
+	ErrInvalidCode             = errors.New("invalid code")
+	ErrInvalidChallenge        = errors.New("invalid challenge token")
+	ErrTooManyCodeAttempts     = errors.New("too many code attempts")
+
+	ErrInvalidCeremony   = errors.New("invalid or expired ceremony")
+	ErrInvalidCredential = errors.New("invalid credential")
+	ErrPasskeyNotFound   = errors.New("passkey not found")
+	ErrPasskeyCloned     = errors.New("passkey sign count mismatch")
+
+	ErrOIDCDisabled         = errors.New("oidc login is disabled")
+	ErrInvalidOIDCCode      = errors.New("invalid oidc authorization code")
+	ErrOIDCEmailNotVerified = errors.New("oidc email not verified")
+	ErrOIDCLoginTaken       = errors.New("login of the oidc email is taken")
+
+	ErrPersonalTokenNotFound = errors.New("personal token not found")
+	ErrInvalidExpiry         = errors.New("expiry must be in the future")
 )
 
 type RegisterInput struct {
 	Login     string
+	Email     string
 	Password  string
 	FirstName string
 	Timezone  string
@@ -25,8 +59,12 @@ type LoginInput struct {
 	UserAgent *string
 }
 
+// LoginOutput carries either the access token or, when the user has
+// two-factor authentication enabled, a challenge token that must be
+// exchanged with VerifyTwoFactor.
 type LoginOutput struct {
-	AccessToken string
+	AccessToken    string
+	ChallengeToken string
 }
 
 type ChangePasswordInput struct {
@@ -34,3 +72,120 @@ type ChangePasswordInput struct {
 	OldPassword string
 	NewPassword string
 }
+
+type ResetPasswordInput struct {
+	Token       string
+	NewPassword string
+}
+
+type EnrollTwoFactorOutput struct {
+	Secret string
+	URI    string
+	QRCode []byte
+}
+
+type ConfirmTwoFactorInput struct {
+	UserID uuid.UUID
+	Code   string
+}
+
+type ConfirmTwoFactorOutput struct {
+	RecoveryCodes []string
+}
+
+type DisableTwoFactorInput struct {
+	UserID   uuid.UUID
+	Password string
+}
+
+type VerifyTwoFactorInput struct {
+	ChallengeToken string
+	Code           string
+	RecoveryCode   string
+	UserAgent      *string
+}
+
+// BeginPasskeyOutput holds the options to pass to navigator.credentials
+// and the ceremony id to send back with the authenticator response.
+type BeginPasskeyOutput struct {
+	CeremonyID uuid.UUID
+	Options    any
+}
+
+type FinishPasskeyRegistrationInput struct {
+	UserID     uuid.UUID
+	CeremonyID uuid.UUID
+	Name       string
+	Credential []byte
+}
+
+type PasskeyOutput struct {
+	ID         uuid.UUID
+	Name       string
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+type GetPasskeysOutput struct {
+	Passkeys []*PasskeyOutput
+}
+
+type DeletePasskeyInput struct {
+	UserID    uuid.UUID
+	PasskeyID uuid.UUID
+}
+
+type FinishPasskeyLoginInput struct {
+	CeremonyID uuid.UUID
+	Credential []byte
+	UserAgent  *string
+}
+
+type CreatePersonalTokenInput struct {
+	UserID    uuid.UUID
+	Name      string
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+type PersonalTokenOutput struct {
+	ID         uuid.UUID
+	Name       string
+	Scopes     []string
+	LastUsedAt *time.Time
+	ExpiresAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// CreatePersonalTokenOutput carries the only copy of the token, it can't be
+// recovered later.
+type CreatePersonalTokenOutput struct {
+	PersonalTokenOutput
+	Token string
+}
+
+type GetPersonalTokensOutput struct {
+	PersonalTokens []*PersonalTokenOutput
+}
+
+type RevokePersonalTokenInput struct {
+	UserID          uuid.UUID
+	PersonalTokenID uuid.UUID
+}
+
+type GetJWKSOutput struct {
+	Keys []*security.JWK
+}
+
+// BeginOIDCOutput holds the provider URL to send the user to. State comes
+// back with the redirect and is passed to FinishOIDCLogin with the code.
+type BeginOIDCOutput struct {
+	URL   string
+	State uuid.UUID
+}
+
+type FinishOIDCLoginInput struct {
+	State     uuid.UUID
+	Code      string
+	UserAgent *string
+}
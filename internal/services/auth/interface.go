@@ -8,7 +8,42 @@ import (
 
 type Service interface {
 	Register(ctx context.Context, input *RegisterInput) error
+	VerifyEmail(ctx context.Context, verificationToken string) error
+	ResendVerification(ctx context.Context, login string) error
+	SendVerification(ctx context.Context, login string) error
 	Login(ctx context.Context, input *LoginInput) (*LoginOutput, error)
+	RestoreAccount(
+		ctx context.Context, input *LoginInput) (*LoginOutput, error)
 	Logout(ctx context.Context, sessionID uuid.UUID) error
 	ChangePassword(ctx context.Context, input *ChangePasswordInput) error
+	ForgotPassword(ctx context.Context, login string) error
+	SendPasswordReset(ctx context.Context, login string) error
+	ResetPassword(ctx context.Context, input *ResetPasswordInput) error
+	EnrollTwoFactor(
+		ctx context.Context, userID uuid.UUID) (*EnrollTwoFactorOutput, error)
+	ConfirmTwoFactor(ctx context.Context,
+		input *ConfirmTwoFactorInput) (*ConfirmTwoFactorOutput, error)
+	DisableTwoFactor(ctx context.Context, input *DisableTwoFactorInput) error
+	VerifyTwoFactor(
+		ctx context.Context, input *VerifyTwoFactorInput) (*LoginOutput, error)
+	BeginPasskeyRegistration(
+		ctx context.Context, userID uuid.UUID) (*BeginPasskeyOutput, error)
+	FinishPasskeyRegistration(ctx context.Context,
+		input *FinishPasskeyRegistrationInput) (*PasskeyOutput, error)
+	GetPasskeys(
+		ctx context.Context, userID uuid.UUID) (*GetPasskeysOutput, error)
+	DeletePasskey(ctx context.Context, input *DeletePasskeyInput) error
+	BeginPasskeyLogin(ctx context.Context) (*BeginPasskeyOutput, error)
+	FinishPasskeyLogin(ctx context.Context,
+		input *FinishPasskeyLoginInput) (*LoginOutput, error)
+	BeginOIDCLogin(ctx context.Context) (*BeginOIDCOutput, error)
+	FinishOIDCLogin(
+		ctx context.Context, input *FinishOIDCLoginInput) (*LoginOutput, error)
+	CreatePersonalToken(ctx context.Context,
+		input *CreatePersonalTokenInput) (*CreatePersonalTokenOutput, error)
+	GetPersonalTokens(
+		ctx context.Context, userID uuid.UUID) (*GetPersonalTokensOutput, error)
+	RevokePersonalToken(
+		ctx context.Context, input *RevokePersonalTokenInput) error
+	GetJWKS(ctx context.Context) (*GetJWKSOutput, error)
 }
@@ -0,0 +1,18 @@
+package auth
+
+import (
+	"context"
+
+	"cloud-notes/internal/logger"
+)
+
+// GetJWKS returns the public keys access tokens can be verified with. It is
+// empty while tokens are signed with the shared secret only.
+func (s *service) GetJWKS(ctx context.Context) (*GetJWKSOutput, error) {
+	const op = "services.auth.GetJWKS"
+	_ = s.log.With(logger.String("op", op))
+
+	return &GetJWKSOutput{
+		Keys: s.sec.JWKS(ctx),
+	}, nil
+}
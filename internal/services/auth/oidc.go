@@ -0,0 +1,305 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cloud-notes/internal/events"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/oidc"
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	oidcFirstNameMinLength = 2
+	oidcFirstNameMaxLength = 32
+	oidcDefaultTimezone    = "UTC"
+)
+
+type oidcCeremony struct {
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+func (s *service) BeginOIDCLogin(
+	ctx context.Context) (*BeginOIDCOutput, error) {
+	const op = "services.auth.BeginOIDCLogin"
+	log := s.log.With(logger.String("op", op))
+
+	if !s.oc.Enabled() {
+		return nil, ErrOIDCDisabled
+	}
+
+	state := &oidcCeremony{
+		Nonce:    oidc.GenerateVerifier(),
+		Verifier: oidc.GenerateVerifier(),
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ceremony := &storage.Ceremony{
+		ID:   uuid.New(),
+		Data: data,
+		ExpiresAt: time.Now().Add(
+			time.Second * time.Duration(s.cfg.OIDC.Timeout)),
+	}
+
+	url, err := s.oc.AuthCodeURL(
+		ctx, ceremony.ID.String(), state.Nonce, state.Verifier)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.st.Ceremonies().Create(ctx, ceremony)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &BeginOIDCOutput{
+		URL:   url,
+		State: ceremony.ID,
+	}, nil
+}
+
+// FinishOIDCLogin exchanges the code for the provider identity and logs in
+// the linked user. Unknown identities are linked to the user with the same
+// verified email, or a new account is created for them.
+func (s *service) FinishOIDCLogin(
+	ctx context.Context, input *FinishOIDCLoginInput) (*LoginOutput, error) {
+	const op = "services.auth.FinishOIDCLogin"
+	_ = s.log.With(logger.String("op", op))
+
+	if !s.oc.Enabled() {
+		return nil, ErrOIDCDisabled
+	}
+
+	ceremony, err := s.st.Ceremonies().Take(ctx, input.State)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// WebAuthn ceremonies are bound to a user or have no nonce, so they
+	// can't be replayed here.
+	state := new(oidcCeremony)
+	if ceremony == nil || ceremony.UserID != nil ||
+		json.Unmarshal(ceremony.Data, state) != nil || state.Nonce == "" {
+		return nil, ErrInvalidCeremony
+	}
+
+	identity, err := s.oc.Exchange(
+		ctx, input.Code, state.Nonce, state.Verifier)
+	if err != nil {
+		return nil, ErrInvalidOIDCCode
+	}
+
+	user, err := s.findOIDCUser(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.login(ctx, user, input.UserAgent)
+}
+
+func (s *service) findOIDCUser(
+	ctx context.Context, identity *oidc.Identity) (*storage.User, error) {
+	const op = "services.auth.findOIDCUser"
+	_ = s.log.With(logger.String("op", op))
+
+	linked, err := s.st.Identities().GetBySubject(
+		ctx, s.oc.Provider(), identity.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if linked != nil {
+		user, err := s.st.Users().GetByID(ctx, linked.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		return user, nil
+	}
+
+	// Linking by an unverified email would let anyone who controls the
+	// provider account take over the local one.
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	email := strings.ToLower(identity.Email)
+	user, err := s.st.Users().GetByEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	link := &storage.Identity{
+		ID:        uuid.New(),
+		Provider:  s.oc.Provider(),
+		Subject:   identity.Subject,
+		Email:     email,
+		CreatedAt: time.Now(),
+	}
+
+	switch {
+	case user == nil:
+		user, err = s.createOIDCUser(ctx, email, identity.Name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	case user.Status == storage.UserStatusPending:
+		link.UserID = user.ID
+		err = s.claimPendingUser(ctx, user, link)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		return user, nil
+	}
+
+	link.UserID = user.ID
+	err = s.st.Identities().Create(ctx, link)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// claimPendingUser hands an unverified account over to the owner of the
+// verified email. Whoever registered it chose the password and may not be
+// that owner, so the password is replaced and everything issued before is
+// revoked. The owner can set a password through the password reset.
+func (s *service) claimPendingUser(ctx context.Context,
+	user *storage.User, link *storage.Identity) error {
+	const op = "services.auth.claimPendingUser"
+	_ = s.log.With(logger.String("op", op))
+
+	passwordHash, err := s.randomPasswordHash(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	user.PasswordHash = passwordHash
+	user.Status = storage.UserStatusActive
+
+	err = s.st.WithTx(ctx, func(st storage.Storage) error {
+		err := st.Users().Update(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		err = st.Sessions().DeleteByUserID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		err = st.PersonalTokens().DeleteByUserID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		err = st.Verifications().Delete(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		return st.Identities().Create(ctx, link)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// randomPasswordHash is the hash of a password nobody knows, for accounts
+// that must not be usable with a password until the user sets one.
+func (s *service) randomPasswordHash(ctx context.Context) (string, error) {
+	const op = "services.auth.randomPasswordHash"
+	log := s.log.With(logger.String("op", op))
+
+	password := make([]byte, 32)
+	_, err := rand.Read(password)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	passwordHash, err := s.pw.Hash(string(password))
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return passwordHash, nil
+}
+
+// createOIDCUser creates an account without a usable password. The user can
+// set one later through the password reset.
+func (s *service) createOIDCUser(
+	ctx context.Context, email, name string) (*storage.User, error) {
+	const op = "services.auth.createOIDCUser"
+	_ = s.log.With(logger.String("op", op))
+
+	// The email becomes the login, unless another account has already
+	// chosen it as one.
+	taken, err := s.st.Users().GetByLogin(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if taken != nil {
+		return nil, ErrOIDCLoginTaken
+	}
+
+	passwordHash, err := s.randomPasswordHash(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user := &storage.User{
+		ID:           uuid.New(),
+		Login:        email,
+		Email:        &email,
+		PasswordHash: passwordHash,
+		FirstName:    oidcFirstName(email, name),
+		Timezone:     oidcDefaultTimezone,
+		Status:       storage.UserStatusActive,
+		CreatedAt:    time.Now(),
+		Role:         storage.UserRoleUser,
+	}
+
+	err = s.createUser(ctx, user, events.SourceOIDC)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// oidcFirstName fits the provider name into the limits of registration,
+// falling back to the local part of the email.
+func oidcFirstName(email, name string) string {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) < oidcFirstNameMinLength {
+		name, _, _ = strings.Cut(email, "@")
+	}
+
+	if utf8.RuneCountInString(name) > oidcFirstNameMaxLength {
+		name = string([]rune(name)[:oidcFirstNameMaxLength])
+	}
+
+	return name
+}
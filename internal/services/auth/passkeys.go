@@ -0,0 +1,391 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/storage"
+	"cloud-notes/internal/webauthn"
+
+	"github.com/google/uuid"
+)
+
+// passkeyUser adapts a user and their stored passkeys to webauthn.User.
+// The user id is used as the user handle, so discoverable logins can find
+// the account without a login.
+type passkeyUser struct {
+	user     *storage.User
+	passkeys []*storage.Passkey
+}
+
+func (u *passkeyUser) WebAuthnID() []byte {
+	return u.user.ID[:]
+}
+
+func (u *passkeyUser) WebAuthnName() string {
+	return u.user.Login
+}
+
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	return u.user.FirstName
+}
+
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.passkeys))
+	for _, passkey := range u.passkeys {
+		credentials = append(credentials, toCredential(passkey))
+	}
+
+	return credentials
+}
+
+func toCredential(passkey *storage.Passkey) webauthn.Credential {
+	transports := make(
+		[]webauthn.AuthenticatorTransport, 0, len(passkey.Transports))
+	for _, transport := range passkey.Transports {
+		transports = append(transports,
+			webauthn.AuthenticatorTransport(transport))
+	}
+
+	credential := webauthn.Credential{
+		ID:              passkey.CredentialID,
+		PublicKey:       passkey.PublicKey,
+		AttestationType: passkey.AttestationType,
+		Transport:       transports,
+	}
+	credential.Authenticator.AAGUID = passkey.AAGUID
+	credential.Authenticator.SignCount = passkey.SignCount
+	credential.Flags.BackupEligible = passkey.BackupEligible
+	credential.Flags.BackupState = passkey.BackupState
+
+	return credential
+}
+
+func (s *service) loadPasskeyUser(
+	ctx context.Context, userID uuid.UUID) (*passkeyUser, error) {
+	const op = "services.auth.loadPasskeyUser"
+	_ = s.log.With(logger.String("op", op))
+
+	user, err := s.st.Users().GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user == nil {
+		return nil, nil
+	}
+
+	passkeys, err := s.st.Passkeys().GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &passkeyUser{
+		user:     user,
+		passkeys: passkeys,
+	}, nil
+}
+
+func (s *service) saveCeremony(ctx context.Context, userID *uuid.UUID,
+	session *webauthn.SessionData) (uuid.UUID, error) {
+	const op = "services.auth.saveCeremony"
+	log := s.log.With(logger.String("op", op))
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ceremony := &storage.Ceremony{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Data:      data,
+		ExpiresAt: session.Expires,
+	}
+
+	err = s.st.Ceremonies().Create(ctx, ceremony)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ceremony.ID, nil
+}
+
+func (s *service) takeCeremony(ctx context.Context,
+	id uuid.UUID) (*storage.Ceremony, *webauthn.SessionData, error) {
+	const op = "services.auth.takeCeremony"
+	_ = s.log.With(logger.String("op", op))
+
+	ceremony, err := s.st.Ceremonies().Take(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if ceremony == nil {
+		return nil, nil, ErrInvalidCeremony
+	}
+
+	session := new(webauthn.SessionData)
+	err = json.Unmarshal(ceremony.Data, session)
+	if err != nil {
+		return nil, nil, ErrInvalidCeremony
+	}
+
+	return ceremony, session, nil
+}
+
+func (s *service) BeginPasskeyRegistration(
+	ctx context.Context, userID uuid.UUID) (*BeginPasskeyOutput, error) {
+	const op = "services.auth.BeginPasskeyRegistration"
+	log := s.log.With(logger.String("op", op))
+
+	user, err := s.loadPasskeyUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	// Existing credentials are excluded, so the same authenticator can't
+	// be registered twice.
+	exclusions := webauthn.Credentials(user.WebAuthnCredentials())
+	options, session, err := s.wa.BeginRegistration(user,
+		webauthn.WithExclusions(exclusions.CredentialDescriptors()))
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ceremonyID, err := s.saveCeremony(ctx, &userID, session)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &BeginPasskeyOutput{
+		CeremonyID: ceremonyID,
+		Options:    options,
+	}, nil
+}
+
+func (s *service) FinishPasskeyRegistration(ctx context.Context,
+	input *FinishPasskeyRegistrationInput) (*PasskeyOutput, error) {
+	const op = "services.auth.FinishPasskeyRegistration"
+	_ = s.log.With(logger.String("op", op))
+
+	ceremony, session, err := s.takeCeremony(ctx, input.CeremonyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ceremony.UserID == nil || *ceremony.UserID != input.UserID {
+		return nil, ErrInvalidCeremony
+	}
+
+	user, err := s.loadPasskeyUser(ctx, input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	response, err := webauthn.ParseCredentialCreation(input.Credential)
+	if err != nil {
+		return nil, ErrInvalidCredential
+	}
+
+	credential, err := s.wa.CreateCredential(user, *session, response)
+	if err != nil {
+		return nil, ErrInvalidCredential
+	}
+
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	passkey := &storage.Passkey{
+		ID:              uuid.New(),
+		UserID:          input.UserID,
+		Name:            input.Name,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      transports,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		CreatedAt:       time.Now(),
+	}
+
+	err = s.st.Passkeys().Create(ctx, passkey)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &PasskeyOutput{
+		ID:         passkey.ID,
+		Name:       passkey.Name,
+		LastUsedAt: passkey.LastUsedAt,
+		CreatedAt:  passkey.CreatedAt,
+	}, nil
+}
+
+func (s *service) GetPasskeys(
+	ctx context.Context, userID uuid.UUID) (*GetPasskeysOutput, error) {
+	const op = "services.auth.GetPasskeys"
+	_ = s.log.With(logger.String("op", op))
+
+	passkeys, err := s.st.Passkeys().GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	output := new(GetPasskeysOutput)
+	for _, passkey := range passkeys {
+		output.Passkeys = append(output.Passkeys, &PasskeyOutput{
+			ID:         passkey.ID,
+			Name:       passkey.Name,
+			LastUsedAt: passkey.LastUsedAt,
+			CreatedAt:  passkey.CreatedAt,
+		})
+	}
+
+	return output, nil
+}
+
+func (s *service) DeletePasskey(
+	ctx context.Context, input *DeletePasskeyInput) error {
+	const op = "services.auth.DeletePasskey"
+	_ = s.log.With(logger.String("op", op))
+
+	passkey, err := s.st.Passkeys().GetByID(ctx, input.PasskeyID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if passkey == nil || passkey.UserID != input.UserID {
+		return ErrPasskeyNotFound
+	}
+
+	err = s.st.Passkeys().Delete(ctx, passkey.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *service) BeginPasskeyLogin(
+	ctx context.Context) (*BeginPasskeyOutput, error) {
+	const op = "services.auth.BeginPasskeyLogin"
+	log := s.log.With(logger.String("op", op))
+
+	options, session, err := s.wa.BeginDiscoverableLogin()
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ceremonyID, err := s.saveCeremony(ctx, nil, session)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &BeginPasskeyOutput{
+		CeremonyID: ceremonyID,
+		Options:    options,
+	}, nil
+}
+
+func (s *service) FinishPasskeyLogin(
+	ctx context.Context, input *FinishPasskeyLoginInput) (*LoginOutput, error) {
+	const op = "services.auth.FinishPasskeyLogin"
+	log := s.log.With(logger.String("op", op))
+
+	_, session, err := s.takeCeremony(ctx, input.CeremonyID)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := webauthn.ParseCredentialAssertion(input.Credential)
+	if err != nil {
+		return nil, ErrInvalidCredential
+	}
+
+	var found *passkeyUser
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, ErrInvalidCredential
+		}
+
+		user, err := s.loadPasskeyUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		if user == nil {
+			return nil, ErrInvalidCredential
+		}
+
+		found = user
+		return user, nil
+	}
+
+	_, credential, err := s.wa.ValidatePasskeyLogin(
+		handler, *session, response)
+	if err != nil {
+		return nil, ErrInvalidCredential
+	}
+
+	var passkey *storage.Passkey
+	for _, p := range found.passkeys {
+		if bytes.Equal(p.CredentialID, credential.ID) {
+			passkey = p
+		}
+	}
+
+	if passkey == nil {
+		return nil, ErrInvalidCredential
+	}
+
+	// A counter that didn't grow means the private key may have been
+	// copied to another authenticator, so the assertion is rejected.
+	if credential.Authenticator.CloneWarning {
+		log.WarnContext(ctx, "passkey sign count did not increase",
+			logger.String("passkey_id", passkey.ID.String()))
+		return nil, s.loginFailed(
+			ctx, found.user.Login, found.user, ErrPasskeyCloned)
+	}
+
+	err = s.checkStatus(ctx, found.user)
+	if err != nil {
+		return nil, s.loginFailed(ctx, found.user.Login, found.user, err)
+	}
+
+	lastUsedAt := time.Now()
+	passkey.SignCount = credential.Authenticator.SignCount
+	passkey.BackupState = credential.Flags.BackupState
+	passkey.LastUsedAt = &lastUsedAt
+	err = s.st.Passkeys().Update(ctx, passkey)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	output, err := s.createSession(ctx, found.user, input.UserAgent)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return output, nil
+}
@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"time"
+
+	"cloud-notes/internal/audit"
+	"cloud-notes/internal/events"
+	"cloud-notes/internal/jobs"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/mailer"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/storage"
+)
+
+const resetTokenSize = 32
+
+const resetText = `Hello, %s!
+
+Someone asked to reset the password of your account. To choose a new
+password open the link below:
+
+%s
+
+The link is valid until %s and can be used once. If it wasn't you, just
+ignore this message - your password won't change.
+`
+
+// ForgotPassword queues a reset link for the owner of the login. The login
+// is looked up by the worker, so known and unknown logins take the same
+// time and get the same answer, and the endpoint can't be used to find out
+// which logins are registered.
+func (s *service) ForgotPassword(ctx context.Context, login string) error {
+	const op = "services.auth.ForgotPassword"
+	_ = s.log.With(logger.String("op", op))
+
+	result, err := s.st.RateLimits().Take(ctx, "password_reset:"+login,
+		&storage.RateLimitBudget{
+			Limit:  s.cfg.PasswordReset.Limit,
+			Period: time.Second * time.Duration(s.cfg.PasswordReset.Period),
+		})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !result.Allowed {
+		return ErrTooManyResetMails
+	}
+
+	job, err := jobs.NewJob(
+		jobs.TypeSendPasswordReset, &jobs.SendPasswordReset{Login: login})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.st.Jobs().Create(ctx, job)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SendPasswordReset mails a reset link to the email of the login's owner,
+// nothing is sent for unknown logins and accounts without an email. Every
+// attempt issues a new link, the token exists only in the mail and is
+// stored hashed.
+func (s *service) SendPasswordReset(ctx context.Context, login string) error {
+	const op = "services.auth.SendPasswordReset"
+	log := s.log.With(logger.String("op", op))
+
+	user, err := s.st.Users().GetByLogin(ctx, login)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user == nil || user.Email == nil {
+		return nil
+	}
+
+	raw := make([]byte, resetTokenSize)
+	_, err = rand.Read(raw)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	reset := &storage.PasswordReset{
+		TokenHash: security.HashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(
+			time.Second * time.Duration(s.cfg.PasswordReset.TTL)),
+	}
+
+	err = s.st.PasswordResets().Create(ctx, reset)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	link := s.cfg.Server.PublicURL + "/reset-password?" +
+		url.Values{"token": {token}}.Encode()
+
+	err = s.ml.Send(ctx, &mailer.Message{
+		To:      *user.Email,
+		Subject: "Reset your password",
+		Text: fmt.Sprintf(resetText, user.FirstName, link,
+			reset.ExpiresAt.UTC().Format(time.RFC1123)),
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *service) ResetPassword(
+	ctx context.Context, input *ResetPasswordInput) error {
+	const op = "services.auth.ResetPassword"
+	log := s.log.With(logger.String("op", op))
+
+	reset, err := s.st.PasswordResets().Take(
+		ctx, security.HashToken(input.Token))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if reset == nil || reset.ExpiresAt.Before(time.Now()) {
+		return ErrInvalidResetToken
+	}
+
+	user, err := s.st.Users().GetByID(ctx, reset.UserID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user == nil {
+		return ErrInvalidResetToken
+	}
+
+	err = s.checkNewPassword(ctx, user, input.NewPassword, false)
+	if err != nil {
+		// A rejected password doesn't use up the link.
+		restoreErr := s.st.PasswordResets().Create(ctx, reset)
+		if restoreErr != nil {
+			log.ErrorContext(ctx, "", logger.Error(restoreErr))
+		}
+
+		return err
+	}
+
+	passwordHash, err := s.pw.Hash(input.NewPassword)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.rememberPassword(ctx, user)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Following the link proves the ownership of the email as well.
+	user.PasswordHash = passwordHash
+	if user.Status == storage.UserStatusPending {
+		user.Status = storage.UserStatusActive
+	}
+
+	event, err := events.NewAccountEvent(
+		events.TypeAccountPasswordChanged, user.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.st.WithTx(ctx, func(st storage.Storage) error {
+		err := st.Users().Update(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		// Whoever knew the old password may have issued tokens with it.
+		err = st.Sessions().DeleteByUserID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		err = st.PersonalTokens().DeleteByUserID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		return st.Outbox().Create(ctx, event)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.au.Record(ctx, &audit.Event{
+		Type:   audit.EventPasswordReset,
+		UserID: &user.ID,
+	})
+
+	return nil
+}
@@ -2,28 +2,56 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"cloud-notes/internal/audit"
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/events"
 	"cloud-notes/internal/logger"
+	"cloud-notes/internal/mailer"
+	"cloud-notes/internal/oidc"
+	"cloud-notes/internal/password"
 	"cloud-notes/internal/security"
 	"cloud-notes/internal/storage"
+	"cloud-notes/internal/totp"
+	"cloud-notes/internal/webauthn"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type service struct {
 	log logger.Logger
 	st  storage.Storage
 	sec security.Security
+	otp totp.TOTP
+	wa  *webauthn.WebAuthn
+	ml  mailer.Mailer
+	oc  oidc.OIDC
+	pw  password.Hasher
+	pp  password.Policy
+	au  audit.Auditor
+	cfg *config.Config
 }
 
-func New(log logger.Logger, st storage.Storage, sec security.Security) Service {
+func New(log logger.Logger, st storage.Storage, sec security.Security,
+	otp totp.TOTP, wa *webauthn.WebAuthn, ml mailer.Mailer, oc oidc.OIDC,
+	pw password.Hasher, pp password.Policy, au audit.Auditor,
+	cfg *config.Config) Service {
 	return &service{
 		log: log,
 		st:  st,
 		sec: sec,
+		otp: otp,
+		wa:  wa,
+		ml:  ml,
+		oc:  oc,
+		pw:  pw,
+		pp:  pp,
+		au:  au,
+		cfg: cfg,
 	}
 }
 
@@ -40,22 +68,71 @@ func (s *service) Register(ctx context.Context, input *RegisterInput) error {
 		return ErrLoginAlreadyExists
 	}
 
-	bytes, err := bcrypt.GenerateFromPassword(
-		[]byte(input.Password), bcrypt.DefaultCost)
+	email := strings.ToLower(input.Email)
+	user, err = s.st.Users().GetByEmail(ctx, email)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user != nil {
+		return ErrEmailAlreadyExists
+	}
+
+	user = &storage.User{
+		ID:        uuid.New(),
+		Login:     input.Login,
+		Email:     &email,
+		FirstName: input.FirstName,
+		Timezone:  input.Timezone,
+		Status:    storage.UserStatusPending,
+		CreatedAt: time.Now(),
+		Role:      storage.UserRoleUser,
+	}
+
+	err = s.checkNewPassword(ctx, user, input.Password, false)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash, err = s.pw.Hash(input.Password)
 	if err != nil {
 		log.ErrorContext(ctx, "", logger.Error(err))
 		return fmt.Errorf("%s: %w", op, err)
 	}
-	passwordHash := string(bytes)
 
-	err = s.st.Users().Create(ctx, &storage.User{
-		ID:           uuid.New(),
-		Login:        input.Login,
-		PasswordHash: passwordHash,
-		FirstName:    input.FirstName,
-		Timezone:     input.Timezone,
-		Status:       storage.UserStatusActive,
-		CreatedAt:    time.Now(),
+	err = s.createUser(ctx, user, events.SourcePassword)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The account already exists at this point and the link can be
+	// requested again, so a failure to queue the mail doesn't fail the
+	// registration.
+	err = s.queueVerification(ctx, user.Login)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+	}
+
+	return nil
+}
+
+// createUser stores the new user together with the user.registered event.
+func (s *service) createUser(
+	ctx context.Context, user *storage.User, source string) error {
+	const op = "services.auth.createUser"
+
+	event, err := events.NewUserRegistered(user, source)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.st.WithTx(ctx, func(st storage.Storage) error {
+		err := st.Users().Create(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		return st.Outbox().Create(ctx, event)
 	})
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
@@ -75,19 +152,167 @@ func (s *service) Login(
 	}
 
 	if user == nil {
+		return nil, s.loginFailed(ctx, input.Login, nil, ErrUserNotFound)
+	}
+
+	err = s.checkPassword(ctx, user, input.Password)
+	if err != nil {
+		return nil, s.loginFailed(ctx, input.Login, user, err)
+	}
+
+	return s.login(ctx, user, input.UserAgent)
+}
+
+// RestoreAccount cancels the deletion of an account within the grace
+// period and logs the user in as Login does.
+func (s *service) RestoreAccount(
+	ctx context.Context, input *LoginInput) (*LoginOutput, error) {
+	const op = "services.auth.RestoreAccount"
+	_ = s.log.With(logger.String("op", op))
+
+	user, err := s.st.Users().GetByLogin(ctx, input.Login)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	err = s.checkPassword(ctx, user, input.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Status != storage.UserStatusDeleted {
+		return nil, ErrUserNotDeleted
+	}
+
+	deletion, err := s.st.Deletions().GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The account is about to be purged, so it's treated as gone.
+	if deletion == nil || !deletion.PurgeAt.After(time.Now()) {
 		return nil, ErrUserNotFound
 	}
 
-	err = bcrypt.CompareHashAndPassword(
-		[]byte(user.PasswordHash), []byte(input.Password))
+	event, err := events.NewAccountEvent(events.TypeAccountRestored, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.st.WithTx(ctx, func(st storage.Storage) error {
+		err := st.Deletions().Delete(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		user.Status = storage.UserStatusActive
+		err = st.Users().Update(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		return st.Outbox().Create(ctx, event)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.au.Record(ctx, &audit.Event{
+		Type:   audit.EventAccountRestored,
+		UserID: &user.ID,
+	})
+
+	return s.login(ctx, user, input.UserAgent)
+}
+
+// login finishes a password login, asking for the second factor when the
+// user has 2FA enabled.
+func (s *service) login(ctx context.Context,
+	user *storage.User, userAgent *string) (*LoginOutput, error) {
+	const op = "services.auth.login"
+	_ = s.log.With(logger.String("op", op))
+
+	err := s.checkStatus(ctx, user)
+	if err != nil {
+		return nil, s.loginFailed(ctx, user.Login, user, err)
+	}
+
+	twoFactor, err := s.st.TwoFactor().GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if twoFactor != nil && twoFactor.Enabled {
+		token := s.sec.GenerateChallengeToken(ctx, &security.ChallengeClaims{
+			UserID: user.ID,
+		})
+
+		return &LoginOutput{
+			ChallengeToken: token,
+		}, nil
+	}
+
+	output, err := s.createSession(ctx, user, userAgent)
 	if err != nil {
-		return nil, ErrInvalidPassword
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return output, nil
+}
+
+// checkStatus tells whether the user may get a new session. A block that
+// is over is lifted and the user gets back the status from before it.
+func (s *service) checkStatus(ctx context.Context, user *storage.User) error {
+	const op = "services.auth.checkStatus"
+	_ = s.log.With(logger.String("op", op))
+
+	if user.Status == storage.UserStatusBlocked {
+		now := time.Now()
+		lifted, err := s.st.Blocks().Lift(ctx, user.ID, &now)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if !lifted {
+			return ErrUserBlocked
+		}
+
+		restored, err := s.st.Users().GetByID(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if restored == nil {
+			return ErrUserDeleted
+		}
+		*user = *restored
+	}
+
+	switch user.Status {
+	case storage.UserStatusActive:
+		return nil
+	case storage.UserStatusPending:
+		return ErrEmailNotVerified
+	case storage.UserStatusBlocked:
+		return ErrUserBlocked
+	default:
+		return ErrUserDeleted
 	}
+}
+
+func (s *service) createSession(ctx context.Context,
+	user *storage.User, userAgent *string) (*LoginOutput, error) {
+	const op = "services.auth.createSession"
+	_ = s.log.With(logger.String("op", op))
 
 	session := &storage.Session{
 		ID:        uuid.New(),
 		UserID:    user.ID,
-		UserAgent: input.UserAgent,
+		UserAgent: userAgent,
 		CreatedAt: time.Now(),
 	}
 
@@ -97,11 +322,19 @@ func (s *service) Login(
 		CreatedAt: session.CreatedAt,
 	})
 
-	err = s.st.Sessions().Create(ctx, session)
+	err := s.st.Sessions().Create(ctx, session)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	s.au.Record(ctx, &audit.Event{
+		Type:   audit.EventLoginSucceeded,
+		UserID: &user.ID,
+		Details: map[string]string{
+			"session_id": session.ID.String(),
+		},
+	})
+
 	return &LoginOutput{
 		AccessToken: token,
 	}, nil
@@ -111,11 +344,28 @@ func (s *service) Logout(ctx context.Context, sessionID uuid.UUID) error {
 	const op = "services.auth.Logout"
 	_ = s.log.With(logger.String("op", op))
 
-	err := s.st.Sessions().Delete(ctx, sessionID)
+	session, err := s.st.Sessions().GetByID(ctx, sessionID)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	if session == nil {
+		return nil
+	}
+
+	err = s.st.Sessions().Delete(ctx, session.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.au.Record(ctx, &audit.Event{
+		Type:   audit.EventLogout,
+		UserID: &session.UserID,
+		Details: map[string]string{
+			"session_id": session.ID.String(),
+		},
+	})
+
 	return nil
 }
 
@@ -129,25 +379,206 @@ func (s *service) ChangePassword(
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	err = bcrypt.CompareHashAndPassword(
-		[]byte(user.PasswordHash), []byte(input.OldPassword))
+	err = s.pw.Compare(user.PasswordHash, input.OldPassword)
 	if err != nil {
 		return ErrInvalidPassword
 	}
 
-	bytes, err := bcrypt.GenerateFromPassword(
-		[]byte(input.NewPassword), bcrypt.DefaultCost)
+	err = s.checkNewPassword(ctx, user, input.NewPassword, true)
+	if err != nil {
+		return err
+	}
+
+	passwordHash, err := s.pw.Hash(input.NewPassword)
 	if err != nil {
 		log.ErrorContext(ctx, "", logger.Error(err))
 		return fmt.Errorf("%s: %w", op, err)
 	}
-	passwordHash := string(bytes)
+
+	err = s.rememberPassword(ctx, user)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	event, err := events.NewAccountEvent(
+		events.TypeAccountPasswordChanged, user.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	user.PasswordHash = passwordHash
+	err = s.st.WithTx(ctx, func(st storage.Storage) error {
+		err := st.Users().Update(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		return st.Outbox().Create(ctx, event)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.au.Record(ctx, &audit.Event{
+		Type:   audit.EventPasswordChanged,
+		UserID: &user.ID,
+	})
+
+	return nil
+}
+
+// checkPassword compares the password with the stored hash and, when the
+// hash is outdated, replaces it while the plain password is known. Failing
+// to rehash doesn't fail the login, it's retried on the next one.
+func (s *service) checkPassword(
+	ctx context.Context, user *storage.User, plain string) error {
+	const op = "services.auth.checkPassword"
+	log := s.log.With(logger.String("op", op))
+
+	err := s.pw.Compare(user.PasswordHash, plain)
+	if err != nil {
+		return ErrInvalidPassword
+	}
+
+	if !s.pw.NeedsRehash(user.PasswordHash) {
+		return nil
+	}
+
+	passwordHash, err := s.pw.Hash(plain)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil
+	}
 
 	user.PasswordHash = passwordHash
 	err = s.st.Users().Update(ctx, user)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+	}
+
+	return nil
+}
+
+// checkNewPassword returns a *password.PolicyError listing every rule the
+// password breaks. With history it also rejects the current password and
+// the ones the user had before it.
+func (s *service) checkNewPassword(ctx context.Context, user *storage.User,
+	plain string, history bool) error {
+	const op = "services.auth.checkNewPassword"
+	log := s.log.With(logger.String("op", op))
+
+	violations, err := s.pp.Check(plain, user.Login, user.FirstName)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if history && s.pp.History() > 0 {
+		reused, err := s.isRecentPassword(ctx, user, plain)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if reused {
+			violations = append(violations, &password.Violation{
+				Rule: password.RuleHistory,
+				Message: fmt.Sprintf("must differ from your last %d passwords",
+					s.pp.History()),
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &password.PolicyError{
+			Violations: violations,
+		}
+	}
+
+	return nil
+}
+
+func (s *service) isRecentPassword(
+	ctx context.Context, user *storage.User, plain string) (bool, error) {
+	const op = "services.auth.isRecentPassword"
+	_ = s.log.With(logger.String("op", op))
+
+	if s.pw.Compare(user.PasswordHash, plain) == nil {
+		return true, nil
+	}
+
+	entries, err := s.st.PasswordHistory().GetLatest(
+		ctx, user.ID, s.pp.History()-1)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, entry := range entries {
+		if s.pw.Compare(entry.PasswordHash, plain) == nil {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// rememberPassword moves the current password hash into the history before
+// it's replaced. Together with the current one the history covers as many
+// passwords as the policy asks for.
+func (s *service) rememberPassword(
+	ctx context.Context, user *storage.User) error {
+	const op = "services.auth.rememberPassword"
+	_ = s.log.With(logger.String("op", op))
+
+	if s.pp.History() <= 1 {
+		return nil
+	}
+
+	err := s.st.PasswordHistory().Create(ctx, &storage.PasswordHistoryEntry{
+		ID:           uuid.New(),
+		UserID:       user.ID,
+		PasswordHash: user.PasswordHash,
+		CreatedAt:    time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.st.PasswordHistory().DeleteOlder(
+		ctx, user.ID, s.pp.History()-1)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	return nil
 }
+
+// loginFailed records the failed attempt and returns err. Only the errors
+// a client can cause are recorded, internal ones say nothing about it.
+func (s *service) loginFailed(ctx context.Context,
+	login string, user *storage.User, err error) error {
+	switch {
+	case errors.Is(err, ErrUserNotFound),
+		errors.Is(err, ErrInvalidPassword),
+		errors.Is(err, ErrEmailNotVerified),
+		errors.Is(err, ErrUserBlocked),
+		errors.Is(err, ErrUserDeleted),
+		errors.Is(err, ErrInvalidCode),
+		errors.Is(err, ErrPasskeyCloned):
+	default:
+		return err
+	}
+
+	event := &audit.Event{
+		Type:  audit.EventLoginFailed,
+		Login: &login,
+		Details: map[string]string{
+			"reason": err.Error(),
+		},
+	}
+	if user != nil {
+		event.UserID = &user.ID
+	}
+
+	s.au.Record(ctx, event)
+	return err
+}
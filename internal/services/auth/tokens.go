@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud-notes/internal/audit"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+func (s *service) CreatePersonalToken(ctx context.Context,
+	input *CreatePersonalTokenInput) (*CreatePersonalTokenOutput, error) {
+	const op = "services.auth.CreatePersonalToken"
+	log := s.log.With(logger.String("op", op))
+
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidExpiry
+	}
+
+	token, tokenHash, err := security.GeneratePersonalToken()
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	personalToken := &storage.PersonalToken{
+		ID:        uuid.New(),
+		UserID:    input.UserID,
+		Name:      input.Name,
+		TokenHash: tokenHash,
+		Scopes:    input.Scopes,
+		ExpiresAt: input.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+
+	err = s.st.PersonalTokens().Create(ctx, personalToken)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.au.Record(ctx, &audit.Event{
+		Type:   audit.EventPersonalTokenCreated,
+		UserID: &input.UserID,
+		Details: map[string]string{
+			"token_id": personalToken.ID.String(),
+			"scopes":   strings.Join(personalToken.Scopes, " "),
+		},
+	})
+
+	return &CreatePersonalTokenOutput{
+		PersonalTokenOutput: *toPersonalTokenOutput(personalToken),
+		Token:               token,
+	}, nil
+}
+
+func (s *service) GetPersonalTokens(
+	ctx context.Context, userID uuid.UUID) (*GetPersonalTokensOutput, error) {
+	const op = "services.auth.GetPersonalTokens"
+	_ = s.log.With(logger.String("op", op))
+
+	personalTokens, err := s.st.PersonalTokens().GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	output := new(GetPersonalTokensOutput)
+	for _, personalToken := range personalTokens {
+		output.PersonalTokens = append(output.PersonalTokens,
+			toPersonalTokenOutput(personalToken))
+	}
+
+	return output, nil
+}
+
+func (s *service) RevokePersonalToken(
+	ctx context.Context, input *RevokePersonalTokenInput) error {
+	const op = "services.auth.RevokePersonalToken"
+	_ = s.log.With(logger.String("op", op))
+
+	personalToken, err := s.st.PersonalTokens().GetByID(
+		ctx, input.PersonalTokenID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if personalToken == nil || personalToken.UserID != input.UserID {
+		return ErrPersonalTokenNotFound
+	}
+
+	err = s.st.PersonalTokens().Delete(ctx, personalToken.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.au.Record(ctx, &audit.Event{
+		Type:   audit.EventPersonalTokenRevoked,
+		UserID: &input.UserID,
+		Details: map[string]string{
+			"token_id": personalToken.ID.String(),
+		},
+	})
+
+	return nil
+}
+
+func toPersonalTokenOutput(
+	personalToken *storage.PersonalToken) *PersonalTokenOutput {
+	return &PersonalTokenOutput{
+		ID:         personalToken.ID,
+		Name:       personalToken.Name,
+		Scopes:     personalToken.Scopes,
+		LastUsedAt: personalToken.LastUsedAt,
+		ExpiresAt:  personalToken.ExpiresAt,
+		CreatedAt:  personalToken.CreatedAt,
+	}
+}
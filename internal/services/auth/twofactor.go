@@ -0,0 +1,329 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud-notes/internal/audit"
+	"cloud-notes/internal/events"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	recoveryCodesCount = 10
+	recoveryCodeSize   = 10
+)
+
+func (s *service) EnrollTwoFactor(
+	ctx context.Context, userID uuid.UUID) (*EnrollTwoFactorOutput, error) {
+	const op = "services.auth.EnrollTwoFactor"
+	log := s.log.With(logger.String("op", op))
+
+	user, err := s.st.Users().GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	twoFactor, err := s.st.TwoFactor().GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if twoFactor != nil && twoFactor.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := s.otp.GenerateSecret()
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	uri := s.otp.URI(user.Login, secret)
+	qrCode, err := s.otp.QRCode(uri)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Enrolling again before confirmation replaces the pending secret.
+	if twoFactor != nil {
+		twoFactor.Secret = secret
+		twoFactor.CreatedAt = time.Now()
+		err = s.st.TwoFactor().Update(ctx, twoFactor)
+	} else {
+		err = s.st.TwoFactor().Create(ctx, &storage.TwoFactor{
+			UserID:    userID,
+			Secret:    secret,
+			Enabled:   false,
+			CreatedAt: time.Now(),
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &EnrollTwoFactorOutput{
+		Secret: secret,
+		URI:    uri,
+		QRCode: qrCode,
+	}, nil
+}
+
+func (s *service) ConfirmTwoFactor(ctx context.Context,
+	input *ConfirmTwoFactorInput) (*ConfirmTwoFactorOutput, error) {
+	const op = "services.auth.ConfirmTwoFactor"
+	log := s.log.With(logger.String("op", op))
+
+	twoFactor, err := s.st.TwoFactor().GetByUserID(ctx, input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if twoFactor == nil {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	if twoFactor.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	step, ok := s.otp.Validate(
+		twoFactor.Secret, input.Code, twoFactor.LastUsedStep)
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	codes := make([]string, 0, recoveryCodesCount)
+	for range recoveryCodesCount {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		codes = append(codes, code)
+	}
+
+	event, err := events.NewAccountEvent(
+		events.TypeAccountTwoFactorEnabled, input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The new codes replace the old ones and enable two-factor at once.
+	err = s.st.WithTx(ctx, func(st storage.Storage) error {
+		err := st.RecoveryCodes().DeleteByUserID(ctx, input.UserID)
+		if err != nil {
+			return err
+		}
+
+		for _, code := range codes {
+			err = st.RecoveryCodes().Create(ctx, &storage.RecoveryCode{
+				ID:        uuid.New(),
+				UserID:    input.UserID,
+				CodeHash:  hashRecoveryCode(code),
+				CreatedAt: time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		confirmedAt := time.Now()
+		twoFactor.Enabled = true
+		twoFactor.LastUsedStep = step
+		twoFactor.ConfirmedAt = &confirmedAt
+		err = st.TwoFactor().Update(ctx, twoFactor)
+		if err != nil {
+			return err
+		}
+
+		return st.Outbox().Create(ctx, event)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.au.Record(ctx, &audit.Event{
+		Type:   audit.EventTwoFactorEnabled,
+		UserID: &input.UserID,
+	})
+
+	return &ConfirmTwoFactorOutput{
+		RecoveryCodes: codes,
+	}, nil
+}
+
+func (s *service) DisableTwoFactor(
+	ctx context.Context, input *DisableTwoFactorInput) error {
+	const op = "services.auth.DisableTwoFactor"
+	_ = s.log.With(logger.String("op", op))
+
+	user, err := s.st.Users().GetByID(ctx, input.UserID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.pw.Compare(user.PasswordHash, input.Password)
+	if err != nil {
+		return ErrInvalidPassword
+	}
+
+	twoFactor, err := s.st.TwoFactor().GetByUserID(ctx, input.UserID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if twoFactor == nil {
+		return ErrTwoFactorNotEnabled
+	}
+
+	event, err := events.NewAccountEvent(
+		events.TypeAccountTwoFactorDisabled, input.UserID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.st.WithTx(ctx, func(st storage.Storage) error {
+		err := st.RecoveryCodes().DeleteByUserID(ctx, input.UserID)
+		if err != nil {
+			return err
+		}
+
+		err = st.TwoFactor().Delete(ctx, input.UserID)
+		if err != nil {
+			return err
+		}
+
+		return st.Outbox().Create(ctx, event)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.au.Record(ctx, &audit.Event{
+		Type:   audit.EventTwoFactorDisabled,
+		UserID: &input.UserID,
+	})
+
+	return nil
+}
+
+func (s *service) VerifyTwoFactor(
+	ctx context.Context, input *VerifyTwoFactorInput) (*LoginOutput, error) {
+	const op = "services.auth.VerifyTwoFactor"
+	_ = s.log.With(logger.String("op", op))
+
+	claims, err := s.sec.ParseChallengeToken(ctx, input.ChallengeToken)
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	user, err := s.st.Users().GetByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user == nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	err = s.checkStatus(ctx, user)
+	if err != nil {
+		return nil, s.loginFailed(ctx, user.Login, user, err)
+	}
+
+	twoFactor, err := s.st.TwoFactor().GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if twoFactor == nil || !twoFactor.Enabled {
+		return nil, ErrInvalidChallenge
+	}
+
+	// The user gets a few guesses per challenge lifetime, otherwise six
+	// digits could be brute-forced. The budget is the user's rather than
+	// the challenge's, so logging in with the password again for a fresh
+	// challenge doesn't bring more guesses.
+	result, err := s.st.RateLimits().Take(ctx,
+		"two_factor:"+user.ID.String(),
+		&storage.RateLimitBudget{
+			Limit:  s.cfg.TOTP.MaxAttempts,
+			Period: time.Second * time.Duration(s.cfg.JWT.ChallengeTTL),
+		})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !result.Allowed {
+		return nil, ErrTooManyCodeAttempts
+	}
+
+	var used bool
+	switch {
+	case input.Code != "":
+		step, ok := s.otp.Validate(
+			twoFactor.Secret, input.Code, twoFactor.LastUsedStep)
+		if ok {
+			used, err = s.st.TwoFactor().UseStep(ctx, user.ID, step)
+		}
+	case input.RecoveryCode != "":
+		used, err = s.st.RecoveryCodes().Use(ctx, user.ID,
+			hashRecoveryCode(input.RecoveryCode), time.Now())
+	default:
+		return nil, ErrInvalidCode
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The code is used up by the storage, so of concurrent requests with
+	// the same code only one gets through.
+	if !used {
+		return nil, s.loginFailed(ctx, user.Login, user, ErrInvalidCode)
+	}
+
+	output, err := s.createSession(ctx, user, input.UserAgent)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return output, nil
+}
+
+func generateRecoveryCode() (string, error) {
+	raw := make([]byte, recoveryCodeSize)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate recovery code: %w", err)
+	}
+
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(raw))
+	code = code[:recoveryCodeSize]
+
+	return code[:recoveryCodeSize/2] + "-" + code[recoveryCodeSize/2:], nil
+}
+
+// hashRecoveryCode normalizes the code the way users tend to mistype it.
+// Codes are random, so a fast hash is enough to keep them unreadable.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
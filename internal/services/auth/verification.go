@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"cloud-notes/internal/jobs"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/mailer"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const verificationText = `Hello, %s!
+
+Please confirm your email address by opening the link below:
+
+%s
+
+The link is valid until %s. If you didn't create an account, just ignore
+this message.
+`
+
+// queueVerification asks the worker to mail a verification link to the
+// owner of the login.
+func (s *service) queueVerification(ctx context.Context, login string) error {
+	const op = "services.auth.queueVerification"
+	_ = s.log.With(logger.String("op", op))
+
+	job, err := jobs.NewJob(
+		jobs.TypeSendVerification, &jobs.SendVerification{Login: login})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.st.Jobs().Create(ctx, job)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SendVerification mails a new verification link to the email of the
+// login's owner. Nothing is sent for unknown logins, verified accounts and
+// accounts without an email.
+func (s *service) SendVerification(ctx context.Context, login string) error {
+	const op = "services.auth.SendVerification"
+	_ = s.log.With(logger.String("op", op))
+
+	user, err := s.st.Users().GetByLogin(ctx, login)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user == nil || user.Status != storage.UserStatusPending ||
+		user.Email == nil {
+		return nil
+	}
+
+	verification := &storage.Verification{
+		UserID:  user.ID,
+		TokenID: uuid.New(),
+		ExpiresAt: time.Now().Add(
+			time.Second * time.Duration(s.cfg.Verification.TTL)),
+	}
+
+	err = s.st.Verifications().Create(ctx, verification)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	token := s.sec.GenerateVerificationToken(ctx, &security.VerificationClaims{
+		UserID:    verification.UserID,
+		TokenID:   verification.TokenID,
+		ExpiresAt: verification.ExpiresAt,
+	})
+	link := s.cfg.Server.PublicURL + "/verify-email?" +
+		url.Values{"token": {token}}.Encode()
+
+	err = s.ml.Send(ctx, &mailer.Message{
+		To:      *user.Email,
+		Subject: "Confirm your email",
+		Text: fmt.Sprintf(verificationText, user.FirstName, link,
+			verification.ExpiresAt.UTC().Format(time.RFC1123)),
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *service) VerifyEmail(
+	ctx context.Context, verificationToken string) error {
+	const op = "services.auth.VerifyEmail"
+	_ = s.log.With(logger.String("op", op))
+
+	claims, err := s.sec.ParseVerificationToken(ctx, verificationToken)
+	if err != nil {
+		return ErrInvalidVerificationToken
+	}
+
+	// The user stays locked until the link is used up, so requests with
+	// the same link take turns and only the first one activates the account.
+	// A block or deletion made meanwhile isn't overwritten either.
+	err = s.st.WithTx(ctx, func(st storage.Storage) error {
+		user, err := st.Users().GetForUpdate(ctx, claims.UserID)
+		if err != nil {
+			return err
+		}
+
+		if user == nil || user.Status != storage.UserStatusPending {
+			return ErrInvalidVerificationToken
+		}
+
+		verification, err := st.Verifications().GetByUserID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		if verification == nil || verification.TokenID != claims.TokenID {
+			return ErrInvalidVerificationToken
+		}
+
+		user.Status = storage.UserStatusActive
+		err = st.Users().Update(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		return st.Verifications().Delete(ctx, user.ID)
+	})
+	if errors.Is(err, ErrInvalidVerificationToken) {
+		return err
+	} else if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ResendVerification queues a new link for pending users. The login is
+// looked up by the worker, so the endpoint answers the same for unknown
+// and already verified logins and can't be used to find out which logins
+// are registered.
+func (s *service) ResendVerification(ctx context.Context, login string) error {
+	const op = "services.auth.ResendVerification"
+	_ = s.log.With(logger.String("op", op))
+
+	result, err := s.st.RateLimits().Take(ctx, "verification:"+login,
+		&storage.RateLimitBudget{
+			Limit: s.cfg.Verification.ResendLimit,
+			Period: time.Second *
+				time.Duration(s.cfg.Verification.ResendPeriod),
+		})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !result.Allowed {
+		return ErrTooManyVerificationMails
+	}
+
+	err = s.queueVerification(ctx, login)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -4,35 +4,56 @@ import (
 	"errors"
 	"time"
 
+	"cloud-notes/internal/quota"
+
 	"github.com/google/uuid"
 )
 
 var (
-	ErrNoteNotFound = errors.New("note not found")
+	ErrNoteNotFound      = errors.New("note not found")
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+	ErrReadOnly          = errors.New("workspace is read-only for viewers")
+	ErrNoteTooLarge      = quota.ErrNoteTooLarge
+	ErrNotesLimit        = quota.ErrNotesLimit
+	ErrStorageLimit      = quota.ErrStorageLimit
 )
 
+// Space selects where the notes live: the user's personal notes when
+// WorkspaceID is nil, or the notes of a workspace the user is a member of.
+type Space struct {
+	UserID      uuid.UUID
+	WorkspaceID *uuid.UUID
+}
+
 type NoteOutput struct {
-	ID        uuid.UUID
-	Title     *string
-	Text      *string
-	Pinned    bool
-	UpdatedAt *time.Time
-	CreatedAt time.Time
+	ID          uuid.UUID
+	WorkspaceID *uuid.UUID
+	AuthorID    uuid.UUID
+	Title       *string
+	Text        *string
+	Pinned      bool
+	UpdatedAt   *time.Time
+	CreatedAt   time.Time
 }
 
 type CreateNoteInput struct {
-	UserID uuid.UUID
+	Space
 	Title  *string
 	Text   *string
 	Pinned bool
 }
 
+type GetNotesInput struct {
+	Space
+	Query *string
+}
+
 type GetNotesOutput struct {
 	Notes []*NoteOutput
 }
 
 type UpdateNoteInput struct {
-	UserID uuid.UUID
+	Space
 	NoteID uuid.UUID
 	Title  *string
 	Text   *string
@@ -40,6 +61,6 @@ type UpdateNoteInput struct {
 }
 
 type DeleteNoteInput struct {
-	UserID uuid.UUID
+	Space
 	NoteID uuid.UUID
 }
@@ -0,0 +1,21 @@
+package notes
+
+import (
+	"context"
+
+	"cloud-notes/internal/storage"
+)
+
+// withEvent runs the change and writes the event it returns in one
+// transaction, so an event is never lost or sent for a rolled back change.
+func (s *service) withEvent(ctx context.Context,
+	change func(st storage.Storage) (*storage.OutboxEvent, error)) error {
+	return s.st.WithTx(ctx, func(st storage.Storage) error {
+		event, err := change(st)
+		if err != nil {
+			return err
+		}
+
+		return st.Outbox().Create(ctx, event)
+	})
+}
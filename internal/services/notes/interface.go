@@ -2,13 +2,11 @@ package notes
 
 import (
 	"context"
-
-	"github.com/google/uuid"
 )
 
 type Service interface {
 	CreateNote(ctx context.Context, input *CreateNoteInput) (*NoteOutput, error)
-	GetNotes(ctx context.Context, userID uuid.UUID) (*GetNotesOutput, error)
+	GetNotes(ctx context.Context, input *GetNotesInput) (*GetNotesOutput, error)
 	UpdateNote(ctx context.Context, input *UpdateNoteInput) (*NoteOutput, error)
 	DeleteNote(ctx context.Context, input *DeleteNoteInput) error
 }
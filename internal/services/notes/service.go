@@ -2,10 +2,13 @@ package notes
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"cloud-notes/internal/events"
 	"cloud-notes/internal/logger"
+	"cloud-notes/internal/quota"
 	"cloud-notes/internal/storage"
 
 	"github.com/google/uuid"
@@ -14,12 +17,14 @@ import (
 type service struct {
 	log logger.Logger
 	st  storage.Storage
+	qt  quota.Quota
 }
 
-func New(log logger.Logger, st storage.Storage) Service {
+func New(log logger.Logger, st storage.Storage, qt quota.Quota) Service {
 	return &service{
 		log: log,
 		st:  st,
+		qt:  qt,
 	}
 }
 
@@ -28,51 +33,73 @@ func (s *service) CreateNote(
 	const op = "services.notes.CreateNote"
 	_ = s.log.With(logger.String("op", op))
 
-	note := &storage.Note{
-		ID:        uuid.New(),
-		UserID:    input.UserID,
-		Title:     input.Title,
-		Text:      input.Text,
-		Pinned:    input.Pinned,
-		UpdatedAt: nil,
-		CreatedAt: time.Now(),
+	err := s.checkAccess(ctx, &input.Space, true)
+	if err != nil {
+		return nil, err
 	}
 
-	err := s.st.Notes().Create(ctx, note)
+	ownerID, err := s.spaceOwner(ctx, &input.Space)
 	if err != nil {
+		return nil, err
+	}
+
+	size := textSize(input.Text)
+	note := &storage.Note{
+		ID:          uuid.New(),
+		UserID:      input.UserID,
+		Title:       input.Title,
+		Text:        input.Text,
+		Pinned:      input.Pinned,
+		UpdatedAt:   nil,
+		CreatedAt:   time.Now(),
+		WorkspaceID: input.WorkspaceID,
+	}
+
+	err = s.withEvent(ctx,
+		func(st storage.Storage) (*storage.OutboxEvent, error) {
+			err := s.qt.Reserve(ctx, st, ownerID, 1, size, size)
+			if err != nil {
+				return nil, err
+			}
+
+			err = st.Notes().Create(ctx, note)
+			if err != nil {
+				return nil, err
+			}
+
+			return events.NewNoteEvent(events.TypeNoteCreated, ownerID, note)
+		})
+	if isQuotaError(err) {
+		return nil, err
+	} else if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &NoteOutput{
-		ID:        note.ID,
-		Title:     note.Title,
-		Text:      note.Text,
-		Pinned:    note.Pinned,
-		UpdatedAt: note.UpdatedAt,
-		CreatedAt: note.CreatedAt,
-	}, nil
+	return toNoteOutput(note), nil
 }
 
 func (s *service) GetNotes(
-	ctx context.Context, userID uuid.UUID) (*GetNotesOutput, error) {
+	ctx context.Context, input *GetNotesInput) (*GetNotesOutput, error) {
 	const op = "services.notes.GetNotes"
 	_ = s.log.With(logger.String("op", op))
 
-	notes, err := s.st.Notes().GetByUserID(ctx, userID)
+	err := s.checkAccess(ctx, &input.Space, false)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := s.st.Notes().Find(ctx, &storage.NoteFilter{
+		UserID:      input.UserID,
+		WorkspaceID: input.WorkspaceID,
+		Query:       input.Query,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	output := new(GetNotesOutput)
 	for _, note := range notes {
-		output.Notes = append(output.Notes, &NoteOutput{
-			ID:        note.ID,
-			Title:     note.Title,
-			Text:      note.Text,
-			Pinned:    note.Pinned,
-			UpdatedAt: note.UpdatedAt,
-			CreatedAt: note.CreatedAt,
-		})
+		output.Notes = append(output.Notes, toNoteOutput(note))
 	}
 
 	return output, nil
@@ -83,33 +110,51 @@ func (s *service) UpdateNote(
 	const op = "services.notes.UpdateNote"
 	_ = s.log.With(logger.String("op", op))
 
-	note, err := s.st.Notes().GetByID(ctx, input.NoteID)
+	err := s.checkAccess(ctx, &input.Space, true)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return nil, err
 	}
 
-	if note == nil || note.UserID != input.UserID {
-		return nil, ErrNoteNotFound
+	ownerID, err := s.spaceOwner(ctx, &input.Space)
+	if err != nil {
+		return nil, err
 	}
 
-	updatedAt := time.Now()
-	note.Title = input.Title
-	note.Text = input.Text
-	note.Pinned = input.Pinned
-	note.UpdatedAt = &updatedAt
-	err = s.st.Notes().Update(ctx, note)
-	if err != nil {
+	var note *storage.Note
+	err = s.withEvent(ctx,
+		func(st storage.Storage) (*storage.OutboxEvent, error) {
+			var err error
+			note, err = lockNote(ctx, st, &input.Space, input.NoteID)
+			if err != nil {
+				return nil, err
+			}
+
+			size := textSize(input.Text)
+			delta := size - textSize(note.Text)
+			err = s.qt.Reserve(ctx, st, ownerID, 0, delta, size)
+			if err != nil {
+				return nil, err
+			}
+
+			updatedAt := time.Now()
+			note.Title = input.Title
+			note.Text = input.Text
+			note.Pinned = input.Pinned
+			note.UpdatedAt = &updatedAt
+			err = st.Notes().Update(ctx, note)
+			if err != nil {
+				return nil, err
+			}
+
+			return events.NewNoteEvent(events.TypeNoteUpdated, ownerID, note)
+		})
+	if isQuotaError(err) || errors.Is(err, ErrNoteNotFound) {
+		return nil, err
+	} else if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &NoteOutput{
-		ID:        note.ID,
-		Title:     note.Title,
-		Text:      note.Text,
-		Pinned:    note.Pinned,
-		UpdatedAt: note.UpdatedAt,
-		CreatedAt: note.CreatedAt,
-	}, nil
+	return toNoteOutput(note), nil
 }
 
 func (s *service) DeleteNote(
@@ -117,19 +162,154 @@ func (s *service) DeleteNote(
 	const op = "services.notes.DeleteNote"
 	_ = s.log.With(logger.String("op", op))
 
-	note, err := s.st.Notes().GetByID(ctx, input.NoteID)
+	err := s.checkAccess(ctx, &input.Space, true)
 	if err != nil {
+		return err
+	}
+
+	ownerID, err := s.spaceOwner(ctx, &input.Space)
+	if err != nil {
+		return err
+	}
+
+	err = s.withEvent(ctx,
+		func(st storage.Storage) (*storage.OutboxEvent, error) {
+			note, err := lockNote(ctx, st, &input.Space, input.NoteID)
+			if err != nil {
+				return nil, err
+			}
+
+			// Only the request that deleted the note gives its quota
+			// back, however many raced for it.
+			deleted, err := st.Notes().Delete(ctx, note.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			if !deleted {
+				return nil, ErrNoteNotFound
+			}
+
+			err = s.qt.Release(ctx, st, ownerID, 1, textSize(note.Text))
+			if err != nil {
+				return nil, err
+			}
+
+			return events.NewNoteEvent(events.TypeNoteDeleted, ownerID, note)
+		})
+	if errors.Is(err, ErrNoteNotFound) {
+		return err
+	} else if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if note == nil || note.UserID != input.UserID {
-		return ErrNoteNotFound
+	return nil
+}
+
+// isQuotaError tells the quota errors apart, they go to the client as they
+// are.
+func isQuotaError(err error) bool {
+	return errors.Is(err, ErrNoteTooLarge) ||
+		errors.Is(err, ErrNotesLimit) ||
+		errors.Is(err, ErrStorageLimit)
+}
+
+// checkAccess makes sure the user may read the space's notes, or change
+// them when write is set. Everyone has full access to their personal
+// space, while viewers of a workspace can only read.
+func (s *service) checkAccess(
+	ctx context.Context, space *Space, write bool) error {
+	const op = "services.notes.checkAccess"
+
+	if space.WorkspaceID == nil {
+		return nil
 	}
 
-	err = s.st.Notes().Delete(ctx, note.ID)
+	member, err := s.st.WorkspaceMembers().Get(
+		ctx, *space.WorkspaceID, space.UserID)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	if member == nil {
+		return ErrWorkspaceNotFound
+	}
+
+	if write && member.Role == storage.WorkspaceRoleViewer {
+		return ErrReadOnly
+	}
+
 	return nil
 }
+
+// spaceOwner is the user whose quota the space's notes count against: the
+// user for the personal space and the owner for a workspace.
+func (s *service) spaceOwner(
+	ctx context.Context, space *Space) (uuid.UUID, error) {
+	const op = "services.notes.spaceOwner"
+
+	if space.WorkspaceID == nil {
+		return space.UserID, nil
+	}
+
+	workspace, err := s.st.Workspaces().GetByID(ctx, *space.WorkspaceID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if workspace == nil {
+		return uuid.Nil, ErrWorkspaceNotFound
+	}
+
+	return workspace.OwnerID, nil
+}
+
+// lockNote finds a note of the space and locks it for the rest of the
+// transaction, so the quota is counted from the text the change replaces.
+// Notes of other spaces are reported as missing.
+func lockNote(ctx context.Context, st storage.Storage,
+	space *Space, noteID uuid.UUID) (*storage.Note, error) {
+	const op = "services.notes.lockNote"
+
+	note, err := st.Notes().GetForUpdate(ctx, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if note == nil {
+		return nil, ErrNoteNotFound
+	}
+
+	switch {
+	case space.WorkspaceID == nil:
+		if note.WorkspaceID != nil || note.UserID != space.UserID {
+			return nil, ErrNoteNotFound
+		}
+	case note.WorkspaceID == nil || *note.WorkspaceID != *space.WorkspaceID:
+		return nil, ErrNoteNotFound
+	}
+
+	return note, nil
+}
+
+// textSize is the note's size counted against the quota, the text in bytes.
+func textSize(text *string) int64 {
+	if text == nil {
+		return 0
+	}
+
+	return int64(len(*text))
+}
+
+func toNoteOutput(note *storage.Note) *NoteOutput {
+	return &NoteOutput{
+		ID:          note.ID,
+		WorkspaceID: note.WorkspaceID,
+		AuthorID:    note.UserID,
+		Title:       note.Title,
+		Text:        note.Text,
+		Pinned:      note.Pinned,
+		UpdatedAt:   note.UpdatedAt,
+		CreatedAt:   note.CreatedAt,
+	}
+}
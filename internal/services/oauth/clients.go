@@ -0,0 +1,100 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+func (s *service) CreateClient(ctx context.Context,
+	input *CreateClientInput) (*CreateClientOutput, error) {
+	const op = "services.oauth.CreateClient"
+	log := s.log.With(logger.String("op", op))
+
+	client := &storage.OAuthClient{
+		ID:           uuid.New(),
+		OwnerID:      input.OwnerID,
+		Name:         input.Name,
+		RedirectURIs: input.RedirectURIs,
+		Scopes:       input.Scopes,
+		CreatedAt:    time.Now(),
+	}
+
+	output := new(CreateClientOutput)
+	if input.Confidential {
+		secret, secretHash, err := security.GenerateClientSecret()
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		client.SecretHash = &secretHash
+		output.Secret = &secret
+	}
+
+	err := s.st.OAuthClients().Create(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	output.ClientOutput = *toClientOutput(client)
+	return output, nil
+}
+
+func (s *service) GetClients(
+	ctx context.Context, ownerID uuid.UUID) (*GetClientsOutput, error) {
+	const op = "services.oauth.GetClients"
+	_ = s.log.With(logger.String("op", op))
+
+	clients, err := s.st.OAuthClients().GetByOwnerID(ctx, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	output := new(GetClientsOutput)
+	for _, client := range clients {
+		output.Clients = append(output.Clients, toClientOutput(client))
+	}
+
+	return output, nil
+}
+
+// DeleteClient removes the client together with every grant issued to it.
+func (s *service) DeleteClient(
+	ctx context.Context, input *DeleteClientInput) error {
+	const op = "services.oauth.DeleteClient"
+	_ = s.log.With(logger.String("op", op))
+
+	client, err := s.st.OAuthClients().GetByID(ctx, input.ClientID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if client == nil || client.OwnerID != input.OwnerID {
+		return ErrClientNotFound
+	}
+
+	err = s.st.OAuthClients().Delete(ctx, client.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func toClientOutput(client *storage.OAuthClient) *ClientOutput {
+	return &ClientOutput{
+		ID:           client.ID,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectURIs,
+		Scopes:       client.Scopes,
+		Confidential: client.SecretHash != nil,
+		CreatedAt:    client.CreatedAt,
+	}
+}
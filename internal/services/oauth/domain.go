@@ -0,0 +1,143 @@
+package oauth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ResponseTypeCode        = "code"
+	CodeChallengeMethodS256 = "S256"
+
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+
+	TokenTypeBearer = "Bearer"
+)
+
+var (
+	ErrClientNotFound          = errors.New("client not found")
+	ErrInvalidRedirectURI      = errors.New("invalid redirect uri")
+	ErrInvalidScope            = errors.New("invalid scope")
+	ErrUnsupportedResponseType = errors.New("unsupported response type")
+	ErrInvalidCodeChallenge    = errors.New("code challenge must use S256")
+
+	ErrInvalidRequest       = errors.New("invalid request")
+	ErrInvalidClient        = errors.New("client authentication failed")
+	ErrInvalidGrant         = errors.New("invalid or expired grant")
+	ErrUnsupportedGrantType = errors.New("unsupported grant type")
+
+	// errInactiveUser rolls the refresh back without counting it as reuse,
+	// the client gets ErrInvalidGrant.
+	errInactiveUser = errors.New("user is not active")
+)
+
+type CreateClientInput struct {
+	OwnerID      uuid.UUID
+	Name         string
+	RedirectURIs []string
+	Scopes       []string
+	Confidential bool
+}
+
+type ClientOutput struct {
+	ID           uuid.UUID
+	Name         string
+	RedirectURIs []string
+	Scopes       []string
+	Confidential bool
+	CreatedAt    time.Time
+}
+
+// CreateClientOutput carries the client secret, which is shown only once.
+// Public clients have no secret.
+type CreateClientOutput struct {
+	ClientOutput
+	Secret *string
+}
+
+type GetClientsOutput struct {
+	Clients []*ClientOutput
+}
+
+type DeleteClientInput struct {
+	OwnerID  uuid.UUID
+	ClientID uuid.UUID
+}
+
+type AuthorizeInput struct {
+	UserID              uuid.UUID
+	ClientID            uuid.UUID
+	RedirectURI         string
+	ResponseType        string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// GetAuthorizationOutput is what the consent screen shows to the user.
+type GetAuthorizationOutput struct {
+	ClientID    uuid.UUID
+	ClientName  string
+	RedirectURI string
+	Scopes      []string
+}
+
+type ApproveAuthorizationInput struct {
+	AuthorizeInput
+	Approved bool
+}
+
+// ApproveAuthorizationOutput is where the user agent must be sent next,
+// with either the code or the access_denied error.
+type ApproveAuthorizationOutput struct {
+	RedirectURL string
+}
+
+type ClientCredentials struct {
+	ClientID     string
+	ClientSecret string
+}
+
+type TokenInput struct {
+	ClientCredentials
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	Scope        string
+	UserAgent    *string
+}
+
+type TokenOutput struct {
+	AccessToken  string
+	TokenType    string
+	ExpiresIn    int
+	RefreshToken string
+	Scopes       []string
+}
+
+type IntrospectInput struct {
+	ClientCredentials
+	Token string
+}
+
+// IntrospectOutput describes an active token. Inactive tokens have Active
+// set to false and nothing else, so nothing leaks about them.
+type IntrospectOutput struct {
+	Active    bool
+	Scopes    []string
+	ClientID  *uuid.UUID
+	UserID    *uuid.UUID
+	TokenType string
+	ExpiresAt *time.Time
+}
+
+type RevokeInput struct {
+	ClientCredentials
+	Token string
+}
@@ -0,0 +1,23 @@
+package oauth
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	CreateClient(ctx context.Context,
+		input *CreateClientInput) (*CreateClientOutput, error)
+	GetClients(
+		ctx context.Context, ownerID uuid.UUID) (*GetClientsOutput, error)
+	DeleteClient(ctx context.Context, input *DeleteClientInput) error
+	GetAuthorization(ctx context.Context,
+		input *AuthorizeInput) (*GetAuthorizationOutput, error)
+	ApproveAuthorization(ctx context.Context,
+		input *ApproveAuthorizationInput) (*ApproveAuthorizationOutput, error)
+	Token(ctx context.Context, input *TokenInput) (*TokenOutput, error)
+	Introspect(
+		ctx context.Context, input *IntrospectInput) (*IntrospectOutput, error)
+	Revoke(ctx context.Context, input *RevokeInput) error
+}
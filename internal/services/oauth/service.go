@@ -0,0 +1,595 @@
+package oauth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"cloud-notes/internal/audit"
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// codeChallengeLength is the length of a base64url encoded SHA-256 hash.
+const codeChallengeLength = 43
+
+type service struct {
+	log logger.Logger
+	st  storage.Storage
+	sec security.Security
+	au  audit.Auditor
+	cfg *config.Config
+}
+
+func New(log logger.Logger, st storage.Storage, sec security.Security,
+	au audit.Auditor, cfg *config.Config) Service {
+	return &service{
+		log: log,
+		st:  st,
+		sec: sec,
+		au:  au,
+		cfg: cfg,
+	}
+}
+
+func (s *service) GetAuthorization(ctx context.Context,
+	input *AuthorizeInput) (*GetAuthorizationOutput, error) {
+	const op = "services.oauth.GetAuthorization"
+	_ = s.log.With(logger.String("op", op))
+
+	client, redirectURI, scopes, err := s.checkAuthorization(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetAuthorizationOutput{
+		ClientID:    client.ID,
+		ClientName:  client.Name,
+		RedirectURI: redirectURI,
+		Scopes:      scopes,
+	}, nil
+}
+
+// ApproveAuthorization issues the authorization code once the user has
+// agreed on the consent screen. The request is checked again, because the
+// user agent could have changed it in between.
+func (s *service) ApproveAuthorization(ctx context.Context,
+	input *ApproveAuthorizationInput) (*ApproveAuthorizationOutput, error) {
+	const op = "services.oauth.ApproveAuthorization"
+	log := s.log.With(logger.String("op", op))
+
+	client, redirectURI, scopes, err := s.checkAuthorization(
+		ctx, &input.AuthorizeInput)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	if input.State != "" {
+		params.Set("state", input.State)
+	}
+
+	if !input.Approved {
+		params.Set("error", "access_denied")
+		return &ApproveAuthorizationOutput{
+			RedirectURL: withQuery(redirectURI, params),
+		}, nil
+	}
+
+	code, codeHash, err := security.GenerateAuthCode()
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.st.AuthCodes().Create(ctx, &storage.AuthCode{
+		CodeHash:      codeHash,
+		ClientID:      client.ID,
+		UserID:        input.UserID,
+		RedirectURI:   redirectURI,
+		Scopes:        scopes,
+		CodeChallenge: input.CodeChallenge,
+		ExpiresAt: time.Now().Add(
+			time.Second * time.Duration(s.cfg.OAuth.CodeTTL)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.au.Record(ctx, &audit.Event{
+		Type:   audit.EventOAuthConsentGranted,
+		UserID: &input.UserID,
+		Details: map[string]string{
+			"client_id": client.ID.String(),
+			"scopes":    strings.Join(scopes, " "),
+		},
+	})
+
+	params.Set("code", code)
+	return &ApproveAuthorizationOutput{
+		RedirectURL: withQuery(redirectURI, params),
+	}, nil
+}
+
+// Token exchanges an authorization code or a refresh token for a new pair
+// of tokens. Refresh tokens are rotated on every use, and reusing a rotated
+// one revokes the grant.
+func (s *service) Token(
+	ctx context.Context, input *TokenInput) (*TokenOutput, error) {
+	const op = "services.oauth.Token"
+	_ = s.log.With(logger.String("op", op))
+
+	client, err := s.authenticateClient(ctx, &input.ClientCredentials)
+	if err != nil {
+		return nil, err
+	}
+
+	switch input.GrantType {
+	case GrantTypeAuthorizationCode:
+		return s.exchangeCode(ctx, client, input)
+	case GrantTypeRefreshToken:
+		return s.refresh(ctx, client, input)
+	case "":
+		return nil, ErrInvalidRequest
+	default:
+		return nil, ErrUnsupportedGrantType
+	}
+}
+
+func (s *service) Introspect(
+	ctx context.Context, input *IntrospectInput) (*IntrospectOutput, error) {
+	const op = "services.oauth.Introspect"
+	_ = s.log.With(logger.String("op", op))
+
+	client, err := s.authenticateClient(ctx, &input.ClientCredentials)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Token == "" {
+		return nil, ErrInvalidRequest
+	}
+
+	session, claims, err := s.findGrant(ctx, client, input.Token)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if session == nil {
+		return &IntrospectOutput{Active: false}, nil
+	}
+
+	// Tokens of blocked and deleted users are rejected by the API, so they
+	// are reported as inactive to resource servers as well.
+	active, err := activeUser(ctx, s.st, session.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !active {
+		return &IntrospectOutput{Active: false}, nil
+	}
+
+	output := &IntrospectOutput{
+		Active:    true,
+		Scopes:    session.Scopes,
+		ClientID:  session.ClientID,
+		UserID:    &session.UserID,
+		TokenType: GrantTypeRefreshToken,
+		ExpiresAt: session.ExpiresAt,
+	}
+
+	if claims != nil {
+		output.Scopes = claims.Scopes
+		output.TokenType = TokenTypeBearer
+		output.ExpiresAt = claims.ExpiresAt
+	}
+
+	return output, nil
+}
+
+// Revoke deletes the grant the access or refresh token belongs to, which
+// invalidates every token issued for it. Unknown tokens are not an error.
+func (s *service) Revoke(ctx context.Context, input *RevokeInput) error {
+	const op = "services.oauth.Revoke"
+	_ = s.log.With(logger.String("op", op))
+
+	client, err := s.authenticateClient(ctx, &input.ClientCredentials)
+	if err != nil {
+		return err
+	}
+
+	if input.Token == "" {
+		return ErrInvalidRequest
+	}
+
+	session, _, err := s.findGrant(ctx, client, input.Token)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if session == nil {
+		return nil
+	}
+
+	err = s.st.Sessions().Delete(ctx, session.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.au.Record(ctx, &audit.Event{
+		Type:   audit.EventSessionRevoked,
+		UserID: &session.UserID,
+		Details: map[string]string{
+			"session_id": session.ID.String(),
+			"client_id":  client.ID.String(),
+		},
+	})
+
+	return nil
+}
+
+func (s *service) checkAuthorization(ctx context.Context,
+	input *AuthorizeInput) (*storage.OAuthClient, string, []string, error) {
+	const op = "services.oauth.checkAuthorization"
+	_ = s.log.With(logger.String("op", op))
+
+	client, err := s.st.OAuthClients().GetByID(ctx, input.ClientID)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if client == nil {
+		return nil, "", nil, ErrClientNotFound
+	}
+
+	// The redirect URI may be omitted only when there is nothing to choose
+	// from, otherwise it must match a registered one exactly.
+	redirectURI := input.RedirectURI
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+
+	if !slices.Contains(client.RedirectURIs, redirectURI) {
+		return nil, "", nil, ErrInvalidRedirectURI
+	}
+
+	if input.ResponseType != ResponseTypeCode {
+		return nil, "", nil, ErrUnsupportedResponseType
+	}
+
+	if input.CodeChallengeMethod != CodeChallengeMethodS256 ||
+		len(input.CodeChallenge) != codeChallengeLength {
+		return nil, "", nil, ErrInvalidCodeChallenge
+	}
+
+	scopes, err := parseScope(input.Scope, client.Scopes)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return client, redirectURI, scopes, nil
+}
+
+func (s *service) exchangeCode(ctx context.Context,
+	client *storage.OAuthClient, input *TokenInput) (*TokenOutput, error) {
+	const op = "services.oauth.exchangeCode"
+	log := s.log.With(logger.String("op", op))
+
+	if input.Code == "" || input.CodeVerifier == "" {
+		return nil, ErrInvalidRequest
+	}
+
+	code, err := s.st.AuthCodes().Take(ctx, security.HashToken(input.Code))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if code == nil || code.ClientID != client.ID ||
+		code.RedirectURI != input.RedirectURI ||
+		!security.VerifyCodeChallenge(input.CodeVerifier, code.CodeChallenge) {
+		return nil, ErrInvalidGrant
+	}
+
+	refreshToken, refreshTokenHash, err := security.GenerateRefreshToken()
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	expiresAt := s.refreshExpiresAt(now)
+	if limit := now.Add(s.grantLifetime()); limit.Before(expiresAt) {
+		expiresAt = limit
+	}
+	session := &storage.Session{
+		ID:               uuid.New(),
+		UserID:           code.UserID,
+		UserAgent:        input.UserAgent,
+		CreatedAt:        now,
+		ClientID:         &client.ID,
+		Scopes:           code.Scopes,
+		RefreshTokenHash: &refreshTokenHash,
+		ExpiresAt:        &expiresAt,
+	}
+
+	err = s.st.Sessions().Create(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.issueTokens(ctx, session, session.Scopes, refreshToken), nil
+}
+
+// refresh rotates the refresh token in one statement, so of concurrent
+// requests with the same token only one gets new tokens. A token that was
+// already rotated means it leaked or the client misbehaves, either way the
+// grant is revoked and the client has to be authorized again.
+func (s *service) refresh(ctx context.Context,
+	client *storage.OAuthClient, input *TokenInput) (*TokenOutput, error) {
+	const op = "services.oauth.refresh"
+	log := s.log.With(logger.String("op", op))
+
+	if input.RefreshToken == "" {
+		return nil, ErrInvalidRequest
+	}
+
+	refreshToken, refreshTokenHash, err := security.GenerateRefreshToken()
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	presentedHash := security.HashToken(input.RefreshToken)
+	now := time.Now()
+
+	var session *storage.Session
+	var scopes []string
+	err = s.st.WithTx(ctx, func(st storage.Storage) error {
+		rotated, err := st.Sessions().RotateRefreshToken(ctx, presentedHash,
+			refreshTokenHash, now, s.refreshExpiresAt(now), s.grantLifetime())
+		if err != nil {
+			return err
+		}
+
+		if rotated == nil || rotated.ClientID == nil ||
+			*rotated.ClientID != client.ID {
+			return ErrInvalidGrant
+		}
+
+		// The grant outlives a block, but no tokens are issued for it
+		// while the user can't sign in.
+		active, err := activeUser(ctx, st, rotated.UserID)
+		if err != nil {
+			return err
+		}
+
+		if !active {
+			return errInactiveUser
+		}
+
+		// A narrower scope applies to the new access token only, the
+		// grant keeps everything the user has agreed to.
+		session, scopes = rotated, rotated.Scopes
+		if input.Scope != "" {
+			scopes, err = parseScope(input.Scope, rotated.Scopes)
+		}
+
+		return err
+	})
+	if errors.Is(err, ErrInvalidGrant) {
+		return nil, s.checkReuse(ctx, client, presentedHash)
+	} else if errors.Is(err, errInactiveUser) {
+		return nil, ErrInvalidGrant
+	} else if errors.Is(err, ErrInvalidScope) {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.issueTokens(ctx, session, scopes, refreshToken), nil
+}
+
+// checkReuse revokes the grant of the client when the refresh token was
+// rotated in it before. The token is rejected either way.
+func (s *service) checkReuse(ctx context.Context,
+	client *storage.OAuthClient, refreshTokenHash string) error {
+	const op = "services.oauth.checkReuse"
+	_ = s.log.With(logger.String("op", op))
+
+	session, err := s.st.Sessions().GetByRotatedRefreshTokenHash(
+		ctx, refreshTokenHash)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if session == nil || session.ClientID == nil ||
+		*session.ClientID != client.ID {
+		return ErrInvalidGrant
+	}
+
+	err = s.st.Sessions().Delete(ctx, session.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.au.Record(ctx, &audit.Event{
+		Type:   audit.EventRefreshTokenReused,
+		UserID: &session.UserID,
+		Details: map[string]string{
+			"session_id": session.ID.String(),
+			"client_id":  client.ID.String(),
+		},
+	})
+
+	return ErrInvalidGrant
+}
+
+// refreshExpiresAt is when an unused refresh token expires. Every refresh
+// moves it on, but never past the grant's lifetime.
+func (s *service) refreshExpiresAt(now time.Time) time.Time {
+	return now.Add(time.Second * time.Duration(s.cfg.OAuth.RefreshTokenTTL))
+}
+
+// grantLifetime is how long a grant lives however often it is refreshed,
+// then the client has to be authorized again.
+func (s *service) grantLifetime() time.Duration {
+	return time.Second * time.Duration(s.cfg.OAuth.GrantLifetime)
+}
+
+// activeUser tells whether the user may use their grants. A block that has
+// run out is lifted, like the session check does.
+func activeUser(ctx context.Context,
+	st storage.Storage, userID uuid.UUID) (bool, error) {
+	user, err := st.Users().GetByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	if user == nil {
+		return false, nil
+	}
+
+	if user.Status == storage.UserStatusBlocked {
+		now := time.Now()
+		lifted, err := st.Blocks().Lift(ctx, user.ID, &now)
+		if err != nil || !lifted {
+			return false, err
+		}
+
+		user, err = st.Users().GetByID(ctx, userID)
+		if err != nil || user == nil {
+			return false, err
+		}
+	}
+
+	return user.Status == storage.UserStatusActive, nil
+}
+
+// issueTokens signs a new access token for the session and pairs it with
+// the refresh token the session was saved with.
+func (s *service) issueTokens(ctx context.Context, session *storage.Session,
+	scopes []string, refreshToken string) *TokenOutput {
+	now := time.Now()
+	accessExpiresAt := now.Add(
+		time.Second * time.Duration(s.cfg.OAuth.AccessTokenTTL))
+	accessToken := s.sec.GenerateAccessToken(ctx, &security.Claims{
+		UserID:    session.UserID,
+		SessionID: session.ID,
+		ClientID:  session.ClientID,
+		Scopes:    scopes,
+		ExpiresAt: &accessExpiresAt,
+		CreatedAt: now,
+	})
+
+	return &TokenOutput{
+		AccessToken:  accessToken,
+		TokenType:    TokenTypeBearer,
+		ExpiresIn:    s.cfg.OAuth.AccessTokenTTL,
+		RefreshToken: refreshToken,
+		Scopes:       scopes,
+	}
+}
+
+// authenticateClient checks the client secret of confidential clients.
+// Public clients are identified by their id alone and rely on PKCE.
+func (s *service) authenticateClient(ctx context.Context,
+	credentials *ClientCredentials) (*storage.OAuthClient, error) {
+	const op = "services.oauth.authenticateClient"
+	_ = s.log.With(logger.String("op", op))
+
+	clientID, err := uuid.Parse(credentials.ClientID)
+	if err != nil {
+		return nil, ErrInvalidClient
+	}
+
+	client, err := s.st.OAuthClients().GetByID(ctx, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if client == nil {
+		return nil, ErrInvalidClient
+	}
+
+	if client.SecretHash != nil {
+		secretHash := security.HashToken(credentials.ClientSecret)
+		if subtle.ConstantTimeCompare(
+			[]byte(secretHash), []byte(*client.SecretHash)) != 1 {
+			return nil, ErrInvalidClient
+		}
+	}
+
+	return client, nil
+}
+
+// findGrant resolves an access or refresh token to the live grant of the
+// client. Claims are returned for access tokens only.
+func (s *service) findGrant(ctx context.Context, client *storage.OAuthClient,
+	token string) (*storage.Session, *security.Claims, error) {
+	const op = "services.oauth.findGrant"
+	_ = s.log.With(logger.String("op", op))
+
+	var session *storage.Session
+	claims, err := s.sec.ParseAccessToken(ctx, token)
+	if err == nil && claims.ClientID != nil {
+		session, err = s.st.Sessions().GetByID(ctx, claims.SessionID)
+	} else {
+		claims = nil
+		session, err = s.st.Sessions().GetByRefreshTokenHash(
+			ctx, security.HashToken(token))
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if session == nil || session.ClientID == nil ||
+		*session.ClientID != client.ID || session.ExpiresAt == nil ||
+		!session.ExpiresAt.After(time.Now()) {
+		return nil, nil, nil
+	}
+
+	return session, claims, nil
+}
+
+// parseScope splits the space separated scope and checks it against the
+// allowed scopes. An empty scope requests everything allowed.
+func parseScope(scope string, allowed []string) ([]string, error) {
+	scopes := strings.Fields(scope)
+	if len(scopes) == 0 {
+		return allowed, nil
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(allowed, scope) {
+			return nil, ErrInvalidScope
+		}
+	}
+
+	slices.Sort(scopes)
+	return slices.Compact(scopes), nil
+}
+
+func withQuery(rawURL string, params url.Values) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
@@ -1,13 +1,24 @@
 package user
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrExportNotFound       = errors.New("export not found")
+	ErrExportInProgress     = errors.New("export already in progress")
+	ErrExportNotReady       = errors.New("export not ready")
+	ErrInvalidDownloadToken = errors.New("invalid download token")
+	ErrUserBlocked          = errors.New("user blocked")
+	ErrUserDeleted          = errors.New("user deleted")
+)
+
 type GetProfileOutput struct {
 	Login     string
+	Email     *string
 	FirstName string
 	Timezone  string
 	CreatedAt time.Time
@@ -18,3 +29,57 @@ type UpdateProfileInput struct {
 	FirstName string
 	Timezone  string
 }
+
+type GetExportInput struct {
+	UserID   uuid.UUID
+	ExportID uuid.UUID
+}
+
+// ExportOutput describes an export. DownloadURL is set once the archive is
+// ready and stays valid until the export expires.
+type ExportOutput struct {
+	ID          uuid.UUID
+	Status      string
+	DownloadURL *string
+	CompletedAt *time.Time
+	ExpiresAt   *time.Time
+	CreatedAt   time.Time
+}
+
+type DownloadExportOutput struct {
+	FileName string
+	Archive  []byte
+}
+
+type GetSecurityEventsInput struct {
+	UserID uuid.UUID
+	Limit  *uint64
+	Offset *uint64
+}
+
+// SecurityEventOutput is an audit trail entry as shown to its owner.
+// ByAdmin hides who exactly acted on the account.
+type SecurityEventOutput struct {
+	ID        uuid.UUID
+	Type      string
+	ByAdmin   bool
+	IP        *string
+	UserAgent *string
+	Details   map[string]string
+	CreatedAt time.Time
+}
+
+type GetSecurityEventsOutput struct {
+	Events []*SecurityEventOutput
+}
+
+// UsageOutput is what the user's notes take up, next to the limits of the
+// user's plan.
+type UsageOutput struct {
+	Plan          string
+	Notes         int64
+	MaxNotes      int64
+	Bytes         int64
+	MaxTotalBytes int64
+	MaxNoteBytes  int64
+}
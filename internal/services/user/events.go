@@ -0,0 +1,51 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/storage"
+)
+
+const (
+	defaultSecurityEventsLimit = 50
+	maxSecurityEventsLimit     = 100
+)
+
+// GetSecurityEvents returns the user's own audit trail, newest first.
+func (s *service) GetSecurityEvents(ctx context.Context,
+	input *GetSecurityEventsInput) (*GetSecurityEventsOutput, error) {
+	const op = "services.user.GetSecurityEvents"
+	_ = s.log.With(logger.String("op", op))
+
+	limit := uint64(defaultSecurityEventsLimit)
+	if input.Limit != nil {
+		limit = min(*input.Limit, maxSecurityEventsLimit)
+	}
+
+	events, err := s.st.SecurityEvents().Find(ctx,
+		&storage.SecurityEventFilter{
+			UserID: &input.UserID,
+			Limit:  &limit,
+			Offset: input.Offset,
+		})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	output := new(GetSecurityEventsOutput)
+	for _, event := range events {
+		output.Events = append(output.Events, &SecurityEventOutput{
+			ID:        event.ID,
+			Type:      event.Type,
+			ByAdmin:   event.ActorID != nil,
+			IP:        event.IP,
+			UserAgent: event.UserAgent,
+			Details:   event.Details,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+
+	return output, nil
+}
@@ -0,0 +1,639 @@
+package user
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"cloud-notes/internal/jobs"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// The archive layout is a public format, so it's described separately from
+// the storage models.
+type exportProfile struct {
+	ID        uuid.UUID `json:"id"`
+	Login     string    `json:"login"`
+	Email     *string   `json:"email"`
+	FirstName string    `json:"first_name"`
+	Timezone  string    `json:"timezone"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportSession struct {
+	ID        uuid.UUID `json:"id"`
+	UserAgent *string   `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportNote struct {
+	ID        uuid.UUID  `json:"id"`
+	Title     *string    `json:"title"`
+	Text      *string    `json:"text"`
+	Pinned    bool       `json:"pinned"`
+	UpdatedAt *time.Time `json:"updated_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type exportPasskey struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type exportSecurityEvent struct {
+	ID        uuid.UUID         `json:"id"`
+	Type      string            `json:"type"`
+	ByAdmin   bool              `json:"by_admin"`
+	IP        *string           `json:"ip"`
+	UserAgent *string           `json:"user_agent"`
+	Details   map[string]string `json:"details"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type exportIdentity struct {
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// exportPersonalToken is the token's metadata, the token itself is only
+// shown once and isn't stored.
+type exportPersonalToken struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type exportOAuthGrant struct {
+	ID         uuid.UUID  `json:"id"`
+	ClientID   uuid.UUID  `json:"client_id"`
+	ClientName *string    `json:"client_name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type exportMembership struct {
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	Name        string    `json:"name"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// exportWebhook leaves out the signing secret.
+type exportWebhook struct {
+	ID        uuid.UUID  `json:"id"`
+	URL       string     `json:"url"`
+	Events    []string   `json:"events"`
+	Active    bool       `json:"active"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// exportTwoFactor tells whether 2FA is on, the TOTP secret stays out.
+type exportTwoFactor struct {
+	Enabled     bool       `json:"enabled"`
+	ConfirmedAt *time.Time `json:"confirmed_at"`
+}
+
+// RequestExport queues building the archive for the worker. Only one
+// export per user may be in progress at a time.
+func (s *service) RequestExport(
+	ctx context.Context, userID uuid.UUID) (*ExportOutput, error) {
+	const op = "services.user.RequestExport"
+	_ = s.log.With(logger.String("op", op))
+
+	exports, err := s.st.Exports().GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, export := range exports {
+		if export.Status == storage.ExportStatusPending {
+			return nil, ErrExportInProgress
+		}
+	}
+
+	export := &storage.Export{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Status:    storage.ExportStatusPending,
+		CreatedAt: time.Now(),
+	}
+
+	job, err := jobs.NewJob(
+		jobs.TypeBuildExport, &jobs.BuildExport{ExportID: export.ID})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The archive is built by the worker. The job is created together with
+	// the export, so a pending export always has someone to build it.
+	err = s.st.WithTx(ctx, func(st storage.Storage) error {
+		err := st.Exports().Create(ctx, export)
+		if err != nil {
+			return err
+		}
+
+		return st.Jobs().Create(ctx, job)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.toExportOutput(ctx, export), nil
+}
+
+func (s *service) GetExport(
+	ctx context.Context, input *GetExportInput) (*ExportOutput, error) {
+	const op = "services.user.GetExport"
+	_ = s.log.With(logger.String("op", op))
+
+	export, err := s.st.Exports().GetByID(ctx, input.ExportID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if export == nil || export.UserID != input.UserID {
+		return nil, ErrExportNotFound
+	}
+
+	return s.toExportOutput(ctx, export), nil
+}
+
+func (s *service) DownloadExport(ctx context.Context,
+	downloadToken string) (*DownloadExportOutput, error) {
+	const op = "services.user.DownloadExport"
+	_ = s.log.With(logger.String("op", op))
+
+	claims, err := s.sec.ParseDownloadToken(ctx, downloadToken)
+	if err != nil {
+		return nil, ErrInvalidDownloadToken
+	}
+
+	export, err := s.st.Exports().GetByID(ctx, claims.ExportID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if export == nil || export.UserID != claims.UserID {
+		return nil, ErrExportNotFound
+	}
+
+	if export.Status != storage.ExportStatusReady {
+		return nil, ErrExportNotReady
+	}
+
+	// The link works without a session, so the status the session checks
+	// is checked here. A block that has run out is lifted the same way.
+	user, err := s.st.Users().GetByID(ctx, export.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user == nil {
+		return nil, ErrExportNotFound
+	}
+
+	if user.Status == storage.UserStatusBlocked {
+		now := time.Now()
+		lifted, err := s.st.Blocks().Lift(ctx, user.ID, &now)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if !lifted {
+			return nil, ErrUserBlocked
+		}
+	}
+
+	if user.Status == storage.UserStatusDeleted {
+		return nil, ErrUserDeleted
+	}
+
+	return &DownloadExportOutput{
+		FileName: fmt.Sprintf("cloud-notes-export-%s.zip",
+			export.CreatedAt.UTC().Format("2006-01-02")),
+		Archive: export.Archive,
+	}, nil
+}
+
+func (s *service) PurgeExpiredExports(ctx context.Context) error {
+	const op = "services.user.PurgeExpiredExports"
+	_ = s.log.With(logger.String("op", op))
+
+	err := s.st.Exports().DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *service) toExportOutput(
+	ctx context.Context, export *storage.Export) *ExportOutput {
+	output := &ExportOutput{
+		ID:          export.ID,
+		Status:      string(export.Status),
+		CompletedAt: export.CompletedAt,
+		ExpiresAt:   export.ExpiresAt,
+		CreatedAt:   export.CreatedAt,
+	}
+
+	if export.Status == storage.ExportStatusReady {
+		token := s.sec.GenerateDownloadToken(ctx, &security.DownloadClaims{
+			UserID:    export.UserID,
+			ExportID:  export.ID,
+			ExpiresAt: *export.ExpiresAt,
+		})
+
+		downloadURL := s.cfg.Server.PublicURL + "/api/user/export/download?" +
+			url.Values{"token": {token}}.Encode()
+		output.DownloadURL = &downloadURL
+	}
+
+	return output
+}
+
+// BuildExport builds the archive of a pending export. An archive that
+// can't be built fails the export, the user may request another one. The
+// job is retried only if the outcome couldn't be saved.
+func (s *service) BuildExport(ctx context.Context, exportID uuid.UUID) error {
+	const op = "services.user.BuildExport"
+	log := s.log.With(logger.String("op", op))
+
+	export, err := s.st.Exports().GetByID(ctx, exportID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// An earlier attempt may have finished the export already.
+	if export == nil || export.Status != storage.ExportStatusPending {
+		return nil
+	}
+
+	archive, err := s.buildArchive(ctx, export.UserID)
+
+	completedAt := time.Now()
+	export.CompletedAt = &completedAt
+	if err != nil {
+		log.ErrorContext(ctx, "failed to build export",
+			logger.String("export_id", export.ID.String()),
+			logger.Error(err))
+		export.Status = storage.ExportStatusFailed
+	} else {
+		expiresAt := completedAt.Add(
+			time.Second * time.Duration(s.cfg.Export.TTL))
+		export.Status = storage.ExportStatusReady
+		export.Archive = archive
+		export.ExpiresAt = &expiresAt
+	}
+
+	err = s.st.Exports().Update(ctx, export)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// buildArchive packs everything stored about the user into a ZIP archive
+// with JSON files for machines and a Markdown file per note for people.
+func (s *service) buildArchive(
+	ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	const op = "services.user.buildArchive"
+	_ = s.log.With(logger.String("op", op))
+
+	user, err := s.st.Users().GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user == nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrExportNotFound)
+	}
+
+	notes, err := s.st.Notes().GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	files := map[string]any{
+		"profile.json": &exportProfile{
+			ID:        user.ID,
+			Login:     user.Login,
+			Email:     user.Email,
+			FirstName: user.FirstName,
+			Timezone:  user.Timezone,
+			Status:    string(user.Status),
+			CreatedAt: user.CreatedAt,
+		},
+	}
+
+	exporters := []func(context.Context, uuid.UUID, map[string]any) error{
+		s.exportSessions,
+		s.exportPasskeys,
+		s.exportSecurityEvents,
+		s.exportIdentities,
+		s.exportPersonalTokens,
+		s.exportMemberships,
+		s.exportWebhooks,
+		s.exportTwoFactor,
+	}
+	for _, export := range exporters {
+		err = export(ctx, userID, files)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	exportNotes := make([]*exportNote, 0, len(notes))
+	for _, note := range notes {
+		exportNotes = append(exportNotes, &exportNote{
+			ID:        note.ID,
+			Title:     note.Title,
+			Text:      note.Text,
+			Pinned:    note.Pinned,
+			UpdatedAt: note.UpdatedAt,
+			CreatedAt: note.CreatedAt,
+		})
+	}
+	files["notes.json"] = exportNotes
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+
+	for name, v := range files {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		err = writeArchiveFile(zw, name, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	for _, note := range notes {
+		err = writeArchiveFile(zw, "notes/"+note.ID.String()+".md",
+			noteMarkdown(note))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	err = zw.Close()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// exportSessions splits the sessions into login sessions and the grants
+// of OAuth clients.
+func (s *service) exportSessions(
+	ctx context.Context, userID uuid.UUID, files map[string]any) error {
+	sessions, err := s.st.Sessions().GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	exportSessions := make([]*exportSession, 0, len(sessions))
+	grants := make([]*exportOAuthGrant, 0)
+	for _, session := range sessions {
+		if session.ClientID == nil {
+			exportSessions = append(exportSessions, &exportSession{
+				ID:        session.ID,
+				UserAgent: session.UserAgent,
+				CreatedAt: session.CreatedAt,
+			})
+			continue
+		}
+
+		client, err := s.st.OAuthClients().GetByID(ctx, *session.ClientID)
+		if err != nil {
+			return err
+		}
+
+		grant := &exportOAuthGrant{
+			ID:        session.ID,
+			ClientID:  *session.ClientID,
+			Scopes:    session.Scopes,
+			ExpiresAt: session.ExpiresAt,
+			CreatedAt: session.CreatedAt,
+		}
+		if client != nil {
+			grant.ClientName = &client.Name
+		}
+		grants = append(grants, grant)
+	}
+	files["sessions.json"] = exportSessions
+	files["oauth_grants.json"] = grants
+
+	return nil
+}
+
+func (s *service) exportPasskeys(
+	ctx context.Context, userID uuid.UUID, files map[string]any) error {
+	passkeys, err := s.st.Passkeys().GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	exportPasskeys := make([]*exportPasskey, 0, len(passkeys))
+	for _, passkey := range passkeys {
+		exportPasskeys = append(exportPasskeys, &exportPasskey{
+			ID:         passkey.ID,
+			Name:       passkey.Name,
+			LastUsedAt: passkey.LastUsedAt,
+			CreatedAt:  passkey.CreatedAt,
+		})
+	}
+	files["passkeys.json"] = exportPasskeys
+
+	return nil
+}
+
+// exportSecurityEvents hides who exactly acted on the account, like the
+// security events of the API.
+func (s *service) exportSecurityEvents(
+	ctx context.Context, userID uuid.UUID, files map[string]any) error {
+	events, err := s.st.SecurityEvents().Find(ctx,
+		&storage.SecurityEventFilter{UserID: &userID})
+	if err != nil {
+		return err
+	}
+
+	exportEvents := make([]*exportSecurityEvent, 0, len(events))
+	for _, event := range events {
+		exportEvents = append(exportEvents, &exportSecurityEvent{
+			ID:        event.ID,
+			Type:      event.Type,
+			ByAdmin:   event.ActorID != nil,
+			IP:        event.IP,
+			UserAgent: event.UserAgent,
+			Details:   event.Details,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+	files["security_events.json"] = exportEvents
+
+	return nil
+}
+
+func (s *service) exportIdentities(
+	ctx context.Context, userID uuid.UUID, files map[string]any) error {
+	identities, err := s.st.Identities().GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	exportIdentities := make([]*exportIdentity, 0, len(identities))
+	for _, identity := range identities {
+		exportIdentities = append(exportIdentities, &exportIdentity{
+			Provider:  identity.Provider,
+			Subject:   identity.Subject,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		})
+	}
+	files["identities.json"] = exportIdentities
+
+	return nil
+}
+
+func (s *service) exportPersonalTokens(
+	ctx context.Context, userID uuid.UUID, files map[string]any) error {
+	tokens, err := s.st.PersonalTokens().GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	exportTokens := make([]*exportPersonalToken, 0, len(tokens))
+	for _, token := range tokens {
+		exportTokens = append(exportTokens, &exportPersonalToken{
+			ID:         token.ID,
+			Name:       token.Name,
+			Scopes:     token.Scopes,
+			LastUsedAt: token.LastUsedAt,
+			ExpiresAt:  token.ExpiresAt,
+			CreatedAt:  token.CreatedAt,
+		})
+	}
+	files["personal_tokens.json"] = exportTokens
+
+	return nil
+}
+
+func (s *service) exportMemberships(
+	ctx context.Context, userID uuid.UUID, files map[string]any) error {
+	workspaces, err := s.st.Workspaces().GetByMemberID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	memberships := make([]*exportMembership, 0, len(workspaces))
+	for _, workspace := range workspaces {
+		member, err := s.st.WorkspaceMembers().Get(
+			ctx, workspace.ID, userID)
+		if err != nil {
+			return err
+		}
+
+		if member == nil {
+			continue
+		}
+
+		memberships = append(memberships, &exportMembership{
+			WorkspaceID: workspace.ID,
+			Name:        workspace.Name,
+			Role:        string(member.Role),
+			CreatedAt:   member.CreatedAt,
+		})
+	}
+	files["workspaces.json"] = memberships
+
+	return nil
+}
+
+func (s *service) exportWebhooks(
+	ctx context.Context, userID uuid.UUID, files map[string]any) error {
+	webhooks, err := s.st.Webhooks().GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	exportWebhooks := make([]*exportWebhook, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		exportWebhooks = append(exportWebhooks, &exportWebhook{
+			ID:        webhook.ID,
+			URL:       webhook.URL,
+			Events:    webhook.Events,
+			Active:    webhook.Active,
+			CreatedAt: webhook.CreatedAt,
+			UpdatedAt: webhook.UpdatedAt,
+		})
+	}
+	files["webhooks.json"] = exportWebhooks
+
+	return nil
+}
+
+func (s *service) exportTwoFactor(
+	ctx context.Context, userID uuid.UUID, files map[string]any) error {
+	twoFactor, err := s.st.TwoFactor().GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	exportTwoFactor := new(exportTwoFactor)
+	if twoFactor != nil && twoFactor.Enabled {
+		exportTwoFactor.Enabled = true
+		exportTwoFactor.ConfirmedAt = twoFactor.ConfirmedAt
+	}
+	files["two_factor.json"] = exportTwoFactor
+
+	return nil
+}
+
+func writeArchiveFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+func noteMarkdown(note *storage.Note) []byte {
+	buf := new(bytes.Buffer)
+
+	if note.Title != nil && *note.Title != "" {
+		fmt.Fprintf(buf, "# %s\n\n", *note.Title)
+	}
+
+	if note.Text != nil {
+		buf.WriteString(*note.Text)
+		buf.WriteString("\n")
+	}
+
+	return buf.Bytes()
+}
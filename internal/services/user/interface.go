@@ -10,4 +10,14 @@ type Service interface {
 	GetProfile(ctx context.Context, userID uuid.UUID) (*GetProfileOutput, error)
 	UpdateProfile(ctx context.Context, input *UpdateProfileInput) error
 	DeleteProfile(ctx context.Context, userID uuid.UUID) error
+	PurgeDeletedUsers(ctx context.Context) error
+	RequestExport(ctx context.Context, userID uuid.UUID) (*ExportOutput, error)
+	GetExport(ctx context.Context, input *GetExportInput) (*ExportOutput, error)
+	DownloadExport(ctx context.Context,
+		downloadToken string) (*DownloadExportOutput, error)
+	BuildExport(ctx context.Context, exportID uuid.UUID) error
+	PurgeExpiredExports(ctx context.Context) error
+	GetSecurityEvents(ctx context.Context,
+		input *GetSecurityEventsInput) (*GetSecurityEventsOutput, error)
+	GetUsage(ctx context.Context, userID uuid.UUID) (*UsageOutput, error)
 }
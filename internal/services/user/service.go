@@ -3,22 +3,39 @@ package user
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"cloud-notes/internal/audit"
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/events"
 	"cloud-notes/internal/logger"
+	"cloud-notes/internal/quota"
+	"cloud-notes/internal/security"
 	"cloud-notes/internal/storage"
 
 	"github.com/google/uuid"
 )
 
+const purgeBatchSize = 100
+
 type service struct {
 	log logger.Logger
 	st  storage.Storage
+	sec security.Security
+	au  audit.Auditor
+	qt  quota.Quota
+	cfg *config.Config
 }
 
-func New(log logger.Logger, st storage.Storage) Service {
+func New(log logger.Logger, st storage.Storage, sec security.Security,
+	au audit.Auditor, qt quota.Quota, cfg *config.Config) Service {
 	return &service{
 		log: log,
 		st:  st,
+		sec: sec,
+		au:  au,
+		qt:  qt,
+		cfg: cfg,
 	}
 }
 
@@ -34,6 +51,7 @@ func (s *service) GetProfile(
 
 	return &GetProfileOutput{
 		Login:     user.Login,
+		Email:     user.Email,
 		FirstName: user.FirstName,
 		Timezone:  user.Timezone,
 		CreatedAt: user.CreatedAt,
@@ -57,10 +75,17 @@ func (s *service) UpdateProfile(
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	s.au.Record(ctx, &audit.Event{
+		Type:   audit.EventProfileUpdated,
+		UserID: &user.ID,
+	})
+
 	return nil
 
 }
 
+// DeleteProfile only marks the user as deleted. The data is kept for the
+// grace period, so the account can be restored by logging in again.
 func (s *service) DeleteProfile(ctx context.Context, userID uuid.UUID) error {
 	const op = "services.user.DeleteProfile"
 	_ = s.log.With(logger.String("op", op))
@@ -70,7 +95,106 @@ func (s *service) DeleteProfile(ctx context.Context, userID uuid.UUID) error {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	err = s.st.Users().Delete(ctx, user.ID)
+	event, err := events.NewAccountEvent(
+		events.TypeAccountDeletionRequested, user.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	gracePeriod := time.Second * time.Duration(s.cfg.Deletion.GracePeriod)
+	err = s.st.WithTx(ctx, func(st storage.Storage) error {
+		err := st.Deletions().Create(ctx, &storage.Deletion{
+			UserID:    user.ID,
+			PurgeAt:   now.Add(gracePeriod),
+			CreatedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+
+		user.Status = storage.UserStatusDeleted
+		err = st.Users().Update(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		err = st.Sessions().DeleteByUserID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		return st.Outbox().Create(ctx, event)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.au.Record(ctx, &audit.Event{
+		Type:   audit.EventAccountDeleted,
+		UserID: &user.ID,
+	})
+
+	return nil
+}
+
+// PurgeDeletedUsers removes users whose grace period is over together with
+// all their data.
+func (s *service) PurgeDeletedUsers(ctx context.Context) error {
+	const op = "services.user.PurgeDeletedUsers"
+	log := s.log.With(logger.String("op", op))
+
+	for {
+		deletions, err := s.st.Deletions().GetDue(
+			ctx, time.Now(), purgeBatchSize)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, deletion := range deletions {
+			err = s.purgeUser(ctx, deletion.UserID)
+			if err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+
+			log.InfoContext(ctx, "user purged",
+				logger.String("user_id", deletion.UserID.String()))
+		}
+
+		if len(deletions) < purgeBatchSize {
+			return nil
+		}
+	}
+}
+
+// purgeUser removes the user with all their data. Workspaces shared with
+// other members aren't removed with the owner but pass to a member, whose
+// usage then includes the workspace's notes. The heir is picked from the
+// members read in the transaction, so it is serializable.
+func (s *service) purgeUser(ctx context.Context, userID uuid.UUID) error {
+	const op = "services.user.purgeUser"
+	_ = s.log.With(logger.String("op", op))
+
+	err := s.st.WithTx(ctx, func(st storage.Storage) error {
+		heirs, err := st.Workspaces().TransferOwned(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		err = st.Users().Purge(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		for _, heir := range heirs {
+			err = st.Usage().Recalculate(ctx, heir)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, storage.Serializable())
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -0,0 +1,42 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"cloud-notes/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+// GetUsage returns the user's usage and limits. The notes of workspaces the
+// user owns count too, whoever wrote them.
+func (s *service) GetUsage(
+	ctx context.Context, userID uuid.UUID) (*UsageOutput, error) {
+	const op = "services.user.GetUsage"
+	_ = s.log.With(logger.String("op", op))
+
+	limits, err := s.qt.Limits(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	usage, err := s.st.Usage().GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	output := &UsageOutput{
+		Plan:          limits.Plan,
+		MaxNotes:      limits.MaxNotes,
+		MaxTotalBytes: limits.MaxTotalBytes,
+		MaxNoteBytes:  limits.MaxNoteBytes,
+	}
+
+	if usage != nil {
+		output.Notes = usage.Notes
+		output.Bytes = usage.Bytes
+	}
+
+	return output, nil
+}
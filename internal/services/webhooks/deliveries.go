@@ -0,0 +1,118 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 100
+)
+
+// GetDeliveries returns the webhook's delivery log, newest first.
+func (s *service) GetDeliveries(ctx context.Context,
+	input *GetDeliveriesInput) (*GetDeliveriesOutput, error) {
+	const op = "services.webhooks.GetDeliveries"
+	_ = s.log.With(logger.String("op", op))
+
+	webhook, err := s.webhook(ctx, &input.WebhookInput)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := uint64(defaultDeliveriesLimit)
+	if input.Limit != nil {
+		limit = min(*input.Limit, maxDeliveriesLimit)
+	}
+
+	offset := uint64(0)
+	if input.Offset != nil {
+		offset = *input.Offset
+	}
+
+	deliveries, err := s.st.WebhookDeliveries().GetByWebhookID(
+		ctx, webhook.ID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	output := new(GetDeliveriesOutput)
+	for _, delivery := range deliveries {
+		output.Deliveries = append(output.Deliveries,
+			toDeliveryOutput(delivery))
+	}
+
+	return output, nil
+}
+
+// ReplayDelivery sends the delivery's event again as a new delivery, with
+// the same event ID so receivers can tell it's a repeat.
+func (s *service) ReplayDelivery(ctx context.Context,
+	input *ReplayDeliveryInput) (*DeliveryOutput, error) {
+	const op = "services.webhooks.ReplayDelivery"
+	_ = s.log.With(logger.String("op", op))
+
+	webhook, err := s.webhook(ctx, &input.WebhookInput)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.st.WebhookDeliveries().GetByID(ctx, input.DeliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if delivery == nil || delivery.WebhookID != webhook.ID {
+		return nil, ErrDeliveryNotFound
+	}
+
+	now := time.Now()
+	replay := &storage.WebhookDelivery{
+		ID:            uuid.New(),
+		WebhookID:     webhook.ID,
+		EventID:       delivery.EventID,
+		EventType:     delivery.EventType,
+		Payload:       delivery.Payload,
+		Status:        storage.WebhookDeliveryStatusPending,
+		Attempts:      0,
+		NextAttemptAt: now,
+		ReplayOf:      &delivery.ID,
+		CreatedAt:     now,
+	}
+
+	err = s.st.WebhookDeliveries().Create(ctx, replay)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return toDeliveryOutput(replay), nil
+}
+
+func toDeliveryOutput(delivery *storage.WebhookDelivery) *DeliveryOutput {
+	output := &DeliveryOutput{
+		ID:           delivery.ID,
+		EventID:      delivery.EventID,
+		EventType:    delivery.EventType,
+		Payload:      delivery.Payload,
+		Status:       string(delivery.Status),
+		Attempts:     delivery.Attempts,
+		ResponseCode: delivery.ResponseCode,
+		Error:        delivery.Error,
+		ReplayOf:     delivery.ReplayOf,
+		DeliveredAt:  delivery.DeliveredAt,
+		CreatedAt:    delivery.CreatedAt,
+	}
+
+	if delivery.Status == storage.WebhookDeliveryStatusPending {
+		output.NextAttempt = &delivery.NextAttemptAt
+	}
+
+	return output
+}
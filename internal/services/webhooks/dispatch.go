@@ -0,0 +1,308 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/events"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	deliverBatchSize = 20
+	maxErrorLength   = 500
+)
+
+// Headers of a delivery. The signature is "t=<unix time>,v1=<hex HMAC>"
+// over "<unix time>.<body>", keyed with the webhook's secret.
+const (
+	headerEvent     = "X-CloudNotes-Event"
+	headerDelivery  = "X-CloudNotes-Delivery"
+	headerSignature = "X-CloudNotes-Signature"
+)
+
+var errPrivateAddress = errors.New("private network addresses not allowed")
+
+// deliveryBody is what receivers get. ID is the event's, the same for
+// every webhook and every replay.
+type deliveryBody struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// HandleEvent turns the event into deliveries to the webhooks subscribed
+// to it. Events of a workspace's notes go to the webhooks of all its
+// members. The event bus may hand the same event over again, deliveries of
+// an event to a webhook are created once.
+func (s *service) HandleEvent(
+	ctx context.Context, event *storage.OutboxEvent) error {
+	const op = "services.webhooks.HandleEvent"
+	_ = s.log.With(logger.String("op", op))
+
+	workspaceID, err := events.NoteWorkspace(event)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	webhooks, err := s.st.Webhooks().GetSubscribed(
+		ctx, event.UserID, workspaceID, event.Type)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	payload, err := json.Marshal(&deliveryBody{
+		ID:        event.ID,
+		Type:      event.Type,
+		CreatedAt: event.CreatedAt,
+		Data:      event.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	for _, webhook := range webhooks {
+		err = s.st.WebhookDeliveries().Create(ctx, &storage.WebhookDelivery{
+			ID:            uuid.New(),
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       payload,
+			Status:        storage.WebhookDeliveryStatusPending,
+			Attempts:      0,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// DeliverWebhooks sends the deliveries that are due, a batch at a time.
+// Each delivery is leased while it is sent, so replicas can run this
+// together without sending anything twice.
+func (s *service) DeliverWebhooks(ctx context.Context) error {
+	const op = "services.webhooks.DeliverWebhooks"
+	_ = s.log.With(logger.String("op", op))
+
+	lease := 2 * time.Second * time.Duration(s.cfg.Webhook.Timeout)
+	for {
+		now := time.Now()
+		deliveries, err := s.st.WebhookDeliveries().Claim(
+			ctx, now, now.Add(lease), deliverBatchSize)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Go(func() { s.deliver(ctx, delivery) })
+		}
+		wg.Wait()
+
+		if len(deliveries) < deliverBatchSize {
+			return nil
+		}
+	}
+}
+
+// deliver makes one attempt and records its outcome. Failed attempts are
+// retried with exponential backoff until the attempts run out. A webhook
+// is disabled once its deliveries failed DisableAfter times in a row.
+func (s *service) deliver(
+	ctx context.Context, delivery *storage.WebhookDelivery) {
+	const op = "services.webhooks.deliver"
+	log := s.log.With(logger.String("op", op),
+		logger.String("delivery_id", delivery.ID.String()))
+
+	webhook, err := s.st.Webhooks().GetByID(ctx, delivery.WebhookID)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get webhook", logger.Error(err))
+		return
+	}
+
+	var responseCode *int
+	switch {
+	case webhook == nil:
+		return
+	case !webhook.Active:
+		err = errors.New("webhook is disabled")
+		delivery.Attempts = s.cfg.Webhook.MaxAttempts
+	default:
+		responseCode, err = s.send(ctx, webhook, delivery)
+		delivery.Attempts++
+	}
+
+	now := time.Now()
+	delivery.ResponseCode = responseCode
+	delivery.Error = nil
+	switch {
+	case err == nil:
+		delivery.Status = storage.WebhookDeliveryStatusSucceeded
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= s.cfg.Webhook.MaxAttempts:
+		delivery.Status = storage.WebhookDeliveryStatusFailed
+	default:
+		delivery.NextAttemptAt = now.Add(s.backoff(delivery.Attempts))
+	}
+
+	if err != nil {
+		message := err.Error()
+		if len(message) > maxErrorLength {
+			message = message[:maxErrorLength]
+		}
+		delivery.Error = &message
+	}
+
+	err = s.st.WebhookDeliveries().Update(ctx, delivery)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to update delivery", logger.Error(err))
+		return
+	}
+
+	switch {
+	case delivery.Status == storage.WebhookDeliveryStatusSucceeded &&
+		webhook.Failures > 0:
+		err = s.st.Webhooks().ResetFailures(ctx, webhook.ID)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to reset failures",
+				logger.Error(err))
+		}
+	case delivery.Status == storage.WebhookDeliveryStatusFailed &&
+		webhook.Active:
+		disabled, err := s.st.Webhooks().RecordFailure(
+			ctx, webhook.ID, s.cfg.Webhook.DisableAfter, now)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to record failure",
+				logger.Error(err))
+		} else if disabled {
+			log.WarnContext(ctx, "webhook disabled after failed deliveries",
+				logger.String("webhook_id", webhook.ID.String()))
+		}
+	}
+}
+
+// send posts the delivery and returns the response code, if there was a
+// response. Only 2xx responses count as delivered.
+func (s *service) send(ctx context.Context, webhook *storage.Webhook,
+	delivery *storage.WebhookDelivery) (*int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := time.Now().Unix()
+	signature := security.SignWebhook(
+		webhook.Secret, timestamp, delivery.Payload)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "CloudNotes-Webhooks")
+	request.Header.Set(headerEvent, delivery.EventType)
+	request.Header.Set(headerDelivery, delivery.ID.String())
+	request.Header.Set(headerSignature,
+		"t="+strconv.FormatInt(timestamp, 10)+",v1="+signature)
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = response.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	code := response.StatusCode
+	if code < 200 || code > 299 {
+		return &code, fmt.Errorf("unexpected status %d", code)
+	}
+
+	return &code, nil
+}
+
+// backoff doubles the delay after every failed attempt, up to the maximum.
+func (s *service) backoff(attempts int) time.Duration {
+	delay := time.Second * time.Duration(s.cfg.Webhook.BackoffBase)
+	maxDelay := time.Second * time.Duration(s.cfg.Webhook.BackoffMax)
+	for range attempts - 1 {
+		if delay >= maxDelay {
+			break
+		}
+		delay *= 2
+	}
+
+	return min(delay, maxDelay)
+}
+
+// PurgeDeliveries trims the delivery log to the retention period.
+func (s *service) PurgeDeliveries(ctx context.Context) error {
+	const op = "services.webhooks.PurgeDeliveries"
+	_ = s.log.With(logger.String("op", op))
+
+	before := time.Now().Add(
+		-time.Second * time.Duration(s.cfg.Webhook.Retention))
+
+	err := s.st.WebhookDeliveries().DeleteBefore(ctx, before)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// newClient doesn't follow redirects and, unless allowed, refuses to
+// connect to loopback and private addresses, so webhooks can't be used to
+// reach the server's own network.
+func newClient(cfg *config.Webhook) *http.Client {
+	timeout := time.Second * time.Duration(cfg.Timeout)
+	dialer := &net.Dialer{Timeout: timeout}
+	if !cfg.AllowPrivate {
+		dialer.Control = denyPrivate
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// denyPrivate checks the address after the name is resolved, so a public
+// name pointing to a private address is refused too.
+func denyPrivate(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsMulticast() {
+		return errPrivateAddress
+	}
+
+	return nil
+}
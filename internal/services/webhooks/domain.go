@@ -0,0 +1,85 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("delivery not found")
+	ErrInvalidURL       = errors.New("invalid webhook url")
+	ErrUnknownEvent     = errors.New("unknown event type")
+	ErrTooManyWebhooks  = errors.New("too many webhooks")
+)
+
+type WebhookOutput struct {
+	ID        uuid.UUID
+	URL       string
+	Events    []string
+	Active    bool
+	Failures  int
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+}
+
+// CreateWebhookOutput carries the signing secret, which is only shown once.
+type CreateWebhookOutput struct {
+	WebhookOutput
+	Secret string
+}
+
+type GetWebhooksOutput struct {
+	Webhooks []*WebhookOutput
+}
+
+type CreateWebhookInput struct {
+	UserID uuid.UUID
+	URL    string
+	Events []string
+}
+
+type WebhookInput struct {
+	UserID    uuid.UUID
+	WebhookID uuid.UUID
+}
+
+type UpdateWebhookInput struct {
+	WebhookInput
+	URL    string
+	Events []string
+	Active bool
+}
+
+type GetDeliveriesInput struct {
+	WebhookInput
+	Limit  *uint64
+	Offset *uint64
+}
+
+type DeliveryOutput struct {
+	ID           uuid.UUID
+	EventID      uuid.UUID
+	EventType    string
+	Payload      json.RawMessage
+	Status       string
+	Attempts     int
+	ResponseCode *int
+	Error        *string
+	ReplayOf     *uuid.UUID
+	NextAttempt  *time.Time
+	DeliveredAt  *time.Time
+	CreatedAt    time.Time
+}
+
+type GetDeliveriesOutput struct {
+	Deliveries []*DeliveryOutput
+}
+
+type ReplayDeliveryInput struct {
+	WebhookInput
+	DeliveryID uuid.UUID
+}
@@ -0,0 +1,26 @@
+package webhooks
+
+import (
+	"context"
+
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	CreateWebhook(ctx context.Context,
+		input *CreateWebhookInput) (*CreateWebhookOutput, error)
+	GetWebhooks(ctx context.Context,
+		userID uuid.UUID) (*GetWebhooksOutput, error)
+	UpdateWebhook(ctx context.Context,
+		input *UpdateWebhookInput) (*WebhookOutput, error)
+	DeleteWebhook(ctx context.Context, input *WebhookInput) error
+	GetDeliveries(ctx context.Context,
+		input *GetDeliveriesInput) (*GetDeliveriesOutput, error)
+	ReplayDelivery(ctx context.Context,
+		input *ReplayDeliveryInput) (*DeliveryOutput, error)
+	HandleEvent(ctx context.Context, event *storage.OutboxEvent) error
+	DeliverWebhooks(ctx context.Context) error
+	PurgeDeliveries(ctx context.Context) error
+}
@@ -0,0 +1,222 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/events"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const maxWebhooks = 10
+
+type service struct {
+	log    logger.Logger
+	st     storage.Storage
+	cfg    *config.Config
+	client *http.Client
+}
+
+func New(log logger.Logger, st storage.Storage, cfg *config.Config) Service {
+	return &service{
+		log:    log,
+		st:     st,
+		cfg:    cfg,
+		client: newClient(&cfg.Webhook),
+	}
+}
+
+func (s *service) CreateWebhook(ctx context.Context,
+	input *CreateWebhookInput) (*CreateWebhookOutput, error) {
+	const op = "services.webhooks.CreateWebhook"
+	_ = s.log.With(logger.String("op", op))
+
+	err := validateURL(input.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	eventTypes, err := eventTypes(input.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.st.Webhooks().CountByUserID(ctx, input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if count >= maxWebhooks {
+		return nil, ErrTooManyWebhooks
+	}
+
+	secret, err := security.GenerateWebhookSecret()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	webhook := &storage.Webhook{
+		ID:        uuid.New(),
+		UserID:    input.UserID,
+		URL:       input.URL,
+		Secret:    secret,
+		Events:    eventTypes,
+		Active:    true,
+		CreatedAt: time.Now(),
+	}
+
+	err = s.st.Webhooks().Create(ctx, webhook)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &CreateWebhookOutput{
+		WebhookOutput: *toWebhookOutput(webhook),
+		Secret:        secret,
+	}, nil
+}
+
+func (s *service) GetWebhooks(
+	ctx context.Context, userID uuid.UUID) (*GetWebhooksOutput, error) {
+	const op = "services.webhooks.GetWebhooks"
+	_ = s.log.With(logger.String("op", op))
+
+	webhooks, err := s.st.Webhooks().GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	output := new(GetWebhooksOutput)
+	for _, webhook := range webhooks {
+		output.Webhooks = append(output.Webhooks, toWebhookOutput(webhook))
+	}
+
+	return output, nil
+}
+
+// UpdateWebhook changes the endpoint. Deliveries due while it is disabled
+// fail instead of being sent, and can be replayed once it is enabled.
+func (s *service) UpdateWebhook(ctx context.Context,
+	input *UpdateWebhookInput) (*WebhookOutput, error) {
+	const op = "services.webhooks.UpdateWebhook"
+	_ = s.log.With(logger.String("op", op))
+
+	err := validateURL(input.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	eventTypes, err := eventTypes(input.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook, err := s.webhook(ctx, &input.WebhookInput)
+	if err != nil {
+		return nil, err
+	}
+
+	// A webhook disabled after failed deliveries starts over when it is
+	// enabled again.
+	if input.Active && !webhook.Active {
+		webhook.Failures = 0
+	}
+
+	updatedAt := time.Now()
+	webhook.URL = input.URL
+	webhook.Events = eventTypes
+	webhook.Active = input.Active
+	webhook.UpdatedAt = &updatedAt
+	err = s.st.Webhooks().Update(ctx, webhook)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return toWebhookOutput(webhook), nil
+}
+
+func (s *service) DeleteWebhook(
+	ctx context.Context, input *WebhookInput) error {
+	const op = "services.webhooks.DeleteWebhook"
+	_ = s.log.With(logger.String("op", op))
+
+	webhook, err := s.webhook(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	err = s.st.Webhooks().Delete(ctx, webhook.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// webhook finds the user's webhook. Webhooks of others are reported as
+// missing.
+func (s *service) webhook(
+	ctx context.Context, input *WebhookInput) (*storage.Webhook, error) {
+	const op = "services.webhooks.webhook"
+
+	webhook, err := s.st.Webhooks().GetByID(ctx, input.WebhookID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if webhook == nil || webhook.UserID != input.UserID {
+		return nil, ErrWebhookNotFound
+	}
+
+	return webhook, nil
+}
+
+func validateURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ErrInvalidURL
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" ||
+		parsed.Host == "" {
+		return ErrInvalidURL
+	}
+
+	return nil
+}
+
+// eventTypes checks the subscribed event types and returns them sorted
+// without duplicates.
+func eventTypes(subscribed []string) ([]string, error) {
+	for _, eventType := range subscribed {
+		if !events.IsType(eventType) {
+			return nil, ErrUnknownEvent
+		}
+	}
+
+	eventTypes := append(make([]string, 0, len(subscribed)), subscribed...)
+	slices.Sort(eventTypes)
+
+	return slices.Compact(eventTypes), nil
+}
+
+func toWebhookOutput(webhook *storage.Webhook) *WebhookOutput {
+	return &WebhookOutput{
+		ID:        webhook.ID,
+		URL:       webhook.URL,
+		Events:    webhook.Events,
+		Active:    webhook.Active,
+		Failures:  webhook.Failures,
+		CreatedAt: webhook.CreatedAt,
+		UpdatedAt: webhook.UpdatedAt,
+	}
+}
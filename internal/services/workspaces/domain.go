@@ -0,0 +1,75 @@
+package workspaces
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+	ErrNotOwner          = errors.New("only the owner can do this")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrAlreadyMember     = errors.New("user is already a member")
+	ErrMemberNotFound    = errors.New("member not found")
+	ErrOwnerRole         = errors.New("owner's role can't be changed")
+	ErrInvalidRole       = errors.New("invalid workspace role")
+)
+
+type WorkspaceOutput struct {
+	ID        uuid.UUID
+	Name      string
+	OwnerID   uuid.UUID
+	Role      string
+	CreatedAt time.Time
+}
+
+type CreateWorkspaceInput struct {
+	UserID uuid.UUID
+	Name   string
+}
+
+type GetWorkspacesOutput struct {
+	Workspaces []*WorkspaceOutput
+}
+
+type WorkspaceInput struct {
+	UserID      uuid.UUID
+	WorkspaceID uuid.UUID
+}
+
+type MemberOutput struct {
+	UserID    uuid.UUID
+	Login     string
+	FirstName string
+	Role      string
+	CreatedAt time.Time
+}
+
+type GetWorkspaceOutput struct {
+	WorkspaceOutput
+	Members []*MemberOutput
+}
+
+type RenameWorkspaceInput struct {
+	WorkspaceInput
+	Name string
+}
+
+type AddMemberInput struct {
+	WorkspaceInput
+	Login string
+	Role  string
+}
+
+type UpdateMemberInput struct {
+	WorkspaceInput
+	MemberID uuid.UUID
+	Role     string
+}
+
+type RemoveMemberInput struct {
+	WorkspaceInput
+	MemberID uuid.UUID
+}
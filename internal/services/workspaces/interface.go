@@ -0,0 +1,21 @@
+package workspaces
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	CreateWorkspace(ctx context.Context,
+		input *CreateWorkspaceInput) (*WorkspaceOutput, error)
+	GetWorkspaces(
+		ctx context.Context, userID uuid.UUID) (*GetWorkspacesOutput, error)
+	GetWorkspace(
+		ctx context.Context, input *WorkspaceInput) (*GetWorkspaceOutput, error)
+	RenameWorkspace(ctx context.Context, input *RenameWorkspaceInput) error
+	DeleteWorkspace(ctx context.Context, input *WorkspaceInput) error
+	AddMember(ctx context.Context, input *AddMemberInput) (*MemberOutput, error)
+	UpdateMember(ctx context.Context, input *UpdateMemberInput) error
+	RemoveMember(ctx context.Context, input *RemoveMemberInput) error
+}
@@ -0,0 +1,408 @@
+package workspaces
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/events"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+type service struct {
+	log logger.Logger
+	st  storage.Storage
+}
+
+func New(log logger.Logger, st storage.Storage) Service {
+	return &service{
+		log: log,
+		st:  st,
+	}
+}
+
+// CreateWorkspace creates a workspace with the user as its owner.
+func (s *service) CreateWorkspace(ctx context.Context,
+	input *CreateWorkspaceInput) (*WorkspaceOutput, error) {
+	const op = "services.workspaces.CreateWorkspace"
+	_ = s.log.With(logger.String("op", op))
+
+	workspace := &storage.Workspace{
+		ID:        uuid.New(),
+		Name:      input.Name,
+		OwnerID:   input.UserID,
+		CreatedAt: time.Now(),
+	}
+
+	// A workspace without its owner's membership would be invisible to
+	// the owner, so both are created together.
+	err := s.st.WithTx(ctx, func(st storage.Storage) error {
+		err := st.Workspaces().Create(ctx, workspace)
+		if err != nil {
+			return err
+		}
+
+		return st.WorkspaceMembers().Create(ctx, &storage.WorkspaceMember{
+			WorkspaceID: workspace.ID,
+			UserID:      input.UserID,
+			Role:        storage.WorkspaceRoleOwner,
+			CreatedAt:   workspace.CreatedAt,
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return toWorkspaceOutput(workspace, storage.WorkspaceRoleOwner), nil
+}
+
+func (s *service) GetWorkspaces(
+	ctx context.Context, userID uuid.UUID) (*GetWorkspacesOutput, error) {
+	const op = "services.workspaces.GetWorkspaces"
+	_ = s.log.With(logger.String("op", op))
+
+	workspaces, err := s.st.Workspaces().GetByMemberID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	output := &GetWorkspacesOutput{
+		Workspaces: make([]*WorkspaceOutput, 0, len(workspaces)),
+	}
+	for _, workspace := range workspaces {
+		member, err := s.st.WorkspaceMembers().Get(
+			ctx, workspace.ID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if member == nil {
+			continue
+		}
+
+		output.Workspaces = append(output.Workspaces,
+			toWorkspaceOutput(workspace, member.Role))
+	}
+
+	return output, nil
+}
+
+func (s *service) GetWorkspace(ctx context.Context,
+	input *WorkspaceInput) (*GetWorkspaceOutput, error) {
+	const op = "services.workspaces.GetWorkspace"
+	_ = s.log.With(logger.String("op", op))
+
+	workspace, member, err := s.membership(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := s.st.WorkspaceMembers().GetByWorkspaceID(
+		ctx, workspace.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ids := make([]uuid.UUID, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.UserID)
+	}
+
+	users, err := s.st.Users().GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	byID := make(map[uuid.UUID]*storage.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+
+	output := &GetWorkspaceOutput{
+		WorkspaceOutput: *toWorkspaceOutput(workspace, member.Role),
+		Members:         make([]*MemberOutput, 0, len(members)),
+	}
+	for _, m := range members {
+		user, ok := byID[m.UserID]
+		if !ok {
+			continue
+		}
+
+		output.Members = append(output.Members, toMemberOutput(m, user))
+	}
+
+	return output, nil
+}
+
+func (s *service) RenameWorkspace(
+	ctx context.Context, input *RenameWorkspaceInput) error {
+	const op = "services.workspaces.RenameWorkspace"
+	_ = s.log.With(logger.String("op", op))
+
+	workspace, err := s.ownedWorkspace(ctx, &input.WorkspaceInput)
+	if err != nil {
+		return err
+	}
+
+	workspace.Name = input.Name
+	err = s.st.Workspaces().Update(ctx, workspace)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteWorkspace deletes the workspace with all its notes, writing a
+// note.deleted event for each of them. The notes counted against the
+// owner's quota, so the owner's usage is counted again.
+func (s *service) DeleteWorkspace(
+	ctx context.Context, input *WorkspaceInput) error {
+	const op = "services.workspaces.DeleteWorkspace"
+	_ = s.log.With(logger.String("op", op))
+
+	workspace, err := s.ownedWorkspace(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	err = s.st.WithTx(ctx, func(st storage.Storage) error {
+		notes, err := st.Notes().Find(ctx, &storage.NoteFilter{
+			UserID:      workspace.OwnerID,
+			WorkspaceID: &workspace.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, note := range notes {
+			event, err := events.NewNoteEvent(
+				events.TypeNoteDeleted, workspace.OwnerID, note)
+			if err != nil {
+				return err
+			}
+
+			err = st.Outbox().Create(ctx, event)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = st.Workspaces().Delete(ctx, workspace.ID)
+		if err != nil {
+			return err
+		}
+
+		return st.Usage().Recalculate(ctx, workspace.OwnerID)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// AddMember adds an existing user found by login. There is one owner per
+// workspace, so members are added as editors or viewers.
+func (s *service) AddMember(
+	ctx context.Context, input *AddMemberInput) (*MemberOutput, error) {
+	const op = "services.workspaces.AddMember"
+	_ = s.log.With(logger.String("op", op))
+
+	role, err := memberRole(input.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	workspace, err := s.ownedWorkspace(ctx, &input.WorkspaceInput)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.st.Users().GetByLogin(ctx, input.Login)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user == nil || user.Status != storage.UserStatusActive {
+		return nil, ErrUserNotFound
+	}
+
+	member, err := s.st.WorkspaceMembers().Get(ctx, workspace.ID, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if member != nil {
+		return nil, ErrAlreadyMember
+	}
+
+	member = &storage.WorkspaceMember{
+		WorkspaceID: workspace.ID,
+		UserID:      user.ID,
+		Role:        role,
+		CreatedAt:   time.Now(),
+	}
+
+	err = s.st.WorkspaceMembers().Create(ctx, member)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return toMemberOutput(member, user), nil
+}
+
+func (s *service) UpdateMember(
+	ctx context.Context, input *UpdateMemberInput) error {
+	const op = "services.workspaces.UpdateMember"
+	_ = s.log.With(logger.String("op", op))
+
+	role, err := memberRole(input.Role)
+	if err != nil {
+		return err
+	}
+
+	workspace, err := s.ownedWorkspace(ctx, &input.WorkspaceInput)
+	if err != nil {
+		return err
+	}
+
+	if input.MemberID == workspace.OwnerID {
+		return ErrOwnerRole
+	}
+
+	member, err := s.st.WorkspaceMembers().Get(
+		ctx, workspace.ID, input.MemberID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if member == nil {
+		return ErrMemberNotFound
+	}
+
+	member.Role = role
+	err = s.st.WorkspaceMembers().Update(ctx, member)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RemoveMember lets the owner remove anyone else and members leave on
+// their own. The owner can't leave, only delete the workspace.
+func (s *service) RemoveMember(
+	ctx context.Context, input *RemoveMemberInput) error {
+	const op = "services.workspaces.RemoveMember"
+	_ = s.log.With(logger.String("op", op))
+
+	workspace, _, err := s.membership(ctx, &input.WorkspaceInput)
+	if err != nil {
+		return err
+	}
+
+	if input.MemberID == workspace.OwnerID {
+		return ErrOwnerRole
+	}
+
+	if input.MemberID != input.UserID && input.UserID != workspace.OwnerID {
+		return ErrNotOwner
+	}
+
+	member, err := s.st.WorkspaceMembers().Get(
+		ctx, workspace.ID, input.MemberID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if member == nil {
+		return ErrMemberNotFound
+	}
+
+	err = s.st.WorkspaceMembers().Delete(ctx, workspace.ID, member.UserID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// membership returns the workspace and the caller's membership in it.
+// Workspaces of other people look the same as missing ones.
+func (s *service) membership(ctx context.Context,
+	input *WorkspaceInput) (*storage.Workspace,
+	*storage.WorkspaceMember, error) {
+	const op = "services.workspaces.membership"
+
+	member, err := s.st.WorkspaceMembers().Get(
+		ctx, input.WorkspaceID, input.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if member == nil {
+		return nil, nil, ErrWorkspaceNotFound
+	}
+
+	workspace, err := s.st.Workspaces().GetByID(ctx, input.WorkspaceID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if workspace == nil {
+		return nil, nil, ErrWorkspaceNotFound
+	}
+
+	return workspace, member, nil
+}
+
+func (s *service) ownedWorkspace(ctx context.Context,
+	input *WorkspaceInput) (*storage.Workspace, error) {
+	workspace, member, err := s.membership(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if member.Role != storage.WorkspaceRoleOwner {
+		return nil, ErrNotOwner
+	}
+
+	return workspace, nil
+}
+
+func memberRole(role string) (storage.WorkspaceRole, error) {
+	switch storage.WorkspaceRole(role) {
+	case storage.WorkspaceRoleEditor:
+		return storage.WorkspaceRoleEditor, nil
+	case storage.WorkspaceRoleViewer:
+		return storage.WorkspaceRoleViewer, nil
+	default:
+		return "", ErrInvalidRole
+	}
+}
+
+func toWorkspaceOutput(workspace *storage.Workspace,
+	role storage.WorkspaceRole) *WorkspaceOutput {
+	return &WorkspaceOutput{
+		ID:        workspace.ID,
+		Name:      workspace.Name,
+		OwnerID:   workspace.OwnerID,
+		Role:      string(role),
+		CreatedAt: workspace.CreatedAt,
+	}
+}
+
+func toMemberOutput(member *storage.WorkspaceMember,
+	user *storage.User) *MemberOutput {
+	return &MemberOutput{
+		UserID:    user.ID,
+		Login:     user.Login,
+		FirstName: user.FirstName,
+		Role:      string(member.Role),
+		CreatedAt: member.CreatedAt,
+	}
+}
@@ -0,0 +1,19 @@
+package authcodes
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuthCode is an issued OAuth authorization code. Only the hash of the code
+// is used as the key, the code itself is known to the client alone.
+type AuthCode struct {
+	CodeHash      string    `json:"code_hash"`
+	ClientID      uuid.UUID `json:"client_id"`
+	UserID        uuid.UUID `json:"user_id"`
+	RedirectURI   string    `json:"redirect_uri"`
+	Scopes        []string  `json:"scopes"`
+	CodeChallenge string    `json:"code_challenge"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
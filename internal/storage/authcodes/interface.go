@@ -0,0 +1,10 @@
+package authcodes
+
+import (
+	"context"
+)
+
+type Storage interface {
+	Create(ctx context.Context, code *AuthCode) error
+	Take(ctx context.Context, codeHash string) (*AuthCode, error)
+}
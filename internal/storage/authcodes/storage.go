@@ -0,0 +1,76 @@
+package authcodes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func key(codeHash string) string {
+	return "auth_code:" + codeHash
+}
+
+func (s *storage) Create(ctx context.Context, code *AuthCode) error {
+	const op = "storage.authcodes.Create"
+	log := s.log.With(logger.String("op", op))
+
+	value, err := json.Marshal(code)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	ttl := time.Until(code.ExpiresAt)
+	err = s.rd.Set(ctx, key(code.CodeHash), value, ttl).Err()
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Take returns the code and removes it, so every code can be exchanged only
+// once.
+func (s *storage) Take(
+	ctx context.Context, codeHash string) (*AuthCode, error) {
+	const op = "storage.authcodes.Take"
+	log := s.log.With(logger.String("op", op))
+
+	value, err := s.rd.GetDel(ctx, key(codeHash)).Bytes()
+	if err != nil && errors.Is(err, redis.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	code := new(AuthCode)
+	err = json.Unmarshal(value, code)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return code, nil
+}
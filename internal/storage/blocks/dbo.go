@@ -0,0 +1,21 @@
+package blocks
+
+import (
+	"time"
+
+	"cloud-notes/internal/storage/users"
+
+	"github.com/google/uuid"
+)
+
+// Block keeps a user blocked until ExpiresAt, or for good without it.
+// PreviousStatus is what the user gets back when the block is lifted, so an
+// unverified user doesn't become active by being blocked.
+type Block struct {
+	UserID         uuid.UUID
+	Reason         string
+	BlockedBy      *uuid.UUID
+	ExpiresAt      *time.Time
+	CreatedAt      time.Time
+	PreviousStatus users.UserStatus
+}
@@ -0,0 +1,15 @@
+package blocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Storage interface {
+	Create(ctx context.Context, block *Block) error
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*Block, error)
+	Lift(ctx context.Context,
+		userID uuid.UUID, expiredBy *time.Time) (bool, error)
+}
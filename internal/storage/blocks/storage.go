@@ -0,0 +1,113 @@
+package blocks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) scan(ctx context.Context, row postgres.Row) (*Block, error) {
+	const op = "storage.blocks.scan"
+	log := s.log.With(logger.String("op", op))
+
+	block := new(Block)
+	err := row.Scan(&block.UserID, &block.Reason,
+		&block.BlockedBy, &block.ExpiresAt, &block.CreatedAt,
+		&block.PreviousStatus)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return block, nil
+}
+
+// Create stores the block replacing the previous one, so blocking an
+// already blocked user updates the reason and the expiry. The status from
+// before the first block is kept.
+func (s *storage) Create(ctx context.Context, block *Block) error {
+	const op = "storage.blocks.Create"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `INSERT INTO user_blocks (user_id, reason, blocked_by, 
+                 expires_at, created_at, previous_status) 
+                 VALUES ($1, $2, $3, $4, $5, $6) 
+                 ON CONFLICT (user_id) DO UPDATE SET reason = $2, 
+                 blocked_by = $3, expires_at = $4, created_at = $5`
+
+	_, err := s.pg.Exec(ctx, sql, block.UserID, block.Reason,
+		block.BlockedBy, block.ExpiresAt, block.CreatedAt,
+		block.PreviousStatus)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) (*Block, error) {
+	const op = "storage.blocks.GetByUserID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM user_blocks WHERE user_id = $1`
+
+	row := s.pg.QueryRow(ctx, sql, userID)
+
+	block, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return block, nil
+}
+
+// Lift deletes the block and gives the user back the status they had
+// before it, in one statement, so concurrent requests lift it once. With
+// expiredBy only a block that has expired by then is lifted. It tells
+// whether the block was lifted.
+func (s *storage) Lift(ctx context.Context,
+	userID uuid.UUID, expiredBy *time.Time) (bool, error) {
+	const op = "storage.blocks.Lift"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `WITH lifted AS (DELETE FROM user_blocks WHERE user_id = $1 
+                 AND ($2::TIMESTAMPTZ IS NULL OR expires_at <= $2) 
+                 RETURNING user_id, previous_status) 
+                 UPDATE users SET status = lifted.previous_status 
+                 FROM lifted WHERE users.id = lifted.user_id 
+                 AND users.status = 'blocked'`
+
+	command, err := s.pg.Exec(ctx, sql, userID, expiredBy)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return command.RowsAffected() == 1, nil
+}
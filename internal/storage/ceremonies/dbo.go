@@ -0,0 +1,16 @@
+package ceremonies
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Ceremony keeps the server side state of a WebAuthn registration or login,
+// or of an OIDC login, between the begin and finish requests.
+type Ceremony struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    *uuid.UUID `json:"user_id"`
+	Data      []byte     `json:"data"`
+	ExpiresAt time.Time  `json:"expires_at"`
+}
@@ -0,0 +1,12 @@
+package ceremonies
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Storage interface {
+	Create(ctx context.Context, ceremony *Ceremony) error
+	Take(ctx context.Context, id uuid.UUID) (*Ceremony, error)
+}
@@ -0,0 +1,77 @@
+package ceremonies
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func key(id uuid.UUID) string {
+	return "ceremony:" + id.String()
+}
+
+func (s *storage) Create(ctx context.Context, ceremony *Ceremony) error {
+	const op = "storage.ceremonies.Create"
+	log := s.log.With(logger.String("op", op))
+
+	value, err := json.Marshal(ceremony)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	ttl := time.Until(ceremony.ExpiresAt)
+	err = s.rd.Set(ctx, key(ceremony.ID), value, ttl).Err()
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Take returns the ceremony and removes it, so every challenge can be
+// answered only once.
+func (s *storage) Take(ctx context.Context, id uuid.UUID) (*Ceremony, error) {
+	const op = "storage.ceremonies.Take"
+	log := s.log.With(logger.String("op", op))
+
+	value, err := s.rd.GetDel(ctx, key(id)).Bytes()
+	if err != nil && errors.Is(err, redis.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ceremony := new(Ceremony)
+	err = json.Unmarshal(value, ceremony)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ceremony, nil
+}
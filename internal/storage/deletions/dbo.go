@@ -0,0 +1,13 @@
+package deletions
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Deletion struct {
+	UserID    uuid.UUID
+	PurgeAt   time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,16 @@
+package deletions
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Storage interface {
+	Create(ctx context.Context, deletion *Deletion) error
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*Deletion, error)
+	GetDue(ctx context.Context, now time.Time,
+		limit uint64) ([]*Deletion, error)
+	Delete(ctx context.Context, userID uuid.UUID) error
+}
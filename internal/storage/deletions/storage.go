@@ -0,0 +1,124 @@
+package deletions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) scan(
+	ctx context.Context, row postgres.Row) (*Deletion, error) {
+	const op = "storage.deletions.scan"
+	log := s.log.With(logger.String("op", op))
+
+	deletion := new(Deletion)
+	err := row.Scan(&deletion.UserID, &deletion.PurgeAt, &deletion.CreatedAt)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deletion, nil
+}
+
+func (s *storage) Create(ctx context.Context, deletion *Deletion) error {
+	const op = "storage.deletions.Create"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `INSERT INTO user_deletions (user_id, purge_at, 
+                 created_at) VALUES ($1, $2, $3)`
+
+	_, err := s.pg.Exec(ctx, sql,
+		deletion.UserID, deletion.PurgeAt, deletion.CreatedAt)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) (*Deletion, error) {
+	const op = "storage.deletions.GetByUserID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM user_deletions WHERE user_id = $1`
+
+	row := s.pg.QueryRow(ctx, sql, userID)
+
+	deletion, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deletion, nil
+}
+
+// GetDue returns deletions whose grace period is over, oldest first.
+func (s *storage) GetDue(ctx context.Context, now time.Time,
+	limit uint64) ([]*Deletion, error) {
+	const op = "storage.deletions.GetDue"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM user_deletions WHERE purge_at <= $1 
+                 ORDER BY purge_at LIMIT $2`
+
+	rows, err := s.pg.Query(ctx, sql, now, limit)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	deletions := make([]*Deletion, 0)
+	for rows.Next() {
+		deletion, err := s.scan(ctx, rows)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		deletions = append(deletions, deletion)
+	}
+
+	return deletions, nil
+}
+
+func (s *storage) Delete(ctx context.Context, userID uuid.UUID) error {
+	const op = "storage.deletions.Delete"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `DELETE FROM user_deletions WHERE user_id = $1`
+
+	_, err := s.pg.Exec(ctx, sql, userID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
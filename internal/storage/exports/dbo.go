@@ -0,0 +1,25 @@
+package exports
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ExportStatus string
+
+const (
+	StatusPending ExportStatus = "pending"
+	StatusReady   ExportStatus = "ready"
+	StatusFailed  ExportStatus = "failed"
+)
+
+type Export struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Status      ExportStatus
+	Archive     []byte
+	CompletedAt *time.Time
+	ExpiresAt   *time.Time
+	CreatedAt   time.Time
+}
@@ -0,0 +1,16 @@
+package exports
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Storage interface {
+	Create(ctx context.Context, export *Export) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Export, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*Export, error)
+	Update(ctx context.Context, export *Export) error
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
@@ -0,0 +1,144 @@
+package exports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) scan(ctx context.Context, row postgres.Row) (*Export, error) {
+	const op = "storage.exports.scan"
+	log := s.log.With(logger.String("op", op))
+
+	export := new(Export)
+	err := row.Scan(&export.ID, &export.UserID, &export.Status,
+		&export.Archive, &export.CompletedAt, &export.ExpiresAt,
+		&export.CreatedAt)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return export, nil
+}
+
+func (s *storage) Create(ctx context.Context, export *Export) error {
+	const op = "storage.exports.Create"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `INSERT INTO exports (id, user_id, status, archive, 
+                 completed_at, expires_at, created_at) 
+                 VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := s.pg.Exec(ctx, sql, export.ID, export.UserID,
+		export.Status, export.Archive, export.CompletedAt,
+		export.ExpiresAt, export.CreatedAt)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) GetByID(ctx context.Context, id uuid.UUID) (*Export, error) {
+	const op = "storage.exports.GetByID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM exports WHERE id = $1`
+
+	row := s.pg.QueryRow(ctx, sql, id)
+
+	export, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return export, nil
+}
+
+func (s *storage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) ([]*Export, error) {
+	const op = "storage.exports.GetByUserID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM exports WHERE user_id = $1 
+                 ORDER BY created_at DESC`
+
+	rows, err := s.pg.Query(ctx, sql, userID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	exports := make([]*Export, 0)
+	for rows.Next() {
+		export, err := s.scan(ctx, rows)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		exports = append(exports, export)
+	}
+
+	return exports, nil
+}
+
+func (s *storage) Update(ctx context.Context, export *Export) error {
+	const op = "storage.exports.Update"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `UPDATE exports SET user_id = $1, status = $2, 
+                 archive = $3, completed_at = $4, expires_at = $5, 
+                 created_at = $6 WHERE id = $7`
+
+	_, err := s.pg.Exec(ctx, sql, export.UserID, export.Status,
+		export.Archive, export.CompletedAt, export.ExpiresAt,
+		export.CreatedAt, export.ID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) DeleteExpired(ctx context.Context, now time.Time) error {
+	const op = "storage.exports.DeleteExpired"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `DELETE FROM exports WHERE expires_at <= $1`
+
+	_, err := s.pg.Exec(ctx, sql, now)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,16 @@
+package identities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Identity struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Provider  string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+}
@@ -0,0 +1,14 @@
+package identities
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Storage interface {
+	Create(ctx context.Context, identity *Identity) error
+	GetBySubject(
+		ctx context.Context, provider, subject string) (*Identity, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*Identity, error)
+}
@@ -0,0 +1,109 @@
+package identities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) scan(
+	ctx context.Context, row postgres.Row) (*Identity, error) {
+	const op = "storage.identities.scan"
+	log := s.log.With(logger.String("op", op))
+
+	identity := new(Identity)
+	err := row.Scan(&identity.ID, &identity.UserID, &identity.Provider,
+		&identity.Subject, &identity.Email, &identity.CreatedAt)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return identity, nil
+}
+
+func (s *storage) Create(ctx context.Context, identity *Identity) error {
+	const op = "storage.identities.Create"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `INSERT INTO identities (id, user_id, provider, subject, 
+                 email, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := s.pg.Exec(ctx, sql, identity.ID, identity.UserID,
+		identity.Provider, identity.Subject, identity.Email,
+		identity.CreatedAt)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) GetBySubject(
+	ctx context.Context, provider, subject string) (*Identity, error) {
+	const op = "storage.identities.GetBySubject"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM identities 
+                 WHERE provider = $1 AND subject = $2`
+
+	row := s.pg.QueryRow(ctx, sql, provider, subject)
+
+	identity, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return identity, nil
+}
+
+func (s *storage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) ([]*Identity, error) {
+	const op = "storage.identities.GetByUserID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM identities WHERE user_id = $1`
+
+	rows, err := s.pg.Query(ctx, sql, userID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	identities := make([]*Identity, 0)
+	for rows.Next() {
+		identity, err := s.scan(ctx, rows)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		identities = append(identities, identity)
+	}
+
+	return identities, nil
+}
@@ -1,9 +1,38 @@
 package storage
 
 import (
+	"context"
+
+	"cloud-notes/internal/storage/authcodes"
+	"cloud-notes/internal/storage/blocks"
+	"cloud-notes/internal/storage/ceremonies"
+	"cloud-notes/internal/storage/deletions"
+	"cloud-notes/internal/storage/exports"
+	"cloud-notes/internal/storage/identities"
+	"cloud-notes/internal/storage/jobs"
 	"cloud-notes/internal/storage/notes"
+	"cloud-notes/internal/storage/oauthclients"
+	"cloud-notes/internal/storage/outbox"
+	"cloud-notes/internal/storage/passkeys"
+	"cloud-notes/internal/storage/passwordhistory"
+	"cloud-notes/internal/storage/passwordresets"
+	"cloud-notes/internal/storage/personaltokens"
+	"cloud-notes/internal/storage/plans"
+	"cloud-notes/internal/storage/quotas"
+	"cloud-notes/internal/storage/ratelimits"
+	"cloud-notes/internal/storage/recoverycodes"
+	"cloud-notes/internal/storage/roles"
+	"cloud-notes/internal/storage/securityevents"
 	"cloud-notes/internal/storage/sessions"
+	"cloud-notes/internal/storage/stream"
+	"cloud-notes/internal/storage/twofactor"
+	"cloud-notes/internal/storage/usage"
 	"cloud-notes/internal/storage/users"
+	"cloud-notes/internal/storage/verifications"
+	"cloud-notes/internal/storage/webhookdeliveries"
+	"cloud-notes/internal/storage/webhooks"
+	"cloud-notes/internal/storage/workspacemembers"
+	"cloud-notes/internal/storage/workspaces"
 )
 
 const (
@@ -13,12 +42,107 @@ const (
 	UserStatusDeleted = users.StatusDeleted
 )
 
+const (
+	UserRoleUser    = users.RoleUser
+	UserRoleSupport = users.RoleSupport
+	UserRoleAdmin   = users.RoleAdmin
+)
+
+const (
+	WorkspaceRoleOwner  = workspacemembers.RoleOwner
+	WorkspaceRoleEditor = workspacemembers.RoleEditor
+	WorkspaceRoleViewer = workspacemembers.RoleViewer
+)
+
+const (
+	WebhookDeliveryStatusPending   = webhookdeliveries.StatusPending
+	WebhookDeliveryStatusSucceeded = webhookdeliveries.StatusSucceeded
+	WebhookDeliveryStatusFailed    = webhookdeliveries.StatusFailed
+)
+
+const (
+	JobStatusPending   = jobs.StatusPending
+	JobStatusSucceeded = jobs.StatusSucceeded
+	JobStatusFailed    = jobs.StatusFailed
+)
+
+const (
+	ExportStatusPending = exports.StatusPending
+	ExportStatusReady   = exports.StatusReady
+	ExportStatusFailed  = exports.StatusFailed
+)
+
+type AuthCode = authcodes.AuthCode
+type Block = blocks.Block
+type Ceremony = ceremonies.Ceremony
+type Deletion = deletions.Deletion
+type Export = exports.Export
+type Identity = identities.Identity
+type Job = jobs.Job
+type JobFilter = jobs.Filter
+type JobStatus = jobs.Status
 type Note = notes.Note
+type NoteFilter = notes.Filter
+type OAuthClient = oauthclients.Client
+type OutboxEvent = outbox.Event
+type Passkey = passkeys.Passkey
+type PasswordHistoryEntry = passwordhistory.Entry
+type PasswordReset = passwordresets.PasswordReset
+type PersonalToken = personaltokens.PersonalToken
+type Plan = plans.Plan
+type Quota = quotas.Quota
+type RateLimitBudget = ratelimits.Budget
+type RecoveryCode = recoverycodes.RecoveryCode
+type Role = roles.Role
+type SecurityEvent = securityevents.Event
+type SecurityEventFilter = securityevents.Filter
 type Session = sessions.Session
+type StreamMessage = stream.Message
+type TwoFactor = twofactor.TwoFactor
+type Usage = usage.Usage
 type User = users.User
+type UserFilter = users.Filter
+type UserRole = users.UserRole
+type UserStatus = users.UserStatus
+type Verification = verifications.Verification
+type Webhook = webhooks.Webhook
+type WebhookDelivery = webhookdeliveries.Delivery
+type WebhookDeliveryStatus = webhookdeliveries.Status
+type Workspace = workspaces.Workspace
+type WorkspaceMember = workspacemembers.Member
+type WorkspaceRole = workspacemembers.Role
 
 type Storage interface {
+	AuthCodes() authcodes.Storage
+	Blocks() blocks.Storage
+	Ceremonies() ceremonies.Storage
+	Deletions() deletions.Storage
+	Exports() exports.Storage
+	Identities() identities.Storage
+	Jobs() jobs.Storage
 	Notes() notes.Storage
+	OAuthClients() oauthclients.Storage
+	Outbox() outbox.Storage
+	Passkeys() passkeys.Storage
+	PasswordHistory() passwordhistory.Storage
+	PasswordResets() passwordresets.Storage
+	PersonalTokens() personaltokens.Storage
+	Plans() plans.Storage
+	Quotas() quotas.Storage
+	RateLimits() ratelimits.Storage
+	RecoveryCodes() recoverycodes.Storage
+	Roles() roles.Storage
+	SecurityEvents() securityevents.Storage
 	Sessions() sessions.Storage
+	Stream() stream.Storage
+	TwoFactor() twofactor.Storage
+	Usage() usage.Storage
 	Users() users.Storage
+	Verifications() verifications.Storage
+	WebhookDeliveries() webhookdeliveries.Storage
+	Webhooks() webhooks.Storage
+	WorkspaceMembers() workspacemembers.Storage
+	Workspaces() workspaces.Storage
+	WithTx(ctx context.Context,
+		fn func(st Storage) error, opts ...TxOption) error
 }
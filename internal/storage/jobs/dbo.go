@@ -0,0 +1,39 @@
+package jobs
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Job is a unit of background work. Pending jobs run at RunAt, Error
+// describes the last failed attempt. A failed job has used up its attempts
+// and waits in the dead-letter list until it is retried by hand.
+// UniqueKey keeps a job from being enqueued twice.
+type Job struct {
+	ID         uuid.UUID
+	Type       string
+	Payload    json.RawMessage
+	Status     Status
+	Attempts   int
+	RunAt      time.Time
+	Error      *string
+	UniqueKey  *string
+	FinishedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// Filter narrows a job listing, empty fields match any job.
+type Filter struct {
+	Status Status
+	Type   string
+}
@@ -0,0 +1,19 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Storage interface {
+	Create(ctx context.Context, job *Job) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Job, error)
+	List(ctx context.Context,
+		filter *Filter, limit, offset uint64) ([]*Job, error)
+	Claim(ctx context.Context, now, leaseUntil time.Time,
+		limit uint64) ([]*Job, error)
+	Update(ctx context.Context, job *Job, lease time.Time) (bool, error)
+	DeleteSucceeded(ctx context.Context, before time.Time) error
+}
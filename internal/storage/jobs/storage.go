@@ -0,0 +1,193 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) scan(ctx context.Context, row postgres.Row) (*Job, error) {
+	const op = "storage.jobs.scan"
+	log := s.log.With(logger.String("op", op))
+
+	job := new(Job)
+	err := row.Scan(&job.ID, &job.Type, &job.Payload, &job.Status,
+		&job.Attempts, &job.RunAt, &job.Error, &job.UniqueKey,
+		&job.FinishedAt, &job.CreatedAt)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, nil
+}
+
+// Create skips a job whose unique key is already taken, so several workers
+// scheduling the same periodic job enqueue it only once.
+func (s *storage) Create(ctx context.Context, job *Job) error {
+	const op = "storage.jobs.Create"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `INSERT INTO jobs (id, type, payload, status, attempts, 
+                 run_at, error, unique_key, finished_at, created_at) 
+                 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) 
+                 ON CONFLICT (unique_key) WHERE unique_key IS NOT NULL 
+                 DO NOTHING`
+
+	_, err := s.pg.Exec(ctx, sql, job.ID, job.Type, job.Payload, job.Status,
+		job.Attempts, job.RunAt, job.Error, job.UniqueKey, job.FinishedAt,
+		job.CreatedAt)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) GetByID(ctx context.Context, id uuid.UUID) (*Job, error) {
+	const op = "storage.jobs.GetByID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM jobs WHERE id = $1`
+
+	row := s.pg.QueryRow(ctx, sql, id)
+
+	job, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, nil
+}
+
+// List returns the newest jobs first.
+func (s *storage) List(ctx context.Context,
+	filter *Filter, limit, offset uint64) ([]*Job, error) {
+	const op = "storage.jobs.List"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM jobs WHERE ($1 = '' OR status = $1) 
+                 AND ($2 = '' OR type = $2) 
+                 ORDER BY created_at DESC LIMIT $3 OFFSET $4`
+
+	rows, err := s.pg.Query(ctx, sql,
+		string(filter.Status), filter.Type, limit, offset)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	jobs := make([]*Job, 0)
+	for rows.Next() {
+		job, err := s.scan(ctx, rows)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// Claim takes the pending jobs due by now and leases them until
+// leaseUntil, so other workers skip them meanwhile. A job whose worker
+// died is claimed again once the lease is over. Every claim counts as an
+// attempt, so such a job can't be retried forever.
+func (s *storage) Claim(ctx context.Context, now, leaseUntil time.Time,
+	limit uint64) ([]*Job, error) {
+	const op = "storage.jobs.Claim"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `UPDATE jobs SET run_at = $2, attempts = attempts + 1 
+                 WHERE id IN (SELECT id FROM jobs 
+                 WHERE status = 'pending' AND run_at <= $1 
+                 ORDER BY run_at LIMIT $3 FOR UPDATE SKIP LOCKED) 
+                 RETURNING *`
+
+	rows, err := s.pg.Query(ctx, sql, now, leaseUntil, limit)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	jobs := make([]*Job, 0)
+	for rows.Next() {
+		job, err := s.scan(ctx, rows)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// Update saves the job unless its run time is no longer lease, the one
+// it had when it was read. A worker whose lease ran out so that another
+// one claimed the job meanwhile doesn't overwrite that worker's outcome.
+// It tells whether the job was saved.
+func (s *storage) Update(
+	ctx context.Context, job *Job, lease time.Time) (bool, error) {
+	const op = "storage.jobs.Update"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `UPDATE jobs SET status = $1, attempts = $2, run_at = $3, 
+                 error = $4, finished_at = $5 WHERE id = $6 AND run_at = $7`
+
+	command, err := s.pg.Exec(ctx, sql, job.Status, job.Attempts, job.RunAt,
+		job.Error, job.FinishedAt, job.ID, lease)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return command.RowsAffected() == 1, nil
+}
+
+// DeleteSucceeded trims finished jobs. Failed jobs are kept until they are
+// retried, so nothing leaves the dead-letter list unnoticed.
+func (s *storage) DeleteSucceeded(
+	ctx context.Context, before time.Time) error {
+	const op = "storage.jobs.DeleteSucceeded"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `DELETE FROM jobs WHERE status = 'succeeded' 
+                 AND finished_at < $1`
+
+	_, err := s.pg.Exec(ctx, sql, before)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
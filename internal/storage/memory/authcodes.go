@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"cloud-notes/internal/storage/authcodes"
+)
+
+// The codes are kept in Redis and expire there, so they reference nothing
+// and outlive their users until they expire.
+type authCodesStorage struct {
+	s *Storage
+}
+
+func copyAuthCode(code *authcodes.AuthCode) *authcodes.AuthCode {
+	c := *code
+	c.Scopes = slices.Clone(code.Scopes)
+	return &c
+}
+
+func (s *authCodesStorage) Create(
+	ctx context.Context, code *authcodes.AuthCode) error {
+	return s.s.write(func(t *tables) error {
+		t.authCodes[code.CodeHash] = copyAuthCode(code)
+		return nil
+	})
+}
+
+// Take returns the code and removes it, so every code can be exchanged only
+// once.
+func (s *authCodesStorage) Take(
+	ctx context.Context, codeHash string) (*authcodes.AuthCode, error) {
+	var found *authcodes.AuthCode
+	_ = s.s.write(func(t *tables) error {
+		code, ok := t.authCodes[codeHash]
+		if !ok {
+			return nil
+		}
+
+		delete(t.authCodes, codeHash)
+		if code.ExpiresAt.After(time.Now()) {
+			found = copyAuthCode(code)
+		}
+		return nil
+	})
+
+	return found, nil
+}
@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/storage/blocks"
+	"cloud-notes/internal/storage/users"
+
+	"github.com/google/uuid"
+)
+
+type blocksStorage struct {
+	s *Storage
+}
+
+func copyBlock(block *blocks.Block) *blocks.Block {
+	c := *block
+	c.BlockedBy = ptr(block.BlockedBy)
+	c.ExpiresAt = timestampPtr(block.ExpiresAt)
+	c.CreatedAt = timestamp(block.CreatedAt)
+	return &c
+}
+
+// Create stores the block replacing the previous one, so blocking an
+// already blocked user updates the reason and the expiry. The status from
+// before the first block is kept.
+func (s *blocksStorage) Create(ctx context.Context, block *blocks.Block) error {
+	const op = "storage.memory.blocks.Create"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.users[block.UserID]; !ok {
+			return fmt.Errorf("%s: %w", op, ErrForeignKey)
+		}
+
+		if block.BlockedBy != nil {
+			if _, ok := t.users[*block.BlockedBy]; !ok {
+				return fmt.Errorf("%s: %w", op, ErrForeignKey)
+			}
+		}
+
+		c := copyBlock(block)
+		if previous, ok := t.blocks[block.UserID]; ok {
+			c.PreviousStatus = previous.PreviousStatus
+		}
+
+		t.blocks[block.UserID] = c
+		return nil
+	})
+}
+
+func (s *blocksStorage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) (*blocks.Block, error) {
+	var found *blocks.Block
+	s.s.read(func(t *tables) {
+		if block, ok := t.blocks[userID]; ok {
+			found = copyBlock(block)
+		}
+	})
+
+	return found, nil
+}
+
+// Lift removes the block and restores the user's status from before it.
+// With expiredBy only a block that expired by then is lifted.
+func (s *blocksStorage) Lift(ctx context.Context,
+	userID uuid.UUID, expiredBy *time.Time) (bool, error) {
+	var lifted bool
+	_ = s.s.write(func(t *tables) error {
+		block, ok := t.blocks[userID]
+		if !ok {
+			return nil
+		}
+
+		if expiredBy != nil && (block.ExpiresAt == nil ||
+			block.ExpiresAt.After(timestamp(*expiredBy))) {
+			return nil
+		}
+
+		delete(t.blocks, userID)
+
+		user, ok := t.users[userID]
+		if !ok || user.Status != users.StatusBlocked {
+			return nil
+		}
+
+		c := copyUser(user)
+		c.Status = block.PreviousStatus
+		t.users[userID] = c
+		lifted = true
+		return nil
+	})
+
+	return lifted, nil
+}
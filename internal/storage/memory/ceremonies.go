@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"cloud-notes/internal/storage/ceremonies"
+
+	"github.com/google/uuid"
+)
+
+// The ceremonies are kept in Redis and expire there.
+type ceremoniesStorage struct {
+	s *Storage
+}
+
+func copyCeremony(ceremony *ceremonies.Ceremony) *ceremonies.Ceremony {
+	c := *ceremony
+	c.UserID = ptr(ceremony.UserID)
+	c.Data = bytes.Clone(ceremony.Data)
+	return &c
+}
+
+func (s *ceremoniesStorage) Create(
+	ctx context.Context, ceremony *ceremonies.Ceremony) error {
+	return s.s.write(func(t *tables) error {
+		t.ceremonies[ceremony.ID] = copyCeremony(ceremony)
+		return nil
+	})
+}
+
+// Take returns the ceremony and removes it, so every challenge can be
+// answered only once.
+func (s *ceremoniesStorage) Take(
+	ctx context.Context, id uuid.UUID) (*ceremonies.Ceremony, error) {
+	var found *ceremonies.Ceremony
+	_ = s.s.write(func(t *tables) error {
+		ceremony, ok := t.ceremonies[id]
+		if !ok {
+			return nil
+		}
+
+		delete(t.ceremonies, id)
+		if ceremony.ExpiresAt.After(time.Now()) {
+			found = copyCeremony(ceremony)
+		}
+		return nil
+	})
+
+	return found, nil
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"cloud-notes/internal/storage/deletions"
+
+	"github.com/google/uuid"
+)
+
+type deletionsStorage struct {
+	s *Storage
+}
+
+func copyDeletion(deletion *deletions.Deletion) *deletions.Deletion {
+	c := *deletion
+	c.PurgeAt = timestamp(deletion.PurgeAt)
+	c.CreatedAt = timestamp(deletion.CreatedAt)
+	return &c
+}
+
+func (s *deletionsStorage) Create(
+	ctx context.Context, deletion *deletions.Deletion) error {
+	const op = "storage.memory.deletions.Create"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.deletions[deletion.UserID]; ok {
+			return fmt.Errorf("%s: %w", op, ErrDuplicate)
+		}
+
+		if _, ok := t.users[deletion.UserID]; !ok {
+			return fmt.Errorf("%s: %w", op, ErrForeignKey)
+		}
+
+		t.deletions[deletion.UserID] = copyDeletion(deletion)
+		return nil
+	})
+}
+
+func (s *deletionsStorage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) (*deletions.Deletion, error) {
+	var found *deletions.Deletion
+	s.s.read(func(t *tables) {
+		if deletion, ok := t.deletions[userID]; ok {
+			found = copyDeletion(deletion)
+		}
+	})
+
+	return found, nil
+}
+
+func (s *deletionsStorage) GetDue(ctx context.Context,
+	now time.Time, limit uint64) ([]*deletions.Deletion, error) {
+	found := make([]*deletions.Deletion, 0)
+	s.s.read(func(t *tables) {
+		for _, deletion := range t.deletions {
+			if !deletion.PurgeAt.After(timestamp(now)) {
+				found = append(found, copyDeletion(deletion))
+			}
+		}
+	})
+
+	slices.SortFunc(found, func(a, b *deletions.Deletion) int {
+		return a.PurgeAt.Compare(b.PurgeAt)
+	})
+
+	return found[:min(limit, uint64(len(found)))], nil
+}
+
+func (s *deletionsStorage) Delete(ctx context.Context, userID uuid.UUID) error {
+	return s.s.write(func(t *tables) error {
+		delete(t.deletions, userID)
+		return nil
+	})
+}
@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"cloud-notes/internal/storage/exports"
+
+	"github.com/google/uuid"
+)
+
+type exportsStorage struct {
+	s *Storage
+}
+
+func copyExport(export *exports.Export) *exports.Export {
+	c := *export
+	c.Archive = bytes.Clone(export.Archive)
+	c.CompletedAt = timestampPtr(export.CompletedAt)
+	c.ExpiresAt = timestampPtr(export.ExpiresAt)
+	c.CreatedAt = timestamp(export.CreatedAt)
+	return &c
+}
+
+func (s *exportsStorage) Create(
+	ctx context.Context, export *exports.Export) error {
+	const op = "storage.memory.exports.Create"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.exports[export.ID]; ok {
+			return fmt.Errorf("%s: %w", op, ErrDuplicate)
+		}
+
+		if _, ok := t.users[export.UserID]; !ok {
+			return fmt.Errorf("%s: %w", op, ErrForeignKey)
+		}
+
+		t.exports[export.ID] = copyExport(export)
+		return nil
+	})
+}
+
+func (s *exportsStorage) GetByID(
+	ctx context.Context, id uuid.UUID) (*exports.Export, error) {
+	var found *exports.Export
+	s.s.read(func(t *tables) {
+		if export, ok := t.exports[id]; ok {
+			found = copyExport(export)
+		}
+	})
+
+	return found, nil
+}
+
+func (s *exportsStorage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) ([]*exports.Export, error) {
+	found := make([]*exports.Export, 0)
+	s.s.read(func(t *tables) {
+		for _, export := range t.exports {
+			if export.UserID == userID {
+				found = append(found, copyExport(export))
+			}
+		}
+	})
+
+	slices.SortFunc(found, func(a, b *exports.Export) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	return found, nil
+}
+
+func (s *exportsStorage) Update(
+	ctx context.Context, export *exports.Export) error {
+	const op = "storage.memory.exports.Update"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.exports[export.ID]; !ok {
+			return nil
+		}
+
+		if _, ok := t.users[export.UserID]; !ok {
+			return fmt.Errorf("%s: %w", op, ErrForeignKey)
+		}
+
+		t.exports[export.ID] = copyExport(export)
+		return nil
+	})
+}
+
+func (s *exportsStorage) DeleteExpired(
+	ctx context.Context, now time.Time) error {
+	return s.s.write(func(t *tables) error {
+		for id, export := range t.exports {
+			if export.ExpiresAt != nil &&
+				!export.ExpiresAt.After(timestamp(now)) {
+				delete(t.exports, id)
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"cloud-notes/internal/storage/identities"
+
+	"github.com/google/uuid"
+)
+
+type identitiesStorage struct {
+	s *Storage
+}
+
+func copyIdentity(identity *identities.Identity) *identities.Identity {
+	c := *identity
+	c.CreatedAt = timestamp(identity.CreatedAt)
+	return &c
+}
+
+func (s *identitiesStorage) Create(
+	ctx context.Context, identity *identities.Identity) error {
+	const op = "storage.memory.identities.Create"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.identities[identity.ID]; ok {
+			return fmt.Errorf("%s: %w", op, ErrDuplicate)
+		}
+
+		for _, other := range t.identities {
+			if other.Provider == identity.Provider &&
+				other.Subject == identity.Subject {
+				return fmt.Errorf("%s: %w", op, ErrDuplicate)
+			}
+		}
+
+		if _, ok := t.users[identity.UserID]; !ok {
+			return fmt.Errorf("%s: %w", op, ErrForeignKey)
+		}
+
+		t.identities[identity.ID] = copyIdentity(identity)
+		return nil
+	})
+}
+
+func (s *identitiesStorage) GetBySubject(ctx context.Context,
+	provider, subject string) (*identities.Identity, error) {
+	var found *identities.Identity
+	s.s.read(func(t *tables) {
+		for _, identity := range t.identities {
+			if identity.Provider == provider && identity.Subject == subject {
+				found = copyIdentity(identity)
+			}
+		}
+	})
+
+	return found, nil
+}
+
+func (s *identitiesStorage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) ([]*identities.Identity, error) {
+	found := make([]*identities.Identity, 0)
+	s.s.read(func(t *tables) {
+		for _, identity := range t.identities {
+			if identity.UserID == userID {
+				found = append(found, copyIdentity(identity))
+			}
+		}
+	})
+
+	return found, nil
+}
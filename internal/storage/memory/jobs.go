@@ -0,0 +1,155 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"cloud-notes/internal/storage/jobs"
+
+	"github.com/google/uuid"
+)
+
+type jobsStorage struct {
+	s *Storage
+}
+
+func copyJob(job *jobs.Job) *jobs.Job {
+	c := *job
+	c.Payload = bytes.Clone(job.Payload)
+	c.RunAt = timestamp(job.RunAt)
+	c.Error = ptr(job.Error)
+	c.UniqueKey = ptr(job.UniqueKey)
+	c.FinishedAt = timestampPtr(job.FinishedAt)
+	c.CreatedAt = timestamp(job.CreatedAt)
+	return &c
+}
+
+// Create skips the job when another one has the same unique key, like the
+// unique index does.
+func (s *jobsStorage) Create(ctx context.Context, job *jobs.Job) error {
+	const op = "storage.memory.jobs.Create"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.jobs[job.ID]; ok {
+			return fmt.Errorf("%s: %w", op, ErrDuplicate)
+		}
+
+		if job.UniqueKey != nil {
+			for _, other := range t.jobs {
+				if other.UniqueKey != nil &&
+					*other.UniqueKey == *job.UniqueKey {
+					return nil
+				}
+			}
+		}
+
+		t.jobs[job.ID] = copyJob(job)
+		return nil
+	})
+}
+
+func (s *jobsStorage) GetByID(
+	ctx context.Context, id uuid.UUID) (*jobs.Job, error) {
+	var found *jobs.Job
+	s.s.read(func(t *tables) {
+		if job, ok := t.jobs[id]; ok {
+			found = copyJob(job)
+		}
+	})
+
+	return found, nil
+}
+
+func (s *jobsStorage) List(ctx context.Context,
+	filter *jobs.Filter, limit, offset uint64) ([]*jobs.Job, error) {
+	found := make([]*jobs.Job, 0)
+	s.s.read(func(t *tables) {
+		for _, job := range t.jobs {
+			if (filter.Status == "" || job.Status == filter.Status) &&
+				(filter.Type == "" || job.Type == filter.Type) {
+				found = append(found, copyJob(job))
+			}
+		}
+	})
+
+	slices.SortFunc(found, func(a, b *jobs.Job) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	found = found[min(offset, uint64(len(found))):]
+	return found[:min(limit, uint64(len(found)))], nil
+}
+
+// Claim leases the due pending jobs until leaseUntil and counts the
+// attempt, like the Postgres storage does.
+func (s *jobsStorage) Claim(ctx context.Context, now, leaseUntil time.Time,
+	limit uint64) ([]*jobs.Job, error) {
+	claimed := make([]*jobs.Job, 0)
+	_ = s.s.write(func(t *tables) error {
+		due := make([]*jobs.Job, 0)
+		for _, job := range t.jobs {
+			if job.Status == jobs.StatusPending &&
+				!job.RunAt.After(timestamp(now)) {
+				due = append(due, job)
+			}
+		}
+
+		slices.SortFunc(due, func(a, b *jobs.Job) int {
+			return a.RunAt.Compare(b.RunAt)
+		})
+
+		for _, job := range due[:min(limit, uint64(len(due)))] {
+			c := copyJob(job)
+			c.RunAt = timestamp(leaseUntil)
+			c.Attempts++
+			t.jobs[job.ID] = c
+			claimed = append(claimed, copyJob(c))
+		}
+
+		return nil
+	})
+
+	return claimed, nil
+}
+
+// Update saves the job unless its run time changed since lease, like the
+// Postgres storage does.
+func (s *jobsStorage) Update(
+	ctx context.Context, job *jobs.Job, lease time.Time) (bool, error) {
+	var updated bool
+	_ = s.s.write(func(t *tables) error {
+		old, ok := t.jobs[job.ID]
+		if !ok || !old.RunAt.Equal(timestamp(lease)) {
+			return nil
+		}
+
+		c := copyJob(old)
+		c.Status = job.Status
+		c.Attempts = job.Attempts
+		c.RunAt = timestamp(job.RunAt)
+		c.Error = ptr(job.Error)
+		c.FinishedAt = timestampPtr(job.FinishedAt)
+		t.jobs[job.ID] = c
+		updated = true
+		return nil
+	})
+
+	return updated, nil
+}
+
+func (s *jobsStorage) DeleteSucceeded(
+	ctx context.Context, before time.Time) error {
+	return s.s.write(func(t *tables) error {
+		for id, job := range t.jobs {
+			if job.Status == jobs.StatusSucceeded && job.FinishedAt != nil &&
+				job.FinishedAt.Before(timestamp(before)) {
+				delete(t.jobs, id)
+			}
+		}
+
+		return nil
+	})
+}
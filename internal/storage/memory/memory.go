@@ -0,0 +1,368 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"cloud-notes/internal/storage"
+	"cloud-notes/internal/storage/authcodes"
+	"cloud-notes/internal/storage/blocks"
+	"cloud-notes/internal/storage/ceremonies"
+	"cloud-notes/internal/storage/deletions"
+	"cloud-notes/internal/storage/exports"
+	"cloud-notes/internal/storage/identities"
+	"cloud-notes/internal/storage/jobs"
+	"cloud-notes/internal/storage/notes"
+	"cloud-notes/internal/storage/oauthclients"
+	"cloud-notes/internal/storage/outbox"
+	"cloud-notes/internal/storage/passkeys"
+	"cloud-notes/internal/storage/passwordhistory"
+	"cloud-notes/internal/storage/passwordresets"
+	"cloud-notes/internal/storage/personaltokens"
+	"cloud-notes/internal/storage/plans"
+	"cloud-notes/internal/storage/quotas"
+	"cloud-notes/internal/storage/ratelimits"
+	"cloud-notes/internal/storage/recoverycodes"
+	"cloud-notes/internal/storage/roles"
+	"cloud-notes/internal/storage/securityevents"
+	"cloud-notes/internal/storage/sessions"
+	"cloud-notes/internal/storage/stream"
+	"cloud-notes/internal/storage/twofactor"
+	"cloud-notes/internal/storage/usage"
+	"cloud-notes/internal/storage/users"
+	"cloud-notes/internal/storage/verifications"
+	"cloud-notes/internal/storage/webhookdeliveries"
+	"cloud-notes/internal/storage/webhooks"
+	"cloud-notes/internal/storage/workspacemembers"
+	"cloud-notes/internal/storage/workspaces"
+
+	"github.com/google/uuid"
+)
+
+// Errors for the constraints Postgres would enforce.
+var (
+	ErrDuplicate  = errors.New("duplicate key")
+	ErrForeignKey = errors.New("foreign key violation")
+)
+
+type tables struct {
+	authCodes         map[string]*authcodes.AuthCode
+	blocks            map[uuid.UUID]*blocks.Block
+	ceremonies        map[uuid.UUID]*ceremonies.Ceremony
+	deletions         map[uuid.UUID]*deletions.Deletion
+	exports           map[uuid.UUID]*exports.Export
+	identities        map[uuid.UUID]*identities.Identity
+	jobs              map[uuid.UUID]*jobs.Job
+	notes             map[uuid.UUID]*notes.Note
+	oauthClients      map[uuid.UUID]*oauthclients.Client
+	outbox            map[uuid.UUID]*outbox.Event
+	passkeys          map[uuid.UUID]*passkeys.Passkey
+	passwordHistory   map[uuid.UUID]*passwordhistory.Entry
+	passwordResets    map[string]*passwordresets.PasswordReset
+	personalTokens    map[uuid.UUID]*personaltokens.PersonalToken
+	plans             map[string]*plans.Plan
+	quotas            map[uuid.UUID]*quotas.Quota
+	recoveryCodes     map[uuid.UUID]*recoverycodes.RecoveryCode
+	roles             map[string]*roles.Role
+	securityEvents    map[uuid.UUID]*securityevents.Event
+	sessions          map[uuid.UUID]*sessions.Session
+	stream            []*stream.Message
+	groups            map[string]*streamGroup
+	twoFactor         map[uuid.UUID]*twofactor.TwoFactor
+	usage             map[uuid.UUID]*usage.Usage
+	users             map[uuid.UUID]*users.User
+	verifications     map[uuid.UUID]*verifications.Verification
+	webhookDeliveries map[uuid.UUID]*webhookdeliveries.Delivery
+	webhooks          map[uuid.UUID]*webhooks.Webhook
+	workspaces        map[uuid.UUID]*workspaces.Workspace
+	members           map[memberKey]*workspacemembers.Member
+	rotated           map[string]rotatedToken
+	buckets           map[string]bucket
+}
+
+func newTables() *tables {
+	return &tables{
+		authCodes:         make(map[string]*authcodes.AuthCode),
+		blocks:            make(map[uuid.UUID]*blocks.Block),
+		ceremonies:        make(map[uuid.UUID]*ceremonies.Ceremony),
+		deletions:         make(map[uuid.UUID]*deletions.Deletion),
+		exports:           make(map[uuid.UUID]*exports.Export),
+		identities:        make(map[uuid.UUID]*identities.Identity),
+		jobs:              make(map[uuid.UUID]*jobs.Job),
+		notes:             make(map[uuid.UUID]*notes.Note),
+		oauthClients:      make(map[uuid.UUID]*oauthclients.Client),
+		outbox:            make(map[uuid.UUID]*outbox.Event),
+		passkeys:          make(map[uuid.UUID]*passkeys.Passkey),
+		passwordHistory:   make(map[uuid.UUID]*passwordhistory.Entry),
+		passwordResets:    make(map[string]*passwordresets.PasswordReset),
+		personalTokens:    make(map[uuid.UUID]*personaltokens.PersonalToken),
+		plans:             make(map[string]*plans.Plan),
+		quotas:            make(map[uuid.UUID]*quotas.Quota),
+		recoveryCodes:     make(map[uuid.UUID]*recoverycodes.RecoveryCode),
+		roles:             make(map[string]*roles.Role),
+		securityEvents:    make(map[uuid.UUID]*securityevents.Event),
+		sessions:          make(map[uuid.UUID]*sessions.Session),
+		groups:            make(map[string]*streamGroup),
+		twoFactor:         make(map[uuid.UUID]*twofactor.TwoFactor),
+		usage:             make(map[uuid.UUID]*usage.Usage),
+		users:             make(map[uuid.UUID]*users.User),
+		verifications:     make(map[uuid.UUID]*verifications.Verification),
+		webhookDeliveries: make(map[uuid.UUID]*webhookdeliveries.Delivery),
+		webhooks:          make(map[uuid.UUID]*webhooks.Webhook),
+		workspaces:        make(map[uuid.UUID]*workspaces.Workspace),
+		members:           make(map[memberKey]*workspacemembers.Member),
+		rotated:           make(map[string]rotatedToken),
+		buckets:           make(map[string]bucket),
+	}
+}
+
+// clone copies the maps. Stored rows are never changed in place, so they
+// can be shared with the copy. The stream is only appended to, the copy
+// gets a slice of its own.
+func (t *tables) clone() *tables {
+	c := newTables()
+	maps.Copy(c.authCodes, t.authCodes)
+	maps.Copy(c.blocks, t.blocks)
+	maps.Copy(c.ceremonies, t.ceremonies)
+	maps.Copy(c.deletions, t.deletions)
+	maps.Copy(c.exports, t.exports)
+	maps.Copy(c.identities, t.identities)
+	maps.Copy(c.jobs, t.jobs)
+	maps.Copy(c.notes, t.notes)
+	maps.Copy(c.oauthClients, t.oauthClients)
+	maps.Copy(c.outbox, t.outbox)
+	maps.Copy(c.passkeys, t.passkeys)
+	maps.Copy(c.passwordHistory, t.passwordHistory)
+	maps.Copy(c.passwordResets, t.passwordResets)
+	maps.Copy(c.personalTokens, t.personalTokens)
+	maps.Copy(c.plans, t.plans)
+	maps.Copy(c.quotas, t.quotas)
+	maps.Copy(c.recoveryCodes, t.recoveryCodes)
+	maps.Copy(c.roles, t.roles)
+	maps.Copy(c.securityEvents, t.securityEvents)
+	maps.Copy(c.sessions, t.sessions)
+	c.stream = slices.Clone(t.stream)
+	maps.Copy(c.groups, t.groups)
+	maps.Copy(c.twoFactor, t.twoFactor)
+	maps.Copy(c.usage, t.usage)
+	maps.Copy(c.users, t.users)
+	maps.Copy(c.verifications, t.verifications)
+	maps.Copy(c.webhookDeliveries, t.webhookDeliveries)
+	maps.Copy(c.webhooks, t.webhooks)
+	maps.Copy(c.workspaces, t.workspaces)
+	maps.Copy(c.members, t.members)
+	maps.Copy(c.rotated, t.rotated)
+	maps.Copy(c.buckets, t.buckets)
+	return c
+}
+
+// Storage keeps everything in memory with the semantics of the Postgres
+// and Redis storages: unique keys, references to existing rows, the
+// cascades on deleting them and the expiry of the short-lived Redis keys.
+// It starts with the builtin roles and plans the migrations create. It is
+// safe for concurrent use, and is meant for tests and for running the
+// server without databases. Nothing survives a restart.
+type Storage struct {
+	mu     *sync.RWMutex
+	t      *tables
+	locked bool
+}
+
+func New() *Storage {
+	t := newTables()
+	seed(t, time.Now())
+
+	return &Storage{
+		mu: new(sync.RWMutex),
+		t:  t,
+	}
+}
+
+func (s *Storage) Notes() notes.Storage {
+	return &notesStorage{s: s}
+}
+
+func (s *Storage) Users() users.Storage {
+	return &usersStorage{s: s}
+}
+
+func (s *Storage) Sessions() sessions.Storage {
+	return &sessionsStorage{s: s}
+}
+
+func (s *Storage) Workspaces() workspaces.Storage {
+	return &workspacesStorage{s: s}
+}
+
+func (s *Storage) WorkspaceMembers() workspacemembers.Storage {
+	return &workspaceMembersStorage{s: s}
+}
+
+func (s *Storage) AuthCodes() authcodes.Storage {
+	return &authCodesStorage{s: s}
+}
+
+func (s *Storage) Blocks() blocks.Storage {
+	return &blocksStorage{s: s}
+}
+
+func (s *Storage) Ceremonies() ceremonies.Storage {
+	return &ceremoniesStorage{s: s}
+}
+
+func (s *Storage) Deletions() deletions.Storage {
+	return &deletionsStorage{s: s}
+}
+
+func (s *Storage) Exports() exports.Storage {
+	return &exportsStorage{s: s}
+}
+
+func (s *Storage) Identities() identities.Storage {
+	return &identitiesStorage{s: s}
+}
+
+func (s *Storage) Jobs() jobs.Storage {
+	return &jobsStorage{s: s}
+}
+
+func (s *Storage) OAuthClients() oauthclients.Storage {
+	return &oauthClientsStorage{s: s}
+}
+
+func (s *Storage) Outbox() outbox.Storage {
+	return &outboxStorage{s: s}
+}
+
+func (s *Storage) Passkeys() passkeys.Storage {
+	return &passkeysStorage{s: s}
+}
+
+func (s *Storage) PasswordHistory() passwordhistory.Storage {
+	return &passwordHistoryStorage{s: s}
+}
+
+func (s *Storage) PasswordResets() passwordresets.Storage {
+	return &passwordResetsStorage{s: s}
+}
+
+func (s *Storage) PersonalTokens() personaltokens.Storage {
+	return &personalTokensStorage{s: s}
+}
+
+func (s *Storage) Plans() plans.Storage {
+	return &plansStorage{s: s}
+}
+
+func (s *Storage) Quotas() quotas.Storage {
+	return &quotasStorage{s: s}
+}
+
+func (s *Storage) RateLimits() ratelimits.Storage {
+	return &rateLimitsStorage{s: s}
+}
+
+func (s *Storage) RecoveryCodes() recoverycodes.Storage {
+	return &recoveryCodesStorage{s: s}
+}
+
+func (s *Storage) Roles() roles.Storage {
+	return &rolesStorage{s: s}
+}
+
+func (s *Storage) SecurityEvents() securityevents.Storage {
+	return &securityEventsStorage{s: s}
+}
+
+func (s *Storage) Stream() stream.Storage {
+	return &streamStorage{s: s}
+}
+
+func (s *Storage) TwoFactor() twofactor.Storage {
+	return &twoFactorStorage{s: s}
+}
+
+func (s *Storage) Usage() usage.Storage {
+	return &usageStorage{s: s}
+}
+
+func (s *Storage) Verifications() verifications.Storage {
+	return &verificationsStorage{s: s}
+}
+
+func (s *Storage) WebhookDeliveries() webhookdeliveries.Storage {
+	return &webhookDeliveriesStorage{s: s}
+}
+
+func (s *Storage) Webhooks() webhooks.Storage {
+	return &webhooksStorage{s: s}
+}
+
+// WithTx holds the lock while fn runs, so transactions are serializable
+// whatever the options, and restores the tables if fn fails. Nested calls
+// only restore their own changes.
+func (s *Storage) WithTx(ctx context.Context,
+	fn func(st storage.Storage) error, _ ...storage.TxOption) error {
+	if !s.locked {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+
+	snapshot := s.t.clone()
+	err := fn(&Storage{
+		mu:     s.mu,
+		t:      s.t,
+		locked: true,
+	})
+	if err != nil {
+		*s.t = *snapshot
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) read(fn func(t *tables)) {
+	if !s.locked {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+	}
+
+	fn(s.t)
+}
+
+func (s *Storage) write(fn func(t *tables) error) error {
+	if !s.locked {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+
+	return fn(s.t)
+}
+
+// Postgres keeps microseconds, and pgx drops the rest.
+func timestamp(t time.Time) time.Time {
+	return t.Truncate(time.Microsecond)
+}
+
+func timestampPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	v := timestamp(*t)
+	return &v
+}
+
+func ptr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+
+	v := *p
+	return &v
+}
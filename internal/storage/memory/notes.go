@@ -0,0 +1,187 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"cloud-notes/internal/storage/notes"
+
+	"github.com/google/uuid"
+)
+
+type notesStorage struct {
+	s *Storage
+}
+
+func copyNote(note *notes.Note) *notes.Note {
+	c := *note
+	c.Title = ptr(note.Title)
+	c.Text = ptr(note.Text)
+	c.UpdatedAt = timestampPtr(note.UpdatedAt)
+	c.CreatedAt = timestamp(note.CreatedAt)
+	c.WorkspaceID = ptr(note.WorkspaceID)
+	return &c
+}
+
+func (s *notesStorage) Create(ctx context.Context, note *notes.Note) error {
+	const op = "storage.memory.notes.Create"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.notes[note.ID]; ok {
+			return fmt.Errorf("%s: %w", op, ErrDuplicate)
+		}
+
+		if !noteReferencesFound(t, note) {
+			return fmt.Errorf("%s: %w", op, ErrForeignKey)
+		}
+
+		t.notes[note.ID] = copyNote(note)
+		return nil
+	})
+}
+
+func noteReferencesFound(t *tables, note *notes.Note) bool {
+	if _, ok := t.users[note.UserID]; !ok {
+		return false
+	}
+
+	if note.WorkspaceID != nil {
+		if _, ok := t.workspaces[*note.WorkspaceID]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *notesStorage) GetByID(
+	ctx context.Context, id uuid.UUID) (*notes.Note, error) {
+	var found *notes.Note
+	s.s.read(func(t *tables) {
+		if note, ok := t.notes[id]; ok {
+			found = copyNote(note)
+		}
+	})
+
+	return found, nil
+}
+
+// GetForUpdate needs no lock of its own, a transaction holds the storage's.
+func (s *notesStorage) GetForUpdate(
+	ctx context.Context, id uuid.UUID) (*notes.Note, error) {
+	return s.GetByID(ctx, id)
+}
+
+func (s *notesStorage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) ([]*notes.Note, error) {
+	found := make([]*notes.Note, 0)
+	s.s.read(func(t *tables) {
+		for _, note := range t.notes {
+			if note.UserID == userID {
+				found = append(found, copyNote(note))
+			}
+		}
+	})
+
+	return found, nil
+}
+
+func (s *notesStorage) Find(
+	ctx context.Context, filter *notes.Filter) ([]*notes.Note, error) {
+	found := make([]*notes.Note, 0)
+	s.s.read(func(t *tables) {
+		for _, note := range t.notes {
+			if matchNote(note, filter) {
+				found = append(found, copyNote(note))
+			}
+		}
+	})
+
+	slices.SortFunc(found, func(a, b *notes.Note) int {
+		if a.Pinned != b.Pinned {
+			if a.Pinned {
+				return -1
+			}
+			return 1
+		}
+
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	return found, nil
+}
+
+func matchNote(note *notes.Note, filter *notes.Filter) bool {
+	if filter.WorkspaceID == nil {
+		if note.WorkspaceID != nil || note.UserID != filter.UserID {
+			return false
+		}
+	} else if note.WorkspaceID == nil ||
+		*note.WorkspaceID != *filter.WorkspaceID {
+		return false
+	}
+
+	if filter.Query == nil {
+		return true
+	}
+
+	query := strings.ToLower(*filter.Query)
+	return contains(note.Title, query) || contains(note.Text, query)
+}
+
+func contains(value *string, query string) bool {
+	return strings.Contains(strings.ToLower(ptrValue(value)), query)
+}
+
+func ptrValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
+
+func (s *notesStorage) Count(
+	ctx context.Context, userID *uuid.UUID) (uint64, error) {
+	var count uint64
+	s.s.read(func(t *tables) {
+		for _, note := range t.notes {
+			if userID == nil || note.UserID == *userID {
+				count++
+			}
+		}
+	})
+
+	return count, nil
+}
+
+func (s *notesStorage) Update(ctx context.Context, note *notes.Note) error {
+	const op = "storage.memory.notes.Update"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.notes[note.ID]; !ok {
+			return nil
+		}
+
+		if !noteReferencesFound(t, note) {
+			return fmt.Errorf("%s: %w", op, ErrForeignKey)
+		}
+
+		t.notes[note.ID] = copyNote(note)
+		return nil
+	})
+}
+
+func (s *notesStorage) Delete(
+	ctx context.Context, id uuid.UUID) (bool, error) {
+	var deleted bool
+	err := s.s.write(func(t *tables) error {
+		_, deleted = t.notes[id]
+		delete(t.notes, id)
+		return nil
+	})
+
+	return deleted, err
+}
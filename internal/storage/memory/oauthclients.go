@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"cloud-notes/internal/storage/oauthclients"
+
+	"github.com/google/uuid"
+)
+
+type oauthClientsStorage struct {
+	s *Storage
+}
+
+func copyOAuthClient(client *oauthclients.Client) *oauthclients.Client {
+	c := *client
+	c.SecretHash = ptr(client.SecretHash)
+	c.RedirectURIs = slices.Clone(client.RedirectURIs)
+	c.Scopes = slices.Clone(client.Scopes)
+	c.CreatedAt = timestamp(client.CreatedAt)
+	return &c
+}
+
+func (s *oauthClientsStorage) Create(
+	ctx context.Context, client *oauthclients.Client) error {
+	const op = "storage.memory.oauthclients.Create"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.oauthClients[client.ID]; ok {
+			return fmt.Errorf("%s: %w", op, ErrDuplicate)
+		}
+
+		if _, ok := t.users[client.OwnerID]; !ok {
+			return fmt.Errorf("%s: %w", op, ErrForeignKey)
+		}
+
+		t.oauthClients[client.ID] = copyOAuthClient(client)
+		return nil
+	})
+}
+
+func (s *oauthClientsStorage) GetByID(
+	ctx context.Context, id uuid.UUID) (*oauthclients.Client, error) {
+	var found *oauthclients.Client
+	s.s.read(func(t *tables) {
+		if client, ok := t.oauthClients[id]; ok {
+			found = copyOAuthClient(client)
+		}
+	})
+
+	return found, nil
+}
+
+func (s *oauthClientsStorage) GetByOwnerID(ctx context.Context,
+	ownerID uuid.UUID) ([]*oauthclients.Client, error) {
+	found := make([]*oauthclients.Client, 0)
+	s.s.read(func(t *tables) {
+		for _, client := range t.oauthClients {
+			if client.OwnerID == ownerID {
+				found = append(found, copyOAuthClient(client))
+			}
+		}
+	})
+
+	slices.SortFunc(found, func(a, b *oauthclients.Client) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return found, nil
+}
+
+// Delete removes the client with the sessions issued to it.
+func (s *oauthClientsStorage) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.s.write(func(t *tables) error {
+		deleteOAuthClient(t, id)
+		return nil
+	})
+}
+
+func deleteOAuthClient(t *tables, id uuid.UUID) {
+	for sessionID, session := range t.sessions {
+		if session.ClientID != nil && *session.ClientID == id {
+			delete(t.sessions, sessionID)
+		}
+	}
+
+	delete(t.oauthClients, id)
+}
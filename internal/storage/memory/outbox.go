@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"cloud-notes/internal/storage/outbox"
+
+	"github.com/google/uuid"
+)
+
+type outboxStorage struct {
+	s *Storage
+}
+
+func copyOutboxEvent(event *outbox.Event) *outbox.Event {
+	c := *event
+	c.Payload = bytes.Clone(event.Payload)
+	c.CreatedAt = timestamp(event.CreatedAt)
+	c.DispatchedAt = timestampPtr(event.DispatchedAt)
+	c.RunAt = timestamp(event.RunAt)
+	c.Error = ptr(event.Error)
+	c.FailedAt = timestampPtr(event.FailedAt)
+	return &c
+}
+
+func (s *outboxStorage) Create(ctx context.Context, event *outbox.Event) error {
+	const op = "storage.memory.outbox.Create"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.outbox[event.ID]; ok {
+			return fmt.Errorf("%s: %w", op, ErrDuplicate)
+		}
+
+		if _, ok := t.users[event.UserID]; !ok {
+			return fmt.Errorf("%s: %w", op, ErrForeignKey)
+		}
+
+		c := copyOutboxEvent(event)
+		c.Error = nil
+		c.FailedAt = nil
+		t.outbox[event.ID] = c
+		return nil
+	})
+}
+
+// Claim leases the due events until leaseUntil, oldest first, and counts
+// the attempt, like the Postgres storage does.
+func (s *outboxStorage) Claim(ctx context.Context, now, leaseUntil time.Time,
+	limit uint64) ([]*outbox.Event, error) {
+	claimed := make([]*outbox.Event, 0)
+	_ = s.s.write(func(t *tables) error {
+		due := make([]*outbox.Event, 0)
+		for _, event := range t.outbox {
+			if event.DispatchedAt == nil && event.FailedAt == nil &&
+				!event.RunAt.After(timestamp(now)) {
+				due = append(due, event)
+			}
+		}
+
+		slices.SortFunc(due, func(a, b *outbox.Event) int {
+			return a.CreatedAt.Compare(b.CreatedAt)
+		})
+
+		for _, event := range due[:min(limit, uint64(len(due)))] {
+			c := copyOutboxEvent(event)
+			c.RunAt = timestamp(leaseUntil)
+			c.Attempts++
+			t.outbox[event.ID] = c
+			claimed = append(claimed, copyOutboxEvent(c))
+		}
+
+		return nil
+	})
+
+	return claimed, nil
+}
+
+func (s *outboxStorage) MarkDispatched(
+	ctx context.Context, id uuid.UUID, at time.Time) error {
+	return s.s.write(func(t *tables) error {
+		if event, ok := t.outbox[id]; ok {
+			c := copyOutboxEvent(event)
+			c.DispatchedAt = timestampPtr(&at)
+			t.outbox[id] = c
+		}
+
+		return nil
+	})
+}
+
+func (s *outboxStorage) Fail(ctx context.Context, id uuid.UUID,
+	message string, failedAt *time.Time) error {
+	return s.s.write(func(t *tables) error {
+		if event, ok := t.outbox[id]; ok {
+			c := copyOutboxEvent(event)
+			c.Error = &message
+			c.FailedAt = timestampPtr(failedAt)
+			t.outbox[id] = c
+		}
+
+		return nil
+	})
+}
+
+func (s *outboxStorage) Retry(ctx context.Context,
+	now time.Time) (int64, error) {
+	var count int64
+	_ = s.s.write(func(t *tables) error {
+		for id, event := range t.outbox {
+			if event.FailedAt == nil {
+				continue
+			}
+
+			c := copyOutboxEvent(event)
+			c.Attempts = 0
+			c.RunAt = timestamp(now)
+			c.FailedAt = nil
+			t.outbox[id] = c
+			count++
+		}
+
+		return nil
+	})
+
+	return count, nil
+}
+
+func (s *outboxStorage) DeleteDispatched(
+	ctx context.Context, before time.Time) error {
+	return s.s.write(func(t *tables) error {
+		for id, event := range t.outbox {
+			if event.DispatchedAt != nil &&
+				event.DispatchedAt.Before(timestamp(before)) {
+				delete(t.outbox, id)
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+
+	"cloud-notes/internal/storage/passkeys"
+
+	"github.com/google/uuid"
+)
+
+type passkeysStorage struct {
+	s *Storage
+}
+
+func copyPasskey(passkey *passkeys.Passkey) *passkeys.Passkey {
+	c := *passkey
+	c.CredentialID = bytes.Clone(passkey.CredentialID)
+	c.PublicKey = bytes.Clone(passkey.PublicKey)
+	c.Transports = slices.Clone(passkey.Transports)
+	c.AAGUID = bytes.Clone(passkey.AAGUID)
+	c.LastUsedAt = timestampPtr(passkey.LastUsedAt)
+	c.CreatedAt = timestamp(passkey.CreatedAt)
+	return &c
+}
+
+// checkPasskey enforces the reference to the user and the unique
+// credential.
+func checkPasskey(t *tables, passkey *passkeys.Passkey) error {
+	if _, ok := t.users[passkey.UserID]; !ok {
+		return ErrForeignKey
+	}
+
+	for _, other := range t.passkeys {
+		if other.ID != passkey.ID &&
+			bytes.Equal(other.CredentialID, passkey.CredentialID) {
+			return ErrDuplicate
+		}
+	}
+
+	return nil
+}
+
+func (s *passkeysStorage) Create(
+	ctx context.Context, passkey *passkeys.Passkey) error {
+	const op = "storage.memory.passkeys.Create"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.passkeys[passkey.ID]; ok {
+			return fmt.Errorf("%s: %w", op, ErrDuplicate)
+		}
+
+		err := checkPasskey(t, passkey)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		t.passkeys[passkey.ID] = copyPasskey(passkey)
+		return nil
+	})
+}
+
+func (s *passkeysStorage) GetByID(
+	ctx context.Context, id uuid.UUID) (*passkeys.Passkey, error) {
+	var found *passkeys.Passkey
+	s.s.read(func(t *tables) {
+		if passkey, ok := t.passkeys[id]; ok {
+			found = copyPasskey(passkey)
+		}
+	})
+
+	return found, nil
+}
+
+func (s *passkeysStorage) GetByCredentialID(
+	ctx context.Context, credentialID []byte) (*passkeys.Passkey, error) {
+	var found *passkeys.Passkey
+	s.s.read(func(t *tables) {
+		for _, passkey := range t.passkeys {
+			if bytes.Equal(passkey.CredentialID, credentialID) {
+				found = copyPasskey(passkey)
+			}
+		}
+	})
+
+	return found, nil
+}
+
+func (s *passkeysStorage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) ([]*passkeys.Passkey, error) {
+	found := make([]*passkeys.Passkey, 0)
+	s.s.read(func(t *tables) {
+		for _, passkey := range t.passkeys {
+			if passkey.UserID == userID {
+				found = append(found, copyPasskey(passkey))
+			}
+		}
+	})
+
+	slices.SortFunc(found, func(a, b *passkeys.Passkey) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return found, nil
+}
+
+func (s *passkeysStorage) Update(
+	ctx context.Context, passkey *passkeys.Passkey) error {
+	const op = "storage.memory.passkeys.Update"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.passkeys[passkey.ID]; !ok {
+			return nil
+		}
+
+		err := checkPasskey(t, passkey)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		t.passkeys[passkey.ID] = copyPasskey(passkey)
+		return nil
+	})
+}
+
+func (s *passkeysStorage) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.s.write(func(t *tables) error {
+		delete(t.passkeys, id)
+		return nil
+	})
+}
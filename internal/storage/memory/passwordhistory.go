@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"cloud-notes/internal/storage/passwordhistory"
+
+	"github.com/google/uuid"
+)
+
+type passwordHistoryStorage struct {
+	s *Storage
+}
+
+func copyPasswordHistoryEntry(
+	entry *passwordhistory.Entry) *passwordhistory.Entry {
+	c := *entry
+	c.CreatedAt = timestamp(entry.CreatedAt)
+	return &c
+}
+
+func (s *passwordHistoryStorage) Create(
+	ctx context.Context, entry *passwordhistory.Entry) error {
+	const op = "storage.memory.passwordhistory.Create"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.passwordHistory[entry.ID]; ok {
+			return fmt.Errorf("%s: %w", op, ErrDuplicate)
+		}
+
+		if _, ok := t.users[entry.UserID]; !ok {
+			return fmt.Errorf("%s: %w", op, ErrForeignKey)
+		}
+
+		t.passwordHistory[entry.ID] = copyPasswordHistoryEntry(entry)
+		return nil
+	})
+}
+
+// latest returns the user's entries, newest first.
+func latest(t *tables, userID uuid.UUID) []*passwordhistory.Entry {
+	found := make([]*passwordhistory.Entry, 0)
+	for _, entry := range t.passwordHistory {
+		if entry.UserID == userID {
+			found = append(found, entry)
+		}
+	}
+
+	slices.SortFunc(found, func(a, b *passwordhistory.Entry) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	return found
+}
+
+func (s *passwordHistoryStorage) GetLatest(ctx context.Context,
+	userID uuid.UUID, limit int) ([]*passwordhistory.Entry, error) {
+	found := make([]*passwordhistory.Entry, 0)
+	s.s.read(func(t *tables) {
+		entries := latest(t, userID)
+		for _, entry := range entries[:min(max(limit, 0), len(entries))] {
+			found = append(found, copyPasswordHistoryEntry(entry))
+		}
+	})
+
+	return found, nil
+}
+
+func (s *passwordHistoryStorage) DeleteOlder(
+	ctx context.Context, userID uuid.UUID, keep int) error {
+	return s.s.write(func(t *tables) error {
+		entries := latest(t, userID)
+		for _, entry := range entries[min(max(keep, 0), len(entries)):] {
+			delete(t.passwordHistory, entry.ID)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"cloud-notes/internal/storage/passwordresets"
+)
+
+// The reset tokens are kept in Redis and expire there.
+type passwordResetsStorage struct {
+	s *Storage
+}
+
+func (s *passwordResetsStorage) Create(
+	ctx context.Context, reset *passwordresets.PasswordReset) error {
+	return s.s.write(func(t *tables) error {
+		c := *reset
+		t.passwordResets[reset.TokenHash] = &c
+		return nil
+	})
+}
+
+// Take returns the reset and removes it, so every link works only once.
+func (s *passwordResetsStorage) Take(ctx context.Context,
+	tokenHash string) (*passwordresets.PasswordReset, error) {
+	var found *passwordresets.PasswordReset
+	_ = s.s.write(func(t *tables) error {
+		reset, ok := t.passwordResets[tokenHash]
+		if !ok {
+			return nil
+		}
+
+		delete(t.passwordResets, tokenHash)
+		if reset.ExpiresAt.After(time.Now()) {
+			c := *reset
+			found = &c
+		}
+		return nil
+	})
+
+	return found, nil
+}
@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"cloud-notes/internal/storage/personaltokens"
+
+	"github.com/google/uuid"
+)
+
+type personalTokensStorage struct {
+	s *Storage
+}
+
+func copyPersonalToken(
+	token *personaltokens.PersonalToken) *personaltokens.PersonalToken {
+	c := *token
+	c.Scopes = slices.Clone(token.Scopes)
+	c.LastUsedAt = timestampPtr(token.LastUsedAt)
+	c.ExpiresAt = timestampPtr(token.ExpiresAt)
+	c.CreatedAt = timestamp(token.CreatedAt)
+	return &c
+}
+
+// checkPersonalToken enforces the reference to the user and the unique
+// token hash.
+func checkPersonalToken(t *tables, token *personaltokens.PersonalToken) error {
+	if _, ok := t.users[token.UserID]; !ok {
+		return ErrForeignKey
+	}
+
+	for _, other := range t.personalTokens {
+		if other.ID != token.ID && other.TokenHash == token.TokenHash {
+			return ErrDuplicate
+		}
+	}
+
+	return nil
+}
+
+func (s *personalTokensStorage) Create(
+	ctx context.Context, token *personaltokens.PersonalToken) error {
+	const op = "storage.memory.personaltokens.Create"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.personalTokens[token.ID]; ok {
+			return fmt.Errorf("%s: %w", op, ErrDuplicate)
+		}
+
+		err := checkPersonalToken(t, token)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		t.personalTokens[token.ID] = copyPersonalToken(token)
+		return nil
+	})
+}
+
+func (s *personalTokensStorage) GetByID(ctx context.Context,
+	id uuid.UUID) (*personaltokens.PersonalToken, error) {
+	var found *personaltokens.PersonalToken
+	s.s.read(func(t *tables) {
+		if token, ok := t.personalTokens[id]; ok {
+			found = copyPersonalToken(token)
+		}
+	})
+
+	return found, nil
+}
+
+func (s *personalTokensStorage) GetByHash(ctx context.Context,
+	tokenHash string) (*personaltokens.PersonalToken, error) {
+	var found *personaltokens.PersonalToken
+	s.s.read(func(t *tables) {
+		for _, token := range t.personalTokens {
+			if token.TokenHash == tokenHash {
+				found = copyPersonalToken(token)
+			}
+		}
+	})
+
+	return found, nil
+}
+
+func (s *personalTokensStorage) GetByUserID(ctx context.Context,
+	userID uuid.UUID) ([]*personaltokens.PersonalToken, error) {
+	found := make([]*personaltokens.PersonalToken, 0)
+	s.s.read(func(t *tables) {
+		for _, token := range t.personalTokens {
+			if token.UserID == userID {
+				found = append(found, copyPersonalToken(token))
+			}
+		}
+	})
+
+	slices.SortFunc(found, func(a, b *personaltokens.PersonalToken) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return found, nil
+}
+
+func (s *personalTokensStorage) Update(
+	ctx context.Context, token *personaltokens.PersonalToken) error {
+	const op = "storage.memory.personaltokens.Update"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.personalTokens[token.ID]; !ok {
+			return nil
+		}
+
+		err := checkPersonalToken(t, token)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		t.personalTokens[token.ID] = copyPersonalToken(token)
+		return nil
+	})
+}
+
+func (s *personalTokensStorage) Delete(
+	ctx context.Context, id uuid.UUID) error {
+	return s.s.write(func(t *tables) error {
+		delete(t.personalTokens, id)
+		return nil
+	})
+}
+
+func (s *personalTokensStorage) DeleteByUserID(
+	ctx context.Context, userID uuid.UUID) error {
+	return s.s.write(func(t *tables) error {
+		for id, token := range t.personalTokens {
+			if token.UserID == userID {
+				delete(t.personalTokens, id)
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"cloud-notes/internal/storage/plans"
+)
+
+// The plans are only read, they are the ones seed adds.
+type plansStorage struct {
+	s *Storage
+}
+
+func (s *plansStorage) GetByName(
+	ctx context.Context, name string) (*plans.Plan, error) {
+	var found *plans.Plan
+	s.s.read(func(t *tables) {
+		if plan, ok := t.plans[name]; ok {
+			c := *plan
+			found = &c
+		}
+	})
+
+	return found, nil
+}
+
+func (s *plansStorage) GetAll(ctx context.Context) ([]*plans.Plan, error) {
+	found := make([]*plans.Plan, 0)
+	s.s.read(func(t *tables) {
+		for _, plan := range t.plans {
+			c := *plan
+			found = append(found, &c)
+		}
+	})
+
+	slices.SortFunc(found, func(a, b *plans.Plan) int {
+		if c := cmp.Compare(a.MaxTotalBytes, b.MaxTotalBytes); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return found, nil
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"cloud-notes/internal/storage/quotas"
+
+	"github.com/google/uuid"
+)
+
+type quotasStorage struct {
+	s *Storage
+}
+
+func copyQuota(quota *quotas.Quota) *quotas.Quota {
+	c := *quota
+	c.Plan = ptr(quota.Plan)
+	c.MaxNotes = ptr(quota.MaxNotes)
+	c.MaxTotalBytes = ptr(quota.MaxTotalBytes)
+	c.MaxNoteBytes = ptr(quota.MaxNoteBytes)
+	c.UpdatedAt = timestamp(quota.UpdatedAt)
+	return &c
+}
+
+// Create stores the override replacing the previous one.
+func (s *quotasStorage) Create(ctx context.Context, quota *quotas.Quota) error {
+	const op = "storage.memory.quotas.Create"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.users[quota.UserID]; !ok {
+			return fmt.Errorf("%s: %w", op, ErrForeignKey)
+		}
+
+		if quota.Plan != nil {
+			if _, ok := t.plans[*quota.Plan]; !ok {
+				return fmt.Errorf("%s: %w", op, ErrForeignKey)
+			}
+		}
+
+		t.quotas[quota.UserID] = copyQuota(quota)
+		return nil
+	})
+}
+
+func (s *quotasStorage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) (*quotas.Quota, error) {
+	var found *quotas.Quota
+	s.s.read(func(t *tables) {
+		if quota, ok := t.quotas[userID]; ok {
+			found = copyQuota(quota)
+		}
+	})
+
+	return found, nil
+}
+
+func (s *quotasStorage) Delete(ctx context.Context, userID uuid.UUID) error {
+	return s.s.write(func(t *tables) error {
+		delete(t.quotas, userID)
+		return nil
+	})
+}
@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"cloud-notes/internal/storage/ratelimits"
+)
+
+// bucket is a token bucket as the Redis script keeps it.
+type bucket struct {
+	tokens float64
+	ts     time.Time
+}
+
+type rateLimitsStorage struct {
+	s *Storage
+}
+
+// Take refills the bucket by the time elapsed since the previous call and
+// tries to take one token from it, like the Redis storage does.
+func (s *rateLimitsStorage) Take(ctx context.Context, key string,
+	budget *ratelimits.Budget) (*ratelimits.Result, error) {
+	limit := float64(budget.Limit)
+	now := time.Now()
+
+	var allowed bool
+	var tokens float64
+	_ = s.s.write(func(t *tables) error {
+		b, ok := t.buckets[key]
+		if !ok {
+			b = bucket{tokens: limit, ts: now}
+		}
+
+		refill := float64(now.Sub(b.ts)) * limit / float64(budget.Period)
+		tokens = math.Min(limit, b.tokens+refill)
+
+		allowed = tokens >= 1
+		if allowed {
+			tokens--
+		}
+
+		t.buckets[key] = bucket{tokens: tokens, ts: now}
+		return nil
+	})
+
+	return ratelimits.NewResult(budget, allowed, tokens), nil
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/storage/recoverycodes"
+
+	"github.com/google/uuid"
+)
+
+type recoveryCodesStorage struct {
+	s *Storage
+}
+
+func copyRecoveryCode(
+	code *recoverycodes.RecoveryCode) *recoverycodes.RecoveryCode {
+	c := *code
+	c.UsedAt = timestampPtr(code.UsedAt)
+	c.CreatedAt = timestamp(code.CreatedAt)
+	return &c
+}
+
+func (s *recoveryCodesStorage) Create(
+	ctx context.Context, code *recoverycodes.RecoveryCode) error {
+	const op = "storage.memory.recoverycodes.Create"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.recoveryCodes[code.ID]; ok {
+			return fmt.Errorf("%s: %w", op, ErrDuplicate)
+		}
+
+		if _, ok := t.users[code.UserID]; !ok {
+			return fmt.Errorf("%s: %w", op, ErrForeignKey)
+		}
+
+		t.recoveryCodes[code.ID] = copyRecoveryCode(code)
+		return nil
+	})
+}
+
+func (s *recoveryCodesStorage) GetByHash(ctx context.Context,
+	userID uuid.UUID, codeHash string) (*recoverycodes.RecoveryCode, error) {
+	var found *recoverycodes.RecoveryCode
+	s.s.read(func(t *tables) {
+		for _, code := range t.recoveryCodes {
+			if code.UserID == userID && code.CodeHash == codeHash {
+				found = copyRecoveryCode(code)
+			}
+		}
+	})
+
+	return found, nil
+}
+
+// Use marks the code used unless it already is, so a code works once even
+// when it is sent twice at the same time.
+func (s *recoveryCodesStorage) Use(ctx context.Context,
+	userID uuid.UUID, codeHash string, usedAt time.Time) (bool, error) {
+	var used bool
+	_ = s.s.write(func(t *tables) error {
+		for id, code := range t.recoveryCodes {
+			if code.UserID != userID || code.CodeHash != codeHash ||
+				code.UsedAt != nil {
+				continue
+			}
+
+			c := copyRecoveryCode(code)
+			c.UsedAt = timestampPtr(&usedAt)
+			t.recoveryCodes[id] = c
+			used = true
+		}
+
+		return nil
+	})
+
+	return used, nil
+}
+
+func (s *recoveryCodesStorage) DeleteByUserID(
+	ctx context.Context, userID uuid.UUID) error {
+	return s.s.write(func(t *tables) error {
+		for id, code := range t.recoveryCodes {
+			if code.UserID == userID {
+				delete(t.recoveryCodes, id)
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"cloud-notes/internal/storage/roles"
+)
+
+type rolesStorage struct {
+	s *Storage
+}
+
+func copyRole(role *roles.Role) *roles.Role {
+	c := *role
+	c.Permissions = slices.Clone(role.Permissions)
+	c.CreatedAt = timestamp(role.CreatedAt)
+	return &c
+}
+
+func (s *rolesStorage) Create(ctx context.Context, role *roles.Role) error {
+	const op = "storage.memory.roles.Create"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.roles[role.Name]; ok {
+			return fmt.Errorf("%s: %w", op, ErrDuplicate)
+		}
+
+		t.roles[role.Name] = copyRole(role)
+		return nil
+	})
+}
+
+func (s *rolesStorage) GetByName(
+	ctx context.Context, name string) (*roles.Role, error) {
+	var found *roles.Role
+	s.s.read(func(t *tables) {
+		if role, ok := t.roles[name]; ok {
+			found = copyRole(role)
+		}
+	})
+
+	return found, nil
+}
+
+func (s *rolesStorage) GetAll(ctx context.Context) ([]*roles.Role, error) {
+	found := make([]*roles.Role, 0)
+	s.s.read(func(t *tables) {
+		for _, role := range t.roles {
+			found = append(found, copyRole(role))
+		}
+	})
+
+	slices.SortFunc(found, func(a, b *roles.Role) int {
+		if a.Builtin != b.Builtin {
+			if a.Builtin {
+				return -1
+			}
+			return 1
+		}
+
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return found, nil
+}
+
+// Update changes the description and the permissions only.
+func (s *rolesStorage) Update(ctx context.Context, role *roles.Role) error {
+	return s.s.write(func(t *tables) error {
+		old, ok := t.roles[role.Name]
+		if !ok {
+			return nil
+		}
+
+		c := copyRole(old)
+		c.Description = role.Description
+		c.Permissions = slices.Clone(role.Permissions)
+		t.roles[role.Name] = c
+		return nil
+	})
+}
+
+// Delete fails while users have the role, like the foreign key does.
+func (s *rolesStorage) Delete(ctx context.Context, name string) error {
+	const op = "storage.memory.roles.Delete"
+
+	return s.s.write(func(t *tables) error {
+		for _, user := range t.users {
+			if string(user.Role) == name {
+				return fmt.Errorf("%s: %w", op, ErrForeignKey)
+			}
+		}
+
+		delete(t.roles, name)
+		return nil
+	})
+}
+
+func (s *rolesStorage) CountUsers(
+	ctx context.Context, name string) (uint64, error) {
+	var count uint64
+	s.s.read(func(t *tables) {
+		for _, user := range t.users {
+			if string(user.Role) == name {
+				count++
+			}
+		}
+	})
+
+	return count, nil
+}
@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"cloud-notes/internal/storage/securityevents"
+)
+
+// The audit trail is append-only, and the events outlive their users like
+// in Postgres.
+type securityEventsStorage struct {
+	s *Storage
+}
+
+func copySecurityEvent(event *securityevents.Event) *securityevents.Event {
+	c := *event
+	c.UserID = ptr(event.UserID)
+	c.ActorID = ptr(event.ActorID)
+	c.Login = ptr(event.Login)
+	c.IP = ptr(event.IP)
+	c.UserAgent = ptr(event.UserAgent)
+	c.Details = maps.Clone(event.Details)
+	if c.Details == nil {
+		c.Details = make(map[string]string)
+	}
+	c.CreatedAt = timestamp(event.CreatedAt)
+	return &c
+}
+
+func (s *securityEventsStorage) Create(
+	ctx context.Context, event *securityevents.Event) error {
+	const op = "storage.memory.securityevents.Create"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.securityEvents[event.ID]; ok {
+			return fmt.Errorf("%s: %w", op, ErrDuplicate)
+		}
+
+		t.securityEvents[event.ID] = copySecurityEvent(event)
+		return nil
+	})
+}
+
+func matchSecurityEvent(
+	event *securityevents.Event, filter *securityevents.Filter) bool {
+	switch {
+	case filter.UserID != nil &&
+		(event.UserID == nil || *event.UserID != *filter.UserID):
+		return false
+	case filter.Type != nil && event.Type != *filter.Type:
+		return false
+	case filter.IP != nil && (event.IP == nil || *event.IP != *filter.IP):
+		return false
+	case filter.From != nil &&
+		event.CreatedAt.Before(timestamp(*filter.From)):
+		return false
+	case filter.To != nil &&
+		!event.CreatedAt.Before(timestamp(*filter.To)):
+		return false
+	}
+
+	return true
+}
+
+func (s *securityEventsStorage) Find(ctx context.Context,
+	filter *securityevents.Filter) ([]*securityevents.Event, error) {
+	found := make([]*securityevents.Event, 0)
+	s.s.read(func(t *tables) {
+		for _, event := range t.securityEvents {
+			if matchSecurityEvent(event, filter) {
+				found = append(found, copySecurityEvent(event))
+			}
+		}
+	})
+
+	slices.SortFunc(found, func(a, b *securityevents.Event) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	if filter.Offset != nil {
+		found = found[min(*filter.Offset, uint64(len(found))):]
+	}
+	if filter.Limit != nil {
+		found = found[:min(*filter.Limit, uint64(len(found)))]
+	}
+
+	return found, nil
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"time"
+
+	"cloud-notes/internal/storage/plans"
+	"cloud-notes/internal/storage/roles"
+)
+
+// seed adds the rows the migrations insert: the builtin roles and the
+// plans.
+func seed(t *tables, now time.Time) {
+	now = timestamp(now)
+
+	for _, role := range []*roles.Role{
+		{
+			Name:        "user",
+			Description: "Regular user",
+			Permissions: []string{},
+		},
+		{
+			Name:        "support",
+			Description: "Support team",
+			Permissions: []string{"users:read", "security_events:read"},
+		},
+		{
+			Name:        "admin",
+			Description: "Administrator",
+			Permissions: []string{"users:read", "users:write",
+				"users:impersonate", "roles:manage", "security_events:read",
+				"stats:read", "jobs:manage"},
+		},
+	} {
+		role.Builtin = true
+		role.CreatedAt = now
+		t.roles[role.Name] = role
+	}
+
+	for _, plan := range []*plans.Plan{
+		{
+			Name:          "free",
+			MaxNotes:      1000,
+			MaxTotalBytes: 10485760,
+			MaxNoteBytes:  10000,
+		},
+		{
+			Name:          "pro",
+			MaxNotes:      100000,
+			MaxTotalBytes: 1073741824,
+			MaxNoteBytes:  1048576,
+		},
+	} {
+		plan.CreatedAt = now
+		t.plans[plan.Name] = plan
+	}
+}
@@ -0,0 +1,257 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"cloud-notes/internal/storage/sessions"
+
+	"github.com/google/uuid"
+)
+
+// rotatedToken is a refresh token replaced in the session. The session may
+// be gone, like the cascade in Postgres would have removed the token.
+type rotatedToken struct {
+	sessionID uuid.UUID
+	expiresAt time.Time
+}
+
+type sessionsStorage struct {
+	s *Storage
+}
+
+func copySession(session *sessions.Session) *sessions.Session {
+	c := *session
+	c.UserAgent = ptr(session.UserAgent)
+	c.CreatedAt = timestamp(session.CreatedAt)
+	c.ClientID = ptr(session.ClientID)
+	c.Scopes = slices.Clone(session.Scopes)
+	c.RefreshTokenHash = ptr(session.RefreshTokenHash)
+	c.ExpiresAt = timestampPtr(session.ExpiresAt)
+	c.ImpersonatorID = ptr(session.ImpersonatorID)
+	return &c
+}
+
+// checkSession enforces the session's references to users and the OAuth
+// client, and the unique refresh token.
+func checkSession(t *tables, session *sessions.Session) error {
+	if _, ok := t.users[session.UserID]; !ok {
+		return ErrForeignKey
+	}
+
+	if session.ClientID != nil {
+		if _, ok := t.oauthClients[*session.ClientID]; !ok {
+			return ErrForeignKey
+		}
+	}
+
+	if session.ImpersonatorID != nil {
+		if _, ok := t.users[*session.ImpersonatorID]; !ok {
+			return ErrForeignKey
+		}
+	}
+
+	if session.RefreshTokenHash == nil {
+		return nil
+	}
+
+	for _, other := range t.sessions {
+		if other.ID != session.ID && other.RefreshTokenHash != nil &&
+			*other.RefreshTokenHash == *session.RefreshTokenHash {
+			return ErrDuplicate
+		}
+	}
+
+	return nil
+}
+
+func (s *sessionsStorage) Create(
+	ctx context.Context, session *sessions.Session) error {
+	const op = "storage.memory.sessions.Create"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.sessions[session.ID]; ok {
+			return fmt.Errorf("%s: %w", op, ErrDuplicate)
+		}
+
+		err := checkSession(t, session)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		t.sessions[session.ID] = copySession(session)
+		return nil
+	})
+}
+
+func (s *sessionsStorage) GetByID(
+	ctx context.Context, id uuid.UUID) (*sessions.Session, error) {
+	var found *sessions.Session
+	s.s.read(func(t *tables) {
+		if session, ok := t.sessions[id]; ok {
+			found = copySession(session)
+		}
+	})
+
+	return found, nil
+}
+
+func (s *sessionsStorage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) ([]*sessions.Session, error) {
+	found := make([]*sessions.Session, 0)
+	s.s.read(func(t *tables) {
+		for _, session := range t.sessions {
+			if session.UserID == userID {
+				found = append(found, copySession(session))
+			}
+		}
+	})
+
+	return found, nil
+}
+
+func (s *sessionsStorage) CountActive(
+	ctx context.Context, userID *uuid.UUID) (uint64, error) {
+	now := time.Now()
+
+	var count uint64
+	s.s.read(func(t *tables) {
+		for _, session := range t.sessions {
+			if userID != nil && session.UserID != *userID {
+				continue
+			}
+
+			if session.ExpiresAt == nil || session.ExpiresAt.After(now) {
+				count++
+			}
+		}
+	})
+
+	return count, nil
+}
+
+func (s *sessionsStorage) GetByRefreshTokenHash(ctx context.Context,
+	refreshTokenHash string) (*sessions.Session, error) {
+	var found *sessions.Session
+	s.s.read(func(t *tables) {
+		for _, session := range t.sessions {
+			if session.RefreshTokenHash != nil &&
+				*session.RefreshTokenHash == refreshTokenHash {
+				found = copySession(session)
+			}
+		}
+	})
+
+	return found, nil
+}
+
+func (s *sessionsStorage) RotateRefreshToken(ctx context.Context,
+	refreshTokenHash, newRefreshTokenHash string, now, expiresAt time.Time,
+	lifetime time.Duration) (*sessions.Session, error) {
+	const op = "storage.memory.sessions.RotateRefreshToken"
+
+	var rotated *sessions.Session
+	err := s.s.write(func(t *tables) error {
+		var old *sessions.Session
+		for _, session := range t.sessions {
+			if session.RefreshTokenHash != nil &&
+				*session.RefreshTokenHash == refreshTokenHash {
+				old = session
+			}
+		}
+
+		if old == nil || old.ExpiresAt == nil || !old.ExpiresAt.After(now) {
+			return nil
+		}
+
+		session := copySession(old)
+		session.RefreshTokenHash = &newRefreshTokenHash
+		expiresAt := timestamp(expiresAt)
+		if limit := old.CreatedAt.Add(lifetime); limit.Before(expiresAt) {
+			expiresAt = timestamp(limit)
+		}
+		session.ExpiresAt = &expiresAt
+
+		err := checkSession(t, session)
+		if err != nil {
+			return err
+		}
+
+		for hash, token := range t.rotated {
+			if token.sessionID == old.ID && !token.expiresAt.After(now) {
+				delete(t.rotated, hash)
+			}
+		}
+
+		t.rotated[refreshTokenHash] = rotatedToken{
+			sessionID: old.ID,
+			expiresAt: *old.ExpiresAt,
+		}
+		t.sessions[old.ID] = session
+		rotated = copySession(session)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rotated, nil
+}
+
+func (s *sessionsStorage) GetByRotatedRefreshTokenHash(ctx context.Context,
+	refreshTokenHash string) (*sessions.Session, error) {
+	var found *sessions.Session
+	s.s.read(func(t *tables) {
+		token, ok := t.rotated[refreshTokenHash]
+		if !ok {
+			return
+		}
+
+		if session, ok := t.sessions[token.sessionID]; ok {
+			found = copySession(session)
+		}
+	})
+
+	return found, nil
+}
+
+func (s *sessionsStorage) Update(
+	ctx context.Context, session *sessions.Session) error {
+	const op = "storage.memory.sessions.Update"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.sessions[session.ID]; !ok {
+			return nil
+		}
+
+		err := checkSession(t, session)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		t.sessions[session.ID] = copySession(session)
+		return nil
+	})
+}
+
+func (s *sessionsStorage) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.s.write(func(t *tables) error {
+		delete(t.sessions, id)
+		return nil
+	})
+}
+
+func (s *sessionsStorage) DeleteByUserID(
+	ctx context.Context, userID uuid.UUID) error {
+	return s.s.write(func(t *tables) error {
+		for id, session := range t.sessions {
+			if session.UserID == userID {
+				delete(t.sessions, id)
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,228 @@
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"cloud-notes/internal/storage/outbox"
+	"cloud-notes/internal/storage/stream"
+)
+
+// streamMaxLen caps the stream like the Redis storage does.
+const streamMaxLen = 100000
+
+// streamGroup is a consumer group: the sequence of the last message
+// delivered to it and the messages its consumers haven't acknowledged.
+// Like the rows, a group is replaced on every change, never changed in
+// place.
+type streamGroup struct {
+	delivered int64
+	pending   map[string]pendingMessage
+}
+
+type pendingMessage struct {
+	consumer    string
+	deliveredAt time.Time
+}
+
+func (g *streamGroup) clone() *streamGroup {
+	return &streamGroup{
+		delivered: g.delivered,
+		pending:   maps.Clone(g.pending),
+	}
+}
+
+type streamStorage struct {
+	s *Storage
+}
+
+func copyMessage(message *stream.Message) *stream.Message {
+	event := *message.Event
+	event.Payload = bytes.Clone(message.Event.Payload)
+	return &stream.Message{
+		ID:    message.ID,
+		Event: &event,
+	}
+}
+
+func sequence(id string) int64 {
+	seq, _ := strconv.ParseInt(id[:len(id)-2], 10, 64)
+	return seq
+}
+
+// Publish appends the event. Only the fields the Redis storage keeps are
+// kept.
+func (s *streamStorage) Publish(
+	ctx context.Context, event *outbox.Event) error {
+	return s.s.write(func(t *tables) error {
+		var seq int64 = 1
+		if len(t.stream) > 0 {
+			seq = sequence(t.stream[len(t.stream)-1].ID) + 1
+		}
+
+		t.stream = append(t.stream, &stream.Message{
+			ID: fmt.Sprintf("%d-0", seq),
+			Event: &outbox.Event{
+				ID:        event.ID,
+				Type:      event.Type,
+				UserID:    event.UserID,
+				Payload:   bytes.Clone(event.Payload),
+				CreatedAt: event.CreatedAt,
+			},
+		})
+		if len(t.stream) > streamMaxLen {
+			t.stream = slices.Clone(t.stream[len(t.stream)-streamMaxLen:])
+		}
+
+		return nil
+	})
+}
+
+// CreateGroup creates the consumer group, reading the stream from its
+// start. A group that already exists is left as it is.
+func (s *streamStorage) CreateGroup(ctx context.Context, group string) error {
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.groups[group]; !ok {
+			t.groups[group] = &streamGroup{
+				pending: make(map[string]pendingMessage),
+			}
+		}
+
+		return nil
+	})
+}
+
+// Read returns messages never delivered to the group, waiting up to block
+// for them. They stay pending for the consumer until acknowledged.
+func (s *streamStorage) Read(ctx context.Context, group, consumer string,
+	count int64, block time.Duration) ([]*stream.Message, error) {
+	messages, err := s.read(group, consumer, count)
+	if err != nil || len(messages) > 0 || block <= 0 {
+		return messages, err
+	}
+
+	timer := time.NewTimer(block)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+	}
+
+	return s.read(group, consumer, count)
+}
+
+func (s *streamStorage) read(
+	group, consumer string, count int64) ([]*stream.Message, error) {
+	const op = "storage.memory.stream.Read"
+
+	messages := make([]*stream.Message, 0)
+	err := s.s.write(func(t *tables) error {
+		g, ok := t.groups[group]
+		if !ok {
+			return fmt.Errorf("%s: no such consumer group %q", op, group)
+		}
+
+		g = g.clone()
+		now := time.Now()
+		for _, message := range t.stream {
+			if int64(len(messages)) == count {
+				break
+			}
+
+			seq := sequence(message.ID)
+			if seq <= g.delivered {
+				continue
+			}
+
+			g.delivered = seq
+			g.pending[message.ID] = pendingMessage{
+				consumer:    consumer,
+				deliveredAt: now,
+			}
+			messages = append(messages, copyMessage(message))
+		}
+
+		t.groups[group] = g
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// Claim takes over the messages other consumers of the group have held
+// unacknowledged for at least minIdle, like the ones of a crashed replica.
+// Trimmed messages are dropped from the pending ones.
+func (s *streamStorage) Claim(ctx context.Context, group, consumer string,
+	minIdle time.Duration, count int64) ([]*stream.Message, error) {
+	const op = "storage.memory.stream.Claim"
+
+	messages := make([]*stream.Message, 0)
+	err := s.s.write(func(t *tables) error {
+		g, ok := t.groups[group]
+		if !ok {
+			return fmt.Errorf("%s: no such consumer group %q", op, group)
+		}
+
+		g = g.clone()
+		now := time.Now()
+		ids := slices.SortedFunc(maps.Keys(g.pending), func(a, b string) int {
+			return cmp.Compare(sequence(a), sequence(b))
+		})
+		for _, id := range ids {
+			if int64(len(messages)) == count {
+				break
+			}
+
+			if now.Sub(g.pending[id].deliveredAt) < minIdle {
+				continue
+			}
+
+			i := slices.IndexFunc(t.stream, func(m *stream.Message) bool {
+				return m.ID == id
+			})
+			if i < 0 {
+				delete(g.pending, id)
+				continue
+			}
+
+			g.pending[id] = pendingMessage{
+				consumer:    consumer,
+				deliveredAt: now,
+			}
+			messages = append(messages, copyMessage(t.stream[i]))
+		}
+
+		t.groups[group] = g
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (s *streamStorage) Ack(ctx context.Context, group, id string) error {
+	return s.s.write(func(t *tables) error {
+		g, ok := t.groups[group]
+		if !ok {
+			return nil
+		}
+
+		g = g.clone()
+		delete(g.pending, id)
+		t.groups[group] = g
+		return nil
+	})
+}
@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"cloud-notes/internal/storage/twofactor"
+
+	"github.com/google/uuid"
+)
+
+type twoFactorStorage struct {
+	s *Storage
+}
+
+func copyTwoFactor(twoFactor *twofactor.TwoFactor) *twofactor.TwoFactor {
+	c := *twoFactor
+	c.ConfirmedAt = timestampPtr(twoFactor.ConfirmedAt)
+	c.CreatedAt = timestamp(twoFactor.CreatedAt)
+	return &c
+}
+
+func (s *twoFactorStorage) Create(
+	ctx context.Context, twoFactor *twofactor.TwoFactor) error {
+	const op = "storage.memory.twofactor.Create"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.twoFactor[twoFactor.UserID]; ok {
+			return fmt.Errorf("%s: %w", op, ErrDuplicate)
+		}
+
+		if _, ok := t.users[twoFactor.UserID]; !ok {
+			return fmt.Errorf("%s: %w", op, ErrForeignKey)
+		}
+
+		t.twoFactor[twoFactor.UserID] = copyTwoFactor(twoFactor)
+		return nil
+	})
+}
+
+func (s *twoFactorStorage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) (*twofactor.TwoFactor, error) {
+	var found *twofactor.TwoFactor
+	s.s.read(func(t *tables) {
+		if twoFactor, ok := t.twoFactor[userID]; ok {
+			found = copyTwoFactor(twoFactor)
+		}
+	})
+
+	return found, nil
+}
+
+func (s *twoFactorStorage) Update(
+	ctx context.Context, twoFactor *twofactor.TwoFactor) error {
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.twoFactor[twoFactor.UserID]; ok {
+			t.twoFactor[twoFactor.UserID] = copyTwoFactor(twoFactor)
+		}
+
+		return nil
+	})
+}
+
+// UseStep records the step unless it or a later one was used already, so
+// a code is accepted once even when it is sent twice at the same time.
+func (s *twoFactorStorage) UseStep(
+	ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	var used bool
+	_ = s.s.write(func(t *tables) error {
+		twoFactor, ok := t.twoFactor[userID]
+		if !ok || twoFactor.LastUsedStep >= step {
+			return nil
+		}
+
+		c := copyTwoFactor(twoFactor)
+		c.LastUsedStep = step
+		t.twoFactor[userID] = c
+		used = true
+		return nil
+	})
+
+	return used, nil
+}
+
+func (s *twoFactorStorage) Delete(ctx context.Context, userID uuid.UUID) error {
+	return s.s.write(func(t *tables) error {
+		delete(t.twoFactor, userID)
+		return nil
+	})
+}
@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"cloud-notes/internal/storage/usage"
+
+	"github.com/google/uuid"
+)
+
+type usageStorage struct {
+	s *Storage
+}
+
+func (s *usageStorage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) (*usage.Usage, error) {
+	var found *usage.Usage
+	s.s.read(func(t *tables) {
+		if u, ok := t.usage[userID]; ok {
+			c := *u
+			found = &c
+		}
+	})
+
+	return found, nil
+}
+
+// Reserve adds the deltas unless a positive one would exceed its limit,
+// like the conditional update in Postgres.
+func (s *usageStorage) Reserve(ctx context.Context, userID uuid.UUID,
+	notes, bytes, maxNotes, maxBytes int64) (bool, error) {
+	const op = "storage.memory.usage.Reserve"
+
+	var reserved bool
+	err := s.s.write(func(t *tables) error {
+		if _, ok := t.users[userID]; !ok {
+			return fmt.Errorf("%s: %w", op, ErrForeignKey)
+		}
+
+		u, ok := t.usage[userID]
+		if !ok {
+			u = &usage.Usage{UserID: userID}
+		}
+
+		if (notes > 0 && u.Notes+notes > maxNotes) ||
+			(bytes > 0 && u.Bytes+bytes > maxBytes) {
+			t.usage[userID] = u
+			return nil
+		}
+
+		t.usage[userID] = &usage.Usage{
+			UserID: userID,
+			Notes:  u.Notes + notes,
+			Bytes:  u.Bytes + bytes,
+		}
+		reserved = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return reserved, nil
+}
+
+// Recalculate counts the notes the user pays for: their own and the ones
+// in the workspaces they own.
+func (s *usageStorage) Recalculate(
+	ctx context.Context, userID uuid.UUID) error {
+	const op = "storage.memory.usage.Recalculate"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.users[userID]; !ok {
+			return fmt.Errorf("%s: %w", op, ErrForeignKey)
+		}
+
+		u := &usage.Usage{UserID: userID}
+		for _, note := range t.notes {
+			if payer(t, note.UserID, note.WorkspaceID) == userID {
+				u.Notes++
+				u.Bytes += int64(len(ptrValue(note.Text)))
+			}
+		}
+
+		t.usage[userID] = u
+		return nil
+	})
+}
+
+func payer(t *tables, userID uuid.UUID, workspaceID *uuid.UUID) uuid.UUID {
+	if workspaceID != nil {
+		if workspace, ok := t.workspaces[*workspaceID]; ok {
+			return workspace.OwnerID
+		}
+	}
+
+	return userID
+}
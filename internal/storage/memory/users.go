@@ -0,0 +1,370 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"cloud-notes/internal/storage/exports"
+	"cloud-notes/internal/storage/identities"
+	"cloud-notes/internal/storage/outbox"
+	"cloud-notes/internal/storage/passkeys"
+	"cloud-notes/internal/storage/passwordhistory"
+	"cloud-notes/internal/storage/personaltokens"
+	"cloud-notes/internal/storage/recoverycodes"
+	"cloud-notes/internal/storage/users"
+
+	"github.com/google/uuid"
+)
+
+type usersStorage struct {
+	s *Storage
+}
+
+func copyUser(user *users.User) *users.User {
+	c := *user
+	c.CreatedAt = timestamp(user.CreatedAt)
+	c.Email = ptr(user.Email)
+	return &c
+}
+
+// checkUser enforces the unique login and email and the reference to the
+// role.
+func checkUser(t *tables, user *users.User) error {
+	if loginTaken(t, user) || emailTaken(t, user) {
+		return ErrDuplicate
+	}
+
+	if _, ok := t.roles[string(user.Role)]; !ok {
+		return ErrForeignKey
+	}
+
+	return nil
+}
+
+func loginTaken(t *tables, user *users.User) bool {
+	for _, other := range t.users {
+		if other.Login == user.Login && other.ID != user.ID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func emailTaken(t *tables, user *users.User) bool {
+	if user.Email == nil {
+		return false
+	}
+
+	for _, other := range t.users {
+		if other.Email != nil && *other.Email == *user.Email &&
+			other.ID != user.ID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *usersStorage) Create(ctx context.Context, user *users.User) error {
+	const op = "storage.memory.users.Create"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.users[user.ID]; ok {
+			return fmt.Errorf("%s: %w", op, ErrDuplicate)
+		}
+
+		err := checkUser(t, user)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		t.users[user.ID] = copyUser(user)
+		return nil
+	})
+}
+
+func (s *usersStorage) GetByID(
+	ctx context.Context, id uuid.UUID) (*users.User, error) {
+	var found *users.User
+	s.s.read(func(t *tables) {
+		if user, ok := t.users[id]; ok {
+			found = copyUser(user)
+		}
+	})
+
+	return found, nil
+}
+
+func (s *usersStorage) GetByIDs(
+	ctx context.Context, ids []uuid.UUID) ([]*users.User, error) {
+	found := make([]*users.User, 0, len(ids))
+	s.s.read(func(t *tables) {
+		for _, id := range ids {
+			if user, ok := t.users[id]; ok {
+				found = append(found, copyUser(user))
+			}
+		}
+	})
+
+	return found, nil
+}
+
+func (s *usersStorage) GetByLogin(
+	ctx context.Context, login string) (*users.User, error) {
+	var found *users.User
+	s.s.read(func(t *tables) {
+		for _, user := range t.users {
+			if user.Login == login {
+				found = copyUser(user)
+			}
+		}
+	})
+
+	return found, nil
+}
+
+func (s *usersStorage) GetByEmail(
+	ctx context.Context, email string) (*users.User, error) {
+	var found *users.User
+	s.s.read(func(t *tables) {
+		for _, user := range t.users {
+			if user.Email != nil && *user.Email == email {
+				found = copyUser(user)
+			}
+		}
+	})
+
+	return found, nil
+}
+
+// GetForUpdate needs no lock of its own, a transaction holds the storage's.
+func (s *usersStorage) GetForUpdate(
+	ctx context.Context, id uuid.UUID) (*users.User, error) {
+	return s.GetByID(ctx, id)
+}
+
+func matchUser(user *users.User, filter *users.Filter) bool {
+	if filter == nil {
+		return true
+	}
+
+	if filter.Query != nil {
+		query := strings.ToLower(*filter.Query)
+		if !strings.Contains(strings.ToLower(user.Login), query) &&
+			!strings.Contains(strings.ToLower(ptrValue(user.Email)), query) &&
+			!strings.Contains(strings.ToLower(user.FirstName), query) {
+			return false
+		}
+	}
+
+	if filter.Status != nil && user.Status != *filter.Status {
+		return false
+	}
+
+	if filter.Role != nil && user.Role != *filter.Role {
+		return false
+	}
+
+	if filter.CreatedAfter != nil &&
+		user.CreatedAt.Before(timestamp(*filter.CreatedAfter)) {
+		return false
+	}
+
+	return true
+}
+
+func (s *usersStorage) List(ctx context.Context,
+	filter *users.Filter, limit, offset *uint64) ([]*users.User, error) {
+	found := make([]*users.User, 0)
+	s.s.read(func(t *tables) {
+		for _, user := range t.users {
+			if matchUser(user, filter) {
+				found = append(found, copyUser(user))
+			}
+		}
+	})
+
+	// Postgres compares UUIDs byte by byte.
+	slices.SortFunc(found, func(a, b *users.User) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+
+	if offset != nil {
+		found = found[min(*offset, uint64(len(found))):]
+	}
+	if limit != nil {
+		found = found[:min(*limit, uint64(len(found)))]
+	}
+
+	return found, nil
+}
+
+func (s *usersStorage) Count(
+	ctx context.Context, filter *users.Filter) (uint64, error) {
+	var count uint64
+	s.s.read(func(t *tables) {
+		for _, user := range t.users {
+			if matchUser(user, filter) {
+				count++
+			}
+		}
+	})
+
+	return count, nil
+}
+
+func (s *usersStorage) Update(ctx context.Context, user *users.User) error {
+	const op = "storage.memory.users.Update"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.users[user.ID]; !ok {
+			return nil
+		}
+
+		err := checkUser(t, user)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		t.users[user.ID] = copyUser(user)
+		return nil
+	})
+}
+
+// Delete fails while the user has sessions, like the foreign key without a
+// cascade does. Everything else the user has goes with them, like the
+// cascades do: notes, owned workspaces, memberships, the sessions the user
+// opened as someone else, credentials, clients, webhooks and the rest.
+func (s *usersStorage) Delete(ctx context.Context, id uuid.UUID) error {
+	const op = "storage.memory.users.Delete"
+
+	return s.s.write(func(t *tables) error {
+		for _, session := range t.sessions {
+			if session.UserID == id {
+				return fmt.Errorf("%s: %w", op, ErrForeignKey)
+			}
+		}
+
+		deleteUser(t, id)
+		return nil
+	})
+}
+
+// Purge removes the user with their notes and sessions, like Delete does
+// with the workspaces the user still owns. Notes the user wrote in other
+// people's workspaces stay there and pass to the workspace owner.
+func (s *usersStorage) Purge(ctx context.Context, id uuid.UUID) error {
+	return s.s.write(func(t *tables) error {
+		for noteID, note := range t.notes {
+			if note.UserID != id || note.WorkspaceID == nil {
+				continue
+			}
+
+			workspace := t.workspaces[*note.WorkspaceID]
+			if workspace.OwnerID != id {
+				c := *note
+				c.UserID = workspace.OwnerID
+				t.notes[noteID] = &c
+			}
+		}
+
+		for sessionID, session := range t.sessions {
+			if session.UserID == id {
+				delete(t.sessions, sessionID)
+			}
+		}
+
+		deleteUser(t, id)
+		return nil
+	})
+}
+
+func deleteUser(t *tables, id uuid.UUID) {
+	for noteID, note := range t.notes {
+		if note.UserID == id {
+			delete(t.notes, noteID)
+		}
+	}
+
+	for workspaceID, workspace := range t.workspaces {
+		if workspace.OwnerID == id {
+			deleteWorkspace(t, workspaceID)
+		}
+	}
+
+	for key := range t.members {
+		if key.userID == id {
+			delete(t.members, key)
+		}
+	}
+
+	for sessionID, session := range t.sessions {
+		if session.ImpersonatorID != nil && *session.ImpersonatorID == id {
+			delete(t.sessions, sessionID)
+		}
+	}
+
+	for clientID, client := range t.oauthClients {
+		if client.OwnerID == id {
+			deleteOAuthClient(t, clientID)
+		}
+	}
+
+	for webhookID, webhook := range t.webhooks {
+		if webhook.UserID == id {
+			deleteWebhook(t, webhookID)
+		}
+	}
+
+	for userID, block := range t.blocks {
+		if block.BlockedBy != nil && *block.BlockedBy == id {
+			c := copyBlock(block)
+			c.BlockedBy = nil
+			t.blocks[userID] = c
+		}
+	}
+
+	deleteWhere(t.exports, func(e *exports.Export) bool {
+		return e.UserID == id
+	})
+	deleteWhere(t.identities, func(i *identities.Identity) bool {
+		return i.UserID == id
+	})
+	deleteWhere(t.outbox, func(e *outbox.Event) bool {
+		return e.UserID == id
+	})
+	deleteWhere(t.passkeys, func(p *passkeys.Passkey) bool {
+		return p.UserID == id
+	})
+	deleteWhere(t.passwordHistory, func(e *passwordhistory.Entry) bool {
+		return e.UserID == id
+	})
+	deleteWhere(t.personalTokens, func(p *personaltokens.PersonalToken) bool {
+		return p.UserID == id
+	})
+	deleteWhere(t.recoveryCodes, func(c *recoverycodes.RecoveryCode) bool {
+		return c.UserID == id
+	})
+
+	delete(t.blocks, id)
+	delete(t.deletions, id)
+	delete(t.quotas, id)
+	delete(t.twoFactor, id)
+	delete(t.usage, id)
+	delete(t.users, id)
+}
+
+func deleteWhere[K comparable, V any](m map[K]V, match func(V) bool) {
+	maps.DeleteFunc(m, func(_ K, v V) bool {
+		return match(v)
+	})
+}
@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"cloud-notes/internal/storage/verifications"
+
+	"github.com/google/uuid"
+)
+
+// The verifications are kept in Redis and expire there.
+type verificationsStorage struct {
+	s *Storage
+}
+
+func (s *verificationsStorage) Create(ctx context.Context,
+	verification *verifications.Verification) error {
+	return s.s.write(func(t *tables) error {
+		c := *verification
+		t.verifications[verification.UserID] = &c
+		return nil
+	})
+}
+
+func (s *verificationsStorage) GetByUserID(ctx context.Context,
+	userID uuid.UUID) (*verifications.Verification, error) {
+	var found *verifications.Verification
+	s.s.read(func(t *tables) {
+		verification, ok := t.verifications[userID]
+		if ok && verification.ExpiresAt.After(time.Now()) {
+			c := *verification
+			found = &c
+		}
+	})
+
+	return found, nil
+}
+
+func (s *verificationsStorage) Delete(
+	ctx context.Context, userID uuid.UUID) error {
+	return s.s.write(func(t *tables) error {
+		delete(t.verifications, userID)
+		return nil
+	})
+}
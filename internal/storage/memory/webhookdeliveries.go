@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"cloud-notes/internal/storage/webhookdeliveries"
+
+	"github.com/google/uuid"
+)
+
+type webhookDeliveriesStorage struct {
+	s *Storage
+}
+
+func copyDelivery(
+	delivery *webhookdeliveries.Delivery) *webhookdeliveries.Delivery {
+	c := *delivery
+	c.Payload = bytes.Clone(delivery.Payload)
+	c.NextAttemptAt = timestamp(delivery.NextAttemptAt)
+	c.ResponseCode = ptr(delivery.ResponseCode)
+	c.Error = ptr(delivery.Error)
+	c.ReplayOf = ptr(delivery.ReplayOf)
+	c.DeliveredAt = timestampPtr(delivery.DeliveredAt)
+	c.CreatedAt = timestamp(delivery.CreatedAt)
+	return &c
+}
+
+// Create skips a second delivery of the same event to the webhook, like
+// the unique index does. Replays are always added.
+func (s *webhookDeliveriesStorage) Create(
+	ctx context.Context, delivery *webhookdeliveries.Delivery) error {
+	const op = "storage.memory.webhookdeliveries.Create"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.webhookDeliveries[delivery.ID]; ok {
+			return fmt.Errorf("%s: %w", op, ErrDuplicate)
+		}
+
+		if _, ok := t.webhooks[delivery.WebhookID]; !ok {
+			return fmt.Errorf("%s: %w", op, ErrForeignKey)
+		}
+
+		if delivery.ReplayOf == nil {
+			for _, other := range t.webhookDeliveries {
+				if other.ReplayOf == nil &&
+					other.WebhookID == delivery.WebhookID &&
+					other.EventID == delivery.EventID {
+					return nil
+				}
+			}
+		}
+
+		t.webhookDeliveries[delivery.ID] = copyDelivery(delivery)
+		return nil
+	})
+}
+
+func (s *webhookDeliveriesStorage) GetByID(ctx context.Context,
+	id uuid.UUID) (*webhookdeliveries.Delivery, error) {
+	var found *webhookdeliveries.Delivery
+	s.s.read(func(t *tables) {
+		if delivery, ok := t.webhookDeliveries[id]; ok {
+			found = copyDelivery(delivery)
+		}
+	})
+
+	return found, nil
+}
+
+func (s *webhookDeliveriesStorage) GetByWebhookID(ctx context.Context,
+	webhookID uuid.UUID,
+	limit, offset uint64) ([]*webhookdeliveries.Delivery, error) {
+	found := make([]*webhookdeliveries.Delivery, 0)
+	s.s.read(func(t *tables) {
+		for _, delivery := range t.webhookDeliveries {
+			if delivery.WebhookID == webhookID {
+				found = append(found, copyDelivery(delivery))
+			}
+		}
+	})
+
+	slices.SortFunc(found, func(a, b *webhookdeliveries.Delivery) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	found = found[min(offset, uint64(len(found))):]
+	return found[:min(limit, uint64(len(found)))], nil
+}
+
+// Claim leases the due pending deliveries until leaseUntil, like the
+// Postgres storage does.
+func (s *webhookDeliveriesStorage) Claim(ctx context.Context,
+	now, leaseUntil time.Time,
+	limit uint64) ([]*webhookdeliveries.Delivery, error) {
+	claimed := make([]*webhookdeliveries.Delivery, 0)
+	_ = s.s.write(func(t *tables) error {
+		due := make([]*webhookdeliveries.Delivery, 0)
+		for _, delivery := range t.webhookDeliveries {
+			if delivery.Status == webhookdeliveries.StatusPending &&
+				!delivery.NextAttemptAt.After(timestamp(now)) {
+				due = append(due, delivery)
+			}
+		}
+
+		slices.SortFunc(due, func(a, b *webhookdeliveries.Delivery) int {
+			return a.NextAttemptAt.Compare(b.NextAttemptAt)
+		})
+
+		for _, delivery := range due[:min(limit, uint64(len(due)))] {
+			c := copyDelivery(delivery)
+			c.NextAttemptAt = timestamp(leaseUntil)
+			t.webhookDeliveries[delivery.ID] = c
+			claimed = append(claimed, copyDelivery(c))
+		}
+
+		return nil
+	})
+
+	return claimed, nil
+}
+
+// Update records the outcome of an attempt, the rest stays.
+func (s *webhookDeliveriesStorage) Update(
+	ctx context.Context, delivery *webhookdeliveries.Delivery) error {
+	return s.s.write(func(t *tables) error {
+		old, ok := t.webhookDeliveries[delivery.ID]
+		if !ok {
+			return nil
+		}
+
+		c := copyDelivery(old)
+		c.Status = delivery.Status
+		c.Attempts = delivery.Attempts
+		c.NextAttemptAt = timestamp(delivery.NextAttemptAt)
+		c.ResponseCode = ptr(delivery.ResponseCode)
+		c.Error = ptr(delivery.Error)
+		c.DeliveredAt = timestampPtr(delivery.DeliveredAt)
+		t.webhookDeliveries[delivery.ID] = c
+		return nil
+	})
+}
+
+func (s *webhookDeliveriesStorage) DeleteBefore(
+	ctx context.Context, before time.Time) error {
+	return s.s.write(func(t *tables) error {
+		for id, delivery := range t.webhookDeliveries {
+			if delivery.CreatedAt.Before(timestamp(before)) &&
+				delivery.Status != webhookdeliveries.StatusPending {
+				delete(t.webhookDeliveries, id)
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"cloud-notes/internal/storage/webhooks"
+
+	"github.com/google/uuid"
+)
+
+type webhooksStorage struct {
+	s *Storage
+}
+
+func copyWebhook(webhook *webhooks.Webhook) *webhooks.Webhook {
+	c := *webhook
+	c.Events = slices.Clone(webhook.Events)
+	c.CreatedAt = timestamp(webhook.CreatedAt)
+	c.UpdatedAt = timestampPtr(webhook.UpdatedAt)
+	return &c
+}
+
+func (s *webhooksStorage) Create(
+	ctx context.Context, webhook *webhooks.Webhook) error {
+	const op = "storage.memory.webhooks.Create"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.webhooks[webhook.ID]; ok {
+			return fmt.Errorf("%s: %w", op, ErrDuplicate)
+		}
+
+		if _, ok := t.users[webhook.UserID]; !ok {
+			return fmt.Errorf("%s: %w", op, ErrForeignKey)
+		}
+
+		t.webhooks[webhook.ID] = copyWebhook(webhook)
+		return nil
+	})
+}
+
+func (s *webhooksStorage) GetByID(
+	ctx context.Context, id uuid.UUID) (*webhooks.Webhook, error) {
+	var found *webhooks.Webhook
+	s.s.read(func(t *tables) {
+		if webhook, ok := t.webhooks[id]; ok {
+			found = copyWebhook(webhook)
+		}
+	})
+
+	return found, nil
+}
+
+func (s *webhooksStorage) find(
+	match func(webhook *webhooks.Webhook) bool) []*webhooks.Webhook {
+	found := make([]*webhooks.Webhook, 0)
+	s.s.read(func(t *tables) {
+		for _, webhook := range t.webhooks {
+			if match(webhook) {
+				found = append(found, copyWebhook(webhook))
+			}
+		}
+	})
+
+	slices.SortFunc(found, func(a, b *webhooks.Webhook) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return found
+}
+
+func (s *webhooksStorage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) ([]*webhooks.Webhook, error) {
+	return s.find(func(webhook *webhooks.Webhook) bool {
+		return webhook.UserID == userID
+	}), nil
+}
+
+func (s *webhooksStorage) GetSubscribed(ctx context.Context, userID uuid.UUID,
+	workspaceID *uuid.UUID, eventType string) ([]*webhooks.Webhook, error) {
+	members := make(map[uuid.UUID]bool)
+	members[userID] = true
+	if workspaceID != nil {
+		s.s.read(func(t *tables) {
+			for key := range t.members {
+				if key.workspaceID == *workspaceID {
+					members[key.userID] = true
+				}
+			}
+		})
+	}
+
+	return s.find(func(webhook *webhooks.Webhook) bool {
+		return members[webhook.UserID] && webhook.Active &&
+			slices.Contains(webhook.Events, eventType)
+	}), nil
+}
+
+func (s *webhooksStorage) CountByUserID(
+	ctx context.Context, userID uuid.UUID) (uint64, error) {
+	found, _ := s.GetByUserID(ctx, userID)
+	return uint64(len(found)), nil
+}
+
+// Update changes what the owner can edit, the rest stays.
+func (s *webhooksStorage) Update(
+	ctx context.Context, webhook *webhooks.Webhook) error {
+	return s.s.write(func(t *tables) error {
+		old, ok := t.webhooks[webhook.ID]
+		if !ok {
+			return nil
+		}
+
+		c := copyWebhook(old)
+		c.URL = webhook.URL
+		c.Secret = webhook.Secret
+		c.Events = slices.Clone(webhook.Events)
+		c.Active = webhook.Active
+		c.UpdatedAt = timestampPtr(webhook.UpdatedAt)
+		c.Failures = webhook.Failures
+		t.webhooks[webhook.ID] = c
+		return nil
+	})
+}
+
+func (s *webhooksStorage) RecordFailure(ctx context.Context,
+	id uuid.UUID, disableAfter int, now time.Time) (bool, error) {
+	var disabled bool
+	err := s.s.write(func(t *tables) error {
+		old, ok := t.webhooks[id]
+		if !ok {
+			return nil
+		}
+
+		c := copyWebhook(old)
+		c.Failures++
+		if c.Active && c.Failures >= disableAfter {
+			c.Active = false
+			c.UpdatedAt = timestampPtr(&now)
+			disabled = true
+		}
+
+		t.webhooks[id] = c
+		return nil
+	})
+
+	return disabled, err
+}
+
+func (s *webhooksStorage) ResetFailures(
+	ctx context.Context, id uuid.UUID) error {
+	return s.s.write(func(t *tables) error {
+		old, ok := t.webhooks[id]
+		if !ok || old.Failures == 0 {
+			return nil
+		}
+
+		c := copyWebhook(old)
+		c.Failures = 0
+		t.webhooks[id] = c
+		return nil
+	})
+}
+
+// Delete removes the webhook with its deliveries.
+func (s *webhooksStorage) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.s.write(func(t *tables) error {
+		deleteWebhook(t, id)
+		return nil
+	})
+}
+
+func deleteWebhook(t *tables, id uuid.UUID) {
+	for deliveryID, delivery := range t.webhookDeliveries {
+		if delivery.WebhookID == id {
+			delete(t.webhookDeliveries, deliveryID)
+		}
+	}
+
+	delete(t.webhooks, id)
+}
@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"cloud-notes/internal/storage/workspacemembers"
+
+	"github.com/google/uuid"
+)
+
+// memberKey is the primary key of a workspace member.
+type memberKey struct {
+	workspaceID uuid.UUID
+	userID      uuid.UUID
+}
+
+type workspaceMembersStorage struct {
+	s *Storage
+}
+
+func copyMember(member *workspacemembers.Member) *workspacemembers.Member {
+	c := *member
+	c.CreatedAt = timestamp(member.CreatedAt)
+	return &c
+}
+
+func (s *workspaceMembersStorage) Create(
+	ctx context.Context, member *workspacemembers.Member) error {
+	const op = "storage.memory.workspacemembers.Create"
+
+	return s.s.write(func(t *tables) error {
+		key := memberKey{member.WorkspaceID, member.UserID}
+		if _, ok := t.members[key]; ok {
+			return fmt.Errorf("%s: %w", op, ErrDuplicate)
+		}
+
+		_, workspaceFound := t.workspaces[member.WorkspaceID]
+		_, userFound := t.users[member.UserID]
+		if !workspaceFound || !userFound {
+			return fmt.Errorf("%s: %w", op, ErrForeignKey)
+		}
+
+		t.members[key] = copyMember(member)
+		return nil
+	})
+}
+
+func (s *workspaceMembersStorage) Get(ctx context.Context,
+	workspaceID, userID uuid.UUID) (*workspacemembers.Member, error) {
+	var found *workspacemembers.Member
+	s.s.read(func(t *tables) {
+		if member, ok := t.members[memberKey{workspaceID, userID}]; ok {
+			found = copyMember(member)
+		}
+	})
+
+	return found, nil
+}
+
+func (s *workspaceMembersStorage) GetByWorkspaceID(ctx context.Context,
+	workspaceID uuid.UUID) ([]*workspacemembers.Member, error) {
+	found := make([]*workspacemembers.Member, 0)
+	s.s.read(func(t *tables) {
+		for key, member := range t.members {
+			if key.workspaceID == workspaceID {
+				found = append(found, copyMember(member))
+			}
+		}
+	})
+
+	slices.SortFunc(found, func(a, b *workspacemembers.Member) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return found, nil
+}
+
+// Update changes only the role, like the Postgres storage.
+func (s *workspaceMembersStorage) Update(
+	ctx context.Context, member *workspacemembers.Member) error {
+	return s.s.write(func(t *tables) error {
+		key := memberKey{member.WorkspaceID, member.UserID}
+		stored, ok := t.members[key]
+		if !ok {
+			return nil
+		}
+
+		c := *stored
+		c.Role = member.Role
+		t.members[key] = &c
+		return nil
+	})
+}
+
+func (s *workspaceMembersStorage) Delete(
+	ctx context.Context, workspaceID, userID uuid.UUID) error {
+	return s.s.write(func(t *tables) error {
+		delete(t.members, memberKey{workspaceID, userID})
+		return nil
+	})
+}
@@ -0,0 +1,180 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+
+	"cloud-notes/internal/storage/workspacemembers"
+	"cloud-notes/internal/storage/workspaces"
+
+	"github.com/google/uuid"
+)
+
+type workspacesStorage struct {
+	s *Storage
+}
+
+func copyWorkspace(workspace *workspaces.Workspace) *workspaces.Workspace {
+	c := *workspace
+	c.CreatedAt = timestamp(workspace.CreatedAt)
+	return &c
+}
+
+func (s *workspacesStorage) Create(
+	ctx context.Context, workspace *workspaces.Workspace) error {
+	const op = "storage.memory.workspaces.Create"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.workspaces[workspace.ID]; ok {
+			return fmt.Errorf("%s: %w", op, ErrDuplicate)
+		}
+
+		if _, ok := t.users[workspace.OwnerID]; !ok {
+			return fmt.Errorf("%s: %w", op, ErrForeignKey)
+		}
+
+		t.workspaces[workspace.ID] = copyWorkspace(workspace)
+		return nil
+	})
+}
+
+func (s *workspacesStorage) GetByID(
+	ctx context.Context, id uuid.UUID) (*workspaces.Workspace, error) {
+	var found *workspaces.Workspace
+	s.s.read(func(t *tables) {
+		if workspace, ok := t.workspaces[id]; ok {
+			found = copyWorkspace(workspace)
+		}
+	})
+
+	return found, nil
+}
+
+func (s *workspacesStorage) GetByMemberID(ctx context.Context,
+	userID uuid.UUID) ([]*workspaces.Workspace, error) {
+	found := make([]*workspaces.Workspace, 0)
+	s.s.read(func(t *tables) {
+		for key := range t.members {
+			if key.userID == userID {
+				found = append(found,
+					copyWorkspace(t.workspaces[key.workspaceID]))
+			}
+		}
+	})
+
+	slices.SortFunc(found, func(a, b *workspaces.Workspace) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return found, nil
+}
+
+func (s *workspacesStorage) Update(
+	ctx context.Context, workspace *workspaces.Workspace) error {
+	const op = "storage.memory.workspaces.Update"
+
+	return s.s.write(func(t *tables) error {
+		if _, ok := t.workspaces[workspace.ID]; !ok {
+			return nil
+		}
+
+		if _, ok := t.users[workspace.OwnerID]; !ok {
+			return fmt.Errorf("%s: %w", op, ErrForeignKey)
+		}
+
+		t.workspaces[workspace.ID] = copyWorkspace(workspace)
+		return nil
+	})
+}
+
+// TransferOwned picks the heirs the way the Postgres storage does: editors
+// before viewers, then the earliest member, then the smallest id.
+func (s *workspacesStorage) TransferOwned(
+	ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	heirs := make([]uuid.UUID, 0)
+	err := s.s.write(func(t *tables) error {
+		for id, workspace := range t.workspaces {
+			if workspace.OwnerID != userID {
+				continue
+			}
+
+			heir := pickHeir(t, id, userID)
+			if heir == nil {
+				continue
+			}
+
+			c := *workspace
+			c.OwnerID = heir.UserID
+			t.workspaces[id] = &c
+
+			m := *heir
+			m.Role = workspacemembers.RoleOwner
+			t.members[memberKey{id, heir.UserID}] = &m
+
+			heirs = append(heirs, heir.UserID)
+		}
+
+		return nil
+	})
+
+	return heirs, err
+}
+
+func pickHeir(t *tables, workspaceID,
+	ownerID uuid.UUID) *workspacemembers.Member {
+	var heir *workspacemembers.Member
+	for key, member := range t.members {
+		if key.workspaceID != workspaceID || key.userID == ownerID {
+			continue
+		}
+
+		if heir == nil || compareHeirs(member, heir) < 0 {
+			heir = member
+		}
+	}
+
+	return heir
+}
+
+func compareHeirs(a, b *workspacemembers.Member) int {
+	aEditor := a.Role == workspacemembers.RoleEditor
+	bEditor := b.Role == workspacemembers.RoleEditor
+	if aEditor != bEditor {
+		if aEditor {
+			return -1
+		}
+		return 1
+	}
+
+	if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+		return c
+	}
+
+	return bytes.Compare(a.UserID[:], b.UserID[:])
+}
+
+// Delete removes the workspace together with its members and notes.
+func (s *workspacesStorage) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.s.write(func(t *tables) error {
+		deleteWorkspace(t, id)
+		return nil
+	})
+}
+
+func deleteWorkspace(t *tables, id uuid.UUID) {
+	for noteID, note := range t.notes {
+		if note.WorkspaceID != nil && *note.WorkspaceID == id {
+			delete(t.notes, noteID)
+		}
+	}
+
+	for key := range t.members {
+		if key.workspaceID == id {
+			delete(t.members, key)
+		}
+	}
+
+	delete(t.workspaces, id)
+}
@@ -6,12 +6,24 @@ import (
 	"github.com/google/uuid"
 )
 
+// Note belongs to its author's personal space, or to a workspace when
+// WorkspaceID is set. UserID is the author either way.
 type Note struct {
-	ID        uuid.UUID
-	UserID    uuid.UUID
-	Title     *string
-	Text      *string
-	Pinned    bool
-	UpdatedAt *time.Time
-	CreatedAt time.Time
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Title       *string
+	Text        *string
+	Pinned      bool
+	UpdatedAt   *time.Time
+	CreatedAt   time.Time
+	WorkspaceID *uuid.UUID
+}
+
+// Filter selects the notes of one space: the user's personal notes when
+// WorkspaceID is nil, or the workspace's notes otherwise. Query matches a
+// part of the title or the text, case-insensitively.
+type Filter struct {
+	UserID      uuid.UUID
+	WorkspaceID *uuid.UUID
+	Query       *string
 }
@@ -9,7 +9,10 @@ import (
 type Storage interface {
 	Create(ctx context.Context, note *Note) error
 	GetByID(ctx context.Context, id uuid.UUID) (*Note, error)
+	GetForUpdate(ctx context.Context, id uuid.UUID) (*Note, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*Note, error)
+	Find(ctx context.Context, filter *Filter) ([]*Note, error)
+	Count(ctx context.Context, userID *uuid.UUID) (uint64, error)
 	Update(ctx context.Context, note *Note) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	Delete(ctx context.Context, id uuid.UUID) (bool, error)
 }
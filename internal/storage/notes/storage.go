@@ -14,11 +14,11 @@ import (
 
 type storage struct {
 	log logger.Logger
-	pg  *postgres.Postgres
+	pg  postgres.DB
 	rd  *redis.Redis
 }
 
-func New(log logger.Logger, pg *postgres.Postgres, rd *redis.Redis) Storage {
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
 	return &storage{
 		log: log,
 		pg:  pg,
@@ -33,7 +33,7 @@ func (s *storage) scan(ctx context.Context, row postgres.Row) (*Note, error) {
 	note := new(Note)
 	err := row.Scan(
 		&note.ID, &note.UserID, &note.Title, &note.Text,
-		&note.Pinned, &note.UpdatedAt, &note.CreatedAt)
+		&note.Pinned, &note.UpdatedAt, &note.CreatedAt, &note.WorkspaceID)
 	if err != nil && errors.Is(err, postgres.ErrNoRows) {
 		return nil, nil
 	} else if err != nil {
@@ -49,11 +49,12 @@ func (s *storage) Create(ctx context.Context, note *Note) error {
 	log := s.log.With(logger.String("op", op))
 
 	const sql = `INSERT INTO notes (id, user_id, title, text, pinned, 
-                 updated_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+                 updated_at, created_at, workspace_id) 
+                 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
 
 	_, err := s.pg.Exec(
 		ctx, sql, note.ID, note.UserID, note.Title, note.Text,
-		note.Pinned, note.UpdatedAt, note.CreatedAt)
+		note.Pinned, note.UpdatedAt, note.CreatedAt, note.WorkspaceID)
 	if err != nil {
 		log.ErrorContext(ctx, "", logger.Error(err))
 		return fmt.Errorf("%s: %w", op, err)
@@ -79,6 +80,26 @@ func (s *storage) GetByID(ctx context.Context, id uuid.UUID) (*Note, error) {
 	return note, nil
 }
 
+// GetForUpdate locks the note until the transaction ends, so the changes
+// made from what it read aren't raced by another one.
+func (s *storage) GetForUpdate(
+	ctx context.Context, id uuid.UUID) (*Note, error) {
+	const op = "storage.notes.GetForUpdate"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM notes WHERE id = $1 FOR UPDATE`
+
+	row := s.pg.QueryRow(ctx, sql, id)
+
+	note, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return note, nil
+}
+
 func (s *storage) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*Note, error) {
 	const op = "storage.notes.GetByUserID"
 	log := s.log.With(logger.String("op", op))
@@ -105,17 +126,69 @@ func (s *storage) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*Note, e
 	return notes, nil
 }
 
+func (s *storage) Find(ctx context.Context, filter *Filter) ([]*Note, error) {
+	const op = "storage.notes.Find"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM notes 
+                 WHERE (($2::UUID IS NULL AND workspace_id IS NULL 
+                 AND user_id = $1) OR workspace_id = $2) 
+                 AND ($3::TEXT IS NULL 
+                 OR strpos(lower(coalesce(title, '')), lower($3)) > 0 
+                 OR strpos(lower(coalesce(text, '')), lower($3)) > 0) 
+                 ORDER BY pinned DESC, created_at DESC`
+
+	rows, err := s.pg.Query(
+		ctx, sql, filter.UserID, filter.WorkspaceID, filter.Query)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	notes := make([]*Note, 0)
+	for rows.Next() {
+		note, err := s.scan(ctx, rows)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		notes = append(notes, note)
+	}
+
+	return notes, nil
+}
+
+// Count counts the notes of the user, or all notes when userID is nil.
+func (s *storage) Count(
+	ctx context.Context, userID *uuid.UUID) (uint64, error) {
+	const op = "storage.notes.Count"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT COUNT(*) FROM notes 
+                 WHERE $1::UUID IS NULL OR user_id = $1`
+
+	var count uint64
+	err := s.pg.QueryRow(ctx, sql, userID).Scan(&count)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
 func (s *storage) Update(ctx context.Context, note *Note) error {
 	const op = "storage.notes.Update"
 	log := s.log.With(logger.String("op", op))
 
 	const sql = `UPDATE notes SET user_id = $1, title = $2, 
                  text = $3, pinned = $4, updated_at = $5, 
-                 created_at = $6 WHERE id = $7`
+                 created_at = $6, workspace_id = $7 WHERE id = $8`
 
 	_, err := s.pg.Exec(
-		ctx, sql, note.UserID, note.Title, note.Text,
-		note.Pinned, note.UpdatedAt, note.CreatedAt, note.ID)
+		ctx, sql, note.UserID, note.Title, note.Text, note.Pinned,
+		note.UpdatedAt, note.CreatedAt, note.WorkspaceID, note.ID)
 	if err != nil {
 		log.ErrorContext(ctx, "", logger.Error(err))
 		return fmt.Errorf("%s: %w", op, err)
@@ -124,17 +197,18 @@ func (s *storage) Update(ctx context.Context, note *Note) error {
 	return nil
 }
 
-func (s *storage) Delete(ctx context.Context, id uuid.UUID) error {
+// Delete reports whether the note was there to delete.
+func (s *storage) Delete(ctx context.Context, id uuid.UUID) (bool, error) {
 	const op = "storage.notes.Delete"
 	log := s.log.With(logger.String("op", op))
 
 	const sql = `DELETE FROM notes WHERE id = $1`
 
-	_, err := s.pg.Exec(ctx, sql, id)
+	command, err := s.pg.Exec(ctx, sql, id)
 	if err != nil {
 		log.ErrorContext(ctx, "", logger.Error(err))
-		return fmt.Errorf("%s: %w", op, err)
+		return false, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil
+	return command.RowsAffected() == 1, nil
 }
@@ -0,0 +1,19 @@
+package oauthclients
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Client is a third-party application registered by a user. Public clients
+// have no secret and rely on PKCE alone.
+type Client struct {
+	ID           uuid.UUID
+	OwnerID      uuid.UUID
+	Name         string
+	SecretHash   *string
+	RedirectURIs []string
+	Scopes       []string
+	CreatedAt    time.Time
+}
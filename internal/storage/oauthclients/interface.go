@@ -0,0 +1,14 @@
+package oauthclients
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Storage interface {
+	Create(ctx context.Context, client *Client) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Client, error)
+	GetByOwnerID(ctx context.Context, ownerID uuid.UUID) ([]*Client, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
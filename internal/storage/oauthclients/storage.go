@@ -0,0 +1,124 @@
+package oauthclients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) scan(ctx context.Context, row postgres.Row) (*Client, error) {
+	const op = "storage.oauthclients.scan"
+	log := s.log.With(logger.String("op", op))
+
+	client := new(Client)
+	err := row.Scan(&client.ID, &client.OwnerID, &client.Name,
+		&client.SecretHash, &client.RedirectURIs, &client.Scopes,
+		&client.CreatedAt)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return client, nil
+}
+
+func (s *storage) Create(ctx context.Context, client *Client) error {
+	const op = "storage.oauthclients.Create"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `INSERT INTO oauth_clients (id, owner_id, name, 
+                 secret_hash, redirect_uris, scopes, created_at) 
+                 VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := s.pg.Exec(ctx, sql, client.ID, client.OwnerID, client.Name,
+		client.SecretHash, client.RedirectURIs, client.Scopes,
+		client.CreatedAt)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) GetByID(ctx context.Context, id uuid.UUID) (*Client, error) {
+	const op = "storage.oauthclients.GetByID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM oauth_clients WHERE id = $1`
+
+	row := s.pg.QueryRow(ctx, sql, id)
+
+	client, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return client, nil
+}
+
+func (s *storage) GetByOwnerID(
+	ctx context.Context, ownerID uuid.UUID) ([]*Client, error) {
+	const op = "storage.oauthclients.GetByOwnerID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM oauth_clients WHERE owner_id = $1 
+                 ORDER BY created_at`
+
+	rows, err := s.pg.Query(ctx, sql, ownerID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	clients := make([]*Client, 0)
+	for rows.Next() {
+		client, err := s.scan(ctx, rows)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		clients = append(clients, client)
+	}
+
+	return clients, nil
+}
+
+func (s *storage) Delete(ctx context.Context, id uuid.UUID) error {
+	const op = "storage.oauthclients.Delete"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `DELETE FROM oauth_clients WHERE id = $1`
+
+	_, err := s.pg.Exec(ctx, sql, id)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event is a change waiting to be dispatched. It is written together with
+// the change, so it exists if and only if the change was committed.
+// UserID is whose event it is, like the owner of the changed note.
+// An event that couldn't be dispatched MaxAttempts times gets FailedAt and
+// is skipped until retried.
+type Event struct {
+	ID           uuid.UUID
+	Type         string
+	UserID       uuid.UUID
+	Payload      json.RawMessage
+	CreatedAt    time.Time
+	DispatchedAt *time.Time
+	Attempts     int
+	RunAt        time.Time
+	Error        *string
+	FailedAt     *time.Time
+}
@@ -0,0 +1,19 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Storage interface {
+	Create(ctx context.Context, event *Event) error
+	Claim(ctx context.Context, now, leaseUntil time.Time,
+		limit uint64) ([]*Event, error)
+	MarkDispatched(ctx context.Context, id uuid.UUID, at time.Time) error
+	Fail(ctx context.Context, id uuid.UUID, message string,
+		failedAt *time.Time) error
+	Retry(ctx context.Context, now time.Time) (int64, error)
+	DeleteDispatched(ctx context.Context, before time.Time) error
+}
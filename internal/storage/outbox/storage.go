@@ -0,0 +1,171 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) scan(ctx context.Context, row postgres.Row) (*Event, error) {
+	const op = "storage.outbox.scan"
+	log := s.log.With(logger.String("op", op))
+
+	event := new(Event)
+	err := row.Scan(&event.ID, &event.Type, &event.UserID, &event.Payload,
+		&event.CreatedAt, &event.DispatchedAt, &event.Attempts, &event.RunAt,
+		&event.Error, &event.FailedAt)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return event, nil
+}
+
+// Create adds the event. It belongs in the transaction of the change it
+// describes, so it exists if and only if the change was committed.
+func (s *storage) Create(ctx context.Context, event *Event) error {
+	const op = "storage.outbox.Create"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `INSERT INTO outbox (id, type, user_id, payload, 
+                 created_at, dispatched_at, attempts, run_at) 
+                 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := s.pg.Exec(ctx, sql, event.ID, event.Type, event.UserID,
+		event.Payload, event.CreatedAt, event.DispatchedAt, event.Attempts,
+		event.RunAt)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Claim takes the oldest events due for dispatch by now and leases them
+// until leaseUntil, so other workers skip them meanwhile. An event whose
+// worker died is claimed again once the lease is over. Every claim counts
+// as an attempt.
+func (s *storage) Claim(ctx context.Context, now, leaseUntil time.Time,
+	limit uint64) ([]*Event, error) {
+	const op = "storage.outbox.Claim"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `UPDATE outbox SET run_at = $2, attempts = attempts + 1 
+                 WHERE id IN (SELECT id FROM outbox 
+                 WHERE dispatched_at IS NULL AND failed_at IS NULL 
+                 AND run_at <= $1 
+                 ORDER BY created_at LIMIT $3 FOR UPDATE SKIP LOCKED) 
+                 RETURNING *`
+
+	rows, err := s.pg.Query(ctx, sql, now, leaseUntil, limit)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	events := make([]*Event, 0)
+	for rows.Next() {
+		event, err := s.scan(ctx, rows)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+func (s *storage) MarkDispatched(
+	ctx context.Context, id uuid.UUID, at time.Time) error {
+	const op = "storage.outbox.MarkDispatched"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `UPDATE outbox SET dispatched_at = $1 WHERE id = $2`
+
+	_, err := s.pg.Exec(ctx, sql, at, id)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Fail records why the event wasn't dispatched. It is claimed again when
+// its lease is over, unless failedAt is set.
+func (s *storage) Fail(ctx context.Context, id uuid.UUID, message string,
+	failedAt *time.Time) error {
+	const op = "storage.outbox.Fail"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `UPDATE outbox SET error = $1, failed_at = $2 WHERE id = $3`
+
+	_, err := s.pg.Exec(ctx, sql, message, failedAt, id)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Retry returns the failed events to dispatch with their attempts reset
+// and reports how many there were.
+func (s *storage) Retry(ctx context.Context, now time.Time) (int64, error) {
+	const op = "storage.outbox.Retry"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `UPDATE outbox SET attempts = 0, run_at = $1, 
+                 failed_at = NULL WHERE failed_at IS NOT NULL`
+
+	command, err := s.pg.Exec(ctx, sql, now)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return command.RowsAffected(), nil
+}
+
+func (s *storage) DeleteDispatched(
+	ctx context.Context, before time.Time) error {
+	const op = "storage.outbox.DeleteDispatched"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `DELETE FROM outbox WHERE dispatched_at < $1`
+
+	_, err := s.pg.Exec(ctx, sql, before)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
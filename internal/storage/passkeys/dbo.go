@@ -0,0 +1,23 @@
+package passkeys
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Passkey struct {
+	ID              uuid.UUID
+	UserID          uuid.UUID
+	Name            string
+	CredentialID    []byte
+	PublicKey       []byte
+	AttestationType string
+	Transports      []string
+	AAGUID          []byte
+	SignCount       uint32
+	BackupEligible  bool
+	BackupState     bool
+	LastUsedAt      *time.Time
+	CreatedAt       time.Time
+}
@@ -0,0 +1,17 @@
+package passkeys
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Storage interface {
+	Create(ctx context.Context, passkey *Passkey) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Passkey, error)
+	GetByCredentialID(
+		ctx context.Context, credentialID []byte) (*Passkey, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*Passkey, error)
+	Update(ctx context.Context, passkey *Passkey) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
@@ -0,0 +1,174 @@
+package passkeys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) scan(
+	ctx context.Context, row postgres.Row) (*Passkey, error) {
+	const op = "storage.passkeys.scan"
+	log := s.log.With(logger.String("op", op))
+
+	passkey := new(Passkey)
+	err := row.Scan(
+		&passkey.ID, &passkey.UserID, &passkey.Name, &passkey.CredentialID,
+		&passkey.PublicKey, &passkey.AttestationType, &passkey.Transports,
+		&passkey.AAGUID, &passkey.SignCount, &passkey.BackupEligible,
+		&passkey.BackupState, &passkey.LastUsedAt, &passkey.CreatedAt)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return passkey, nil
+}
+
+func (s *storage) Create(ctx context.Context, passkey *Passkey) error {
+	const op = "storage.passkeys.Create"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `INSERT INTO passkeys (id, user_id, name, credential_id, 
+                 public_key, attestation_type, transports, aaguid, 
+                 sign_count, backup_eligible, backup_state, last_used_at, 
+                 created_at) 
+                 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, 
+                 $13)`
+
+	_, err := s.pg.Exec(
+		ctx, sql, passkey.ID, passkey.UserID, passkey.Name,
+		passkey.CredentialID, passkey.PublicKey, passkey.AttestationType,
+		passkey.Transports, passkey.AAGUID, passkey.SignCount,
+		passkey.BackupEligible, passkey.BackupState, passkey.LastUsedAt,
+		passkey.CreatedAt)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) GetByID(ctx context.Context, id uuid.UUID) (*Passkey, error) {
+	const op = "storage.passkeys.GetByID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM passkeys WHERE id = $1`
+
+	row := s.pg.QueryRow(ctx, sql, id)
+
+	passkey, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return passkey, nil
+}
+
+func (s *storage) GetByCredentialID(
+	ctx context.Context, credentialID []byte) (*Passkey, error) {
+	const op = "storage.passkeys.GetByCredentialID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM passkeys WHERE credential_id = $1`
+
+	row := s.pg.QueryRow(ctx, sql, credentialID)
+
+	passkey, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return passkey, nil
+}
+
+func (s *storage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) ([]*Passkey, error) {
+	const op = "storage.passkeys.GetByUserID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM passkeys WHERE user_id = $1 
+                 ORDER BY created_at`
+
+	rows, err := s.pg.Query(ctx, sql, userID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	passkeys := make([]*Passkey, 0)
+	for rows.Next() {
+		passkey, err := s.scan(ctx, rows)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		passkeys = append(passkeys, passkey)
+	}
+
+	return passkeys, nil
+}
+
+func (s *storage) Update(ctx context.Context, passkey *Passkey) error {
+	const op = "storage.passkeys.Update"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `UPDATE passkeys SET user_id = $1, name = $2, 
+                 credential_id = $3, public_key = $4, attestation_type = $5, 
+                 transports = $6, aaguid = $7, sign_count = $8, 
+                 backup_eligible = $9, backup_state = $10, 
+                 last_used_at = $11, created_at = $12 WHERE id = $13`
+
+	_, err := s.pg.Exec(
+		ctx, sql, passkey.UserID, passkey.Name, passkey.CredentialID,
+		passkey.PublicKey, passkey.AttestationType, passkey.Transports,
+		passkey.AAGUID, passkey.SignCount, passkey.BackupEligible,
+		passkey.BackupState, passkey.LastUsedAt, passkey.CreatedAt, passkey.ID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) Delete(ctx context.Context, id uuid.UUID) error {
+	const op = "storage.passkeys.Delete"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `DELETE FROM passkeys WHERE id = $1`
+
+	_, err := s.pg.Exec(ctx, sql, id)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package passwordhistory
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Entry is a password the user has had before, kept to prevent its reuse.
+type Entry struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	PasswordHash string
+	CreatedAt    time.Time
+}
@@ -0,0 +1,14 @@
+package passwordhistory
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Storage interface {
+	Create(ctx context.Context, entry *Entry) error
+	GetLatest(
+		ctx context.Context, userID uuid.UUID, limit int) ([]*Entry, error)
+	DeleteOlder(ctx context.Context, userID uuid.UUID, keep int) error
+}
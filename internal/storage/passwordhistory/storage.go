@@ -0,0 +1,108 @@
+package passwordhistory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) scan(ctx context.Context, row postgres.Row) (*Entry, error) {
+	const op = "storage.passwordhistory.scan"
+	log := s.log.With(logger.String("op", op))
+
+	entry := new(Entry)
+	err := row.Scan(&entry.ID, &entry.UserID, &entry.PasswordHash,
+		&entry.CreatedAt)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return entry, nil
+}
+
+func (s *storage) Create(ctx context.Context, entry *Entry) error {
+	const op = "storage.passwordhistory.Create"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `INSERT INTO password_history (id, user_id, password_hash, 
+                 created_at) VALUES ($1, $2, $3, $4)`
+
+	_, err := s.pg.Exec(ctx, sql, entry.ID, entry.UserID,
+		entry.PasswordHash, entry.CreatedAt)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) GetLatest(
+	ctx context.Context, userID uuid.UUID, limit int) ([]*Entry, error) {
+	const op = "storage.passwordhistory.GetLatest"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM password_history WHERE user_id = $1 
+                 ORDER BY created_at DESC LIMIT $2`
+
+	rows, err := s.pg.Query(ctx, sql, userID, limit)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	entries := make([]*Entry, 0)
+	for rows.Next() {
+		entry, err := s.scan(ctx, rows)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// DeleteOlder keeps only the latest keep entries of the user.
+func (s *storage) DeleteOlder(
+	ctx context.Context, userID uuid.UUID, keep int) error {
+	const op = "storage.passwordhistory.DeleteOlder"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `DELETE FROM password_history WHERE user_id = $1 
+                 AND id NOT IN (SELECT id FROM password_history 
+                 WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2)`
+
+	_, err := s.pg.Exec(ctx, sql, userID, keep)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,10 @@
+package passwordresets
+
+import (
+	"context"
+)
+
+type Storage interface {
+	Create(ctx context.Context, reset *PasswordReset) error
+	Take(ctx context.Context, tokenHash string) (*PasswordReset, error)
+}
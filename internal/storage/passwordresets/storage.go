@@ -0,0 +1,75 @@
+package passwordresets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func key(tokenHash string) string {
+	return "password_reset:" + tokenHash
+}
+
+func (s *storage) Create(ctx context.Context, reset *PasswordReset) error {
+	const op = "storage.passwordresets.Create"
+	log := s.log.With(logger.String("op", op))
+
+	value, err := json.Marshal(reset)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	ttl := time.Until(reset.ExpiresAt)
+	err = s.rd.Set(ctx, key(reset.TokenHash), value, ttl).Err()
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Take returns the reset and removes it, so every token works only once.
+func (s *storage) Take(
+	ctx context.Context, tokenHash string) (*PasswordReset, error) {
+	const op = "storage.passwordresets.Take"
+	log := s.log.With(logger.String("op", op))
+
+	value, err := s.rd.GetDel(ctx, key(tokenHash)).Bytes()
+	if err != nil && errors.Is(err, redis.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	reset := new(PasswordReset)
+	err = json.Unmarshal(value, reset)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return reset, nil
+}
@@ -0,0 +1,18 @@
+package personaltokens
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PersonalToken struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	TokenHash  string
+	Scopes     []string
+	LastUsedAt *time.Time
+	ExpiresAt  *time.Time
+	CreatedAt  time.Time
+}
@@ -0,0 +1,18 @@
+package personaltokens
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Storage interface {
+	Create(ctx context.Context, token *PersonalToken) error
+	GetByID(ctx context.Context, id uuid.UUID) (*PersonalToken, error)
+	GetByHash(ctx context.Context, tokenHash string) (*PersonalToken, error)
+	GetByUserID(
+		ctx context.Context, userID uuid.UUID) ([]*PersonalToken, error)
+	Update(ctx context.Context, token *PersonalToken) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+}
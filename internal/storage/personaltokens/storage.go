@@ -0,0 +1,177 @@
+package personaltokens
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) scan(
+	ctx context.Context, row postgres.Row) (*PersonalToken, error) {
+	const op = "storage.personaltokens.scan"
+	log := s.log.With(logger.String("op", op))
+
+	token := new(PersonalToken)
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.TokenHash,
+		&token.Scopes, &token.LastUsedAt, &token.ExpiresAt, &token.CreatedAt)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return token, nil
+}
+
+func (s *storage) Create(ctx context.Context, token *PersonalToken) error {
+	const op = "storage.personaltokens.Create"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `INSERT INTO personal_tokens (id, user_id, name, 
+                 token_hash, scopes, last_used_at, expires_at, created_at) 
+                 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := s.pg.Exec(ctx, sql, token.ID, token.UserID, token.Name,
+		token.TokenHash, token.Scopes, token.LastUsedAt, token.ExpiresAt,
+		token.CreatedAt)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) GetByID(
+	ctx context.Context, id uuid.UUID) (*PersonalToken, error) {
+	const op = "storage.personaltokens.GetByID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM personal_tokens WHERE id = $1`
+
+	row := s.pg.QueryRow(ctx, sql, id)
+
+	token, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return token, nil
+}
+
+func (s *storage) GetByHash(
+	ctx context.Context, tokenHash string) (*PersonalToken, error) {
+	const op = "storage.personaltokens.GetByHash"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM personal_tokens WHERE token_hash = $1`
+
+	row := s.pg.QueryRow(ctx, sql, tokenHash)
+
+	token, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return token, nil
+}
+
+func (s *storage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) ([]*PersonalToken, error) {
+	const op = "storage.personaltokens.GetByUserID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM personal_tokens WHERE user_id = $1 
+                 ORDER BY created_at`
+
+	rows, err := s.pg.Query(ctx, sql, userID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	tokens := make([]*PersonalToken, 0)
+	for rows.Next() {
+		token, err := s.scan(ctx, rows)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
+
+func (s *storage) Update(ctx context.Context, token *PersonalToken) error {
+	const op = "storage.personaltokens.Update"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `UPDATE personal_tokens SET user_id = $1, name = $2, 
+                 token_hash = $3, scopes = $4, last_used_at = $5, 
+                 expires_at = $6, created_at = $7 WHERE id = $8`
+
+	_, err := s.pg.Exec(ctx, sql, token.UserID, token.Name, token.TokenHash,
+		token.Scopes, token.LastUsedAt, token.ExpiresAt, token.CreatedAt,
+		token.ID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) Delete(ctx context.Context, id uuid.UUID) error {
+	const op = "storage.personaltokens.Delete"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `DELETE FROM personal_tokens WHERE id = $1`
+
+	_, err := s.pg.Exec(ctx, sql, id)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	const op = "storage.personaltokens.DeleteByUserID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `DELETE FROM personal_tokens WHERE user_id = $1`
+
+	_, err := s.pg.Exec(ctx, sql, userID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package plans
+
+import (
+	"time"
+)
+
+// Plan is a named set of limits. Users get the default plan from the
+// config unless an admin picks another one for them.
+type Plan struct {
+	Name          string
+	MaxNotes      int64
+	MaxTotalBytes int64
+	MaxNoteBytes  int64
+	CreatedAt     time.Time
+}
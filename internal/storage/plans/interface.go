@@ -0,0 +1,10 @@
+package plans
+
+import (
+	"context"
+)
+
+type Storage interface {
+	GetByName(ctx context.Context, name string) (*Plan, error)
+	GetAll(ctx context.Context) ([]*Plan, error)
+}
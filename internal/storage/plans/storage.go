@@ -0,0 +1,85 @@
+package plans
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) scan(ctx context.Context, row postgres.Row) (*Plan, error) {
+	const op = "storage.plans.scan"
+	log := s.log.With(logger.String("op", op))
+
+	plan := new(Plan)
+	err := row.Scan(&plan.Name, &plan.MaxNotes, &plan.MaxTotalBytes,
+		&plan.MaxNoteBytes, &plan.CreatedAt)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return plan, nil
+}
+
+func (s *storage) GetByName(ctx context.Context, name string) (*Plan, error) {
+	const op = "storage.plans.GetByName"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM plans WHERE name = $1`
+
+	row := s.pg.QueryRow(ctx, sql, name)
+
+	plan, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return plan, nil
+}
+
+func (s *storage) GetAll(ctx context.Context) ([]*Plan, error) {
+	const op = "storage.plans.GetAll"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM plans ORDER BY max_total_bytes, name`
+
+	rows, err := s.pg.Query(ctx, sql)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	plans := make([]*Plan, 0)
+	for rows.Next() {
+		plan, err := s.scan(ctx, rows)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		plans = append(plans, plan)
+	}
+
+	return plans, nil
+}
@@ -0,0 +1,19 @@
+package quotas
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Quota is what an admin changed in the user's limits: the plan and the
+// limits overriding the plan's ones. A nil plan is the default one from
+// the config, and nil limits are taken from the plan.
+type Quota struct {
+	UserID        uuid.UUID
+	Plan          *string
+	MaxNotes      *int64
+	MaxTotalBytes *int64
+	MaxNoteBytes  *int64
+	UpdatedAt     time.Time
+}
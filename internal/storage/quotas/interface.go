@@ -0,0 +1,13 @@
+package quotas
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Storage interface {
+	Create(ctx context.Context, quota *Quota) error
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*Quota, error)
+	Delete(ctx context.Context, userID uuid.UUID) error
+}
@@ -0,0 +1,99 @@
+package quotas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) scan(ctx context.Context, row postgres.Row) (*Quota, error) {
+	const op = "storage.quotas.scan"
+	log := s.log.With(logger.String("op", op))
+
+	quota := new(Quota)
+	err := row.Scan(&quota.UserID, &quota.Plan, &quota.MaxNotes,
+		&quota.MaxTotalBytes, &quota.MaxNoteBytes, &quota.UpdatedAt)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return quota, nil
+}
+
+// Create stores the quota replacing the previous one.
+func (s *storage) Create(ctx context.Context, quota *Quota) error {
+	const op = "storage.quotas.Create"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `INSERT INTO quotas (user_id, plan, max_notes, 
+                 max_total_bytes, max_note_bytes, updated_at) 
+                 VALUES ($1, $2, $3, $4, $5, $6) 
+                 ON CONFLICT (user_id) DO UPDATE SET plan = $2, 
+                 max_notes = $3, max_total_bytes = $4, 
+                 max_note_bytes = $5, updated_at = $6`
+
+	_, err := s.pg.Exec(ctx, sql, quota.UserID, quota.Plan, quota.MaxNotes,
+		quota.MaxTotalBytes, quota.MaxNoteBytes, quota.UpdatedAt)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) (*Quota, error) {
+	const op = "storage.quotas.GetByUserID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM quotas WHERE user_id = $1`
+
+	row := s.pg.QueryRow(ctx, sql, userID)
+
+	quota, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return quota, nil
+}
+
+func (s *storage) Delete(ctx context.Context, userID uuid.UUID) error {
+	const op = "storage.quotas.Delete"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `DELETE FROM quotas WHERE user_id = $1`
+
+	_, err := s.pg.Exec(ctx, sql, userID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
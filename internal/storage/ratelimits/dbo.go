@@ -0,0 +1,38 @@
+package ratelimits
+
+import (
+	"math"
+	"time"
+)
+
+type Budget struct {
+	Limit  int
+	Period time.Duration
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// NewResult describes the bucket left with tokens after a take.
+func NewResult(budget *Budget, allowed bool, tokens float64) *Result {
+	// Time needed to refill a single token.
+	perToken := float64(budget.Period) / float64(budget.Limit)
+
+	result := &Result{
+		Allowed:   allowed,
+		Limit:     budget.Limit,
+		Remaining: int(math.Floor(tokens)),
+		Reset: time.Duration(
+			math.Ceil((float64(budget.Limit) - tokens) * perToken)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration(math.Ceil((1 - tokens) * perToken))
+	}
+
+	return result
+}
@@ -0,0 +1,9 @@
+package ratelimits
+
+import (
+	"context"
+)
+
+type Storage interface {
+	Take(ctx context.Context, key string, budget *Budget) (*Result, error)
+}
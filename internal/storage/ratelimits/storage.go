@@ -0,0 +1,76 @@
+package ratelimits
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+)
+
+// take refills the bucket stored at KEYS[1] according to the time elapsed
+// since the previous call and tries to take one token from it. Redis server
+// time is used so that every replica shares the same clock.
+var take = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1]) or limit
+local ts = tonumber(bucket[2]) or now
+
+tokens = math.min(limit, tokens + (now - ts) * limit / period)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], period)
+
+return {allowed, tostring(tokens)}
+`)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) Take(
+	ctx context.Context, key string, budget *Budget) (*Result, error) {
+	const op = "storage.ratelimits.Take"
+	log := s.log.With(logger.String("op", op))
+
+	period := budget.Period.Milliseconds()
+	values, err := take.Run(ctx, s.rd, []string{"ratelimit:" + key},
+		budget.Limit, period).Slice()
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	allowed, _ := values[0].(int64)
+	raw, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return NewResult(budget, allowed == 1, tokens), nil
+}
@@ -0,0 +1,17 @@
+package recoverycodes
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Storage interface {
+	Create(ctx context.Context, code *RecoveryCode) error
+	GetByHash(ctx context.Context,
+		userID uuid.UUID, codeHash string) (*RecoveryCode, error)
+	Use(ctx context.Context,
+		userID uuid.UUID, codeHash string, usedAt time.Time) (bool, error)
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+}
@@ -0,0 +1,117 @@
+package recoverycodes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) scan(
+	ctx context.Context, row postgres.Row) (*RecoveryCode, error) {
+	const op = "storage.recoverycodes.scan"
+	log := s.log.With(logger.String("op", op))
+
+	code := new(RecoveryCode)
+	err := row.Scan(&code.ID, &code.UserID,
+		&code.CodeHash, &code.UsedAt, &code.CreatedAt)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return code, nil
+}
+
+func (s *storage) Create(ctx context.Context, code *RecoveryCode) error {
+	const op = "storage.recoverycodes.Create"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `INSERT INTO recovery_codes (id, user_id, code_hash, 
+                 used_at, created_at) VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := s.pg.Exec(ctx, sql, code.ID, code.UserID,
+		code.CodeHash, code.UsedAt, code.CreatedAt)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) GetByHash(ctx context.Context,
+	userID uuid.UUID, codeHash string) (*RecoveryCode, error) {
+	const op = "storage.recoverycodes.GetByHash"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM recovery_codes 
+                 WHERE user_id = $1 AND code_hash = $2`
+
+	row := s.pg.QueryRow(ctx, sql, userID, codeHash)
+
+	code, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return code, nil
+}
+
+// Use marks the unused code with the hash as used. The check and the mark
+// are one statement, so a code can't be used twice by concurrent requests.
+// It tells whether the code was there to use.
+func (s *storage) Use(ctx context.Context,
+	userID uuid.UUID, codeHash string, usedAt time.Time) (bool, error) {
+	const op = "storage.recoverycodes.Use"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `UPDATE recovery_codes SET used_at = $3 
+                 WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	command, err := s.pg.Exec(ctx, sql, userID, codeHash, usedAt)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return command.RowsAffected() == 1, nil
+}
+
+func (s *storage) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	const op = "storage.recoverycodes.DeleteByUserID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `DELETE FROM recovery_codes WHERE user_id = $1`
+
+	_, err := s.pg.Exec(ctx, sql, userID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package roles
+
+import (
+	"time"
+)
+
+// Role is a named set of permissions assigned to users. Builtin roles are
+// created by the migrations and can't be changed or deleted.
+type Role struct {
+	Name        string
+	Description string
+	Permissions []string
+	Builtin     bool
+	CreatedAt   time.Time
+}
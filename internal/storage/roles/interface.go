@@ -0,0 +1,14 @@
+package roles
+
+import (
+	"context"
+)
+
+type Storage interface {
+	Create(ctx context.Context, role *Role) error
+	GetByName(ctx context.Context, name string) (*Role, error)
+	GetAll(ctx context.Context) ([]*Role, error)
+	Update(ctx context.Context, role *Role) error
+	Delete(ctx context.Context, name string) error
+	CountUsers(ctx context.Context, name string) (uint64, error)
+}
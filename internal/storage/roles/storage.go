@@ -0,0 +1,150 @@
+package roles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) scan(ctx context.Context, row postgres.Row) (*Role, error) {
+	const op = "storage.roles.scan"
+	log := s.log.With(logger.String("op", op))
+
+	role := new(Role)
+	err := row.Scan(&role.Name, &role.Description, &role.Permissions,
+		&role.Builtin, &role.CreatedAt)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return role, nil
+}
+
+func (s *storage) Create(ctx context.Context, role *Role) error {
+	const op = "storage.roles.Create"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `INSERT INTO roles (name, description, permissions, 
+                 builtin, created_at) VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := s.pg.Exec(ctx, sql, role.Name, role.Description,
+		role.Permissions, role.Builtin, role.CreatedAt)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) GetByName(ctx context.Context, name string) (*Role, error) {
+	const op = "storage.roles.GetByName"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM roles WHERE name = $1`
+
+	row := s.pg.QueryRow(ctx, sql, name)
+
+	role, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return role, nil
+}
+
+func (s *storage) GetAll(ctx context.Context) ([]*Role, error) {
+	const op = "storage.roles.GetAll"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM roles ORDER BY builtin DESC, name`
+
+	rows, err := s.pg.Query(ctx, sql)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	roles := make([]*Role, 0)
+	for rows.Next() {
+		role, err := s.scan(ctx, rows)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, nil
+}
+
+func (s *storage) Update(ctx context.Context, role *Role) error {
+	const op = "storage.roles.Update"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `UPDATE roles SET description = $1, permissions = $2 
+                 WHERE name = $3`
+
+	_, err := s.pg.Exec(
+		ctx, sql, role.Description, role.Permissions, role.Name)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) Delete(ctx context.Context, name string) error {
+	const op = "storage.roles.Delete"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `DELETE FROM roles WHERE name = $1`
+
+	_, err := s.pg.Exec(ctx, sql, name)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) CountUsers(ctx context.Context, name string) (uint64, error) {
+	const op = "storage.roles.CountUsers"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT COUNT(*) FROM users WHERE role = $1`
+
+	var count uint64
+	err := s.pg.QueryRow(ctx, sql, name).Scan(&count)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,33 @@
+package securityevents
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event is a record in the append-only audit trail. ActorID is set when
+// someone other than the user, such as an admin, caused the event. Login
+// keeps the attempted login of failed logins without a known user.
+type Event struct {
+	ID        uuid.UUID
+	UserID    *uuid.UUID
+	ActorID   *uuid.UUID
+	Type      string
+	Login     *string
+	IP        *string
+	UserAgent *string
+	Details   map[string]string
+	CreatedAt time.Time
+}
+
+// Filter narrows Find down. Nil fields match everything.
+type Filter struct {
+	UserID *uuid.UUID
+	Type   *string
+	IP     *string
+	From   *time.Time
+	To     *time.Time
+	Limit  *uint64
+	Offset *uint64
+}
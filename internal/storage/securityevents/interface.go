@@ -0,0 +1,10 @@
+package securityevents
+
+import (
+	"context"
+)
+
+type Storage interface {
+	Create(ctx context.Context, event *Event) error
+	Find(ctx context.Context, filter *Filter) ([]*Event, error)
+}
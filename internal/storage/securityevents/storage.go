@@ -0,0 +1,101 @@
+package securityevents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) scan(ctx context.Context, row postgres.Row) (*Event, error) {
+	const op = "storage.securityevents.scan"
+	log := s.log.With(logger.String("op", op))
+
+	event := new(Event)
+	err := row.Scan(&event.ID, &event.UserID, &event.ActorID, &event.Type,
+		&event.Login, &event.IP, &event.UserAgent, &event.Details,
+		&event.CreatedAt)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return event, nil
+}
+
+func (s *storage) Create(ctx context.Context, event *Event) error {
+	const op = "storage.securityevents.Create"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `INSERT INTO security_events (id, user_id, actor_id, type, 
+                 login, ip, user_agent, details, created_at) 
+                 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	details := event.Details
+	if details == nil {
+		details = make(map[string]string)
+	}
+
+	_, err := s.pg.Exec(ctx, sql, event.ID, event.UserID, event.ActorID,
+		event.Type, event.Login, event.IP, event.UserAgent, details,
+		event.CreatedAt)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Find returns the newest events first.
+func (s *storage) Find(ctx context.Context, filter *Filter) ([]*Event, error) {
+	const op = "storage.securityevents.Find"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM security_events 
+                 WHERE ($1::UUID IS NULL OR user_id = $1) 
+                 AND ($2::TEXT IS NULL OR type = $2) 
+                 AND ($3::TEXT IS NULL OR ip = $3) 
+                 AND ($4::TIMESTAMPTZ IS NULL OR created_at >= $4) 
+                 AND ($5::TIMESTAMPTZ IS NULL OR created_at < $5) 
+                 ORDER BY created_at DESC LIMIT $6 OFFSET $7`
+
+	rows, err := s.pg.Query(ctx, sql, filter.UserID, filter.Type, filter.IP,
+		filter.From, filter.To, filter.Limit, filter.Offset)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	events := make([]*Event, 0)
+	for rows.Next() {
+		event, err := s.scan(ctx, rows)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
@@ -6,9 +6,17 @@ import (
 	"github.com/google/uuid"
 )
 
+// Session is either a login session or, when ClientID is set, an OAuth
+// grant limited to Scopes that is renewed with the refresh token. Sessions
+// an admin opened to act as the user have ImpersonatorID set.
 type Session struct {
-	ID        uuid.UUID
-	UserID    uuid.UUID
-	UserAgent *string
-	CreatedAt time.Time
+	ID               uuid.UUID
+	UserID           uuid.UUID
+	UserAgent        *string
+	CreatedAt        time.Time
+	ClientID         *uuid.UUID
+	Scopes           []string
+	RefreshTokenHash *string
+	ExpiresAt        *time.Time
+	ImpersonatorID   *uuid.UUID
 }
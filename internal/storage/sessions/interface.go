@@ -2,6 +2,7 @@ package sessions
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -10,6 +11,15 @@ type Storage interface {
 	Create(ctx context.Context, session *Session) error
 	GetByID(ctx context.Context, id uuid.UUID) (*Session, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*Session, error)
+	CountActive(ctx context.Context, userID *uuid.UUID) (uint64, error)
+	GetByRefreshTokenHash(
+		ctx context.Context, refreshTokenHash string) (*Session, error)
+	RotateRefreshToken(ctx context.Context, refreshTokenHash,
+		newRefreshTokenHash string, now, expiresAt time.Time,
+		lifetime time.Duration) (*Session, error)
+	GetByRotatedRefreshTokenHash(
+		ctx context.Context, refreshTokenHash string) (*Session, error)
 	Update(ctx context.Context, session *Session) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
 }
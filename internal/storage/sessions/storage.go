@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"cloud-notes/internal/database/postgres"
 	"cloud-notes/internal/database/redis"
@@ -14,11 +15,11 @@ import (
 
 type storage struct {
 	log logger.Logger
-	pg  *postgres.Postgres
+	pg  postgres.DB
 	rd  *redis.Redis
 }
 
-func New(log logger.Logger, pg *postgres.Postgres, rd *redis.Redis) Storage {
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
 	return &storage{
 		log: log,
 		pg:  pg,
@@ -32,7 +33,9 @@ func (s *storage) scan(ctx context.Context, row postgres.Row) (*Session, error)
 
 	session := new(Session)
 	err := row.Scan(&session.ID, &session.UserID,
-		&session.UserAgent, &session.CreatedAt)
+		&session.UserAgent, &session.CreatedAt, &session.ClientID,
+		&session.Scopes, &session.RefreshTokenHash, &session.ExpiresAt,
+		&session.ImpersonatorID)
 	if err != nil && errors.Is(err, postgres.ErrNoRows) {
 		return nil, nil
 	} else if err != nil {
@@ -48,10 +51,14 @@ func (s *storage) Create(ctx context.Context, session *Session) error {
 	log := s.log.With(logger.String("op", op))
 
 	const sql = `INSERT INTO sessions (id, user_id, user_agent, 
-                 created_at) VALUES ($1, $2, $3, $4)`
+                 created_at, client_id, scopes, refresh_token_hash, 
+                 expires_at, impersonator_id) 
+                 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
 
 	_, err := s.pg.Exec(ctx, sql, session.ID, session.UserID,
-		session.UserAgent, session.CreatedAt)
+		session.UserAgent, session.CreatedAt, session.ClientID,
+		session.Scopes, session.RefreshTokenHash, session.ExpiresAt,
+		session.ImpersonatorID)
 	if err != nil {
 		log.ErrorContext(ctx, "", logger.Error(err))
 		return fmt.Errorf("%s: %w", op, err)
@@ -104,15 +111,119 @@ func (s *storage) GetByUserID(
 	return sessions, nil
 }
 
+// CountActive counts unexpired sessions of the user, or of everyone when
+// userID is nil.
+func (s *storage) CountActive(
+	ctx context.Context, userID *uuid.UUID) (uint64, error) {
+	const op = "storage.sessions.CountActive"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT COUNT(*) FROM sessions 
+                 WHERE ($1::UUID IS NULL OR user_id = $1) 
+                 AND (expires_at IS NULL OR expires_at > now())`
+
+	var count uint64
+	err := s.pg.QueryRow(ctx, sql, userID).Scan(&count)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+func (s *storage) GetByRefreshTokenHash(
+	ctx context.Context, refreshTokenHash string) (*Session, error) {
+	const op = "storage.sessions.GetByRefreshTokenHash"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM sessions WHERE refresh_token_hash = $1`
+
+	row := s.pg.QueryRow(ctx, sql, refreshTokenHash)
+
+	session, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return session, nil
+}
+
+// RotateRefreshToken replaces the live refresh token with a new one that
+// expires at expiresAt, but no later than lifetime after the session was
+// created, and returns the session, or nil when no session has the token
+// or it has expired. The replaced token is remembered until it would have
+// expired, so its reuse can be told apart from an unknown token.
+// Concurrent calls with the same token rotate it once.
+func (s *storage) RotateRefreshToken(ctx context.Context, refreshTokenHash,
+	newRefreshTokenHash string, now, expiresAt time.Time,
+	lifetime time.Duration) (*Session, error) {
+	const op = "storage.sessions.RotateRefreshToken"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `WITH old AS (SELECT id, expires_at FROM sessions 
+                 WHERE refresh_token_hash = $1 AND expires_at > $3 
+                 FOR UPDATE), 
+                 pruned AS (DELETE FROM rotated_refresh_tokens 
+                 WHERE session_id IN (SELECT id FROM old) 
+                 AND expires_at <= $3), 
+                 rotated AS (INSERT INTO rotated_refresh_tokens 
+                 (hash, session_id, expires_at) 
+                 SELECT $1, id, expires_at FROM old) 
+                 UPDATE sessions s SET refresh_token_hash = $2, 
+                 expires_at = LEAST($4, s.created_at 
+                 + $5::DOUBLE PRECISION * INTERVAL '1 second') 
+                 FROM old WHERE s.id = old.id 
+                 RETURNING s.*`
+
+	row := s.pg.QueryRow(ctx, sql, refreshTokenHash, newRefreshTokenHash,
+		now, expiresAt, lifetime.Seconds())
+
+	session, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return session, nil
+}
+
+// GetByRotatedRefreshTokenHash returns the session a refresh token was
+// replaced in, or nil when the token was never rotated.
+func (s *storage) GetByRotatedRefreshTokenHash(
+	ctx context.Context, refreshTokenHash string) (*Session, error) {
+	const op = "storage.sessions.GetByRotatedRefreshTokenHash"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT s.* FROM sessions s 
+                 JOIN rotated_refresh_tokens r ON r.session_id = s.id 
+                 WHERE r.hash = $1`
+
+	row := s.pg.QueryRow(ctx, sql, refreshTokenHash)
+
+	session, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return session, nil
+}
+
 func (s *storage) Update(ctx context.Context, session *Session) error {
 	const op = "storage.sessions.Update"
 	log := s.log.With(logger.String("op", op))
 
 	const sql = `UPDATE sessions SET user_id = $1, user_agent = $2, 
-                 created_at = $3 WHERE id = $4`
+                 created_at = $3, client_id = $4, scopes = $5, 
+                 refresh_token_hash = $6, expires_at = $7, 
+                 impersonator_id = $8 WHERE id = $9`
 
 	_, err := s.pg.Exec(ctx, sql, session.UserID,
-		session.UserAgent, session.CreatedAt, session.ID)
+		session.UserAgent, session.CreatedAt, session.ClientID,
+		session.Scopes, session.RefreshTokenHash, session.ExpiresAt,
+		session.ImpersonatorID, session.ID)
 	if err != nil {
 		log.ErrorContext(ctx, "", logger.Error(err))
 		return fmt.Errorf("%s: %w", op, err)
@@ -135,3 +246,18 @@ func (s *storage) Delete(ctx context.Context, id uuid.UUID) error {
 
 	return nil
 }
+
+func (s *storage) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	const op = "storage.sessions.DeleteByUserID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `DELETE FROM sessions WHERE user_id = $1`
+
+	_, err := s.pg.Exec(ctx, sql, userID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -4,22 +4,118 @@ import (
 	"cloud-notes/internal/database/postgres"
 	"cloud-notes/internal/database/redis"
 	"cloud-notes/internal/logger"
+	"cloud-notes/internal/storage/authcodes"
+	"cloud-notes/internal/storage/blocks"
+	"cloud-notes/internal/storage/ceremonies"
+	"cloud-notes/internal/storage/deletions"
+	"cloud-notes/internal/storage/exports"
+	"cloud-notes/internal/storage/identities"
+	"cloud-notes/internal/storage/jobs"
 	"cloud-notes/internal/storage/notes"
+	"cloud-notes/internal/storage/oauthclients"
+	"cloud-notes/internal/storage/outbox"
+	"cloud-notes/internal/storage/passkeys"
+	"cloud-notes/internal/storage/passwordhistory"
+	"cloud-notes/internal/storage/passwordresets"
+	"cloud-notes/internal/storage/personaltokens"
+	"cloud-notes/internal/storage/plans"
+	"cloud-notes/internal/storage/quotas"
+	"cloud-notes/internal/storage/ratelimits"
+	"cloud-notes/internal/storage/recoverycodes"
+	"cloud-notes/internal/storage/roles"
+	"cloud-notes/internal/storage/securityevents"
 	"cloud-notes/internal/storage/sessions"
+	"cloud-notes/internal/storage/stream"
+	"cloud-notes/internal/storage/twofactor"
+	"cloud-notes/internal/storage/usage"
 	"cloud-notes/internal/storage/users"
+	"cloud-notes/internal/storage/verifications"
+	"cloud-notes/internal/storage/webhookdeliveries"
+	"cloud-notes/internal/storage/webhooks"
+	"cloud-notes/internal/storage/workspacemembers"
+	"cloud-notes/internal/storage/workspaces"
 )
 
 type storage struct {
-	notes    notes.Storage
-	users    users.Storage
-	sessions sessions.Storage
+	log               logger.Logger
+	pg                postgres.DB
+	rd                *redis.Redis
+	tx                bool
+	notes             notes.Storage
+	users             users.Storage
+	sessions          sessions.Storage
+	rateLimits        ratelimits.Storage
+	twoFactor         twofactor.Storage
+	recoveryCodes     recoverycodes.Storage
+	passkeys          passkeys.Storage
+	ceremonies        ceremonies.Storage
+	verifications     verifications.Storage
+	passwordResets    passwordresets.Storage
+	blocks            blocks.Storage
+	deletions         deletions.Storage
+	exports           exports.Storage
+	personalTokens    personaltokens.Storage
+	identities        identities.Storage
+	oauthClients      oauthclients.Storage
+	authCodes         authcodes.Storage
+	passwordHistory   passwordhistory.Storage
+	securityEvents    securityevents.Storage
+	roles             roles.Storage
+	workspaces        workspaces.Storage
+	workspaceMembers  workspacemembers.Storage
+	plans             plans.Storage
+	quotas            quotas.Storage
+	usage             usage.Storage
+	outbox            outbox.Storage
+	webhooks          webhooks.Storage
+	webhookDeliveries webhookdeliveries.Storage
+	stream            stream.Storage
+	jobs              jobs.Storage
 }
 
 func New(log logger.Logger, pg *postgres.Postgres, rd *redis.Redis) Storage {
+	return newStorage(log, pg, rd, false)
+}
+
+// newStorage builds the sub-storages on pg, which is a transaction for the
+// storage WithTx passes to its function.
+func newStorage(
+	log logger.Logger, pg postgres.DB, rd *redis.Redis, tx bool) *storage {
 	return &storage{
-		notes:    notes.New(log, pg, rd),
-		users:    users.New(log, pg, rd),
-		sessions: sessions.New(log, pg, rd),
+		log:               log,
+		pg:                pg,
+		rd:                rd,
+		tx:                tx,
+		notes:             notes.New(log, pg, rd),
+		users:             users.New(log, pg, rd),
+		sessions:          sessions.New(log, pg, rd),
+		rateLimits:        ratelimits.New(log, pg, rd),
+		twoFactor:         twofactor.New(log, pg, rd),
+		recoveryCodes:     recoverycodes.New(log, pg, rd),
+		passkeys:          passkeys.New(log, pg, rd),
+		ceremonies:        ceremonies.New(log, pg, rd),
+		verifications:     verifications.New(log, pg, rd),
+		passwordResets:    passwordresets.New(log, pg, rd),
+		blocks:            blocks.New(log, pg, rd),
+		deletions:         deletions.New(log, pg, rd),
+		exports:           exports.New(log, pg, rd),
+		personalTokens:    personaltokens.New(log, pg, rd),
+		identities:        identities.New(log, pg, rd),
+		oauthClients:      oauthclients.New(log, pg, rd),
+		authCodes:         authcodes.New(log, pg, rd),
+		passwordHistory:   passwordhistory.New(log, pg, rd),
+		securityEvents:    securityevents.New(log, pg, rd),
+		roles:             roles.New(log, pg, rd),
+		workspaces:        workspaces.New(log, pg, rd),
+		workspaceMembers:  workspacemembers.New(log, pg, rd),
+		plans:             plans.New(log, pg, rd),
+		quotas:            quotas.New(log, pg, rd),
+		usage:             usage.New(log, pg, rd),
+		outbox:            outbox.New(log, pg, rd),
+		webhooks:          webhooks.New(log, pg, rd),
+		webhookDeliveries: webhookdeliveries.New(log, pg, rd),
+		stream:            stream.New(log, pg, rd),
+		jobs:              jobs.New(log, pg, rd),
 	}
 }
 
@@ -27,6 +123,10 @@ func (s *storage) Notes() notes.Storage {
 	return s.notes
 }
 
+func (s *storage) RateLimits() ratelimits.Storage {
+	return s.rateLimits
+}
+
 func (s *storage) Sessions() sessions.Storage {
 	return s.sessions
 }
@@ -34,3 +134,107 @@ func (s *storage) Sessions() sessions.Storage {
 func (s *storage) Users() users.Storage {
 	return s.users
 }
+
+func (s *storage) TwoFactor() twofactor.Storage {
+	return s.twoFactor
+}
+
+func (s *storage) RecoveryCodes() recoverycodes.Storage {
+	return s.recoveryCodes
+}
+
+func (s *storage) Passkeys() passkeys.Storage {
+	return s.passkeys
+}
+
+func (s *storage) Ceremonies() ceremonies.Storage {
+	return s.ceremonies
+}
+
+func (s *storage) Verifications() verifications.Storage {
+	return s.verifications
+}
+
+func (s *storage) PasswordResets() passwordresets.Storage {
+	return s.passwordResets
+}
+
+func (s *storage) Blocks() blocks.Storage {
+	return s.blocks
+}
+
+func (s *storage) Deletions() deletions.Storage {
+	return s.deletions
+}
+
+func (s *storage) Exports() exports.Storage {
+	return s.exports
+}
+
+func (s *storage) PersonalTokens() personaltokens.Storage {
+	return s.personalTokens
+}
+
+func (s *storage) Identities() identities.Storage {
+	return s.identities
+}
+
+func (s *storage) OAuthClients() oauthclients.Storage {
+	return s.oauthClients
+}
+
+func (s *storage) AuthCodes() authcodes.Storage {
+	return s.authCodes
+}
+
+func (s *storage) PasswordHistory() passwordhistory.Storage {
+	return s.passwordHistory
+}
+
+func (s *storage) SecurityEvents() securityevents.Storage {
+	return s.securityEvents
+}
+
+func (s *storage) Roles() roles.Storage {
+	return s.roles
+}
+
+func (s *storage) Workspaces() workspaces.Storage {
+	return s.workspaces
+}
+
+func (s *storage) WorkspaceMembers() workspacemembers.Storage {
+	return s.workspaceMembers
+}
+
+func (s *storage) Plans() plans.Storage {
+	return s.plans
+}
+
+func (s *storage) Quotas() quotas.Storage {
+	return s.quotas
+}
+
+func (s *storage) Usage() usage.Storage {
+	return s.usage
+}
+
+func (s *storage) Outbox() outbox.Storage {
+	return s.outbox
+}
+
+func (s *storage) Webhooks() webhooks.Storage {
+	return s.webhooks
+}
+
+func (s *storage) WebhookDeliveries() webhookdeliveries.Storage {
+	return s.webhookDeliveries
+}
+
+func (s *storage) Stream() stream.Storage {
+	return s.stream
+}
+
+func (s *storage) Jobs() jobs.Storage {
+	return s.jobs
+}
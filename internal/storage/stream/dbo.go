@@ -0,0 +1,12 @@
+package stream
+
+import (
+	"cloud-notes/internal/storage/outbox"
+)
+
+// Message is an event read from the stream by a consumer group. ID is the
+// stream's own entry ID, used to acknowledge the message.
+type Message struct {
+	ID    string
+	Event *outbox.Event
+}
@@ -0,0 +1,18 @@
+package stream
+
+import (
+	"context"
+	"time"
+
+	"cloud-notes/internal/storage/outbox"
+)
+
+type Storage interface {
+	Publish(ctx context.Context, event *outbox.Event) error
+	CreateGroup(ctx context.Context, group string) error
+	Read(ctx context.Context, group, consumer string,
+		count int64, block time.Duration) ([]*Message, error)
+	Claim(ctx context.Context, group, consumer string,
+		minIdle time.Duration, count int64) ([]*Message, error)
+	Ack(ctx context.Context, group, id string) error
+}
@@ -0,0 +1,196 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/storage/outbox"
+
+	"github.com/google/uuid"
+)
+
+const (
+	key = "events"
+
+	// maxLen caps the stream approximately, a consumer group that falls
+	// this far behind loses the oldest events.
+	maxLen = 100000
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) Publish(ctx context.Context, event *outbox.Event) error {
+	const op = "storage.stream.Publish"
+	log := s.log.With(logger.String("op", op))
+
+	err := s.rd.XAdd(ctx, &redis.XAddArgs{
+		Stream: key,
+		MaxLen: maxLen,
+		Approx: true,
+		Values: map[string]any{
+			"id":         event.ID.String(),
+			"type":       event.Type,
+			"user_id":    event.UserID.String(),
+			"payload":    string(event.Payload),
+			"created_at": event.CreatedAt.Format(time.RFC3339Nano),
+		},
+	}).Err()
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// CreateGroup creates the consumer group, reading the stream from its
+// start. A group that already exists is left as it is.
+func (s *storage) CreateGroup(ctx context.Context, group string) error {
+	const op = "storage.stream.CreateGroup"
+	log := s.log.With(logger.String("op", op))
+
+	err := s.rd.XGroupCreateMkStream(ctx, key, group, "0").Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Read returns messages never delivered to the group, waiting up to block
+// for them. They stay pending for the consumer until acknowledged.
+func (s *storage) Read(ctx context.Context, group, consumer string,
+	count int64, block time.Duration) ([]*Message, error) {
+	const op = "storage.stream.Read"
+	log := s.log.With(logger.String("op", op))
+
+	streams, err := s.rd.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{key, ">"},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if err != nil && errors.Is(err, redis.ErrNoRows) {
+		return []*Message{}, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	messages := make([]*Message, 0)
+	for _, stream := range streams {
+		for _, value := range stream.Messages {
+			message, err := s.message(value)
+			if err != nil {
+				log.ErrorContext(ctx, "", logger.Error(err))
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			messages = append(messages, message)
+		}
+	}
+
+	return messages, nil
+}
+
+// Claim takes over the messages other consumers of the group have held
+// unacknowledged for at least minIdle, like the ones of a crashed replica.
+func (s *storage) Claim(ctx context.Context, group, consumer string,
+	minIdle time.Duration, count int64) ([]*Message, error) {
+	const op = "storage.stream.Claim"
+	log := s.log.With(logger.String("op", op))
+
+	values, _, err := s.rd.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   key,
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Start:    "0-0",
+		Count:    count,
+	}).Result()
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	messages := make([]*Message, 0, len(values))
+	for _, value := range values {
+		message, err := s.message(value)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		messages = append(messages, message)
+	}
+
+	return messages, nil
+}
+
+func (s *storage) Ack(ctx context.Context, group, id string) error {
+	const op = "storage.stream.Ack"
+	log := s.log.With(logger.String("op", op))
+
+	err := s.rd.XAck(ctx, key, group, id).Err()
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) message(value redis.XMessage) (*Message, error) {
+	const op = "storage.stream.message"
+
+	field := func(name string) string {
+		v, _ := value.Values[name].(string)
+		return v
+	}
+
+	id, err := uuid.Parse(field("id"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	userID, err := uuid.Parse(field("user_id"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, field("created_at"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Message{
+		ID: value.ID,
+		Event: &outbox.Event{
+			ID:        id,
+			Type:      field("type"),
+			UserID:    userID,
+			Payload:   []byte(field("payload")),
+			CreatedAt: createdAt,
+		},
+	}, nil
+}
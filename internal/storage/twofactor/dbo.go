@@ -0,0 +1,16 @@
+package twofactor
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TwoFactor struct {
+	UserID       uuid.UUID
+	Secret       string
+	Enabled      bool
+	LastUsedStep int64
+	ConfirmedAt  *time.Time
+	CreatedAt    time.Time
+}
@@ -0,0 +1,15 @@
+package twofactor
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Storage interface {
+	Create(ctx context.Context, twoFactor *TwoFactor) error
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*TwoFactor, error)
+	Update(ctx context.Context, twoFactor *TwoFactor) error
+	UseStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	Delete(ctx context.Context, userID uuid.UUID) error
+}
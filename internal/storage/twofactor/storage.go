@@ -0,0 +1,137 @@
+package twofactor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) scan(
+	ctx context.Context, row postgres.Row) (*TwoFactor, error) {
+	const op = "storage.twofactor.scan"
+	log := s.log.With(logger.String("op", op))
+
+	twoFactor := new(TwoFactor)
+	err := row.Scan(
+		&twoFactor.UserID, &twoFactor.Secret, &twoFactor.Enabled,
+		&twoFactor.LastUsedStep, &twoFactor.ConfirmedAt, &twoFactor.CreatedAt)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return twoFactor, nil
+}
+
+func (s *storage) Create(ctx context.Context, twoFactor *TwoFactor) error {
+	const op = "storage.twofactor.Create"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `INSERT INTO two_factor (user_id, secret, enabled, 
+                 last_used_step, confirmed_at, created_at) 
+                 VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := s.pg.Exec(
+		ctx, sql, twoFactor.UserID, twoFactor.Secret, twoFactor.Enabled,
+		twoFactor.LastUsedStep, twoFactor.ConfirmedAt, twoFactor.CreatedAt)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) (*TwoFactor, error) {
+	const op = "storage.twofactor.GetByUserID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM two_factor WHERE user_id = $1`
+
+	row := s.pg.QueryRow(ctx, sql, userID)
+
+	twoFactor, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return twoFactor, nil
+}
+
+func (s *storage) Update(ctx context.Context, twoFactor *TwoFactor) error {
+	const op = "storage.twofactor.Update"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `UPDATE two_factor SET secret = $1, enabled = $2, 
+                 last_used_step = $3, confirmed_at = $4, 
+                 created_at = $5 WHERE user_id = $6`
+
+	_, err := s.pg.Exec(
+		ctx, sql, twoFactor.Secret, twoFactor.Enabled, twoFactor.LastUsedStep,
+		twoFactor.ConfirmedAt, twoFactor.CreatedAt, twoFactor.UserID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) Delete(ctx context.Context, userID uuid.UUID) error {
+	const op = "storage.twofactor.Delete"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `DELETE FROM two_factor WHERE user_id = $1`
+
+	_, err := s.pg.Exec(ctx, sql, userID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UseStep records the step of an accepted code unless the same or a later
+// step was recorded meanwhile, so a code is accepted once even when it is
+// sent twice at the same time. It tells whether the step was recorded.
+func (s *storage) UseStep(
+	ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	const op = "storage.twofactor.UseStep"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `UPDATE two_factor SET last_used_step = $2 
+                 WHERE user_id = $1 AND last_used_step < $2`
+
+	command, err := s.pg.Exec(ctx, sql, userID, step)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return command.RowsAffected() == 1, nil
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/logger"
+)
+
+const (
+	maxTxAttempts = 3
+	txRetryDelay  = 20 * time.Millisecond
+)
+
+// TxOption changes how WithTx runs the transaction.
+type TxOption func(opts *postgres.TxOptions)
+
+// Serializable runs the transaction at the SERIALIZABLE isolation level
+// instead of READ COMMITTED, for when fn decides what to write from what
+// it has read.
+func Serializable() TxOption {
+	return func(opts *postgres.TxOptions) {
+		opts.IsoLevel = postgres.Serializable
+	}
+}
+
+// WithTx runs fn with a storage whose sub-storages share one transaction.
+// It is committed if fn returns nil and rolled back otherwise, the error of
+// fn is returned as it is. Called on the storage of a transaction, WithTx
+// starts a savepoint, so only fn's part is rolled back on failure.
+//
+// A transaction Postgres aborted because of a deadlock, or of a
+// serialization failure when it is Serializable, is run again, so fn must
+// do nothing but work with the storage. A savepoint runs with the options
+// of its transaction and isn't run again on its own: the error reaches
+// the outermost WithTx, which runs the whole transaction again. Redis
+// backed storages aren't part of the transaction.
+func (s *storage) WithTx(ctx context.Context,
+	fn func(st Storage) error, opts ...TxOption) error {
+	const op = "storage.WithTx"
+	log := s.log.With(logger.String("op", op))
+
+	// Retrying a savepoint doesn't help, the outer transaction is aborted.
+	var txOpts postgres.TxOptions
+	for _, opt := range opts {
+		opt(&txOpts)
+	}
+
+	if s.tx {
+		return s.runTx(ctx, fn, txOpts)
+	}
+
+	var err error
+	for attempt := range maxTxAttempts {
+		err = s.runTx(ctx, fn, txOpts)
+		if !postgres.IsRetryable(err) || attempt == maxTxAttempts-1 {
+			break
+		}
+
+		log.WarnContext(ctx, "transaction retried", logger.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w", op, ctx.Err())
+		case <-time.After(txRetryDelay * time.Duration(attempt+1)):
+		}
+	}
+
+	return err
+}
+
+func (s *storage) runTx(ctx context.Context,
+	fn func(st Storage) error, opts postgres.TxOptions) error {
+	const op = "storage.runTx"
+
+	tx, err := postgres.BeginTx(ctx, s.pg, opts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	err = fn(newStorage(s.log, tx, s.rd, true))
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
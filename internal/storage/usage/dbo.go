@@ -0,0 +1,13 @@
+package usage
+
+import (
+	"github.com/google/uuid"
+)
+
+// Usage is what the user's notes take up. Notes of a workspace are
+// counted for the workspace owner, whoever wrote them.
+type Usage struct {
+	UserID uuid.UUID
+	Notes  int64
+	Bytes  int64
+}
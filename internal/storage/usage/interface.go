@@ -0,0 +1,14 @@
+package usage
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Storage interface {
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*Usage, error)
+	Reserve(ctx context.Context, userID uuid.UUID,
+		notes, bytes, maxNotes, maxBytes int64) (bool, error)
+	Recalculate(ctx context.Context, userID uuid.UUID) error
+}
@@ -0,0 +1,117 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) scan(ctx context.Context, row postgres.Row) (*Usage, error) {
+	const op = "storage.usage.scan"
+	log := s.log.With(logger.String("op", op))
+
+	usage := new(Usage)
+	err := row.Scan(&usage.UserID, &usage.Notes, &usage.Bytes)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return usage, nil
+}
+
+func (s *storage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) (*Usage, error) {
+	const op = "storage.usage.GetByUserID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM usage WHERE user_id = $1`
+
+	row := s.pg.QueryRow(ctx, sql, userID)
+
+	usage, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return usage, nil
+}
+
+// Reserve changes the usage by the given amounts in a single statement, so
+// concurrent writes can't overshoot the limits together. It reports false
+// and changes nothing when an increase goes over its limit. Decreases
+// always succeed, even for users already over a lowered limit.
+func (s *storage) Reserve(ctx context.Context, userID uuid.UUID,
+	notes, bytes, maxNotes, maxBytes int64) (bool, error) {
+	const op = "storage.usage.Reserve"
+	log := s.log.With(logger.String("op", op))
+
+	const insertSQL = `INSERT INTO usage (user_id, notes, bytes) 
+                       VALUES ($1, 0, 0) ON CONFLICT (user_id) DO NOTHING`
+
+	_, err := s.pg.Exec(ctx, insertSQL, userID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	const updateSQL = `UPDATE usage SET notes = notes + $2, 
+                       bytes = bytes + $3 WHERE user_id = $1 
+                       AND ($2 <= 0 OR notes + $2 <= $4) 
+                       AND ($3 <= 0 OR bytes + $3 <= $5)`
+
+	command, err := s.pg.Exec(
+		ctx, updateSQL, userID, notes, bytes, maxNotes, maxBytes)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return command.RowsAffected() == 1, nil
+}
+
+// Recalculate counts the usage again from the notes, for changes too
+// broad to track one note at a time, like deleting a workspace.
+func (s *storage) Recalculate(ctx context.Context, userID uuid.UUID) error {
+	const op = "storage.usage.Recalculate"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `INSERT INTO usage (user_id, notes, bytes) 
+                 SELECT $1, count(*), COALESCE(sum(octet_length(n.text)), 0) 
+                 FROM notes n LEFT JOIN workspaces w 
+                 ON w.id = n.workspace_id 
+                 WHERE COALESCE(w.owner_id, n.user_id) = $1 
+                 ON CONFLICT (user_id) DO UPDATE 
+                 SET notes = excluded.notes, bytes = excluded.bytes`
+
+	_, err := s.pg.Exec(ctx, sql, userID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -15,6 +15,14 @@ const (
 	StatusDeleted UserStatus = "deleted"
 )
 
+type UserRole string
+
+const (
+	RoleUser    UserRole = "user"
+	RoleSupport UserRole = "support"
+	RoleAdmin   UserRole = "admin"
+)
+
 type User struct {
 	ID           uuid.UUID
 	Login        string
@@ -23,4 +31,17 @@ type User struct {
 	Timezone     string
 	Status       UserStatus
 	CreatedAt    time.Time
+	Role         UserRole
+	// Email is where the mail goes. Accounts registered before it was asked
+	// for may have none.
+	Email *string
+}
+
+// Filter narrows List and Count down. Query matches a part of the login, the
+// email or the first name, case-insensitively. Nil fields match everything.
+type Filter struct {
+	Query        *string
+	Status       *UserStatus
+	Role         *UserRole
+	CreatedAfter *time.Time
 }
@@ -9,9 +9,14 @@ import (
 type Storage interface {
 	Create(ctx context.Context, user *User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*User, error)
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*User, error)
 	GetByLogin(ctx context.Context, login string) (*User, error)
-	List(ctx context.Context, limit, offset *uint64) ([]*User, error)
-	Count(ctx context.Context) (uint64, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	GetForUpdate(ctx context.Context, id uuid.UUID) (*User, error)
+	List(ctx context.Context,
+		filter *Filter, limit, offset *uint64) ([]*User, error)
+	Count(ctx context.Context, filter *Filter) (uint64, error)
 	Update(ctx context.Context, user *User) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	Purge(ctx context.Context, id uuid.UUID) error
 }
@@ -14,11 +14,11 @@ import (
 
 type storage struct {
 	log logger.Logger
-	pg  *postgres.Postgres
+	pg  postgres.DB
 	rd  *redis.Redis
 }
 
-func New(log logger.Logger, pg *postgres.Postgres, rd *redis.Redis) Storage {
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
 	return &storage{
 		log: log,
 		pg:  pg,
@@ -33,7 +33,8 @@ func (s *storage) scan(ctx context.Context, row postgres.Row) (*User, error) {
 	user := new(User)
 	err := row.Scan(
 		&user.ID, &user.Login, &user.PasswordHash, &user.FirstName,
-		&user.Timezone, &user.Status, &user.CreatedAt)
+		&user.Timezone, &user.Status, &user.CreatedAt, &user.Role,
+		&user.Email)
 	if err != nil && errors.Is(err, postgres.ErrNoRows) {
 		return nil, nil
 	} else if err != nil {
@@ -49,12 +50,12 @@ func (s *storage) Create(ctx context.Context, user *User) error {
 	log := s.log.With(logger.String("op", op))
 
 	const sql = `INSERT INTO users (id, login, password_hash, 
-                 first_name, timezone, status, created_at) 
-                 VALUES ($1, $2, $3, $4, $5, $6, $7)`
+                 first_name, timezone, status, created_at, role, email) 
+                 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
 
 	_, err := s.pg.Exec(
-		ctx, sql, user.ID, user.Login, user.PasswordHash,
-		user.FirstName, user.Timezone, user.Status, user.CreatedAt)
+		ctx, sql, user.ID, user.Login, user.PasswordHash, user.FirstName,
+		user.Timezone, user.Status, user.CreatedAt, user.Role, user.Email)
 	if err != nil {
 		log.ErrorContext(ctx, "", logger.Error(err))
 		return fmt.Errorf("%s: %w", op, err)
@@ -80,6 +81,35 @@ func (s *storage) GetByID(ctx context.Context, id uuid.UUID) (*User, error) {
 	return user, nil
 }
 
+// GetByIDs returns the users that exist of the ones asked for, in no
+// particular order.
+func (s *storage) GetByIDs(
+	ctx context.Context, ids []uuid.UUID) ([]*User, error) {
+	const op = "storage.users.GetByIDs"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM users WHERE id = ANY ($1)`
+
+	rows, err := s.pg.Query(ctx, sql, ids)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	users := make([]*User, 0, len(ids))
+	for rows.Next() {
+		user, err := s.scan(ctx, rows)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
 func (s *storage) GetByLogin(ctx context.Context, login string) (*User, error) {
 	const op = "storage.users.GetByLogin"
 	log := s.log.With(logger.String("op", op))
@@ -97,14 +127,73 @@ func (s *storage) GetByLogin(ctx context.Context, login string) (*User, error) {
 	return user, nil
 }
 
-func (s *storage) List(
-	ctx context.Context, limit, offset *uint64) ([]*User, error) {
+func (s *storage) GetByEmail(ctx context.Context, email string) (*User, error) {
+	const op = "storage.users.GetByEmail"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM users WHERE email = $1`
+
+	row := s.pg.QueryRow(ctx, sql, email)
+
+	user, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// GetForUpdate locks the user until the transaction ends, so a status change
+// made from what it read isn't raced by another one.
+func (s *storage) GetForUpdate(
+	ctx context.Context, id uuid.UUID) (*User, error) {
+	const op = "storage.users.GetForUpdate"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM users WHERE id = $1 FOR UPDATE`
+
+	row := s.pg.QueryRow(ctx, sql, id)
+
+	user, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// filterSQL is shared by List and Count, so a page and the total always
+// describe the same set of users.
+const filterSQL = `WHERE ($1::TEXT IS NULL 
+                   OR strpos(lower(login), lower($1)) > 0 
+                   OR strpos(lower(email), lower($1)) > 0 
+                   OR strpos(lower(first_name), lower($1)) > 0) 
+                   AND ($2::TEXT IS NULL OR status = $2) 
+                   AND ($3::TEXT IS NULL OR role = $3) 
+                   AND ($4::TIMESTAMPTZ IS NULL OR created_at >= $4)`
+
+func filterArgs(filter *Filter) []any {
+	if filter == nil {
+		filter = new(Filter)
+	}
+
+	return []any{
+		filter.Query, filter.Status, filter.Role, filter.CreatedAfter,
+	}
+}
+
+func (s *storage) List(ctx context.Context,
+	filter *Filter, limit, offset *uint64) ([]*User, error) {
 	const op = "storage.users.List"
 	log := s.log.With(logger.String("op", op))
 
-	const sql = `SELECT * FROM users LIMIT $1 OFFSET $2`
+	const sql = `SELECT * FROM users ` + filterSQL + ` 
+                 ORDER BY created_at DESC, id LIMIT $5 OFFSET $6`
 
-	rows, err := s.pg.Query(ctx, sql, limit, offset)
+	args := append(filterArgs(filter), limit, offset)
+	rows, err := s.pg.Query(ctx, sql, args...)
 	if err != nil {
 		log.ErrorContext(ctx, "", logger.Error(err))
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -124,13 +213,13 @@ func (s *storage) List(
 	return users, nil
 }
 
-func (s *storage) Count(ctx context.Context) (uint64, error) {
+func (s *storage) Count(ctx context.Context, filter *Filter) (uint64, error) {
 	const op = "storage.users.Count"
 	log := s.log.With(logger.String("op", op))
 
-	const sql = `SELECT COUNT(*) FROM users`
+	const sql = `SELECT COUNT(*) FROM users ` + filterSQL
 
-	row := s.pg.QueryRow(ctx, sql)
+	row := s.pg.QueryRow(ctx, sql, filterArgs(filter)...)
 	count := new(uint64)
 	err := row.Scan(&count)
 	if err != nil {
@@ -146,12 +235,13 @@ func (s *storage) Update(ctx context.Context, user *User) error {
 	log := s.log.With(logger.String("op", op))
 
 	const sql = `UPDATE users SET login = $1, password_hash = $2, 
-                 first_name = $3, timezone = $4, status = $6, 
-                 created_at = $7 WHERE id = $8`
+                 first_name = $3, timezone = $4, status = $5, 
+                 created_at = $6, role = $7, email = $8 WHERE id = $9`
 
 	_, err := s.pg.Exec(
 		ctx, sql, user.Login, user.PasswordHash, user.FirstName,
-		user.Timezone, user.Status, user.CreatedAt, user.ID)
+		user.Timezone, user.Status, user.CreatedAt, user.Role, user.Email,
+		user.ID)
 	if err != nil {
 		log.ErrorContext(ctx, "", logger.Error(err))
 		return fmt.Errorf("%s: %w", op, err)
@@ -174,3 +264,33 @@ func (s *storage) Delete(ctx context.Context, id uuid.UUID) error {
 
 	return nil
 }
+
+// Purge removes the user with their notes and sessions. It runs several
+// statements, so it belongs in a transaction with WithTx. The rest of the
+// user's data is removed by the foreign key cascades, including the
+// workspaces the user still owns, so shared workspaces are passed on with
+// the workspaces' TransferOwned first. Notes the user wrote in other
+// people's workspaces stay there and pass to the workspace owner.
+func (s *storage) Purge(ctx context.Context, id uuid.UUID) error {
+	const op = "storage.users.Purge"
+	log := s.log.With(logger.String("op", op))
+
+	queries := []string{
+		`UPDATE notes n SET user_id = w.owner_id FROM workspaces w 
+         WHERE n.workspace_id = w.id AND n.user_id = $1 
+         AND w.owner_id <> $1`,
+		`DELETE FROM notes WHERE user_id = $1`,
+		`DELETE FROM sessions WHERE user_id = $1`,
+		`DELETE FROM users WHERE id = $1`,
+	}
+
+	for _, sql := range queries {
+		_, err := s.pg.Exec(ctx, sql, id)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
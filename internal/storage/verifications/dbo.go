@@ -0,0 +1,15 @@
+package verifications
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Verification is the only email verification token of a user that is
+// still accepted. Sending a new link replaces it.
+type Verification struct {
+	UserID    uuid.UUID `json:"user_id"`
+	TokenID   uuid.UUID `json:"token_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,13 @@
+package verifications
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Storage interface {
+	Create(ctx context.Context, verification *Verification) error
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*Verification, error)
+	Delete(ctx context.Context, userID uuid.UUID) error
+}
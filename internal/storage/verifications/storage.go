@@ -0,0 +1,90 @@
+package verifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func key(userID uuid.UUID) string {
+	return "verification:" + userID.String()
+}
+
+func (s *storage) Create(
+	ctx context.Context, verification *Verification) error {
+	const op = "storage.verifications.Create"
+	log := s.log.With(logger.String("op", op))
+
+	value, err := json.Marshal(verification)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	ttl := time.Until(verification.ExpiresAt)
+	err = s.rd.Set(ctx, key(verification.UserID), value, ttl).Err()
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) (*Verification, error) {
+	const op = "storage.verifications.GetByUserID"
+	log := s.log.With(logger.String("op", op))
+
+	value, err := s.rd.Get(ctx, key(userID)).Bytes()
+	if err != nil && errors.Is(err, redis.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	verification := new(Verification)
+	err = json.Unmarshal(value, verification)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return verification, nil
+}
+
+func (s *storage) Delete(ctx context.Context, userID uuid.UUID) error {
+	const op = "storage.verifications.Delete"
+	log := s.log.With(logger.String("op", op))
+
+	err := s.rd.Del(ctx, key(userID)).Err()
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package webhookdeliveries
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Delivery is an event sent to a webhook. Pending deliveries are sent at
+// NextAttemptAt, ResponseCode and Error describe the last attempt.
+// ReplayOf links a replay to the delivery it repeats.
+type Delivery struct {
+	ID            uuid.UUID
+	WebhookID     uuid.UUID
+	EventID       uuid.UUID
+	EventType     string
+	Payload       json.RawMessage
+	Status        Status
+	Attempts      int
+	NextAttemptAt time.Time
+	ResponseCode  *int
+	Error         *string
+	ReplayOf      *uuid.UUID
+	DeliveredAt   *time.Time
+	CreatedAt     time.Time
+}
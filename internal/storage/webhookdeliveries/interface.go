@@ -0,0 +1,19 @@
+package webhookdeliveries
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Storage interface {
+	Create(ctx context.Context, delivery *Delivery) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Delivery, error)
+	GetByWebhookID(ctx context.Context,
+		webhookID uuid.UUID, limit, offset uint64) ([]*Delivery, error)
+	Claim(ctx context.Context, now, leaseUntil time.Time,
+		limit uint64) ([]*Delivery, error)
+	Update(ctx context.Context, delivery *Delivery) error
+	DeleteBefore(ctx context.Context, before time.Time) error
+}
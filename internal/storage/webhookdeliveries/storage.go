@@ -0,0 +1,192 @@
+package webhookdeliveries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) scan(
+	ctx context.Context, row postgres.Row) (*Delivery, error) {
+	const op = "storage.webhookdeliveries.scan"
+	log := s.log.With(logger.String("op", op))
+
+	delivery := new(Delivery)
+	err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.EventID,
+		&delivery.EventType, &delivery.Payload, &delivery.Status,
+		&delivery.Attempts, &delivery.NextAttemptAt, &delivery.ResponseCode,
+		&delivery.Error, &delivery.ReplayOf, &delivery.DeliveredAt,
+		&delivery.CreatedAt)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return delivery, nil
+}
+
+// Create skips an event already delivered to the webhook, so dispatching
+// the same event again doesn't send it twice. Replays are always created.
+func (s *storage) Create(ctx context.Context, delivery *Delivery) error {
+	const op = "storage.webhookdeliveries.Create"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `INSERT INTO webhook_deliveries (id, webhook_id, event_id, 
+                 event_type, payload, status, attempts, next_attempt_at, 
+                 response_code, error, replay_of, delivered_at, created_at) 
+                 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, 
+                 $13) ON CONFLICT (webhook_id, event_id) 
+                 WHERE replay_of IS NULL DO NOTHING`
+
+	_, err := s.pg.Exec(ctx, sql, delivery.ID, delivery.WebhookID,
+		delivery.EventID, delivery.EventType, delivery.Payload,
+		delivery.Status, delivery.Attempts, delivery.NextAttemptAt,
+		delivery.ResponseCode, delivery.Error, delivery.ReplayOf,
+		delivery.DeliveredAt, delivery.CreatedAt)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) GetByID(
+	ctx context.Context, id uuid.UUID) (*Delivery, error) {
+	const op = "storage.webhookdeliveries.GetByID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM webhook_deliveries WHERE id = $1`
+
+	row := s.pg.QueryRow(ctx, sql, id)
+
+	delivery, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return delivery, nil
+}
+
+// GetByWebhookID returns the newest deliveries first.
+func (s *storage) GetByWebhookID(ctx context.Context,
+	webhookID uuid.UUID, limit, offset uint64) ([]*Delivery, error) {
+	const op = "storage.webhookdeliveries.GetByWebhookID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM webhook_deliveries WHERE webhook_id = $1 
+                 ORDER BY created_at DESC LIMIT $2 OFFSET $3`
+
+	rows, err := s.pg.Query(ctx, sql, webhookID, limit, offset)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*Delivery, 0)
+	for rows.Next() {
+		delivery, err := s.scan(ctx, rows)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+// Claim takes the pending deliveries due by now and leases them until
+// leaseUntil, so other replicas skip them meanwhile. A delivery whose
+// sender died is claimed again once the lease is over.
+func (s *storage) Claim(ctx context.Context, now, leaseUntil time.Time,
+	limit uint64) ([]*Delivery, error) {
+	const op = "storage.webhookdeliveries.Claim"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `UPDATE webhook_deliveries SET next_attempt_at = $2 
+                 WHERE id IN (SELECT id FROM webhook_deliveries 
+                 WHERE status = 'pending' AND next_attempt_at <= $1 
+                 ORDER BY next_attempt_at LIMIT $3 FOR UPDATE SKIP LOCKED) 
+                 RETURNING *`
+
+	rows, err := s.pg.Query(ctx, sql, now, leaseUntil, limit)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*Delivery, 0)
+	for rows.Next() {
+		delivery, err := s.scan(ctx, rows)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+func (s *storage) Update(ctx context.Context, delivery *Delivery) error {
+	const op = "storage.webhookdeliveries.Update"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `UPDATE webhook_deliveries SET status = $1, attempts = $2, 
+                 next_attempt_at = $3, response_code = $4, error = $5, 
+                 delivered_at = $6 WHERE id = $7`
+
+	_, err := s.pg.Exec(ctx, sql, delivery.Status, delivery.Attempts,
+		delivery.NextAttemptAt, delivery.ResponseCode, delivery.Error,
+		delivery.DeliveredAt, delivery.ID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteBefore trims the delivery log, keeping pending deliveries.
+func (s *storage) DeleteBefore(ctx context.Context, before time.Time) error {
+	const op = "storage.webhookdeliveries.DeleteBefore"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `DELETE FROM webhook_deliveries WHERE created_at < $1 
+                 AND status <> 'pending'`
+
+	_, err := s.pg.Exec(ctx, sql, before)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
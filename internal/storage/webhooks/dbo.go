@@ -0,0 +1,22 @@
+package webhooks
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook is an endpoint of the user's that gets the events it subscribed
+// to. Secret signs the deliveries, so it is kept as is. Failures counts the
+// deliveries that failed in a row.
+type Webhook struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	URL       string
+	Secret    string
+	Events    []string
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+	Failures  int
+}
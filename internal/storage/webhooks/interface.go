@@ -0,0 +1,22 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Storage interface {
+	Create(ctx context.Context, webhook *Webhook) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Webhook, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*Webhook, error)
+	GetSubscribed(ctx context.Context, userID uuid.UUID,
+		workspaceID *uuid.UUID, eventType string) ([]*Webhook, error)
+	CountByUserID(ctx context.Context, userID uuid.UUID) (uint64, error)
+	Update(ctx context.Context, webhook *Webhook) error
+	RecordFailure(ctx context.Context,
+		id uuid.UUID, disableAfter int, now time.Time) (bool, error)
+	ResetFailures(ctx context.Context, id uuid.UUID) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
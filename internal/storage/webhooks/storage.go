@@ -0,0 +1,237 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) scan(
+	ctx context.Context, row postgres.Row) (*Webhook, error) {
+	const op = "storage.webhooks.scan"
+	log := s.log.With(logger.String("op", op))
+
+	webhook := new(Webhook)
+	err := row.Scan(&webhook.ID, &webhook.UserID, &webhook.URL,
+		&webhook.Secret, &webhook.Events, &webhook.Active,
+		&webhook.CreatedAt, &webhook.UpdatedAt, &webhook.Failures)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return webhook, nil
+}
+
+func (s *storage) Create(ctx context.Context, webhook *Webhook) error {
+	const op = "storage.webhooks.Create"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `INSERT INTO webhooks (id, user_id, url, secret, events, 
+                 active, created_at, updated_at, failures) 
+                 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := s.pg.Exec(ctx, sql, webhook.ID, webhook.UserID, webhook.URL,
+		webhook.Secret, webhook.Events, webhook.Active, webhook.CreatedAt,
+		webhook.UpdatedAt, webhook.Failures)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) GetByID(ctx context.Context, id uuid.UUID) (*Webhook, error) {
+	const op = "storage.webhooks.GetByID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM webhooks WHERE id = $1`
+
+	row := s.pg.QueryRow(ctx, sql, id)
+
+	webhook, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return webhook, nil
+}
+
+func (s *storage) GetByUserID(
+	ctx context.Context, userID uuid.UUID) ([]*Webhook, error) {
+	const op = "storage.webhooks.GetByUserID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM webhooks WHERE user_id = $1 
+                 ORDER BY created_at`
+
+	rows, err := s.pg.Query(ctx, sql, userID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]*Webhook, 0)
+	for rows.Next() {
+		webhook, err := s.scan(ctx, rows)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, nil
+}
+
+// GetSubscribed returns the active webhooks subscribed to the event of the
+// user and, when workspaceID is set, of the workspace's members.
+func (s *storage) GetSubscribed(ctx context.Context, userID uuid.UUID,
+	workspaceID *uuid.UUID, eventType string) ([]*Webhook, error) {
+	const op = "storage.webhooks.GetSubscribed"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM webhooks WHERE active AND $3 = ANY (events) 
+                 AND (user_id = $1 OR user_id IN (SELECT user_id 
+                 FROM workspace_members WHERE workspace_id = $2)) 
+                 ORDER BY created_at`
+
+	rows, err := s.pg.Query(ctx, sql, userID, workspaceID, eventType)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]*Webhook, 0)
+	for rows.Next() {
+		webhook, err := s.scan(ctx, rows)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, nil
+}
+
+func (s *storage) CountByUserID(
+	ctx context.Context, userID uuid.UUID) (uint64, error) {
+	const op = "storage.webhooks.CountByUserID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT count(*) FROM webhooks WHERE user_id = $1`
+
+	var count uint64
+	err := s.pg.QueryRow(ctx, sql, userID).Scan(&count)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+func (s *storage) Update(ctx context.Context, webhook *Webhook) error {
+	const op = "storage.webhooks.Update"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `UPDATE webhooks SET url = $1, secret = $2, events = $3, 
+                 active = $4, updated_at = $5, failures = $6 WHERE id = $7`
+
+	_, err := s.pg.Exec(ctx, sql, webhook.URL, webhook.Secret,
+		webhook.Events, webhook.Active, webhook.UpdatedAt,
+		webhook.Failures, webhook.ID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RecordFailure counts a failed delivery and disables the webhook once
+// disableAfter deliveries failed in a row. It reports whether this failure
+// disabled it.
+func (s *storage) RecordFailure(ctx context.Context,
+	id uuid.UUID, disableAfter int, now time.Time) (bool, error) {
+	const op = "storage.webhooks.RecordFailure"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `UPDATE webhooks SET failures = failures + 1, 
+                 active = active AND failures + 1 < $2, 
+                 updated_at = CASE WHEN active AND failures + 1 >= $2 
+                 THEN $3 ELSE updated_at END 
+                 WHERE id = $1 RETURNING failures = $2`
+
+	var disabled bool
+	err := s.pg.QueryRow(ctx, sql, id, disableAfter, now).Scan(&disabled)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return disabled, nil
+}
+
+// ResetFailures starts counting the failures over after a delivery
+// succeeded.
+func (s *storage) ResetFailures(ctx context.Context, id uuid.UUID) error {
+	const op = "storage.webhooks.ResetFailures"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `UPDATE webhooks SET failures = 0 
+                 WHERE id = $1 AND failures > 0`
+
+	_, err := s.pg.Exec(ctx, sql, id)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) Delete(ctx context.Context, id uuid.UUID) error {
+	const op = "storage.webhooks.Delete"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `DELETE FROM webhooks WHERE id = $1`
+
+	_, err := s.pg.Exec(ctx, sql, id)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package workspacemembers
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Role string
+
+// Owners manage the workspace and its members, editors change notes and
+// viewers only read them.
+const (
+	RoleOwner  Role = "owner"
+	RoleEditor Role = "editor"
+	RoleViewer Role = "viewer"
+)
+
+type Member struct {
+	WorkspaceID uuid.UUID
+	UserID      uuid.UUID
+	Role        Role
+	CreatedAt   time.Time
+}
@@ -0,0 +1,17 @@
+package workspacemembers
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Storage interface {
+	Create(ctx context.Context, member *Member) error
+	Get(ctx context.Context,
+		workspaceID, userID uuid.UUID) (*Member, error)
+	GetByWorkspaceID(
+		ctx context.Context, workspaceID uuid.UUID) ([]*Member, error)
+	Update(ctx context.Context, member *Member) error
+	Delete(ctx context.Context, workspaceID, userID uuid.UUID) error
+}
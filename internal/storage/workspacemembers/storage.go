@@ -0,0 +1,142 @@
+package workspacemembers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) scan(ctx context.Context, row postgres.Row) (*Member, error) {
+	const op = "storage.workspacemembers.scan"
+	log := s.log.With(logger.String("op", op))
+
+	member := new(Member)
+	err := row.Scan(&member.WorkspaceID, &member.UserID,
+		&member.Role, &member.CreatedAt)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return member, nil
+}
+
+func (s *storage) Create(ctx context.Context, member *Member) error {
+	const op = "storage.workspacemembers.Create"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `INSERT INTO workspace_members (workspace_id, user_id, 
+                 role, created_at) VALUES ($1, $2, $3, $4)`
+
+	_, err := s.pg.Exec(ctx, sql, member.WorkspaceID, member.UserID,
+		member.Role, member.CreatedAt)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) Get(ctx context.Context,
+	workspaceID, userID uuid.UUID) (*Member, error) {
+	const op = "storage.workspacemembers.Get"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM workspace_members 
+                 WHERE workspace_id = $1 AND user_id = $2`
+
+	row := s.pg.QueryRow(ctx, sql, workspaceID, userID)
+
+	member, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return member, nil
+}
+
+func (s *storage) GetByWorkspaceID(
+	ctx context.Context, workspaceID uuid.UUID) ([]*Member, error) {
+	const op = "storage.workspacemembers.GetByWorkspaceID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM workspace_members 
+                 WHERE workspace_id = $1 ORDER BY created_at`
+
+	rows, err := s.pg.Query(ctx, sql, workspaceID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	members := make([]*Member, 0)
+	for rows.Next() {
+		member, err := s.scan(ctx, rows)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+func (s *storage) Update(ctx context.Context, member *Member) error {
+	const op = "storage.workspacemembers.Update"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `UPDATE workspace_members SET role = $1 
+                 WHERE workspace_id = $2 AND user_id = $3`
+
+	_, err := s.pg.Exec(
+		ctx, sql, member.Role, member.WorkspaceID, member.UserID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) Delete(
+	ctx context.Context, workspaceID, userID uuid.UUID) error {
+	const op = "storage.workspacemembers.Delete"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `DELETE FROM workspace_members 
+                 WHERE workspace_id = $1 AND user_id = $2`
+
+	_, err := s.pg.Exec(ctx, sql, workspaceID, userID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
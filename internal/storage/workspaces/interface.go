@@ -0,0 +1,18 @@
+package workspaces
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Storage interface {
+	Create(ctx context.Context, workspace *Workspace) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Workspace, error)
+	GetByMemberID(
+		ctx context.Context, userID uuid.UUID) ([]*Workspace, error)
+	Update(ctx context.Context, workspace *Workspace) error
+	TransferOwned(
+		ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
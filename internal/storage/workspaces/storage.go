@@ -0,0 +1,186 @@
+package workspaces
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type storage struct {
+	log logger.Logger
+	pg  postgres.DB
+	rd  *redis.Redis
+}
+
+func New(log logger.Logger, pg postgres.DB, rd *redis.Redis) Storage {
+	return &storage{
+		log: log,
+		pg:  pg,
+		rd:  rd,
+	}
+}
+
+func (s *storage) scan(
+	ctx context.Context, row postgres.Row) (*Workspace, error) {
+	const op = "storage.workspaces.scan"
+	log := s.log.With(logger.String("op", op))
+
+	workspace := new(Workspace)
+	err := row.Scan(&workspace.ID, &workspace.Name,
+		&workspace.OwnerID, &workspace.CreatedAt)
+	if err != nil && errors.Is(err, postgres.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return workspace, nil
+}
+
+func (s *storage) Create(ctx context.Context, workspace *Workspace) error {
+	const op = "storage.workspaces.Create"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `INSERT INTO workspaces (id, name, owner_id, created_at) 
+                 VALUES ($1, $2, $3, $4)`
+
+	_, err := s.pg.Exec(ctx, sql, workspace.ID, workspace.Name,
+		workspace.OwnerID, workspace.CreatedAt)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *storage) GetByID(
+	ctx context.Context, id uuid.UUID) (*Workspace, error) {
+	const op = "storage.workspaces.GetByID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT * FROM workspaces WHERE id = $1`
+
+	row := s.pg.QueryRow(ctx, sql, id)
+
+	workspace, err := s.scan(ctx, row)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return workspace, nil
+}
+
+func (s *storage) GetByMemberID(
+	ctx context.Context, userID uuid.UUID) ([]*Workspace, error) {
+	const op = "storage.workspaces.GetByMemberID"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `SELECT w.* FROM workspaces w 
+                 JOIN workspace_members m ON m.workspace_id = w.id 
+                 WHERE m.user_id = $1 ORDER BY w.created_at`
+
+	rows, err := s.pg.Query(ctx, sql, userID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	workspaces := make([]*Workspace, 0)
+	for rows.Next() {
+		workspace, err := s.scan(ctx, rows)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		workspaces = append(workspaces, workspace)
+	}
+
+	return workspaces, nil
+}
+
+func (s *storage) Update(ctx context.Context, workspace *Workspace) error {
+	const op = "storage.workspaces.Update"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `UPDATE workspaces SET name = $1, owner_id = $2, 
+                 created_at = $3 WHERE id = $4`
+
+	_, err := s.pg.Exec(ctx, sql, workspace.Name, workspace.OwnerID,
+		workspace.CreatedAt, workspace.ID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// TransferOwned passes the workspaces of the user that have other members
+// to one of them: the earliest editor, or the earliest viewer when there
+// are no editors. The heir becomes the workspace's owner member. It
+// returns the users who received workspaces.
+func (s *storage) TransferOwned(
+	ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	const op = "storage.workspaces.TransferOwned"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `WITH heirs AS (SELECT DISTINCT ON (m.workspace_id) 
+                 m.workspace_id, m.user_id FROM workspace_members m 
+                 JOIN workspaces w ON w.id = m.workspace_id 
+                 WHERE w.owner_id = $1 AND m.user_id <> $1 
+                 ORDER BY m.workspace_id, m.role = 'editor' DESC, 
+                 m.created_at, m.user_id), 
+                 moved AS (UPDATE workspaces w SET owner_id = heirs.user_id 
+                 FROM heirs WHERE w.id = heirs.workspace_id 
+                 RETURNING w.id, w.owner_id) 
+                 UPDATE workspace_members m SET role = 'owner' FROM moved 
+                 WHERE m.workspace_id = moved.id 
+                 AND m.user_id = moved.owner_id 
+                 RETURNING m.user_id`
+
+	rows, err := s.pg.Query(ctx, sql, userID)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	heirs := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var heir uuid.UUID
+		err = rows.Scan(&heir)
+		if err != nil {
+			log.ErrorContext(ctx, "", logger.Error(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		heirs = append(heirs, heir)
+	}
+
+	return heirs, nil
+}
+
+// Delete removes the workspace together with its members and notes.
+func (s *storage) Delete(ctx context.Context, id uuid.UUID) error {
+	const op = "storage.workspaces.Delete"
+	log := s.log.With(logger.String("op", op))
+
+	const sql = `DELETE FROM workspaces WHERE id = $1`
+
+	_, err := s.pg.Exec(ctx, sql, id)
+	if err != nil {
+		log.ErrorContext(ctx, "", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,8 @@
+package totp
+
+type TOTP interface {
+	GenerateSecret() (string, error)
+	URI(account, secret string) string
+	QRCode(uri string) ([]byte, error)
+	Validate(secret, code string, lastStep int64) (int64, bool)
+}
@@ -0,0 +1,61 @@
+package webauthn
+
+import (
+	"fmt"
+	"time"
+
+	"cloud-notes/internal/config"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+type WebAuthn = webauthn.WebAuthn
+type User = webauthn.User
+type Credential = webauthn.Credential
+type Credentials = webauthn.Credentials
+type SessionData = webauthn.SessionData
+
+type CredentialCreation = protocol.CredentialCreation
+type CredentialAssertion = protocol.CredentialAssertion
+type AuthenticatorTransport = protocol.AuthenticatorTransport
+
+var WithExclusions = webauthn.WithExclusions
+var ParseCredentialCreation = protocol.ParseCredentialCreationResponseBytes
+var ParseCredentialAssertion = protocol.ParseCredentialRequestResponseBytes
+
+func New(cfg *config.WebAuthn) (*WebAuthn, error) {
+	timeout := webauthn.TimeoutConfig{
+		Enforce:    true,
+		Timeout:    time.Second * time.Duration(cfg.Timeout),
+		TimeoutUVD: time.Second * time.Duration(cfg.Timeout),
+	}
+
+	w, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: cfg.RPName,
+		RPOrigins:     cfg.RPOrigins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationPreferred,
+		},
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        timeout,
+			Registration: timeout,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure webauthn: %w", err)
+	}
+
+	return w, nil
+}
+
+func MustNew(cfg *config.WebAuthn) *WebAuthn {
+	w, err := New(cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	return w
+}
@@ -0,0 +1,112 @@
+// Package worker wires the background jobs: the job handlers, their
+// schedules and the webhooks consumer group. cmd/worker runs it on its own,
+// the server runs it in-process with STORAGE=memory, where no other process
+// can see the data.
+package worker
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"cloud-notes/internal/audit"
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/events"
+	"cloud-notes/internal/jobs"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/mailer"
+	"cloud-notes/internal/oidc"
+	"cloud-notes/internal/password"
+	"cloud-notes/internal/quota"
+	"cloud-notes/internal/security"
+	authService "cloud-notes/internal/services/auth"
+	userService "cloud-notes/internal/services/user"
+	webhooksService "cloud-notes/internal/services/webhooks"
+	"cloud-notes/internal/storage"
+	"cloud-notes/internal/totp"
+	"cloud-notes/internal/webauthn"
+)
+
+type Worker struct {
+	log      logger.Logger
+	cfg      *config.Config
+	queue    jobs.Queue
+	bus      events.Bus
+	webhooks webhooksService.Service
+}
+
+func MustNew(log logger.Logger, st storage.Storage,
+	cfg *config.Config) *Worker {
+	sec := security.MustNew(log, st, &cfg.JWT)
+	otp := totp.New(&cfg.TOTP, time.Now)
+	wa := webauthn.MustNew(&cfg.WebAuthn)
+	ml := mailer.MustLoad(log, &cfg.Mailer)
+	oc := oidc.New(&cfg.OIDC)
+	pw := password.MustNew(&cfg.PasswordHash)
+	pp := password.NewPolicy(&cfg.PasswordPolicy, &cfg.PasswordHash)
+	au := audit.New(log, st)
+	qt := quota.New(log, st, &cfg.Quota)
+	bus := events.New(log, st, &cfg.Events)
+
+	authSrv := authService.New(log, st, sec, otp, wa, ml, oc, pw, pp, au, cfg)
+	userSrv := userService.New(log, st, sec, au, qt, cfg)
+	webhooksSrv := webhooksService.New(log, st, cfg)
+
+	q := jobs.New(log, st, &cfg.Jobs)
+	q.Handle(jobs.TypeSendVerification, jobs.Typed(
+		func(ctx context.Context, payload *jobs.SendVerification) error {
+			return authSrv.SendVerification(ctx, payload.Login)
+		}))
+	q.Handle(jobs.TypeSendPasswordReset, jobs.Typed(
+		func(ctx context.Context, payload *jobs.SendPasswordReset) error {
+			return authSrv.SendPasswordReset(ctx, payload.Login)
+		}))
+	q.Handle(jobs.TypeBuildExport, jobs.Typed(
+		func(ctx context.Context, payload *jobs.BuildExport) error {
+			return userSrv.BuildExport(ctx, payload.ExportID)
+		}))
+	q.Handle(jobs.TypePurgeDeletedUsers,
+		jobs.Task(userSrv.PurgeDeletedUsers))
+	q.Handle(jobs.TypePurgeExpiredExports,
+		jobs.Task(userSrv.PurgeExpiredExports))
+	q.Handle(jobs.TypeDispatchEvents, jobs.Task(bus.Dispatch))
+	q.Handle(jobs.TypePurgeEvents, jobs.Task(bus.Purge))
+	q.Handle(jobs.TypeDeliverWebhooks,
+		jobs.Task(webhooksSrv.DeliverWebhooks))
+	q.Handle(jobs.TypePurgeWebhookDeliveries,
+		jobs.Task(webhooksSrv.PurgeDeliveries))
+	q.Handle(jobs.TypePurgeJobs, jobs.Task(q.Purge))
+
+	q.Schedule(jobs.TypePurgeDeletedUsers,
+		time.Second*time.Duration(cfg.Deletion.PurgeInterval))
+	q.Schedule(jobs.TypePurgeExpiredExports,
+		time.Second*time.Duration(cfg.Export.CleanupInterval))
+	q.Schedule(jobs.TypeDispatchEvents,
+		time.Second*time.Duration(cfg.Events.Interval))
+	q.Schedule(jobs.TypePurgeEvents, time.Hour)
+	q.Schedule(jobs.TypeDeliverWebhooks,
+		time.Second*time.Duration(cfg.Webhook.Interval))
+	q.Schedule(jobs.TypePurgeWebhookDeliveries, time.Hour)
+	q.Schedule(jobs.TypePurgeJobs, time.Hour)
+
+	return &Worker{
+		log:      log,
+		cfg:      cfg,
+		queue:    q,
+		bus:      bus,
+		webhooks: webhooksSrv,
+	}
+}
+
+// Run works until ctx is done.
+func (w *Worker) Run(ctx context.Context) {
+	// Every worker is a member of the webhooks consumer group, so each
+	// event becomes deliveries once, whichever worker reads it.
+	hostname, _ := os.Hostname()
+	go w.bus.Consume(ctx, "webhooks", hostname, w.webhooks.HandleEvent)
+
+	w.log.InfoContext(ctx, "starting worker",
+		logger.String("env", w.cfg.Env),
+		logger.Int("concurrency", w.cfg.Jobs.Concurrency))
+	w.queue.Run(ctx)
+}
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS passkeys
+(
+    id               UUID PRIMARY KEY,
+    user_id          UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name             TEXT        NOT NULL,
+    credential_id    BYTEA UNIQUE NOT NULL,
+    public_key       BYTEA       NOT NULL,
+    attestation_type TEXT        NOT NULL,
+    transports       TEXT[]      NOT NULL,
+    aaguid           BYTEA       NOT NULL,
+    sign_count       BIGINT      NOT NULL,
+    backup_eligible  BOOLEAN     NOT NULL,
+    backup_state     BOOLEAN     NOT NULL,
+    last_used_at     TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS passkeys_user_id_idx ON passkeys (user_id);
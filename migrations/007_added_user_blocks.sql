@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS user_blocks
+(
+    user_id         UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    reason          TEXT        NOT NULL,
+    blocked_by      UUID        REFERENCES users (id) ON DELETE SET NULL,
+    expires_at      TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL,
+    previous_status TEXT        NOT NULL CHECK (previous_status IN ('pending', 'active'))
+);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS user_deletions
+(
+    user_id    UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    purge_at   TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS user_deletions_purge_at_idx ON user_deletions (purge_at);
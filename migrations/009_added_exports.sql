@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS exports
+(
+    id           UUID PRIMARY KEY,
+    user_id      UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status       TEXT        NOT NULL CHECK (status IN ('pending', 'ready', 'failed')),
+    archive      BYTEA,
+    completed_at TIMESTAMPTZ,
+    expires_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS exports_user_id_idx ON exports (user_id);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS personal_tokens
+(
+    id           UUID PRIMARY KEY,
+    user_id      UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name         TEXT        NOT NULL,
+    token_hash   TEXT UNIQUE NOT NULL,
+    scopes       TEXT[]      NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    expires_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS personal_tokens_user_id_idx ON personal_tokens (user_id);
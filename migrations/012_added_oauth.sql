@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS oauth_clients
+(
+    id            UUID PRIMARY KEY,
+    owner_id      UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name          TEXT        NOT NULL,
+    secret_hash   TEXT,
+    redirect_uris TEXT[]      NOT NULL,
+    scopes        TEXT[]      NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS oauth_clients_owner_id_idx ON oauth_clients (owner_id);
+
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS client_id          UUID REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS scopes             TEXT[],
+    ADD COLUMN IF NOT EXISTS refresh_token_hash TEXT UNIQUE,
+    ADD COLUMN IF NOT EXISTS expires_at         TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS rotated_refresh_tokens
+(
+    hash       TEXT PRIMARY KEY,
+    session_id UUID        NOT NULL REFERENCES sessions (id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS rotated_refresh_tokens_session_id_idx ON rotated_refresh_tokens (session_id);
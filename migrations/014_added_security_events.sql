@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS security_events
+(
+    id         UUID PRIMARY KEY,
+    user_id    UUID,
+    actor_id   UUID,
+    type       TEXT        NOT NULL,
+    login      TEXT,
+    ip         TEXT,
+    user_agent TEXT,
+    details    JSONB       NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS security_events_user_id_idx ON security_events (user_id, created_at);
+CREATE INDEX IF NOT EXISTS security_events_created_at_idx ON security_events (created_at);
+
+-- The audit trail is append-only. Events outlive their users on purpose, so
+-- there are no foreign keys that could cascade deletes into the table.
+CREATE OR REPLACE FUNCTION security_events_append_only() RETURNS TRIGGER AS
+$$
+BEGIN
+    RAISE EXCEPTION 'security_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS security_events_append_only ON security_events;
+CREATE TRIGGER security_events_append_only
+    BEFORE UPDATE OR DELETE ON security_events
+    FOR EACH ROW EXECUTE FUNCTION security_events_append_only();
@@ -0,0 +1,7 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin'));
+
+CREATE INDEX IF NOT EXISTS users_created_at_idx ON users (created_at);
+
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS impersonator_id UUID REFERENCES users (id) ON DELETE CASCADE;
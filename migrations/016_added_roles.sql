@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS roles
+(
+    name        TEXT PRIMARY KEY,
+    description TEXT        NOT NULL,
+    permissions TEXT[]      NOT NULL,
+    builtin     BOOLEAN     NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL
+);
+
+INSERT INTO roles (name, description, permissions, builtin, created_at)
+VALUES ('user', 'Regular user', '{}', TRUE, now()),
+       ('support', 'Support team', '{users:read,security_events:read}', TRUE, now()),
+       ('admin', 'Administrator',
+        '{users:read,users:write,users:impersonate,roles:manage,security_events:read,stats:read}', TRUE, now())
+ON CONFLICT (name) DO NOTHING;
+
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS users_role_check,
+    DROP CONSTRAINT IF EXISTS users_role_fkey,
+    ADD CONSTRAINT users_role_fkey FOREIGN KEY (role) REFERENCES roles (name);
+
+CREATE INDEX IF NOT EXISTS users_role_idx ON users (role);
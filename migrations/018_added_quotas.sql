@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS plans
+(
+    name            TEXT PRIMARY KEY,
+    max_notes       BIGINT      NOT NULL,
+    max_total_bytes BIGINT      NOT NULL,
+    max_note_bytes  BIGINT      NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL
+);
+
+INSERT INTO plans (name, max_notes, max_total_bytes, max_note_bytes, created_at)
+VALUES ('free', 1000, 10485760, 10000, now()),
+       ('pro', 100000, 1073741824, 1048576, now())
+ON CONFLICT (name) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS quotas
+(
+    user_id         UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    plan            TEXT REFERENCES plans (name),
+    max_notes       BIGINT,
+    max_total_bytes BIGINT,
+    max_note_bytes  BIGINT,
+    updated_at      TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS usage
+(
+    user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    notes   BIGINT NOT NULL,
+    bytes   BIGINT NOT NULL
+);
+
+INSERT INTO usage (user_id, notes, bytes)
+SELECT COALESCE(w.owner_id, n.user_id), count(*), COALESCE(sum(octet_length(n.text)), 0)
+FROM notes n
+         LEFT JOIN workspaces w ON w.id = n.workspace_id
+GROUP BY COALESCE(w.owner_id, n.user_id)
+ON CONFLICT (user_id) DO NOTHING;
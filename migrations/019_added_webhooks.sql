@@ -0,0 +1,55 @@
+CREATE TABLE IF NOT EXISTS outbox
+(
+    id            UUID PRIMARY KEY,
+    type          TEXT        NOT NULL,
+    user_id       UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    payload       JSONB       NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL,
+    dispatched_at TIMESTAMPTZ,
+    attempts      INTEGER     NOT NULL,
+    run_at        TIMESTAMPTZ NOT NULL,
+    error         TEXT,
+    failed_at     TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS outbox_due_idx ON outbox (run_at) WHERE dispatched_at IS NULL AND failed_at IS NULL;
+CREATE INDEX IF NOT EXISTS outbox_failed_idx ON outbox (failed_at) WHERE failed_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS webhooks
+(
+    id         UUID PRIMARY KEY,
+    user_id    UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    url        TEXT        NOT NULL,
+    secret     TEXT        NOT NULL,
+    events     TEXT[]      NOT NULL,
+    active     BOOLEAN     NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ,
+    failures   INTEGER     NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS webhooks_user_id_idx ON webhooks (user_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id              UUID PRIMARY KEY,
+    webhook_id      UUID        NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id        UUID        NOT NULL,
+    event_type      TEXT        NOT NULL,
+    payload         JSONB       NOT NULL,
+    status          TEXT        NOT NULL CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts        INTEGER     NOT NULL,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    response_code   INTEGER,
+    error           TEXT,
+    replay_of       UUID,
+    delivered_at    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_deliveries_event_idx
+    ON webhook_deliveries (webhook_id, event_id) WHERE replay_of IS NULL;
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx
+    ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id_idx
+    ON webhook_deliveries (webhook_id, created_at);
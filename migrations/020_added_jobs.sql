@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS jobs
+(
+    id          UUID PRIMARY KEY,
+    type        TEXT        NOT NULL,
+    payload     JSONB       NOT NULL,
+    status      TEXT        NOT NULL CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts    INTEGER     NOT NULL,
+    run_at      TIMESTAMPTZ NOT NULL,
+    error       TEXT,
+    unique_key  TEXT,
+    finished_at TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS jobs_unique_key_idx ON jobs (unique_key) WHERE unique_key IS NOT NULL;
+CREATE INDEX IF NOT EXISTS jobs_due_idx ON jobs (run_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS jobs_status_idx ON jobs (status, created_at);
+
+UPDATE roles
+SET permissions = array_append(permissions, 'jobs:manage')
+WHERE name = 'admin'
+  AND NOT 'jobs:manage' = ANY (permissions);
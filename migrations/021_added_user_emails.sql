@@ -0,0 +1,6 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS email TEXT UNIQUE;
+
+-- Accounts registered before the email was asked for separately keep the
+-- login as the address when it is one, the others get no mail.
+UPDATE users SET email = login WHERE email IS NULL AND login LIKE '_%@_%';
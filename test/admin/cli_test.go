@@ -0,0 +1,271 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/audit"
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/migrator"
+	"cloud-notes/internal/password"
+	"cloud-notes/internal/storage"
+	"cloud-notes/test/testenv"
+
+	"github.com/google/uuid"
+)
+
+// cli is the admin tool built from cmd/admin. It runs with the environment
+// of the test, which testenv.Config fills the way the server's is.
+type cli struct {
+	bin string
+}
+
+func newCLI(t *testing.T) *cli {
+	t.Helper()
+
+	bin := filepath.Join(t.TempDir(), "admin")
+	out, err := exec.Command("go", "build", "-o", bin,
+		"../../cmd/admin").CombinedOutput()
+	if err != nil {
+		t.Fatalf("build: %v\n%s", err, out)
+	}
+
+	return &cli{bin: bin}
+}
+
+// run runs the command with the input on stdin and returns what it printed
+// and its exit code.
+func (c *cli) run(t *testing.T, stdin string,
+	args ...string) (string, string, int) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(c.bin, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return stdout.String(), stderr.String(), 0
+	case errors.As(err, &exitErr):
+		return stdout.String(), stderr.String(), exitErr.ExitCode()
+	default:
+		t.Fatalf("run: %v", err)
+		return "", "", 0
+	}
+}
+
+// databaseEnv points the config to TEST_POSTGRES_URL and TEST_REDIS_URL,
+// split into the parts the config requires as well, and skips the test
+// without them.
+func databaseEnv(t *testing.T) map[string]string {
+	t.Helper()
+
+	pgURL, rdURL := os.Getenv("TEST_POSTGRES_URL"),
+		os.Getenv("TEST_REDIS_URL")
+	if pgURL == "" || rdURL == "" {
+		t.Skip("TEST_POSTGRES_URL or TEST_REDIS_URL is not set")
+	}
+
+	pgParsed, err := url.Parse(pgURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rdParsed, err := url.Parse(rdURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pgPassword, _ := pgParsed.User.Password()
+	rdDB := strings.TrimPrefix(rdParsed.Path, "/")
+	if rdDB == "" {
+		rdDB = "0"
+	}
+
+	return map[string]string{
+		"STORAGE":           config.StoragePostgres,
+		"POSTGRES_USER":     pgParsed.User.Username(),
+		"POSTGRES_PASSWORD": pgPassword,
+		"POSTGRES_HOST":     pgParsed.Hostname(),
+		"POSTGRES_PORT":     pgParsed.Port(),
+		"POSTGRES_DB":       strings.TrimPrefix(pgParsed.Path, "/"),
+		"POSTGRES_URL":      pgURL,
+		"REDIS_HOST":        rdParsed.Hostname(),
+		"REDIS_PORT":        rdParsed.Port(),
+		"REDIS_DB":          rdDB,
+		"REDIS_URL":         rdURL,
+	}
+}
+
+func TestCLIUsage(t *testing.T) {
+	testenv.Config(t, nil)
+	c := newCLI(t)
+
+	for name, args := range map[string][]string{
+		"no command":      nil,
+		"unknown command": {"drop-users"},
+	} {
+		_, stderr, code := c.run(t, "", args...)
+		if code != 2 || !strings.Contains(stderr, "create-user") {
+			t.Errorf("%s: exit %d, %q, want the usage", name, code, stderr)
+		}
+	}
+
+	// The in-memory storage belongs to a server process.
+	_, stderr, code := c.run(t, "", "notes-count", "-login", "ivan")
+	if code != 1 || !strings.Contains(stderr, "STORAGE=memory") {
+		t.Errorf("exit %d, %q, want the storage refused", code, stderr)
+	}
+}
+
+func TestCLIUsers(t *testing.T) {
+	cfg := testenv.Config(t, databaseEnv(t))
+	ctx := context.Background()
+
+	err := migrator.Migrate(ctx, "../../migrations", cfg.Postgres.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pg, err := postgres.Connect(ctx, &cfg.Postgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pg.Close)
+
+	st := storage.New(testenv.Logger(), pg, nil)
+	pw := password.MustNew(&cfg.PasswordHash)
+	c := newCLI(t)
+	login := uuid.NewString() + "@example.com"
+
+	stdout, stderr, code := c.run(t, "", "create-user",
+		"-login", login, "-first-name", "Ivan")
+	if code != 0 {
+		t.Fatalf("create-user: exit %d, %s", code, stderr)
+	}
+
+	user, err := st.Users().GetByLogin(ctx, login)
+	if err != nil || user == nil {
+		t.Fatalf("user = %v, %v", user, err)
+	}
+	t.Cleanup(func() {
+		err := st.Users().Purge(ctx, user.ID)
+		if err != nil {
+			t.Errorf("purge user: %v", err)
+		}
+	})
+
+	// The generated password is printed once and meets the policy.
+	match := regexp.MustCompile(`password: (\S+)`).FindStringSubmatch(stdout)
+	if match == nil || pw.Compare(user.PasswordHash, match[1]) != nil ||
+		user.Status != storage.UserStatusActive || user.Email == nil ||
+		*user.Email != login {
+		t.Errorf("user = %+v, output %q, want it active with the password",
+			user, stdout)
+	}
+
+	_, stderr, code = c.run(t, "", "create-user",
+		"-login", login, "-first-name", "Ivan")
+	if code != 1 || !strings.Contains(stderr, "already exists") {
+		t.Errorf("create-user again: exit %d, %q", code, stderr)
+	}
+
+	_, _, code = c.run(t, "", "create-user", "-login", login)
+	if code != 2 {
+		t.Errorf("create-user without a name: exit %d, want 2", code)
+	}
+
+	session := &storage.Session{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Scopes:    []string{},
+		CreatedAt: time.Now(),
+	}
+	err = st.Sessions().Create(ctx, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, _, code = c.run(t, "", "sessions", "-login", login)
+	if code != 0 || !strings.Contains(stdout, session.ID.String()) {
+		t.Errorf("sessions: exit %d, %q, want the session", code, stdout)
+	}
+
+	_, stderr, code = c.run(t, "", "revoke-sessions", "-login", login,
+		"-session", uuid.NewString())
+	if code != 1 || !strings.Contains(stderr, "not found") {
+		t.Errorf("revoke-sessions: exit %d, %q", code, stderr)
+	}
+
+	// Blocking goes through the admin service, with its audit trail.
+	_, stderr, code = c.run(t, "", "block", "-login", login,
+		"-reason", "spam")
+	if code != 0 {
+		t.Fatalf("block: exit %d, %s", code, stderr)
+	}
+
+	block, err := st.Blocks().GetByUserID(ctx, user.ID)
+	if err != nil || block == nil || block.Reason != "spam" ||
+		block.BlockedBy != nil {
+		t.Errorf("block = %+v, %v, want one by nobody", block, err)
+	}
+
+	sessions, err := st.Sessions().GetByUserID(ctx, user.ID)
+	if err != nil || len(sessions) != 0 {
+		t.Errorf("sessions = %d, %v, want all ended", len(sessions), err)
+	}
+
+	eventType := audit.EventUserBlocked
+	events, err := st.SecurityEvents().Find(ctx,
+		&storage.SecurityEventFilter{UserID: &user.ID, Type: &eventType})
+	if err != nil || len(events) != 1 ||
+		events[0].Details["source"] != "cli" || events[0].ActorID != nil {
+		t.Errorf("events = %+v, %v, want the block from the cli",
+			events, err)
+	}
+
+	_, stderr, code = c.run(t, "", "unblock", "-login", login)
+	if code != 0 {
+		t.Fatalf("unblock: exit %d, %s", code, stderr)
+	}
+
+	_, stderr, code = c.run(t, "short\n", "reset-password",
+		"-login", login, "-password-stdin")
+	if code != 1 || !strings.Contains(stderr, "password") {
+		t.Errorf("reset-password: exit %d, %q, want the policy error",
+			code, stderr)
+	}
+
+	const plain = "stapled-battery-horse-correct"
+	_, stderr, code = c.run(t, plain+"\n", "reset-password",
+		"-login", login, "-password-stdin")
+	if code != 0 {
+		t.Fatalf("reset-password: exit %d, %s", code, stderr)
+	}
+
+	user, err = st.Users().GetByLogin(ctx, login)
+	if err != nil || user.Status != storage.UserStatusActive ||
+		pw.Compare(user.PasswordHash, plain) != nil {
+		t.Errorf("user = %+v, %v, want active with the new password",
+			user, err)
+	}
+
+	stdout, _, code = c.run(t, "", "notes-count", "-login", login)
+	if code != 0 || stdout != "0\n" {
+		t.Errorf("notes-count: exit %d, %q", code, stdout)
+	}
+}
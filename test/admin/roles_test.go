@@ -0,0 +1,174 @@
+package admin
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"cloud-notes/internal/security"
+	adminService "cloud-notes/internal/services/admin"
+	"cloud-notes/internal/storage"
+	"cloud-notes/test/testenv"
+)
+
+func TestCreateRole(t *testing.T) {
+	e := newAdminEnv(t)
+
+	for name, tt := range map[string]struct {
+		input *adminService.SaveRoleInput
+		want  error
+	}{
+		"empty name": {&adminService.SaveRoleInput{Name: ""},
+			adminService.ErrInvalidRoleName},
+		"uppercase name": {&adminService.SaveRoleInput{Name: "Editor"},
+			adminService.ErrInvalidRoleName},
+		"name in url": {&adminService.SaveRoleInput{Name: "a/b"},
+			adminService.ErrInvalidRoleName},
+		"unknown permission": {&adminService.SaveRoleInput{Name: "editor",
+			Permissions: []string{"notes:delete"}},
+			adminService.ErrUnknownPermission},
+	} {
+		_, err := e.srv.CreateRole(e.ctx, tt.input)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: create = %v, want %v", name, err, tt.want)
+		}
+	}
+
+	// Permissions are stored sorted and once.
+	role, err := e.srv.CreateRole(e.ctx, &adminService.SaveRoleInput{
+		Name: "analyst",
+		Permissions: []string{security.PermissionStatsRead,
+			security.PermissionUsersRead, security.PermissionStatsRead},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	want := []string{security.PermissionStatsRead,
+		security.PermissionUsersRead}
+	if role.Builtin || !slices.Equal(role.Permissions, want) {
+		t.Errorf("role = %+v, want custom with %v", role, want)
+	}
+
+	_, err = e.srv.CreateRole(e.ctx, &adminService.SaveRoleInput{
+		Name: "analyst",
+	})
+	if !errors.Is(err, adminService.ErrRoleExists) {
+		t.Errorf("create again = %v, want %v", err, adminService.ErrRoleExists)
+	}
+
+	// No permissions is a role too, not a NULL.
+	role, err = e.srv.CreateRole(e.ctx, &adminService.SaveRoleInput{
+		Name: "guest",
+	})
+	if err != nil || role.Permissions == nil {
+		t.Errorf("role = %+v, %v, want empty permissions", role, err)
+	}
+}
+
+func TestBuiltinRoles(t *testing.T) {
+	e := newAdminEnv(t)
+
+	output, err := e.srv.GetRoles(e.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	builtin := make([]string, 0)
+	for _, role := range output.Roles {
+		if role.Builtin {
+			builtin = append(builtin, role.Name)
+		}
+	}
+	slices.Sort(builtin)
+	if !slices.Equal(builtin, []string{"admin", "support", "user"}) {
+		t.Errorf("builtin roles %v", builtin)
+	}
+
+	for _, name := range []string{"user", "support", "admin"} {
+		_, err := e.srv.UpdateRole(e.ctx, &adminService.SaveRoleInput{
+			Name:        name,
+			Permissions: security.Permissions,
+		})
+		if !errors.Is(err, adminService.ErrRoleBuiltin) {
+			t.Errorf("update %s = %v, want %v",
+				name, err, adminService.ErrRoleBuiltin)
+		}
+
+		err = e.srv.DeleteRole(e.ctx, name)
+		if !errors.Is(err, adminService.ErrRoleBuiltin) {
+			t.Errorf("delete %s = %v, want %v",
+				name, err, adminService.ErrRoleBuiltin)
+		}
+	}
+}
+
+func TestSetRole(t *testing.T) {
+	e := newAdminEnv(t)
+	user := testenv.NewUser(t, e.st, "ivan@example.com", "hash",
+		storage.UserStatusActive)
+
+	_, err := e.srv.CreateRole(e.ctx, &adminService.SaveRoleInput{
+		Name:        "analyst",
+		Permissions: []string{security.PermissionStatsRead},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tt := range map[string]struct {
+		input *adminService.SetRoleInput
+		want  error
+	}{
+		"own role": {&adminService.SetRoleInput{AdminID: e.admin.ID,
+			UserID: e.admin.ID, Role: "user"}, adminService.ErrChangeOwnRole},
+		"unknown role": {&adminService.SetRoleInput{AdminID: e.admin.ID,
+			UserID: user.ID, Role: "root"}, adminService.ErrRoleNotFound},
+	} {
+		err := e.srv.SetRole(e.ctx, tt.input)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: set role = %v, want %v", name, err, tt.want)
+		}
+	}
+
+	err = e.srv.SetRole(e.ctx, &adminService.SetRoleInput{
+		AdminID: e.admin.ID,
+		UserID:  user.ID,
+		Role:    "analyst",
+	})
+	if err != nil {
+		t.Fatalf("set role: %v", err)
+	}
+
+	events := e.events(t, user.ID)
+	if len(events) != 1 || events[0].Details["from"] != "user" ||
+		events[0].Details["to"] != "analyst" {
+		t.Errorf("events = %+v, want the role change", events)
+	}
+
+	// Users have to be moved off a role before it can go.
+	err = e.srv.DeleteRole(e.ctx, "analyst")
+	if !errors.Is(err, adminService.ErrRoleInUse) {
+		t.Errorf("delete = %v, want %v", err, adminService.ErrRoleInUse)
+	}
+
+	err = e.srv.SetRole(e.ctx, &adminService.SetRoleInput{
+		AdminID: e.admin.ID,
+		UserID:  user.ID,
+		Role:    "user",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = e.srv.DeleteRole(e.ctx, "analyst")
+	if err != nil {
+		t.Errorf("delete: %v", err)
+	}
+
+	err = e.srv.DeleteRole(e.ctx, "analyst")
+	if !errors.Is(err, adminService.ErrRoleNotFound) {
+		t.Errorf("delete again = %v, want %v",
+			err, adminService.ErrRoleNotFound)
+	}
+}
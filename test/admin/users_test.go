@@ -0,0 +1,352 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/audit"
+	"cloud-notes/internal/middleware"
+	"cloud-notes/internal/password"
+	"cloud-notes/internal/quota"
+	"cloud-notes/internal/security"
+	adminService "cloud-notes/internal/services/admin"
+	"cloud-notes/internal/storage"
+	"cloud-notes/internal/storage/memory"
+	"cloud-notes/test/testenv"
+
+	"github.com/google/uuid"
+)
+
+type adminEnv struct {
+	st    storage.Storage
+	sec   security.Security
+	srv   adminService.Service
+	admin *storage.User
+	// ctx is a request of the admin.
+	ctx context.Context
+}
+
+func newAdminEnv(t *testing.T) *adminEnv {
+	t.Helper()
+
+	cfg := testenv.Config(t, map[string]string{
+		"ADMIN_IMPERSONATION_TTL": "600",
+	})
+	log := testenv.Logger()
+	st := memory.New()
+	sec := security.MustNew(log, st, &cfg.JWT)
+
+	admin := testenv.NewUser(t, st, "admin@example.com", "hash",
+		storage.UserStatusActive)
+	admin.Role = storage.UserRoleAdmin
+	err := st.Users().Update(context.Background(), admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &adminEnv{
+		st:  st,
+		sec: sec,
+		srv: adminService.New(log, st, sec, audit.New(log, st),
+			quota.New(log, st, &cfg.Quota), password.MustNew(&cfg.PasswordHash),
+			password.NewPolicy(&cfg.PasswordPolicy, &cfg.PasswordHash), cfg),
+		admin: admin,
+		ctx: security.SetClaims(context.Background(), &security.Claims{
+			UserID: admin.ID,
+		}),
+	}
+}
+
+// newSession starts a login session of the user.
+func (e *adminEnv) newSession(t *testing.T, userID uuid.UUID) {
+	t.Helper()
+
+	err := e.st.Sessions().Create(context.Background(), &storage.Session{
+		ID:        uuid.New(),
+		UserID:    userID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// events returns the audit trail of the user, newest first.
+func (e *adminEnv) events(t *testing.T,
+	userID uuid.UUID) []*storage.SecurityEvent {
+	t.Helper()
+
+	events, err := e.st.SecurityEvents().Find(context.Background(),
+		&storage.SecurityEventFilter{UserID: &userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return events
+}
+
+// authenticate passes the token through the session middleware and returns
+// the claims it sets, nil when the token is refused.
+func (e *adminEnv) authenticate(token string) *security.Claims {
+	var claims *security.Claims
+	h := middleware.Security(testenv.Logger(), e.st, e.sec)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims = security.GetClaims(r.Context())
+		}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	return claims
+}
+
+func TestListUsers(t *testing.T) {
+	e := newAdminEnv(t)
+	for _, login := range []string{"ivan@example.com", "olga@example.com",
+		"oleg@example.com"} {
+		testenv.NewUser(t, e.st, login, "hash", storage.UserStatusActive)
+	}
+	testenv.NewUser(t, e.st, "olaf@example.com", "hash",
+		storage.UserStatusPending)
+
+	query, status, role := "OL", "active", "admin"
+	limit, offset := uint64(1), uint64(1)
+	tests := []struct {
+		name  string
+		input adminService.ListUsersInput
+		users int
+		total uint64
+	}{
+		{"all", adminService.ListUsersInput{}, 5, 5},
+		{"query ignores case", adminService.ListUsersInput{Query: &query},
+			3, 3},
+		{"query and status", adminService.ListUsersInput{
+			Query: &query, Status: &status}, 2, 2},
+		{"role", adminService.ListUsersInput{Role: &role}, 1, 1},
+		{"page counts every match", adminService.ListUsersInput{
+			Query: &query, Limit: &limit, Offset: &offset}, 1, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := e.srv.ListUsers(e.ctx, &tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(output.Users) != tt.users || output.Total != tt.total {
+				t.Errorf("got %d users of %d, want %d of %d",
+					len(output.Users), output.Total, tt.users, tt.total)
+			}
+		})
+	}
+}
+
+func TestBlockUser(t *testing.T) {
+	e := newAdminEnv(t)
+	user := testenv.NewUser(t, e.st, "ivan@example.com", "hash",
+		storage.UserStatusPending)
+	e.newSession(t, user.ID)
+
+	past := time.Now().Add(-time.Minute)
+	for name, input := range map[string]*adminService.BlockUserInput{
+		"self": {AdminID: &e.admin.ID, UserID: e.admin.ID},
+		"past": {AdminID: &e.admin.ID, UserID: user.ID, ExpiresAt: &past},
+		"none": {AdminID: &e.admin.ID, UserID: uuid.New()},
+	} {
+		if err := e.srv.BlockUser(e.ctx, input); err == nil {
+			t.Errorf("%s: blocked", name)
+		}
+	}
+
+	err := e.srv.BlockUser(e.ctx, &adminService.BlockUserInput{
+		AdminID: &e.admin.ID,
+		UserID:  user.ID,
+		Reason:  "spam",
+	})
+	if err != nil {
+		t.Fatalf("block: %v", err)
+	}
+
+	output, err := e.srv.GetUser(e.ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output.Status != string(storage.UserStatusBlocked) ||
+		output.SessionsCount != 0 || output.Block == nil ||
+		output.Block.Reason != "spam" || output.Block.BlockedBy == nil ||
+		*output.Block.BlockedBy != e.admin.ID {
+		t.Errorf("user = %+v, block = %+v, want blocked by the admin",
+			output, output.Block)
+	}
+
+	events := e.events(t, user.ID)
+	if len(events) != 1 || events[0].Type != audit.EventUserBlocked ||
+		events[0].ActorID == nil || *events[0].ActorID != e.admin.ID {
+		t.Errorf("events = %+v, want the block by the admin", events)
+	}
+
+	err = e.srv.UnblockUser(e.ctx, user.ID)
+	if err != nil {
+		t.Fatalf("unblock: %v", err)
+	}
+
+	// The user hadn't verified the email before the block and still has
+	// to.
+	output, err = e.srv.GetUser(e.ctx, user.ID)
+	if err != nil || output.Status != string(storage.UserStatusPending) ||
+		output.Block != nil {
+		t.Errorf("user = %+v, %v, want pending again", output, err)
+	}
+
+	err = e.srv.UnblockUser(e.ctx, user.ID)
+	if !errors.Is(err, adminService.ErrUserNotBlocked) {
+		t.Errorf("unblock again = %v, want %v",
+			err, adminService.ErrUserNotBlocked)
+	}
+}
+
+func TestLogoutUser(t *testing.T) {
+	e := newAdminEnv(t)
+	user := testenv.NewUser(t, e.st, "ivan@example.com", "hash",
+		storage.UserStatusActive)
+	other := testenv.NewUser(t, e.st, "olga@example.com", "hash",
+		storage.UserStatusActive)
+	for _, id := range []uuid.UUID{user.ID, user.ID, other.ID} {
+		e.newSession(t, id)
+	}
+
+	output, err := e.srv.GetUser(e.ctx, user.ID)
+	if err != nil || output.SessionsCount != 2 {
+		t.Fatalf("user = %+v, %v, want 2 sessions", output, err)
+	}
+
+	err = e.srv.LogoutUser(e.ctx, user.ID)
+	if err != nil {
+		t.Fatalf("logout: %v", err)
+	}
+
+	for id, want := range map[uuid.UUID]uint64{user.ID: 0, other.ID: 1} {
+		output, err := e.srv.GetUser(e.ctx, id)
+		if err != nil || output.SessionsCount != want {
+			t.Errorf("user = %+v, %v, want %d sessions", output, err, want)
+		}
+	}
+
+	events := e.events(t, user.ID)
+	if len(events) != 1 || events[0].Type != audit.EventForcedLogout {
+		t.Errorf("events = %+v, want the forced logout", events)
+	}
+
+	err = e.srv.LogoutUser(e.ctx, uuid.New())
+	if !errors.Is(err, adminService.ErrUserNotFound) {
+		t.Errorf("logout = %v, want %v", err, adminService.ErrUserNotFound)
+	}
+}
+
+func TestImpersonateUser(t *testing.T) {
+	e := newAdminEnv(t)
+	user := testenv.NewUser(t, e.st, "ivan@example.com", "hash",
+		storage.UserStatusActive)
+	pending := testenv.NewUser(t, e.st, "olga@example.com", "hash",
+		storage.UserStatusPending)
+
+	// A support user's permissions don't come with the session.
+	user.Role = storage.UserRoleSupport
+	err := e.st.Users().Update(context.Background(), user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for id, want := range map[uuid.UUID]error{
+		e.admin.ID: adminService.ErrImpersonateSelf,
+		pending.ID: adminService.ErrUserNotActive,
+		uuid.New(): adminService.ErrUserNotFound,
+	} {
+		_, err := e.srv.ImpersonateUser(e.ctx,
+			&adminService.ImpersonateUserInput{
+				AdminID: e.admin.ID,
+				UserID:  id,
+			})
+		if !errors.Is(err, want) {
+			t.Errorf("impersonate = %v, want %v", err, want)
+		}
+	}
+
+	before := time.Now()
+	output, err := e.srv.ImpersonateUser(e.ctx,
+		&adminService.ImpersonateUserInput{
+			AdminID: e.admin.ID,
+			UserID:  user.ID,
+		})
+	if err != nil {
+		t.Fatalf("impersonate: %v", err)
+	}
+
+	if output.ExpiresAt.Before(before.Add(10*time.Minute)) ||
+		output.ExpiresAt.After(time.Now().Add(10*time.Minute)) {
+		t.Errorf("expires at %v, want in ADMIN_IMPERSONATION_TTL",
+			output.ExpiresAt)
+	}
+
+	claims := e.authenticate(output.AccessToken)
+	if claims == nil || claims.UserID != user.ID ||
+		claims.ImpersonatorID == nil || *claims.ImpersonatorID != e.admin.ID ||
+		len(claims.Permissions) != 0 {
+		t.Errorf("claims = %+v, want the user with the admin behind",
+			claims)
+	}
+
+	events := e.events(t, user.ID)
+	if len(events) != 1 || events[0].Type != audit.EventImpersonated ||
+		events[0].ActorID == nil || *events[0].ActorID != e.admin.ID {
+		t.Errorf("events = %+v, want the impersonation by the admin",
+			events)
+	}
+
+	// Ending the user's sessions ends the impersonation as well.
+	err = e.srv.LogoutUser(e.ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims := e.authenticate(output.AccessToken); claims != nil {
+		t.Error("the impersonation outlived a forced logout")
+	}
+}
+
+func TestGetStats(t *testing.T) {
+	e := newAdminEnv(t)
+	for login, status := range map[string]storage.UserStatus{
+		"ivan@example.com": storage.UserStatusActive,
+		"olga@example.com": storage.UserStatusPending,
+		"oleg@example.com": storage.UserStatusBlocked,
+		"olaf@example.com": storage.UserStatusDeleted,
+	} {
+		testenv.NewUser(t, e.st, login, "hash", status)
+	}
+	e.newSession(t, e.admin.ID)
+
+	output, err := e.srv.GetStats(e.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := adminService.UserStats{
+		Total:   5,
+		Pending: 1,
+		Active:  2,
+		Blocked: 1,
+		Deleted: 1,
+		Admins:  1,
+	}
+	if output.Users != want || output.NewUsersDay != 5 ||
+		output.ActiveSessions != 1 {
+		t.Errorf("stats = %+v, want %+v", output, want)
+	}
+}
@@ -0,0 +1,162 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"testing"
+
+	"cloud-notes/internal/audit"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/storage"
+	"cloud-notes/internal/storage/memory"
+	"cloud-notes/internal/storage/securityevents"
+	"cloud-notes/test/testenv"
+
+	"github.com/google/uuid"
+)
+
+// failingStorage can't write the audit trail.
+type failingStorage struct {
+	storage.Storage
+}
+
+func (s failingStorage) SecurityEvents() securityevents.Storage {
+	return failingEvents{}
+}
+
+type failingEvents struct{}
+
+func (failingEvents) Create(context.Context, *storage.SecurityEvent) error {
+	return errors.New("disk is full")
+}
+
+func (failingEvents) Find(context.Context,
+	*storage.SecurityEventFilter) ([]*storage.SecurityEvent, error) {
+	return nil, errors.New("disk is full")
+}
+
+// recorded returns the only event of the user.
+func recorded(t *testing.T, st storage.Storage,
+	userID uuid.UUID) *storage.SecurityEvent {
+	t.Helper()
+
+	events, err := st.SecurityEvents().Find(context.Background(),
+		&storage.SecurityEventFilter{UserID: &userID})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("events = %v, %v, want one", events, err)
+	}
+
+	return events[0]
+}
+
+func TestRecordRequest(t *testing.T) {
+	st := memory.New()
+	auditor := audit.New(testenv.Logger(), st)
+	userID := uuid.New()
+
+	ctx := audit.SetRequest(context.Background(), &audit.Request{
+		IP:        "192.0.2.1",
+		UserAgent: "Mozilla/5.0",
+	})
+	ctx = security.SetClaims(ctx, &security.Claims{UserID: userID})
+	auditor.Record(ctx, &audit.Event{
+		Type:   audit.EventLoginSucceeded,
+		UserID: &userID,
+	})
+
+	event := recorded(t, st, userID)
+	if event.Type != audit.EventLoginSucceeded || event.IP == nil ||
+		*event.IP != "192.0.2.1" || event.UserAgent == nil ||
+		*event.UserAgent != "Mozilla/5.0" {
+		t.Errorf("event = %+v, want the request recorded", event)
+	}
+
+	// A user acting on their own account isn't an actor.
+	if event.ActorID != nil {
+		t.Errorf("actor = %v, want none", event.ActorID)
+	}
+}
+
+func TestRecordActor(t *testing.T) {
+	adminID, impersonatorID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name   string
+		claims *security.Claims
+		want   *uuid.UUID
+	}{
+		{"no claims", nil, nil},
+		{"admin", &security.Claims{UserID: adminID}, &adminID},
+		{"impersonator", &security.Claims{
+			UserID:         adminID,
+			ImpersonatorID: &impersonatorID,
+		}, &impersonatorID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := memory.New()
+			auditor := audit.New(testenv.Logger(), st)
+			userID := uuid.New()
+
+			ctx := context.Background()
+			if tt.claims != nil {
+				ctx = security.SetClaims(ctx, tt.claims)
+			}
+			auditor.Record(ctx, &audit.Event{
+				Type:   audit.EventUserBlocked,
+				UserID: &userID,
+			})
+
+			event := recorded(t, st, userID)
+			switch {
+			case tt.want == nil && event.ActorID != nil:
+				t.Errorf("actor = %v, want none", *event.ActorID)
+			case tt.want != nil &&
+				(event.ActorID == nil || *event.ActorID != *tt.want):
+				t.Errorf("actor = %v, want %v", event.ActorID, *tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordSource(t *testing.T) {
+	st := memory.New()
+	auditor := audit.New(testenv.Logger(), st)
+	userID := uuid.New()
+
+	details := map[string]string{"role": "admin"}
+	ctx := audit.SetSource(context.Background(), "cli")
+	auditor.Record(ctx, &audit.Event{
+		Type:    audit.EventRoleChanged,
+		UserID:  &userID,
+		Details: details,
+	})
+
+	event := recorded(t, st, userID)
+	want := map[string]string{"role": "admin", "source": "cli"}
+	if !maps.Equal(event.Details, want) {
+		t.Errorf("details = %v, want %v", event.Details, want)
+	}
+
+	if len(details) != 1 {
+		t.Errorf("the caller's details became %v", details)
+	}
+
+	// Changes made outside a request have no client.
+	if event.IP != nil || event.UserAgent != nil {
+		t.Errorf("event = %+v, want no client", event)
+	}
+}
+
+func TestRecordFailureIsLogged(t *testing.T) {
+	auditor := audit.New(testenv.Logger(), failingStorage{memory.New()})
+	userID := uuid.New()
+
+	// The action has happened already, recording it must not panic or
+	// block.
+	auditor.Record(context.Background(), &audit.Event{
+		Type:   audit.EventLogout,
+		UserID: &userID,
+	})
+}
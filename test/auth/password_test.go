@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"cloud-notes/internal/password"
+	authService "cloud-notes/internal/services/auth"
+	"cloud-notes/internal/storage"
+	"cloud-notes/internal/storage/memory"
+	"cloud-notes/test/testenv"
+)
+
+func TestLoginRehashesOutdatedPassword(t *testing.T) {
+	ctx := context.Background()
+	cfg := testenv.Config(t, map[string]string{
+		"PASSWORD_HASH_ALGORITHM":          "argon2id",
+		"PASSWORD_HASH_ARGON2_MEMORY":      "1024",
+		"PASSWORD_HASH_ARGON2_ITERATIONS":  "1",
+		"PASSWORD_HASH_ARGON2_PARALLELISM": "1",
+		"PASSWORD_HASH_BCRYPT_COST":        "4",
+	})
+	st := memory.New()
+	srv := testenv.Auth(cfg, st)
+
+	legacy := cfg.PasswordHash
+	legacy.Algorithm = password.AlgorithmBcrypt
+	hash, err := password.MustNew(&legacy).Hash(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := testenv.NewUser(t, st,
+		"ivan@example.com", hash, storage.UserStatusActive)
+
+	login := func(plain string) error {
+		_, err := srv.Login(ctx, &authService.LoginInput{
+			Login:    user.Login,
+			Password: plain,
+		})
+		return err
+	}
+	stored := func() string {
+		got, err := st.Users().GetByID(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got.PasswordHash
+	}
+
+	err = login("wrong password")
+	if err == nil {
+		t.Fatal("logged in with a wrong password")
+	}
+
+	if stored() != hash {
+		t.Fatal("a failed login changed the hash")
+	}
+
+	err = login(secret)
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	rehashed := stored()
+	if !strings.HasPrefix(rehashed, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("hash after login = %q, want argon2id", rehashed)
+	}
+
+	err = login(secret)
+	if err != nil {
+		t.Fatalf("login with the new hash: %v", err)
+	}
+
+	if stored() != rehashed {
+		t.Error("a current hash was replaced again")
+	}
+
+	stronger := *cfg
+	stronger.PasswordHash.Argon2Iterations = 2
+	srv = testenv.Auth(&stronger, st)
+
+	err = login(secret)
+	if err != nil {
+		t.Fatalf("login after the parameters changed: %v", err)
+	}
+
+	if got := stored(); !strings.HasPrefix(got,
+		"$argon2id$v=19$m=1024,t=2,p=1$") {
+		t.Errorf("hash after the parameters changed = %q", got)
+	}
+}
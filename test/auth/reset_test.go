@@ -0,0 +1,259 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"cloud-notes/internal/jobs"
+	"cloud-notes/internal/password"
+	authService "cloud-notes/internal/services/auth"
+	"cloud-notes/internal/storage"
+)
+
+const newSecret = "stapled-battery-horse-correct"
+
+// activate registers a user and follows the verification link.
+func activate(t *testing.T, srv authService.Service, mail *mailbox,
+	login, email string) {
+	t.Helper()
+	ctx := context.Background()
+
+	err := register(srv, login, email)
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	err = srv.SendVerification(ctx, login)
+	if err != nil {
+		t.Fatalf("send verification: %v", err)
+	}
+
+	_, token := mail.take()
+	err = srv.VerifyEmail(ctx, token)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+}
+
+func login(srv authService.Service, login, plain string) error {
+	_, err := srv.Login(context.Background(), &authService.LoginInput{
+		Login:    login,
+		Password: plain,
+	})
+	return err
+}
+
+// resetLink mails a reset link to the login's owner and returns its token.
+func resetLink(t *testing.T, srv authService.Service, mail *mailbox,
+	login string) string {
+	t.Helper()
+
+	err := srv.SendPasswordReset(context.Background(), login)
+	if err != nil {
+		t.Fatalf("send password reset: %v", err)
+	}
+
+	_, token := mail.take()
+	return token
+}
+
+func reset(srv authService.Service, token, plain string) error {
+	return srv.ResetPassword(context.Background(),
+		&authService.ResetPasswordInput{
+			Token:       token,
+			NewPassword: plain,
+		})
+}
+
+func TestForgotPasswordHidesLogins(t *testing.T) {
+	srv, st, mail := newMailEnv(t, map[string]string{
+		"PASSWORD_RESET_LIMIT": "2",
+	})
+	ctx := context.Background()
+
+	err := register(srv, "ivan_petrov", "ivan@example.com")
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	// Known and unknown logins get the same answer, the worker finds out
+	// which is which.
+	for _, name := range []string{"ivan_petrov", "nobody_here"} {
+		for range 2 {
+			err := srv.ForgotPassword(ctx, name)
+			if err != nil {
+				t.Fatalf("forgot password of %s: %v", name, err)
+			}
+		}
+
+		err := srv.ForgotPassword(ctx, name)
+		if !errors.Is(err, authService.ErrTooManyResetMails) {
+			t.Errorf("forgot password of %s = %v, want %v",
+				name, err, authService.ErrTooManyResetMails)
+		}
+	}
+
+	list, err := st.Jobs().List(ctx,
+		&storage.JobFilter{Type: jobs.TypeSendPasswordReset}, 10, 0)
+	if err != nil || len(list) != 4 {
+		t.Fatalf("jobs = %d, %v, want a job for every request", len(list),
+			err)
+	}
+
+	err = srv.SendPasswordReset(ctx, "nobody_here")
+	if err != nil || !mail.empty() {
+		t.Errorf("an unknown login got a link: %v", err)
+	}
+
+	err = srv.SendPasswordReset(ctx, "ivan_petrov")
+	if err != nil {
+		t.Fatalf("send password reset: %v", err)
+	}
+
+	to, _ := mail.take()
+	if to != "ivan@example.com" {
+		t.Errorf("mail went to %q, want the email", to)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	srv, st, mail := newMailEnv(t, nil)
+	ctx := context.Background()
+
+	activate(t, srv, mail, "ivan_petrov", "ivan@example.com")
+	err := login(srv, "ivan_petrov", secret)
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	user, err := st.Users().GetByLogin(ctx, "ivan_petrov")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := resetLink(t, srv, mail, "ivan_petrov")
+
+	// A rejected password doesn't use up the link.
+	var policyErr *password.PolicyError
+	err = reset(srv, token, "short")
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("reset = %v, want a policy error", err)
+	}
+
+	err = reset(srv, token, newSecret)
+	if err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+
+	err = reset(srv, token, newSecret+"-again")
+	if !errors.Is(err, authService.ErrInvalidResetToken) {
+		t.Errorf("reset again = %v, want %v",
+			err, authService.ErrInvalidResetToken)
+	}
+
+	sessions, err := st.Sessions().GetByUserID(ctx, user.ID)
+	if err != nil || len(sessions) != 0 {
+		t.Errorf("sessions = %d, %v, want all revoked", len(sessions), err)
+	}
+
+	if err := login(srv, "ivan_petrov", secret); err == nil {
+		t.Error("logged in with the old password")
+	}
+
+	if err := login(srv, "ivan_petrov", newSecret); err != nil {
+		t.Errorf("login with the new password: %v", err)
+	}
+}
+
+func TestResetPasswordOnce(t *testing.T) {
+	srv, _, mail := newMailEnv(t, nil)
+
+	activate(t, srv, mail, "ivan_petrov", "ivan@example.com")
+	stale := resetLink(t, srv, mail, "ivan_petrov")
+	token := resetLink(t, srv, mail, "ivan_petrov")
+
+	// Requests with the same link at once change the password once.
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		changed int
+	)
+	for range 8 {
+		wg.Go(func() {
+			err := reset(srv, token, newSecret)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				changed++
+			case !errors.Is(err, authService.ErrInvalidResetToken):
+				t.Errorf("reset: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if changed != 1 {
+		t.Errorf("the link worked %d times, want once", changed)
+	}
+
+	// Every link is a token of its own, an earlier one still works.
+	err := reset(srv, stale, secret)
+	if err != nil {
+		t.Errorf("reset with the earlier link: %v", err)
+	}
+}
+
+func TestResetPasswordRejects(t *testing.T) {
+	srv, st, mail := newMailEnv(t, map[string]string{
+		"PASSWORD_RESET_TTL": "0",
+	})
+	ctx := context.Background()
+
+	activate(t, srv, mail, "ivan_petrov", "ivan@example.com")
+	expired := resetLink(t, srv, mail, "ivan_petrov")
+
+	for name, token := range map[string]string{
+		"expired": expired,
+		"unknown": "not-a-token",
+		"empty":   "",
+	} {
+		err := reset(srv, token, newSecret)
+		if !errors.Is(err, authService.ErrInvalidResetToken) {
+			t.Errorf("%s: reset = %v, want %v",
+				name, err, authService.ErrInvalidResetToken)
+		}
+	}
+
+	if err := login(srv, "ivan_petrov", secret); err != nil {
+		t.Errorf("the password changed: %v", err)
+	}
+
+	user, err := st.Users().GetByLogin(ctx, "ivan_petrov")
+	if err != nil || user.Status != storage.UserStatusActive {
+		t.Errorf("user = %+v, %v, want active", user, err)
+	}
+}
+
+func TestResetPasswordVerifiesEmail(t *testing.T) {
+	srv, st, mail := newMailEnv(t, nil)
+	ctx := context.Background()
+
+	err := register(srv, "ivan_petrov", "ivan@example.com")
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	err = reset(srv, resetLink(t, srv, mail, "ivan_petrov"), newSecret)
+	if err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+
+	user, err := st.Users().GetByLogin(ctx, "ivan_petrov")
+	if err != nil || user.Status != storage.UserStatusActive {
+		t.Errorf("user = %+v, %v, want active", user, err)
+	}
+}
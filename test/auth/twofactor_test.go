@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/password"
+	authService "cloud-notes/internal/services/auth"
+	"cloud-notes/internal/storage"
+	"cloud-notes/internal/storage/memory"
+	"cloud-notes/test/testenv"
+
+	"github.com/google/uuid"
+)
+
+const secret = "correct-horse-battery-staple"
+
+func TestVerifyTwoFactorBudgetIsPerUser(t *testing.T) {
+	ctx := context.Background()
+	cfg := testenv.Config(t, map[string]string{"TOTP_MAX_ATTEMPTS": "3"})
+	st := memory.New()
+	srv := testenv.Auth(cfg, st)
+
+	hash, err := password.MustNew(&cfg.PasswordHash).Hash(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := testenv.NewUser(t, st,
+		"ivan@example.com", hash, storage.UserStatusActive)
+	err = st.TwoFactor().Create(ctx, &storage.TwoFactor{
+		UserID:    user.ID,
+		Secret:    "JBSWY3DPEHPK3PXP",
+		Enabled:   true,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenge := func() string {
+		t.Helper()
+
+		output, err := srv.Login(ctx, &authService.LoginInput{
+			Login:    user.Login,
+			Password: secret,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output.ChallengeToken == "" {
+			t.Fatal("no challenge for a user with 2FA")
+		}
+
+		return output.ChallengeToken
+	}
+
+	// Every guess comes with a fresh challenge, the budget runs out anyway.
+	for range cfg.TOTP.MaxAttempts {
+		_, err := srv.VerifyTwoFactor(ctx, &authService.VerifyTwoFactorInput{
+			ChallengeToken: challenge(),
+			Code:           "wrong",
+		})
+		if !errors.Is(err, authService.ErrInvalidCode) {
+			t.Fatalf("verify = %v, want %v", err, authService.ErrInvalidCode)
+		}
+	}
+
+	_, err = srv.VerifyTwoFactor(ctx, &authService.VerifyTwoFactorInput{
+		ChallengeToken: challenge(),
+		Code:           "wrong",
+	})
+	if !errors.Is(err, authService.ErrTooManyCodeAttempts) {
+		t.Fatalf("verify = %v, want %v",
+			err, authService.ErrTooManyCodeAttempts)
+	}
+}
+
+func TestEnrollTwoFactorUnknownUser(t *testing.T) {
+	srv := testenv.Auth(testenv.Config(t, nil), memory.New())
+
+	_, err := srv.EnrollTwoFactor(context.Background(), uuid.New())
+	if !errors.Is(err, authService.ErrUserNotFound) {
+		t.Fatalf("enroll = %v, want %v", err, authService.ErrUserNotFound)
+	}
+}
@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	authService "cloud-notes/internal/services/auth"
+	"cloud-notes/internal/storage"
+	"cloud-notes/internal/storage/memory"
+	"cloud-notes/test/testenv"
+)
+
+var linkToken = regexp.MustCompile(`token=(\S+)`)
+
+// mailbox keeps the mail the file mailer writes, one test at a time.
+type mailbox struct {
+	t   *testing.T
+	dir string
+}
+
+// take returns the recipient and the link token of the only new message,
+// and removes it.
+func (m *mailbox) take() (string, string) {
+	m.t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(m.dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		m.t.Fatalf("mail = %v, %v, want one message", files, err)
+	}
+
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		m.t.Fatal(err)
+	}
+
+	err = os.Remove(files[0])
+	if err != nil {
+		m.t.Fatal(err)
+	}
+
+	var to string
+	for line := range strings.Lines(string(data)) {
+		if value, ok := strings.CutPrefix(line, "To: "); ok {
+			to = strings.TrimSpace(value)
+		}
+	}
+
+	match := linkToken.FindStringSubmatch(string(data))
+	if match == nil {
+		m.t.Fatalf("no link in %s", data)
+	}
+
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		m.t.Fatal(err)
+	}
+
+	return to, token
+}
+
+func (m *mailbox) empty() bool {
+	files, _ := filepath.Glob(filepath.Join(m.dir, "*.eml"))
+	return len(files) == 0
+}
+
+// newMailEnv sets up the service with the file mailer, env adds to the
+// configuration.
+func newMailEnv(t *testing.T, env map[string]string) (
+	authService.Service, storage.Storage, *mailbox) {
+	t.Helper()
+
+	dir := t.TempDir()
+	vars := map[string]string{
+		"MAILER_DRIVER":                    "file",
+		"MAILER_DIR":                       dir,
+		"PASSWORD_HASH_ALGORITHM":          "argon2id",
+		"PASSWORD_HASH_ARGON2_MEMORY":      "1024",
+		"PASSWORD_HASH_ARGON2_ITERATIONS":  "1",
+		"PASSWORD_HASH_ARGON2_PARALLELISM": "1",
+	}
+	maps.Copy(vars, env)
+	cfg := testenv.Config(t, vars)
+	st := memory.New()
+	return testenv.Auth(cfg, st), st, &mailbox{t: t, dir: dir}
+}
+
+func register(srv authService.Service, login, email string) error {
+	return srv.Register(context.Background(), &authService.RegisterInput{
+		Login:     login,
+		Email:     email,
+		Password:  secret,
+		FirstName: "Ivan",
+		Timezone:  "UTC",
+	})
+}
+
+func TestRegisterKeepsLoginApartFromEmail(t *testing.T) {
+	srv, st, _ := newMailEnv(t, nil)
+	ctx := context.Background()
+
+	err := register(srv, "ivan_petrov", "Ivan@Example.com")
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	user, err := st.Users().GetByLogin(ctx, "ivan_petrov")
+	if err != nil || user == nil {
+		t.Fatalf("get user: %v, %v", user, err)
+	}
+
+	if user.Email == nil || *user.Email != "ivan@example.com" ||
+		user.Status != storage.UserStatusPending {
+		t.Errorf("user = %+v, want pending with the email in lower case",
+			user)
+	}
+
+	tests := []struct {
+		name  string
+		login string
+		email string
+		want  error
+	}{
+		{"taken login", "ivan_petrov", "other@example.com",
+			authService.ErrLoginAlreadyExists},
+		{"taken email", "other_login", "ivan@example.com",
+			authService.ErrEmailAlreadyExists},
+		{"taken email in other case", "other_login", "IVAN@example.com",
+			authService.ErrEmailAlreadyExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := register(srv, tt.login, tt.email)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("register = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	srv, st, mail := newMailEnv(t, nil)
+	ctx := context.Background()
+
+	err := register(srv, "ivan_petrov", "ivan@example.com")
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	err = srv.SendVerification(ctx, "ivan_petrov")
+	if err != nil {
+		t.Fatalf("send verification: %v", err)
+	}
+
+	to, stale := mail.take()
+	if to != "ivan@example.com" {
+		t.Errorf("mail went to %q, want the email", to)
+	}
+
+	// A new link replaces the one sent before.
+	err = srv.SendVerification(ctx, "ivan_petrov")
+	if err != nil {
+		t.Fatalf("send verification: %v", err)
+	}
+	_, token := mail.take()
+
+	err = srv.VerifyEmail(ctx, stale)
+	if !errors.Is(err, authService.ErrInvalidVerificationToken) {
+		t.Fatalf("stale link = %v, want %v",
+			err, authService.ErrInvalidVerificationToken)
+	}
+
+	// Requests with the same link at once activate the account once.
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		verified int
+	)
+	for range 8 {
+		wg.Go(func() {
+			err := srv.VerifyEmail(ctx, token)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				verified++
+			case !errors.Is(err, authService.ErrInvalidVerificationToken):
+				t.Errorf("verify: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if verified != 1 {
+		t.Errorf("the link worked %d times, want once", verified)
+	}
+
+	user, err := st.Users().GetByLogin(ctx, "ivan_petrov")
+	if err != nil || user.Status != storage.UserStatusActive {
+		t.Fatalf("user = %+v, %v, want active", user, err)
+	}
+
+	err = srv.SendVerification(ctx, "ivan_petrov")
+	if err != nil || !mail.empty() {
+		t.Errorf("a verified account got a link: %v", err)
+	}
+}
+
+func TestVerifyEmailKeepsBlock(t *testing.T) {
+	srv, st, mail := newMailEnv(t, nil)
+	ctx := context.Background()
+
+	err := register(srv, "ivan_petrov", "ivan@example.com")
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	err = srv.SendVerification(ctx, "ivan_petrov")
+	if err != nil {
+		t.Fatalf("send verification: %v", err)
+	}
+	_, token := mail.take()
+
+	user, err := st.Users().GetByLogin(ctx, "ivan_petrov")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user.Status = storage.UserStatusBlocked
+	err = st.Users().Update(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = srv.VerifyEmail(ctx, token)
+	if !errors.Is(err, authService.ErrInvalidVerificationToken) {
+		t.Fatalf("verify = %v, want %v",
+			err, authService.ErrInvalidVerificationToken)
+	}
+
+	user, err = st.Users().GetByID(ctx, user.ID)
+	if err != nil || user.Status != storage.UserStatusBlocked {
+		t.Errorf("user = %+v, %v, want still blocked", user, err)
+	}
+}
+
+func TestNoMailWithoutEmail(t *testing.T) {
+	srv, st, mail := newMailEnv(t, nil)
+	ctx := context.Background()
+
+	for _, status := range []storage.UserStatus{
+		storage.UserStatusPending, storage.UserStatusActive,
+	} {
+		user := testenv.NewUser(t, st, "legacy_"+string(status), "hash",
+			status)
+		user.Email = nil
+		err := st.Users().Update(ctx, user)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = srv.SendVerification(ctx, user.Login)
+		if err != nil {
+			t.Errorf("send verification: %v", err)
+		}
+
+		err = srv.SendPasswordReset(ctx, user.Login)
+		if err != nil {
+			t.Errorf("send password reset: %v", err)
+		}
+	}
+
+	if !mail.empty() {
+		t.Error("mail was sent to an account without an email")
+	}
+}
@@ -0,0 +1,332 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/events"
+	"cloud-notes/internal/storage"
+	"cloud-notes/internal/storage/memory"
+	"cloud-notes/internal/storage/outbox"
+	"cloud-notes/internal/storage/stream"
+	"cloud-notes/test/testenv"
+
+	"github.com/google/uuid"
+)
+
+var errUnavailable = errors.New("redis is unavailable")
+
+// brokenStream fails to publish until it is repaired.
+type brokenStream struct {
+	stream.Storage
+	mu     sync.Mutex
+	broken bool
+}
+
+func (s *brokenStream) Publish(ctx context.Context, event *outbox.Event) error {
+	s.mu.Lock()
+	broken := s.broken
+	s.mu.Unlock()
+
+	if broken {
+		return errUnavailable
+	}
+
+	return s.Storage.Publish(ctx, event)
+}
+
+func (s *brokenStream) repair() {
+	s.mu.Lock()
+	s.broken = false
+	s.mu.Unlock()
+}
+
+type brokenStorage struct {
+	storage.Storage
+	stream *brokenStream
+}
+
+func (s *brokenStorage) Stream() stream.Storage {
+	return s.stream
+}
+
+// cfg has no lease, so a failed event is due again at once. Consumers
+// claim messages left unacknowledged for claimIdle seconds.
+func cfg(claimIdle int) *config.Events {
+	return &config.Events{
+		Interval:    1,
+		Lease:       0,
+		MaxAttempts: 3,
+		Retention:   60,
+		ClaimIdle:   claimIdle,
+	}
+}
+
+// publish stores count events of the user in the outbox, a millisecond
+// apart, and returns their IDs oldest first.
+func publish(t *testing.T, st storage.Storage,
+	userID uuid.UUID, count int) []uuid.UUID {
+	t.Helper()
+
+	ids := make([]uuid.UUID, 0, count)
+	for range count {
+		event, err := events.NewAccountEvent(
+			events.TypeAccountPasswordChanged, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = st.Outbox().Create(context.Background(), event)
+		if err != nil {
+			t.Fatalf("create event: %v", err)
+		}
+
+		ids = append(ids, event.ID)
+		time.Sleep(time.Millisecond)
+	}
+
+	return ids
+}
+
+// read returns the IDs of the events the stream holds for a new group.
+func read(t *testing.T, st storage.Storage) []uuid.UUID {
+	t.Helper()
+
+	ctx := context.Background()
+	group := uuid.NewString()
+	err := st.Stream().CreateGroup(ctx, group)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages, err := st.Stream().Read(ctx, group, "reader", 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make([]uuid.UUID, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.Event.ID)
+	}
+
+	return ids
+}
+
+func TestDispatchPublishesOnce(t *testing.T) {
+	st := memory.New()
+	user := testenv.NewUser(t, st, "ivan@example.com", "hash",
+		storage.UserStatusActive)
+	bus := events.New(testenv.Logger(), st, cfg(60))
+	ctx := context.Background()
+
+	want := publish(t, st, user.ID, 3)
+
+	err := bus.Dispatch(ctx)
+	if err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+
+	err = bus.Dispatch(ctx)
+	if err != nil {
+		t.Fatalf("dispatch again: %v", err)
+	}
+
+	if got := read(t, st); !slices.Equal(got, want) {
+		t.Errorf("stream = %v, want %v once, oldest first", got, want)
+	}
+
+	err = bus.Purge(ctx)
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+
+	count, err := st.Outbox().Retry(ctx, time.Now())
+	if err != nil || count != 0 {
+		t.Errorf("retry = %d, %v, want nothing failed", count, err)
+	}
+}
+
+func TestDispatchDeadLetters(t *testing.T) {
+	mem := memory.New()
+	user := testenv.NewUser(t, mem, "ivan@example.com", "hash",
+		storage.UserStatusActive)
+	broken := &brokenStream{Storage: mem.Stream(), broken: true}
+	st := &brokenStorage{Storage: mem, stream: broken}
+	bus := events.New(testenv.Logger(), st, cfg(60))
+	ctx := context.Background()
+
+	want := publish(t, st, user.ID, 1)
+
+	// Every run is one attempt, the last one puts the event aside.
+	for range cfg(60).MaxAttempts + 1 {
+		err := bus.Dispatch(ctx)
+		if err != nil {
+			t.Fatalf("dispatch: %v", err)
+		}
+	}
+
+	broken.repair()
+
+	err := bus.Dispatch(ctx)
+	if err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+
+	if got := read(t, st); len(got) != 0 {
+		t.Fatalf("stream = %v, want the failed event held back", got)
+	}
+
+	count, err := st.Outbox().Retry(ctx, time.Now())
+	if err != nil || count != 1 {
+		t.Fatalf("retry = %d, %v, want the failed event", count, err)
+	}
+
+	err = bus.Dispatch(ctx)
+	if err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+
+	if got := read(t, st); !slices.Equal(got, want) {
+		t.Errorf("stream = %v, want %v after the retry", got, want)
+	}
+}
+
+// consumer records the events it handled and fails the ones it is told to
+// fail, once.
+type consumer struct {
+	mu      sync.Mutex
+	handled map[uuid.UUID]int
+	fail    map[uuid.UUID]bool
+}
+
+func newConsumer() *consumer {
+	return &consumer{
+		handled: make(map[uuid.UUID]int),
+		fail:    make(map[uuid.UUID]bool),
+	}
+}
+
+func (c *consumer) handle(ctx context.Context, event *outbox.Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.fail[event.ID] {
+		delete(c.fail, event.ID)
+		return errUnavailable
+	}
+
+	c.handled[event.ID]++
+	return nil
+}
+
+func (c *consumer) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.handled)
+}
+
+// consume runs the consumers until they have handled want events between
+// them. A consumer is named after its group, like "webhooks/1".
+func consume(t *testing.T, bus events.Bus,
+	want int, consumers map[string]*consumer) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for name, c := range consumers {
+		group, _, _ := strings.Cut(name, "/")
+		wg.Go(func() {
+			bus.Consume(ctx, group, name, c.handle)
+		})
+	}
+
+	for ctx.Err() == nil {
+		total := 0
+		for _, c := range consumers {
+			total += c.count()
+		}
+
+		if total >= want {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	wg.Wait()
+}
+
+func TestConsumerGroups(t *testing.T) {
+	st := memory.New()
+	user := testenv.NewUser(t, st, "ivan@example.com", "hash",
+		storage.UserStatusActive)
+	// Nothing is claimed from the other consumer while it handles it.
+	bus := events.New(testenv.Logger(), st, cfg(60))
+
+	ids := publish(t, st, user.ID, 6)
+	err := bus.Dispatch(context.Background())
+	if err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+
+	consumers := map[string]*consumer{
+		"webhooks/1": newConsumer(),
+		"webhooks/2": newConsumer(),
+		"search/1":   newConsumer(),
+	}
+	consume(t, bus, 2*len(ids), consumers)
+
+	// Each group sees every event once, split between its consumers.
+	groups := map[string]map[uuid.UUID]int{
+		"webhooks": {},
+		"search":   {},
+	}
+	for name, c := range consumers {
+		group, _, _ := strings.Cut(name, "/")
+		for id, times := range c.handled {
+			groups[group][id] += times
+		}
+	}
+
+	for group, handled := range groups {
+		for _, id := range ids {
+			if handled[id] != 1 {
+				t.Errorf("%s handled %s %d times, want once",
+					group, id, handled[id])
+			}
+		}
+	}
+}
+
+func TestConsumerRetriesFailedEvent(t *testing.T) {
+	st := memory.New()
+	user := testenv.NewUser(t, st, "ivan@example.com", "hash",
+		storage.UserStatusActive)
+	bus := events.New(testenv.Logger(), st, cfg(0))
+
+	ids := publish(t, st, user.ID, 2)
+	err := bus.Dispatch(context.Background())
+	if err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+
+	c := newConsumer()
+	c.fail[ids[0]] = true
+	consume(t, bus, len(ids), map[string]*consumer{"webhooks/1": c})
+
+	for _, id := range ids {
+		if c.handled[id] != 1 {
+			t.Errorf("handled %s %d times, want once", id, c.handled[id])
+		}
+	}
+}
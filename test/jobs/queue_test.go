@@ -0,0 +1,301 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/jobs"
+	"cloud-notes/internal/storage"
+	"cloud-notes/internal/storage/memory"
+	"cloud-notes/test/testenv"
+
+	"github.com/google/uuid"
+)
+
+const jobType = "test.job"
+
+var cfg = &config.Jobs{
+	Interval:    1,
+	Concurrency: 2,
+	Lease:       60,
+	MaxAttempts: 4,
+	BackoffBase: 10,
+	BackoffMax:  25,
+	Retention:   60,
+}
+
+// handler fails the attempts it is told to fail and counts the calls.
+type handler struct {
+	mu    sync.Mutex
+	calls int
+	fail  func(job *storage.Job) error
+}
+
+func (h *handler) handle(ctx context.Context, job *storage.Job) error {
+	h.mu.Lock()
+	h.calls++
+	fail := h.fail
+	h.mu.Unlock()
+
+	if fail != nil {
+		return fail(job)
+	}
+
+	return nil
+}
+
+func (h *handler) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.calls
+}
+
+func failing(job *storage.Job) error {
+	return fmt.Errorf("attempt %d", job.Attempts)
+}
+
+// pass makes the worker run the due jobs once. Run works through the due
+// jobs before it looks at the context, so a done one makes it return
+// right after.
+func pass(q jobs.Queue) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	q.Run(ctx)
+}
+
+func enqueue(t *testing.T, q jobs.Queue, st storage.Storage) *storage.Job {
+	t.Helper()
+	ctx := context.Background()
+
+	err := q.Enqueue(ctx, jobType, map[string]string{"key": "value"})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	list, err := st.Jobs().List(ctx, &storage.JobFilter{}, 1, 0)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("jobs = %v, %v, want the job", list, err)
+	}
+
+	return list[0]
+}
+
+func get(t *testing.T, st storage.Storage, id uuid.UUID) *storage.Job {
+	t.Helper()
+
+	job, err := st.Jobs().GetByID(context.Background(), id)
+	if err != nil || job == nil {
+		t.Fatalf("get job: %v, %v", job, err)
+	}
+
+	return job
+}
+
+// due brings the pending job's run time forward, so the next pass claims
+// it without waiting for the backoff.
+func due(t *testing.T, st storage.Storage, job *storage.Job) {
+	t.Helper()
+
+	lease := job.RunAt
+	job.RunAt = time.Now()
+	updated, err := st.Jobs().Update(context.Background(), job, lease)
+	if err != nil || !updated {
+		t.Fatalf("update = %v, %v", updated, err)
+	}
+}
+
+func TestRunSucceeds(t *testing.T) {
+	st := memory.New()
+	q := jobs.New(testenv.Logger(), st, cfg)
+	h := &handler{}
+	q.Handle(jobType, h.handle)
+
+	job := enqueue(t, q, st)
+	pass(q)
+	pass(q)
+
+	job = get(t, st, job.ID)
+	if h.count() != 1 || job.Status != storage.JobStatusSucceeded ||
+		job.Attempts != 1 || job.FinishedAt == nil || job.Error != nil {
+		t.Errorf("ran %d times, job = %+v, want succeeded once",
+			h.count(), job)
+	}
+
+	err := q.Purge(context.Background())
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+
+	if get(t, st, job.ID) == nil {
+		t.Error("a job within the retention was purged")
+	}
+}
+
+func TestRunBacksOff(t *testing.T) {
+	st := memory.New()
+	q := jobs.New(testenv.Logger(), st, cfg)
+	h := &handler{fail: failing}
+	q.Handle(jobType, h.handle)
+
+	job := enqueue(t, q, st)
+
+	// The delay doubles from BACKOFF_BASE until it reaches BACKOFF_MAX.
+	for attempt, backoff := range []time.Duration{
+		10 * time.Second, 20 * time.Second, 25 * time.Second,
+	} {
+		before := time.Now()
+		pass(q)
+		after := time.Now()
+
+		job = get(t, st, job.ID)
+		want := fmt.Sprintf("attempt %d", attempt+1)
+		if job.Status != storage.JobStatusPending ||
+			job.Error == nil || *job.Error != want {
+			t.Fatalf("job = %+v, want pending after %q", job, want)
+		}
+
+		if job.RunAt.Before(before.Add(backoff).Truncate(time.Microsecond)) ||
+			job.RunAt.After(after.Add(backoff)) {
+			t.Errorf("attempt %d: run at %v, want %v later",
+				attempt+1, job.RunAt.Sub(after), backoff)
+		}
+
+		// Not due yet.
+		pass(q)
+		if h.count() != attempt+1 {
+			t.Fatalf("ran %d times before the backoff was over", h.count())
+		}
+
+		due(t, st, job)
+	}
+}
+
+func TestRunDeadLetters(t *testing.T) {
+	st := memory.New()
+	q := jobs.New(testenv.Logger(), st, cfg)
+	h := &handler{fail: failing}
+	q.Handle(jobType, h.handle)
+
+	job := enqueue(t, q, st)
+	for range cfg.MaxAttempts - 1 {
+		pass(q)
+		due(t, st, get(t, st, job.ID))
+	}
+	pass(q)
+
+	job = get(t, st, job.ID)
+	want := fmt.Sprintf("attempt %d", cfg.MaxAttempts)
+	if job.Status != storage.JobStatusFailed || job.FinishedAt == nil ||
+		job.Error == nil || *job.Error != want {
+		t.Fatalf("job = %+v, want failed after %q", job, want)
+	}
+
+	// A failed job waits for a retry by hand even when it is due.
+	due(t, st, job)
+	pass(q)
+	if h.count() != cfg.MaxAttempts {
+		t.Errorf("ran %d times, want %d", h.count(), cfg.MaxAttempts)
+	}
+
+	// Failed jobs stay when the succeeded ones are purged.
+	err := q.Purge(context.Background())
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	get(t, st, job.ID)
+}
+
+func TestRunRecoversPanic(t *testing.T) {
+	st := memory.New()
+	q := jobs.New(testenv.Logger(), st, cfg)
+	q.Handle(jobType, func(ctx context.Context, job *storage.Job) error {
+		panic("boom")
+	})
+
+	job := enqueue(t, q, st)
+	pass(q)
+
+	job = get(t, st, job.ID)
+	if job.Status != storage.JobStatusPending || job.Error == nil ||
+		*job.Error != "panic: boom" {
+		t.Errorf("job = %+v, want the panic as the error", job)
+	}
+}
+
+func TestRunKeepsOutcomeOfNextLease(t *testing.T) {
+	st := memory.New()
+	q := jobs.New(testenv.Logger(), st, cfg)
+
+	// The lease runs out while the handler runs, and another worker
+	// claims the job.
+	var reclaimed *storage.Job
+	q.Handle(jobType, func(ctx context.Context, job *storage.Job) error {
+		later := time.Now().Add(2 * time.Minute)
+		claimed, err := st.Jobs().Claim(context.Background(),
+			later, later.Add(time.Minute), 1)
+		if err != nil || len(claimed) != 1 {
+			return fmt.Errorf("claim = %v, %v", claimed, err)
+		}
+
+		reclaimed = claimed[0]
+		return nil
+	})
+
+	job := enqueue(t, q, st)
+	pass(q)
+
+	if reclaimed == nil {
+		t.Fatal("the job wasn't claimed again")
+	}
+
+	job = get(t, st, job.ID)
+	if job.Status != storage.JobStatusPending || job.Attempts != 2 ||
+		!job.RunAt.Equal(reclaimed.RunAt) {
+		t.Errorf("job = %+v, want the second lease untouched", job)
+	}
+}
+
+func TestScheduleEnqueuesOnce(t *testing.T) {
+	st := memory.New()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Every worker schedules the job, one of them runs it.
+	h := &handler{}
+	var wg sync.WaitGroup
+	for range 3 {
+		q := jobs.New(testenv.Logger(), st, cfg)
+		q.Handle(jobType, h.handle)
+		q.Schedule(jobType, time.Hour)
+		wg.Go(func() { q.Run(ctx) })
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for h.count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Leaves the slower workers time to schedule the job too.
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	wg.Wait()
+
+	list, err := st.Jobs().List(context.Background(),
+		&storage.JobFilter{Type: jobType}, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 1 || h.count() != 1 {
+		t.Fatalf("jobs = %d, ran %d times, want one job run once",
+			len(list), h.count())
+	}
+
+	key := fmt.Sprintf("%s@%d", jobType, time.Now().Truncate(time.Hour).Unix())
+	if list[0].UniqueKey == nil || *list[0].UniqueKey != key {
+		t.Errorf("job = %+v, want the key %s", list[0], key)
+	}
+}
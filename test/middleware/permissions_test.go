@@ -0,0 +1,217 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/middleware"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/storage"
+	"cloud-notes/test/testenv"
+
+	"github.com/google/uuid"
+)
+
+// guard mounts the middleware after the session one, like the server does.
+func (e *scopeEnv) guard(mw func(http.Handler) http.Handler) http.Handler {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	return middleware.Security(newLogger(), e.st, e.sec)(mw(ok))
+}
+
+// impersonationToken opens a session of the user for the admin, the way
+// the admin service does.
+func (e *scopeEnv) impersonationToken(t *testing.T,
+	userID, adminID uuid.UUID) string {
+	t.Helper()
+	ctx := context.Background()
+
+	expiresAt := time.Now().Add(time.Hour)
+	session := &storage.Session{
+		ID:             uuid.New(),
+		UserID:         userID,
+		CreatedAt:      time.Now(),
+		ExpiresAt:      &expiresAt,
+		ImpersonatorID: &adminID,
+	}
+	err := e.st.Sessions().Create(ctx, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return e.sec.GenerateAccessToken(ctx, &security.Claims{
+		UserID:         userID,
+		SessionID:      session.ID,
+		ImpersonatorID: &adminID,
+		ExpiresAt:      &expiresAt,
+		CreatedAt:      session.CreatedAt,
+	})
+}
+
+func (e *scopeEnv) newUserWithRole(t *testing.T, login string,
+	role storage.UserRole) *storage.User {
+	t.Helper()
+
+	user := testenv.NewUser(t, e.st, login, "hash", storage.UserStatusActive)
+	user.Role = role
+	err := e.st.Users().Update(context.Background(), user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return user
+}
+
+func status(h http.Handler, token string) int {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	return w.Code
+}
+
+func TestRequire(t *testing.T) {
+	e := newScopeEnv(t)
+	users := map[storage.UserRole]string{}
+	for login, role := range map[string]storage.UserRole{
+		"ivan@example.com":  storage.UserRoleUser,
+		"olga@example.com":  storage.UserRoleSupport,
+		"admin@example.com": storage.UserRoleAdmin,
+	} {
+		user := e.newUserWithRole(t, login, role)
+		users[role] = e.sessionToken(t, user.ID)
+	}
+
+	readUsers := e.guard(middleware.Require(security.PermissionUsersRead))
+	writeUsers := e.guard(middleware.Require(security.PermissionUsersRead,
+		security.PermissionUsersWrite))
+	nothing := e.guard(middleware.Require())
+
+	tests := []struct {
+		name    string
+		h       http.Handler
+		role    storage.UserRole
+		allowed bool
+	}{
+		{"user reads", readUsers, storage.UserRoleUser, false},
+		{"support reads", readUsers, storage.UserRoleSupport, true},
+		{"support writes", writeUsers, storage.UserRoleSupport, false},
+		{"admin writes", writeUsers, storage.UserRoleAdmin, true},
+		{"user needs nothing", nothing, storage.UserRoleUser, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := http.StatusForbidden
+			if tt.allowed {
+				want = http.StatusOK
+			}
+
+			if got := status(tt.h, users[tt.role]); got != want {
+				t.Errorf("status %d, want %d", got, want)
+			}
+		})
+	}
+
+	// Without Security in front there are no claims to check.
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	middleware.Require()(http.NotFoundHandler()).ServeHTTP(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("status %d without claims, want 403", w.Code)
+	}
+}
+
+func TestRequireLoadsRole(t *testing.T) {
+	e := newScopeEnv(t)
+	ctx := context.Background()
+	h := e.guard(middleware.Require(security.PermissionStatsRead))
+
+	user := e.newUserWithRole(t, "ivan@example.com", storage.UserRoleUser)
+	token := e.sessionToken(t, user.ID)
+	if got := status(h, token); got != http.StatusForbidden {
+		t.Fatalf("status %d, want 403", got)
+	}
+
+	err := e.st.Roles().Create(ctx, &storage.Role{
+		Name:        "analyst",
+		Permissions: []string{security.PermissionStatsRead},
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A role change applies to the token the user already has.
+	user.Role = "analyst"
+	err = e.st.Users().Update(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := status(h, token); got != http.StatusOK {
+		t.Errorf("status %d with the custom role, want 200", got)
+	}
+
+	user.Role = storage.UserRoleUser
+	err = e.st.Users().Update(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := status(h, token); got != http.StatusForbidden {
+		t.Errorf("status %d after the role was taken, want 403", got)
+	}
+}
+
+func TestRequireIgnoresRoleOfTokens(t *testing.T) {
+	e := newScopeEnv(t)
+	admin := e.newUserWithRole(t, "admin@example.com", storage.UserRoleAdmin)
+	other := e.newUserWithRole(t, "root@example.com", storage.UserRoleAdmin)
+
+	require := middleware.Require(security.PermissionUsersRead)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	scoped := middleware.Security(newLogger(), e.st, e.sec,
+		security.ScopeProfileRead)(require(ok))
+
+	token := e.newToken(t, admin.ID, nil, security.ScopeProfileRead)
+	if got := status(scoped, token.Token); got != http.StatusForbidden {
+		t.Errorf("personal token: status %d, want 403", got)
+	}
+
+	// An admin acting as another admin doesn't get their role either.
+	impersonation := e.impersonationToken(t, admin.ID, other.ID)
+	if got := status(e.guard(require), impersonation); got !=
+		http.StatusForbidden {
+		t.Errorf("impersonation: status %d, want 403", got)
+	}
+}
+
+func TestDenyImpersonation(t *testing.T) {
+	e := newScopeEnv(t)
+	user := e.newUserWithRole(t, "ivan@example.com", storage.UserRoleUser)
+	admin := e.newUserWithRole(t, "admin@example.com", storage.UserRoleAdmin)
+	h := e.guard(middleware.DenyImpersonation())
+
+	if got := status(h, e.sessionToken(t, user.ID)); got != http.StatusOK {
+		t.Errorf("user: status %d, want 200", got)
+	}
+
+	token := e.impersonationToken(t, user.ID, admin.ID)
+	if got := status(h, token); got != http.StatusForbidden {
+		t.Errorf("impersonation: status %d, want 403", got)
+	}
+
+	// The same session passes routes that don't deny it.
+	if got := status(e.guard(middleware.Require()), token); got !=
+		http.StatusOK {
+		t.Errorf("impersonation elsewhere: status %d, want 200", got)
+	}
+}
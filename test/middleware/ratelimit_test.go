@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/middleware"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/storage/memory"
+
+	"github.com/google/uuid"
+)
+
+func newLogger() logger.Logger {
+	return logger.MustLoad(&config.Logger{
+		Level:  "error",
+		Output: "discard",
+		Format: "text",
+	})
+}
+
+func newHandler(limit int, proxies config.Proxies) http.Handler {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	rateLimit := middleware.RateLimit(newLogger(), memory.New(), "test",
+		&config.RateLimitBudget{Limit: limit, Period: 60})
+	return middleware.RealIP(proxies)(rateLimit(ok))
+}
+
+// request sends a request from remoteAddr, with X-Forwarded-For when
+// forwardedFor is set.
+func request(h http.Handler, remoteAddr, forwardedFor string,
+	claims *security.Claims) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		r.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	if claims != nil {
+		r = r.WithContext(security.SetClaims(r.Context(), claims))
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func proxies(t *testing.T, value string) config.Proxies {
+	t.Helper()
+
+	var p config.Proxies
+	err := p.SetValue(value)
+	if err != nil {
+		t.Fatalf("parse proxies: %v", err)
+	}
+
+	return p
+}
+
+func TestRateLimit(t *testing.T) {
+	h := newHandler(2, nil)
+
+	for i, remaining := range []string{"1", "0"} {
+		w := request(h, "192.0.2.1:1234", "", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d", i+1, w.Code)
+		}
+
+		if got := w.Header().Get("RateLimit-Limit"); got != "2" {
+			t.Fatalf("RateLimit-Limit %q, want 2", got)
+		}
+		if got := w.Header().Get("RateLimit-Remaining"); got != remaining {
+			t.Fatalf("RateLimit-Remaining %q, want %s", got, remaining)
+		}
+	}
+
+	w := request(h, "192.0.2.1:1234", "", nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "30" {
+		t.Fatalf("Retry-After %q, want 30", got)
+	}
+	if got := w.Header().Get("RateLimit-Reset"); got != "60" {
+		t.Fatalf("RateLimit-Reset %q, want 60", got)
+	}
+}
+
+func TestRateLimitPerClient(t *testing.T) {
+	h := newHandler(1, nil)
+	claims := &security.Claims{UserID: uuid.New()}
+
+	requests := []struct {
+		name   string
+		addr   string
+		claims *security.Claims
+	}{
+		{"first ip", "192.0.2.1:1234", nil},
+		{"second ip", "192.0.2.2:1234", nil},
+		{"user on the first ip", "192.0.2.1:1234", claims},
+	}
+	for _, req := range requests {
+		w := request(h, req.addr, "", req.claims)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status %d", req.name, w.Code)
+		}
+	}
+
+	w := request(h, "192.0.2.3:1234", "", claims)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("user on another ip: status %d, want 429", w.Code)
+	}
+}
+
+func TestRateLimitForwardedFor(t *testing.T) {
+	h := newHandler(1, proxies(t, "10.0.0.0/8, 192.0.2.10"))
+
+	// Behind the proxies every client has a budget of its own.
+	for _, client := range []string{"198.51.100.1", "198.51.100.2"} {
+		w := request(h, "10.0.0.1:1234", client+", 192.0.2.10", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("client %s: status %d", client, w.Code)
+		}
+	}
+
+	// The leftmost address is the client's to write, only the one the
+	// trusted proxy appended counts.
+	w := request(h, "10.0.0.1:1234", "203.0.113.1, 198.51.100.1", nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("forged address: status %d, want 429", w.Code)
+	}
+}
+
+func TestRateLimitForwardedForUntrusted(t *testing.T) {
+	h := newHandler(1, proxies(t, "10.0.0.0/8"))
+
+	w := request(h, "192.0.2.1:1234", "198.51.100.1", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("first request: status %d", w.Code)
+	}
+
+	w = request(h, "192.0.2.1:1234", "198.51.100.2", nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("header of an untrusted peer used: status %d", w.Code)
+	}
+}
+
+func TestProxiesRejectInvalid(t *testing.T) {
+	var p config.Proxies
+	err := p.SetValue("10.0.0.0/8, proxy")
+	if err == nil {
+		t.Fatal("invalid proxy accepted")
+	}
+}
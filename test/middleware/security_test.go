@@ -0,0 +1,250 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/middleware"
+	"cloud-notes/internal/security"
+	authService "cloud-notes/internal/services/auth"
+	"cloud-notes/internal/storage"
+	"cloud-notes/internal/storage/memory"
+	"cloud-notes/test/testenv"
+
+	"github.com/google/uuid"
+)
+
+// scopeEnv guards a route of every kind the server has: one for login
+// sessions only and one per scope.
+type scopeEnv struct {
+	st     storage.Storage
+	sec    security.Security
+	auth   authService.Service
+	routes map[string]http.Handler
+	claims *security.Claims
+}
+
+func newScopeEnv(t *testing.T) *scopeEnv {
+	t.Helper()
+
+	cfg := testenv.Config(t, nil)
+	st := memory.New()
+	e := &scopeEnv{
+		st:     st,
+		sec:    security.MustNew(newLogger(), st, &cfg.JWT),
+		auth:   testenv.Auth(cfg, st),
+		routes: make(map[string]http.Handler),
+	}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e.claims = security.GetClaims(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	e.routes["session"] = middleware.Security(newLogger(), st, e.sec)(ok)
+	for _, scope := range []string{
+		security.ScopeNotesRead, security.ScopeNotesWrite,
+		security.ScopeProfileRead, security.ScopeProfileWrite,
+	} {
+		e.routes[scope] = middleware.Security(
+			newLogger(), st, e.sec, scope)(ok)
+	}
+
+	return e
+}
+
+func (e *scopeEnv) newToken(t *testing.T, userID uuid.UUID,
+	expiresAt *time.Time,
+	scopes ...string) *authService.CreatePersonalTokenOutput {
+	t.Helper()
+
+	output, err := e.auth.CreatePersonalToken(context.Background(),
+		&authService.CreatePersonalTokenInput{
+			UserID:    userID,
+			Name:      "script",
+			Scopes:    scopes,
+			ExpiresAt: expiresAt,
+		})
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+
+	return output
+}
+
+// sessionToken starts a login session of the user and returns its access
+// token.
+func (e *scopeEnv) sessionToken(t *testing.T, userID uuid.UUID) string {
+	t.Helper()
+	ctx := context.Background()
+
+	session := &storage.Session{
+		ID:        uuid.New(),
+		UserID:    userID,
+		CreatedAt: time.Now(),
+	}
+	err := e.st.Sessions().Create(ctx, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return e.sec.GenerateAccessToken(ctx, &security.Claims{
+		UserID:    userID,
+		SessionID: session.ID,
+		CreatedAt: session.CreatedAt,
+	})
+}
+
+// allowed returns the routes the token gets through and fails the test on
+// answers other than 200, 401 and 403.
+func (e *scopeEnv) allowed(t *testing.T, token string) []string {
+	t.Helper()
+
+	allowed := make([]string, 0)
+	for name, h := range e.routes {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		switch w.Code {
+		case http.StatusOK:
+			allowed = append(allowed, name)
+		case http.StatusUnauthorized, http.StatusForbidden:
+		default:
+			t.Fatalf("%s: status %d", name, w.Code)
+		}
+	}
+
+	slices.Sort(allowed)
+	return allowed
+}
+
+func TestPersonalTokenScopes(t *testing.T) {
+	e := newScopeEnv(t)
+	user := testenv.NewUser(t, e.st, "ivan@example.com", "hash",
+		storage.UserStatusActive)
+
+	tests := []struct {
+		name   string
+		scopes []string
+		want   []string
+	}{
+		{"notes read", []string{security.ScopeNotesRead},
+			[]string{security.ScopeNotesRead}},
+		{"notes",
+			[]string{security.ScopeNotesRead, security.ScopeNotesWrite},
+			[]string{security.ScopeNotesRead, security.ScopeNotesWrite}},
+		{"profile write", []string{security.ScopeProfileWrite},
+			[]string{security.ScopeProfileWrite}},
+		{"no scopes", nil, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := e.newToken(t, user.ID, nil, tt.scopes...)
+
+			// Personal tokens never pass for a login session.
+			got := e.allowed(t, token.Token)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("allowed %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	token := e.sessionToken(t, user.ID)
+	want := []string{security.ScopeNotesRead, security.ScopeNotesWrite,
+		security.ScopeProfileRead, security.ScopeProfileWrite, "session"}
+	if got := e.allowed(t, token); !slices.Equal(got, want) {
+		t.Errorf("session allowed %v, want %v", got, want)
+	}
+}
+
+func TestPersonalTokenClaims(t *testing.T) {
+	e := newScopeEnv(t)
+	ctx := context.Background()
+	user := testenv.NewUser(t, e.st, "ivan@example.com", "hash",
+		storage.UserStatusActive)
+	token := e.newToken(t, user.ID, nil, security.ScopeNotesRead)
+
+	stored, err := e.st.PersonalTokens().GetByID(ctx, token.ID)
+	if err != nil || stored == nil {
+		t.Fatalf("token = %v, %v", stored, err)
+	}
+
+	if stored.TokenHash == token.Token ||
+		stored.TokenHash != security.HashPersonalToken(token.Token) {
+		t.Error("the token is stored as is")
+	}
+
+	if stored.LastUsedAt != nil {
+		t.Error("a new token was used")
+	}
+
+	e.allowed(t, token.Token)
+
+	// The token acts with the user's data but not their role.
+	if e.claims == nil || e.claims.UserID != user.ID ||
+		!slices.Equal(e.claims.Scopes, []string{security.ScopeNotesRead}) ||
+		len(e.claims.Permissions) != 0 {
+		t.Errorf("claims = %+v, want the user limited to the scope",
+			e.claims)
+	}
+
+	stored, err = e.st.PersonalTokens().GetByID(ctx, token.ID)
+	if err != nil || stored.LastUsedAt == nil {
+		t.Errorf("token = %+v, %v, want the use recorded", stored, err)
+	}
+}
+
+func TestPersonalTokenRejected(t *testing.T) {
+	e := newScopeEnv(t)
+	ctx := context.Background()
+	user := testenv.NewUser(t, e.st, "ivan@example.com", "hash",
+		storage.UserStatusActive)
+	blocked := testenv.NewUser(t, e.st, "olga@example.com", "hash",
+		storage.UserStatusActive)
+
+	expiresAt := time.Now().Add(20 * time.Millisecond)
+	expired := e.newToken(t, user.ID, &expiresAt, security.ScopeNotesRead)
+	revoked := e.newToken(t, user.ID, nil, security.ScopeNotesRead)
+	ofBlocked := e.newToken(t, blocked.ID, nil, security.ScopeNotesRead)
+	valid := e.newToken(t, user.ID, nil, security.ScopeNotesRead)
+
+	err := e.auth.RevokePersonalToken(ctx,
+		&authService.RevokePersonalTokenInput{
+			UserID:          user.ID,
+			PersonalTokenID: revoked.ID,
+		})
+	if err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+
+	blocked.Status = storage.UserStatusBlocked
+	err = e.st.Users().Update(ctx, blocked)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(time.Until(expiresAt))
+
+	for name, token := range map[string]string{
+		"expired":  expired.Token,
+		"revoked":  revoked.Token,
+		"blocked":  ofBlocked.Token,
+		"unknown":  "cnp_unknown",
+		"tampered": valid.Token[:len(valid.Token)-1] + "!",
+	} {
+		if got := e.allowed(t, token); len(got) != 0 {
+			t.Errorf("%s token allowed %v", name, got)
+		}
+	}
+
+	want := []string{security.ScopeNotesRead}
+	if got := e.allowed(t, valid.Token); !slices.Equal(got, want) {
+		t.Errorf("valid token allowed %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,202 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/quota"
+	notesService "cloud-notes/internal/services/notes"
+	"cloud-notes/internal/storage"
+	"cloud-notes/internal/storage/memory"
+
+	"github.com/google/uuid"
+)
+
+func newService(t *testing.T) (notesService.Service, storage.Storage) {
+	t.Helper()
+
+	log := logger.MustLoad(&config.Logger{
+		Level:  "error",
+		Output: "discard",
+		Format: "text",
+	})
+	st := memory.New()
+	qt := quota.New(log, st, &config.Quota{DefaultPlan: "free"})
+	return notesService.New(log, st, qt), st
+}
+
+func newUser(t *testing.T, st storage.Storage) uuid.UUID {
+	t.Helper()
+
+	user := &storage.User{
+		ID:           uuid.New(),
+		Login:        uuid.NewString() + "@example.com",
+		PasswordHash: "hash",
+		FirstName:    "Ivan",
+		Timezone:     "UTC",
+		Status:       storage.UserStatusActive,
+		CreatedAt:    time.Now(),
+		Role:         storage.UserRoleUser,
+	}
+
+	err := st.Users().Create(context.Background(), user)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	return user.ID
+}
+
+func usage(t *testing.T, st storage.Storage, userID uuid.UUID) (int64, int64) {
+	t.Helper()
+
+	u, err := st.Usage().GetByUserID(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("get usage: %v", err)
+	}
+
+	if u == nil {
+		return 0, 0
+	}
+
+	return u.Notes, u.Bytes
+}
+
+func TestDeleteNoteConcurrently(t *testing.T) {
+	srv, st := newService(t)
+	ctx := context.Background()
+	userID := newUser(t, st)
+
+	text := "some text"
+	note, err := srv.CreateNote(ctx, &notesService.CreateNoteInput{
+		Space: notesService.Space{UserID: userID},
+		Text:  &text,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notes, bytes := usage(t, st, userID)
+	if notes != 1 || bytes != int64(len(text)) {
+		t.Fatalf("usage after create = %d notes, %d bytes", notes, bytes)
+	}
+
+	const requests = 10
+	errs := make([]error, requests)
+	var wg sync.WaitGroup
+	for i := range requests {
+		wg.Go(func() {
+			errs[i] = srv.DeleteNote(ctx, &notesService.DeleteNoteInput{
+				Space:  notesService.Space{UserID: userID},
+				NoteID: note.ID,
+			})
+		})
+	}
+	wg.Wait()
+
+	var deleted int
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			deleted++
+		case !errors.Is(err, notesService.ErrNoteNotFound):
+			t.Fatalf("delete: %v", err)
+		}
+	}
+	if deleted != 1 {
+		t.Fatalf("%d requests deleted the note, want 1", deleted)
+	}
+
+	notes, bytes = usage(t, st, userID)
+	if notes != 0 || bytes != 0 {
+		t.Fatalf("usage after delete = %d notes, %d bytes", notes, bytes)
+	}
+}
+
+func TestUpdateNoteConcurrently(t *testing.T) {
+	srv, st := newService(t)
+	ctx := context.Background()
+	userID := newUser(t, st)
+
+	text := "x"
+	note, err := srv.CreateNote(ctx, &notesService.CreateNoteInput{
+		Space: notesService.Space{UserID: userID},
+		Text:  &text,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every update changes the size by its own amount, so a delta counted
+	// from a text another update already replaced shows in the total.
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Go(func() {
+			text := strings.Repeat("x", 10*(i+1))
+			_, err := srv.UpdateNote(ctx, &notesService.UpdateNoteInput{
+				Space:  notesService.Space{UserID: userID},
+				NoteID: note.ID,
+				Text:   &text,
+			})
+			if err != nil {
+				t.Errorf("update: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	got, err := st.Notes().GetByID(ctx, note.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notes, bytes := usage(t, st, userID)
+	if notes != 1 || bytes != int64(len(*got.Text)) {
+		t.Fatalf("usage = %d notes, %d bytes, want 1 note, %d bytes",
+			notes, bytes, len(*got.Text))
+	}
+}
+
+func TestNotesOfOtherUsersAreMissing(t *testing.T) {
+	srv, st := newService(t)
+	ctx := context.Background()
+	ownerID := newUser(t, st)
+	otherID := newUser(t, st)
+
+	text := "text"
+	note, err := srv.CreateNote(ctx, &notesService.CreateNoteInput{
+		Space: notesService.Space{UserID: ownerID},
+		Text:  &text,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = srv.UpdateNote(ctx, &notesService.UpdateNoteInput{
+		Space:  notesService.Space{UserID: otherID},
+		NoteID: note.ID,
+		Text:   &text,
+	})
+	if !errors.Is(err, notesService.ErrNoteNotFound) {
+		t.Fatalf("update = %v, want %v", err, notesService.ErrNoteNotFound)
+	}
+
+	err = srv.DeleteNote(ctx, &notesService.DeleteNoteInput{
+		Space:  notesService.Space{UserID: otherID},
+		NoteID: note.ID,
+	})
+	if !errors.Is(err, notesService.ErrNoteNotFound) {
+		t.Fatalf("delete = %v, want %v", err, notesService.ErrNoteNotFound)
+	}
+
+	notes, bytes := usage(t, st, ownerID)
+	if notes != 1 || bytes != int64(len(text)) {
+		t.Fatalf("usage = %d notes, %d bytes", notes, bytes)
+	}
+}
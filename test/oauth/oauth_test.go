@@ -0,0 +1,525 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/audit"
+	"cloud-notes/internal/security"
+	oauthService "cloud-notes/internal/services/oauth"
+	"cloud-notes/internal/storage"
+	"cloud-notes/internal/storage/memory"
+	"cloud-notes/test/testenv"
+
+	"github.com/google/uuid"
+)
+
+const (
+	redirectURI = "https://app.example.com/callback"
+	verifier    = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+type env struct {
+	t      *testing.T
+	st     storage.Storage
+	srv    oauthService.Service
+	user   *storage.User
+	client *oauthService.CreateClientOutput
+}
+
+func newEnv(t *testing.T, vars map[string]string) *env {
+	t.Helper()
+
+	cfg := testenv.Config(t, vars)
+	log := testenv.Logger()
+	st := memory.New()
+	e := &env{
+		t:  t,
+		st: st,
+		srv: oauthService.New(log, st, security.MustNew(log, st, &cfg.JWT),
+			audit.New(log, st), cfg),
+		user: testenv.NewUser(t, st, "ivan@example.com", "hash",
+			storage.UserStatusActive),
+	}
+
+	client, err := e.srv.CreateClient(context.Background(),
+		&oauthService.CreateClientInput{
+			OwnerID:      e.user.ID,
+			Name:         "Calendar",
+			RedirectURIs: []string{redirectURI},
+			Scopes: []string{security.ScopeNotesRead,
+				security.ScopeNotesWrite},
+			Confidential: true,
+		})
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	e.client = client
+
+	return e
+}
+
+func (e *env) credentials() oauthService.ClientCredentials {
+	return oauthService.ClientCredentials{
+		ClientID:     e.client.ID.String(),
+		ClientSecret: *e.client.Secret,
+	}
+}
+
+func (e *env) authorizeInput() oauthService.AuthorizeInput {
+	return oauthService.AuthorizeInput{
+		UserID:              e.user.ID,
+		ClientID:            e.client.ID,
+		RedirectURI:         redirectURI,
+		ResponseType:        oauthService.ResponseTypeCode,
+		State:               "xyz",
+		CodeChallenge:       challenge(verifier),
+		CodeChallengeMethod: oauthService.CodeChallengeMethodS256,
+	}
+}
+
+// code approves the authorization and returns the code from the redirect.
+func (e *env) code() string {
+	e.t.Helper()
+
+	output, err := e.srv.ApproveAuthorization(context.Background(),
+		&oauthService.ApproveAuthorizationInput{
+			AuthorizeInput: e.authorizeInput(),
+			Approved:       true,
+		})
+	if err != nil {
+		e.t.Fatalf("approve: %v", err)
+	}
+
+	redirect, err := url.Parse(output.RedirectURL)
+	if err != nil {
+		e.t.Fatalf("parse redirect: %v", err)
+	}
+
+	if state := redirect.Query().Get("state"); state != "xyz" {
+		e.t.Errorf("state = %q, want xyz", state)
+	}
+
+	return redirect.Query().Get("code")
+}
+
+func (e *env) exchange(code, verifier string) (
+	*oauthService.TokenOutput, error) {
+	return e.srv.Token(context.Background(), &oauthService.TokenInput{
+		ClientCredentials: e.credentials(),
+		GrantType:         oauthService.GrantTypeAuthorizationCode,
+		Code:              code,
+		RedirectURI:       redirectURI,
+		CodeVerifier:      verifier,
+	})
+}
+
+func (e *env) tokens() *oauthService.TokenOutput {
+	e.t.Helper()
+
+	tokens, err := e.exchange(e.code(), verifier)
+	if err != nil {
+		e.t.Fatalf("exchange code: %v", err)
+	}
+
+	return tokens
+}
+
+func (e *env) refresh(refreshToken, scope string) (
+	*oauthService.TokenOutput, error) {
+	return e.srv.Token(context.Background(), &oauthService.TokenInput{
+		ClientCredentials: e.credentials(),
+		GrantType:         oauthService.GrantTypeRefreshToken,
+		RefreshToken:      refreshToken,
+		Scope:             scope,
+	})
+}
+
+func (e *env) introspect(token string) *oauthService.IntrospectOutput {
+	e.t.Helper()
+
+	output, err := e.srv.Introspect(context.Background(),
+		&oauthService.IntrospectInput{
+			ClientCredentials: e.credentials(),
+			Token:             token,
+		})
+	if err != nil {
+		e.t.Fatalf("introspect: %v", err)
+	}
+
+	return output
+}
+
+func TestAuthorizeChecksRequest(t *testing.T) {
+	e := newEnv(t, nil)
+
+	tests := []struct {
+		name   string
+		change func(input *oauthService.AuthorizeInput)
+		want   error
+	}{
+		{"valid", func(*oauthService.AuthorizeInput) {}, nil},
+		{"default redirect", func(input *oauthService.AuthorizeInput) {
+			input.RedirectURI = ""
+		}, nil},
+		{"unknown client", func(input *oauthService.AuthorizeInput) {
+			input.ClientID = uuid.New()
+		}, oauthService.ErrClientNotFound},
+		{"other redirect", func(input *oauthService.AuthorizeInput) {
+			input.RedirectURI = redirectURI + "/other"
+		}, oauthService.ErrInvalidRedirectURI},
+		{"token response", func(input *oauthService.AuthorizeInput) {
+			input.ResponseType = "token"
+		}, oauthService.ErrUnsupportedResponseType},
+		{"plain challenge", func(input *oauthService.AuthorizeInput) {
+			input.CodeChallengeMethod = "plain"
+			input.CodeChallenge = verifier
+		}, oauthService.ErrInvalidCodeChallenge},
+		{"short challenge", func(input *oauthService.AuthorizeInput) {
+			input.CodeChallenge = "short"
+		}, oauthService.ErrInvalidCodeChallenge},
+		{"unknown scope", func(input *oauthService.AuthorizeInput) {
+			input.Scope = "admin"
+		}, oauthService.ErrInvalidScope},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := e.authorizeInput()
+			tt.change(&input)
+
+			output, err := e.srv.GetAuthorization(
+				context.Background(), &input)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("authorize = %v, want %v", err, tt.want)
+			}
+
+			if err == nil && (output.ClientName != "Calendar" ||
+				output.RedirectURI != redirectURI) {
+				t.Errorf("authorization = %+v", output)
+			}
+		})
+	}
+}
+
+func TestAuthorizationDenied(t *testing.T) {
+	e := newEnv(t, nil)
+
+	output, err := e.srv.ApproveAuthorization(context.Background(),
+		&oauthService.ApproveAuthorizationInput{
+			AuthorizeInput: e.authorizeInput(),
+			Approved:       false,
+		})
+	if err != nil {
+		t.Fatalf("deny: %v", err)
+	}
+
+	redirect, err := url.Parse(output.RedirectURL)
+	if err != nil {
+		t.Fatalf("parse redirect: %v", err)
+	}
+
+	query := redirect.Query()
+	if query.Get("error") != "access_denied" || query.Get("state") != "xyz" ||
+		query.Has("code") {
+		t.Errorf("redirect = %s, want access_denied", output.RedirectURL)
+	}
+}
+
+func TestExchangeCode(t *testing.T) {
+	e := newEnv(t, nil)
+
+	tokens, err := e.exchange(e.code(), verifier)
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" ||
+		tokens.TokenType != oauthService.TokenTypeBearer {
+		t.Errorf("tokens = %+v", tokens)
+	}
+
+	if !slices.Equal(tokens.Scopes, []string{security.ScopeNotesRead,
+		security.ScopeNotesWrite}) {
+		t.Errorf("scopes = %v, want everything the client may ask", tokens)
+	}
+
+	access := e.introspect(tokens.AccessToken)
+	if !access.Active || access.TokenType != oauthService.TokenTypeBearer ||
+		*access.UserID != e.user.ID || *access.ClientID != e.client.ID {
+		t.Errorf("access token = %+v", access)
+	}
+
+	refresh := e.introspect(tokens.RefreshToken)
+	if !refresh.Active ||
+		refresh.TokenType != oauthService.GrantTypeRefreshToken {
+		t.Errorf("refresh token = %+v", refresh)
+	}
+}
+
+func TestExchangeCodeRejects(t *testing.T) {
+	e := newEnv(t, nil)
+	ctx := context.Background()
+
+	used := e.code()
+	_, err := e.exchange(used, verifier)
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		input func() *oauthService.TokenInput
+		want  error
+	}{
+		{"used code", func() *oauthService.TokenInput {
+			return &oauthService.TokenInput{
+				ClientCredentials: e.credentials(),
+				GrantType:         oauthService.GrantTypeAuthorizationCode,
+				Code:              used,
+				RedirectURI:       redirectURI,
+				CodeVerifier:      verifier,
+			}
+		}, oauthService.ErrInvalidGrant},
+		{"other verifier", func() *oauthService.TokenInput {
+			return &oauthService.TokenInput{
+				ClientCredentials: e.credentials(),
+				GrantType:         oauthService.GrantTypeAuthorizationCode,
+				Code:              e.code(),
+				RedirectURI:       redirectURI,
+				CodeVerifier:      verifier + "x",
+			}
+		}, oauthService.ErrInvalidGrant},
+		{"other redirect", func() *oauthService.TokenInput {
+			return &oauthService.TokenInput{
+				ClientCredentials: e.credentials(),
+				GrantType:         oauthService.GrantTypeAuthorizationCode,
+				Code:              e.code(),
+				RedirectURI:       redirectURI + "/other",
+				CodeVerifier:      verifier,
+			}
+		}, oauthService.ErrInvalidGrant},
+		{"wrong secret", func() *oauthService.TokenInput {
+			return &oauthService.TokenInput{
+				ClientCredentials: oauthService.ClientCredentials{
+					ClientID:     e.client.ID.String(),
+					ClientSecret: "wrong",
+				},
+				GrantType:    oauthService.GrantTypeAuthorizationCode,
+				Code:         e.code(),
+				RedirectURI:  redirectURI,
+				CodeVerifier: verifier,
+			}
+		}, oauthService.ErrInvalidClient},
+		{"no verifier", func() *oauthService.TokenInput {
+			return &oauthService.TokenInput{
+				ClientCredentials: e.credentials(),
+				GrantType:         oauthService.GrantTypeAuthorizationCode,
+				Code:              e.code(),
+				RedirectURI:       redirectURI,
+			}
+		}, oauthService.ErrInvalidRequest},
+		{"password grant", func() *oauthService.TokenInput {
+			return &oauthService.TokenInput{
+				ClientCredentials: e.credentials(),
+				GrantType:         "password",
+			}
+		}, oauthService.ErrUnsupportedGrantType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := e.srv.Token(ctx, tt.input())
+			if !errors.Is(err, tt.want) {
+				t.Errorf("token = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefreshRotates(t *testing.T) {
+	e := newEnv(t, nil)
+	tokens := e.tokens()
+
+	refreshed, err := e.refresh(tokens.RefreshToken, security.ScopeNotesRead)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	if refreshed.RefreshToken == tokens.RefreshToken {
+		t.Error("the refresh token wasn't rotated")
+	}
+
+	if !slices.Equal(refreshed.Scopes, []string{security.ScopeNotesRead}) {
+		t.Errorf("scopes = %v, want the narrower one", refreshed.Scopes)
+	}
+
+	// The grant keeps all its scopes for the next refresh.
+	again, err := e.refresh(refreshed.RefreshToken, "")
+	if err != nil {
+		t.Fatalf("refresh again: %v", err)
+	}
+
+	if len(again.Scopes) != 2 {
+		t.Errorf("scopes = %v, want the whole grant", again.Scopes)
+	}
+
+	_, err = e.refresh(again.RefreshToken, "admin")
+	if !errors.Is(err, oauthService.ErrInvalidScope) {
+		t.Errorf("refresh with a wider scope = %v, want %v",
+			err, oauthService.ErrInvalidScope)
+	}
+}
+
+func TestRefreshTokenReuseRevokesGrant(t *testing.T) {
+	e := newEnv(t, nil)
+	tokens := e.tokens()
+
+	refreshed, err := e.refresh(tokens.RefreshToken, "")
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	_, err = e.refresh(tokens.RefreshToken, "")
+	if !errors.Is(err, oauthService.ErrInvalidGrant) {
+		t.Fatalf("reuse = %v, want %v", err, oauthService.ErrInvalidGrant)
+	}
+
+	_, err = e.refresh(refreshed.RefreshToken, "")
+	if !errors.Is(err, oauthService.ErrInvalidGrant) {
+		t.Errorf("refresh after reuse = %v, want %v",
+			err, oauthService.ErrInvalidGrant)
+	}
+
+	if e.introspect(refreshed.AccessToken).Active {
+		t.Error("the access token of a revoked grant is active")
+	}
+
+	_, err = e.refresh("unknown", "")
+	if !errors.Is(err, oauthService.ErrInvalidGrant) {
+		t.Errorf("unknown token = %v, want %v",
+			err, oauthService.ErrInvalidGrant)
+	}
+}
+
+func TestRefreshKeepsGrantLifetime(t *testing.T) {
+	e := newEnv(t, map[string]string{
+		"OAUTH_REFRESH_TOKEN_TTL": "3600",
+		"OAUTH_GRANT_LIFETIME":    "600",
+	})
+	start := time.Now()
+	tokens := e.tokens()
+
+	limit := start.Add(600 * time.Second)
+	expiresAt := e.introspect(tokens.RefreshToken).ExpiresAt
+	if expiresAt == nil || expiresAt.After(limit.Add(time.Second)) {
+		t.Fatalf("grant expires at %v, want by %v", expiresAt, limit)
+	}
+
+	for range 3 {
+		var err error
+		tokens, err = e.refresh(tokens.RefreshToken, "")
+		if err != nil {
+			t.Fatalf("refresh: %v", err)
+		}
+
+		got := e.introspect(tokens.RefreshToken).ExpiresAt
+		if got == nil || !got.Equal(*expiresAt) {
+			t.Errorf("after a refresh the grant expires at %v, want %v",
+				got, expiresAt)
+		}
+	}
+}
+
+func TestInactiveUsers(t *testing.T) {
+	ctx := context.Background()
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name   string
+		status storage.UserStatus
+		block  *time.Time
+		active bool
+	}{
+		{"blocked", storage.UserStatusBlocked, &future, false},
+		{"block ran out", storage.UserStatusBlocked, &past, true},
+		{"deleted", storage.UserStatusDeleted, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newEnv(t, nil)
+			tokens := e.tokens()
+
+			if tt.block != nil {
+				err := e.st.Blocks().Create(ctx, &storage.Block{
+					UserID:         e.user.ID,
+					Reason:         "spam",
+					ExpiresAt:      tt.block,
+					CreatedAt:      time.Now(),
+					PreviousStatus: storage.UserStatusActive,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			user := *e.user
+			user.Status = tt.status
+			err := e.st.Users().Update(ctx, &user)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for name, token := range map[string]string{
+				"access":  tokens.AccessToken,
+				"refresh": tokens.RefreshToken,
+			} {
+				if got := e.introspect(token).Active; got != tt.active {
+					t.Errorf("%s token active = %v, want %v",
+						name, got, tt.active)
+				}
+			}
+
+			refreshed, err := e.refresh(tokens.RefreshToken, "")
+			if tt.active && err != nil {
+				t.Fatalf("refresh = %v, want new tokens", err)
+			}
+
+			if !tt.active && !errors.Is(err, oauthService.ErrInvalidGrant) {
+				t.Fatalf("refresh = %v, want %v",
+					err, oauthService.ErrInvalidGrant)
+			}
+
+			if tt.active {
+				return
+			}
+
+			// The refresh was refused, not taken for reuse, so the grant
+			// works again once the user does.
+			_, err = e.st.Blocks().Lift(ctx, e.user.ID, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = e.st.Users().Update(ctx, e.user)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			refreshed, err = e.refresh(tokens.RefreshToken, "")
+			if err != nil || refreshed.RefreshToken == "" {
+				t.Errorf("refresh after unblocking = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	clientID     = "cloud-notes"
+	clientSecret = "secret"
+	redirectURL  = "http://localhost:8000/oidc/callback"
+)
+
+// issuer is an OpenID provider with discovery, JWKS and a token endpoint
+// that checks PKCE. authorize stands in for the user approving the login.
+type issuer struct {
+	t   *testing.T
+	srv *httptest.Server
+	key *rsa.PrivateKey
+
+	mu     sync.Mutex
+	grants map[string]*grant
+}
+
+type grant struct {
+	challenge string
+	claims    jwt.MapClaims
+	key       *rsa.PrivateKey
+}
+
+func newIssuer(t *testing.T) *issuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	iss := &issuer{
+		t:      t,
+		key:    key,
+		grants: make(map[string]*grant),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", iss.discovery)
+	mux.HandleFunc("GET /jwks", iss.jwks)
+	mux.HandleFunc("POST /token", iss.token)
+	iss.srv = httptest.NewServer(mux)
+	t.Cleanup(iss.srv.Close)
+
+	return iss
+}
+
+func (iss *issuer) URL() string {
+	return iss.srv.URL
+}
+
+func (iss *issuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                iss.URL(),
+		"authorization_endpoint":                iss.URL() + "/authorize",
+		"token_endpoint":                        iss.URL() + "/token",
+		"jwks_uri":                              iss.URL() + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (iss *issuer) jwks(w http.ResponseWriter, r *http.Request) {
+	public := iss.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   encode(public.N.Bytes()),
+			"e":   encode(big.NewInt(int64(public.E)).Bytes()),
+		}},
+	})
+}
+
+func (iss *issuer) token(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		id, secret = r.FormValue("client_id"), r.FormValue("client_secret")
+	}
+	if id != clientID || secret != clientSecret {
+		writeJSON(w, http.StatusUnauthorized,
+			map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	iss.mu.Lock()
+	g := iss.grants[r.FormValue("code")]
+	delete(iss.grants, r.FormValue("code"))
+	iss.mu.Unlock()
+
+	if r.FormValue("grant_type") != "authorization_code" || g == nil ||
+		challenge(r.FormValue("code_verifier")) != g.challenge {
+		writeJSON(w, http.StatusBadRequest,
+			map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, g.claims)
+	token.Header["kid"] = "test"
+	idToken, err := token.SignedString(g.key)
+	if err != nil {
+		iss.t.Errorf("sign id token: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+// authorize checks the request the client sends the user with, approves
+// it and returns the code and the state of the redirect back. The ID token
+// carries the request's nonce and claims, and the issuer and the audience
+// the client expects; claims may override any of them.
+func (iss *issuer) authorize(authURL string,
+	claims jwt.MapClaims) (code, state string) {
+	iss.t.Helper()
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		iss.t.Fatalf("parse auth url: %v", err)
+	}
+
+	query := u.Query()
+	switch {
+	case u.String() != iss.URL()+"/authorize?"+u.RawQuery:
+		iss.t.Fatalf("auth url %s is not the issuer's", authURL)
+	case query.Get("response_type") != "code":
+		iss.t.Fatalf("response_type = %q", query.Get("response_type"))
+	case query.Get("client_id") != clientID:
+		iss.t.Fatalf("client_id = %q", query.Get("client_id"))
+	case query.Get("redirect_uri") != redirectURL:
+		iss.t.Fatalf("redirect_uri = %q", query.Get("redirect_uri"))
+	case query.Get("code_challenge_method") != "S256":
+		iss.t.Fatalf("code_challenge_method = %q",
+			query.Get("code_challenge_method"))
+	case query.Get("code_challenge") == "":
+		iss.t.Fatal("no code_challenge")
+	case query.Get("nonce") == "":
+		iss.t.Fatal("no nonce")
+	case query.Get("state") == "":
+		iss.t.Fatal("no state")
+	}
+
+	now := time.Now()
+	g := &grant{
+		challenge: query.Get("code_challenge"),
+		claims: jwt.MapClaims{
+			"iss":   iss.URL(),
+			"aud":   clientID,
+			"nonce": query.Get("nonce"),
+			"iat":   now.Unix(),
+			"exp":   now.Add(time.Hour).Unix(),
+		},
+		key: iss.key,
+	}
+	for name, value := range claims {
+		g.claims[name] = value
+	}
+
+	code = rand.Text()
+	iss.mu.Lock()
+	iss.grants[code] = g
+	iss.mu.Unlock()
+
+	return code, query.Get("state")
+}
+
+// signWith makes the issuer sign the code's ID token with another key.
+func (iss *issuer) signWith(code string, key *rsa.PrivateKey) {
+	iss.mu.Lock()
+	defer iss.mu.Unlock()
+
+	iss.grants[code].key = key
+}
+
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return encode(sum[:])
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,376 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/oidc"
+	authService "cloud-notes/internal/services/auth"
+	"cloud-notes/internal/storage"
+	"cloud-notes/internal/storage/memory"
+	"cloud-notes/test/testenv"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func newConfig(t *testing.T, iss *issuer) *config.Config {
+	t.Helper()
+
+	return testenv.Config(t, map[string]string{
+		"OIDC_PROVIDER":      "test",
+		"OIDC_ISSUER":        iss.URL(),
+		"OIDC_CLIENT_ID":     clientID,
+		"OIDC_CLIENT_SECRET": clientSecret,
+		"OIDC_REDIRECT_URL":  redirectURL,
+	})
+}
+
+func TestExchange(t *testing.T) {
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		claims   jwt.MapClaims
+		verifier string
+		key      *rsa.PrivateKey
+		err      error
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name:   "other nonce",
+			claims: jwt.MapClaims{"nonce": "other"},
+			err:    oidc.ErrInvalidNonce,
+		},
+		{
+			name:     "other verifier",
+			verifier: oidc.GenerateVerifier(),
+			err:      oidc.ErrInvalidCode,
+		},
+		{
+			name:   "other issuer",
+			claims: jwt.MapClaims{"iss": "https://evil.example.com"},
+			err:    oidc.ErrInvalidClaims,
+		},
+		{
+			name:   "other audience",
+			claims: jwt.MapClaims{"aud": "other-client"},
+			err:    oidc.ErrInvalidClaims,
+		},
+		{
+			name: "expired",
+			claims: jwt.MapClaims{
+				"exp": time.Now().Add(-time.Hour).Unix(),
+			},
+			err: oidc.ErrInvalidClaims,
+		},
+		{
+			name: "other key",
+			key:  other,
+			err:  oidc.ErrInvalidClaims,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			iss := newIssuer(t)
+			oc := oidc.New(&newConfig(t, iss).OIDC)
+
+			nonce, verifier := oidc.GenerateVerifier(), oidc.GenerateVerifier()
+			authURL, err := oc.AuthCodeURL(ctx, "state", nonce, verifier)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			claims := jwt.MapClaims{
+				"sub":            "subject",
+				"email":          "ivan@example.com",
+				"email_verified": true,
+				"given_name":     "Ivan",
+			}
+			for name, value := range tt.claims {
+				claims[name] = value
+			}
+
+			code, state := iss.authorize(authURL, claims)
+			if state != "state" {
+				t.Fatalf("state = %q, want %q", state, "state")
+			}
+			if tt.key != nil {
+				iss.signWith(code, tt.key)
+			}
+			if tt.verifier != "" {
+				verifier = tt.verifier
+			}
+
+			identity, err := oc.Exchange(ctx, code, nonce, verifier)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("exchange = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+
+			want := oidc.Identity{
+				Subject:       "subject",
+				Email:         "ivan@example.com",
+				EmailVerified: true,
+				Name:          "Ivan",
+			}
+			if *identity != want {
+				t.Fatalf("identity = %+v, want %+v", *identity, want)
+			}
+		})
+	}
+}
+
+// login goes through the whole OIDC login as the user with the claims.
+func login(t *testing.T, srv authService.Service, iss *issuer,
+	claims jwt.MapClaims) (*authService.LoginOutput, error) {
+	t.Helper()
+	ctx := context.Background()
+
+	begin, err := srv.BeginOIDCLogin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, state := iss.authorize(begin.URL, claims)
+	if state != begin.State.String() {
+		t.Fatalf("state = %q, want %q", state, begin.State)
+	}
+
+	return srv.FinishOIDCLogin(ctx, &authService.FinishOIDCLoginInput{
+		State: begin.State,
+		Code:  code,
+	})
+}
+
+func identity(t *testing.T, st storage.Storage,
+	subject string) *storage.Identity {
+	t.Helper()
+
+	linked, err := st.Identities().GetBySubject(
+		context.Background(), "test", subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return linked
+}
+
+func TestLoginCreatesUser(t *testing.T) {
+	ctx := context.Background()
+	iss := newIssuer(t)
+	st := memory.New()
+	srv := testenv.Auth(newConfig(t, iss), st)
+
+	claims := jwt.MapClaims{
+		"sub":            "subject",
+		"email":          "Ivan@Example.com",
+		"email_verified": true,
+		"name":           "Ivan Petrov",
+	}
+	output, err := login(t, srv, iss, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.AccessToken == "" {
+		t.Fatal("no access token")
+	}
+
+	user, err := st.Users().GetByLogin(ctx, "ivan@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user == nil || user.Status != storage.UserStatusActive ||
+		user.FirstName != "Ivan Petrov" {
+		t.Fatalf("user = %+v", user)
+	}
+
+	linked := identity(t, st, "subject")
+	if linked == nil || linked.UserID != user.ID {
+		t.Fatalf("identity = %+v, want user %s", linked, user.ID)
+	}
+
+	// The subject keeps pointing to the user when the email changes.
+	claims["email"] = "petrov@example.com"
+	_, err = login(t, srv, iss, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := st.Users().GetByLogin(ctx, "petrov@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other != nil {
+		t.Fatalf("created %+v for a linked subject", other)
+	}
+}
+
+func TestLoginLinksVerifiedEmail(t *testing.T) {
+	ctx := context.Background()
+	iss := newIssuer(t)
+	st := memory.New()
+	srv := testenv.Auth(newConfig(t, iss), st)
+	user := testenv.NewUser(t, st,
+		"ivan@example.com", "hash", storage.UserStatusActive)
+
+	_, err := login(t, srv, iss, jwt.MapClaims{
+		"sub":            "unverified",
+		"email":          "ivan@example.com",
+		"email_verified": false,
+	})
+	if !errors.Is(err, authService.ErrOIDCEmailNotVerified) {
+		t.Fatalf("login = %v, want %v",
+			err, authService.ErrOIDCEmailNotVerified)
+	}
+	if linked := identity(t, st, "unverified"); linked != nil {
+		t.Fatalf("linked an unverified email: %+v", linked)
+	}
+
+	_, err = login(t, srv, iss, jwt.MapClaims{
+		"sub":            "verified",
+		"email":          "ivan@example.com",
+		"email_verified": true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	linked := identity(t, st, "verified")
+	if linked == nil || linked.UserID != user.ID {
+		t.Fatalf("identity = %+v, want user %s", linked, user.ID)
+	}
+
+	got, err := st.Users().GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.PasswordHash != "hash" {
+		t.Fatal("linking changed the password of an active user")
+	}
+}
+
+func TestLoginClaimsPendingUser(t *testing.T) {
+	ctx := context.Background()
+	iss := newIssuer(t)
+	st := memory.New()
+	srv := testenv.Auth(newConfig(t, iss), st)
+	user := testenv.NewUser(t, st,
+		"ivan@example.com", "hash", storage.UserStatusPending)
+
+	_, err := login(t, srv, iss, jwt.MapClaims{
+		"sub":            "subject",
+		"email":          "ivan@example.com",
+		"email_verified": true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := st.Users().GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != storage.UserStatusActive {
+		t.Fatalf("status = %s, want %s", got.Status, storage.UserStatusActive)
+	}
+	if got.PasswordHash == "hash" {
+		t.Fatal("the registrant's password survived the claim")
+	}
+}
+
+func TestLoginChecksState(t *testing.T) {
+	ctx := context.Background()
+	iss := newIssuer(t)
+	st := memory.New()
+	srv := testenv.Auth(newConfig(t, iss), st)
+
+	begin, err := srv.BeginOIDCLogin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, _ := iss.authorize(begin.URL, jwt.MapClaims{
+		"sub":            "subject",
+		"email":          "ivan@example.com",
+		"email_verified": true,
+	})
+
+	_, err = srv.FinishOIDCLogin(ctx, &authService.FinishOIDCLoginInput{
+		State: uuid.New(),
+		Code:  code,
+	})
+	if !errors.Is(err, authService.ErrInvalidCeremony) {
+		t.Fatalf("unknown state = %v, want %v",
+			err, authService.ErrInvalidCeremony)
+	}
+
+	input := &authService.FinishOIDCLoginInput{State: begin.State, Code: code}
+	_, err = srv.FinishOIDCLogin(ctx, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = srv.FinishOIDCLogin(ctx, input)
+	if !errors.Is(err, authService.ErrInvalidCeremony) {
+		t.Fatalf("reused state = %v, want %v",
+			err, authService.ErrInvalidCeremony)
+	}
+}
+
+func TestLoginRejectsForeignTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+	}{
+		{"other nonce", jwt.MapClaims{"nonce": "other"}},
+		{"other issuer", jwt.MapClaims{"iss": "https://evil.example.com"}},
+		{"other audience", jwt.MapClaims{"aud": "other-client"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			iss := newIssuer(t)
+			st := memory.New()
+			srv := testenv.Auth(newConfig(t, iss), st)
+
+			claims := jwt.MapClaims{
+				"sub":            "subject",
+				"email":          "ivan@example.com",
+				"email_verified": true,
+			}
+			for name, value := range tt.claims {
+				claims[name] = value
+			}
+
+			_, err := login(t, srv, iss, claims)
+			if !errors.Is(err, authService.ErrInvalidOIDCCode) {
+				t.Fatalf("login = %v, want %v",
+					err, authService.ErrInvalidOIDCCode)
+			}
+
+			user, err := st.Users().GetByLogin(ctx, "ivan@example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user != nil || identity(t, st, "subject") != nil {
+				t.Fatal("a rejected token created an account")
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/password"
+)
+
+// cheap keeps the hashing fast, the parameters are still valid ones.
+func cheap(algorithm string) *config.PasswordHash {
+	return &config.PasswordHash{
+		Algorithm:         algorithm,
+		Argon2Memory:      1024,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+		Argon2KeyLength:   32,
+		BcryptCost:        4,
+	}
+}
+
+func newHasher(t *testing.T, cfg *config.PasswordHash) password.Hasher {
+	t.Helper()
+
+	h, err := password.New(cfg)
+	if err != nil {
+		t.Fatalf("new hasher: %v", err)
+	}
+
+	return h
+}
+
+func TestHasherRoundTrip(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		prefix    string
+	}{
+		{password.AlgorithmArgon2id, "$argon2id$v=19$m=1024,t=1,p=1$"},
+		{password.AlgorithmBcrypt, "$2a$04$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			h := newHasher(t, cheap(tt.algorithm))
+
+			hash, err := h.Hash("пароль-secret")
+			if err != nil {
+				t.Fatalf("hash: %v", err)
+			}
+
+			if !strings.HasPrefix(hash, tt.prefix) {
+				t.Errorf("hash = %q, want prefix %q", hash, tt.prefix)
+			}
+
+			err = h.Compare(hash, "пароль-secret")
+			if err != nil {
+				t.Errorf("compare the password: %v", err)
+			}
+
+			err = h.Compare(hash, "пароль-Secret")
+			if !errors.Is(err, password.ErrMismatchedPassword) {
+				t.Errorf("compare another password = %v, want %v",
+					err, password.ErrMismatchedPassword)
+			}
+
+			if h.NeedsRehash(hash) {
+				t.Error("a fresh hash needs a rehash")
+			}
+
+			other, err := h.Hash("пароль-secret")
+			if err != nil {
+				t.Fatalf("hash: %v", err)
+			}
+
+			if other == hash {
+				t.Error("two hashes of a password are equal, salt is missing")
+			}
+		})
+	}
+}
+
+func TestHasherComparesOtherAlgorithms(t *testing.T) {
+	argon := newHasher(t, cheap(password.AlgorithmArgon2id))
+	bcrypt := newHasher(t, cheap(password.AlgorithmBcrypt))
+
+	for name, pair := range map[string][2]password.Hasher{
+		"bcrypt hash, argon2id hasher": {bcrypt, argon},
+		"argon2id hash, bcrypt hasher": {argon, bcrypt},
+	} {
+		t.Run(name, func(t *testing.T) {
+			made, checker := pair[0], pair[1]
+
+			hash, err := made.Hash("secret")
+			if err != nil {
+				t.Fatalf("hash: %v", err)
+			}
+
+			err = checker.Compare(hash, "secret")
+			if err != nil {
+				t.Errorf("compare: %v", err)
+			}
+
+			err = checker.Compare(hash, "other")
+			if !errors.Is(err, password.ErrMismatchedPassword) {
+				t.Errorf("compare another password = %v", err)
+			}
+
+			if !checker.NeedsRehash(hash) {
+				t.Error("a hash of another algorithm doesn't need a rehash")
+			}
+		})
+	}
+}
+
+func TestHasherNeedsRehash(t *testing.T) {
+	base := cheap(password.AlgorithmArgon2id)
+	old := newHasher(t, base)
+	hash, err := old.Hash("secret")
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(cfg *config.PasswordHash)
+		want   bool
+	}{
+		{"same", func(*config.PasswordHash) {}, false},
+		{"memory", func(cfg *config.PasswordHash) {
+			cfg.Argon2Memory = 2048
+		}, true},
+		{"iterations", func(cfg *config.PasswordHash) {
+			cfg.Argon2Iterations = 2
+		}, true},
+		{"parallelism", func(cfg *config.PasswordHash) {
+			cfg.Argon2Parallelism = 2
+		}, true},
+		{"key length", func(cfg *config.PasswordHash) {
+			cfg.Argon2KeyLength = 64
+		}, true},
+		{"bcrypt cost", func(cfg *config.PasswordHash) {
+			cfg.BcryptCost = 5
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := *base
+			tt.change(&cfg)
+			h := newHasher(t, &cfg)
+
+			if got := h.NeedsRehash(hash); got != tt.want {
+				t.Errorf("needs rehash = %v, want %v", got, tt.want)
+			}
+
+			err := h.Compare(hash, "secret")
+			if err != nil {
+				t.Errorf("compare with other parameters: %v", err)
+			}
+		})
+	}
+
+	if !old.NeedsRehash("$argon2id$v=19$m=1024,t=1,p=1$broken") {
+		t.Error("a broken hash doesn't need a rehash")
+	}
+
+	err = old.Compare("$5$rounds=1000$salt$hash", "secret")
+	if !errors.Is(err, password.ErrUnknownHash) {
+		t.Errorf("compare an unknown hash = %v, want %v",
+			err, password.ErrUnknownHash)
+	}
+}
+
+func TestNewChecksConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(cfg *config.PasswordHash)
+		want   string
+	}{
+		{"valid", func(*config.PasswordHash) {}, ""},
+		{"parallelism zero", func(cfg *config.PasswordHash) {
+			cfg.Argon2Parallelism = 0
+		}, "PARALLELISM"},
+		{"parallelism over 255", func(cfg *config.PasswordHash) {
+			cfg.Argon2Parallelism = 256
+			cfg.Argon2Memory = 8 * 256
+		}, "PARALLELISM"},
+		{"iterations zero", func(cfg *config.PasswordHash) {
+			cfg.Argon2Iterations = 0
+		}, "ITERATIONS"},
+		{"memory zero", func(cfg *config.PasswordHash) {
+			cfg.Argon2Memory = 0
+		}, "MEMORY"},
+		{"memory per thread", func(cfg *config.PasswordHash) {
+			cfg.Argon2Parallelism = 4
+			cfg.Argon2Memory = 16
+		}, "MEMORY"},
+		{"key length zero", func(cfg *config.PasswordHash) {
+			cfg.Argon2KeyLength = 0
+		}, "KEY_LENGTH"},
+		{"bcrypt cost", func(cfg *config.PasswordHash) {
+			cfg.BcryptCost = 3
+		}, "BCRYPT_COST"},
+		{"algorithm", func(cfg *config.PasswordHash) {
+			cfg.Algorithm = "md5"
+		}, "md5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := cheap(password.AlgorithmArgon2id)
+			tt.change(cfg)
+
+			_, err := password.New(cfg)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("new = %v, want no error", err)
+			case tt.want != "" && err == nil:
+				t.Errorf("new = nil, want an error about %s", tt.want)
+			case tt.want != "" && !strings.Contains(err.Error(), tt.want):
+				t.Errorf("new = %v, want an error about %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyCapsLengthForBcrypt(t *testing.T) {
+	long := strings.Repeat("a", 73)
+	cyrillic := strings.Repeat("ж", 40)
+
+	tests := []struct {
+		name      string
+		algorithm string
+		maxLength int
+		password  string
+		want      bool
+	}{
+		{"argon2id long", password.AlgorithmArgon2id, 128, long, false},
+		{"bcrypt long", password.AlgorithmBcrypt, 128, long, true},
+		{"bcrypt unlimited", password.AlgorithmBcrypt, 0, long, true},
+		{"bcrypt 72", password.AlgorithmBcrypt, 128, long[:72], false},
+		{"bcrypt multibyte", password.AlgorithmBcrypt, 128, cyrillic, true},
+		{"argon2id multibyte", password.AlgorithmArgon2id, 128, cyrillic,
+			false},
+		{"bcrypt lower limit", password.AlgorithmBcrypt, 20, long[:21],
+			true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := password.NewPolicy(&config.PasswordPolicy{
+				MinLength: 8,
+				MaxLength: tt.maxLength,
+			}, cheap(tt.algorithm))
+
+			violations, err := p.Check(tt.password)
+			if err != nil {
+				t.Fatalf("check: %v", err)
+			}
+
+			got := false
+			for _, v := range violations {
+				got = got || v.Rule == password.RuleMaxLength
+			}
+
+			if got != tt.want {
+				t.Errorf("too long = %v, want %v (%v)",
+					got, tt.want, violations)
+			}
+		})
+	}
+}
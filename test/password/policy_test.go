@@ -0,0 +1,188 @@
+package password
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/password"
+)
+
+// breachedDir holds k-anonymity ranges for a few known passwords:
+//
+//	password                     5BAA6, seen 9659365 times
+//	Tr0ub4dor&3                  87457, lower case suffix, CRLF lines
+//	rarely-leaked-1              174BF, seen once
+//	broken-range                 F565A, the count isn't a number
+//	correct-horse-battery-staple DD606, no range file
+const breachedDir = "testdata/breached"
+
+// rules returns the rules the password fails, in the order they are
+// checked.
+func rules(t *testing.T, p password.Policy,
+	plain string, personal ...string) []string {
+	t.Helper()
+
+	violations, err := p.Check(plain, personal...)
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+
+	got := make([]string, 0, len(violations))
+	for _, v := range violations {
+		if v.Message == "" {
+			t.Errorf("rule %s has no message", v.Rule)
+		}
+		got = append(got, v.Rule)
+	}
+
+	return got
+}
+
+func TestPolicyRules(t *testing.T) {
+	strict := &config.PasswordPolicy{
+		MinLength:     10,
+		MaxLength:     20,
+		RequireLower:  true,
+		RequireUpper:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{"everything", "Lorem-ipsum-42", nil},
+		{"min length", "Lor-em-42", []string{password.RuleMinLength}},
+		{"exactly min length", "Lore-ips42", nil},
+		{"exactly max length", "Lorem-ipsum-dolor-42", nil},
+		{"max length", "Lorem-ipsum-dolor-421",
+			[]string{password.RuleMaxLength}},
+		{"length in runes", "Пароль-42-Ок", nil},
+		{"no lower", "LOREM-IPSUM-42", []string{password.RuleLower}},
+		{"no upper", "lorem-ipsum-42", []string{password.RuleUpper}},
+		{"no digit", "Lorem-ipsum-xx", []string{password.RuleDigit}},
+		{"no symbol", "LoremIpsum42", []string{password.RuleSymbol}},
+		{"space is a symbol", "Lorem ipsum 42", nil},
+		{"cyrillic classes", "пАроль-сорок-2", nil},
+		{"all at once", "abc", []string{password.RuleMinLength,
+			password.RuleUpper, password.RuleDigit, password.RuleSymbol}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := password.NewPolicy(strict, cheap(password.AlgorithmArgon2id))
+
+			got := rules(t, p, tt.password)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("violations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyNoMaxLength(t *testing.T) {
+	p := password.NewPolicy(&config.PasswordPolicy{MinLength: 8},
+		cheap(password.AlgorithmArgon2id))
+
+	if got := rules(t, p, strings.Repeat("a", 1000)); len(got) != 0 {
+		t.Errorf("violations = %v, want none without MAX_LENGTH", got)
+	}
+}
+
+func TestPolicyPersonalInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		personal []string
+		want     bool
+	}{
+		{"login", "my-ivan_petrov-pass", []string{"ivan_petrov", "Ivan"},
+			true},
+		{"login in other case", "IVAN_PETROV-2024", []string{"ivan_petrov"},
+			true},
+		{"first name", "secret-ivan-2024", []string{"login_x", "Ivan"},
+			true},
+		{"email local part", "petrov.i-secret", []string{"petrov.i@mail.ru"},
+			true},
+		{"whole email", "x-petrov.i@mail.ru", []string{"petrov.i@mail.ru"},
+			true},
+		{"email domain only", "mail.ru-secret", []string{"petrov.i@mail.ru"},
+			false},
+		{"short name", "li-jones-secret", []string{"Li"}, false},
+		{"padded name", "secret-olga-2024", []string{"  Olga "}, true},
+		{"cyrillic name", "ИВАН-пароль-1", []string{"иван"}, true},
+		{"unrelated", "correct-horse", []string{"ivan_petrov", "Ivan"},
+			false},
+		{"nothing personal", "correct-horse", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := password.NewPolicy(&config.PasswordPolicy{
+				DisallowPersonal: true,
+			}, cheap(password.AlgorithmArgon2id))
+
+			got := slices.Contains(
+				rules(t, p, tt.password, tt.personal...),
+				password.RulePersonal)
+			if got != tt.want {
+				t.Errorf("personal = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	p := password.NewPolicy(&config.PasswordPolicy{
+		DisallowPersonal: false,
+	}, cheap(password.AlgorithmArgon2id))
+	if got := rules(t, p, "ivan_petrov", "ivan_petrov"); len(got) != 0 {
+		t.Errorf("violations = %v with DISALLOW_PERSONAL off", got)
+	}
+}
+
+func TestPolicyBreached(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		minCount int
+		want     bool
+	}{
+		{"common", "password", 1, true},
+		{"common over a high bar", "password", 1000, true},
+		{"not in the ranges", "password1", 1, false},
+		{"lower case range", "Tr0ub4dor&3", 1, true},
+		{"seen once", "rarely-leaked-1", 1, true},
+		{"seen once, bar of two", "rarely-leaked-1", 2, false},
+		{"no range file", "correct-horse-battery-staple", 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := password.NewPolicy(&config.PasswordPolicy{
+				BreachedDir:      breachedDir,
+				BreachedMinCount: tt.minCount,
+			}, cheap(password.AlgorithmArgon2id))
+
+			got := slices.Contains(rules(t, p, tt.password),
+				password.RuleBreached)
+			if got != tt.want {
+				t.Errorf("breached = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	p := password.NewPolicy(&config.PasswordPolicy{
+		BreachedDir:      breachedDir,
+		BreachedMinCount: 1,
+	}, cheap(password.AlgorithmArgon2id))
+	_, err := p.Check("broken-range")
+	if err == nil {
+		t.Error("a broken range file was taken as no match")
+	}
+
+	p = password.NewPolicy(&config.PasswordPolicy{},
+		cheap(password.AlgorithmArgon2id))
+	if got := rules(t, p, "password"); len(got) != 0 {
+		t.Errorf("violations = %v without BREACHED_DIR", got)
+	}
+}
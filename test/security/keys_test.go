@@ -0,0 +1,426 @@
+package security
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/security"
+	"cloud-notes/internal/storage/memory"
+	"cloud-notes/test/testenv"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// keyPair is a signing key written to files the way it is deployed.
+type keyPair struct {
+	private    crypto.Signer
+	privateKey string
+	publicKey  string
+}
+
+func writePEM(t *testing.T, kind string, der []byte) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "key.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der})
+	err := os.WriteFile(file, data, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+func newKeyPair(t *testing.T, private crypto.Signer) *keyPair {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	public, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &keyPair{
+		private:    private,
+		privateKey: writePEM(t, "PRIVATE KEY", der),
+		publicKey:  writePEM(t, "PUBLIC KEY", public),
+	}
+}
+
+func newEd25519(t *testing.T) *keyPair {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return newKeyPair(t, private)
+}
+
+func newRSA(t *testing.T) *keyPair {
+	t.Helper()
+
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return newKeyPair(t, private)
+}
+
+func newSecurity(t *testing.T, cfg *config.JWT) security.Security {
+	t.Helper()
+
+	sec, err := security.New(testenv.Logger(), memory.New(), cfg)
+	if err != nil {
+		t.Fatalf("new security: %v", err)
+	}
+
+	return sec
+}
+
+func accessToken(sec security.Security) string {
+	return sec.GenerateAccessToken(context.Background(), &security.Claims{
+		UserID:    uuid.New(),
+		SessionID: uuid.New(),
+		CreatedAt: time.Now(),
+	})
+}
+
+func valid(sec security.Security, token string) bool {
+	_, err := sec.ParseAccessToken(context.Background(), token)
+	return err == nil
+}
+
+// header returns the kid and the alg of the token.
+func header(t *testing.T, token string) (string, string) {
+	t.Helper()
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+
+	kid, _ := parsed.Header["kid"].(string)
+	return kid, parsed.Method.Alg()
+}
+
+func decode(t *testing.T, value string) []byte {
+	t.Helper()
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		t.Fatalf("decode %q: %v", value, err)
+	}
+
+	return data
+}
+
+// publicKey rebuilds the key from the JWKS document, as a service that
+// verifies our tokens does.
+func publicKey(t *testing.T, jwk *security.JWK) any {
+	t.Helper()
+
+	switch jwk.Kty {
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			t.Fatalf("curve %s", jwk.Crv)
+		}
+		return ed25519.PublicKey(decode(t, jwk.X))
+	case "RSA":
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(decode(t, jwk.N)),
+			E: int(new(big.Int).SetBytes(decode(t, jwk.E)).Int64()),
+		}
+	default:
+		t.Fatalf("key type %s", jwk.Kty)
+		return nil
+	}
+}
+
+func kids(jwks []*security.JWK) []string {
+	ids := make([]string, 0, len(jwks))
+	for _, jwk := range jwks {
+		ids = append(ids, jwk.Kid)
+	}
+
+	slices.Sort(ids)
+	return ids
+}
+
+func TestSigningKeys(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		key       *keyPair
+	}{
+		{"EdDSA", newEd25519(t)},
+		{"RS256", newRSA(t)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			sec := newSecurity(t, &config.JWT{
+				Algorithm:      tt.algorithm,
+				PrivateKeyFile: tt.key.privateKey,
+			})
+
+			jwks := sec.JWKS(context.Background())
+			if len(jwks) != 1 {
+				t.Fatalf("jwks = %d keys, want the signing key", len(jwks))
+			}
+
+			jwk := jwks[0]
+			if jwk.Alg != tt.algorithm || jwk.Use != "sig" || jwk.Kid == "" {
+				t.Errorf("jwk = %+v", jwk)
+			}
+
+			token := accessToken(sec)
+			kid, alg := header(t, token)
+			if kid != jwk.Kid || alg != tt.algorithm {
+				t.Errorf("header kid %q, alg %s, want %q, %s",
+					kid, alg, jwk.Kid, tt.algorithm)
+			}
+
+			if !valid(sec, token) {
+				t.Error("own token rejected")
+			}
+
+			// Another service verifies the token with the published key
+			// alone.
+			_, err := jwt.Parse(token, func(*jwt.Token) (any, error) {
+				return publicKey(t, jwk), nil
+			}, jwt.WithValidMethods([]string{tt.algorithm}))
+			if err != nil {
+				t.Errorf("verify with the jwk: %v", err)
+			}
+
+			// The kid is the key's thumbprint, every replica agrees on it.
+			again := newSecurity(t, &config.JWT{
+				Algorithm:      tt.algorithm,
+				PrivateKeyFile: tt.key.privateKey,
+			})
+			if got := kids(again.JWKS(context.Background())); !slices.Equal(
+				got, []string{jwk.Kid}) {
+				t.Errorf("kid on another replica %v, want %s", got, jwk.Kid)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	old, current := newEd25519(t), newRSA(t)
+
+	before := newSecurity(t, &config.JWT{
+		Algorithm:      "EdDSA",
+		PrivateKeyFile: old.privateKey,
+	})
+	issued := accessToken(before)
+
+	// The new key signs, the old one is still accepted.
+	after := newSecurity(t, &config.JWT{
+		Algorithm:      "RS256",
+		PrivateKeyFile: current.privateKey,
+		PublicKeyFiles: []string{old.publicKey, ""},
+	})
+
+	if !valid(after, issued) {
+		t.Error("token of the previous key rejected during the rotation")
+	}
+
+	token := accessToken(after)
+	if _, alg := header(t, token); alg != "RS256" {
+		t.Errorf("signed with %s, want the new key", alg)
+	}
+
+	if valid(before, token) {
+		t.Error("the old replica accepted a key it doesn't know")
+	}
+
+	want := kids(append(before.JWKS(context.Background()),
+		newSecurity(t, &config.JWT{
+			Algorithm:      "RS256",
+			PrivateKeyFile: current.privateKey,
+		}).JWKS(context.Background())...))
+	if got := kids(after.JWKS(context.Background())); !slices.Equal(
+		got, want) {
+		t.Errorf("jwks %v, want both keys %v", got, want)
+	}
+
+	// Once the old key is dropped, its tokens stop working.
+	done := newSecurity(t, &config.JWT{
+		Algorithm:      "RS256",
+		PrivateKeyFile: current.privateKey,
+	})
+	if valid(done, issued) {
+		t.Error("token of a dropped key accepted")
+	}
+
+	if !valid(done, token) {
+		t.Error("token of the current key rejected")
+	}
+}
+
+func TestForgedTokens(t *testing.T) {
+	key, other := newEd25519(t), newEd25519(t)
+	sec := newSecurity(t, &config.JWT{
+		Algorithm:      "EdDSA",
+		PrivateKeyFile: key.privateKey,
+		LegacyHS256:    false,
+		Secret:         "shared-secret",
+	})
+	kid, _ := header(t, accessToken(sec))
+
+	claims := jwt.MapClaims{
+		"user_id":    uuid.NewString(),
+		"session_id": uuid.NewString(),
+		"created_at": time.Now().Format(time.RFC3339),
+	}
+	sign := func(method jwt.SigningMethod, kid string, key any) string {
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return signed
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(key.private.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM, err := os.ReadFile(key.publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !valid(sec, sign(jwt.SigningMethodEdDSA, kid, key.private)) {
+		t.Fatal("a token of the key rejected")
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"no signature", sign(jwt.SigningMethodNone, kid,
+			jwt.UnsafeAllowNoneSignatureType)},
+		{"other key under the kid", sign(jwt.SigningMethodEdDSA, kid,
+			other.private)},
+		{"unknown kid", sign(jwt.SigningMethodEdDSA, "unknown",
+			other.private)},
+		{"no kid", sign(jwt.SigningMethodEdDSA, "", key.private)},
+		{"public key as hmac secret", sign(jwt.SigningMethodHS256, kid,
+			publicDER)},
+		{"public pem as hmac secret", sign(jwt.SigningMethodHS256, kid,
+			publicPEM)},
+		{"secret without legacy hs256", sign(jwt.SigningMethodHS256, "",
+			[]byte("shared-secret"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if valid(sec, tt.token) {
+				t.Error("forged token accepted")
+			}
+		})
+	}
+}
+
+func TestLegacyHS256(t *testing.T) {
+	key := newEd25519(t)
+	legacy := newSecurity(t, &config.JWT{
+		Algorithm:   "HS256",
+		LegacyHS256: true,
+		Secret:      "shared-secret",
+	})
+	issued := accessToken(legacy)
+
+	if kid, alg := header(t, issued); kid != "" || alg != "HS256" {
+		t.Errorf("legacy token kid %q, alg %s", kid, alg)
+	}
+
+	if len(legacy.JWKS(context.Background())) != 0 {
+		t.Error("the shared secret is published")
+	}
+
+	// Moving to a key pair keeps the tokens of the secret valid while
+	// JWT_LEGACY_HS256 is on.
+	moving := newSecurity(t, &config.JWT{
+		Algorithm:      "EdDSA",
+		PrivateKeyFile: key.privateKey,
+		LegacyHS256:    true,
+		Secret:         "shared-secret",
+	})
+	if !valid(moving, issued) {
+		t.Error("legacy token rejected while HS256 is on")
+	}
+
+	if len(moving.JWKS(context.Background())) != 1 {
+		t.Error("the jwks lists more than the key pair")
+	}
+
+	moved := newSecurity(t, &config.JWT{
+		Algorithm:      "EdDSA",
+		PrivateKeyFile: key.privateKey,
+		Secret:         "shared-secret",
+	})
+	if valid(moved, issued) {
+		t.Error("legacy token accepted with HS256 off")
+	}
+}
+
+func TestKeyConfigErrors(t *testing.T) {
+	ed, rs := newEd25519(t), newRSA(t)
+
+	tests := []struct {
+		name string
+		cfg  *config.JWT
+	}{
+		{"rsa key for EdDSA", &config.JWT{
+			Algorithm: "EdDSA", PrivateKeyFile: rs.privateKey}},
+		{"ed25519 key for RS256", &config.JWT{
+			Algorithm: "RS256", PrivateKeyFile: ed.privateKey}},
+		{"public key to sign", &config.JWT{
+			Algorithm: "EdDSA", PrivateKeyFile: ed.publicKey}},
+		{"missing key", &config.JWT{
+			Algorithm: "EdDSA", PrivateKeyFile: "missing.pem"}},
+		{"unsupported algorithm", &config.JWT{
+			Algorithm: "ES256", PrivateKeyFile: ed.privateKey}},
+		{"HS256 without legacy", &config.JWT{
+			Algorithm: "HS256", Secret: "shared-secret"}},
+		{"legacy without secret", &config.JWT{
+			Algorithm: "EdDSA", PrivateKeyFile: ed.privateKey,
+			LegacyHS256: true}},
+		{"private key to verify", &config.JWT{
+			Algorithm: "EdDSA", PrivateKeyFile: ed.privateKey,
+			PublicKeyFiles: []string{rs.privateKey}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := security.New(testenv.Logger(), memory.New(), tt.cfg)
+			if err == nil {
+				t.Error("loaded")
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// past is long before any job the application enqueues, so the tests
+// claim only their own jobs from a shared database.
+var past = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newJob creates a pending job due at runAt that is deleted after the
+// test.
+func newJob(t *testing.T, st storage.Storage, runAt time.Time,
+	uniqueKey *string) *storage.Job {
+	t.Helper()
+	ctx := context.Background()
+
+	job := &storage.Job{
+		ID:        uuid.New(),
+		Type:      "test.job",
+		Payload:   []byte(`{}`),
+		Status:    storage.JobStatusPending,
+		RunAt:     runAt,
+		UniqueKey: uniqueKey,
+		CreatedAt: now(),
+	}
+
+	err := st.Jobs().Create(ctx, job)
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+
+	t.Cleanup(func() {
+		got, err := st.Jobs().GetByID(ctx, job.ID)
+		if err != nil || got == nil {
+			return
+		}
+
+		lease := got.RunAt
+		got.Status = storage.JobStatusSucceeded
+		got.FinishedAt = &past
+		_, err = st.Jobs().Update(ctx, got, lease)
+		if err == nil {
+			err = st.Jobs().DeleteSucceeded(ctx, past.Add(time.Hour))
+		}
+		if err != nil {
+			t.Errorf("delete job: %v", err)
+		}
+	})
+
+	return job
+}
+
+func sortIDs(ids []uuid.UUID) []uuid.UUID {
+	slices.SortFunc(ids, func(a, b uuid.UUID) int {
+		return bytes.Compare(a[:], b[:])
+	})
+
+	return ids
+}
+
+// claimedIDs returns the sorted IDs of the claimed jobs, Postgres returns
+// them in no particular order.
+func claimedIDs(jobs []*storage.Job) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(jobs))
+	for _, job := range jobs {
+		ids = append(ids, job.ID)
+	}
+
+	return sortIDs(ids)
+}
+
+func normalizeJob(job *storage.Job) {
+	job.RunAt = job.RunAt.UTC()
+	job.FinishedAt = utc(job.FinishedAt)
+	job.CreatedAt = job.CreatedAt.UTC()
+}
+
+func TestJobsClaim(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		first := newJob(t, st, past, nil)
+		second := newJob(t, st, past.Add(time.Minute), nil)
+		third := newJob(t, st, past.Add(2*time.Minute), nil)
+		later := newJob(t, st, past.Add(time.Hour), nil)
+
+		// The due jobs are claimed oldest first, up to the limit.
+		now := past.Add(30 * time.Minute)
+		lease := now.Add(time.Minute)
+		claimed, err := st.Jobs().Claim(ctx, now, lease, 2)
+		if err != nil {
+			t.Fatalf("claim: %v", err)
+		}
+
+		want := sortIDs([]uuid.UUID{first.ID, second.ID})
+		if got := claimedIDs(claimed); !slices.Equal(got, want) {
+			t.Fatalf("claimed %v, want %v", got, want)
+		}
+
+		i := slices.IndexFunc(claimed, func(job *storage.Job) bool {
+			return job.ID == first.ID
+		})
+		first.Attempts = 1
+		first.RunAt = lease
+		same(t, first, claimed[i], normalizeJob)
+
+		// Claimed jobs are skipped until their lease is over.
+		claimed, err = st.Jobs().Claim(ctx, now, lease, 10)
+		if err != nil {
+			t.Fatalf("claim: %v", err)
+		}
+
+		want = []uuid.UUID{third.ID}
+		if got := claimedIDs(claimed); !slices.Equal(got, want) {
+			t.Fatalf("claimed %v, want %v", got, want)
+		}
+
+		// Every claim counts as an attempt.
+		claimed, err = st.Jobs().Claim(ctx, lease, lease.Add(time.Minute), 10)
+		if err != nil {
+			t.Fatalf("claim: %v", err)
+		}
+
+		want = sortIDs([]uuid.UUID{first.ID, second.ID, third.ID})
+		if got := claimedIDs(claimed); !slices.Equal(got, want) {
+			t.Fatalf("claimed %v, want %v", got, want)
+		}
+
+		for _, job := range claimed {
+			if job.ID != third.ID && job.Attempts != 2 {
+				t.Errorf("attempts = %d, want 2", job.Attempts)
+			}
+		}
+
+		got, err := st.Jobs().GetByID(ctx, later.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, later, got, normalizeJob)
+	})
+}
+
+func TestJobsUpdateChecksLease(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		newJob(t, st, past, nil)
+
+		now := past.Add(time.Minute)
+		claimed, err := st.Jobs().Claim(ctx, now, now.Add(time.Minute), 1)
+		if err != nil || len(claimed) != 1 {
+			t.Fatalf("claim = %v, %v", claimed, err)
+		}
+		stale := claimed[0]
+		lease := stale.RunAt
+
+		// The lease ran out and another worker claimed the job.
+		now = lease.Add(time.Second)
+		claimed, err = st.Jobs().Claim(ctx, now, now.Add(time.Minute), 1)
+		if err != nil || len(claimed) != 1 {
+			t.Fatalf("claim = %v, %v", claimed, err)
+		}
+		current := claimed[0]
+
+		finishedAt := now
+		stale.Status = storage.JobStatusSucceeded
+		stale.FinishedAt = &finishedAt
+		updated, err := st.Jobs().Update(ctx, stale, lease)
+		if err != nil || updated {
+			t.Fatalf("update = %v, %v, want the lease lost", updated, err)
+		}
+
+		got, err := st.Jobs().GetByID(ctx, current.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, current, got, normalizeJob)
+
+		message := "failed"
+		current.RunAt = now.Add(time.Hour)
+		current.Error = &message
+		updated, err = st.Jobs().Update(ctx, current, got.RunAt)
+		if err != nil || !updated {
+			t.Fatalf("update = %v, %v, want the job saved", updated, err)
+		}
+
+		got, err = st.Jobs().GetByID(ctx, current.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, current, got, normalizeJob)
+
+		updated, err = st.Jobs().Update(ctx, &storage.Job{ID: uuid.New()},
+			now)
+		if err != nil || updated {
+			t.Errorf("update = %v, %v, want a missing job skipped",
+				updated, err)
+		}
+	})
+}
+
+func TestJobsUniqueKey(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		key := uuid.NewString()
+
+		first := newJob(t, st, past, &key)
+		second := newJob(t, st, past, &key)
+		other := newJob(t, st, past, nil)
+		another := newJob(t, st, past, nil)
+
+		for _, job := range []*storage.Job{first, other, another} {
+			got, err := st.Jobs().GetByID(ctx, job.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			same(t, job, got, normalizeJob)
+		}
+
+		got, err := st.Jobs().GetByID(ctx, second.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, nil, got, normalizeJob)
+	})
+}
@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/database/redis"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/migrator"
+	"cloud-notes/internal/storage"
+	"cloud-notes/internal/storage/memory"
+
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// The contract tests run against the in-memory storage and, when
+// TEST_POSTGRES_URL points to a database, against Postgres, so the two
+// can't drift apart. The database is migrated first, and every test works
+// with users of its own, so it may hold other data. The storages kept in
+// Redis run against TEST_REDIS_URL the same way.
+var (
+	pg *postgres.Postgres
+	rd *redis.Redis
+)
+
+func TestMain(m *testing.M) {
+	url := os.Getenv("TEST_POSTGRES_URL")
+	if url != "" {
+		ctx := context.Background()
+		err := migrator.Migrate(ctx, "../../migrations", url)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		pg, err = postgres.Connect(ctx, &config.Postgres{URL: url})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	url = os.Getenv("TEST_REDIS_URL")
+	if url != "" {
+		opts, err := goredis.ParseURL(url)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		rd = goredis.NewClient(opts)
+	}
+
+	code := m.Run()
+	if pg != nil {
+		pg.Close()
+	}
+	if rd != nil {
+		_ = rd.Close()
+	}
+
+	os.Exit(code)
+}
+
+// run runs the test once for every implementation.
+func run(t *testing.T, test func(t *testing.T, st storage.Storage)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, memory.New())
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		if pg == nil {
+			t.Skip("TEST_POSTGRES_URL is not set")
+		}
+
+		test(t, storage.New(discardLogger(), pg, rd))
+	})
+}
+
+// runRedis runs the test of a storage kept in Redis once for every
+// implementation.
+func runRedis(t *testing.T, test func(t *testing.T, st storage.Storage)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, memory.New())
+	})
+
+	t.Run("redis", func(t *testing.T) {
+		if rd == nil {
+			t.Skip("TEST_REDIS_URL is not set")
+		}
+
+		test(t, storage.New(discardLogger(), nil, rd))
+	})
+}
+
+func discardLogger() logger.Logger {
+	return logger.MustLoad(&config.Logger{
+		Level:  "error",
+		Output: "discard",
+		Format: "text",
+	})
+}
+
+// now is the current time as Postgres stores it.
+func now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// newUser creates an active user that is purged after the test.
+func newUser(t *testing.T, st storage.Storage, firstName string,
+	createdAt time.Time) *storage.User {
+	t.Helper()
+	ctx := context.Background()
+
+	email := uuid.NewString() + "@example.net"
+	user := &storage.User{
+		ID:           uuid.New(),
+		Login:        uuid.NewString() + "@example.com",
+		Email:        &email,
+		PasswordHash: "hash",
+		FirstName:    firstName,
+		Timezone:     "UTC",
+		Status:       storage.UserStatusActive,
+		CreatedAt:    createdAt,
+		Role:         storage.UserRoleUser,
+	}
+
+	err := st.Users().Create(ctx, user)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	t.Cleanup(func() {
+		err := st.Users().Purge(ctx, user.ID)
+		if err != nil {
+			t.Errorf("purge user: %v", err)
+		}
+	})
+
+	return user
+}
+
+func newNote(t *testing.T, st storage.Storage, userID uuid.UUID,
+	title, text string, pinned bool, createdAt time.Time) *storage.Note {
+	t.Helper()
+
+	note := &storage.Note{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Title:     &title,
+		Text:      &text,
+		Pinned:    pinned,
+		CreatedAt: createdAt,
+	}
+
+	err := st.Notes().Create(context.Background(), note)
+	if err != nil {
+		t.Fatalf("create note: %v", err)
+	}
+
+	return note
+}
+
+func newSession(t *testing.T, st storage.Storage,
+	userID uuid.UUID, expiresAt *time.Time) *storage.Session {
+	t.Helper()
+
+	refreshTokenHash := uuid.NewString()
+	session := &storage.Session{
+		ID:               uuid.New(),
+		UserID:           userID,
+		CreatedAt:        now(),
+		Scopes:           []string{},
+		RefreshTokenHash: &refreshTokenHash,
+		ExpiresAt:        expiresAt,
+	}
+
+	err := st.Sessions().Create(context.Background(), session)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+
+	return session
+}
+
+// same fails the test unless got equals want. Times are compared in UTC,
+// Postgres returns them in the local time zone.
+func same[T any](t *testing.T, want, got *T, normalize func(*T)) {
+	t.Helper()
+
+	if want != nil {
+		c := *want
+		normalize(&c)
+		want = &c
+	}
+	if got != nil {
+		c := *got
+		normalize(&c)
+		got = &c
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		w, _ := json.Marshal(want)
+		g, _ := json.Marshal(got)
+		t.Fatalf("want %s, got %s", w, g)
+	}
+}
+
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	v := t.UTC()
+	return &v
+}
+
+func normalizeUser(user *storage.User) {
+	user.CreatedAt = user.CreatedAt.UTC()
+}
+
+func normalizeNote(note *storage.Note) {
+	note.CreatedAt = note.CreatedAt.UTC()
+	note.UpdatedAt = utc(note.UpdatedAt)
+}
+
+func normalizeSession(session *storage.Session) {
+	session.CreatedAt = session.CreatedAt.UTC()
+	session.ExpiresAt = utc(session.ExpiresAt)
+}
@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+func TestNotesCreateUpdateDelete(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+		note := newNote(t, st, user.ID, "Title", "Text", false, now())
+
+		got, err := st.Notes().GetByID(ctx, note.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, note, got, normalizeNote)
+
+		title, updatedAt := "New title", now()
+		note.Title = &title
+		note.Text = nil
+		note.Pinned = true
+		note.UpdatedAt = &updatedAt
+		err = st.Notes().Update(ctx, note)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err = st.Notes().GetByID(ctx, note.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, note, got, normalizeNote)
+
+		deleted, err := st.Notes().Delete(ctx, note.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !deleted {
+			t.Fatal("note not deleted")
+		}
+
+		got, err = st.Notes().GetByID(ctx, note.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, nil, got, normalizeNote)
+
+		deleted, err = st.Notes().Delete(ctx, note.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if deleted {
+			t.Fatal("deleted a missing note")
+		}
+	})
+}
+
+func TestNotesGetForUpdate(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+		note := newNote(t, st, user.ID, "Title", "Text", false, now())
+
+		err := st.WithTx(ctx, func(st storage.Storage) error {
+			got, err := st.Notes().GetForUpdate(ctx, note.ID)
+			if err != nil {
+				return err
+			}
+			same(t, note, got, normalizeNote)
+
+			got, err = st.Notes().GetForUpdate(ctx, uuid.New())
+			if err != nil {
+				return err
+			}
+			same(t, nil, got, normalizeNote)
+
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestNotesRequireUser(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		title := "Title"
+
+		err := st.Notes().Create(ctx, &storage.Note{
+			ID:        uuid.New(),
+			UserID:    uuid.New(),
+			Title:     &title,
+			CreatedAt: now(),
+		})
+		if err == nil {
+			t.Fatal("created a note of a missing user")
+		}
+	})
+}
+
+func TestNotesFind(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+		other := newUser(t, st, "Petr", now())
+
+		start := now().Add(-time.Hour)
+		old := newNote(t, st, user.ID, "Postgres", "", false, start)
+		pinned := newNote(t, st, user.ID, "Redis", "", true, start)
+		recent := newNote(t, st, user.ID, "Go", "Uses POSTGRES",
+			false, start.Add(time.Minute))
+		newNote(t, st, other.ID, "Postgres", "", false, start)
+
+		found, err := st.Notes().Find(
+			ctx, &storage.NoteFilter{UserID: user.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []uuid.UUID{pinned.ID, recent.ID, old.ID}
+		if got := noteIDs(found); !equalIDs(want, got) {
+			t.Fatalf("want %v, got %v", want, got)
+		}
+
+		query := "postgres"
+		found, err = st.Notes().Find(ctx, &storage.NoteFilter{
+			UserID: user.ID,
+			Query:  &query,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = []uuid.UUID{recent.ID, old.ID}
+		if got := noteIDs(found); !equalIDs(want, got) {
+			t.Fatalf("want %v, got %v", want, got)
+		}
+	})
+}
+
+func TestNotesCountAndGetByUserID(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+		other := newUser(t, st, "Petr", now())
+		newNote(t, st, user.ID, "One", "", false, now())
+		newNote(t, st, user.ID, "Two", "", false, now())
+		newNote(t, st, other.ID, "Three", "", false, now())
+
+		count, err := st.Notes().Count(ctx, &user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 2 {
+			t.Fatalf("want 2 notes, got %d", count)
+		}
+
+		notes, err := st.Notes().GetByUserID(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(notes) != 2 {
+			t.Fatalf("want 2 notes, got %d", len(notes))
+		}
+	})
+}
+
+func noteIDs(notes []*storage.Note) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(notes))
+	for _, note := range notes {
+		ids = append(ids, note.ID)
+	}
+
+	return ids
+}
+
+func equalIDs(want, got []uuid.UUID) bool {
+	if len(want) != len(got) {
+		return false
+	}
+
+	for i := range want {
+		if want[i] != got[i] {
+			return false
+		}
+	}
+
+	return true
+}
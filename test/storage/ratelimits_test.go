@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+func TestRateLimitsTake(t *testing.T) {
+	runRedis(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		key := uuid.NewString()
+		budget := &storage.RateLimitBudget{Limit: 3, Period: time.Minute}
+
+		for i := range budget.Limit {
+			result, err := st.RateLimits().Take(ctx, key, budget)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !result.Allowed {
+				t.Fatalf("request %d denied", i+1)
+			}
+			if result.Remaining != budget.Limit-i-1 {
+				t.Fatalf("request %d: remaining %d, want %d",
+					i+1, result.Remaining, budget.Limit-i-1)
+			}
+			if result.Limit != budget.Limit {
+				t.Fatalf("limit %d, want %d", result.Limit, budget.Limit)
+			}
+		}
+
+		result, err := st.RateLimits().Take(ctx, key, budget)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Allowed {
+			t.Fatal("request over the limit allowed")
+		}
+		perToken := budget.Period / time.Duration(budget.Limit)
+		if result.RetryAfter <= 0 || result.RetryAfter > perToken {
+			t.Fatalf("retry after %v, want up to %v",
+				result.RetryAfter, perToken)
+		}
+		if result.Reset <= 0 || result.Reset > budget.Period {
+			t.Fatalf("reset %v, want up to %v", result.Reset, budget.Period)
+		}
+	})
+}
+
+func TestRateLimitsTakeKeys(t *testing.T) {
+	runRedis(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		budget := &storage.RateLimitBudget{Limit: 1, Period: time.Minute}
+
+		for _, key := range []string{uuid.NewString(), uuid.NewString()} {
+			result, err := st.RateLimits().Take(ctx, key, budget)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !result.Allowed {
+				t.Fatalf("first request of %s denied", key)
+			}
+		}
+	})
+}
+
+func TestRateLimitsTakeRefills(t *testing.T) {
+	runRedis(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		key := uuid.NewString()
+		budget := &storage.RateLimitBudget{
+			Limit:  2,
+			Period: 200 * time.Millisecond,
+		}
+
+		for range budget.Limit + 1 {
+			_, err := st.RateLimits().Take(ctx, key, budget)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// One token is back after Period / Limit.
+		time.Sleep(150 * time.Millisecond)
+
+		result, err := st.RateLimits().Take(ctx, key, budget)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !result.Allowed {
+			t.Fatal("request denied after the bucket refilled")
+		}
+	})
+}
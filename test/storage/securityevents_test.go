@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+func newSecurityEvent(t *testing.T, st storage.Storage, userID *uuid.UUID,
+	eventType, ip string, createdAt time.Time) *storage.SecurityEvent {
+	t.Helper()
+
+	userAgent := "Mozilla/5.0"
+	event := &storage.SecurityEvent{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Type:      eventType,
+		IP:        &ip,
+		UserAgent: &userAgent,
+		Details:   map[string]string{"session_id": uuid.NewString()},
+		CreatedAt: createdAt,
+	}
+
+	err := st.SecurityEvents().Create(context.Background(), event)
+	if err != nil {
+		t.Fatalf("create security event: %v", err)
+	}
+
+	return event
+}
+
+func normalizeSecurityEvent(event *storage.SecurityEvent) {
+	event.CreatedAt = event.CreatedAt.UTC()
+}
+
+func securityEventIDs(events []*storage.SecurityEvent) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+
+	return ids
+}
+
+func TestSecurityEventsFind(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+		start := now()
+
+		first := newSecurityEvent(t, st, &user.ID, "login_failed",
+			"192.0.2.1", start)
+		second := newSecurityEvent(t, st, &user.ID, "login_succeeded",
+			"192.0.2.1", start.Add(time.Second))
+		third := newSecurityEvent(t, st, &user.ID, "logout",
+			"192.0.2.2", start.Add(2*time.Second))
+
+		loginFailed, ip := "login_failed", "192.0.2.1"
+		from, to := start.Add(time.Second), start.Add(2*time.Second)
+		limit, offset := uint64(1), uint64(1)
+		tests := []struct {
+			name   string
+			filter storage.SecurityEventFilter
+			want   []uuid.UUID
+		}{
+			{"newest first", storage.SecurityEventFilter{},
+				[]uuid.UUID{third.ID, second.ID, first.ID}},
+			{"type", storage.SecurityEventFilter{Type: &loginFailed},
+				[]uuid.UUID{first.ID}},
+			{"ip", storage.SecurityEventFilter{IP: &ip},
+				[]uuid.UUID{second.ID, first.ID}},
+			{"from is inclusive, to isn't",
+				storage.SecurityEventFilter{From: &from, To: &to},
+				[]uuid.UUID{second.ID}},
+			{"page", storage.SecurityEventFilter{
+				Limit: &limit, Offset: &offset},
+				[]uuid.UUID{second.ID}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				filter := tt.filter
+				filter.UserID = &user.ID
+
+				events, err := st.SecurityEvents().Find(ctx, &filter)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				got := securityEventIDs(events)
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("found %v, want %v", got, tt.want)
+				}
+			})
+		}
+
+		events, err := st.SecurityEvents().Find(ctx,
+			&storage.SecurityEventFilter{UserID: &user.ID, Type: &loginFailed})
+		if err != nil || len(events) != 1 {
+			t.Fatalf("found %v, %v", events, err)
+		}
+		same(t, first, events[0], normalizeSecurityEvent)
+	})
+}
+
+func TestSecurityEventsOutliveUsers(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+		admin := newUser(t, st, "Admin", now())
+
+		event := newSecurityEvent(t, st, &user.ID, "user_blocked",
+			"192.0.2.1", now())
+		event.ActorID = &admin.ID
+
+		// An event is never overwritten, not even by its own ID.
+		err := st.SecurityEvents().Create(ctx, event)
+		if err == nil {
+			t.Fatal("an event was written twice")
+		}
+		event.ActorID = nil
+
+		for _, id := range []uuid.UUID{user.ID, admin.ID} {
+			err = st.Users().Purge(ctx, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		events, err := st.SecurityEvents().Find(ctx,
+			&storage.SecurityEventFilter{UserID: &user.ID})
+		if err != nil || len(events) != 1 {
+			t.Fatalf("found %v, %v, want the event kept", events, err)
+		}
+		same(t, event, events[0], normalizeSecurityEvent)
+	})
+}
+
+// The storages have no way to change an event, the table refuses it too.
+func TestSecurityEventsAppendOnly(t *testing.T) {
+	if pg == nil {
+		t.Skip("TEST_POSTGRES_URL is not set")
+	}
+
+	ctx := context.Background()
+	st := storage.New(discardLogger(), pg, rd)
+	user := newUser(t, st, "Ivan", now())
+	event := newSecurityEvent(t, st, &user.ID, "login_failed", "192.0.2.1",
+		now())
+
+	for _, sql := range []string{
+		`UPDATE security_events SET type = 'login_succeeded' WHERE id = $1`,
+		`DELETE FROM security_events WHERE id = $1`,
+	} {
+		_, err := pg.Exec(ctx, sql, event.ID)
+		if err == nil {
+			t.Errorf("%s: changed the audit trail", sql)
+		}
+	}
+
+	events, err := st.SecurityEvents().Find(ctx,
+		&storage.SecurityEventFilter{UserID: &user.ID})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("found %v, %v, want the event", events, err)
+	}
+	same(t, event, events[0], normalizeSecurityEvent)
+}
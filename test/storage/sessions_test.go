@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+func TestSessionsCreateAndGet(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+		session := newSession(t, st, user.ID, nil)
+
+		got, err := st.Sessions().GetByID(ctx, session.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, session, got, normalizeSession)
+
+		got, err = st.Sessions().GetByRefreshTokenHash(
+			ctx, *session.RefreshTokenHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, session, got, normalizeSession)
+
+		list, err := st.Sessions().GetByUserID(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 1 {
+			t.Fatalf("want 1 session, got %d", len(list))
+		}
+		same(t, session, list[0], normalizeSession)
+	})
+}
+
+func TestSessionsKeepNilScopes(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+
+		session := &storage.Session{
+			ID:        uuid.New(),
+			UserID:    user.ID,
+			CreatedAt: now(),
+		}
+		err := st.Sessions().Create(ctx, session)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := st.Sessions().GetByID(ctx, session.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, session, got, normalizeSession)
+	})
+}
+
+func TestSessionsConstraints(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+		session := newSession(t, st, user.ID, nil)
+
+		err := st.Sessions().Create(ctx, &storage.Session{
+			ID:        uuid.New(),
+			UserID:    uuid.New(),
+			CreatedAt: now(),
+		})
+		if err == nil {
+			t.Fatal("created a session of a missing user")
+		}
+
+		err = st.Sessions().Create(ctx, &storage.Session{
+			ID:               uuid.New(),
+			UserID:           user.ID,
+			CreatedAt:        now(),
+			RefreshTokenHash: session.RefreshTokenHash,
+		})
+		if err == nil {
+			t.Fatal("created a session with a taken refresh token")
+		}
+	})
+}
+
+func TestSessionsCountActive(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+
+		expired := now().Add(-time.Minute)
+		valid := now().Add(time.Hour)
+		newSession(t, st, user.ID, nil)
+		newSession(t, st, user.ID, &valid)
+		newSession(t, st, user.ID, &expired)
+
+		count, err := st.Sessions().CountActive(ctx, &user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 2 {
+			t.Fatalf("want 2 sessions, got %d", count)
+		}
+	})
+}
+
+func TestSessionsUpdateAndDelete(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+		session := newSession(t, st, user.ID, nil)
+		other := newSession(t, st, user.ID, nil)
+
+		expiresAt, userAgent := now().Add(time.Hour), "curl"
+		session.ExpiresAt = &expiresAt
+		session.UserAgent = &userAgent
+		session.Scopes = []string{"notes:read"}
+		err := st.Sessions().Update(ctx, session)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := st.Sessions().GetByID(ctx, session.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, session, got, normalizeSession)
+
+		err = st.Sessions().Delete(ctx, session.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err = st.Sessions().GetByID(ctx, session.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, nil, got, normalizeSession)
+
+		err = st.Sessions().DeleteByUserID(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err = st.Sessions().GetByID(ctx, other.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, nil, got, normalizeSession)
+	})
+}
+
+func TestSessionsRotateRefreshToken(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+		expiresAt := now().Add(time.Hour)
+		session := newSession(t, st, user.ID, &expiresAt)
+		oldHash := *session.RefreshTokenHash
+
+		newHash, newExpiresAt := uuid.NewString(), now().Add(2*time.Hour)
+		got, err := st.Sessions().RotateRefreshToken(
+			ctx, oldHash, newHash, now(), newExpiresAt, 24*time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		session.RefreshTokenHash = &newHash
+		session.ExpiresAt = &newExpiresAt
+		same(t, session, got, normalizeSession)
+
+		// The replaced token doesn't rotate again but leads to the session.
+		got, err = st.Sessions().RotateRefreshToken(ctx, oldHash,
+			uuid.NewString(), now(), newExpiresAt, 24*time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, nil, got, normalizeSession)
+
+		got, err = st.Sessions().GetByRotatedRefreshTokenHash(ctx, oldHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, session, got, normalizeSession)
+
+		got, err = st.Sessions().GetByRotatedRefreshTokenHash(ctx, newHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, nil, got, normalizeSession)
+
+		err = st.Sessions().Delete(ctx, session.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err = st.Sessions().GetByRotatedRefreshTokenHash(ctx, oldHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, nil, got, normalizeSession)
+	})
+}
+
+func TestSessionsRotateRefreshTokenKeepsLifetime(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+		expiresAt := now().Add(time.Hour)
+		session := newSession(t, st, user.ID, &expiresAt)
+
+		got, err := st.Sessions().RotateRefreshToken(ctx,
+			*session.RefreshTokenHash, uuid.NewString(), now(),
+			now().Add(48*time.Hour), 2*time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == nil || got.ExpiresAt == nil {
+			t.Fatalf("rotated = %+v, want the session", got)
+		}
+
+		limit := session.CreatedAt.Add(2 * time.Hour)
+		if !got.ExpiresAt.Equal(limit) {
+			t.Fatalf("expires at %v, want the lifetime end %v",
+				got.ExpiresAt, limit)
+		}
+	})
+}
+
+func TestSessionsRotateExpiredRefreshToken(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+		expiresAt := now().Add(-time.Minute)
+		session := newSession(t, st, user.ID, &expiresAt)
+
+		got, err := st.Sessions().RotateRefreshToken(ctx,
+			*session.RefreshTokenHash, uuid.NewString(), now(),
+			now().Add(time.Hour), 24*time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, nil, got, normalizeSession)
+
+		got, err = st.Sessions().GetByID(ctx, session.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, session, got, normalizeSession)
+	})
+}
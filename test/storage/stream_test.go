@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// ours keeps the messages of the events the test published, the stream
+// may hold events of other tests.
+func ours(messages []*storage.StreamMessage,
+	ids []uuid.UUID) []*storage.StreamMessage {
+	return slices.DeleteFunc(messages, func(m *storage.StreamMessage) bool {
+		return !slices.Contains(ids, m.Event.ID)
+	})
+}
+
+// readAll reads the stream as the consumer until the group has nothing
+// new.
+func readAll(t *testing.T, st storage.Storage,
+	group, consumer string) []*storage.StreamMessage {
+	t.Helper()
+
+	all := make([]*storage.StreamMessage, 0)
+	for {
+		messages, err := st.Stream().Read(
+			context.Background(), group, consumer, 1000, 0)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+
+		if len(messages) == 0 {
+			return all
+		}
+
+		all = append(all, messages...)
+	}
+}
+
+func eventIDs(messages []*storage.StreamMessage) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.Event.ID)
+	}
+
+	return ids
+}
+
+func TestStreamConsumerGroups(t *testing.T) {
+	runRedis(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		first, second := uuid.NewString(), uuid.NewString()
+
+		for _, group := range []string{first, second, first} {
+			err := st.Stream().CreateGroup(ctx, group)
+			if err != nil {
+				t.Fatalf("create group: %v", err)
+			}
+		}
+
+		ids := make([]uuid.UUID, 0, 2)
+		for range 2 {
+			event := &storage.OutboxEvent{
+				ID:        uuid.New(),
+				Type:      "note.created",
+				UserID:    uuid.New(),
+				Payload:   []byte(`{"note_id":"1"}`),
+				CreatedAt: now(),
+			}
+
+			err := st.Stream().Publish(ctx, event)
+			if err != nil {
+				t.Fatalf("publish: %v", err)
+			}
+
+			ids = append(ids, event.ID)
+		}
+
+		// Every group gets every event, in the order they were published.
+		for _, group := range []string{first, second} {
+			got := eventIDs(ours(readAll(t, st, group, "a"), ids))
+			if !slices.Equal(got, ids) {
+				t.Fatalf("group read %v, want %v", got, ids)
+			}
+		}
+
+		if got := ours(readAll(t, st, first, "b"), ids); len(got) != 0 {
+			t.Fatalf("another consumer read %d delivered events", len(got))
+		}
+
+		// Unacknowledged events are taken over by another consumer.
+		claimed, err := st.Stream().Claim(ctx, first, "b", 0, 1000)
+		if err != nil {
+			t.Fatalf("claim: %v", err)
+		}
+
+		claimed = ours(claimed, ids)
+		if got := eventIDs(claimed); !slices.Equal(got, ids) {
+			t.Fatalf("claimed %v, want %v", got, ids)
+		}
+
+		if claimed[0].Event.Type != "note.created" ||
+			string(claimed[0].Event.Payload) != `{"note_id":"1"}` {
+			t.Errorf("claimed event = %+v", claimed[0].Event)
+		}
+
+		claimed, err = st.Stream().Claim(ctx, first, "c", time.Hour, 1000)
+		if err != nil {
+			t.Fatalf("claim: %v", err)
+		}
+
+		if got := ours(claimed, ids); len(got) != 0 {
+			t.Fatalf("claimed %d events held for less than min idle",
+				len(got))
+		}
+
+		messages, err := st.Stream().Claim(ctx, first, "b", 0, 1000)
+		if err != nil {
+			t.Fatalf("claim: %v", err)
+		}
+
+		for _, message := range ours(messages, ids) {
+			err = st.Stream().Ack(ctx, first, message.ID)
+			if err != nil {
+				t.Fatalf("ack: %v", err)
+			}
+		}
+
+		claimed, err = st.Stream().Claim(ctx, first, "c", 0, 1000)
+		if err != nil {
+			t.Fatalf("claim: %v", err)
+		}
+
+		if got := ours(claimed, ids); len(got) != 0 {
+			t.Errorf("claimed %d acknowledged events", len(got))
+		}
+	})
+}
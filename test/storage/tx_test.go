@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"cloud-notes/internal/database/postgres"
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// txAttempts is how many times WithTx runs a transaction Postgres keeps
+// aborting.
+const txAttempts = 3
+
+func TestWithTxRollsBack(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+		errFailed := errors.New("failed")
+
+		var note *storage.Note
+		err := st.WithTx(ctx, func(st storage.Storage) error {
+			note = newNote(t, st, user.ID, "Title", "Text", false, now())
+			return errFailed
+		})
+		if !errors.Is(err, errFailed) {
+			t.Fatalf("want the error of the function, got %v", err)
+		}
+
+		got, err := st.Notes().GetByID(ctx, note.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, nil, got, normalizeNote)
+	})
+}
+
+func TestWithTxNested(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+		errFailed := errors.New("failed")
+
+		var kept, dropped *storage.Note
+		err := st.WithTx(ctx, func(st storage.Storage) error {
+			kept = newNote(t, st, user.ID, "Kept", "", false, now())
+
+			err := st.WithTx(ctx, func(st storage.Storage) error {
+				dropped = newNote(t, st, user.ID, "Dropped", "", false, now())
+				return errFailed
+			})
+			if !errors.Is(err, errFailed) {
+				t.Fatalf("want the error of the function, got %v", err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := st.Notes().GetByID(ctx, kept.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, kept, got, normalizeNote)
+
+		got, err = st.Notes().GetByID(ctx, dropped.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, nil, got, normalizeNote)
+	})
+}
+
+func TestWithTxRetries(t *testing.T) {
+	if pg == nil {
+		t.Skip("TEST_POSTGRES_URL is not set")
+	}
+
+	ctx := context.Background()
+	st := storage.New(discardLogger(), pg, rd)
+	user := newUser(t, st, "Ivan", now())
+	errSerialization := &pgconn.PgError{Code: "40001"}
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name     string
+		err      error
+		attempts int
+	}{
+		{"serialization failure", errSerialization, txAttempts},
+		{"deadlock", &pgconn.PgError{Code: "40P01"}, txAttempts},
+		{"other error", errFailed, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := st.WithTx(ctx, func(st storage.Storage) error {
+				attempts++
+				return tt.err
+			})
+			if !errors.Is(err, tt.err) || attempts != tt.attempts {
+				t.Errorf("%d attempts, %v, want %d, %v",
+					attempts, err, tt.attempts, tt.err)
+			}
+		})
+	}
+
+	// A savepoint isn't run again on its own, the whole transaction is.
+	var outer, inner int
+	var notes []*storage.Note
+	err := st.WithTx(ctx, func(st storage.Storage) error {
+		outer++
+		note := newNote(t, st, user.ID, "Attempt", "", false, now())
+		notes = append(notes, note)
+
+		return st.WithTx(ctx, func(st storage.Storage) error {
+			inner++
+			if inner == 1 {
+				return errSerialization
+			}
+
+			return nil
+		})
+	})
+	if err != nil || outer != 2 || inner != 2 {
+		t.Fatalf("%d outer and %d inner attempts, %v, want 2 of each",
+			outer, inner, err)
+	}
+
+	for i, want := range []bool{false, true} {
+		got, err := st.Notes().GetByID(ctx, notes[i].ID)
+		if err != nil || (got != nil) != want {
+			t.Errorf("note of attempt %d = %v, %v, kept %t",
+				i+1, got, err, want)
+		}
+	}
+}
+
+// TestWithTxSerializable runs two transactions that each add a note only
+// when the user has none, and both read before either writes.
+func TestWithTxSerializable(t *testing.T) {
+	if pg == nil {
+		t.Skip("TEST_POSTGRES_URL is not set")
+	}
+
+	ctx := context.Background()
+	st := storage.New(discardLogger(), pg, rd)
+
+	tests := []struct {
+		name  string
+		opts  []storage.TxOption
+		notes uint64
+		retry bool
+	}{
+		// READ COMMITTED lets both see no note, nothing is retried.
+		{"read committed", nil, 2, false},
+		{"serializable", []storage.TxOption{storage.Serializable()}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := newUser(t, st, "Ivan", now())
+
+			var (
+				read     sync.WaitGroup
+				wg       sync.WaitGroup
+				mu       sync.Mutex
+				attempts int
+			)
+			read.Add(2)
+			for range 2 {
+				var once sync.Once
+				wg.Go(func() {
+					err := st.WithTx(ctx, func(st storage.Storage) error {
+						mu.Lock()
+						attempts++
+						mu.Unlock()
+
+						count, err := st.Notes().Count(ctx, &user.ID)
+						if err != nil {
+							return err
+						}
+
+						once.Do(func() {
+							read.Done()
+							read.Wait()
+						})
+
+						if count > 0 {
+							return nil
+						}
+
+						title := "Only"
+						return st.Notes().Create(ctx, &storage.Note{
+							ID:        uuid.New(),
+							UserID:    user.ID,
+							Title:     &title,
+							CreatedAt: now(),
+						})
+					}, tt.opts...)
+					if err != nil {
+						t.Errorf("transaction: %v", err)
+					}
+				})
+			}
+			wg.Wait()
+
+			count, err := st.Notes().Count(ctx, &user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if count != tt.notes || (attempts > 2) != tt.retry {
+				t.Errorf("%d notes in %d attempts, want %d, retried %t",
+					count, attempts, tt.notes, tt.retry)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	for err, want := range map[error]bool{
+		&pgconn.PgError{Code: "40001"}: true,
+		&pgconn.PgError{Code: "40P01"}: true,
+		&pgconn.PgError{Code: "23505"}: false,
+		errors.New("40001"):            false,
+	} {
+		if got := postgres.IsRetryable(err); got != want {
+			t.Errorf("IsRetryable(%v) = %t, want %t", err, got, want)
+		}
+	}
+}
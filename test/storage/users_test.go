@@ -0,0 +1,329 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+func TestUsersCreateAndGet(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+
+		got, err := st.Users().GetByID(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, user, got, normalizeUser)
+
+		got, err = st.Users().GetByLogin(ctx, user.Login)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, user, got, normalizeUser)
+
+		got, err = st.Users().GetByEmail(ctx, *user.Email)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, user, got, normalizeUser)
+
+		err = st.WithTx(ctx, func(st storage.Storage) error {
+			got, err = st.Users().GetForUpdate(ctx, user.ID)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, user, got, normalizeUser)
+
+		got, err = st.Users().GetByEmail(ctx, user.Login)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, nil, got, normalizeUser)
+
+		got, err = st.Users().GetByID(ctx, uuid.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, nil, got, normalizeUser)
+	})
+}
+
+func TestUsersGetByIDs(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+		other := newUser(t, st, "Petr", now())
+		newUser(t, st, "Oleg", now())
+
+		got, err := st.Users().GetByIDs(ctx,
+			[]uuid.UUID{other.ID, uuid.New(), user.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 2 {
+			t.Fatalf("got %d users, want 2", len(got))
+		}
+
+		byID := make(map[uuid.UUID]*storage.User)
+		for _, u := range got {
+			byID[u.ID] = u
+		}
+		same(t, user, byID[user.ID], normalizeUser)
+		same(t, other, byID[other.ID], normalizeUser)
+
+		got, err = st.Users().GetByIDs(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 0 {
+			t.Fatalf("got %d users for no ids", len(got))
+		}
+	})
+}
+
+func TestUsersUniqueLogin(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+		other := newUser(t, st, "Petr", now())
+
+		duplicate := *user
+		duplicate.ID = uuid.New()
+		err := st.Users().Create(ctx, &duplicate)
+		if err == nil {
+			t.Fatal("created a user with a taken login")
+		}
+
+		other.Login = user.Login
+		err = st.Users().Update(ctx, other)
+		if err == nil {
+			t.Fatal("changed the login to a taken one")
+		}
+	})
+}
+
+func TestUsersUniqueEmail(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+		other := newUser(t, st, "Petr", now())
+
+		duplicate := *user
+		duplicate.ID = uuid.New()
+		duplicate.Login = uuid.NewString()
+		err := st.Users().Create(ctx, &duplicate)
+		if err == nil {
+			t.Fatal("created a user with a taken email")
+		}
+
+		other.Email = user.Email
+		err = st.Users().Update(ctx, other)
+		if err == nil {
+			t.Fatal("changed the email to a taken one")
+		}
+
+		// Accounts from before the email was asked for have none, any
+		// number of them.
+		for _, u := range []*storage.User{user, other} {
+			u.Email = nil
+			err = st.Users().Update(ctx, u)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := st.Users().GetByID(ctx, u.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			same(t, u, got, normalizeUser)
+		}
+	})
+}
+
+func TestUsersUpdate(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+
+		user.FirstName = "Petr"
+		user.Status = storage.UserStatusBlocked
+		user.Role = storage.UserRoleSupport
+		err := st.Users().Update(ctx, user)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := st.Users().GetByID(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, user, got, normalizeUser)
+	})
+}
+
+func TestUsersListAndCount(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+
+		// The tag keeps other users in the database out of the results.
+		tag := uuid.NewString()
+		start := now().Add(-time.Hour)
+		first := newUser(t, st, "First "+tag, start)
+		second := newUser(t, st, "Second "+tag, start.Add(time.Minute))
+		third := newUser(t, st, "Third "+tag, start.Add(2*time.Minute))
+
+		third.Status = storage.UserStatusBlocked
+		err := st.Users().Update(ctx, third)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		upper := "FIRST " + tag
+		list, err := st.Users().List(
+			ctx, &storage.UserFilter{Query: &upper}, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 1 || list[0].ID != first.ID {
+			t.Fatalf("want the first user, got %d users", len(list))
+		}
+
+		filter := &storage.UserFilter{Query: &tag}
+		limit, offset := uint64(2), uint64(1)
+		list, err = st.Users().List(ctx, filter, &limit, &offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 2 || list[0].ID != second.ID ||
+			list[1].ID != first.ID {
+			t.Fatalf("want the second and the first user, got %d users",
+				len(list))
+		}
+
+		count, err := st.Users().Count(ctx, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 3 {
+			t.Fatalf("want 3 users, got %d", count)
+		}
+
+		status := storage.UserStatusActive
+		after := start.Add(time.Minute)
+		count, err = st.Users().Count(ctx, &storage.UserFilter{
+			Query:        &tag,
+			Status:       &status,
+			CreatedAfter: &after,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Fatalf("want 1 user, got %d", count)
+		}
+	})
+}
+
+func TestUsersDeleteCascadesNotes(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+		note := newNote(t, st, user.ID, "Title", "Text", false, now())
+
+		err := st.Users().Delete(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := st.Notes().GetByID(ctx, note.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, nil, got, normalizeNote)
+	})
+}
+
+func TestUsersDeleteWithSessions(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+		newSession(t, st, user.ID, nil)
+
+		err := st.Users().Delete(ctx, user.ID)
+		if err == nil {
+			t.Fatal("deleted a user with sessions")
+		}
+
+		got, err := st.Users().GetByID(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, user, got, normalizeUser)
+	})
+}
+
+func TestUsersDeleteCascadesImpersonation(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		admin := newUser(t, st, "Admin", now())
+		user := newUser(t, st, "Ivan", now())
+
+		session := newSession(t, st, user.ID, nil)
+		session.ImpersonatorID = &admin.ID
+		err := st.Sessions().Update(ctx, session)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = st.Users().Delete(ctx, admin.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := st.Sessions().GetByID(ctx, session.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, nil, got, normalizeSession)
+	})
+}
+
+func TestUsersPurge(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		user := newUser(t, st, "Ivan", now())
+		note := newNote(t, st, user.ID, "Title", "Text", false, now())
+		session := newSession(t, st, user.ID, nil)
+
+		err := st.Users().Purge(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		gotUser, err := st.Users().GetByID(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, nil, gotUser, normalizeUser)
+
+		gotNote, err := st.Notes().GetByID(ctx, note.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, nil, gotNote, normalizeNote)
+
+		gotSession, err := st.Sessions().GetByID(ctx, session.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, nil, gotSession, normalizeSession)
+	})
+}
@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+func newWorkspace(t *testing.T, st storage.Storage,
+	ownerID uuid.UUID) *storage.Workspace {
+	t.Helper()
+	ctx := context.Background()
+
+	workspace := &storage.Workspace{
+		ID:        uuid.New(),
+		Name:      "Team",
+		OwnerID:   ownerID,
+		CreatedAt: now(),
+	}
+
+	err := st.Workspaces().Create(ctx, workspace)
+	if err != nil {
+		t.Fatalf("create workspace: %v", err)
+	}
+
+	newMember(t, st, workspace.ID, ownerID, storage.WorkspaceRoleOwner,
+		workspace.CreatedAt)
+	return workspace
+}
+
+func newMember(t *testing.T, st storage.Storage, workspaceID,
+	userID uuid.UUID, role storage.WorkspaceRole,
+	createdAt time.Time) *storage.WorkspaceMember {
+	t.Helper()
+
+	member := &storage.WorkspaceMember{
+		WorkspaceID: workspaceID,
+		UserID:      userID,
+		Role:        role,
+		CreatedAt:   createdAt,
+	}
+
+	err := st.WorkspaceMembers().Create(context.Background(), member)
+	if err != nil {
+		t.Fatalf("create member: %v", err)
+	}
+
+	return member
+}
+
+func newWorkspaceNote(t *testing.T, st storage.Storage,
+	userID, workspaceID uuid.UUID) *storage.Note {
+	t.Helper()
+
+	title, text := "Title", "Text"
+	note := &storage.Note{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Title:       &title,
+		Text:        &text,
+		CreatedAt:   now(),
+		WorkspaceID: &workspaceID,
+	}
+
+	err := st.Notes().Create(context.Background(), note)
+	if err != nil {
+		t.Fatalf("create note: %v", err)
+	}
+
+	return note
+}
+
+func normalizeWorkspace(workspace *storage.Workspace) {
+	workspace.CreatedAt = workspace.CreatedAt.UTC()
+}
+
+func normalizeMember(member *storage.WorkspaceMember) {
+	member.CreatedAt = member.CreatedAt.UTC()
+}
+
+func TestUsersPurgePassesNotesToOwner(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		owner := newUser(t, st, "Owner", now())
+		editor := newUser(t, st, "Editor", now())
+		workspace := newWorkspace(t, st, owner.ID)
+		newMember(t, st, workspace.ID, editor.ID,
+			storage.WorkspaceRoleEditor, now())
+		note := newWorkspaceNote(t, st, editor.ID, workspace.ID)
+
+		err := st.Users().Purge(ctx, editor.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		note.UserID = owner.ID
+		got, err := st.Notes().GetByID(ctx, note.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, note, got, normalizeNote)
+
+		member, err := st.WorkspaceMembers().Get(
+			ctx, workspace.ID, editor.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, nil, member, normalizeMember)
+	})
+}
+
+func TestUsersPurgeRemovesOwnedWorkspaces(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		owner := newUser(t, st, "Owner", now())
+		workspace := newWorkspace(t, st, owner.ID)
+		note := newWorkspaceNote(t, st, owner.ID, workspace.ID)
+
+		err := st.Users().Purge(ctx, owner.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		gotWorkspace, err := st.Workspaces().GetByID(ctx, workspace.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, nil, gotWorkspace, normalizeWorkspace)
+
+		gotNote, err := st.Notes().GetByID(ctx, note.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, nil, gotNote, normalizeNote)
+	})
+}
+
+func TestWorkspacesTransferOwned(t *testing.T) {
+	run(t, func(t *testing.T, st storage.Storage) {
+		ctx := context.Background()
+		createdAt := now()
+		owner := newUser(t, st, "Owner", createdAt)
+		viewer := newUser(t, st, "Viewer", createdAt)
+		editor := newUser(t, st, "Editor", createdAt)
+
+		shared := newWorkspace(t, st, owner.ID)
+		newMember(t, st, shared.ID, viewer.ID,
+			storage.WorkspaceRoleViewer, createdAt)
+		heir := newMember(t, st, shared.ID, editor.ID,
+			storage.WorkspaceRoleEditor, createdAt.Add(time.Second))
+		solo := newWorkspace(t, st, owner.ID)
+		note := newWorkspaceNote(t, st, owner.ID, shared.ID)
+
+		heirs, err := st.Workspaces().TransferOwned(ctx, owner.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(heirs) != 1 || heirs[0] != editor.ID {
+			t.Fatalf("want heirs [%s], got %v", editor.ID, heirs)
+		}
+
+		err = st.Users().Purge(ctx, owner.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		shared.OwnerID = editor.ID
+		gotShared, err := st.Workspaces().GetByID(ctx, shared.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, shared, gotShared, normalizeWorkspace)
+
+		heir.Role = storage.WorkspaceRoleOwner
+		gotHeir, err := st.WorkspaceMembers().Get(ctx, shared.ID, editor.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, heir, gotHeir, normalizeMember)
+
+		note.UserID = editor.ID
+		gotNote, err := st.Notes().GetByID(ctx, note.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, note, gotNote, normalizeNote)
+
+		gotSolo, err := st.Workspaces().GetByID(ctx, solo.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same(t, nil, gotSolo, normalizeWorkspace)
+	})
+}
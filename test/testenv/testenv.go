@@ -0,0 +1,136 @@
+// Package testenv sets up what the service tests share: the config loaded
+// the way the server loads it, a quiet logger, signing keys and users.
+package testenv
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/audit"
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/logger"
+	"cloud-notes/internal/mailer"
+	"cloud-notes/internal/oidc"
+	"cloud-notes/internal/password"
+	"cloud-notes/internal/security"
+	authService "cloud-notes/internal/services/auth"
+	"cloud-notes/internal/storage"
+	"cloud-notes/internal/totp"
+	"cloud-notes/internal/webauthn"
+
+	"github.com/google/uuid"
+)
+
+// Config loads the config from the environment with the defaults of every
+// optional value and the in-memory storage. env sets or overrides the
+// variables for the test.
+func Config(t *testing.T, env map[string]string) *config.Config {
+	t.Helper()
+
+	vars := map[string]string{
+		"ENV":                  "test",
+		"STORAGE":              config.StorageMemory,
+		"SERVER_HOST":          "127.0.0.1",
+		"SERVER_PORT":          "8000",
+		"SERVER_READ_TIMEOUT":  "5",
+		"SERVER_WRITE_TIMEOUT": "5",
+		"SERVER_IDLE_TIMEOUT":  "60",
+		"LOGGER_LEVEL":         "error",
+		"LOGGER_OUTPUT":        "discard",
+		"LOGGER_FORMAT":        "text",
+		"JWT_PRIVATE_KEY_FILE": KeyFile(t),
+	}
+	for name, value := range env {
+		vars[name] = value
+	}
+	for name, value := range vars {
+		t.Setenv(name, value)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+
+	return cfg
+}
+
+func Logger() logger.Logger {
+	return logger.MustLoad(&config.Logger{
+		Level:  "error",
+		Output: "discard",
+		Format: "text",
+	})
+}
+
+// Auth builds the auth service the way the server does.
+func Auth(cfg *config.Config, st storage.Storage) authService.Service {
+	log := Logger()
+	return authService.New(log, st,
+		security.MustNew(log, st, &cfg.JWT),
+		totp.New(&cfg.TOTP, time.Now),
+		webauthn.MustNew(&cfg.WebAuthn),
+		mailer.MustLoad(log, &cfg.Mailer),
+		oidc.New(&cfg.OIDC),
+		password.MustNew(&cfg.PasswordHash),
+		password.NewPolicy(&cfg.PasswordPolicy, &cfg.PasswordHash),
+		audit.New(log, st),
+		cfg)
+}
+
+// KeyFile writes a new Ed25519 signing key and returns its path.
+func KeyFile(t *testing.T) string {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	file := filepath.Join(t.TempDir(), "jwt.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	err = os.WriteFile(file, data, 0o600)
+	if err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+
+	return file
+}
+
+// NewUser creates a user with the login, which is the email as well, the
+// status and the password hash the caller made.
+func NewUser(t *testing.T, st storage.Storage,
+	login, passwordHash string, status storage.UserStatus) *storage.User {
+	t.Helper()
+
+	user := &storage.User{
+		ID:           uuid.New(),
+		Login:        login,
+		Email:        &login,
+		PasswordHash: passwordHash,
+		FirstName:    "Ivan",
+		Timezone:     "UTC",
+		Status:       status,
+		CreatedAt:    time.Now(),
+		Role:         storage.UserRoleUser,
+	}
+
+	err := st.Users().Create(context.Background(), user)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	return user
+}
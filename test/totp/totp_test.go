@@ -0,0 +1,128 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/totp"
+)
+
+// secret is the RFC 6238 SHA-1 test key "12345678901234567890" in base32.
+const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func at(unix int64) func() time.Time {
+	return func() time.Time { return time.Unix(unix, 0) }
+}
+
+// TestValidateVectors checks the RFC 6238 appendix B SHA-1 vectors, cut to
+// the last 6 digits.
+func TestValidateVectors(t *testing.T) {
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, v := range vectors {
+		otp := totp.New(&config.TOTP{Skew: 0}, at(v.unix))
+
+		step, ok := otp.Validate(secret, v.code, 0)
+		if !ok {
+			t.Errorf("code %s at %d rejected", v.code, v.unix)
+			continue
+		}
+
+		if want := v.unix / 30; step != want {
+			t.Errorf("code %s at %d matched step %d, want %d",
+				v.code, v.unix, step, want)
+		}
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	// 287082 is the code of step 1, which spans 30..59.
+	tests := []struct {
+		name string
+		unix int64
+		skew int
+		ok   bool
+	}{
+		{"same step", 45, 0, true},
+		{"next step without skew", 75, 0, false},
+		{"next step within skew", 75, 1, true},
+		{"previous step within skew", 15, 1, true},
+		{"two steps later within skew 1", 105, 1, false},
+		{"two steps later within skew 2", 105, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			otp := totp.New(&config.TOTP{Skew: tt.skew}, at(tt.unix))
+
+			step, ok := otp.Validate(secret, "287082", 0)
+			if ok != tt.ok {
+				t.Fatalf("got ok %v, want %v", ok, tt.ok)
+			}
+
+			if ok && step != 1 {
+				t.Fatalf("matched step %d, want 1", step)
+			}
+		})
+	}
+}
+
+func TestValidateReplay(t *testing.T) {
+	otp := totp.New(&config.TOTP{Skew: 1}, at(59))
+
+	step, ok := otp.Validate(secret, "287082", 0)
+	if !ok {
+		t.Fatal("fresh code rejected")
+	}
+
+	if _, ok = otp.Validate(secret, "287082", step); ok {
+		t.Fatal("code accepted again for the step it was used at")
+	}
+
+	if _, ok = otp.Validate(secret, "287082", step+1); ok {
+		t.Fatal("code accepted after a later step was used")
+	}
+}
+
+func TestValidateRejects(t *testing.T) {
+	otp := totp.New(&config.TOTP{Skew: 1}, at(59))
+
+	for _, code := range []string{"", "000000", "28708", "2870820"} {
+		if _, ok := otp.Validate(secret, code, 0); ok {
+			t.Errorf("code %q accepted", code)
+		}
+	}
+
+	if _, ok := otp.Validate("not base32!", "287082", 0); ok {
+		t.Error("code accepted with an invalid secret")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	otp := totp.New(&config.TOTP{Skew: 1}, time.Now)
+
+	first, err := otp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := otp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(first) != 32 || first == second {
+		t.Fatalf("unexpected secrets %q and %q", first, second)
+	}
+}
@@ -0,0 +1,358 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/audit"
+	"cloud-notes/internal/password"
+	"cloud-notes/internal/quota"
+	"cloud-notes/internal/security"
+	authService "cloud-notes/internal/services/auth"
+	notesService "cloud-notes/internal/services/notes"
+	userService "cloud-notes/internal/services/user"
+	workspacesService "cloud-notes/internal/services/workspaces"
+	"cloud-notes/internal/storage"
+	"cloud-notes/internal/storage/memory"
+	"cloud-notes/test/testenv"
+
+	"github.com/google/uuid"
+)
+
+const secret = "correct-horse-battery-staple"
+
+type deletionEnv struct {
+	st         storage.Storage
+	users      userService.Service
+	auth       authService.Service
+	notes      notesService.Service
+	workspaces workspacesService.Service
+	hash       string
+}
+
+// newDeletionEnv keeps deleted accounts for gracePeriod seconds.
+func newDeletionEnv(t *testing.T, gracePeriod string) *deletionEnv {
+	t.Helper()
+
+	cfg := testenv.Config(t, map[string]string{
+		"DELETION_GRACE_PERIOD":            gracePeriod,
+		"PASSWORD_HASH_ALGORITHM":          "argon2id",
+		"PASSWORD_HASH_ARGON2_MEMORY":      "1024",
+		"PASSWORD_HASH_ARGON2_ITERATIONS":  "1",
+		"PASSWORD_HASH_ARGON2_PARALLELISM": "1",
+	})
+	log := testenv.Logger()
+	st := memory.New()
+	qt := quota.New(log, st, &cfg.Quota)
+
+	hash, err := password.MustNew(&cfg.PasswordHash).Hash(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &deletionEnv{
+		st: st,
+		users: userService.New(log, st, security.MustNew(log, st, &cfg.JWT),
+			audit.New(log, st), qt, cfg),
+		auth:       testenv.Auth(cfg, st),
+		notes:      notesService.New(log, st, qt),
+		workspaces: workspacesService.New(log, st),
+		hash:       hash,
+	}
+}
+
+// newUser creates an active user who logs in with the secret and has a
+// session and a note.
+func (e *deletionEnv) newUser(t *testing.T, login string) (uuid.UUID,
+	uuid.UUID) {
+	t.Helper()
+
+	user := testenv.NewUser(t, e.st, login, e.hash, storage.UserStatusActive)
+
+	err := e.login(login)
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	note, err := e.notes.CreateNote(context.Background(),
+		&notesService.CreateNoteInput{
+			Space: notesService.Space{UserID: user.ID},
+			Title: ptr("Note of " + login),
+		})
+	if err != nil {
+		t.Fatalf("create note: %v", err)
+	}
+
+	return user.ID, note.ID
+}
+
+func (e *deletionEnv) login(login string) error {
+	_, err := e.auth.Login(context.Background(), &authService.LoginInput{
+		Login:    login,
+		Password: secret,
+	})
+	return err
+}
+
+func (e *deletionEnv) restore(login string) error {
+	_, err := e.auth.RestoreAccount(context.Background(),
+		&authService.LoginInput{
+			Login:    login,
+			Password: secret,
+		})
+	return err
+}
+
+func (e *deletionEnv) user(t *testing.T, id uuid.UUID) *storage.User {
+	t.Helper()
+
+	user, err := e.st.Users().GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return user
+}
+
+func (e *deletionEnv) note(t *testing.T, id uuid.UUID) *storage.Note {
+	t.Helper()
+
+	note, err := e.st.Notes().GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return note
+}
+
+func (e *deletionEnv) sessions(t *testing.T, userID uuid.UUID) int {
+	t.Helper()
+
+	sessions, err := e.st.Sessions().GetByUserID(context.Background(), userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return len(sessions)
+}
+
+func TestDeleteProfileKeepsData(t *testing.T) {
+	e := newDeletionEnv(t, "3600")
+	ctx := context.Background()
+	userID, noteID := e.newUser(t, "ivan@example.com")
+
+	before := time.Now()
+	err := e.users.DeleteProfile(ctx, userID)
+	if err != nil {
+		t.Fatalf("delete profile: %v", err)
+	}
+
+	if user := e.user(t, userID); user.Status != storage.UserStatusDeleted {
+		t.Errorf("status = %s, want deleted", user.Status)
+	}
+
+	if n := e.sessions(t, userID); n != 0 {
+		t.Errorf("sessions = %d, want all revoked", n)
+	}
+
+	if e.note(t, noteID) == nil {
+		t.Error("the note is gone within the grace period")
+	}
+
+	deletion, err := e.st.Deletions().GetByUserID(ctx, userID)
+	if err != nil || deletion == nil {
+		t.Fatalf("deletion = %v, %v", deletion, err)
+	}
+
+	if deletion.PurgeAt.Before(before.Add(time.Hour).Truncate(time.Second)) ||
+		deletion.PurgeAt.After(time.Now().Add(time.Hour)) {
+		t.Errorf("purge at %v, want an hour later", deletion.PurgeAt)
+	}
+
+	err = e.login("ivan@example.com")
+	if !errors.Is(err, authService.ErrUserDeleted) {
+		t.Errorf("login = %v, want %v", err, authService.ErrUserDeleted)
+	}
+
+	// Nothing is due within the grace period.
+	err = e.users.PurgeDeletedUsers(ctx)
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+
+	if e.user(t, userID) == nil {
+		t.Fatal("the user was purged within the grace period")
+	}
+
+	err = e.restore("ivan@example.com")
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+
+	if user := e.user(t, userID); user.Status != storage.UserStatusActive {
+		t.Errorf("status = %s, want active again", user.Status)
+	}
+
+	deletion, err = e.st.Deletions().GetByUserID(ctx, userID)
+	if err != nil || deletion != nil {
+		t.Errorf("deletion = %+v, %v, want it cancelled", deletion, err)
+	}
+
+	if n := e.sessions(t, userID); n != 1 {
+		t.Errorf("sessions = %d, want the restoring one", n)
+	}
+
+	err = e.restore("ivan@example.com")
+	if !errors.Is(err, authService.ErrUserNotDeleted) {
+		t.Errorf("restore again = %v, want %v",
+			err, authService.ErrUserNotDeleted)
+	}
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	e := newDeletionEnv(t, "0")
+	ctx := context.Background()
+	userID, noteID := e.newUser(t, "ivan@example.com")
+	otherID, otherNoteID := e.newUser(t, "olga@example.com")
+
+	err := e.users.DeleteProfile(ctx, userID)
+	if err != nil {
+		t.Fatalf("delete profile: %v", err)
+	}
+
+	// The account is about to be purged and can't be restored anymore.
+	err = e.restore("ivan@example.com")
+	if !errors.Is(err, authService.ErrUserNotFound) {
+		t.Errorf("restore = %v, want %v", err, authService.ErrUserNotFound)
+	}
+
+	err = e.users.PurgeDeletedUsers(ctx)
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+
+	if e.user(t, userID) != nil || e.note(t, noteID) != nil ||
+		e.sessions(t, userID) != 0 {
+		t.Error("the data of the purged user is left")
+	}
+
+	deletion, err := e.st.Deletions().GetByUserID(ctx, userID)
+	if err != nil || deletion != nil {
+		t.Errorf("deletion = %+v, %v, want it gone", deletion, err)
+	}
+
+	if e.user(t, otherID) == nil || e.note(t, otherNoteID) == nil {
+		t.Error("another user's data was purged")
+	}
+
+	err = e.users.PurgeDeletedUsers(ctx)
+	if err != nil {
+		t.Errorf("purge again: %v", err)
+	}
+}
+
+func TestPurgeTransfersWorkspaces(t *testing.T) {
+	e := newDeletionEnv(t, "0")
+	ctx := context.Background()
+	ownerID, _ := e.newUser(t, "owner@example.com")
+	viewerID, _ := e.newUser(t, "viewer@example.com")
+	editorID, _ := e.newUser(t, "editor@example.com")
+
+	create := func(name string) uuid.UUID {
+		workspace, err := e.workspaces.CreateWorkspace(ctx,
+			&workspacesService.CreateWorkspaceInput{
+				UserID: ownerID,
+				Name:   name,
+			})
+		if err != nil {
+			t.Fatalf("create workspace: %v", err)
+		}
+
+		return workspace.ID
+	}
+	shared, solo := create("Team"), create("Solo")
+
+	// An editor inherits the workspace before a viewer who joined
+	// earlier.
+	for _, member := range []struct{ login, role string }{
+		{"viewer@example.com", "viewer"},
+		{"editor@example.com", "editor"},
+	} {
+		_, err := e.workspaces.AddMember(ctx, &workspacesService.AddMemberInput{
+			WorkspaceInput: workspacesService.WorkspaceInput{
+				UserID:      ownerID,
+				WorkspaceID: shared,
+			},
+			Login: member.login,
+			Role:  member.role,
+		})
+		if err != nil {
+			t.Fatalf("add %s: %v", member.login, err)
+		}
+	}
+
+	note, err := e.notes.CreateNote(ctx, &notesService.CreateNoteInput{
+		Space: notesService.Space{UserID: ownerID, WorkspaceID: &shared},
+		Title: ptr("Shared"),
+		Text:  ptr("Text of the team"),
+	})
+	if err != nil {
+		t.Fatalf("create note: %v", err)
+	}
+
+	before, err := e.st.Usage().GetByUserID(ctx, editorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = e.users.DeleteProfile(ctx, ownerID)
+	if err != nil {
+		t.Fatalf("delete profile: %v", err)
+	}
+
+	err = e.users.PurgeDeletedUsers(ctx)
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+
+	workspace, err := e.st.Workspaces().GetByID(ctx, shared)
+	if err != nil || workspace == nil || workspace.OwnerID != editorID {
+		t.Fatalf("workspace = %+v, %v, want it owned by the editor",
+			workspace, err)
+	}
+
+	member, err := e.st.WorkspaceMembers().Get(ctx, shared, editorID)
+	if err != nil || member == nil ||
+		member.Role != storage.WorkspaceRoleOwner {
+		t.Errorf("member = %+v, %v, want the owner", member, err)
+	}
+
+	member, err = e.st.WorkspaceMembers().Get(ctx, shared, viewerID)
+	if err != nil || member == nil ||
+		member.Role != storage.WorkspaceRoleViewer {
+		t.Errorf("member = %+v, %v, want still a viewer", member, err)
+	}
+
+	if got := e.note(t, note.ID); got == nil || got.UserID != editorID {
+		t.Errorf("note = %+v, want it passed to the editor", got)
+	}
+
+	// The heir's usage now includes the notes of the workspace.
+	after, err := e.st.Usage().GetByUserID(ctx, editorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if after.Notes != before.Notes+1 || after.Bytes <= before.Bytes {
+		t.Errorf("usage = %+v, was %+v, want the shared note added",
+			after, before)
+	}
+
+	if workspace, err := e.st.Workspaces().GetByID(ctx, solo); err != nil ||
+		workspace != nil {
+		t.Errorf("workspace = %+v, %v, want the solo one gone",
+			workspace, err)
+	}
+}
@@ -0,0 +1,289 @@
+package user
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/audit"
+	"cloud-notes/internal/quota"
+	"cloud-notes/internal/security"
+	userService "cloud-notes/internal/services/user"
+	"cloud-notes/internal/storage"
+	"cloud-notes/internal/storage/memory"
+	"cloud-notes/test/testenv"
+
+	"github.com/google/uuid"
+)
+
+func newService(t *testing.T) (userService.Service, storage.Storage) {
+	t.Helper()
+
+	cfg := testenv.Config(t, nil)
+	log := testenv.Logger()
+	st := memory.New()
+	return userService.New(log, st, security.MustNew(log, st, &cfg.JWT),
+		audit.New(log, st), quota.New(log, st, &cfg.Quota), cfg), st
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// fill stores a record of every kind the export covers. The secrets are
+// marked, so the test can tell they stayed out of the archive.
+func fill(t *testing.T, st storage.Storage, userID uuid.UUID) {
+	t.Helper()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	client := &storage.OAuthClient{
+		ID:           uuid.New(),
+		OwnerID:      userID,
+		Name:         "Calendar",
+		SecretHash:   ptr("secret-client-hash"),
+		RedirectURIs: []string{"https://calendar.example.com/callback"},
+		Scopes:       []string{"notes:read"},
+		CreatedAt:    now,
+	}
+	workspace := &storage.Workspace{
+		ID:        uuid.New(),
+		Name:      "Team",
+		OwnerID:   userID,
+		CreatedAt: now,
+	}
+
+	steps := map[string]func() error{
+		"session": func() error {
+			return st.Sessions().Create(ctx, &storage.Session{
+				ID:        uuid.New(),
+				UserID:    userID,
+				UserAgent: ptr("Mozilla/5.0"),
+				CreatedAt: now,
+			})
+		},
+		"grant": func() error {
+			err := st.OAuthClients().Create(ctx, client)
+			if err != nil {
+				return err
+			}
+
+			return st.Sessions().Create(ctx, &storage.Session{
+				ID:               uuid.New(),
+				UserID:           userID,
+				CreatedAt:        now,
+				ClientID:         &client.ID,
+				Scopes:           []string{"notes:read"},
+				RefreshTokenHash: ptr("secret-refresh-hash"),
+				ExpiresAt:        ptr(now.Add(time.Hour)),
+			})
+		},
+		"security event": func() error {
+			return st.SecurityEvents().Create(ctx, &storage.SecurityEvent{
+				ID:        uuid.New(),
+				UserID:    &userID,
+				Type:      "login_succeeded",
+				IP:        ptr("203.0.113.10"),
+				UserAgent: ptr("Mozilla/5.0"),
+				Details:   map[string]string{},
+				CreatedAt: now,
+			})
+		},
+		"identity": func() error {
+			return st.Identities().Create(ctx, &storage.Identity{
+				ID:        uuid.New(),
+				UserID:    userID,
+				Provider:  "google",
+				Subject:   "google-subject",
+				Email:     "ivan@gmail.com",
+				CreatedAt: now,
+			})
+		},
+		"personal token": func() error {
+			return st.PersonalTokens().Create(ctx, &storage.PersonalToken{
+				ID:        uuid.New(),
+				UserID:    userID,
+				Name:      "CLI",
+				TokenHash: "secret-token-hash",
+				Scopes:    []string{"notes:write"},
+				CreatedAt: now,
+			})
+		},
+		"workspace": func() error {
+			err := st.Workspaces().Create(ctx, workspace)
+			if err != nil {
+				return err
+			}
+
+			return st.WorkspaceMembers().Create(ctx, &storage.WorkspaceMember{
+				WorkspaceID: workspace.ID,
+				UserID:      userID,
+				Role:        storage.WorkspaceRoleOwner,
+				CreatedAt:   now,
+			})
+		},
+		"webhook": func() error {
+			return st.Webhooks().Create(ctx, &storage.Webhook{
+				ID:        uuid.New(),
+				UserID:    userID,
+				URL:       "https://example.com/hooks",
+				Secret:    "secret-webhook",
+				Events:    []string{"note.created"},
+				Active:    true,
+				CreatedAt: now,
+			})
+		},
+		"two factor": func() error {
+			return st.TwoFactor().Create(ctx, &storage.TwoFactor{
+				UserID:      userID,
+				Secret:      "secret-totp",
+				Enabled:     true,
+				ConfirmedAt: &now,
+				CreatedAt:   now,
+			})
+		},
+	}
+	for name, step := range steps {
+		err := step()
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+	}
+}
+
+// archive builds the user's export and returns its files.
+func archive(t *testing.T, srv userService.Service,
+	st storage.Storage, userID uuid.UUID) map[string][]byte {
+	t.Helper()
+
+	ctx := context.Background()
+	export, err := srv.RequestExport(ctx, userID)
+	if err != nil {
+		t.Fatalf("request export: %v", err)
+	}
+
+	err = srv.BuildExport(ctx, export.ID)
+	if err != nil {
+		t.Fatalf("build export: %v", err)
+	}
+
+	stored, err := st.Exports().GetByID(ctx, export.ID)
+	if err != nil || stored == nil {
+		t.Fatalf("get export: %v, %v", stored, err)
+	}
+
+	if stored.Status != storage.ExportStatusReady {
+		t.Fatalf("export status = %s, want ready", stored.Status)
+	}
+
+	zr, err := zip.NewReader(
+		bytes.NewReader(stored.Archive), int64(len(stored.Archive)))
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+
+	files := make(map[string][]byte)
+	for _, file := range zr.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", file.Name, err)
+		}
+
+		data, err := io.ReadAll(r)
+		_ = r.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", file.Name, err)
+		}
+
+		files[file.Name] = data
+	}
+
+	return files
+}
+
+func TestExportCoversAccount(t *testing.T) {
+	srv, st := newService(t)
+	user := testenv.NewUser(t, st, "ivan@example.com", "hash",
+		storage.UserStatusActive)
+	fill(t, st, user.ID)
+
+	files := archive(t, srv, st, user.ID)
+
+	tests := []struct {
+		file  string
+		count int
+		field string
+		want  any
+	}{
+		{"sessions.json", 1, "user_agent", "Mozilla/5.0"},
+		{"oauth_grants.json", 1, "client_name", "Calendar"},
+		{"security_events.json", 1, "ip", "203.0.113.10"},
+		{"identities.json", 1, "subject", "google-subject"},
+		{"personal_tokens.json", 1, "name", "CLI"},
+		{"workspaces.json", 1, "role", "owner"},
+		{"webhooks.json", 1, "url", "https://example.com/hooks"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			var records []map[string]any
+			err := json.Unmarshal(files[tt.file], &records)
+			if err != nil {
+				t.Fatalf("decode %s: %v", tt.file, err)
+			}
+
+			if len(records) != tt.count {
+				t.Fatalf("got %d records, want %d", len(records), tt.count)
+			}
+
+			if got := records[0][tt.field]; got != tt.want {
+				t.Errorf("%s = %v, want %v", tt.field, got, tt.want)
+			}
+		})
+	}
+
+	var twoFactor struct {
+		Enabled     bool       `json:"enabled"`
+		ConfirmedAt *time.Time `json:"confirmed_at"`
+	}
+	err := json.Unmarshal(files["two_factor.json"], &twoFactor)
+	if err != nil {
+		t.Fatalf("decode two_factor.json: %v", err)
+	}
+
+	if !twoFactor.Enabled || twoFactor.ConfirmedAt == nil {
+		t.Errorf("two factor = %+v, want enabled", twoFactor)
+	}
+
+	for name, data := range files {
+		if strings.Contains(string(data), "secret-") {
+			t.Errorf("%s contains a secret: %s", name, data)
+		}
+	}
+}
+
+func TestExportOfNewAccount(t *testing.T) {
+	srv, st := newService(t)
+	user := testenv.NewUser(t, st, "ivan@example.com", "hash",
+		storage.UserStatusActive)
+
+	files := archive(t, srv, st, user.ID)
+
+	for _, name := range []string{"sessions.json", "oauth_grants.json",
+		"security_events.json", "identities.json", "personal_tokens.json",
+		"workspaces.json", "webhooks.json", "passkeys.json", "notes.json"} {
+		if got := strings.TrimSpace(string(files[name])); got != "[]" {
+			t.Errorf("%s = %q, want an empty list", name, got)
+		}
+	}
+
+	if got := string(files["two_factor.json"]); !strings.Contains(got,
+		`"enabled": false`) {
+		t.Errorf("two_factor.json = %s, want disabled", got)
+	}
+}
@@ -0,0 +1,391 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/webauthn"
+
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/google/uuid"
+)
+
+const origin = "http://localhost:8000"
+
+// Authenticator data flags.
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttested     = 0x40
+)
+
+var b64 = base64.RawURLEncoding
+
+// authenticator is a software passkey: one P-256 key that signs with the
+// signature counter it keeps, like a hardware authenticator would.
+type authenticator struct {
+	origin       string
+	credentialID []byte
+	key          *ecdsa.PrivateKey
+	signCount    uint32
+	userHandle   []byte
+}
+
+func newAuthenticator(t *testing.T) *authenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	credentialID := make([]byte, 16)
+	_, _ = rand.Read(credentialID)
+
+	return &authenticator{
+		origin:       origin,
+		credentialID: credentialID,
+		key:          key,
+	}
+}
+
+// authData builds the authenticator data for the RP, with the attested
+// credential when attested is set.
+func (a *authenticator) authData(
+	t *testing.T, rpID string, attested bool) []byte {
+	t.Helper()
+
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := bytes.NewBuffer(rpIDHash[:])
+
+	flags := byte(flagUserPresent | flagUserVerified)
+	if attested {
+		flags |= flagAttested
+	}
+	data.WriteByte(flags)
+	_ = binary.Write(data, binary.BigEndian, a.signCount)
+
+	if attested {
+		publicKey, err := webauthncbor.Marshal(map[int]any{
+			1:  2,  // kty: EC2
+			3:  -7, // alg: ES256
+			-1: 1,  // crv: P-256
+			-2: a.key.X.FillBytes(make([]byte, 32)),
+			-3: a.key.Y.FillBytes(make([]byte, 32)),
+		})
+		if err != nil {
+			t.Fatalf("marshal public key: %v", err)
+		}
+
+		data.Write(make([]byte, 16)) // AAGUID
+		_ = binary.Write(data, binary.BigEndian,
+			uint16(len(a.credentialID)))
+		data.Write(a.credentialID)
+		data.Write(publicKey)
+	}
+
+	return data.Bytes()
+}
+
+func (a *authenticator) clientData(
+	t *testing.T, ceremony string, challenge []byte) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": b64.EncodeToString(challenge),
+		"origin":    a.origin,
+	})
+	if err != nil {
+		t.Fatalf("marshal client data: %v", err)
+	}
+
+	return data
+}
+
+// create answers navigator.credentials.create with "none" attestation.
+func (a *authenticator) create(
+	t *testing.T, options *webauthn.CredentialCreation) []byte {
+	t.Helper()
+
+	attestation, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": a.authData(t, options.Response.RelyingParty.ID, true),
+	})
+	if err != nil {
+		t.Fatalf("marshal attestation: %v", err)
+	}
+
+	return a.credential(t, map[string]string{
+		"clientDataJSON": b64.EncodeToString(a.clientData(
+			t, "webauthn.create", options.Response.Challenge)),
+		"attestationObject": b64.EncodeToString(attestation),
+	})
+}
+
+// get answers navigator.credentials.get, counting the signature.
+func (a *authenticator) get(
+	t *testing.T, options *webauthn.CredentialAssertion) []byte {
+	t.Helper()
+
+	a.signCount++
+	authData := a.authData(t, options.Response.RelyingPartyID, false)
+	clientData := a.clientData(
+		t, "webauthn.get", options.Response.Challenge)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	return a.credential(t, map[string]string{
+		"clientDataJSON":    b64.EncodeToString(clientData),
+		"authenticatorData": b64.EncodeToString(authData),
+		"signature":         b64.EncodeToString(signature),
+		"userHandle":        b64.EncodeToString(a.userHandle),
+	})
+}
+
+func (a *authenticator) credential(
+	t *testing.T, response map[string]string) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]any{
+		"id":       b64.EncodeToString(a.credentialID),
+		"rawId":    b64.EncodeToString(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		t.Fatalf("marshal credential: %v", err)
+	}
+
+	return data
+}
+
+type user struct {
+	id          uuid.UUID
+	credentials []webauthn.Credential
+}
+
+func (u *user) WebAuthnID() []byte {
+	return u.id[:]
+}
+
+func (u *user) WebAuthnName() string {
+	return "user@example.com"
+}
+
+func (u *user) WebAuthnDisplayName() string {
+	return "John"
+}
+
+func (u *user) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+func newWebAuthn(t *testing.T) *webauthn.WebAuthn {
+	t.Helper()
+
+	wa, err := webauthn.New(&config.WebAuthn{
+		RPID:      "localhost",
+		RPName:    "CloudNotes",
+		RPOrigins: []string{origin},
+		Timeout:   300,
+	})
+	if err != nil {
+		t.Fatalf("new webauthn: %v", err)
+	}
+
+	return wa
+}
+
+// roundTrip stores and loads the session like the ceremonies do.
+func roundTrip(t *testing.T,
+	session *webauthn.SessionData) webauthn.SessionData {
+	t.Helper()
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		t.Fatalf("marshal session: %v", err)
+	}
+
+	var loaded webauthn.SessionData
+	err = json.Unmarshal(data, &loaded)
+	if err != nil {
+		t.Fatalf("unmarshal session: %v", err)
+	}
+
+	return loaded
+}
+
+// register runs a registration ceremony and keeps the credential.
+func register(t *testing.T, wa *webauthn.WebAuthn,
+	u *user, a *authenticator) *webauthn.Credential {
+	t.Helper()
+
+	exclusions := webauthn.Credentials(u.WebAuthnCredentials())
+	options, session, err := wa.BeginRegistration(u,
+		webauthn.WithExclusions(exclusions.CredentialDescriptors()))
+	if err != nil {
+		t.Fatalf("begin registration: %v", err)
+	}
+
+	a.userHandle = u.WebAuthnID()
+	response, err := webauthn.ParseCredentialCreation(a.create(t, options))
+	if err != nil {
+		t.Fatalf("parse credential: %v", err)
+	}
+
+	credential, err := wa.CreateCredential(
+		u, roundTrip(t, session), response)
+	if err != nil {
+		t.Fatalf("create credential: %v", err)
+	}
+
+	u.credentials = append(u.credentials, *credential)
+	return credential
+}
+
+// login runs a discoverable login ceremony with the authenticator.
+func login(t *testing.T, wa *webauthn.WebAuthn, u *user,
+	a *authenticator) (*webauthn.Credential, error) {
+	t.Helper()
+
+	options, session, err := wa.BeginDiscoverableLogin()
+	if err != nil {
+		t.Fatalf("begin login: %v", err)
+	}
+
+	response, err := webauthn.ParseCredentialAssertion(a.get(t, options))
+	if err != nil {
+		return nil, err
+	}
+
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		return u, nil
+	}
+
+	_, credential, err := wa.ValidatePasskeyLogin(
+		handler, roundTrip(t, session), response)
+	if err != nil {
+		return nil, err
+	}
+
+	return credential, nil
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	wa := newWebAuthn(t)
+	u := &user{id: uuid.New()}
+	a := newAuthenticator(t)
+
+	registered := register(t, wa, u, a)
+	if !bytes.Equal(registered.ID, a.credentialID) {
+		t.Fatalf("registered credential %x, want %x",
+			registered.ID, a.credentialID)
+	}
+
+	for i := 1; i <= 2; i++ {
+		credential, err := login(t, wa, u, a)
+		if err != nil {
+			t.Fatalf("login %d: %v", i, err)
+		}
+
+		if credential.Authenticator.CloneWarning {
+			t.Fatalf("login %d: unexpected clone warning", i)
+		}
+
+		if credential.Authenticator.SignCount != a.signCount {
+			t.Fatalf("login %d: sign count %d, want %d", i,
+				credential.Authenticator.SignCount, a.signCount)
+		}
+
+		u.credentials[0] = *credential
+	}
+}
+
+func TestRegisterExcludesCredentials(t *testing.T) {
+	wa := newWebAuthn(t)
+	u := &user{id: uuid.New()}
+	a := newAuthenticator(t)
+	register(t, wa, u, a)
+
+	exclusions := webauthn.Credentials(u.WebAuthnCredentials())
+	options, _, err := wa.BeginRegistration(u,
+		webauthn.WithExclusions(exclusions.CredentialDescriptors()))
+	if err != nil {
+		t.Fatalf("begin registration: %v", err)
+	}
+
+	excluded := options.Response.CredentialExcludeList
+	if len(excluded) != 1 ||
+		!bytes.Equal(excluded[0].CredentialID, a.credentialID) {
+		t.Fatalf("registered credential isn't excluded: %+v", excluded)
+	}
+}
+
+func TestLoginRejectsOtherOrigin(t *testing.T) {
+	wa := newWebAuthn(t)
+	u := &user{id: uuid.New()}
+	a := newAuthenticator(t)
+	register(t, wa, u, a)
+
+	a.origin = "https://evil.example.com"
+	_, err := login(t, wa, u, a)
+	if err == nil {
+		t.Fatal("assertion from another origin accepted")
+	}
+}
+
+func TestLoginRejectsOtherKey(t *testing.T) {
+	wa := newWebAuthn(t)
+	u := &user{id: uuid.New()}
+	a := newAuthenticator(t)
+	register(t, wa, u, a)
+
+	// Same credential id, different private key.
+	forged := newAuthenticator(t)
+	forged.credentialID = a.credentialID
+	forged.userHandle = a.userHandle
+	_, err := login(t, wa, u, forged)
+	if err == nil {
+		t.Fatal("assertion signed by another key accepted")
+	}
+}
+
+// A counter that doesn't grow means the key may have been copied, the
+// service rejects such logins.
+func TestLoginWarnsOnCloneWithoutCounterGrowth(t *testing.T) {
+	wa := newWebAuthn(t)
+	u := &user{id: uuid.New()}
+	a := newAuthenticator(t)
+	register(t, wa, u, a)
+
+	credential, err := login(t, wa, u, a)
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	u.credentials[0] = *credential
+
+	a.signCount--
+	credential, err = login(t, wa, u, a)
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	if !credential.Authenticator.CloneWarning {
+		t.Fatal("repeated sign count didn't raise a clone warning")
+	}
+}
@@ -0,0 +1,458 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud-notes/internal/events"
+	"cloud-notes/internal/security"
+	webhooksService "cloud-notes/internal/services/webhooks"
+	"cloud-notes/internal/storage"
+	"cloud-notes/internal/storage/memory"
+	"cloud-notes/test/testenv"
+
+	"github.com/google/uuid"
+)
+
+// receiver is an endpoint that answers with the queued status codes, then
+// with 200, and keeps what it got.
+type receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	requests []*received
+}
+
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	t.Helper()
+
+	r := &receiver{statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, request *http.Request) {
+			body, _ := io.ReadAll(request.Body)
+
+			r.mu.Lock()
+			r.requests = append(r.requests,
+				&received{header: request.Header.Clone(), body: body})
+			status := http.StatusOK
+			if len(r.statuses) > 0 {
+				status, r.statuses = r.statuses[0], r.statuses[1:]
+			}
+			r.mu.Unlock()
+
+			w.WriteHeader(status)
+		}))
+	t.Cleanup(r.Close)
+
+	return r
+}
+
+func (r *receiver) got() []*received {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]*received(nil), r.requests...)
+}
+
+// newService uses no backoff, so every failed attempt is due again at once.
+func newService(t *testing.T,
+	env map[string]string) (webhooksService.Service, storage.Storage) {
+	t.Helper()
+
+	vars := map[string]string{
+		"WEBHOOK_ALLOW_PRIVATE": "true",
+		"WEBHOOK_BACKOFF_BASE":  "0",
+		"WEBHOOK_MAX_ATTEMPTS":  "3",
+		"WEBHOOK_DISABLE_AFTER": "2",
+		"WEBHOOK_TIMEOUT":       "5",
+	}
+	for name, value := range env {
+		vars[name] = value
+	}
+
+	cfg := testenv.Config(t, vars)
+	st := memory.New()
+	return webhooksService.New(testenv.Logger(), st, cfg), st
+}
+
+func newUser(t *testing.T, st storage.Storage) uuid.UUID {
+	t.Helper()
+
+	user := testenv.NewUser(t, st, uuid.NewString()+"@example.com",
+		"hash", storage.UserStatusActive)
+	return user.ID
+}
+
+func newWebhook(t *testing.T, srv webhooksService.Service,
+	userID uuid.UUID, url string) *webhooksService.CreateWebhookOutput {
+	t.Helper()
+
+	webhook, err := srv.CreateWebhook(context.Background(),
+		&webhooksService.CreateWebhookInput{
+			UserID: userID,
+			URL:    url,
+			Events: []string{events.TypeNoteCreated,
+				events.TypeAccountPasswordChanged},
+		})
+	if err != nil {
+		t.Fatalf("create webhook: %v", err)
+	}
+
+	return webhook
+}
+
+func publish(t *testing.T, srv webhooksService.Service,
+	event *storage.OutboxEvent) {
+	t.Helper()
+
+	err := srv.HandleEvent(context.Background(), event)
+	if err != nil {
+		t.Fatalf("handle event: %v", err)
+	}
+}
+
+func deliver(t *testing.T, srv webhooksService.Service, times int) {
+	t.Helper()
+
+	for range times {
+		err := srv.DeliverWebhooks(context.Background())
+		if err != nil {
+			t.Fatalf("deliver webhooks: %v", err)
+		}
+	}
+}
+
+func deliveries(t *testing.T, srv webhooksService.Service,
+	userID, webhookID uuid.UUID) []*webhooksService.DeliveryOutput {
+	t.Helper()
+
+	output, err := srv.GetDeliveries(context.Background(),
+		&webhooksService.GetDeliveriesInput{
+			WebhookInput: webhooksService.WebhookInput{
+				UserID:    userID,
+				WebhookID: webhookID,
+			},
+		})
+	if err != nil {
+		t.Fatalf("get deliveries: %v", err)
+	}
+
+	return output.Deliveries
+}
+
+func webhook(t *testing.T, st storage.Storage, id uuid.UUID) *storage.Webhook {
+	t.Helper()
+
+	webhook, err := st.Webhooks().GetByID(context.Background(), id)
+	if err != nil || webhook == nil {
+		t.Fatalf("get webhook: %v, %v", webhook, err)
+	}
+
+	return webhook
+}
+
+func accountEvent(t *testing.T, userID uuid.UUID) *storage.OutboxEvent {
+	t.Helper()
+
+	event, err := events.NewAccountEvent(
+		events.TypeAccountPasswordChanged, userID)
+	if err != nil {
+		t.Fatalf("new event: %v", err)
+	}
+
+	return event
+}
+
+func TestDeliverySignature(t *testing.T) {
+	srv, st := newService(t, nil)
+	userID := newUser(t, st)
+	r := newReceiver(t)
+	hook := newWebhook(t, srv, userID, r.URL)
+
+	event := accountEvent(t, userID)
+	publish(t, srv, event)
+	deliver(t, srv, 1)
+
+	got := r.got()
+	if len(got) != 1 {
+		t.Fatalf("got %d requests, want 1", len(got))
+	}
+
+	request := got[0]
+	if name := request.header.Get("X-CloudNotes-Event"); name != event.Type {
+		t.Errorf("event header = %q, want %q", name, event.Type)
+	}
+
+	timestamp, signature, ok := strings.Cut(
+		request.header.Get("X-CloudNotes-Signature"), ",v1=")
+	if !ok || !strings.HasPrefix(timestamp, "t=") {
+		t.Fatalf("signature header = %q",
+			request.header.Get("X-CloudNotes-Signature"))
+	}
+
+	unix, err := strconv.ParseInt(
+		strings.TrimPrefix(timestamp, "t="), 10, 64)
+	if err != nil {
+		t.Fatalf("parse timestamp: %v", err)
+	}
+
+	want := security.SignWebhook(hook.Secret, unix, request.body)
+	if !hmac.Equal([]byte(signature), []byte(want)) {
+		t.Errorf("signature = %q, want %q", signature, want)
+	}
+
+	forged := security.SignWebhook("cnw_other", unix, request.body)
+	if hmac.Equal([]byte(signature), []byte(forged)) {
+		t.Error("signature matches another secret")
+	}
+
+	var body struct {
+		ID   uuid.UUID `json:"id"`
+		Type string    `json:"type"`
+		Data struct {
+			UserID uuid.UUID `json:"user_id"`
+		} `json:"data"`
+	}
+	err = json.Unmarshal(request.body, &body)
+	if err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+
+	if body.ID != event.ID || body.Type != event.Type ||
+		body.Data.UserID != userID {
+		t.Errorf("body = %+v, want event %s of %s", body, event.ID, userID)
+	}
+}
+
+func TestDeliveryRetries(t *testing.T) {
+	srv, st := newService(t, nil)
+	userID := newUser(t, st)
+	r := newReceiver(t, http.StatusInternalServerError)
+	hook := newWebhook(t, srv, userID, r.URL)
+
+	publish(t, srv, accountEvent(t, userID))
+	deliver(t, srv, 1)
+
+	got := deliveries(t, srv, userID, hook.ID)
+	if len(got) != 1 || got[0].Status != "pending" || got[0].Attempts != 1 {
+		t.Fatalf("after a failure deliveries = %+v, want one pending", got)
+	}
+
+	if got[0].ResponseCode == nil ||
+		*got[0].ResponseCode != http.StatusInternalServerError {
+		t.Errorf("response code = %v, want 500", got[0].ResponseCode)
+	}
+
+	deliver(t, srv, 1)
+
+	got = deliveries(t, srv, userID, hook.ID)
+	if got[0].Status != "succeeded" || got[0].Attempts != 2 ||
+		got[0].DeliveredAt == nil {
+		t.Errorf("after a retry delivery = %+v, want succeeded", got[0])
+	}
+
+	requests := r.got()
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+
+	first := requests[0].header.Get("X-CloudNotes-Delivery")
+	second := requests[1].header.Get("X-CloudNotes-Delivery")
+	if first != second || string(requests[0].body) !=
+		string(requests[1].body) {
+		t.Error("retry differs from the first attempt")
+	}
+
+	if failures := webhook(t, st, hook.ID).Failures; failures != 0 {
+		t.Errorf("failures = %d, want 0", failures)
+	}
+}
+
+func TestDeliveryGivesUp(t *testing.T) {
+	srv, st := newService(t, map[string]string{
+		"WEBHOOK_DISABLE_AFTER": "5",
+	})
+	userID := newUser(t, st)
+	r := newReceiver(t, http.StatusBadGateway, http.StatusBadGateway,
+		http.StatusBadGateway, http.StatusBadGateway)
+	hook := newWebhook(t, srv, userID, r.URL)
+
+	publish(t, srv, accountEvent(t, userID))
+	deliver(t, srv, 5)
+
+	got := deliveries(t, srv, userID, hook.ID)
+	if len(got) != 1 || got[0].Status != "failed" || got[0].Attempts != 3 {
+		t.Fatalf("deliveries = %+v, want one failed after 3 attempts", got)
+	}
+
+	if len(r.got()) != 3 {
+		t.Errorf("got %d requests, want 3", len(r.got()))
+	}
+
+	current := webhook(t, st, hook.ID)
+	if current.Failures != 1 || !current.Active {
+		t.Errorf("webhook failures = %d, active = %v, want 1 and active",
+			current.Failures, current.Active)
+	}
+}
+
+func TestWebhookDisabledAfterFailures(t *testing.T) {
+	srv, st := newService(t, map[string]string{
+		"WEBHOOK_MAX_ATTEMPTS": "1",
+	})
+	ctx := context.Background()
+	userID := newUser(t, st)
+	r := newReceiver(t, http.StatusInternalServerError,
+		http.StatusInternalServerError)
+	hook := newWebhook(t, srv, userID, r.URL)
+
+	publish(t, srv, accountEvent(t, userID))
+	deliver(t, srv, 1)
+
+	current := webhook(t, st, hook.ID)
+	if current.Failures != 1 || !current.Active {
+		t.Fatalf("after one failure failures = %d, active = %v",
+			current.Failures, current.Active)
+	}
+
+	publish(t, srv, accountEvent(t, userID))
+	deliver(t, srv, 1)
+
+	current = webhook(t, st, hook.ID)
+	if current.Failures != 2 || current.Active {
+		t.Fatalf("after two failures failures = %d, active = %v",
+			current.Failures, current.Active)
+	}
+
+	publish(t, srv, accountEvent(t, userID))
+	deliver(t, srv, 1)
+	if len(r.got()) != 2 {
+		t.Errorf("disabled webhook got %d requests, want 2", len(r.got()))
+	}
+
+	output, err := srv.UpdateWebhook(ctx, &webhooksService.UpdateWebhookInput{
+		WebhookInput: webhooksService.WebhookInput{
+			UserID:    userID,
+			WebhookID: hook.ID,
+		},
+		URL:    hook.URL,
+		Events: hook.Events,
+		Active: true,
+	})
+	if err != nil {
+		t.Fatalf("update webhook: %v", err)
+	}
+
+	if output.Failures != 0 || !output.Active {
+		t.Errorf("enabled webhook = %+v, want no failures", output)
+	}
+
+	publish(t, srv, accountEvent(t, userID))
+	deliver(t, srv, 1)
+	if len(r.got()) != 3 {
+		t.Errorf("enabled webhook got %d requests, want 3", len(r.got()))
+	}
+}
+
+func TestWorkspaceEventsReachMembers(t *testing.T) {
+	srv, st := newService(t, nil)
+	ctx := context.Background()
+	ownerID := newUser(t, st)
+	memberID := newUser(t, st)
+	strangerID := newUser(t, st)
+
+	workspace := &storage.Workspace{
+		ID:        uuid.New(),
+		Name:      "Team",
+		OwnerID:   ownerID,
+		CreatedAt: time.Now(),
+	}
+	err := st.Workspaces().Create(ctx, workspace)
+	if err != nil {
+		t.Fatalf("create workspace: %v", err)
+	}
+
+	for userID, role := range map[uuid.UUID]storage.WorkspaceRole{
+		ownerID:  storage.WorkspaceRoleOwner,
+		memberID: storage.WorkspaceRoleViewer,
+	} {
+		err = st.WorkspaceMembers().Create(ctx, &storage.WorkspaceMember{
+			WorkspaceID: workspace.ID,
+			UserID:      userID,
+			Role:        role,
+			CreatedAt:   time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("create member: %v", err)
+		}
+	}
+
+	receivers := make(map[uuid.UUID]*receiver)
+	for _, userID := range []uuid.UUID{ownerID, memberID, strangerID} {
+		receivers[userID] = newReceiver(t)
+		newWebhook(t, srv, userID, receivers[userID].URL)
+	}
+
+	text := "text"
+	event, err := events.NewNoteEvent(events.TypeNoteCreated, ownerID,
+		&storage.Note{
+			ID:          uuid.New(),
+			UserID:      memberID,
+			WorkspaceID: &workspace.ID,
+			Text:        &text,
+			CreatedAt:   time.Now(),
+		})
+	if err != nil {
+		t.Fatalf("new event: %v", err)
+	}
+
+	publish(t, srv, event)
+	deliver(t, srv, 1)
+
+	for userID, want := range map[uuid.UUID]int{
+		ownerID:    1,
+		memberID:   1,
+		strangerID: 0,
+	} {
+		if got := len(receivers[userID].got()); got != want {
+			t.Errorf("user %s got %d requests, want %d", userID, got, want)
+		}
+	}
+
+	personal, err := events.NewNoteEvent(events.TypeNoteCreated, ownerID,
+		&storage.Note{
+			ID:        uuid.New(),
+			UserID:    ownerID,
+			Text:      &text,
+			CreatedAt: time.Now(),
+		})
+	if err != nil {
+		t.Fatalf("new event: %v", err)
+	}
+
+	publish(t, srv, personal)
+	deliver(t, srv, 1)
+
+	if got := len(receivers[memberID].got()); got != 1 {
+		t.Errorf("member got %d requests, want 1 after a personal note", got)
+	}
+
+	if got := len(receivers[ownerID].got()); got != 2 {
+		t.Errorf("owner got %d requests, want 2", got)
+	}
+}
@@ -0,0 +1,326 @@
+package workspaces
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cloud-notes/internal/config"
+	"cloud-notes/internal/quota"
+	notesService "cloud-notes/internal/services/notes"
+	workspacesService "cloud-notes/internal/services/workspaces"
+	"cloud-notes/internal/storage"
+	"cloud-notes/internal/storage/memory"
+	"cloud-notes/test/testenv"
+
+	"github.com/google/uuid"
+)
+
+type env struct {
+	st         storage.Storage
+	workspaces workspacesService.Service
+	notes      notesService.Service
+}
+
+func newEnv() *env {
+	log := testenv.Logger()
+	st := memory.New()
+	qt := quota.New(log, st, &config.Quota{DefaultPlan: "free"})
+	return &env{
+		st:         st,
+		workspaces: workspacesService.New(log, st),
+		notes:      notesService.New(log, st, qt),
+	}
+}
+
+func (e *env) newUser(t *testing.T, login string) uuid.UUID {
+	t.Helper()
+
+	return testenv.NewUser(t, e.st, login, "hash",
+		storage.UserStatusActive).ID
+}
+
+// team is a workspace of the owner with an editor and a viewer, and a user
+// who isn't a member.
+type team struct {
+	workspaceID uuid.UUID
+	owner       uuid.UUID
+	editor      uuid.UUID
+	viewer      uuid.UUID
+	stranger    uuid.UUID
+}
+
+func (e *env) newTeam(t *testing.T) *team {
+	t.Helper()
+
+	ctx := context.Background()
+	tm := &team{
+		owner:    e.newUser(t, "owner@example.com"),
+		editor:   e.newUser(t, "editor@example.com"),
+		viewer:   e.newUser(t, "viewer@example.com"),
+		stranger: e.newUser(t, "stranger@example.com"),
+	}
+
+	workspace, err := e.workspaces.CreateWorkspace(ctx,
+		&workspacesService.CreateWorkspaceInput{
+			UserID: tm.owner,
+			Name:   "Team",
+		})
+	if err != nil {
+		t.Fatalf("create workspace: %v", err)
+	}
+	tm.workspaceID = workspace.ID
+
+	for login, role := range map[string]string{
+		"editor@example.com": "editor",
+		"viewer@example.com": "viewer",
+	} {
+		_, err = e.workspaces.AddMember(ctx, &workspacesService.AddMemberInput{
+			WorkspaceInput: tm.as(tm.owner),
+			Login:          login,
+			Role:           role,
+		})
+		if err != nil {
+			t.Fatalf("add %s: %v", login, err)
+		}
+	}
+
+	return tm
+}
+
+func (tm *team) as(userID uuid.UUID) workspacesService.WorkspaceInput {
+	return workspacesService.WorkspaceInput{
+		UserID:      userID,
+		WorkspaceID: tm.workspaceID,
+	}
+}
+
+func (tm *team) space(userID uuid.UUID) notesService.Space {
+	return notesService.Space{UserID: userID, WorkspaceID: &tm.workspaceID}
+}
+
+func TestCreateWorkspaceMakesOwner(t *testing.T) {
+	e := newEnv()
+	ctx := context.Background()
+	ownerID := e.newUser(t, "owner@example.com")
+
+	workspace, err := e.workspaces.CreateWorkspace(ctx,
+		&workspacesService.CreateWorkspaceInput{
+			UserID: ownerID,
+			Name:   "Team",
+		})
+	if err != nil {
+		t.Fatalf("create workspace: %v", err)
+	}
+
+	if workspace.Role != "owner" || workspace.OwnerID != ownerID {
+		t.Errorf("workspace = %+v, want owned by the user", workspace)
+	}
+
+	list, err := e.workspaces.GetWorkspaces(ctx, ownerID)
+	if err != nil {
+		t.Fatalf("get workspaces: %v", err)
+	}
+
+	if len(list.Workspaces) != 1 || list.Workspaces[0].ID != workspace.ID {
+		t.Errorf("workspaces = %+v, want the new one", list.Workspaces)
+	}
+}
+
+func TestGetWorkspaceListsMembers(t *testing.T) {
+	e := newEnv()
+	tm := e.newTeam(t)
+	ctx := context.Background()
+
+	input := tm.as(tm.viewer)
+	workspace, err := e.workspaces.GetWorkspace(ctx, &input)
+	if err != nil {
+		t.Fatalf("get workspace: %v", err)
+	}
+
+	if workspace.Role != "viewer" {
+		t.Errorf("role = %s, want viewer", workspace.Role)
+	}
+
+	roles := make(map[uuid.UUID]string)
+	for _, member := range workspace.Members {
+		roles[member.UserID] = member.Role
+	}
+
+	want := map[uuid.UUID]string{
+		tm.owner:  "owner",
+		tm.editor: "editor",
+		tm.viewer: "viewer",
+	}
+	if len(roles) != len(want) {
+		t.Fatalf("members = %v, want %v", roles, want)
+	}
+
+	for userID, role := range want {
+		if roles[userID] != role {
+			t.Errorf("role of %s = %q, want %q", userID, roles[userID], role)
+		}
+	}
+
+	input = tm.as(tm.stranger)
+	_, err = e.workspaces.GetWorkspace(ctx, &input)
+	if !errors.Is(err, workspacesService.ErrWorkspaceNotFound) {
+		t.Errorf("stranger got %v, want ErrWorkspaceNotFound", err)
+	}
+}
+
+func TestMembershipPermissions(t *testing.T) {
+	e := newEnv()
+	tm := e.newTeam(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		do   func() error
+		want error
+	}{
+		{"editor renames", func() error {
+			return e.workspaces.RenameWorkspace(ctx,
+				&workspacesService.RenameWorkspaceInput{
+					WorkspaceInput: tm.as(tm.editor),
+					Name:           "Mine",
+				})
+		}, workspacesService.ErrNotOwner},
+		{"editor adds a member", func() error {
+			_, err := e.workspaces.AddMember(ctx,
+				&workspacesService.AddMemberInput{
+					WorkspaceInput: tm.as(tm.editor),
+					Login:          "stranger@example.com",
+					Role:           "editor",
+				})
+			return err
+		}, workspacesService.ErrNotOwner},
+		{"owner adds an owner", func() error {
+			_, err := e.workspaces.AddMember(ctx,
+				&workspacesService.AddMemberInput{
+					WorkspaceInput: tm.as(tm.owner),
+					Login:          "stranger@example.com",
+					Role:           "owner",
+				})
+			return err
+		}, workspacesService.ErrInvalidRole},
+		{"owner adds a member again", func() error {
+			_, err := e.workspaces.AddMember(ctx,
+				&workspacesService.AddMemberInput{
+					WorkspaceInput: tm.as(tm.owner),
+					Login:          "viewer@example.com",
+					Role:           "editor",
+				})
+			return err
+		}, workspacesService.ErrAlreadyMember},
+		{"owner adds a missing user", func() error {
+			_, err := e.workspaces.AddMember(ctx,
+				&workspacesService.AddMemberInput{
+					WorkspaceInput: tm.as(tm.owner),
+					Login:          "nobody@example.com",
+					Role:           "editor",
+				})
+			return err
+		}, workspacesService.ErrUserNotFound},
+		{"owner changes own role", func() error {
+			return e.workspaces.UpdateMember(ctx,
+				&workspacesService.UpdateMemberInput{
+					WorkspaceInput: tm.as(tm.owner),
+					MemberID:       tm.owner,
+					Role:           "viewer",
+				})
+		}, workspacesService.ErrOwnerRole},
+		{"owner leaves", func() error {
+			return e.workspaces.RemoveMember(ctx,
+				&workspacesService.RemoveMemberInput{
+					WorkspaceInput: tm.as(tm.owner),
+					MemberID:       tm.owner,
+				})
+		}, workspacesService.ErrOwnerRole},
+		{"viewer removes the editor", func() error {
+			return e.workspaces.RemoveMember(ctx,
+				&workspacesService.RemoveMemberInput{
+					WorkspaceInput: tm.as(tm.viewer),
+					MemberID:       tm.editor,
+				})
+		}, workspacesService.ErrNotOwner},
+		{"stranger deletes", func() error {
+			input := tm.as(tm.stranger)
+			return e.workspaces.DeleteWorkspace(ctx, &input)
+		}, workspacesService.ErrWorkspaceNotFound},
+		{"viewer writes a note", func() error {
+			_, err := e.notes.CreateNote(ctx, &notesService.CreateNoteInput{
+				Space: tm.space(tm.viewer),
+			})
+			return err
+		}, notesService.ErrReadOnly},
+		{"stranger reads notes", func() error {
+			_, err := e.notes.GetNotes(ctx, &notesService.GetNotesInput{
+				Space: tm.space(tm.stranger),
+			})
+			return err
+		}, notesService.ErrWorkspaceNotFound},
+		{"viewer reads notes", func() error {
+			_, err := e.notes.GetNotes(ctx, &notesService.GetNotesInput{
+				Space: tm.space(tm.viewer),
+			})
+			return err
+		}, nil},
+		{"editor writes a note", func() error {
+			_, err := e.notes.CreateNote(ctx, &notesService.CreateNoteInput{
+				Space: tm.space(tm.editor),
+			})
+			return err
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.do()
+			if !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemberChanges(t *testing.T) {
+	e := newEnv()
+	tm := e.newTeam(t)
+	ctx := context.Background()
+
+	err := e.workspaces.UpdateMember(ctx, &workspacesService.UpdateMemberInput{
+		WorkspaceInput: tm.as(tm.owner),
+		MemberID:       tm.viewer,
+		Role:           "editor",
+	})
+	if err != nil {
+		t.Fatalf("update member: %v", err)
+	}
+
+	_, err = e.notes.CreateNote(ctx, &notesService.CreateNoteInput{
+		Space: tm.space(tm.viewer),
+	})
+	if err != nil {
+		t.Errorf("promoted viewer can't write: %v", err)
+	}
+
+	err = e.workspaces.RemoveMember(ctx, &workspacesService.RemoveMemberInput{
+		WorkspaceInput: tm.as(tm.editor),
+		MemberID:       tm.editor,
+	})
+	if err != nil {
+		t.Fatalf("leave workspace: %v", err)
+	}
+
+	_, err = e.notes.GetNotes(ctx, &notesService.GetNotesInput{
+		Space: tm.space(tm.editor),
+	})
+	if !errors.Is(err, notesService.ErrWorkspaceNotFound) {
+		t.Errorf("former member got %v, want ErrWorkspaceNotFound", err)
+	}
+
+	list, err := e.workspaces.GetWorkspaces(ctx, tm.editor)
+	if err != nil || len(list.Workspaces) != 0 {
+		t.Errorf("former member workspaces = %+v, %v", list, err)
+	}
+}